@@ -118,10 +118,14 @@ var (
 		utils.TestnetFlag,
 		utils.RinkebyFlag,
 		utils.VMEnableDebugFlag,
+		utils.VMOpcodeProfileFlag,
+		utils.VMDposValidateFlag,
 		utils.NetworkIdFlag,
 		utils.ConstantinopleOverrideFlag,
 		utils.RPCCORSDomainFlag,
 		utils.RPCVirtualHostsFlag,
+		utils.RPCGlobalGasCapFlag,
+		utils.RPCGlobalEVMTimeoutFlag,
 		utils.EthStatsURLFlag,
 		utils.MetricsEnabledFlag,
 		utils.FakePoWFlag,
@@ -132,6 +136,9 @@ var (
 		utils.EVMInterpreterFlag,
 		configFileFlag,
 		utils.StorageRoleFlag,
+		utils.StorageCompressionFlag,
+		utils.StorageCompressSectorDataFlag,
+		utils.StorageDrainTimeoutFlag,
 	}
 
 	rpcFlags = []cli.Flag{
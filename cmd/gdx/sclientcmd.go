@@ -5,14 +5,19 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/DxChainNetwork/godx/cmd/utils"
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/unit"
 	"github.com/DxChainNetwork/godx/node"
 	"github.com/DxChainNetwork/godx/rpc"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient"
+	"github.com/DxChainNetwork/godx/storage/storageclient/contractmanager"
 	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
 	"github.com/olekukonko/tablewriter"
 
 	"gopkg.in/urfave/cli.v1"
@@ -54,6 +59,77 @@ var (
 		Usage: "Money can be spent for the file storage within in one period",
 	}
 
+	hostFundRatioFlag = cli.StringFlag{
+		Name:  "hostfundratio",
+		Usage: "Max fraction of the fund can be committed to a single host operator, 0 means no limit",
+	}
+
+	maxMemoryFlag = cli.StringFlag{
+		Name:  "maxmemory",
+		Usage: "Max memory used by the file upload and download, e.g. 768mb",
+	}
+
+	uploadBatchSizeFlag = cli.StringFlag{
+		Name:  "uploadbatchsize",
+		Usage: "Max size of the sectors uploaded to a host in one request, within [4mib, 8mib], e.g. 8mib",
+	}
+
+	segmentCacheSizeFlag = cli.StringFlag{
+		Name:  "segmentcachesize",
+		Usage: "Max memory used to cache the segments downloaded, 0 disables the cache, e.g. 256mib",
+	}
+
+	segmentCacheDiskSizeFlag = cli.StringFlag{
+		Name:  "segmentcachedisksize",
+		Usage: "Max disk space used to persist the segments downloaded, 0 disables the disk cache, e.g. 4gib",
+	}
+
+	preferRegionsFlag = cli.StringFlag{
+		Name:  "preferregions",
+		Usage: "Comma separated host regions preferred for the data placement, empty means any region",
+	}
+
+	requireRegionsFlag = cli.StringFlag{
+		Name:  "requireregions",
+		Usage: "Comma separated host regions required for the data placement, empty means any region",
+	}
+
+	evictionRatioFlag = cli.StringFlag{
+		Name:  "evictionratio",
+		Usage: "Ratio of the evaluation baseline below which the host is evicted and its data repaired onto other hosts, 0 means disabled",
+	}
+
+	evictionHoursFlag = cli.StringFlag{
+		Name:  "evictionhours",
+		Usage: "Hours of failed scans after which the host is evicted and its data repaired onto other hosts, 0 means disabled",
+	}
+
+	hostSelectionFlag = cli.StringFlag{
+		Name:  "hostselection",
+		Usage: "Strategy selecting the hosts to form contracts with: weighted, topk or diversity",
+	}
+
+	mirrorContractsFlag = cli.StringFlag{
+		Name:  "mirror",
+		Usage: "Mirror the sectors of each contract onto a secondary host, which takes over once the primary host misses the storage proof",
+	}
+
+	verifyUploadsFlag = cli.StringFlag{
+		Name:  "verifyuploads",
+		Usage: "Download a random range of each sector uploaded with the merkle proof before the sector is marked as uploaded",
+	}
+
+	evaluationWeightsFlag = cli.StringFlag{
+		Name:  "weights",
+		Usage: "Comma separated weights of the evaluation factors, e.g. uptime=2,interaction=0. The factors not weighted have the weight 1",
+	}
+
+	simulationCutoffFlag = cli.DurationFlag{
+		Name:  "cutoff",
+		Usage: "How long ago the storage hosts are selected in the evaluation simulation",
+		Value: 24 * time.Hour,
+	}
+
 	fileSourceFlag = cli.StringFlag{
 		Name:  "src",
 		Usage: "Absolute path of the file that is going to be uploaded/downloaded from (source)",
@@ -78,6 +154,21 @@ var (
 		Name:  "newpath",
 		Usage: "New absolute file path",
 	}
+
+	downloadIDFlag = cli.StringFlag{
+		Name:  "id",
+		Usage: "ID of the pending download",
+	}
+
+	downloadPriorityFlag = cli.Uint64Flag{
+		Name:  "priority",
+		Usage: "Priority of the download, download with higher priority will be processed first",
+	}
+
+	pauseDurationFlag = cli.StringFlag{
+		Name:  "duration",
+		Usage: "Duration the uploads and repairs are paused for, e.g. 2h30m",
+	}
 )
 
 var storageClientCommand = cli.Command{
@@ -141,6 +232,41 @@ will display display detailed host's ranking status including detailed evaluatio
 each of the storage host`,
 		},
 
+		{
+			Name:      "previewhosts",
+			Usage:     "Preview the storage hosts selected under each host selection strategy",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(getHostSelectionPreview),
+			Flags: []cli.Flag{
+				contractHostFlag,
+				preferRegionsFlag,
+				requireRegionsFlag,
+			},
+			Description: `
+			gdx sclient previewhosts [--host arg] [--preferregions arg] [--requireregions arg]
+
+will display the storage hosts that would be selected to form contracts with under each of the host
+selection strategies, with the current client settings updated by the flags. No contract is formed`,
+		},
+
+		{
+			Name:      "simulatehosts",
+			Usage:     "Simulate the storage host selection with the evaluation factors weighted",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(simulateHostEvaluation),
+			Flags: []cli.Flag{
+				evaluationWeightsFlag,
+				simulationCutoffFlag,
+				contractHostFlag,
+			},
+			Description: `
+			gdx sclient simulatehosts [--weights arg] [--cutoff arg] [--host arg]
+
+will replay the storage host scans and interactions recorded before the cutoff, and display the storage
+hosts that would have been selected under the default and the weighted evaluation, along with their uptime
+and failed interactions since the cutoff`,
+		},
+
 		{
 			Name:      "contracts",
 			Usage:     "Retrieve all active storage contracts signed by the client",
@@ -191,6 +317,17 @@ included contractID, revisionNumber, hostID, and etc.'`,
 		
 will display the the account address used for the storage service. Unless user set it specifically,
 the payment address for the storage service will always be the first account address`,
+		},
+		{
+			Name:      "reservations",
+			Usage:     "Retrieve the balance reserved for the storage contracts pending",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(getFundReservations),
+			Description: `
+			gdx sclient reservations
+
+will display the balance of the payment address reserved for the storage contracts being formed
+or renewed, and the balance still available for new contracts`,
 		},
 		{
 			Name:      "setPaymentAddr",
@@ -217,19 +354,55 @@ flag must be used along with this flag to specify the account address`,
 				contractPeriodFlag,
 				contractHostFlag,
 				contractFundFlag,
+				hostFundRatioFlag,
+				preferRegionsFlag,
+				requireRegionsFlag,
+				maxMemoryFlag,
+				evictionRatioFlag,
+				evictionHoursFlag,
+				uploadBatchSizeFlag,
+				segmentCacheSizeFlag,
+				segmentCacheDiskSizeFlag,
+				mirrorContractsFlag,
+				hostSelectionFlag,
+				verifyUploadsFlag,
 			},
 			Description: `
-			gdx sclient setConfig [--period arg] [--host arg] [--fund arg]
+			gdx sclient setConfig [--period arg] [--host arg] [--fund arg] [--hostfundratio arg] [--preferregions arg] [--requireregions arg] [--maxmemory arg] [--evictionratio arg] [--evictionhours arg] [--uploadbatchsize arg] [--segmentcachesize arg] [--segmentcachedisksize arg] [--mirror arg] [--hostselection arg] [--verifyuploads arg]
 		
 will configure the client settings used for contract creation, file upload, download, and etc. There are
 multiple flags can be used along with this command to specify the setting:
 1. period: specifies the file storage time
 2. host: specifies the number of storage hosts that the client want to sign contracts with
 3. fund: specifies the amount of money the client wants to be used for the storage service
+4. hostfundratio: specifies the max fraction of the fund, within [0, 1], that can be committed to a single host
+   operator. Hosts sharing the public key or the IP network are considered as the same operator
+5. preferregions: specifies the comma separated host regions preferred, hosts out of the regions are evaluated lower
+6. requireregions: specifies the comma separated host regions required, hosts out of the regions are not used
+7. maxmemory: specifies the max memory used by the file upload and download, the uploads and downloads wait
+   for the memory once the limit is reached
+8. evictionratio: specifies the ratio, within [0, 1], of the evaluation baseline below which the host is evicted.
+   The data stored on the evicted host is repaired onto other hosts proactively
+9. evictionhours: specifies the hours the host fails the scans continuously before it is evicted
+10. uploadbatchsize: specifies the max size of the sectors uploaded to a host in one request and revision,
+    within [4mib, 8mib]. Larger batches save the round trips and the contract revisions
+11. segmentcachesize: specifies the max memory used to cache the segments downloaded, so that the segments
+    streamed repeatedly are not downloaded and paid for again. 0 disables the cache
+12. segmentcachedisksize: specifies the max disk space used to persist the cached segments under the data
+    directory, which survive the restarts. 0 disables the disk cache
+13. mirror: specifies whether the sectors of each contract are mirrored onto a secondary host, which takes over
+    the contract once the primary host misses the storage proof. The mirrors cost the extra contract fund
+14. hostselection: specifies the strategy selecting the hosts to form contracts with. weighted selects the hosts
+    randomly weighted by the evaluation, topk selects the hosts with the highest evaluations, and diversity
+    spreads the hosts over as many regions as possible
+15. verifyuploads: specifies whether a random range of each sector uploaded is downloaded with the merkle proof
+    before the sector is marked as uploaded, which catches the hosts discarding the data. The verification
+    costs the download bandwidth
 
 units:
 currency: [camel, gcamel, dx]
 time: [h, b, d, w, m, y] -> hour, block, day, week, month, year
+memory: [kb, mb, gb, tb, kib, mib, gib, tib]
 
 Note: without using any of those flags, default settings will be used`,
 		},
@@ -251,6 +424,23 @@ with two flags to specify the source of the file that is going to be uploaded, a
 that the file is going to be uploaded to. Note: the src must be absolute path: /home/ubuntu/upload.file`,
 		},
 
+		{
+			Name:      "uploadDir",
+			Usage:     "Upload the directory from the local machine recursively",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(dirUpload),
+			Flags: []cli.Flag{
+				fileSourceFlag,
+				fileDestinationFlag,
+			},
+			Description: `
+			gdx sclient uploadDir [--src arg] [--dst arg]
+
+will upload all files within the directory specified by the client to the storage hosts. A manifest
+containing the relative path and hash of each file is saved along with the uploaded directory, which
+could be used for integrity verification. Note: the src must be absolute path: /home/ubuntu/uploaddir`,
+		},
+
 		{
 			Name:      "download",
 			Usage:     "Download file to the local machine",
@@ -268,6 +458,125 @@ with two flags to specify the source of the file that is going to be downloaded,
 that the file is going to be downloaded from. Note, the download destination must be absolute path.`,
 		},
 
+		{
+			Name:      "workers",
+			Usage:     "Retrieve the workers of the storage client",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(getWorkers),
+			Description: `
+			gdx sclient workers
+
+will display the workers working on the contracts, including the consecutive negotiation failures
+of the host, and whether the worker is on cooldown. The worker on cooldown takes no task until the
+cooldown passed and the host is probed successfully`,
+		},
+
+		{
+			Name:      "downloads",
+			Usage:     "Retrieve the pending downloads",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(getDownloads),
+			Description: `
+			gdx sclient downloads
+
+will display the downloads that are not completed yet, including the download id, priority,
+and the number of segments remaining. The download id can be used to cancel the download or
+change its priority`,
+		},
+
+		{
+			Name:      "cancelDownload",
+			Usage:     "Cancel a pending download",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(cancelDownload),
+			Flags: []cli.Flag{
+				downloadIDFlag,
+			},
+			Description: `
+			gdx sclient cancelDownload [--id arg]
+
+will cancel the pending download. The id flag must be used along with this command to specify
+which download will be cancelled`,
+		},
+
+		{
+			Name:      "setDownloadPriority",
+			Usage:     "Change the priority of a pending download",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(setDownloadPriority),
+			Flags: []cli.Flag{
+				downloadIDFlag,
+				downloadPriorityFlag,
+			},
+			Description: `
+			gdx sclient setDownloadPriority [--id arg] [--priority arg]
+
+will change the priority of the pending download, the download with higher priority will be
+processed first. Both id and priority flags must be used along with this command`,
+		},
+
+		{
+			Name:      "pauseUploads",
+			Usage:     "Pause the uploads and repairs for a duration",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(pauseUploads),
+			Flags: []cli.Flag{
+				pauseDurationFlag,
+			},
+			Description: `
+			gdx sclient pauseUploads [--duration arg]
+
+will pause the uploads and repairs for the duration. The sectors being uploaded are finished,
+and no more sectors are uploaded until the uploads are resumed or the duration elapsed`,
+		},
+
+		{
+			Name:      "resumeUploads",
+			Usage:     "Resume the uploads and repairs paused",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(resumeUploads),
+			Description: `
+			gdx sclient resumeUploads
+
+will resume the uploads and repairs paused before the duration elapsed`,
+		},
+
+		{
+			Name:      "setPassphrase",
+			Usage:     "Set the passphrase sealing the storage client secrets persisted",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(setPersistPassphrase),
+			Description: `
+			gdx sclient setPassphrase
+
+will prompt for the old passphrase if it is set, and the new passphrase. Once the passphrase
+is set, the contract secrets, the storage host information and the cipher keys of the files
+created are encrypted with the passphrase when persisted`,
+		},
+
+		{
+			Name:      "unlock",
+			Usage:     "Unlock the storage client secrets persisted with the passphrase",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(unlockPersist),
+			Description: `
+			gdx sclient unlock
+
+will prompt for the passphrase to unlock the storage client secrets persisted. The files
+encrypted could not be uploaded or downloaded until unlocked`,
+		},
+
+		{
+			Name:      "lock",
+			Usage:     "Lock the storage client secrets persisted",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(lockPersist),
+			Description: `
+			gdx sclient lock
+
+will drop the key unlocked from the memory`,
+		},
+
 		{
 			Name:      "file",
 			Usage:     "Retrieve detailed information of an uploaded/uploading file",
@@ -351,10 +660,26 @@ func getConfig(ctx *cli.Context) error {
 	ExpecedDownload:                %s
 	Max Upload Speed:               %s
 	Max Download Speed:             %s
+	Max Memory:                     %s
 	IP Violation Check Status:      %s
+	Max Fund Per Host:              %s
+	Preferred Regions:              %s
+	Required Regions:               %s
+	Eviction Evaluation Ratio:      %s
+	Eviction Offline Hours:         %s
+	Upload Batch Size:              %s
+	Segment Cache Size:             %s
+	Segment Cache Disk Size:        %s
+	Mirror Contracts:               %s
+	Host Selection:                 %s
+	Upload Verification:            %s
 `, config.RentPayment.Fund, config.RentPayment.Period, config.RentPayment.StorageHosts,
 		config.RentPayment.ExpectedRedundancy, config.RentPayment.ExpectedStorage, config.RentPayment.ExpectedUpload,
-		config.RentPayment.ExpectedDownload, config.MaxUploadSpeed, config.MaxDownloadSpeed, config.EnableIPViolation)
+		config.RentPayment.ExpectedDownload, config.MaxUploadSpeed, config.MaxDownloadSpeed, config.MaxMemory, config.EnableIPViolation,
+		config.RentPayment.MaxHostFundRatio, config.RentPayment.PreferRegions, config.RentPayment.RequireRegions,
+		config.RentPayment.EvictionEvalRatio, config.RentPayment.EvictionOfflineHours, config.UploadBatchSize,
+		config.SegmentCacheSize, config.SegmentCacheDiskSize, config.RentPayment.MirrorContracts,
+		config.RentPayment.HostSelection, config.VerifyUploads)
 
 	return nil
 }
@@ -453,6 +778,116 @@ func getRanking(ctx *cli.Context) error {
 	return nil
 }
 
+func getHostSelectionPreview(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var settings = make(map[string]string)
+	if ctx.IsSet(contractHostFlag.Name) {
+		settings["hosts"] = ctx.String(contractHostFlag.Name)
+	}
+	if ctx.IsSet(preferRegionsFlag.Name) {
+		settings["preferregions"] = ctx.String(preferRegionsFlag.Name)
+	}
+	if ctx.IsSet(requireRegionsFlag.Name) {
+		settings["requireregions"] = ctx.String(requireRegionsFlag.Name)
+	}
+
+	var preview map[string][]storagehostmanager.SelectedHost
+	if err = client.Call(&preview, "sclient_previewHostSelection", settings); err != nil {
+		utils.Fatalf("failed to preview the host selection: %s", err.Error())
+	}
+
+	for _, strategy := range storagehosttree.StrategyNames() {
+		fmt.Printf("Strategy %s:\n", strategy)
+		hosts := preview[strategy]
+		if len(hosts) == 0 {
+			fmt.Println("No storage host can be selected")
+			fmt.Println()
+			continue
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "IP", "Region", "Evaluation"})
+		for _, host := range hosts {
+			table.Append([]string{host.EnodeID.String(), host.IP, host.Region, int64ToString(host.Evaluation)})
+		}
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.Render()
+		fmt.Println()
+	}
+	return nil
+}
+
+func simulateHostEvaluation(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	args := storagehostmanager.SimulationArgs{
+		Cutoff: time.Now().Add(-ctx.Duration(simulationCutoffFlag.Name)),
+	}
+	if args.Weights, err = parseEvaluationWeights(ctx.String(evaluationWeightsFlag.Name)); err != nil {
+		utils.Fatalf("invalid evaluation weights: %s", err.Error())
+	}
+	if ctx.IsSet(contractHostFlag.Name) {
+		if args.Hosts, err = strconv.Atoi(ctx.String(contractHostFlag.Name)); err != nil {
+			utils.Fatalf("invalid number of hosts: %s", err.Error())
+		}
+	}
+
+	var result storagehostmanager.SimulationResult
+	if err = client.Call(&result, "sclient_simulateEvaluation", args); err != nil {
+		utils.Fatalf("failed to simulate the host evaluation: %s", err.Error())
+	}
+
+	fmt.Printf("Storage hosts active at %v: %v\n\n", result.Cutoff.Format(time.RFC3339), result.Candidates)
+	for _, outcome := range []struct {
+		name string
+		storagehostmanager.SimulationOutcome
+	}{{"Default evaluation", result.Baseline}, {"Weighted evaluation", result.Simulated}} {
+		fmt.Printf("%s: uptime %.4f, failed interactions %v/%v\n", outcome.name, outcome.Uptime, outcome.FailedInteractions, outcome.Interactions)
+		if len(outcome.Selected) == 0 {
+			fmt.Println("No storage host can be selected")
+			fmt.Println()
+			continue
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"ID", "IP", "Evaluation", "Scans", "Successful Scans", "Interactions", "Failed Interactions"})
+		for _, host := range outcome.Selected {
+			table.Append([]string{host.EnodeID.String(), host.IP, int64ToString(host.Evaluation), strconv.Itoa(host.Scans),
+				strconv.Itoa(host.SuccessfulScans), strconv.Itoa(host.Interactions), strconv.Itoa(host.FailedInteractions)})
+		}
+		table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+		table.Render()
+		fmt.Println()
+	}
+	return nil
+}
+
+// parseEvaluationWeights parses the comma separated weights of the evaluation factors in the
+// form of factor=weight
+func parseEvaluationWeights(str string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	if strings.TrimSpace(str) == "" {
+		return weights, nil
+	}
+	for _, item := range strings.Split(str, ",") {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("weight %q is not in the form of factor=weight", item)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("weight of the factor %v: %v", kv[0], err)
+		}
+		weights[strings.TrimSpace(kv[0])] = weight
+	}
+	return weights, nil
+}
+
 func getContracts(ctx *cli.Context) error {
 	client, err := gdxAttach(ctx)
 	if err != nil {
@@ -546,6 +981,9 @@ func getContract(ctx *cli.Context) error {
 	GasCost:              %s
 	ContractCost:         %s
 	TotalCost:            %s
+	RenewStorageCost:     %s
+	ClientFunding:        %s
+	HostDeposit:          %s
 	ContractStart:        %s
 	ContractEnd:          %s
 	UploadAbility:        %s
@@ -563,7 +1001,8 @@ Latest ContractRevision Information:
 	NewValidProofOutputs:        %v
 	NewMissedProofOutputs        %v
 `, contract.ID, contract.EnodeID, contract.ContractBalance, contract.UploadCost, contract.DownloadCost,
-		contract.StorageCost, contract.GasCost, contract.ContractFee, contract.TotalCost, contract.StartHeight,
+		contract.StorageCost, contract.GasCost, contract.ContractFee, contract.TotalCost, contract.RenewStorageCost,
+		contract.ClientFunding, contract.HostDeposit, contract.StartHeight,
 		contract.EndHeight, contract.UploadAbility, contract.RenewAbility, contract.Canceled,
 		contract.LatestContractRevision.ParentID, contract.LatestContractRevision.UnlockConditions,
 		contract.LatestContractRevision.NewRevisionNumber, contract.LatestContractRevision.NewFileSize,
@@ -590,6 +1029,28 @@ func getPaymentAddress(ctx *cli.Context) error {
 	return nil
 }
 
+func getFundReservations(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var status contractmanager.FundReservationStatus
+	if err = client.Call(&status, "sclient_fundReservations"); err != nil {
+		utils.Fatalf("failed to retrieve the fund reservations: %s", err.Error())
+	}
+
+	fmt.Printf(`Payment Address:    %s
+Balance:            %s
+Reserved:           %s
+Available:          %s
+`, status.Address.String(), unit.FormatCurrency(status.Balance), unit.FormatCurrency(status.Reserved), unit.FormatCurrency(status.Available))
+	for _, r := range status.Reservations {
+		fmt.Printf("  %s  %s  height %d  tx %s\n", r.ContractID.String(), unit.FormatCurrency(r.Amount), r.Height, r.TxHash.String())
+	}
+	return nil
+}
+
 func setPaymentAddress(ctx *cli.Context) error {
 	client, err := gdxAttach(ctx)
 	if err != nil {
@@ -637,6 +1098,54 @@ func setClientConfig(ctx *cli.Context) error {
 		settings["fund"] = ctx.String(contractFundFlag.Name)
 	}
 
+	if ctx.IsSet(hostFundRatioFlag.Name) {
+		settings["hostfundratio"] = ctx.String(hostFundRatioFlag.Name)
+	}
+
+	if ctx.IsSet(maxMemoryFlag.Name) {
+		settings["maxmemory"] = ctx.String(maxMemoryFlag.Name)
+	}
+
+	if ctx.IsSet(preferRegionsFlag.Name) {
+		settings["preferregions"] = ctx.String(preferRegionsFlag.Name)
+	}
+
+	if ctx.IsSet(requireRegionsFlag.Name) {
+		settings["requireregions"] = ctx.String(requireRegionsFlag.Name)
+	}
+
+	if ctx.IsSet(evictionRatioFlag.Name) {
+		settings["evictionratio"] = ctx.String(evictionRatioFlag.Name)
+	}
+
+	if ctx.IsSet(evictionHoursFlag.Name) {
+		settings["evictionhours"] = ctx.String(evictionHoursFlag.Name)
+	}
+
+	if ctx.IsSet(uploadBatchSizeFlag.Name) {
+		settings["uploadbatchsize"] = ctx.String(uploadBatchSizeFlag.Name)
+	}
+
+	if ctx.IsSet(segmentCacheSizeFlag.Name) {
+		settings["segmentcachesize"] = ctx.String(segmentCacheSizeFlag.Name)
+	}
+
+	if ctx.IsSet(segmentCacheDiskSizeFlag.Name) {
+		settings["segmentcachedisksize"] = ctx.String(segmentCacheDiskSizeFlag.Name)
+	}
+
+	if ctx.IsSet(mirrorContractsFlag.Name) {
+		settings["mirror"] = ctx.String(mirrorContractsFlag.Name)
+	}
+
+	if ctx.IsSet(hostSelectionFlag.Name) {
+		settings["hostselection"] = ctx.String(hostSelectionFlag.Name)
+	}
+
+	if ctx.IsSet(verifyUploadsFlag.Name) {
+		settings["verifyuploads"] = ctx.String(verifyUploadsFlag.Name)
+	}
+
 	var resp string
 	if err = client.Call(&resp, "sclient_setConfig", settings); err != nil {
 		utils.Fatalf("%s", err.Error())
@@ -674,6 +1183,30 @@ func fileUpload(ctx *cli.Context) error {
 	return nil
 }
 
+func dirUpload(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(fileSourceFlag.Name) {
+		utils.Fatalf("must specify the source path of the directory used for uploading")
+	}
+	if !ctx.IsSet(fileDestinationFlag.Name) {
+		utils.Fatalf("must specify the destination path used for saving the directory")
+	}
+	source := ctx.String(fileSourceFlag.Name)
+	destination := ctx.String(fileDestinationFlag.Name)
+
+	var manifest storageclient.DirManifest
+	if err = client.Call(&manifest, "sclient_uploadDir", source, destination); err != nil {
+		utils.Fatalf("failed to upload the directory: %s", err.Error())
+	}
+
+	fmt.Printf("Directory uploaded successfully, %v files uploaded\n", len(manifest.Files))
+	return nil
+}
+
 // download remote file by sync mode
 // NOTE: RPC not support async download, because it is stateless, should block until download task done.
 func fileDownload(ctx *cli.Context) error {
@@ -705,6 +1238,198 @@ func fileDownload(ctx *cli.Context) error {
 	return nil
 }
 
+func getWorkers(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var workers []storageclient.WorkerInfo
+	if err = client.Call(&workers, "sclient_workers"); err != nil {
+		utils.Fatalf("failed to retrieve the workers: %s", err.Error())
+	}
+
+	if len(workers) == 0 {
+		fmt.Println("No workers")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"HostID", "ContractID", "Failures", "Cooldown", "PendingDownloads", "PendingUploads"})
+
+	for _, w := range workers {
+		cooldown := "no"
+		if w.OnCooldown {
+			cooldown = "until " + w.CooldownUntil.Format(time.RFC3339)
+		}
+		dataEntry := []string{w.HostID, w.ContractID, strconv.Itoa(w.ConsecutiveFailures), cooldown,
+			strconv.Itoa(w.PendingDownloads), strconv.Itoa(w.PendingUploads)}
+		table.Append(dataEntry)
+	}
+
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.Render()
+	fmt.Println()
+	return nil
+}
+
+func getDownloads(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var downloads []storageclient.DownloadInfo
+	if err = client.Call(&downloads, "sclient_downloads"); err != nil {
+		utils.Fatalf("failed to retrieve the pending downloads: %s", err.Error())
+	}
+
+	if len(downloads) == 0 {
+		fmt.Println("No pending downloads")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "DxPath", "Destination", "Priority", "SegmentsRemaining"})
+
+	for _, d := range downloads {
+		dataEntry := []string{d.ID, d.DxPath, d.Destination, strconv.FormatUint(d.Priority, 10),
+			strconv.FormatUint(d.SegmentsRemaining, 10)}
+		table.Append(dataEntry)
+	}
+
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.Render()
+	fmt.Println()
+	return nil
+}
+
+func cancelDownload(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(downloadIDFlag.Name) {
+		utils.Fatalf("the --id flag must be used to specify which download want to be cancelled")
+	}
+	id := ctx.String(downloadIDFlag.Name)
+
+	var resp string
+	if err = client.Call(&resp, "sclient_cancelDownload", id); err != nil {
+		utils.Fatalf("failed to cancel the download: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func setDownloadPriority(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(downloadIDFlag.Name) || !ctx.IsSet(downloadPriorityFlag.Name) {
+		utils.Fatalf("the --id and --priority flags must be used to specify the download and its new priority")
+	}
+	id := ctx.String(downloadIDFlag.Name)
+	priority := ctx.Uint64(downloadPriorityFlag.Name)
+
+	var resp string
+	if err = client.Call(&resp, "sclient_setDownloadPriority", id, priority); err != nil {
+		utils.Fatalf("failed to set the download priority: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func pauseUploads(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(pauseDurationFlag.Name) {
+		utils.Fatalf("the --duration flag must be used to specify how long the uploads are paused for")
+	}
+	duration := ctx.String(pauseDurationFlag.Name)
+
+	var resp string
+	if err = client.Call(&resp, "sclient_pauseUploads", duration); err != nil {
+		utils.Fatalf("failed to pause the uploads: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func resumeUploads(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_resumeUploads"); err != nil {
+		utils.Fatalf("failed to resume the uploads: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func setPersistPassphrase(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	oldPassphrase := getPassPhrase("Please enter the old passphrase, leave it empty if not set before.", false, 0, nil)
+	newPassphrase := getPassPhrase("Please enter the new passphrase.", true, 0, nil)
+
+	var resp string
+	if err = client.Call(&resp, "sclient_setPersistPassphrase", oldPassphrase, newPassphrase); err != nil {
+		utils.Fatalf("failed to set the passphrase: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func unlockPersist(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	passphrase := getPassPhrase("Unlocking the storage client secrets.", false, 0, nil)
+
+	var resp string
+	if err = client.Call(&resp, "sclient_unlockPersist", passphrase); err != nil {
+		utils.Fatalf("failed to unlock: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
+func lockPersist(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var resp string
+	if err = client.Call(&resp, "sclient_lockPersist"); err != nil {
+		utils.Fatalf("failed to lock: %s", err.Error())
+	}
+
+	fmt.Println(resp)
+	return nil
+}
+
 func getFile(ctx *cli.Context) error {
 	client, err := gdxAttach(ctx)
 	if err != nil {
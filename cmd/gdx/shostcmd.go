@@ -6,7 +6,10 @@ package main
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/DxChainNetwork/godx/cmd/utils"
+	"github.com/DxChainNetwork/godx/common/unit"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storagehost"
 
@@ -73,6 +76,37 @@ var (
 		Name:  "folderPath",
 		Usage: "Path of the folder",
 	}
+
+	folderTargetPathFlag = cli.StringFlag{
+		Name:  "targetPath",
+		Usage: "Path of the folder to migrate to",
+	}
+
+	hostExportPathFlag = cli.StringFlag{
+		Name:  "exportPath",
+		Usage: "Path of the host export file",
+	}
+
+	responsibilityStatusFlag = cli.StringFlag{
+		Name:  "status",
+		Usage: "Status of the storage responsibilities: unresolved, rejected, succeeded or failed",
+	}
+
+	offsetFlag = cli.Uint64Flag{
+		Name:  "offset",
+		Usage: "Number of the entries to skip",
+	}
+
+	limitFlag = cli.Uint64Flag{
+		Name:  "limit",
+		Usage: "Max number of the entries to return, 0 means no limit",
+	}
+
+	bandwidthByFlag = cli.StringFlag{
+		Name:  "by",
+		Usage: "Group the bandwidth served by contract or peer",
+		Value: "peer",
+	}
 )
 
 var storageHostCommand = cli.Command{
@@ -165,6 +199,45 @@ will display the detailed financial metrics for hosting the storage service, inc
 potential revenue.`,
 		},
 
+		{
+			Name:      "responsibilities",
+			Usage:     "Retrieve the storage responsibilities of the host",
+			ArgsUsage: "",
+			Flags:     []cli.Flag{responsibilityStatusFlag, offsetFlag, limitFlag},
+			Action:    utils.MigrateFlags(getResponsibilities),
+			Description: `
+			gdx shost responsibilities --status unresolved --offset 0 --limit 10
+
+will display the storage responsibilities of the host filtered by the status, including
+the contract id, file size, expiration height, and the risked storage deposit.`,
+		},
+
+		{
+			Name:      "confighistory",
+			Usage:     "Retrieve the history of the host config changes",
+			ArgsUsage: "",
+			Flags:     []cli.Flag{offsetFlag, limitFlag},
+			Action:    utils.MigrateFlags(getConfigHistory),
+			Description: `
+			gdx shost confighistory --offset 0 --limit 10
+
+will display the host config changes made through the api, including the time, the api
+method, the caller, and the old and new values of the fields changed.`,
+		},
+
+		{
+			Name:      "bandwidth",
+			Usage:     "Retrieve the bandwidth served by the host per contract or per client peer",
+			ArgsUsage: "",
+			Flags:     []cli.Flag{bandwidthByFlag, limitFlag},
+			Action:    utils.MigrateFlags(getBandwidth),
+			Description: `
+			gdx shost bandwidth --by peer --limit 10
+
+will display the bytes uploaded and downloaded through the host along with the bandwidth
+revenue, grouped by the storage contract or the client peer, with the heaviest first.`,
+		},
+
 		{
 			Name:      "announce",
 			Usage:     "Announce the node as a storage host node",
@@ -230,6 +303,58 @@ will free up the disk space used for saving data uploaded by the storage client.
 specified using --folderPath.`,
 		},
 
+		{
+			Name:      "migrateFolder",
+			Usage:     "Move the data saved in the folder to another local directory or object store",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(migrateFolder),
+			Flags: []cli.Flag{
+				folderPathFlag,
+				folderTargetPathFlag,
+			},
+			Description: `
+			gdx shost migrateFolder [--folderPath arg] [--targetPath arg]
+
+will move the data saved in the folder specified using --folderPath to the target path specified using
+--targetPath. The target path could be a local directory, or an S3 compatible object store in the form of
+s3://endpoint/bucket/prefix (s3+http://endpoint/bucket/prefix for the endpoint without TLS). The object store
+credentials are read from the environment variables AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_REGION
+of the gdx node.`,
+		},
+
+		{
+			Name:      "export",
+			Usage:     "Export the storage host for migrating to new hardware",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(exportHost),
+			Flags: []cli.Flag{
+				hostExportPathFlag,
+			},
+			Description: `
+			gdx shost export [--exportPath arg]
+
+will package the storage responsibilities, the sector data references, the config and the key of the
+payment address to the file specified using --exportPath. The key is encrypted with the passphrase of the
+payment address. The host does not accept new contracts, uploads or downloads during the export. The
+storage folders and the storage manager data shall be moved to the new machine along with the file.`,
+		},
+
+		{
+			Name:      "import",
+			Usage:     "Import the storage host exported from another machine",
+			ArgsUsage: "",
+			Action:    utils.MigrateFlags(importHost),
+			Flags: []cli.Flag{
+				hostExportPathFlag,
+			},
+			Description: `
+			gdx shost import [--exportPath arg]
+
+will import the storage host from the file specified using --exportPath. The file is checked before
+imported, including the checksum, the storage responsibilities not already in the host, and the sectors
+referenced stored in the host.`,
+		},
+
 		{
 			Name:      "paymentAddr",
 			Usage:     "Retrieve the account address used for storage service revenue",
@@ -271,11 +396,33 @@ func getHostConfig(ctx *cli.Context) error {
 	SectorAccessPrice:             %v
 	StoragePrice:                  %v
 	UploadBandwidthPrice:          %v
+	MinContractDuration:           %v
+	MaxContractSize:               %v
+	MinPriceMargin:                %v
+	MaxClientContracts:            %v
+	ClientAllowlist:               %v
+	MaxDownloadSections:           %v
+	MaxDownloadSize:               %v
+	MaxDownloadProofSize:          %v
+	PublicRead:                    %v
+	ReadCacheSize:                 %v
+	ReadCacheDiskPath:             %v
+	ReadCacheDiskSize:             %v
+	ReadWorkers:                   %v
+	Readahead:                     %v
+	DiskReadConcurrency:           %v
+	FolderReadConcurrency:         %v
+	Region:                        %v
 `, config.AcceptingContracts, config.MaxDownloadBatchSize, config.MaxDuration,
 		config.MaxReviseBatchSize, config.WindowSize, config.PaymentAddress,
 		config.Deposit, config.DepositBudget, config.MaxDeposit, config.BaseRPCPrice,
 		config.ContractPrice, config.DownloadBandwidthPrice, config.SectorAccessPrice,
-		config.StoragePrice, config.UploadBandwidthPrice)
+		config.StoragePrice, config.UploadBandwidthPrice, config.MinContractDuration,
+		config.MaxContractSize, config.MinPriceMargin, config.MaxClientContracts, config.ClientAllowlist,
+		config.MaxDownloadSections, config.MaxDownloadSize, config.MaxDownloadProofSize,
+		config.PublicRead, config.ReadCacheSize, config.ReadCacheDiskPath, config.ReadCacheDiskSize,
+		config.ReadWorkers, config.Readahead, config.DiskReadConcurrency, config.FolderReadConcurrency,
+		config.Region)
 
 	return nil
 }
@@ -427,11 +574,108 @@ func getFinance(ctx *cli.Context) error {
 	PotentialDownloadBandwidthRevenue:      %v 
 	PotentialUploadBandwidthRevenue:        %v 
 	UploadBandwidthRevenue:                 %v 
+	UploadBandwidthBytes:                   %v
+	DownloadBandwidthBytes:                 %v
 `, finance.ContractCount, finance.ContractCompensation, finance.PotentialContractCompensation,
 		finance.LockedStorageDeposit, finance.LostRevenue, finance.LostStorageDeposit, finance.PotentialStorageRevenue,
 		finance.RiskedStorageDeposit, finance.StorageRevenue, finance.TransactionFeeExpenses, finance.DownloadBandwidthRevenue,
-		finance.PotentialDownloadBandwidthRevenue, finance.PotentialUploadBandwidthRevenue, finance.UploadBandwidthRevenue)
+		finance.PotentialDownloadBandwidthRevenue, finance.PotentialUploadBandwidthRevenue, finance.UploadBandwidthRevenue,
+		finance.UploadBandwidthBytes, finance.DownloadBandwidthBytes)
+
+	return nil
+}
+
+func getResponsibilities(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
 
+	var sos []storagehost.StorageResponsibilityForDisplay
+	status, offset, limit := ctx.String(responsibilityStatusFlag.Name), ctx.Uint64(offsetFlag.Name), ctx.Uint64(limitFlag.Name)
+	if err = client.Call(&sos, "shost_responsibilities", status, offset, limit); err != nil {
+		utils.Fatalf("failed to get the storage responsibilities: %s", err.Error())
+	}
+
+	if len(sos) == 0 {
+		fmt.Println("No storage responsibility found")
+		return nil
+	}
+	for _, so := range sos {
+		fmt.Printf(`Storage Responsibility %v:
+	Status:                        %v
+	FileSize:                      %v
+	NegotiationHeight:             %v
+	ExpirationHeight:              %v
+	ProofDeadline:                 %v
+	ContractCost:                  %v
+	LockedStorageDeposit:          %v
+	RiskedStorageDeposit:          %v
+`, so.ContractID.String(), so.Status, so.FileSize, so.NegotiationHeight, so.ExpirationHeight,
+			so.ProofDeadline, so.ContractCost, so.LockedStorageDeposit, so.RiskedStorageDeposit)
+	}
+	return nil
+}
+
+func getConfigHistory(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var entries []storagehost.ConfigAuditEntry
+	offset, limit := ctx.Uint64(offsetFlag.Name), ctx.Uint64(limitFlag.Name)
+	if err = client.Call(&entries, "shost_configHistory", offset, limit); err != nil {
+		utils.Fatalf("failed to get the host config history: %s", err.Error())
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No host config change found")
+		return nil
+	}
+	for _, entry := range entries {
+		fmt.Printf("%v by %v from %v:\n", entry.Source, entry.Caller, entry.Time.Format(time.RFC3339))
+		for _, change := range entry.Changes {
+			fmt.Printf("\t%-30v %s -> %s\n", change.Field+":", change.Old, change.New)
+		}
+	}
+	return nil
+}
+
+func getBandwidth(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var method string
+	switch by := ctx.String(bandwidthByFlag.Name); by {
+	case "peer":
+		method = "shost_bandwidthByPeer"
+	case "contract":
+		method = "shost_bandwidthByContract"
+	default:
+		utils.Fatalf("invalid --by value %v, expect peer or contract", by)
+	}
+
+	var usages []storagehost.BandwidthUsage
+	if err = client.Call(&usages, method, ctx.Uint64(limitFlag.Name)); err != nil {
+		utils.Fatalf("failed to get the bandwidth served: %s", err.Error())
+	}
+
+	if len(usages) == 0 {
+		fmt.Println("No bandwidth served")
+		return nil
+	}
+	for _, usage := range usages {
+		fmt.Printf(`%v:
+	UploadBytes:                   %v
+	DownloadBytes:                 %v
+	UploadRevenue:                 %v
+	DownloadRevenue:               %v
+`, usage.ID, unit.FormatStorage(usage.UploadBytes, true), unit.FormatStorage(usage.DownloadBytes, true),
+			unit.FormatCurrency(usage.UploadRevenue), unit.FormatCurrency(usage.DownloadRevenue))
+	}
 	return nil
 }
 
@@ -528,6 +772,76 @@ func deleteFolder(ctx *cli.Context) error {
 	return nil
 }
 
+func migrateFolder(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	var path, target string
+	if !ctx.IsSet(folderPathFlag.Name) {
+		utils.Fatalf("the --folderpath flag must be used to specify the folder to be migrated")
+	} else {
+		path = ctx.String(folderPathFlag.Name)
+	}
+
+	if !ctx.IsSet(folderTargetPathFlag.Name) {
+		utils.Fatalf("the --targetPath flag must be used to specify the path to migrate to")
+	} else {
+		target = ctx.String(folderTargetPathFlag.Name)
+	}
+
+	var resp string
+	if err = client.Call(&resp, "shost_migrateFolder", path, target); err != nil {
+		utils.Fatalf("failed to migrate the folder: %s", err.Error())
+	}
+
+	fmt.Printf("%s \n\n", resp)
+	return nil
+}
+
+func exportHost(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(hostExportPathFlag.Name) {
+		utils.Fatalf("the --exportPath flag must be used to specify the file to export to")
+	}
+	path := ctx.String(hostExportPathFlag.Name)
+	passphrase := getPassPhrase("Please enter the passphrase of the payment address.", false, 0, utils.MakePasswordList(ctx))
+
+	var resp string
+	if err = client.Call(&resp, "shost_export", path, passphrase); err != nil {
+		utils.Fatalf("failed to export the host: %s", err.Error())
+	}
+
+	fmt.Printf("%s \n\n", resp)
+	return nil
+}
+
+func importHost(ctx *cli.Context) error {
+	client, err := gdxAttach(ctx)
+	if err != nil {
+		utils.Fatalf("unable to connect to remote gdx, please start the gdx first: %s", err.Error())
+	}
+
+	if !ctx.IsSet(hostExportPathFlag.Name) {
+		utils.Fatalf("the --exportPath flag must be used to specify the file to import from")
+	}
+	path := ctx.String(hostExportPathFlag.Name)
+	passphrase := getPassPhrase("Please enter the passphrase the host is exported with.", false, 0, utils.MakePasswordList(ctx))
+
+	var resp string
+	if err = client.Call(&resp, "shost_import", path, passphrase); err != nil {
+		utils.Fatalf("failed to import the host: %s", err.Error())
+	}
+
+	fmt.Printf("%s \n\n", resp)
+	return nil
+}
+
 func getHostPaymentAddress(ctx *cli.Context) error {
 	client, err := gdxAttach(ctx)
 	if err != nil {
@@ -384,6 +384,14 @@ var (
 		Name:  "vmdebug",
 		Usage: "Record information useful for VM and contract debugging",
 	}
+	VMOpcodeProfileFlag = cli.BoolFlag{
+		Name:  "vmprofile",
+		Usage: "Collect the execution count and time of each VM opcode, exposed through debug_opcodeProfile",
+	}
+	VMDposValidateFlag = cli.BoolFlag{
+		Name:  "dposvalidate",
+		Usage: "Check the invariants of the dpos context after processing each block (slow, for debugging)",
+	}
 	// Logging and debug settings
 	EthStatsURLFlag = cli.StringFlag{
 		Name:  "ethstats",
@@ -427,6 +435,16 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	RPCGlobalGasCapFlag = cli.Uint64Flag{
+		Name:  "rpc.gascap",
+		Usage: "Sets a cap on gas that can be used in eth_call/estimateGas, including the storage and dpos precompiled contracts (0 = no cap)",
+		Value: eth.DefaultConfig.RPCGasCap,
+	}
+	RPCGlobalEVMTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.evmtimeout",
+		Usage: "Sets a timeout used for eth_call/estimateGas (0 = no timeout)",
+		Value: eth.DefaultConfig.RPCEVMTimeout,
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -599,6 +617,19 @@ var (
 		Name:  "role",
 		Usage: "Chooses which role a node can be. There are four options: all, host, client, and none",
 	}
+	StorageCompressionFlag = cli.BoolFlag{
+		Name:  "storage.compression",
+		Usage: "Requests the snappy compression of the large storage negotiation payloads from the peers",
+	}
+	StorageCompressSectorDataFlag = cli.BoolFlag{
+		Name:  "storage.compression.sectordata",
+		Usage: "Compresses the storage negotiation payloads carrying the sector data as well",
+	}
+	StorageDrainTimeoutFlag = cli.DurationFlag{
+		Name:  "storage.draintimeout",
+		Usage: "Time waited for the storage negotiations in progress to finish on shutdown",
+		Value: eth.DefaultConfig.StorageDrainTimeout,
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -1187,6 +1218,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
 	}
+	if ctx.GlobalIsSet(VMOpcodeProfileFlag.Name) {
+		cfg.EnableOpcodeProfiling = ctx.GlobalBool(VMOpcodeProfileFlag.Name)
+	}
+	if ctx.GlobalIsSet(VMDposValidateFlag.Name) {
+		cfg.ValidateDposContext = ctx.GlobalBool(VMDposValidateFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCGlobalGasCapFlag.Name) {
+		cfg.RPCGasCap = ctx.GlobalUint64(RPCGlobalGasCapFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCGlobalEVMTimeoutFlag.Name) {
+		cfg.RPCEVMTimeout = ctx.GlobalDuration(RPCGlobalEVMTimeoutFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(EWASMInterpreterFlag.Name) {
 		cfg.EWASMInterpreter = ctx.GlobalString(EWASMInterpreterFlag.Name)
@@ -1214,6 +1257,16 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		}
 	}
 
+	if ctx.GlobalIsSet(StorageCompressionFlag.Name) {
+		cfg.StorageCompression.Enabled = ctx.GlobalBool(StorageCompressionFlag.Name)
+	}
+	if ctx.GlobalIsSet(StorageCompressSectorDataFlag.Name) {
+		cfg.StorageCompression.SectorData = ctx.GlobalBool(StorageCompressSectorDataFlag.Name)
+	}
+	if ctx.GlobalIsSet(StorageDrainTimeoutFlag.Name) {
+		cfg.StorageDrainTimeout = ctx.GlobalDuration(StorageDrainTimeoutFlag.Name)
+	}
+
 	// If datadir is set, change ethash directory
 	if ctx.GlobalIsSet(DataDirFlag.Name) {
 		cfg.Ethash.DatasetDir = filepath.Join(ctx.GlobalString(DataDirFlag.Name), "Ethash")
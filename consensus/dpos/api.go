@@ -7,12 +7,14 @@ package dpos
 import (
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/consensus"
 	"github.com/DxChainNetwork/godx/core/state"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/trie"
 	"github.com/syndtr/goleveldb/leveldb"
 )
@@ -24,6 +26,107 @@ type API struct {
 	dpos  *Dpos
 }
 
+// ScheduledSlot is a block production slot and the validator expected to produce the block
+type ScheduledSlot struct {
+	EpochID   int64          `json:"epoch"`
+	Slot      int64          `json:"slot"`
+	Timestamp int64          `json:"timestamp"`
+	Validator common.Address `json:"validator"`
+}
+
+// GetProductionSchedule returns the expected validator for each of the next blockCount slots
+// after the current block, based on the validators of the current epoch and the block interval.
+// The schedule stops at the end of the current epoch, since the validators of the next epoch
+// are only elected when the epoch starts
+func (api *API) GetProductionSchedule(blockCount uint64) ([]ScheduledSlot, error) {
+	header := api.chain.CurrentHeader()
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	validators, err := GetValidators(api.dpos.db, header)
+	if err != nil {
+		return nil, err
+	}
+	return productionSchedule(api.dpos.config, validators, header.Time.Int64(), blockCount), nil
+}
+
+// productionSchedule returns the scheduled slots following the block at headTime within the
+// epoch of the head block
+func productionSchedule(config *params.DposConfig, validators []common.Address, headTime int64, blockCount uint64) []ScheduledSlot {
+	schedule := make([]ScheduledSlot, 0)
+	if len(validators) == 0 {
+		return schedule
+	}
+	blockInterval, epochInterval := config.BlockPeriod(), config.EpochPeriod()
+	epochID := CalculateEpochID(headTime, epochInterval)
+	blockTime := NextSlot(headTime+1, blockInterval)
+	for i := uint64(0); i < blockCount && CalculateEpochID(blockTime, epochInterval) == epochID; i++ {
+		slot, err := calcBlockSlot(config, blockTime)
+		if err != nil {
+			break
+		}
+		schedule = append(schedule, ScheduledSlot{
+			EpochID:   epochID,
+			Slot:      slot,
+			Timestamp: blockTime,
+			Validator: validators[slot%int64(len(validators))],
+		})
+		blockTime += blockInterval
+	}
+	return schedule
+}
+
+// ElectionSchedule is the schedule of the election of the next epoch. The validators of the
+// next epoch are elected with the votes as of the snapshot block, which is the first block of
+// the next epoch, after the txs of the block are executed. Thus a vote must be mined no later
+// than the snapshot block to count for the next epoch
+type ElectionSchedule struct {
+	EpochID      int64 `json:"epoch"`
+	NextEpochID  int64 `json:"nextEpoch"`
+	SnapshotTime int64 `json:"snapshotTime"`
+
+	// SnapshotBlock is the estimated height of the snapshot block, assuming no slot is
+	// missed from the current block on
+	SnapshotBlock uint64 `json:"snapshotBlock"`
+
+	// ETA is the number of seconds until the snapshot block is produced
+	ETA int64 `json:"eta"`
+}
+
+// GetElectionSchedule returns the block height and the time the election snapshot of the next
+// epoch will be taken, so that the delegators know the deadline by which a vote must be mined
+func (api *API) GetElectionSchedule() (ElectionSchedule, error) {
+	header := api.chain.CurrentHeader()
+	if header == nil {
+		return ElectionSchedule{}, errUnknownBlock
+	}
+	return NextElection(api.dpos.config, header, time.Now().Unix()), nil
+}
+
+// NextElection returns the schedule of the election following the head block at the time now,
+// with the intervals of the dpos config. If the first slot of the next epoch has passed without
+// a block, the election is taken at the next block produced
+func NextElection(config *params.DposConfig, head *types.Header, now int64) ElectionSchedule {
+	blockInterval, epochInterval := config.BlockPeriod(), config.EpochPeriod()
+	headTime := head.Time.Int64()
+	snapshotTime := NextSlot((CalculateEpochID(headTime, epochInterval)+1)*epochInterval, blockInterval)
+	blocks := (snapshotTime - headTime) / blockInterval
+	if now > snapshotTime {
+		snapshotTime, blocks = NextSlot(now, blockInterval), 1
+	}
+	eta := snapshotTime - now
+	if eta < 0 {
+		eta = 0
+	}
+	return ElectionSchedule{
+		EpochID:       CalculateEpochID(headTime, epochInterval),
+		NextEpochID:   CalculateEpochID(snapshotTime, epochInterval),
+		SnapshotTime:  snapshotTime,
+		SnapshotBlock: head.Number.Uint64() + uint64(blocks),
+		ETA:           eta,
+	}
+}
+
 // GetConfirmedBlockNumber retrieves the latest irreversible block
 func (api *API) GetConfirmedBlockNumber() (*big.Int, error) {
 	var err error
@@ -45,6 +148,38 @@ func (api *API) GetConfirmedBlockNumber() (*big.Int, error) {
 	return header.Number, nil
 }
 
+// GetForkStatus returns the fork choice status, including the latest competing chain detected
+// and the number of reorgs refused
+func (api *API) GetForkStatus() ForkStatus {
+	return api.dpos.ForkStatus(api.chain)
+}
+
+// GetCheckpoint returns the checkpoint of the epoch on the canonical chain, signed by the
+// validators of the epoch. The checkpoint is final if signed by the threshold of the validators
+func (api *API) GetCheckpoint(epoch uint64) (*SignedCheckpoint, error) {
+	return api.dpos.SignedCheckpoint(api.chain, epoch)
+}
+
+// GetLatestCheckpoint returns the checkpoint of the latest epoch signed by the threshold of the
+// validators, looking back at most maxCheckpointLookback epochs
+func (api *API) GetLatestCheckpoint() (*SignedCheckpoint, error) {
+	header := api.chain.CurrentHeader()
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	epoch := uint64(CalculateEpochID(header.Time.Int64(), api.dpos.config.EpochPeriod()))
+	for i := uint64(0); i <= maxCheckpointLookback && i <= epoch; i++ {
+		checkpoint, err := api.dpos.SignedCheckpoint(api.chain, epoch-i)
+		if err != nil {
+			continue
+		}
+		if checkpoint.Final {
+			return checkpoint, nil
+		}
+	}
+	return nil, errCheckpointNotFinal
+}
+
 // GetValidators will return the validator list based on the block header provided
 func GetValidators(diskdb ethdb.Database, header *types.Header) ([]common.Address, error) {
 	// re-construct trieDB and get the epochTrie
@@ -92,12 +227,13 @@ func GetCandidates(diskdb ethdb.Database, header *types.Header) ([]common.Addres
 	return dposContext.GetCandidates(), nil
 }
 
-// GetValidatorInfo will return the detailed validator information
-func GetValidatorInfo(stateDb *state.StateDB, validatorAddress common.Address, diskdb ethdb.Database, header *types.Header) (common.BigInt, uint64, int64, int64, error) {
+// GetValidatorInfo will return the detailed validator information, with the epoch calculated
+// by the epoch interval
+func GetValidatorInfo(stateDb *state.StateDB, validatorAddress common.Address, diskdb ethdb.Database, header *types.Header, epochInterval int64) (common.BigInt, uint64, int64, int64, error) {
 	votes := GetTotalVote(stateDb, validatorAddress)
 	rewardRatio := GetRewardRatioNumeratorLastEpoch(stateDb, validatorAddress)
-	minedCount, err := getMinedBlocksCount(diskdb, header, validatorAddress)
-	epochID := CalculateEpochID(header.Time.Int64())
+	minedCount, err := getMinedBlocksCount(diskdb, header, validatorAddress, epochInterval)
+	epochID := CalculateEpochID(header.Time.Int64(), epochInterval)
 	if err != nil {
 		return common.BigInt0, 0, 0, 0, err
 	}
@@ -123,7 +259,7 @@ func GetCandidateInfo(stateDb *state.StateDB, candidateAddress common.Address, h
 }
 
 // getMinedBlocksCount will return the number of blocks mined by the validator within the current epoch
-func getMinedBlocksCount(diskdb ethdb.Database, header *types.Header, validatorAddress common.Address) (int64, error) {
+func getMinedBlocksCount(diskdb ethdb.Database, header *types.Header, validatorAddress common.Address, epochInterval int64) (int64, error) {
 	// re-construct the minedCntTrie
 	trieDb := trie.NewDatabase(diskdb)
 	minedCntTrie, err := types.NewMinedCntTrie(header.DposContext.MinedCntRoot, trieDb)
@@ -132,7 +268,7 @@ func getMinedBlocksCount(diskdb ethdb.Database, header *types.Header, validatorA
 	}
 
 	// based on the header, calculate the epochID
-	epochID := CalculateEpochID(header.Time.Int64())
+	epochID := CalculateEpochID(header.Time.Int64(), epochInterval)
 
 	// construct dposContext and get mined count
 	dposContext := types.DposContext{}
@@ -8,14 +8,16 @@ import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/trie"
 )
 
-// ProcessAddCandidate adds a candidates to the DposContext and updated the related fields in stateDB
+// ProcessAddCandidate adds a candidates to the DposContext and updated the related fields in stateDB.
+// minDeposit is the minimum candidate deposit defined in chain config at the block
 func ProcessAddCandidate(state stateDB, ctx *types.DposContext, addr common.Address, deposit common.BigInt,
-	rewardRatio uint64) error {
+	rewardRatio uint64, minDeposit common.BigInt) error {
 
-	if err := checkValidCandidate(state, addr, deposit, rewardRatio); err != nil {
+	if err := checkValidCandidate(state, addr, deposit, rewardRatio, minDeposit); err != nil {
 		return err
 	}
 	// Add the candidates to DposContext
@@ -34,25 +36,54 @@ func ProcessAddCandidate(state stateDB, ctx *types.DposContext, addr common.Addr
 	return nil
 }
 
-// ProcessCancelCandidate cancel the addr being an candidates
-func ProcessCancelCandidate(state stateDB, ctx *types.DposContext, addr common.Address, time int64) error {
+// ProcessCancelCandidate cancel the addr being an candidates in the epoch. If validatorLock is
+// set, the validators of the current epoch could not cancel until the epoch ends
+func ProcessCancelCandidate(state stateDB, ctx *types.DposContext, addr common.Address, epoch int64, validatorLock bool) error {
+	if validatorLock {
+		if err := checkValidatorNotLocked(ctx, addr); err != nil {
+			return err
+		}
+	}
 	// Kick out the candidates in DposContext
 	if err := ctx.KickoutCandidate(addr); err != nil {
 		return err
 	}
 	// Mark the thawing address in the future
 	prevDeposit := GetCandidateDeposit(state, addr)
-	currentEpochID := CalculateEpochID(time)
-	markThawingAddressAndValue(state, addr, currentEpochID, prevDeposit)
+	markThawingAddressAndValue(state, addr, epoch, prevDeposit)
 	// set the candidates deposit to 0
 	SetCandidateDeposit(state, addr, common.BigInt0)
 	SetRewardRatioNumerator(state, addr, 0)
 	return nil
 }
 
+// CancelCandidateTxValidation will validate the cancel candidate transaction before sending it.
+// The validators of the current epoch are locked if validatorLock is set
+func CancelCandidateTxValidation(ctx *types.DposContext, candidateAddress common.Address, validatorLock bool) error {
+	if !validatorLock {
+		return nil
+	}
+	return checkValidatorNotLocked(ctx, candidateAddress)
+}
+
+// checkValidatorNotLocked checks that the candidate is not serving as a validator in the
+// current epoch, whose deposit is locked until the epoch ends
+func checkValidatorNotLocked(ctx *types.DposContext, addr common.Address) error {
+	validators, err := ctx.GetValidators()
+	if err != nil {
+		return err
+	}
+	for _, validator := range validators {
+		if validator == addr {
+			return errCandidateValidatorLocked
+		}
+	}
+	return nil
+}
+
 // CandidateTxDataValidation will validate the candidate apply transaction before sending it
-func CandidateTxDataValidation(state stateDB, data types.AddCandidateTxData, candidateAddress common.Address) error {
-	return checkValidCandidate(state, candidateAddress, data.Deposit, data.RewardRatio)
+func CandidateTxDataValidation(state stateDB, data types.AddCandidateTxData, candidateAddress common.Address, minDeposit common.BigInt) error {
+	return checkValidCandidate(state, candidateAddress, data.Deposit, data.RewardRatio, minDeposit)
 }
 
 // IsCandidate will check whether or not the given address is a candidate address
@@ -78,22 +109,30 @@ func isCandidate(candidateTrie *trie.Trie, addr common.Address) bool {
 // CalcCandidateTotalVotes calculate the total votes for the candidates. The result include the deposit for the
 // candidates himself and the delegated votes from delegator
 func CalcCandidateTotalVotes(candidateAddr common.Address, state stateDB, delegateTrie *trie.Trie) common.BigInt {
+	return calcCandidateTotalVotesInEpoch(candidateAddr, state, delegateTrie, 0, nil)
+}
+
+// calcCandidateTotalVotesInEpoch calculate the total votes for the candidates counted in the
+// epoch, with the vote expiration applied to the delegated votes
+func calcCandidateTotalVotesInEpoch(candidateAddr common.Address, state stateDB, delegateTrie *trie.Trie, epoch int64,
+	expiration *params.VoteExpiration) common.BigInt {
 	// Calculate the candidates deposit and delegatedVote
 	candidateDeposit := GetCandidateDeposit(state, candidateAddr)
-	delegatedVote := calcCandidateDelegatedVotes(state, candidateAddr, delegateTrie)
+	delegatedVote := calcCandidateDelegatedVotes(state, candidateAddr, delegateTrie, epoch, expiration)
 	// return the sum of candidates deposit and delegated vote
 	return candidateDeposit.Add(delegatedVote)
 }
 
 // calcCandidateDelegatedVotes calculate the total votes from delegator for the candidates in the current dposContext
-func calcCandidateDelegatedVotes(state stateDB, candidateAddr common.Address, dt *trie.Trie) common.BigInt {
+func calcCandidateDelegatedVotes(state stateDB, candidateAddr common.Address, dt *trie.Trie, epoch int64,
+	expiration *params.VoteExpiration) common.BigInt {
 	delegateIterator := trie.NewIterator(dt.PrefixIterator(candidateAddr.Bytes()))
 	// loop through each delegator, get all votes
 	delegatorVotes := common.BigInt0
 	for delegateIterator.Next() {
 		delegatorAddr := common.BytesToAddress(delegateIterator.Value)
 		// Get the weighted vote
-		vote := GetVoteDeposit(state, delegatorAddr)
+		vote := expireVote(state, delegatorAddr, epoch, expiration)
 		// add the weightedVote
 		delegatorVotes = delegatorVotes.Add(vote)
 	}
@@ -114,7 +153,7 @@ func getAllDelegatorForCandidate(ctx *types.DposContext, candidateAddr common.Ad
 
 // checkValidCandidate checks whether the candidateAddr in transaction is valid for becoming a candidates.
 // If not valid, an error is returned.
-func checkValidCandidate(state stateDB, candidateAddr common.Address, deposit common.BigInt, rewardRatio uint64) error {
+func checkValidCandidate(state stateDB, candidateAddr common.Address, deposit common.BigInt, rewardRatio uint64, minDeposit common.BigInt) error {
 	// Candidate deposit should be great than the threshold
 	if deposit.Cmp(minDeposit) < 0 {
 		return errCandidateInsufficientDeposit
@@ -14,8 +14,12 @@ import (
 	"github.com/DxChainNetwork/godx/core/state"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/params"
 )
 
+// minDeposit is the minimum candidate deposit used in tests
+var minDeposit = params.DefaultMinCandidateDeposit
+
 // candidates is the structure of necessary information about a candidates
 type candidate struct {
 	address         common.Address
@@ -37,7 +41,7 @@ func TestProcessAddCandidate(t *testing.T) {
 	}
 	c := newCandidatePrototype(candidateAddr)
 	addOrigCandidateInState(state, c)
-	err = ProcessAddCandidate(state, dposCtx, candidateAddr, c.deposit, c.rewardRatio)
+	err = ProcessAddCandidate(state, dposCtx, candidateAddr, c.deposit, c.rewardRatio, minDeposit)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -50,7 +54,7 @@ func TestProcessAddCandidate(t *testing.T) {
 	// the rewardRatio and deposit
 	c.deposit = c.deposit.AddInt64(1e18)
 	c.rewardRatio = c.rewardRatio + 1
-	err = ProcessAddCandidate(state, dposCtx, candidateAddr, c.deposit, c.rewardRatio)
+	err = ProcessAddCandidate(state, dposCtx, candidateAddr, c.deposit, c.rewardRatio, minDeposit)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -76,7 +80,7 @@ func TestProcessAddCandidateError(t *testing.T) {
 	}
 	// Decrease the deposit and add candidates.
 	c.deposit = c.prevDeposit.SubInt64(1000)
-	err = ProcessAddCandidate(state, dposCtx, candidateAddr, c.deposit, c.rewardRatio)
+	err = ProcessAddCandidate(state, dposCtx, candidateAddr, c.deposit, c.rewardRatio, minDeposit)
 	if err == nil {
 		t.Fatal("decrease the deposit should report error")
 	}
@@ -91,12 +95,12 @@ func TestProcessCancelCandidate(t *testing.T) {
 	}
 	c := candidatePrototype(addr)
 	addAccountInState(state, c.address, c.balance, c.frozenAssets)
-	if err = ProcessAddCandidate(state, dposCtx, c.address, c.deposit, c.rewardRatio); err != nil {
+	if err = ProcessAddCandidate(state, dposCtx, c.address, c.deposit, c.rewardRatio, minDeposit); err != nil {
 		t.Fatal(err)
 	}
 	// cancel the candidates and commit
 	curTime := time.Now().Unix()
-	if err = ProcessCancelCandidate(state, dposCtx, addr, curTime); err != nil {
+	if err = ProcessCancelCandidate(state, dposCtx, addr, CalculateEpochID(curTime, EpochInterval), false); err != nil {
 		t.Fatal(err)
 	}
 	if _, err := state.Commit(true); err != nil {
@@ -111,7 +115,7 @@ func TestProcessCancelCandidate(t *testing.T) {
 	m := map[common.Address]common.BigInt{
 		addr: c.deposit,
 	}
-	epoch := calcThawingEpoch(CalculateEpochID(curTime))
+	epoch := calcThawingEpoch(CalculateEpochID(curTime, EpochInterval))
 	if err = checkThawingAddressAndValue(state, epoch, m); err != nil {
 		t.Fatal(err)
 	}
@@ -121,6 +125,39 @@ func TestProcessCancelCandidate(t *testing.T) {
 	}
 }
 
+// TestProcessCancelCandidate_ValidatorLocked test the validator of the current epoch could not
+// cancel being the candidate if the validator lock is set
+func TestProcessCancelCandidate_ValidatorLocked(t *testing.T) {
+	validator, candidate := common.BytesToAddress([]byte{1}), common.BytesToAddress([]byte{2})
+	state, dposCtx, err := newStateAndDposContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, addr := range []common.Address{validator, candidate} {
+		c := candidatePrototype(addr)
+		addAccountInState(state, c.address, c.balance, c.frozenAssets)
+		if err = ProcessAddCandidate(state, dposCtx, c.address, c.deposit, c.rewardRatio, minDeposit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = dposCtx.SetValidators([]common.Address{validator}); err != nil {
+		t.Fatal(err)
+	}
+	curTime := time.Now().Unix()
+	if err = ProcessCancelCandidate(state, dposCtx, validator, CalculateEpochID(curTime, EpochInterval), true); err != errCandidateValidatorLocked {
+		t.Fatalf("expect error %v, got %v", errCandidateValidatorLocked, err)
+	}
+	if deposit := GetCandidateDeposit(state, validator); deposit.Cmp(common.BigInt0) == 0 {
+		t.Fatal("the deposit of the locked validator is withdrawn")
+	}
+	if err = ProcessCancelCandidate(state, dposCtx, candidate, CalculateEpochID(curTime, EpochInterval), true); err != nil {
+		t.Fatalf("candidate not serving as validator should cancel: %v", err)
+	}
+	if err = ProcessCancelCandidate(state, dposCtx, validator, CalculateEpochID(curTime, EpochInterval), false); err != nil {
+		t.Fatalf("validator should cancel without the lock: %v", err)
+	}
+}
+
 func TestCheckValidCandidate(t *testing.T) {
 	candidateAddr := common.BytesToAddress([]byte{1})
 	tests := []struct {
@@ -157,6 +194,11 @@ func TestCheckValidCandidate(t *testing.T) {
 			func(c *candidate) { c.rewardRatio = 101 },
 			errCandidateInvalidRewardRatio,
 		},
+		// deposit lower than the minimum deposit
+		{
+			func(c *candidate) { c.deposit = minDeposit.SubInt64(1) },
+			errCandidateInsufficientDeposit,
+		},
 	}
 	for i, test := range tests {
 		c := candidatePrototype(candidateAddr)
@@ -166,7 +208,7 @@ func TestCheckValidCandidate(t *testing.T) {
 			t.Fatal(err)
 		}
 		addOrigCandidateInState(state, c)
-		err = checkValidCandidate(state, c.address, c.deposit, c.rewardRatio)
+		err = checkValidCandidate(state, c.address, c.deposit, c.rewardRatio, minDeposit)
 		if err != test.expectErr {
 			t.Errorf("check valid candidates %d error: \nexpect [%v]\ngot [%v]", i, test.expectErr, err)
 		}
@@ -0,0 +1,316 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package dpos
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/DxChainNetwork/godx/accounts"
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/consensus"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+var checkpointSignaturePrefix = []byte("dpos-checkpoint-")
+
+// Checkpoint is the summary of the chain at the epoch boundary, which is the first block of the
+// epoch where the validators of the epoch are elected. The validators of the epoch sign the
+// checkpoint in the extra data of the blocks they produce early in the epoch
+type Checkpoint struct {
+	Epoch          uint64      `json:"epoch"`
+	ValidatorsHash common.Hash `json:"validatorsHash"`
+	Root           common.Hash `json:"root"`
+}
+
+// Hash returns the hash of the checkpoint signed by the validators
+func (c Checkpoint) Hash() common.Hash {
+	enc, _ := rlp.EncodeToBytes(c)
+	return crypto.Keccak256Hash(enc)
+}
+
+// CheckpointSignature is the signature of the checkpoint by the validator
+type CheckpointSignature struct {
+	Validator common.Address `json:"validator"`
+	Signature hexutil.Bytes  `json:"signature"`
+}
+
+// SignedCheckpoint is the checkpoint along with the signatures of the validators collected. The
+// checkpoint is final once signed by the threshold of the validators, which is a compact finality
+// proof of the state root verifiable with the validator list only
+type SignedCheckpoint struct {
+	Checkpoint
+	Hash       common.Hash           `json:"hash"`
+	Number     uint64                `json:"number"`
+	BlockHash  common.Hash           `json:"blockHash"`
+	Validators []common.Address      `json:"validators"`
+	Threshold  int                   `json:"threshold"`
+	Signatures []CheckpointSignature `json:"signatures"`
+	Final      bool                  `json:"final"`
+}
+
+// Verify verifies the checkpoint is signed by the threshold of the validators listed, and the
+// validator list matches the validators hash of the checkpoint
+func (sc *SignedCheckpoint) Verify() error {
+	if validatorsHash(sc.Validators) != sc.ValidatorsHash {
+		return errCheckpointValidators
+	}
+	if sc.Hash != sc.Checkpoint.Hash() {
+		return fmt.Errorf("%v: expect %x, got %x", errCheckpointHash, sc.Checkpoint.Hash(), sc.Hash)
+	}
+	validators := make(map[common.Address]bool)
+	for _, v := range sc.Validators {
+		validators[v] = true
+	}
+	signed := make(map[common.Address]bool)
+	for _, sig := range sc.Signatures {
+		signer, err := recoverCheckpointSigner(sc.Hash, sig.Signature)
+		if err != nil {
+			return err
+		}
+		if signer != sig.Validator || !validators[signer] {
+			return fmt.Errorf("%v: %v", errCheckpointSigner, sig.Validator.String())
+		}
+		signed[signer] = true
+	}
+	if len(signed) < checkpointThreshold(len(sc.Validators)) {
+		return fmt.Errorf("%v: %v signed, threshold %v", errCheckpointNotFinal, len(signed), checkpointThreshold(len(sc.Validators)))
+	}
+	return nil
+}
+
+// checkpointThreshold returns the number of the validator signatures needed for the checkpoint
+// to be final, which is the same as the validators needed to confirm a block
+func checkpointThreshold(validatorSize int) int {
+	return consensusSize(validatorSize)
+}
+
+// validatorsHash returns the hash of the validator list
+func validatorsHash(validators []common.Address) common.Hash {
+	enc, _ := rlp.EncodeToBytes(validators)
+	return crypto.Keccak256Hash(enc)
+}
+
+// recoverCheckpointSigner recovers the validator signed the checkpoint hash
+func recoverCheckpointSigner(hash common.Hash, sig []byte) (common.Address, error) {
+	pubkey, err := crypto.Ecrecover(hash.Bytes(), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	return signer, nil
+}
+
+// newCheckpoint returns the checkpoint of the epoch boundary block
+func (d *Dpos) newCheckpoint(boundary *types.Header) (Checkpoint, []common.Address, error) {
+	validators, err := GetValidators(d.db, boundary)
+	if err != nil {
+		return Checkpoint{}, nil, err
+	}
+	return Checkpoint{
+		Epoch:          uint64(CalculateEpochID(boundary.Time.Int64(), d.config.EpochPeriod())),
+		ValidatorsHash: validatorsHash(validators),
+		Root:           boundary.Root,
+	}, validators, nil
+}
+
+// checkpointAttestation signs the checkpoint of the current epoch by the validator producing the
+// header, returning the checkpoint hash and the signature to be put in the extra data. Nothing
+// is signed before the checkpoint fork, for the epoch boundary block itself, or after the
+// validators had checkpointSignRounds rounds to sign the checkpoint
+func (d *Dpos) checkpointAttestation(chain consensus.ChainReader, parent, header *types.Header) []byte {
+	if !d.config.IsCheckpointSigned(header.Number) {
+		return nil
+	}
+	d.mu.RLock()
+	signer, signFn := d.signer, d.signFn
+	d.mu.RUnlock()
+	if signFn == nil || d.db == nil {
+		return nil
+	}
+
+	epochInterval := d.config.EpochPeriod()
+	boundary := epochBoundary(chain, parent, CalculateEpochID(header.Time.Int64(), epochInterval), epochInterval, checkpointSignRounds*uint64(d.maxValidatorSize(header.Number)))
+	if boundary == nil {
+		return nil
+	}
+	checkpoint, _, err := d.newCheckpoint(boundary)
+	if err != nil {
+		log.Warn("Failed to create the dpos checkpoint", "number", boundary.Number, "err", err)
+		return nil
+	}
+	hash := checkpoint.Hash()
+	sig, err := signFn(accounts.Account{Address: signer}, hash.Bytes())
+	if err != nil {
+		log.Warn("Failed to sign the dpos checkpoint", "epoch", checkpoint.Epoch, "err", err)
+		return nil
+	}
+	// the signature is stored at once, since the blocks produced locally are not verified
+	if err := d.storeCheckpointSignature(hash, signer, sig); err != nil {
+		log.Warn("Failed to store the dpos checkpoint signature", "epoch", checkpoint.Epoch, "err", err)
+	}
+	return append(hash.Bytes(), sig...)
+}
+
+// epochBoundary returns the first block of the epoch on the chain of the parent, looking back
+// at most maxDepth blocks. Nil is returned if the boundary is not within maxDepth blocks, or the
+// parent is not in the epoch, where the block following the parent is the boundary
+func epochBoundary(chain consensus.ChainReader, parent *types.Header, epoch int64, epochInterval int64, maxDepth uint64) *types.Header {
+	if CalculateEpochID(parent.Time.Int64(), epochInterval) != epoch {
+		return nil
+	}
+	boundary := parent
+	for i := uint64(0); boundary.Number.Uint64() > 0; i++ {
+		prev := chain.GetHeader(boundary.ParentHash, boundary.Number.Uint64()-1)
+		if prev == nil {
+			return nil
+		}
+		if CalculateEpochID(prev.Time.Int64(), epochInterval) != epoch {
+			return boundary
+		}
+		if i >= maxDepth {
+			return nil
+		}
+		boundary = prev
+	}
+	return boundary
+}
+
+// collectCheckpointSignature stores the checkpoint signature in the extra data of the header
+// verified, if signed by the validator of the header. The checkpoint signature is not part of
+// the consensus, so the header with an invalid signature is not rejected. The extra data of the
+// headers before the checkpoint fork is never interpreted as a checkpoint signature
+func (d *Dpos) collectCheckpointSignature(header *types.Header) {
+	if !d.config.IsCheckpointSigned(header.Number) || len(header.Extra) != extraVanity+extraCheckpoint+extraSeal {
+		return
+	}
+	attestation := header.Extra[extraVanity : extraVanity+extraCheckpoint]
+	hash := common.BytesToHash(attestation[:common.HashLength])
+	sig := attestation[common.HashLength:]
+	signer, err := recoverCheckpointSigner(hash, sig)
+	if err != nil || signer != header.Validator {
+		log.Debug("Invalid dpos checkpoint signature", "number", header.Number, "validator", header.Validator, "err", err)
+		return
+	}
+	if err := d.storeCheckpointSignature(hash, signer, sig); err != nil {
+		log.Warn("Failed to store the dpos checkpoint signature", "number", header.Number, "err", err)
+	}
+}
+
+// storeCheckpointSignature adds the signature of the validator to the signatures of the checkpoint
+func (d *Dpos) storeCheckpointSignature(hash common.Hash, validator common.Address, sig []byte) error {
+	d.checkpointLock.Lock()
+	defer d.checkpointLock.Unlock()
+
+	sigs, err := d.loadCheckpointSignatures(hash)
+	if err != nil {
+		return err
+	}
+	for _, s := range sigs {
+		if s.Validator == validator {
+			return nil
+		}
+	}
+	sigs = append(sigs, CheckpointSignature{Validator: validator, Signature: common.CopyBytes(sig)})
+	sort.Slice(sigs, func(i, j int) bool {
+		return bytes.Compare(sigs[i].Validator.Bytes(), sigs[j].Validator.Bytes()) < 0
+	})
+	enc, err := rlp.EncodeToBytes(sigs)
+	if err != nil {
+		return err
+	}
+	return d.db.Put(append(checkpointSignaturePrefix, hash.Bytes()...), enc)
+}
+
+// loadCheckpointSignatures loads the signatures collected of the checkpoint
+func (d *Dpos) loadCheckpointSignatures(hash common.Hash) ([]CheckpointSignature, error) {
+	key := append(checkpointSignaturePrefix, hash.Bytes()...)
+	if has, err := d.db.Has(key); err != nil || !has {
+		return nil, err
+	}
+	enc, err := d.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var sigs []CheckpointSignature
+	if err := rlp.DecodeBytes(enc, &sigs); err != nil {
+		return nil, err
+	}
+	return sigs, nil
+}
+
+// SignedCheckpoint returns the checkpoint of the epoch on the canonical chain, along with the
+// signatures of the validators of the epoch collected
+func (d *Dpos) SignedCheckpoint(chain consensus.ChainReader, epoch uint64) (*SignedCheckpoint, error) {
+	boundary := canonicalEpochBoundary(chain, epoch, d.config.EpochPeriod())
+	if boundary == nil {
+		return nil, fmt.Errorf("%v: epoch %v", errUnknownCheckpoint, epoch)
+	}
+	checkpoint, validators, err := d.newCheckpoint(boundary)
+	if err != nil {
+		return nil, err
+	}
+	sc := &SignedCheckpoint{
+		Checkpoint: checkpoint,
+		Hash:       checkpoint.Hash(),
+		Number:     boundary.Number.Uint64(),
+		BlockHash:  boundary.Hash(),
+		Validators: validators,
+		Threshold:  checkpointThreshold(len(validators)),
+		Signatures: make([]CheckpointSignature, 0),
+	}
+
+	d.checkpointLock.Lock()
+	sigs, err := d.loadCheckpointSignatures(sc.Hash)
+	d.checkpointLock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	isValidator := make(map[common.Address]bool)
+	for _, v := range validators {
+		isValidator[v] = true
+	}
+	for _, sig := range sigs {
+		if isValidator[sig.Validator] {
+			sc.Signatures = append(sc.Signatures, sig)
+		}
+	}
+	sc.Final = len(sc.Signatures) >= sc.Threshold
+	return sc, nil
+}
+
+// canonicalEpochBoundary returns the first block of the epoch on the canonical chain, nil if
+// the epoch has not started yet
+func canonicalEpochBoundary(chain consensus.ChainReader, epoch uint64, epochInterval int64) *types.Header {
+	head := chain.CurrentHeader()
+	if head == nil || uint64(CalculateEpochID(head.Time.Int64(), epochInterval)) < epoch {
+		return nil
+	}
+	// binary search the first block whose epoch is not less than the epoch
+	lo, hi := uint64(0), head.Number.Uint64()
+	for lo < hi {
+		mid := (lo + hi) / 2
+		header := chain.GetHeaderByNumber(mid)
+		if header == nil {
+			return nil
+		}
+		if uint64(CalculateEpochID(header.Time.Int64(), epochInterval)) < epoch {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	boundary := chain.GetHeaderByNumber(lo)
+	if boundary == nil || uint64(CalculateEpochID(boundary.Time.Int64(), epochInterval)) != epoch {
+		return nil
+	}
+	return boundary
+}
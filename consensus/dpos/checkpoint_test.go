@@ -0,0 +1,141 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package dpos
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/params"
+)
+
+func TestEpochBoundary(t *testing.T) {
+	// 5 blocks per epoch, with the first block of each epoch at number 5*i
+	var headers []*types.Header
+	var parentHash common.Hash
+	for i := int64(0); i < 15; i++ {
+		header := &types.Header{
+			Number:      big.NewInt(i),
+			Time:        big.NewInt(i * EpochInterval / 5),
+			ParentHash:  parentHash,
+			Difficulty:  new(big.Int),
+			DposContext: &types.DposContextRoot{},
+		}
+		headers = append(headers, header)
+		parentHash = header.Hash()
+	}
+	cr := newFakeChainReaderForIntegration(nil, types.NewBlockWithHeader(headers[0]))
+	for _, header := range headers[1:] {
+		cr.insertBlock(types.NewBlockWithHeader(header))
+	}
+
+	tests := []struct {
+		parent   int
+		epoch    int64
+		maxDepth uint64
+		boundary int64
+	}{
+		{7, 1, 10, 5},
+		{5, 1, 10, 5},
+		{9, 1, 3, -1},
+		{9, 1, 4, 5},
+		{9, 2, 10, -1},
+		{3, 0, 10, 0},
+	}
+	for _, test := range tests {
+		boundary := epochBoundary(cr, headers[test.parent], test.epoch, EpochInterval, test.maxDepth)
+		if test.boundary < 0 {
+			if boundary != nil {
+				t.Errorf("parent %v epoch %v: expect no boundary, got %v", test.parent, test.epoch, boundary.Number)
+			}
+			continue
+		}
+		if boundary == nil || boundary.Number.Int64() != test.boundary {
+			t.Errorf("parent %v epoch %v: expect boundary %v, got %v", test.parent, test.epoch, test.boundary, boundary)
+		}
+	}
+
+	for epoch, expect := range map[uint64]int64{0: 0, 1: 5, 2: 10, 3: -1} {
+		boundary := canonicalEpochBoundary(cr, epoch, EpochInterval)
+		if expect < 0 {
+			if boundary != nil {
+				t.Errorf("epoch %v: expect no boundary, got %v", epoch, boundary.Number)
+			}
+			continue
+		}
+		if boundary == nil || boundary.Number.Int64() != expect {
+			t.Errorf("epoch %v: expect boundary %v, got %v", epoch, expect, boundary)
+		}
+	}
+}
+
+func TestCheckpointSignatures(t *testing.T) {
+	var (
+		keys       []*ecdsa.PrivateKey
+		validators []common.Address
+	)
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+		validators = append(validators, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	checkpoint := Checkpoint{Epoch: 1, ValidatorsHash: validatorsHash(validators), Root: common.Hash{1}}
+	hash := checkpoint.Hash()
+	d := &Dpos{db: ethdb.NewMemDatabase(), config: &params.DposConfig{CheckpointBlock: common.Big0}}
+	preFork := &Dpos{db: ethdb.NewMemDatabase(), config: &params.DposConfig{CheckpointBlock: big.NewInt(100)}}
+
+	// the signatures in the extra data are collected if signed by the validator of the header
+	for i, key := range keys {
+		sig, err := crypto.Sign(hash.Bytes(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		extra := append(make([]byte, extraVanity), hash.Bytes()...)
+		extra = append(extra, sig...)
+		extra = append(extra, make([]byte, extraSeal)...)
+		header := &types.Header{Number: big.NewInt(int64(i)), Validator: validators[i], Extra: extra}
+		if i == 3 {
+			header.Validator = validators[0]
+		}
+		d.collectCheckpointSignature(header)
+		// collected once only
+		d.collectCheckpointSignature(header)
+		preFork.collectCheckpointSignature(header)
+	}
+	if sigs, err := preFork.loadCheckpointSignatures(hash); err != nil || len(sigs) != 0 {
+		t.Fatalf("expect no signature collected before the checkpoint fork, got %v, %v", len(sigs), err)
+	}
+	sigs, err := d.loadCheckpointSignatures(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 3 {
+		t.Fatalf("expect 3 signatures collected, got %v", len(sigs))
+	}
+
+	sc := &SignedCheckpoint{
+		Checkpoint: checkpoint,
+		Hash:       hash,
+		Validators: validators,
+		Signatures: sigs,
+	}
+	if err := sc.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	sc.Signatures = sigs[:2]
+	if err := sc.Verify(); err == nil {
+		t.Error("checkpoint below the threshold verified")
+	}
+	sc.Signatures = sigs
+	sc.Validators = validators[:3]
+	if err := sc.Verify(); err == nil {
+		t.Error("checkpoint with the validators mismatched verified")
+	}
+}
@@ -4,7 +4,10 @@
 
 package dpos
 
-import "github.com/DxChainNetwork/godx/common"
+import (
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/params"
+)
 
 const (
 	// Fixed number of extra-data prefix bytes reserved for signer vanity
@@ -13,17 +16,23 @@ const (
 	// Fixed number of extra-data suffix bytes reserved for signer seal
 	extraSeal = 65
 
-	// Number of recent block signatures to keep in memory
-	inmemorySignatures = 4096
+	// Number of extra-data bytes between the vanity and the seal for the checkpoint signature,
+	// which is the checkpoint hash followed by the signature of the validator
+	extraCheckpoint = 32 + 65
 
-	// MaxValidatorSize indicates that the max number of validators in dpos consensus
-	MaxValidatorSize = 21
+	// checkpointSignRounds is the number of the validator rounds since the epoch boundary, in
+	// which the validators sign the checkpoint of the epoch in the blocks produced
+	checkpointSignRounds = 3
 
-	// SafeSize indicates that the least number of validators in dpos consensus
-	SafeSize = MaxValidatorSize*2/3 + 1
+	// maxCheckpointLookback is the max number of epochs looked back for the latest final checkpoint
+	maxCheckpointLookback = 8
 
-	// ConsensusSize indicates that a confirmed block needs the least number of validators to approve
-	ConsensusSize = MaxValidatorSize*2/3 + 1
+	// Number of recent block signatures to keep in memory
+	inmemorySignatures = 4096
+
+	// MaxValidatorSize indicates that the default max number of validators in dpos consensus.
+	// The value could be adjusted at fork heights in the chain config
+	MaxValidatorSize = params.DefaultMaxValidatorSize
 
 	// RewardRatioDenominator is the max value of reward ratio
 	RewardRatioDenominator uint64 = 100
@@ -35,11 +44,13 @@ const (
 	// produces block less than expected by this denominator, it is considered as ineligible.
 	eligibleValidatorDenominator = 2
 
-	// BlockInterval indicates that a block will be produced every 10 seconds
-	BlockInterval = int64(10)
+	// BlockInterval indicates that a block will be produced every 10 seconds by default. The
+	// interval configured in the chain config is returned by DposConfig.BlockPeriod
+	BlockInterval = int64(params.DefaultBlockInterval)
 
-	// EpochInterval indicates that a new epoch will be elected every a day
-	EpochInterval = int64(86400)
+	// EpochInterval indicates that a new epoch will be elected every a day by default. The
+	// interval configured in the chain config is returned by DposConfig.EpochPeriod
+	EpochInterval = int64(params.DefaultEpochInterval)
 
 	// MaxVoteCount is the maximum number of candidates that a vote transaction could
 	// include
@@ -55,7 +66,4 @@ var (
 
 	// Block reward in camel for successfully mining a block upward from Constantinople
 	constantinopleBlockReward = common.NewBigIntUint64(1e18).MultInt64(2)
-
-	// minDeposit defines the minimum deposit of candidate
-	minDeposit = common.NewBigIntUint64(1e18).MultInt64(10000)
 )
@@ -8,17 +8,26 @@ import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/trie"
 )
 
-// ProcessVote process the process request for state and dpos context
+// ProcessVote process the process request for state and dpos context. If candidateCheck is
+// set, the vote is rejected if any of the candidates does not exist, instead of voting only
+// the existing candidates. The vote epoch is refreshed only if voteExpiration is set, i.e.
+// the vote expiration is activated. epoch is the epoch of the block the vote is processed in
 func ProcessVote(state stateDB, ctx *types.DposContext, addr common.Address, deposit common.BigInt,
-	candidates []common.Address, time int64) (int, error) {
+	candidates []common.Address, epoch int64, candidateCheck bool, voteExpiration bool) (int, error) {
 
 	// Validation: voting with 0 deposit is not allowed
 	if err := checkValidVote(state, addr, deposit, candidates); err != nil {
 		return 0, err
 	}
+	if candidateCheck {
+		if err := checkCandidatesExist(ctx, candidates); err != nil {
+			return 0, err
+		}
+	}
 	// Vote the candidates
 	successVote, err := ctx.Vote(addr, candidates)
 	if err != nil {
@@ -32,7 +41,6 @@ func ProcessVote(state stateDB, ctx *types.DposContext, addr common.Address, dep
 		// If new deposit is smaller than previous deposit, the diff will be thawed after
 		// ThawingEpochDuration
 		diff := prevDeposit.Sub(deposit)
-		epoch := CalculateEpochID(time)
 		markThawingAddressAndValue(state, addr, epoch, diff)
 	} else if deposit.Cmp(prevDeposit) > 0 {
 		// If the new deposit is larger than previous deposit, the diff will be added directly
@@ -40,29 +48,68 @@ func ProcessVote(state stateDB, ctx *types.DposContext, addr common.Address, dep
 		diff := deposit.Sub(prevDeposit)
 		AddFrozenAssets(state, addr, diff)
 	}
-	// Update vote deposit
+	// Update vote deposit, and refresh the vote epoch so that the vote is not expired
 	SetVoteDeposit(state, addr, deposit)
+	if voteExpiration {
+		SetVoteEpoch(state, addr, epoch)
+	}
 
 	return successVote, nil
 }
 
-// ProcessCancelVote process the cancel vote request for state and dpos context
-func ProcessCancelVote(state stateDB, ctx *types.DposContext, addr common.Address, time int64) error {
+// ProcessCancelVote process the cancel vote request for state and dpos context in the epoch.
+// The vote epoch is cleared only if voteExpiration is set
+func ProcessCancelVote(state stateDB, ctx *types.DposContext, addr common.Address, epoch int64, voteExpiration bool) error {
 	if err := ctx.CancelVote(addr); err != nil {
 		return err
 	}
 	prevDeposit := GetVoteDeposit(state, addr)
-	currentEpoch := CalculateEpochID(time)
-	markThawingAddressAndValue(state, addr, currentEpoch, prevDeposit)
+	markThawingAddressAndValue(state, addr, epoch, prevDeposit)
 	SetVoteDeposit(state, addr, common.BigInt0)
+	if voteExpiration {
+		SetVoteEpoch(state, addr, 0)
+	}
 	return nil
 }
 
+// expireVote returns the vote of the delegator counted in the epoch. If the vote expiration
+// is applied, the vote not refreshed for expiration.Epochs epochs is not counted, or decays
+// linearly within the epochs if expiration.Decay is set. The votes cast before the expiration
+// applied have no vote epoch recorded, and are stamped with the epoch
+func expireVote(state stateDB, addr common.Address, epoch int64, expiration *params.VoteExpiration) common.BigInt {
+	vote := GetVoteDeposit(state, addr)
+	if expiration == nil || expiration.Epochs == 0 {
+		return vote
+	}
+	voteEpoch := GetVoteEpoch(state, addr)
+	if voteEpoch == 0 {
+		SetVoteEpoch(state, addr, epoch)
+		return vote
+	}
+	age := uint64(0)
+	if epoch > voteEpoch {
+		age = uint64(epoch - voteEpoch)
+	}
+	if age >= expiration.Epochs {
+		return common.BigInt0
+	}
+	if expiration.Decay {
+		return vote.MultUint64(expiration.Epochs - age).DivUint64(expiration.Epochs)
+	}
+	return vote
+}
+
 // VoteTxDepositValidation will validate the vote transaction before sending it
 func VoteTxDepositValidation(state stateDB, delegatorAddress common.Address, voteData types.VoteTxData) error {
 	return checkValidVote(state, delegatorAddress, voteData.Deposit, voteData.Candidates)
 }
 
+// VoteTxCandidatesValidation will validate all candidates of the vote transaction exist before
+// sending it. An *InvalidCandidatesError is returned with the addresses not being candidates
+func VoteTxCandidatesValidation(ctx *types.DposContext, voteData types.VoteTxData) error {
+	return checkCandidatesExist(ctx, voteData.Candidates)
+}
+
 // HasVoted will check whether the provided delegator address is voted
 func HasVoted(delegatorAddress common.Address, header *types.Header, diskDB ethdb.Database) bool {
 	// re-construct trieDB and get the voteTrie
@@ -81,6 +128,21 @@ func HasVoted(delegatorAddress common.Address, header *types.Header, diskDB ethd
 	return true
 }
 
+// checkCandidatesExist checks whether all candidates exist in the candidate trie, and returns
+// an *InvalidCandidatesError with the addresses not being candidates
+func checkCandidatesExist(ctx *types.DposContext, candidates []common.Address) error {
+	var invalid []common.Address
+	for _, candidate := range candidates {
+		if !isCandidate(ctx.CandidateTrie(), candidate) {
+			invalid = append(invalid, candidate)
+		}
+	}
+	if len(invalid) != 0 {
+		return &InvalidCandidatesError{Candidates: invalid}
+	}
+	return nil
+}
+
 // checkValidVote checks whether the input argument is valid for a vote transaction
 func checkValidVote(state stateDB, delegatorAddr common.Address, deposit common.BigInt, candidates []common.Address) error {
 	if deposit.Cmp(common.BigInt0) <= 0 {
@@ -6,12 +6,14 @@ package dpos
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/state"
 	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rlp"
 )
 
@@ -27,14 +29,14 @@ func TestProcessVoteNewDelegator(t *testing.T) {
 	deposit, curTime := dx.MultInt64(10), time.Now().Unix()
 	addAccountInState(stateDB, addr, deposit, common.BigInt0)
 	// Process vote
-	_, err = ProcessVote(stateDB, ctx, addr, deposit, candidates, curTime)
+	_, err = ProcessVote(stateDB, ctx, addr, deposit, candidates, CalculateEpochID(curTime, EpochInterval), false, false)
 	if err != nil {
 		t.Fatal(err)
 	}
 	if _, err = stateDB.Commit(true); err != nil {
 		t.Fatal(err)
 	}
-	err = checkProcessVote(stateDB, ctx, addr, deposit, deposit, candidates, calcThawingEpoch(CalculateEpochID(curTime)),
+	err = checkProcessVote(stateDB, ctx, addr, deposit, deposit, candidates, calcThawingEpoch(CalculateEpochID(curTime, EpochInterval)),
 		common.BigInt0, true)
 	if err != nil {
 		t.Fatal(err)
@@ -50,13 +52,13 @@ func TestProcessVoteIncreasingDeposit(t *testing.T) {
 	addAccountInState(stateDB, addr, dx.MultInt64(10), common.BigInt0)
 	// Vote the first time
 	prevDeposit, prevCandidates, prevTime := dx, candidates[:30], time.Now().AddDate(0, 0, -1).Unix()
-	_, err = ProcessVote(stateDB, ctx, addr, prevDeposit, prevCandidates, prevTime)
+	_, err = ProcessVote(stateDB, ctx, addr, prevDeposit, prevCandidates, CalculateEpochID(prevTime, EpochInterval), false, false)
 	if err != nil {
 		t.Fatal(err)
 	}
 	// Vote the second time
 	curDeposit, curCandidates, curTime := dx.MultInt64(10), candidates[20:], time.Now().Unix()
-	_, err = ProcessVote(stateDB, ctx, addr, curDeposit, curCandidates, curTime)
+	_, err = ProcessVote(stateDB, ctx, addr, curDeposit, curCandidates, CalculateEpochID(curTime, EpochInterval), false, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -65,7 +67,7 @@ func TestProcessVoteIncreasingDeposit(t *testing.T) {
 	}
 	// Check the result
 	err = checkProcessVote(stateDB, ctx, addr, curDeposit, curDeposit, curCandidates,
-		calcThawingEpoch(CalculateEpochID(curTime)), common.BigInt0, true)
+		calcThawingEpoch(CalculateEpochID(curTime, EpochInterval)), common.BigInt0, true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -80,13 +82,13 @@ func TestProcessVoteDecreasingDeposit(t *testing.T) {
 	addAccountInState(stateDB, addr, dx.MultInt64(10), common.BigInt0)
 	// Vote the first time
 	prevDeposit, prevCandidates, prevTime := dx.MultInt64(10), candidates[:30], time.Now().AddDate(0, 0, -1).Unix()
-	_, err = ProcessVote(stateDB, ctx, addr, prevDeposit, prevCandidates, prevTime)
+	_, err = ProcessVote(stateDB, ctx, addr, prevDeposit, prevCandidates, CalculateEpochID(prevTime, EpochInterval), false, false)
 	if err != nil {
 		t.Fatal(err)
 	}
 	// Vote the second time
 	curDeposit, curCandidates, curTime := dx.MultInt64(1), candidates[20:], time.Now().Unix()
-	_, err = ProcessVote(stateDB, ctx, addr, curDeposit, curCandidates, curTime)
+	_, err = ProcessVote(stateDB, ctx, addr, curDeposit, curCandidates, CalculateEpochID(curTime, EpochInterval), false, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -95,7 +97,7 @@ func TestProcessVoteDecreasingDeposit(t *testing.T) {
 	}
 	// Check the result
 	err = checkProcessVote(stateDB, ctx, addr, prevDeposit, curDeposit, curCandidates,
-		calcThawingEpoch(CalculateEpochID(curTime)), prevDeposit.Sub(curDeposit), true)
+		calcThawingEpoch(CalculateEpochID(curTime, EpochInterval)), prevDeposit.Sub(curDeposit), true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -109,9 +111,9 @@ func TestProcessVoteErr(t *testing.T) {
 	}
 	addAccountInState(stateDB, addr, dx.MultInt64(10), common.BigInt0)
 	curTime := time.Now().Unix()
-	thawingEpoch := calcThawingEpoch(CalculateEpochID(curTime))
+	thawingEpoch := calcThawingEpoch(CalculateEpochID(curTime, EpochInterval))
 	// Error 1: error from checkValidVote
-	_, err = ProcessVote(stateDB, ctx, addr, dx.MultInt64(11), candidates, curTime)
+	_, err = ProcessVote(stateDB, ctx, addr, dx.MultInt64(11), candidates, CalculateEpochID(curTime, EpochInterval), false, false)
 	if err == nil {
 		t.Fatal("should raise error not enough balance")
 	}
@@ -125,7 +127,7 @@ func TestProcessVoteErr(t *testing.T) {
 		t.Fatal(err)
 	}
 	// Error 2: no valid candidates
-	_, err = ProcessVote(stateDB, ctx, addr, dx.MultInt64(1), []common.Address{randomAddress()}, curTime)
+	_, err = ProcessVote(stateDB, ctx, addr, dx.MultInt64(1), []common.Address{randomAddress()}, CalculateEpochID(curTime, EpochInterval), false, false)
 	if err == nil {
 		t.Fatal("should raise no candidate voted error")
 	}
@@ -139,6 +141,48 @@ func TestProcessVoteErr(t *testing.T) {
 	}
 }
 
+func TestProcessVoteCandidateCheck(t *testing.T) {
+	addr := randomAddress()
+	stateDB, ctx, candidates, err := newStateAndDposContextWithCandidate(30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addAccountInState(stateDB, addr, dx.MultInt64(10), common.BigInt0)
+	curTime := time.Now().Unix()
+	invalid := []common.Address{randomAddress(), randomAddress()}
+	votes := append([]common.Address{candidates[0], invalid[0], candidates[1]}, invalid[1])
+
+	// the vote with the addresses not being candidates is rejected with the invalid subset
+	_, err = ProcessVote(stateDB, ctx, addr, dx.MultInt64(1), votes, CalculateEpochID(curTime, EpochInterval), true, false)
+	invalidErr, ok := err.(*InvalidCandidatesError)
+	if !ok {
+		t.Fatalf("expect InvalidCandidatesError, got %v", err)
+	}
+	if !reflect.DeepEqual(invalidErr.Candidates, invalid) {
+		t.Errorf("invalid candidates not expected. Got %v, Expect %v", invalidErr.Candidates, invalid)
+	}
+	if err := VoteTxCandidatesValidation(ctx, types.VoteTxData{Candidates: votes}); err == nil {
+		t.Error("vote tx with the addresses not being candidates should not pass the validation")
+	}
+	if !GetVoteDeposit(stateDB, addr).IsEqual(common.BigInt0) {
+		t.Error("rejected vote should not update the vote deposit")
+	}
+
+	// without the check, only the existing candidates are voted
+	voted, err := ProcessVote(stateDB, ctx, addr, dx.MultInt64(1), votes, CalculateEpochID(curTime, EpochInterval), false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if voted != 2 {
+		t.Errorf("voted count not expected. Got %v, Expect %v", voted, 2)
+	}
+
+	// the vote with only the candidates passes the check
+	if _, err := ProcessVote(stateDB, ctx, addr, dx.MultInt64(1), candidates[:2], CalculateEpochID(curTime, EpochInterval), true, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestProcessCancelVote(t *testing.T) {
 	addr := randomAddress()
 	stateDB, ctx, candidates, err := newStateAndDposContextWithCandidate(30)
@@ -147,14 +191,14 @@ func TestProcessCancelVote(t *testing.T) {
 	}
 	prevFrozen, deposit, curTime := dx.MultInt64(1), dx.MultInt64(8), time.Now().Unix()
 	addAccountInState(stateDB, addr, dx.MultInt64(10), prevFrozen)
-	thawingEpoch := calcThawingEpoch(CalculateEpochID(curTime))
+	thawingEpoch := calcThawingEpoch(CalculateEpochID(curTime, EpochInterval))
 	// Process Vote
-	_, err = ProcessVote(stateDB, ctx, addr, deposit, candidates, curTime)
+	_, err = ProcessVote(stateDB, ctx, addr, deposit, candidates, CalculateEpochID(curTime, EpochInterval), false, false)
 	if err != nil {
 		t.Fatal(err)
 	}
 	// Cancel Vote
-	if err = ProcessCancelVote(stateDB, ctx, addr, curTime); err != nil {
+	if err = ProcessCancelVote(stateDB, ctx, addr, CalculateEpochID(curTime, EpochInterval), false); err != nil {
 		t.Fatal(err)
 	}
 	if _, err = stateDB.Commit(true); err != nil {
@@ -167,6 +211,37 @@ func TestProcessCancelVote(t *testing.T) {
 	}
 }
 
+// TestProcessVoteEpoch test the vote epoch is refreshed and cleared only if the vote expiration
+// is activated
+func TestProcessVoteEpoch(t *testing.T) {
+	addr := randomAddress()
+	stateDB, ctx, candidates, err := newStateAndDposContextWithCandidate(30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposit, curTime := dx.MultInt64(1), time.Now().Unix()
+	addAccountInState(stateDB, addr, dx.MultInt64(10), common.BigInt0)
+
+	if _, err = ProcessVote(stateDB, ctx, addr, deposit, candidates, CalculateEpochID(curTime, EpochInterval), false, false); err != nil {
+		t.Fatal(err)
+	}
+	if epoch := GetVoteEpoch(stateDB, addr); epoch != 0 {
+		t.Fatalf("vote epoch recorded before the vote expiration: %v", epoch)
+	}
+	if _, err = ProcessVote(stateDB, ctx, addr, deposit, candidates, CalculateEpochID(curTime, EpochInterval), false, true); err != nil {
+		t.Fatal(err)
+	}
+	if epoch := GetVoteEpoch(stateDB, addr); epoch != CalculateEpochID(curTime, EpochInterval) {
+		t.Fatalf("vote epoch not expected. Got %v, Expect %v", epoch, CalculateEpochID(curTime, EpochInterval))
+	}
+	if err = ProcessCancelVote(stateDB, ctx, addr, CalculateEpochID(curTime, EpochInterval), true); err != nil {
+		t.Fatal(err)
+	}
+	if epoch := GetVoteEpoch(stateDB, addr); epoch != 0 {
+		t.Fatalf("vote epoch not cleared after cancel: %v", epoch)
+	}
+}
+
 func TestCheckValidVote(t *testing.T) {
 	addr := randomAddress()
 	tests := []struct {
@@ -295,7 +370,7 @@ func newStateAndDposContextWithCandidate(num int) (*state.StateDB, *types.DposCo
 	for i := 0; i != num; i++ {
 		addr := common.BigToAddress(common.NewBigIntUint64(uint64(i)).BigIntPtr())
 		addAccountInState(stateDB, addr, minDeposit, common.BigInt0)
-		err = ProcessAddCandidate(stateDB, ctx, addr, minDeposit, uint64(50))
+		err = ProcessAddCandidate(stateDB, ctx, addr, minDeposit, uint64(50), minDeposit)
 		if err != nil {
 			return nil, nil, nil, err
 		}
@@ -303,3 +378,41 @@ func newStateAndDposContextWithCandidate(num int) (*state.StateDB, *types.DposCo
 	}
 	return stateDB, ctx, addresses, nil
 }
+
+func TestExpireVote(t *testing.T) {
+	addr := randomAddress()
+	stateDB, _, err := newStateAndDposContext()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deposit := dx.MultInt64(10)
+	SetVoteDeposit(stateDB, addr, deposit)
+
+	// the vote cast before the expiration applied should be stamped with the epoch
+	expiration := &params.VoteExpiration{Epochs: 5}
+	if vote := expireVote(stateDB, addr, 100, expiration); vote.Cmp(deposit) != 0 {
+		t.Fatalf("vote not expected. Got %v, Expect %v", vote, deposit)
+	}
+	if epoch := GetVoteEpoch(stateDB, addr); epoch != 100 {
+		t.Fatalf("vote epoch not expected. Got %v, Expect %v", epoch, 100)
+	}
+
+	decay := &params.VoteExpiration{Epochs: 5, Decay: true}
+	tests := []struct {
+		epoch      int64
+		expiration *params.VoteExpiration
+		expect     common.BigInt
+	}{
+		{104, nil, deposit},
+		{104, expiration, deposit},
+		{105, expiration, common.BigInt0},
+		{100, decay, deposit},
+		{102, decay, dx.MultInt64(6)},
+		{105, decay, common.BigInt0},
+	}
+	for i, test := range tests {
+		if vote := expireVote(stateDB, addr, test.epoch, test.expiration); vote.Cmp(test.expect) != 0 {
+			t.Errorf("test %d: vote not expected. Got %v, Expect %v", i, vote, test.expect)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"runtime"
 	"sync"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/event"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rlp"
@@ -61,6 +63,14 @@ type Dpos struct {
 	signatures           *lru.ARCCache // Signatures of recent blocks to speed up mining
 	confirmedBlockHeader *types.Header
 
+	mux           *event.TypeMux // Event mux to post the reorg events
+	forkLock      sync.RWMutex
+	lastReorg     *ReorgEvent
+	refusedReorgs uint64
+
+	// checkpointLock protects the checkpoint signatures stored
+	checkpointLock sync.Mutex
+
 	mu   sync.RWMutex
 	stop chan bool
 
@@ -104,13 +114,14 @@ func sigHash(header *types.Header) (hash common.Hash) {
 	return hash
 }
 
-// New creates a dpos consensus engine
-func New(config *params.DposConfig, db ethdb.Database) *Dpos {
+// New creates a dpos consensus engine. The reorg events are posted to the event mux
+func New(config *params.DposConfig, db ethdb.Database, mux *event.TypeMux) *Dpos {
 	signatures, _ := lru.NewARC(inmemorySignatures)
 	return &Dpos{
 		config:     config,
 		db:         db,
 		signatures: signatures,
+		mux:        mux,
 	}
 }
 
@@ -191,7 +202,12 @@ func (d *Dpos) verifyHeader(chain consensus.ChainReader, header *types.Header, p
 	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
 		return consensus.ErrUnknownAncestor
 	}
-	if parent.Time.Uint64()+uint64(BlockInterval) > header.Time.Uint64() {
+	blockInterval := d.config.BlockPeriod()
+	if parent.Time.Uint64()+uint64(blockInterval) > header.Time.Uint64() {
+		return ErrInvalidTimestamp
+	}
+	// the block must be produced at a slot of the configured block interval
+	if header.Time.Int64()%blockInterval != 0 {
 		return ErrInvalidTimestamp
 	}
 	return nil
@@ -202,6 +218,12 @@ func (d *Dpos) VerifyHeaders(chain consensus.ChainReader, headers []*types.Heade
 	abort := make(chan struct{})
 	results := make(chan error, len(headers))
 
+	// recover the signers concurrently ahead of the headers verified in sequence, which
+	// picks up the signers from the signature cache
+	if d.Mode != ModeFake {
+		go d.recoverSigners(headers, abort)
+	}
+
 	go func() {
 		for i, header := range headers {
 			err := d.verifyHeader(chain, header, headers[:i])
@@ -215,6 +237,34 @@ func (d *Dpos) VerifyHeaders(chain consensus.ChainReader, headers []*types.Heade
 	return abort, results
 }
 
+// recoverSigners recovers the signers of the headers into the signature cache with a pool
+// of workers. The headers without the signature are left to the verification
+func (d *Dpos) recoverSigners(headers []*types.Header, abort <-chan struct{}) {
+	workers := runtime.GOMAXPROCS(0)
+	if len(headers) < workers {
+		workers = len(headers)
+	}
+	inputs := make(chan *types.Header)
+	defer close(inputs)
+	for i := 0; i < workers; i++ {
+		go func() {
+			for header := range inputs {
+				ecrecover(header, d.signatures)
+			}
+		}()
+	}
+	for _, header := range headers {
+		if len(header.Extra) < extraVanity+extraSeal || header.DposContext == nil {
+			continue
+		}
+		select {
+		case <-abort:
+			return
+		case inputs <- header:
+		}
+	}
+}
+
 // VerifyUncles implements consensus.Engine, returning an error if the block has uncles,
 // because dpos engine doesn't support uncles.
 func (d *Dpos) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
@@ -254,7 +304,7 @@ func (d *Dpos) verifySeal(chain consensus.ChainReader, header *types.Header, par
 	if err != nil {
 		return err
 	}
-	epochContext := &EpochContext{DposContext: dposContext}
+	epochContext := &EpochContext{DposContext: dposContext, config: d.config}
 	validator, err := epochContext.lookupValidator(header.Time.Int64())
 	if err != nil {
 		return err
@@ -262,6 +312,8 @@ func (d *Dpos) verifySeal(chain consensus.ChainReader, header *types.Header, par
 	if err := d.verifyBlockSigner(validator, header); err != nil {
 		return err
 	}
+	d.collectCheckpointSignature(header)
+	d.detectCompetingChain(chain, header)
 	return d.updateConfirmedBlockHeader(chain)
 }
 
@@ -293,6 +345,8 @@ func (d *Dpos) updateConfirmedBlockHeader(chain consensus.ChainReader) error {
 	}
 
 	curHeader := chain.CurrentHeader()
+	// the number of validators needed to confirm a block is based on the validator size at the current header
+	confirmSize := consensusSize(d.maxValidatorSize(curHeader.Number))
 
 	validatorMap := make(map[common.Address]bool)
 	for d.confirmedBlockHeader.Hash() != curHeader.Hash() &&
@@ -301,13 +355,13 @@ func (d *Dpos) updateConfirmedBlockHeader(chain consensus.ChainReader) error {
 		// fast return
 		// if block number difference less consensusSize-witnessNum,
 		// there is no need to check block is confirmed
-		if curHeader.Number.Int64()-d.confirmedBlockHeader.Number.Int64() < int64(ConsensusSize-len(validatorMap)) {
+		if curHeader.Number.Int64()-d.confirmedBlockHeader.Number.Int64() < int64(confirmSize-len(validatorMap)) {
 			log.Debug("Dpos fast return", "current", curHeader.Number.String(), "confirmed", d.confirmedBlockHeader.Number.String(), "witnessCount", len(validatorMap))
 			return nil
 		}
 
 		validatorMap[curHeader.Validator] = true
-		if len(validatorMap) >= ConsensusSize {
+		if len(validatorMap) >= confirmSize {
 			d.confirmedBlockHeader = curHeader
 			if err := d.storeConfirmedBlockHeader(d.db); err != nil {
 				return err
@@ -324,6 +378,11 @@ func (d *Dpos) updateConfirmedBlockHeader(chain consensus.ChainReader) error {
 	return nil
 }
 
+// maxValidatorSize returns the number of validators elected per epoch at the given block
+func (d *Dpos) maxValidatorSize(number *big.Int) int {
+	return int(d.config.MaxValidatorSize(number))
+}
+
 // load the latest confirmed block from the database
 func (d *Dpos) loadConfirmedBlockHeader(chain consensus.ChainReader) (*types.Header, error) {
 	key, err := d.db.Get(confirmedBlockHead)
@@ -346,21 +405,24 @@ func (d *Dpos) storeConfirmedBlockHeader(db ethdb.Database) error {
 func (d *Dpos) Prepare(chain consensus.ChainReader, header *types.Header) error {
 	header.Nonce = types.BlockNonce{}
 	number := header.Number.Uint64()
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
 	if len(header.Extra) < extraVanity {
 		header.Extra = append(header.Extra, bytes.Repeat([]byte{0x00}, extraVanity-len(header.Extra))...)
 	}
 	header.Extra = header.Extra[:extraVanity]
+	// the checkpoint of the epoch is signed between the vanity and the seal
+	header.Extra = append(header.Extra, d.checkpointAttestation(chain, parent, header)...)
 	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
-	parent := chain.GetHeader(header.ParentHash, number-1)
-	if parent == nil {
-		return consensus.ErrUnknownAncestor
-	}
 	header.Difficulty = d.CalcDifficulty(chain, header.Time.Uint64(), parent)
 	header.Validator = d.signer
 	return nil
 }
 
-// accumulateRewards add the block award to Coinbase of validator
+// accumulateRewards add the block award to Coinbase of validator. The rewards distributed are
+// also recorded in state per epoch for auditing
 func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, db *trie.Database, genesis *types.Header) {
 	// Select the correct block reward based on chain progression
 	blockReward := frontierBlockReward
@@ -370,10 +432,19 @@ func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header
 	if config.IsConstantinople(header.Number) {
 		blockReward = constantinopleBlockReward
 	}
+	// the rewards are recorded per epoch only from the epoch reward record fork, so that the
+	// state of the blocks before the fork is kept unchanged
+	epoch := CalculateEpochID(header.Time.Int64(), config.Dpos.EpochPeriod())
+	recordReward := func(addr common.Address, prefix []byte, reward common.BigInt) {
+		if config.Dpos.IsEpochRewardRecorded(header.Number) {
+			addEpochReward(state, addr, prefix, epoch, reward)
+		}
+	}
 	// retrieve the total vote weight of header's validator
 	voteCount := GetTotalVote(state, header.Validator)
 	if voteCount.Cmp(common.BigInt0) <= 0 {
 		state.AddBalance(header.Coinbase, blockReward.BigIntPtr())
+		recordReward(header.Validator, PrefixEpochValidatorReward, blockReward)
 		return
 	}
 	// get ratio of reward between validator and its delegator
@@ -397,11 +468,14 @@ func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header
 		// calculate reward of each delegator due to it's vote(stake) percent
 		delegatorReward := delegatorVote.Mult(sharedReward).Div(voteCount)
 		state.AddBalance(delegator, delegatorReward.BigIntPtr())
+		recordReward(delegator, PrefixEpochDelegatorReward, delegatorReward)
 		assignedReward = assignedReward.Add(delegatorReward)
 	}
 	// accumulate the rest rewards for the validator
 	validatorReward := blockReward.Sub(assignedReward)
 	state.AddBalance(header.Coinbase, validatorReward.BigIntPtr())
+	recordReward(header.Validator, PrefixEpochValidatorReward, validatorReward)
+	recordReward(header.Validator, PrefixEpochSharedReward, assignedReward)
 }
 
 // Finalize implements consensus.Engine, commit state、calculate block award and update some context
@@ -418,20 +492,22 @@ func (d *Dpos) Finalize(chain consensus.ChainReader, header *types.Header, state
 
 	parent := chain.GetHeaderByHash(header.ParentHash)
 	epochContext := &EpochContext{
-		stateDB:     state,
-		DposContext: dposContext,
-		TimeStamp:   header.Time.Int64(),
+		stateDB:        state,
+		DposContext:    dposContext,
+		TimeStamp:      header.Time.Int64(),
+		config:         d.config,
+		voteExpiration: d.config.VoteExpirationAt(header.Number),
 	}
 	// update the value of timeOfFirstBlock if the value is 0
 	updateTimeOfFirstBlockIfNecessary(chain)
 
 	//update mined count trie
-	err := updateMinedCnt(parent.Time.Int64(), header.Validator, dposContext)
+	err := updateMinedCnt(parent.Time.Int64(), d.config.EpochPeriod(), header.Validator, dposContext)
 	if err != nil {
 		return nil, err
 	}
 	// try to elect, if current block is the first one in a new epoch, then elect new epoch
-	err = epochContext.tryElect(genesis, parent)
+	err = epochContext.tryElect(genesis, parent, d.maxValidatorSize(header.Number))
 	if err != nil {
 		return nil, fmt.Errorf("got error when elect next epoch, err: %s", err)
 	}
@@ -444,8 +520,8 @@ func (d *Dpos) Finalize(chain consensus.ChainReader, header *types.Header, state
 
 // checkDeadline check the given block whether is fit to produced at now
 func (d *Dpos) checkDeadline(lastBlock *types.Block, now int64) error {
-	prevSlot := PrevSlot(now)
-	nextSlot := NextSlot(now)
+	prevSlot := PrevSlot(now, d.config.BlockPeriod())
+	nextSlot := NextSlot(now, d.config.BlockPeriod())
 	if lastBlock.Time().Int64() >= nextSlot {
 		return ErrMinedFutureBlock
 	}
@@ -469,7 +545,7 @@ func (d *Dpos) CheckValidator(lastBlock *types.Block, now int64) error {
 	if err != nil {
 		return err
 	}
-	epochContext := &EpochContext{DposContext: dposContext}
+	epochContext := &EpochContext{DposContext: dposContext, config: d.config}
 	validator, err := epochContext.lookupValidator(now)
 	if err != nil {
 		return err
@@ -578,21 +654,21 @@ func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, er
 	return signer, nil
 }
 
-// PrevSlot calculate the last block time
-func PrevSlot(now int64) int64 {
-	return int64((now-1)/BlockInterval) * BlockInterval
+// PrevSlot calculate the last block time with the block interval
+func PrevSlot(now int64, blockInterval int64) int64 {
+	return int64((now-1)/blockInterval) * blockInterval
 }
 
-// NextSlot calculate the next block time
-func NextSlot(now int64) int64 {
-	return int64((now+BlockInterval-1)/BlockInterval) * BlockInterval
+// NextSlot calculate the next block time with the block interval
+func NextSlot(now int64, blockInterval int64) int64 {
+	return int64((now+blockInterval-1)/blockInterval) * blockInterval
 }
 
 // updateMinedCnt update counts in minedCntTrie for the miner of newBlock
-func updateMinedCnt(parentBlockTime int64, validator common.Address, dposContext *types.DposContext) error {
+func updateMinedCnt(parentBlockTime int64, epochInterval int64, validator common.Address, dposContext *types.DposContext) error {
 	mct := dposContext.MinedCntTrie()
 	// The updated mined count belong to the parent epoch
-	epoch := CalculateEpochID(parentBlockTime)
+	epoch := CalculateEpochID(parentBlockTime, epochInterval)
 	cnt, err := getMinedCnt(mct, epoch, validator)
 	if err != nil {
 		return err
@@ -134,7 +134,7 @@ func TestDPOSIntegration(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	dpos := New(genesis.config.Dpos, tec.db)
+	dpos := New(genesis.config.Dpos, tec.db, nil)
 	opPerBlock := 50
 	numBlocks := 300
 	if testing.Short() {
@@ -352,7 +352,7 @@ func (tec *testEpochContext) executeAddCandidate(addr common.Address) error {
 	newRewardRatio := (RewardRatioDenominator-prevRewardRatio)/4 + prevRewardRatio
 	l.Printf("User %x add candidate (%v / %v) -> (%v / %v)\n", addr, prevDeposit, prevRewardRatio, newDeposit, newRewardRatio)
 	// Process Add candidate
-	if err := ProcessAddCandidate(tec.epc.stateDB, tec.epc.DposContext, addr, newDeposit, newRewardRatio); err != nil {
+	if err := ProcessAddCandidate(tec.epc.stateDB, tec.epc.DposContext, addr, newDeposit, newRewardRatio, minDeposit); err != nil {
 		return err
 	}
 	// Update the expected result
@@ -367,7 +367,7 @@ func (tec *testEpochContext) executeCancelCandidate(addr common.Address) error {
 		return fmt.Errorf("address %x not previously in candidateRecords", addr)
 	}
 	l.Printf("User %x cancel candidate\n", addr)
-	if err := ProcessCancelCandidate(tec.epc.stateDB, tec.epc.DposContext, addr, tec.epc.TimeStamp); err != nil {
+	if err := ProcessCancelCandidate(tec.epc.stateDB, tec.epc.DposContext, addr, CalculateEpochID(tec.epc.TimeStamp, EpochInterval), false); err != nil {
 		return err
 	}
 	// Update the expected result
@@ -387,7 +387,7 @@ func (tec *testEpochContext) executeVoteIncreaseDeposit(addr common.Address) err
 	newDeposit := prevDeposit.Add(GetAvailableBalance(tec.epc.stateDB, addr).DivUint64(100))
 	votes := randomPickCandidates(tec.ec.candidateRecords, maxVotes)
 	l.Printf("User %x increase vote deposit %v -> %v\n", addr, prevDeposit, newDeposit)
-	if _, err := ProcessVote(tec.epc.stateDB, tec.epc.DposContext, addr, newDeposit, votes, tec.epc.TimeStamp); err != nil {
+	if _, err := ProcessVote(tec.epc.stateDB, tec.epc.DposContext, addr, newDeposit, votes, CalculateEpochID(tec.epc.TimeStamp, EpochInterval), false, false); err != nil {
 		return err
 	}
 	// Update expected context
@@ -407,7 +407,7 @@ func (tec *testEpochContext) executeVoteDecreaseDeposit(addr common.Address) err
 	newDeposit := prevDeposit.MultInt64(2).DivUint64(3)
 	votes := randomPickCandidates(tec.ec.candidateRecords, maxVotes)
 	l.Printf("User %x decrease deposit %v -> %v\n", addr, prevDeposit, newDeposit)
-	if _, err := ProcessVote(tec.epc.stateDB, tec.epc.DposContext, addr, newDeposit, votes, tec.epc.TimeStamp); err != nil {
+	if _, err := ProcessVote(tec.epc.stateDB, tec.epc.DposContext, addr, newDeposit, votes, CalculateEpochID(tec.epc.TimeStamp, EpochInterval), false, false); err != nil {
 		return err
 	}
 	// Update expected context
@@ -422,7 +422,7 @@ func (tec *testEpochContext) executeCancelVote(addr common.Address) error {
 		return errors.New("vote record previously not in record map")
 	}
 	l.Printf("User %x cancel vote\n", addr)
-	if err := ProcessCancelVote(tec.epc.stateDB, tec.epc.DposContext, addr, tec.epc.TimeStamp); err != nil {
+	if err := ProcessCancelVote(tec.epc.stateDB, tec.epc.DposContext, addr, CalculateEpochID(tec.epc.TimeStamp, EpochInterval), false); err != nil {
 		return err
 	}
 	tec.ec.cancelVote(addr, tec.epc.TimeStamp)
@@ -545,7 +545,7 @@ func (tec *testEpochContext) checkDelegatorRecordsLastEpochConsistency() error {
 // in state
 func (tec *testEpochContext) checkThawingConsistency() error {
 	// only check the thawing effected epoch
-	curEpoch := CalculateEpochID(tec.epc.TimeStamp)
+	curEpoch := CalculateEpochID(tec.epc.TimeStamp, EpochInterval)
 	thawEpoch := calcThawingEpoch(curEpoch)
 	for epoch := curEpoch + 1; epoch <= thawEpoch; epoch++ {
 		l.Println("expect epoch", epoch)
@@ -1071,7 +1071,7 @@ func (ec *expectContext) deleteDelegateVotes(delegator common.Address) {
 
 // addThawing add the thawing of diff amount of address addr to the thawing record.
 func (ec *expectContext) addThawing(addr common.Address, diff common.BigInt, curTime int64) {
-	thawEpoch := calcThawingEpoch(CalculateEpochID(curTime))
+	thawEpoch := calcThawingEpoch(CalculateEpochID(curTime, EpochInterval))
 	prevThawing, exist := ec.thawing[thawEpoch][addr]
 	if !exist {
 		prevThawing = common.BigInt0
@@ -1139,7 +1139,7 @@ func (ec *expectContext) getValidators() []common.Address {
 
 // getBlockProducer return the block producer of the give time slot
 func (ec *expectContext) getBlockProducer(blockTime int64) (common.Address, error) {
-	slot, err := calcBlockSlot(blockTime)
+	slot, err := calcBlockSlot(nil, blockTime)
 	if err != nil {
 		return common.Address{}, err
 	}
@@ -1188,8 +1188,8 @@ func (ec *expectContext) countDelegateVotesForCandidate(candidate common.Address
 
 // try elect elect for the validators in the new epoch
 func (ec *expectContext) tryElect(cr consensus.ChainReader, genesis *types.Header, parent *types.Header, time int64, epc EpochContext) error {
-	prevEpoch := CalculateEpochID(parent.Time.Int64())
-	currentEpoch := CalculateEpochID(time)
+	prevEpoch := CalculateEpochID(parent.Time.Int64(), EpochInterval)
+	currentEpoch := CalculateEpochID(time, EpochInterval)
 	if prevEpoch == currentEpoch || prevEpoch == 0 {
 		if prevEpoch == 0 {
 			ec.minedCnt = make(map[common.Address]int)
@@ -1224,7 +1224,7 @@ func (ec *expectContext) kickOutValidators(cr consensus.ChainReader, time int64)
 	ineligibleValidators := ec.getIneligibleValidators(cr, time)
 	numCandidates := len(ec.candidateRecords)
 	for _, v := range ineligibleValidators {
-		if numCandidates <= SafeSize {
+		if numCandidates <= safeSize(MaxValidatorSize) {
 			break
 		}
 		addr := v.address
@@ -1243,7 +1243,7 @@ func (ec *expectContext) getIneligibleValidators(cr consensus.ChainReader, curTi
 		return addressesByCnt{}
 	}
 	timeFirstBlock := firstHeader.Time.Int64()
-	expectBlocks := expectedBlocksPerValidatorInEpoch(timeFirstBlock, curTime)
+	expectBlocks := expectedBlocksPerValidatorInEpoch(nil, timeFirstBlock, curTime, len(ec.validators))
 	// Iterate over the validators
 	var ineligibleValidators addressesByCnt
 	for _, addr := range ec.validators {
@@ -42,7 +42,7 @@ func TestUpdateMinedCnt(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = updateMinedCnt(lastTime, miner, dposContext)
+	err = updateMinedCnt(lastTime, EpochInterval, miner, dposContext)
 	assert.Nil(t, err)
 
 	afterUpdateCnt, err := getMinedCnt(dposContext.MinedCntTrie(), blockTime/EpochInterval, miner)
@@ -65,7 +65,7 @@ func TestUpdateMinedCnt(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = updateMinedCnt(lastTime, miner, dposContext)
+	err = updateMinedCnt(lastTime, EpochInterval, miner, dposContext)
 	assert.Nil(t, err)
 
 	afterUpdateCnt, err = getMinedCnt(dposContext.MinedCntTrie(), blockTime/EpochInterval, miner)
@@ -82,7 +82,7 @@ func TestUpdateMinedCnt(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = updateMinedCnt(lastTime, miner, dposContext)
+	err = updateMinedCnt(lastTime, EpochInterval, miner, dposContext)
 	assert.Nil(t, err)
 
 	afterUpdateCnt, err = getMinedCnt(dposContext.MinedCntTrie(), lastTime/EpochInterval, miner)
@@ -118,6 +118,12 @@ func TestAccumulateRewards(t *testing.T) {
 	SetTotalVote(stateDB, validator, common.PtrBigInt(big.NewInt(100000)))
 
 	stateDbCopy := stateDB.Copy()
+	stateDbUnrecorded := stateDB.Copy()
+
+	// record the rewards per epoch from the block
+	config, dposConfig := *params.MainnetChainConfig, *params.MainnetChainConfig.Dpos
+	dposConfig.EpochRewardRecordBlock = big.NewInt(1)
+	config.Dpos = &dposConfig
 
 	dposEng := &Dpos{
 		db: db,
@@ -127,7 +133,7 @@ func TestAccumulateRewards(t *testing.T) {
 		headers: make(map[uint64]*testHeader, 0),
 	}
 
-	for i := uint64(0); i < ConsensusSize; i++ {
+	for i := uint64(0); i < uint64(consensusSize(MaxValidatorSize)); i++ {
 		hash := common.BigToHash(new(big.Int).SetUint64(i))
 		if i == 0 {
 			testChain.insertGenesis(hash, uint64(10*i+1000), dposCtx)
@@ -137,13 +143,13 @@ func TestAccumulateRewards(t *testing.T) {
 	}
 
 	// Byzantium
-	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1 << 10), Coinbase: validator, Validator: validator}
+	header := &types.Header{Number: big.NewInt(1), Time: big.NewInt(EpochInterval + 1), Difficulty: big.NewInt(1 << 10), Coinbase: validator, Validator: validator}
 	expectedDelegatorReward := big.NewInt(1.5e+18)
 	expectedValidatorReward := big.NewInt(1.5e+18)
 
 	// allocate the block reward among validator and its delegators
-	accumulateRewards(params.MainnetChainConfig, stateDB, header, trie.NewDatabase(db), testChain.GetHeaderByNumber(0))
-	header.Root = stateDB.IntermediateRoot(params.MainnetChainConfig.IsEIP158(header.Number))
+	accumulateRewards(&config, stateDB, header, trie.NewDatabase(db), testChain.GetHeaderByNumber(0))
+	header.Root = stateDB.IntermediateRoot(config.IsEIP158(header.Number))
 
 	validatorBalance := stateDB.GetBalance(validator)
 	if validatorBalance.Cmp(expectedValidatorReward) != 0 {
@@ -155,14 +161,36 @@ func TestAccumulateRewards(t *testing.T) {
 		t.Errorf("delegator reward not equal to the value assigned to address, want: %v, got: %v", expectedValidatorReward.String(), validatorBalance.String())
 	}
 
+	// the distribution should be recorded in the epoch of the block
+	validatorRecord := GetEpochRewardRecord(stateDB, validator, 1)
+	if validatorRecord.ValidatorReward.BigIntPtr().Cmp(expectedValidatorReward) != 0 || validatorRecord.SharedReward.BigIntPtr().Cmp(expectedDelegatorReward) != 0 {
+		t.Errorf("validator reward record not expected: %+v", validatorRecord)
+	}
+	delegatorRecord := GetEpochRewardRecord(stateDB, delegator, 1)
+	if delegatorRecord.DelegatorReward.BigIntPtr().Cmp(expectedDelegatorReward) != 0 {
+		t.Errorf("delegator reward record not expected: %+v", delegatorRecord)
+	}
+	if record := GetEpochRewardRecord(stateDB, delegator, 0); record.DelegatorReward.Sign() != 0 {
+		t.Errorf("reward should not be recorded in other epochs: %+v", record)
+	}
+
 	// mock block sync
 	headerCopy := header
-	accumulateRewards(params.MainnetChainConfig, stateDbCopy, headerCopy, trie.NewDatabase(db), testChain.GetHeaderByNumber(0))
-	headerCopy.Root = stateDB.IntermediateRoot(params.MainnetChainConfig.IsEIP158(headerCopy.Number))
+	accumulateRewards(&config, stateDbCopy, headerCopy, trie.NewDatabase(db), testChain.GetHeaderByNumber(0))
+	headerCopy.Root = stateDB.IntermediateRoot(config.IsEIP158(headerCopy.Number))
 
 	if header.Root != headerCopy.Root {
 		t.Errorf("block sync state root not equal, one: %s, another: %s", header.Root.String(), headerCopy.Root.String())
 	}
+
+	// the rewards should not be recorded before the epoch reward record fork
+	accumulateRewards(params.MainnetChainConfig, stateDbUnrecorded, header, trie.NewDatabase(db), testChain.GetHeaderByNumber(0))
+	if record := GetEpochRewardRecord(stateDbUnrecorded, validator, 1); record.ValidatorReward.Sign() != 0 {
+		t.Errorf("reward should not be recorded before the fork: %+v", record)
+	}
+	if balance := stateDbUnrecorded.GetBalance(validator); balance.Cmp(expectedValidatorReward) != 0 {
+		t.Errorf("validator reward before the fork not expected, want: %v, got: %v", expectedValidatorReward, balance)
+	}
 }
 
 func TestDpos_CheckValidator(t *testing.T) {
@@ -184,7 +212,7 @@ func TestDpos_CheckValidator(t *testing.T) {
 					}
 
 					lastBlock := types.NewBlockWithHeader(lastBlockHeader)
-					dposEng := New(nil, db)
+					dposEng := New(nil, db, nil)
 					dposEng.signer = validator
 					return dposEng.CheckValidator(lastBlock, int64(86420))
 				},
@@ -200,7 +228,7 @@ func TestDpos_CheckValidator(t *testing.T) {
 					}
 
 					lastBlock := types.NewBlockWithHeader(lastBlockHeader)
-					dposEng := New(nil, db)
+					dposEng := New(nil, db, nil)
 					dposEng.signer = validator
 					return dposEng.CheckValidator(lastBlock, int64(86443))
 				},
@@ -216,7 +244,7 @@ func TestDpos_CheckValidator(t *testing.T) {
 					}
 
 					lastBlock := types.NewBlockWithHeader(lastBlockHeader)
-					dposEng := New(nil, db)
+					dposEng := New(nil, db, nil)
 					dposEng.signer = common.HexToAddress("0x234")
 					return dposEng.CheckValidator(lastBlock, int64(86440))
 				},
@@ -232,7 +260,7 @@ func TestDpos_CheckValidator(t *testing.T) {
 					}
 
 					lastBlock := types.NewBlockWithHeader(lastBlockHeader)
-					dposEng := New(nil, db)
+					dposEng := New(nil, db, nil)
 					dposEng.signer = validator
 					return dposEng.CheckValidator(lastBlock, int64(86410))
 				},
@@ -269,7 +297,7 @@ func TestUpdateConfirmedBlockHeader(t *testing.T) {
 			wantConfirmedBlockNum uint64
 		}{
 			{
-				name: "the number of current block chain less than ConsensusSize",
+				name: "the number of current block chain less than consensus size",
 				fn: func() (uint64, error) {
 					chainDB := ethdb.NewMemDatabase()
 					dposEng := &Dpos{
@@ -280,7 +308,7 @@ func TestUpdateConfirmedBlockHeader(t *testing.T) {
 						headers: make(map[uint64]*testHeader, 0),
 					}
 
-					for i := uint64(0); i < ConsensusSize; i++ {
+					for i := uint64(0); i < uint64(consensusSize(MaxValidatorSize)); i++ {
 						hash := common.BigToHash(new(big.Int).SetUint64(i))
 						if i == 0 {
 							testChain.insertGenesis(hash, uint64(10*i+1000), nil)
@@ -297,7 +325,7 @@ func TestUpdateConfirmedBlockHeader(t *testing.T) {
 				wantConfirmedBlockNum: 0,
 			},
 			{
-				name: "the number of current block chain more than ConsensusSize",
+				name: "the number of current block chain more than consensus size",
 				fn: func() (uint64, error) {
 					chainDB := ethdb.NewMemDatabase()
 					dposEng := &Dpos{
@@ -322,7 +350,7 @@ func TestUpdateConfirmedBlockHeader(t *testing.T) {
 
 					return dposEng.confirmedBlockHeader.Number.Uint64(), nil
 				},
-				wantConfirmedBlockNum: (MaxValidatorSize + 5) - ConsensusSize,
+				wantConfirmedBlockNum: uint64(MaxValidatorSize + 5 - consensusSize(MaxValidatorSize)),
 			},
 		}
 	)
@@ -524,7 +552,7 @@ func mockDposContext(db ethdb.Database, now int64, delegator common.Address) (*t
 
 	// update mined count trie
 	cnt := int64(0)
-	epochID := CalculateEpochID(now)
+	epochID := CalculateEpochID(now, EpochInterval)
 	epochBytes := make([]byte, 8)
 	binary.BigEndian.PutUint64(epochBytes, uint64(epochID))
 	for i := 0; i < MaxValidatorSize; i++ {
@@ -4,47 +4,64 @@
 
 package dpos
 
-import "github.com/DxChainNetwork/godx/consensus"
+import (
+	"github.com/DxChainNetwork/godx/consensus"
+	"github.com/DxChainNetwork/godx/params"
+)
 
 var timeOfFirstBlock = int64(0)
 
 // expectedBlocksPerValidatorInEpoch return the expected number of blocks to be produced
 // for each validator in an epoch. The input timeFirstBlock and curTime is passed in to
-// calculate for the expected epoch number
-func expectedBlocksPerValidatorInEpoch(timeFirstBlock, curTime int64) int64 {
-	numBlocks := expectedBlocksInEpoch(timeFirstBlock, curTime)
-	return numBlocks / MaxValidatorSize
+// calculate for the expected epoch number, and validatorSize is the number of validators
+// in the epoch
+func expectedBlocksPerValidatorInEpoch(config *params.DposConfig, timeFirstBlock, curTime int64, validatorSize int) int64 {
+	numBlocks := expectedBlocksInEpoch(config, timeFirstBlock, curTime)
+	return numBlocks / int64(validatorSize)
+}
+
+// safeSize returns the least number of candidates required to elect maxValidatorSize validators
+func safeSize(maxValidatorSize int) int {
+	return maxValidatorSize*2/3 + 1
+}
+
+// consensusSize returns the least number of validators required to approve a confirmed block
+func consensusSize(maxValidatorSize int) int {
+	return maxValidatorSize*2/3 + 1
 }
 
 // expectedBlocksInEpoch return the expected blocks to be produced in the epoch.
 // The value is only different when currently is in the first block
-func expectedBlocksInEpoch(timeFirstBlock int64, curTime int64) int64 {
-	epochDuration := EpochInterval
+func expectedBlocksInEpoch(config *params.DposConfig, timeFirstBlock int64, curTime int64) int64 {
+	blockInterval, epochInterval := config.BlockPeriod(), config.EpochPeriod()
+	epochDuration := epochInterval
 	// First epoch duration may lt epoch interval,
 	// while the first block time wouldn't always align with epoch interval,
 	// so calculate the first epoch duration with first block time instead of epoch interval,
 	// prevent the validators were kickout incorrectly.
-	if diff := curTime - timeFirstBlock; diff < EpochInterval {
+	if diff := curTime - timeFirstBlock; diff < epochInterval {
 		epochDuration = diff
 	}
-	return epochDuration / BlockInterval
+	return epochDuration / blockInterval
 }
 
-// calcBlockSlot calculate slot ID for the block time stamp.
+// calcBlockSlot calculate slot ID for the block time stamp with the intervals of the config.
 // If not a valid slot, errInvalidMinedBlockTime will be returned.
-func calcBlockSlot(blockTime int64) (int64, error) {
-	offset := blockTime % EpochInterval
-	if offset%BlockInterval != 0 {
+func calcBlockSlot(config *params.DposConfig, blockTime int64) (int64, error) {
+	blockInterval := config.BlockPeriod()
+	offset := blockTime % config.EpochPeriod()
+	if offset%blockInterval != 0 {
 		return 0, errInvalidMinedBlockTime
 	}
 
-	slot := offset / BlockInterval
+	slot := offset / blockInterval
 	return slot, nil
 }
 
-// CalculateEpochID calculate the epoch ID given the block time
-func CalculateEpochID(blockTime int64) int64 {
-	return blockTime / EpochInterval
+// CalculateEpochID calculate the epoch ID given the block time and the epoch interval, which
+// is the EpochPeriod of the dpos config of the chain
+func CalculateEpochID(blockTime int64, epochInterval int64) int64 {
+	return blockTime / epochInterval
 }
 
 // updateTimeOfFirstBlockIfNecessary update the value of timeOfFirstBlock if the value is not assigned
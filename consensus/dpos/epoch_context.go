@@ -14,6 +14,7 @@ import (
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/trie"
 )
 
@@ -22,13 +23,22 @@ type EpochContext struct {
 	TimeStamp   int64
 	DposContext *types.DposContext
 	stateDB     stateDB
+
+	// config is the dpos config of the chain, whose block interval and epoch interval are
+	// applied. nil means the default intervals
+	config *params.DposConfig
+
+	// voteExpiration is the vote expiration applied in election. nil means the votes never expire
+	voteExpiration *params.VoteExpiration
 }
 
-// tryElect will process election at the beginning of current epoch
-func (ec *EpochContext) tryElect(genesis, parent *types.Header) error {
-	genesisEpoch := CalculateEpochID(genesis.Time.Int64())
-	prevEpoch := CalculateEpochID(parent.Time.Int64())
-	currentEpoch := CalculateEpochID(ec.TimeStamp)
+// tryElect will process election at the beginning of current epoch. maxValidatorSize is the
+// number of validators to be elected in the new epoch
+func (ec *EpochContext) tryElect(genesis, parent *types.Header, maxValidatorSize int) error {
+	epochInterval := ec.config.EpochPeriod()
+	genesisEpoch := CalculateEpochID(genesis.Time.Int64(), epochInterval)
+	prevEpoch := CalculateEpochID(parent.Time.Int64(), epochInterval)
+	currentEpoch := CalculateEpochID(ec.TimeStamp, epochInterval)
 	// if current block does not reach new epoch, directly return
 	if prevEpoch == currentEpoch {
 		return nil
@@ -50,7 +60,7 @@ func (ec *EpochContext) tryElect(genesis, parent *types.Header) error {
 	for i := prevEpoch; i < currentEpoch; i++ {
 		// if prevEpoch is not genesis, kick out not active candidates
 		if iter.Next() {
-			if err := ec.kickoutValidators(prevEpoch); err != nil {
+			if err := ec.kickoutValidators(prevEpoch, maxValidatorSize); err != nil {
 				return err
 			}
 		}
@@ -60,12 +70,12 @@ func (ec *EpochContext) tryElect(genesis, parent *types.Header) error {
 			return err
 		}
 		// check if number of candidates is smaller than safe size
-		if len(candidateVotes) < SafeSize {
+		if len(candidateVotes) < safeSize(maxValidatorSize) {
 			return errors.New("too few candidates")
 		}
 		// Create the seed and pseudo-randomly select the validators
 		seed := makeSeed(parent.Hash(), i)
-		validators, err := selectValidator(candidateVotes, seed)
+		validators, err := selectValidator(candidateVotes, seed, maxValidatorSize)
 		if err != nil {
 			return err
 		}
@@ -85,8 +95,8 @@ func (ec *EpochContext) tryElect(genesis, parent *types.Header) error {
 		// Set vote last epoch for all delegators who select the validators.
 		allDelegators := allDelegatorForValidators(ec.DposContext, validators)
 		for delegator := range allDelegators {
-			// get the vote deposit and set it in vote last epoch
-			vote := GetVoteDeposit(ec.stateDB, delegator)
+			// get the vote counted in the election and set it in vote last epoch
+			vote := expireVote(ec.stateDB, delegator, currentEpoch, ec.voteExpiration)
 			SetVoteLastEpoch(ec.stateDB, delegator, vote)
 		}
 		log.Info("Come to new epoch", "prevEpoch", i, "nextEpoch", i+1)
@@ -102,6 +112,7 @@ func (ec *EpochContext) countVotes() (votes randomSelectorEntries, err error) {
 	// get the needed variables
 	candidateTrie := ec.DposContext.CandidateTrie()
 	statedb := ec.stateDB
+	epoch := CalculateEpochID(ec.TimeStamp, ec.config.EpochPeriod())
 
 	iterCandidate := trie.NewIterator(candidateTrie.NodeIterator(nil))
 	var hasCandidate bool
@@ -113,7 +124,7 @@ func (ec *EpochContext) countVotes() (votes randomSelectorEntries, err error) {
 		candidateAddr := common.BytesToAddress(iterCandidate.Value)
 		// sanity check
 		// calculate the candidates votes
-		totalVotes := CalcCandidateTotalVotes(candidateAddr, ec.stateDB, ec.DposContext.DelegateTrie())
+		totalVotes := calcCandidateTotalVotesInEpoch(candidateAddr, ec.stateDB, ec.DposContext.DelegateTrie(), epoch, ec.voteExpiration)
 		// write the totalVotes to result and state
 		votes = append(votes, &randomSelectorEntry{addr: candidateAddr, vote: totalVotes})
 		SetTotalVote(statedb, candidateAddr, totalVotes)
@@ -125,9 +136,10 @@ func (ec *EpochContext) countVotes() (votes randomSelectorEntries, err error) {
 	return votes, nil
 }
 
-// kickoutValidators will kick out irresponsible validators of last epoch at the beginning of current epoch
-func (ec *EpochContext) kickoutValidators(epoch int64) error {
-	needKickoutValidators, err := getIneligibleValidators(ec.DposContext, epoch, ec.TimeStamp)
+// kickoutValidators will kick out irresponsible validators of last epoch at the beginning of current epoch.
+// At least the safe size of candidates for electing maxValidatorSize validators are kept
+func (ec *EpochContext) kickoutValidators(epoch int64, maxValidatorSize int) error {
+	needKickoutValidators, err := getIneligibleValidators(ec.config, ec.DposContext, epoch, ec.TimeStamp)
 	if err != nil {
 		return err
 	}
@@ -140,18 +152,19 @@ func (ec *EpochContext) kickoutValidators(epoch int64) error {
 	// and they will be remove firstly
 	sort.Sort(needKickoutValidators)
 	// count candidates to a safe size as a threshold to remove candidates
+	safeCandidateCount := safeSize(maxValidatorSize)
 	candidateCount := 0
 	iter := trie.NewIterator(ec.DposContext.CandidateTrie().NodeIterator(nil))
 	for iter.Next() {
 		candidateCount++
-		if candidateCount >= needKickoutValidatorCnt+SafeSize {
+		if candidateCount >= needKickoutValidatorCnt+safeCandidateCount {
 			break
 		}
 	}
 	// Loop over the first part of the needKickOutValidators to kick out
 	for i, validator := range needKickoutValidators {
 		// ensure candidates count greater than or equal to safeSize
-		if candidateCount <= SafeSize {
+		if candidateCount <= safeCandidateCount {
 			log.Info("No more candidates can be kickout", "prevEpochID", epoch, "candidateCount", candidateCount, "needKickoutCount", len(needKickoutValidators)-i)
 			return nil
 		}
@@ -164,7 +177,7 @@ func (ec *EpochContext) kickoutValidators(epoch int64) error {
 			return err
 		}
 		// if successfully above, then mark the validator that will be thawed in next next epoch
-		currentEpochID := CalculateEpochID(ec.TimeStamp)
+		currentEpochID := CalculateEpochID(ec.TimeStamp, ec.config.EpochPeriod())
 		deposit := GetCandidateDeposit(ec.stateDB, validator.address)
 		markThawingAddressAndValue(ec.stateDB, validator.address, currentEpochID, deposit)
 		// set candidates deposit to 0
@@ -179,7 +192,7 @@ func (ec *EpochContext) kickoutValidators(epoch int64) error {
 
 // getIneligibleValidators return the ineligible validators in a certain epoch. An ineligible validator is
 // defined as a validator who produced blocks less than half as expected
-func getIneligibleValidators(ctx *types.DposContext, epoch int64, curTime int64) (addressesByCnt, error) {
+func getIneligibleValidators(config *params.DposConfig, ctx *types.DposContext, epoch int64, curTime int64) (addressesByCnt, error) {
 	validators, err := ctx.GetValidators()
 	if err != nil {
 		return addressesByCnt{}, fmt.Errorf("failed to get validator: %s", err)
@@ -187,7 +200,7 @@ func getIneligibleValidators(ctx *types.DposContext, epoch int64, curTime int64)
 	if len(validators) == 0 {
 		return addressesByCnt{}, errors.New("no validators")
 	}
-	expectedBlockPerValidator := expectedBlocksPerValidatorInEpoch(timeOfFirstBlock, curTime)
+	expectedBlockPerValidator := expectedBlocksPerValidatorInEpoch(config, timeOfFirstBlock, curTime, len(validators))
 	var ineligibleValidators addressesByCnt
 	for _, validator := range validators {
 		cnt := ctx.GetMinedCnt(epoch, validator)
@@ -206,9 +219,9 @@ func isEligibleValidator(gotBlockProduced, expectedBlockProduced int64) bool {
 	return gotBlockProduced >= expectedBlockProduced/eligibleValidatorDenominator
 }
 
-// selectValidator select validators randomly based on candidates votes and seed
-func selectValidator(candidateVotes randomSelectorEntries, seed int64) ([]common.Address, error) {
-	return randomSelectAddress(typeLuckyWheel, candidateVotes, seed, MaxValidatorSize)
+// selectValidator select at most maxValidatorSize validators randomly based on candidates votes and seed
+func selectValidator(candidateVotes randomSelectorEntries, seed int64, maxValidatorSize int) ([]common.Address, error) {
+	return randomSelectAddress(typeLuckyWheel, candidateVotes, seed, maxValidatorSize)
 }
 
 // allDelegatorForValidators returns a map containing all delegators who vote for the validators
@@ -227,7 +240,7 @@ func allDelegatorForValidators(ctx *types.DposContext, validators []common.Addre
 // If not a valid timestamp, an error is returned
 func (ec *EpochContext) lookupValidator(blockTime int64) (validator common.Address, err error) {
 	validator = common.Address{}
-	slot, err := calcBlockSlot(blockTime)
+	slot, err := calcBlockSlot(ec.config, blockTime)
 	if err != nil {
 		return common.Address{}, err
 	}
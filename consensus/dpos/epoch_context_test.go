@@ -49,6 +49,76 @@ func TestLookupValidator(t *testing.T) {
 	}
 }
 
+func TestProductionSchedule(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	dposCtx, _ := types.NewDposContext(db)
+	mockEpochContext := &EpochContext{
+		DposContext: dposCtx,
+	}
+	validators := []common.Address{
+		common.HexToAddress("0x1"),
+		common.HexToAddress("0x2"),
+		common.HexToAddress("0x3"),
+	}
+	if err := mockEpochContext.DposContext.SetValidators(validators); err != nil {
+		t.Fatalf("Failed to set valdiators,error: %v", err)
+	}
+
+	// the schedule follows the head block, and matches the validator lookup
+	headTime := EpochInterval + 4*BlockInterval
+	schedule := productionSchedule(nil, validators, headTime, 5)
+	if len(schedule) != 5 {
+		t.Fatalf("expect 5 slots, got %v", len(schedule))
+	}
+	for i, slot := range schedule {
+		if slot.Timestamp != headTime+int64(i+1)*BlockInterval {
+			t.Errorf("slot %v: unexpected timestamp %v", i, slot.Timestamp)
+		}
+		expected, err := mockEpochContext.lookupValidator(slot.Timestamp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if slot.Validator != expected || slot.EpochID != 1 || slot.Slot != int64(i+5) {
+			t.Errorf("slot %v: expect validator %v at slot %v, got %+v", i, expected.String(), i+5, slot)
+		}
+	}
+
+	// the schedule stops at the end of the epoch
+	headTime = 2*EpochInterval - 3*BlockInterval
+	if schedule = productionSchedule(nil, validators, headTime, 10); len(schedule) != 2 {
+		t.Errorf("expect 2 slots before the epoch ends, got %v", len(schedule))
+	}
+	if schedule = productionSchedule(nil, nil, headTime, 10); len(schedule) != 0 {
+		t.Errorf("expect no slots without validators, got %v", len(schedule))
+	}
+}
+
+// TestNextElection test the snapshot of the next epoch is taken at the first slot of the next
+// epoch, or at the next block if the first slot has passed
+func TestNextElection(t *testing.T) {
+	headTime := 2*EpochInterval - 3*BlockInterval
+	head := &types.Header{Number: big.NewInt(100), Time: big.NewInt(headTime)}
+
+	schedule := NextElection(nil, head, headTime+1)
+	expected := ElectionSchedule{
+		EpochID:       1,
+		NextEpochID:   2,
+		SnapshotTime:  2 * EpochInterval,
+		SnapshotBlock: 103,
+		ETA:           3*BlockInterval - 1,
+	}
+	if schedule != expected {
+		t.Errorf("expect schedule %+v, got %+v", expected, schedule)
+	}
+
+	// the first slot of the next epoch passed without a block
+	now := 2*EpochInterval + BlockInterval + 1
+	schedule = NextElection(nil, head, now)
+	if schedule.SnapshotBlock != 101 || schedule.SnapshotTime != 2*EpochInterval+2*BlockInterval || schedule.ETA != BlockInterval-1 {
+		t.Errorf("unexpected schedule with the first slot passed: %+v", schedule)
+	}
+}
+
 func Test_CountVotes(t *testing.T) {
 
 	// mock addresses
@@ -154,8 +224,8 @@ func Test_KickoutValidators(t *testing.T) {
 		stateDB:     stateDB,
 	}
 
-	epochID := CalculateEpochID(now)
-	err = epochContext.kickoutValidators(epochID)
+	epochID := CalculateEpochID(now, EpochInterval)
+	err = epochContext.kickoutValidators(epochID, MaxValidatorSize)
 	if err != nil {
 		t.Errorf("something wrong to kick out validators,error: %v", err)
 	}
@@ -251,7 +321,7 @@ func randomProcessVote(stateDB *state.StateDB, ctx *types.DposContext, addr comm
 			break
 		}
 	}
-	if _, err := ProcessVote(stateDB, ctx, addr, deposit, votedCandidates, time); err != nil {
+	if _, err := ProcessVote(stateDB, ctx, addr, deposit, votedCandidates, CalculateEpochID(time, EpochInterval), false, false); err != nil {
 		return false, err
 	}
 	return selected, nil
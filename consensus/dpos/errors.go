@@ -7,6 +7,9 @@ package dpos
 import (
 	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/DxChainNetwork/godx/common"
 )
 
 var (
@@ -44,6 +47,30 @@ var (
 
 	// ErrNilBlockHeader is returned if returning a nil block header in api functions
 	ErrNilBlockHeader = errors.New("nil block header returned")
+
+	// errReorgPastConfirmed is returned if the reorg drops the confirmed block, which is
+	// irreversible
+	errReorgPastConfirmed = errors.New("reorg past the confirmed block")
+
+	// errReorgTooDeep is returned if the reorg drops more blocks than the max reorg depth
+	errReorgTooDeep = errors.New("reorg exceeds the max reorg depth")
+
+	// errUnknownCheckpoint is returned if the epoch of the checkpoint requested has not started
+	errUnknownCheckpoint = errors.New("unknown checkpoint")
+
+	// errCheckpointValidators is returned if the validator list of the checkpoint does not match
+	// the validators hash
+	errCheckpointValidators = errors.New("checkpoint validators mismatch the validators hash")
+
+	// errCheckpointHash is returned if the hash of the checkpoint is not expected
+	errCheckpointHash = errors.New("invalid checkpoint hash")
+
+	// errCheckpointSigner is returned if the checkpoint is signed by a non-validator, or the
+	// signature does not match the validator
+	errCheckpointSigner = errors.New("invalid checkpoint signer")
+
+	// errCheckpointNotFinal is returned if the checkpoint is not signed by enough validators
+	errCheckpointNotFinal = errors.New("checkpoint not signed by the threshold of validators")
 )
 
 var (
@@ -61,7 +88,7 @@ var (
 
 	// errCandidateInsufficientDeposit happens when processing a candidates transaction, found
 	// that the candidates's deposit is lower than the threshold
-	errCandidateInsufficientDeposit = fmt.Errorf("candidates argument not qualified - minimum deposit: %v", minDeposit)
+	errCandidateInsufficientDeposit = errors.New("candidates argument not qualified - deposit lower than the minimum deposit")
 
 	// errCandidateInvalidRewardRatio happens when processing a candidates transaction, found
 	// the value of reward ratio is invalid
@@ -79,6 +106,10 @@ var (
 	// transaction
 	errCandidateInsufficientBalance = errors.New("candidates not qualified - candidates does not have enough balance")
 
+	// errCandidateValidatorLocked happens when a validator of the current epoch cancels being
+	// the candidate before the epoch ends
+	errCandidateValidatorLocked = errors.New("validator of the current epoch cannot cancel candidate until the epoch ends")
+
 	// errInsufficientFrozenAssets is the error happens when subtracting frozen assets, the diff value is
 	// larger the stored frozen assets
 	errInsufficientFrozenAssets = errors.New("not enough frozen assets to subtract")
@@ -97,3 +128,18 @@ var (
 	// errDelegatorInsufficientBalance indicates the delegator does not have enough balance to pay for the vote deposit
 	errDelegatorInsufficientBalance = errors.New("delegator does not have enough balance to pay for the vote deposit")
 )
+
+// InvalidCandidatesError happens when voting the addresses which are not candidates. The
+// addresses are kept so that the sender knows which part of the vote is invalid
+type InvalidCandidatesError struct {
+	Candidates []common.Address
+}
+
+// Error implements the error interface
+func (e *InvalidCandidatesError) Error() string {
+	addrs := make([]string, 0, len(e.Candidates))
+	for _, addr := range e.Candidates {
+		addrs = append(addrs, addr.String())
+	}
+	return fmt.Sprintf("cannot vote the addresses which are not candidates: %v", strings.Join(addrs, ", "))
+}
@@ -0,0 +1,144 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package dpos
+
+import (
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/log"
+)
+
+// ReorgEvent is posted to the event mux once a competing chain is detected, with the depth
+// of the reorg needed to switch onto the competing chain. It is posted again with Refused
+// set if the reorg is refused by the reorg guard
+type ReorgEvent struct {
+	Ancestor       common.Hash `json:"ancestor"`
+	AncestorNumber uint64      `json:"ancestorNumber"`
+	OldHead        common.Hash `json:"oldHead"`
+	NewHead        common.Hash `json:"newHead"`
+	Depth          uint64      `json:"depth"`
+	Refused        bool        `json:"refused"`
+}
+
+// ForkStatus is the fork choice status of the dpos engine
+type ForkStatus struct {
+	Head          uint64      `json:"head"`
+	HeadHash      common.Hash `json:"headHash"`
+	Confirmed     uint64      `json:"confirmed"`
+	ConfirmedHash common.Hash `json:"confirmedHash"`
+	MaxReorgDepth uint64      `json:"maxReorgDepth"`
+	LastReorg     *ReorgEvent `json:"lastReorg"`
+	RefusedReorgs uint64      `json:"refusedReorgs"`
+}
+
+// detectCompetingChain checks whether the header verified extends the current head. If not,
+// the header is on a competing chain, and the reorg event with the depth of the fork is posted
+func (d *Dpos) detectCompetingChain(chain consensus.ChainReader, header *types.Header) {
+	current := chain.CurrentHeader()
+	if current == nil || header.ParentHash == current.Hash() {
+		return
+	}
+	ancestor := canonicalAncestor(chain, header)
+	if ancestor == nil {
+		return
+	}
+	d.recordReorg(&ReorgEvent{
+		Ancestor:       ancestor.Hash(),
+		AncestorNumber: ancestor.Number.Uint64(),
+		OldHead:        current.Hash(),
+		NewHead:        header.Hash(),
+		Depth:          current.Number.Uint64() - ancestor.Number.Uint64(),
+	})
+}
+
+// VerifyReorg checks whether the chain could be reorganized from the old head onto the new
+// head forking from the ancestor. The reorg is refused if it drops the confirmed block, which
+// is irreversible, or drops more blocks than the max reorg depth configured
+func (d *Dpos) VerifyReorg(ancestor, oldHead, newHead *types.Header) error {
+	if d.Mode == ModeFake {
+		return nil
+	}
+
+	var (
+		depth     = oldHead.Number.Uint64() - ancestor.Number.Uint64()
+		maxDepth  = d.config.ReorgDepthLimit()
+		confirmed = d.confirmedBlockHeader
+		err       error
+	)
+	if confirmed != nil && ancestor.Number.Cmp(confirmed.Number) < 0 {
+		err = fmt.Errorf("%v: ancestor %v, confirmed %v", errReorgPastConfirmed, ancestor.Number, confirmed.Number)
+	} else if maxDepth != 0 && depth > maxDepth {
+		err = fmt.Errorf("%v: depth %v, max %v", errReorgTooDeep, depth, maxDepth)
+	}
+	if err != nil {
+		d.recordReorg(&ReorgEvent{
+			Ancestor:       ancestor.Hash(),
+			AncestorNumber: ancestor.Number.Uint64(),
+			OldHead:        oldHead.Hash(),
+			NewHead:        newHead.Hash(),
+			Depth:          depth,
+			Refused:        true,
+		})
+	}
+	return err
+}
+
+// ForkStatus returns the fork choice status with the current head of the chain
+func (d *Dpos) ForkStatus(chain consensus.ChainReader) ForkStatus {
+	status := ForkStatus{
+		MaxReorgDepth: d.config.ReorgDepthLimit(),
+	}
+	if head := chain.CurrentHeader(); head != nil {
+		status.Head, status.HeadHash = head.Number.Uint64(), head.Hash()
+	}
+	if confirmed := d.confirmedBlockHeader; confirmed != nil {
+		status.Confirmed, status.ConfirmedHash = confirmed.Number.Uint64(), confirmed.Hash()
+	}
+
+	d.forkLock.RLock()
+	defer d.forkLock.RUnlock()
+
+	if d.lastReorg != nil {
+		reorg := *d.lastReorg
+		status.LastReorg = &reorg
+	}
+	status.RefusedReorgs = d.refusedReorgs
+	return status
+}
+
+// recordReorg records the reorg as the latest one, and posts it to the event mux
+func (d *Dpos) recordReorg(event *ReorgEvent) {
+	d.forkLock.Lock()
+	d.lastReorg = event
+	if event.Refused {
+		d.refusedReorgs++
+	}
+	d.forkLock.Unlock()
+
+	if !event.Refused {
+		log.Debug("Dpos detected competing chain", "ancestor", event.AncestorNumber, "depth", event.Depth, "newHead", event.NewHead)
+	}
+	// the event is posted asynchronously, since the reorg is verified with the chain locked
+	if d.mux != nil {
+		go d.mux.Post(*event)
+	}
+}
+
+// canonicalAncestor returns the latest ancestor of the header on the canonical chain
+func canonicalAncestor(chain consensus.ChainReader, header *types.Header) *types.Header {
+	for number := header.Number.Uint64(); number > 0; number-- {
+		header = chain.GetHeader(header.ParentHash, number-1)
+		if header == nil {
+			return nil
+		}
+		if canon := chain.GetHeaderByNumber(number - 1); canon != nil && canon.Hash() == header.Hash() {
+			return header
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package dpos
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/event"
+	"github.com/DxChainNetwork/godx/params"
+)
+
+func TestVerifyReorg(t *testing.T) {
+	header := func(number int64) *types.Header {
+		return &types.Header{Number: big.NewInt(number), Extra: []byte{byte(number)}}
+	}
+	tests := []struct {
+		name      string
+		maxDepth  uint64
+		confirmed *types.Header
+		ancestor  int64
+		oldHead   int64
+		refused   bool
+	}{
+		{"no limit", 0, nil, 10, 100, false},
+		{"within max depth", 5, nil, 95, 100, false},
+		{"exceed max depth", 5, nil, 94, 100, true},
+		{"ancestor is confirmed", 0, header(90), 90, 100, false},
+		{"past confirmed", 0, header(90), 89, 100, true},
+	}
+	for _, test := range tests {
+		mux := new(event.TypeMux)
+		sub := mux.Subscribe(ReorgEvent{})
+		d := New(&params.DposConfig{MaxReorgDepth: test.maxDepth}, nil, mux)
+		d.confirmedBlockHeader = test.confirmed
+
+		err := d.VerifyReorg(header(test.ancestor), header(test.oldHead), header(test.oldHead+1))
+		if refused := err != nil; refused != test.refused {
+			t.Errorf("%s: reorg refused %v, expect %v", test.name, refused, test.refused)
+		}
+		if !test.refused {
+			sub.Unsubscribe()
+			continue
+		}
+
+		select {
+		case ev := <-sub.Chan():
+			reorg := ev.Data.(ReorgEvent)
+			if !reorg.Refused || reorg.Depth != uint64(test.oldHead-test.ancestor) {
+				t.Errorf("%s: unexpected reorg event %+v", test.name, reorg)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%s: reorg event not posted", test.name)
+		}
+		if d.refusedReorgs != 1 || d.lastReorg == nil {
+			t.Errorf("%s: refused reorg not recorded", test.name)
+		}
+		sub.Unsubscribe()
+	}
+}
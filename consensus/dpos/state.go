@@ -40,6 +40,9 @@ var (
 	// KeyVoteLastEpoch is the vote deposit in the last epoch
 	KeyVoteLastEpoch = common.BytesToHash([]byte("vote-last-epoch"))
 
+	// KeyVoteEpoch is the key of the epoch the delegator last voted in
+	KeyVoteEpoch = common.BytesToHash([]byte("vote-epoch"))
+
 	// KeyTotalVote is the key of total vote for each candidates
 	KeyTotalVote = common.BytesToHash([]byte("total-vote"))
 
@@ -49,6 +52,16 @@ var (
 	// PrefixThawingAssets is the prefix recording the amount to be thawed in a specified epoch
 	PrefixThawingAssets = []byte("thawing-assets")
 
+	// PrefixEpochValidatorReward is the prefix recording the block reward kept by the validator in an epoch
+	PrefixEpochValidatorReward = []byte("epoch-validator-reward")
+
+	// PrefixEpochSharedReward is the prefix recording the block reward shared by the validator
+	// to its delegators in an epoch
+	PrefixEpochSharedReward = []byte("epoch-shared-reward")
+
+	// PrefixEpochDelegatorReward is the prefix recording the reward received by the delegator in an epoch
+	PrefixEpochDelegatorReward = []byte("epoch-delegator-reward")
+
 	// KeyPreEpochSnapshotDelegateTrieRoot is the key of block number where snapshot delegate trie
 	KeyPreEpochSnapshotDelegateTrieRoot = common.BytesToHash([]byte("pre-epoch-dtr"))
 
@@ -106,6 +119,18 @@ func SetRewardRatioNumeratorLastEpoch(state stateDB, addr common.Address, value
 	state.SetState(addr, KeyRewardRatioNumeratorLastEpoch, hash)
 }
 
+// GetVoteEpoch get the epoch the delegator last voted in. 0 is returned if not recorded
+func GetVoteEpoch(state stateDB, addr common.Address) int64 {
+	hash := state.GetState(addr, KeyVoteEpoch)
+	return int64(hashToUint64(hash))
+}
+
+// SetVoteEpoch set the epoch the delegator last voted in
+func SetVoteEpoch(state stateDB, addr common.Address, epoch int64) {
+	hash := uint64ToHash(uint64(epoch))
+	state.SetState(addr, KeyVoteEpoch, hash)
+}
+
 // GetTotalVote get the total vote for the candidates address
 func GetTotalVote(state stateDB, addr common.Address) common.BigInt {
 	hash := state.GetState(addr, KeyTotalVote)
@@ -197,6 +222,50 @@ func makeThawingAssetsKey(epoch int64) common.Hash {
 	return common.BytesToHash(append(PrefixThawingAssets, epochByte...))
 }
 
+// EpochRewardRecord is the block reward distribution record of an address in an epoch
+type EpochRewardRecord struct {
+	// ValidatorReward is the block reward kept by the address as a validator
+	ValidatorReward common.BigInt
+
+	// SharedReward is the block reward shared by the address to its delegators as a validator
+	SharedReward common.BigInt
+
+	// DelegatorReward is the reward received by the address as a delegator
+	DelegatorReward common.BigInt
+}
+
+// GetEpochRewardRecord returns the block reward distribution record of the address in the epoch
+func GetEpochRewardRecord(state stateDB, addr common.Address, epoch int64) EpochRewardRecord {
+	return EpochRewardRecord{
+		ValidatorReward: getEpochReward(state, addr, PrefixEpochValidatorReward, epoch),
+		SharedReward:    getEpochReward(state, addr, PrefixEpochSharedReward, epoch),
+		DelegatorReward: getEpochReward(state, addr, PrefixEpochDelegatorReward, epoch),
+	}
+}
+
+// getEpochReward returns the reward of the prefix recorded for the address in the epoch
+func getEpochReward(state stateDB, addr common.Address, prefix []byte, epoch int64) common.BigInt {
+	hash := state.GetState(addr, makeEpochRewardKey(prefix, epoch))
+	return common.PtrBigInt(hash.Big())
+}
+
+// addEpochReward add the diff to the reward of the prefix recorded for the address in the epoch
+func addEpochReward(state stateDB, addr common.Address, prefix []byte, epoch int64, diff common.BigInt) {
+	if diff.Sign() == 0 {
+		return
+	}
+	prev := getEpochReward(state, addr, prefix, epoch)
+	hash := common.BigToHash(prev.Add(diff).BigIntPtr())
+	state.SetState(addr, makeEpochRewardKey(prefix, epoch), hash)
+}
+
+// makeEpochRewardKey makes the key for the reward record of the prefix in a certain epoch
+func makeEpochRewardKey(prefix []byte, epoch int64) common.Hash {
+	epochByte := make([]byte, 8)
+	binary.BigEndian.PutUint64(epochByte, uint64(epoch))
+	return common.BytesToHash(append(append([]byte{}, prefix...), epochByte...))
+}
+
 // GetVoteLastEpoch get the vote deposit in the last epoch
 func GetVoteLastEpoch(state stateDB, addr common.Address) common.BigInt {
 	h := state.GetState(addr, KeyVoteLastEpoch)
@@ -48,7 +48,7 @@ func TestEmptyThawAllFrozenAssetsInEpoch(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	epoch := calcThawingEpoch(CalculateEpochID(time.Now().Unix()))
+	epoch := calcThawingEpoch(CalculateEpochID(time.Now().Unix(), EpochInterval))
 	err = checkThawingAddressAndValue(state, epoch, make(map[common.Address]common.BigInt))
 	if err != nil {
 		t.Fatal(err)
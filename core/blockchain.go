@@ -1042,6 +1042,17 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 			reorg = !currentPreserve && (blockPreserve || mrand.Float64() < 0.5)
 		}
 	}
+	// keep the block on the side chain if the dpos engine refuses to reorg onto it
+	if reorg && block.ParentHash() != currentBlock.Hash() {
+		if dposEngine, ok := bc.engine.(*dpos.Dpos); ok {
+			if ancestor := rawdb.FindCommonAncestor(bc.db, currentBlock.Header(), block.Header()); ancestor != nil {
+				if err := dposEngine.VerifyReorg(ancestor, currentBlock.Header(), block.Header()); err != nil {
+					log.Warn("Chain reorg refused", "number", block.Number(), "hash", block.Hash(), "err", err)
+					reorg = false
+				}
+			}
+		}
+	}
 	if reorg {
 		var chainChangeEvent *ChainChangeEvent
 
@@ -1137,6 +1148,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, []
 	}
 	// Start a parallel signature recovery (signer will fluke on fork transition, minimal perf loss)
 	senderCacher.recoverFromBlocks(types.MakeSigner(bc.chainConfig, chain[0].Number()), chain)
+	storageSigCacher.recoverFromBlocks(chain)
 
 	// A queued approach to delivering events. This is generally
 	// faster than direct delivery and requires much less mutex
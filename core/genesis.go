@@ -158,6 +158,11 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, constant
 	if genesis != nil && genesis.Config == nil {
 		return params.DposChainConfig, common.Hash{}, errGenesisNoConfig
 	}
+	if genesis != nil {
+		if err := genesis.Config.Dpos.ValidateTiming(); err != nil {
+			return genesis.Config, common.Hash{}, err
+		}
+	}
 
 	// Just commit the new block if there is no stored genesis block.
 	stored := rawdb.ReadCanonicalHash(db, 0)
@@ -208,6 +213,11 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, genesis *Genesis, constant
 	if compatErr != nil && *height != 0 && compatErr.RewindTo != 0 {
 		return newcfg, stored, compatErr
 	}
+	if *height != 0 {
+		if err := storedcfg.Dpos.CheckGenesisCompatible(newcfg.Dpos); err != nil {
+			return newcfg, stored, err
+		}
+	}
 	rawdb.WriteChainConfig(db, stored, newcfg)
 	return newcfg, stored, nil
 }
@@ -486,6 +496,39 @@ func initGenesisDposContext(stateDB *state.StateDB, g *Genesis, db ethdb.Databas
 		dpos.SetRewardRatioNumeratorLastEpoch(stateDB, validatorAddr, validator.RewardRatio)
 	}
 
+	// add the genesis candidates besides the validators
+	minDeposit := g.Config.Dpos.MinCandidateDeposit(common.Big0)
+	for _, candidate := range g.Config.Dpos.Candidates {
+		if _, exist := vMap[candidate.Address]; exist {
+			return nil, fmt.Errorf("duplicate candidate address %x", candidate.Address)
+		}
+		vMap[candidate.Address] = struct{}{}
+
+		if err = dpos.ProcessAddCandidate(stateDB, dc, candidate.Address, candidate.Deposit, candidate.RewardRatio, minDeposit); err != nil {
+			return nil, fmt.Errorf("during initializing for genesis, failed to add candidate %x: %v", candidate.Address, err)
+		}
+		dpos.SetRewardRatioNumeratorLastEpoch(stateDB, candidate.Address, candidate.RewardRatio)
+	}
+
+	// cast the genesis votes, which must be for the validators and the candidates
+	delegators := make(map[common.Address]struct{})
+	voteExpiration := g.Config.Dpos.VoteExpirationAt(common.Big0) != nil
+	epoch := dpos.CalculateEpochID(int64(g.Timestamp), g.Config.Dpos.EpochPeriod())
+	for _, vote := range g.Config.Dpos.Votes {
+		if _, exist := delegators[vote.Delegator]; exist {
+			return nil, fmt.Errorf("duplicate delegator address %x", vote.Delegator)
+		}
+		delegators[vote.Delegator] = struct{}{}
+
+		voted, err := dpos.ProcessVote(stateDB, dc, vote.Delegator, vote.Deposit, vote.Candidates, epoch, false, voteExpiration)
+		if err != nil {
+			return nil, fmt.Errorf("during initializing for genesis, failed to vote from %x: %v", vote.Delegator, err)
+		}
+		if voted != len(vote.Candidates) {
+			return nil, fmt.Errorf("during initializing for genesis, delegator %x voted for non candidates", vote.Delegator)
+		}
+	}
+
 	// init the KeyValueCommonAddress account and set its nonce 1 to avoid deleting empty state object
 	stateDB.SetNonce(dpos.KeyValueCommonAddress, 1)
 	stateDB.SetState(dpos.KeyValueCommonAddress, dpos.KeyPreEpochSnapshotDelegateTrieRoot, dc.DelegateTrie().Hash())
@@ -22,7 +22,9 @@ import (
 	"testing"
 
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
 	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/state"
 	"github.com/DxChainNetwork/godx/ethdb"
 	"github.com/DxChainNetwork/godx/params"
 	"github.com/davecgh/go-spew/spew"
@@ -142,3 +144,62 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+// TestGenesisCandidatesAndVotes test the genesis candidates and votes are populated into the
+// dpos context and the state
+func TestGenesisCandidatesAndVotes(t *testing.T) {
+	var (
+		validator = common.HexToAddress("0x01")
+		candidate = common.HexToAddress("0x02")
+		delegator = common.HexToAddress("0x03")
+		deposit   = params.DefaultMinCandidateDeposit
+		vote      = common.NewBigIntUint64(1e18)
+	)
+	newGenesis := func(votes []params.VoteConfig) (*Genesis, *state.StateDB, ethdb.Database) {
+		g := &Genesis{
+			Config: &params.ChainConfig{
+				Dpos: &params.DposConfig{
+					Validators: []params.ValidatorConfig{{Address: validator, Deposit: deposit, RewardRatio: 30}},
+					Candidates: []params.ValidatorConfig{{Address: candidate, Deposit: deposit, RewardRatio: 50}},
+					Votes:      votes,
+				},
+			},
+		}
+		db := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+		for _, addr := range []common.Address{validator, candidate, delegator} {
+			statedb.AddBalance(addr, deposit.BigIntPtr())
+		}
+		return g, statedb, db
+	}
+
+	g, statedb, db := newGenesis([]params.VoteConfig{{Delegator: delegator, Deposit: vote, Candidates: []common.Address{validator, candidate}}})
+	dc, err := initGenesisDposContext(statedb, g, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := dpos.GetCandidateDeposit(statedb, candidate); got.Cmp(deposit) != 0 {
+		t.Errorf("candidate deposit %v, want %v", got, deposit)
+	}
+	if got := dpos.GetRewardRatioNumerator(statedb, candidate); got != 50 {
+		t.Errorf("candidate reward ratio %v, want 50", got)
+	}
+	if got := dpos.GetVoteDeposit(statedb, delegator); got.Cmp(vote) != 0 {
+		t.Errorf("vote deposit %v, want %v", got, vote)
+	}
+	if got := dpos.GetFrozenAssets(statedb, delegator); got.Cmp(vote) != 0 {
+		t.Errorf("delegator frozen assets %v, want %v", got, vote)
+	}
+	for _, addr := range []common.Address{validator, candidate} {
+		want := dpos.GetCandidateDeposit(statedb, addr).Add(vote)
+		if got := dpos.CalcCandidateTotalVotes(addr, statedb, dc.DelegateTrie()); got.Cmp(want) != 0 {
+			t.Errorf("total votes of %x %v, want %v", addr, got, want)
+		}
+	}
+
+	// the genesis votes must be for the validators and the candidates
+	g, statedb, db = newGenesis([]params.VoteConfig{{Delegator: delegator, Deposit: vote, Candidates: []common.Address{delegator}}})
+	if _, err = initGenesisDposContext(statedb, g, db); err == nil {
+		t.Errorf("expect error voting for non candidates")
+	}
+}
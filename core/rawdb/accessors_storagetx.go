@@ -0,0 +1,80 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package rawdb
+
+import (
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// StorageTxEntry is the record of an executed storage contract or dpos transaction,
+// with the payload decoded into a short summary
+type StorageTxEntry struct {
+	TxHash     common.Hash
+	TxIndex    uint64
+	From       common.Address
+	Type       string
+	ContractID common.Hash
+	Summary    string
+}
+
+// StorageTxLocation is the position of a storage transaction in the chain
+type StorageTxLocation struct {
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxIndex     uint64
+}
+
+// ReadStorageTxEntries retrieves the storage transactions executed in the block
+func ReadStorageTxEntries(db DatabaseReader, hash common.Hash, number uint64) []StorageTxEntry {
+	data, _ := db.Get(storageTxKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	var entries []StorageTxEntry
+	if err := rlp.DecodeBytes(data, &entries); err != nil {
+		log.Error("Invalid storage transaction entries RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return entries
+}
+
+// WriteStorageTxEntries stores the storage transactions executed in the block
+func WriteStorageTxEntries(db DatabaseWriter, hash common.Hash, number uint64, entries []StorageTxEntry) {
+	data, err := rlp.EncodeToBytes(entries)
+	if err != nil {
+		log.Crit("Failed to encode storage transaction entries", "err", err)
+	}
+	if err := db.Put(storageTxKey(number, hash), data); err != nil {
+		log.Crit("Failed to store storage transaction entries", "err", err)
+	}
+}
+
+// ReadStorageTxLocations retrieves the locations of the storage transactions sent by
+// the address. The locations might include the ones of the non-canonical blocks
+func ReadStorageTxLocations(db DatabaseReader, address common.Address) []StorageTxLocation {
+	data, _ := db.Get(storageTxAddressKey(address))
+	if len(data) == 0 {
+		return nil
+	}
+	var locations []StorageTxLocation
+	if err := rlp.DecodeBytes(data, &locations); err != nil {
+		log.Error("Invalid storage transaction locations RLP", "address", address, "err", err)
+		return nil
+	}
+	return locations
+}
+
+// WriteStorageTxLocations stores the locations of the storage transactions sent by the address
+func WriteStorageTxLocations(db DatabaseWriter, address common.Address, locations []StorageTxLocation) {
+	data, err := rlp.EncodeToBytes(locations)
+	if err != nil {
+		log.Crit("Failed to encode storage transaction locations", "err", err)
+	}
+	if err := db.Put(storageTxAddressKey(address), data); err != nil {
+		log.Crit("Failed to store storage transaction locations", "err", err)
+	}
+}
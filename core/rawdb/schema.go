@@ -52,11 +52,15 @@ var (
 	txLookupPrefix  = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
 
+	storageTxPrefix        = []byte("S") // storageTxPrefix + num (uint64 big endian) + hash -> storage transactions of the block
+	storageTxAddressPrefix = []byte("X") // storageTxAddressPrefix + address -> locations of the storage transactions sent by the address
+
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	StorageTxIndexPrefix = []byte("iS") // StorageTxIndexPrefix is the data table of the storage transaction indexer to track its progress
 
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
@@ -122,6 +126,16 @@ func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	return key
 }
 
+// storageTxKey = storageTxPrefix + num (uint64 big endian) + hash
+func storageTxKey(number uint64, hash common.Hash) []byte {
+	return append(append(storageTxPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// storageTxAddressKey = storageTxAddressPrefix + address
+func storageTxAddressKey(address common.Address) []byte {
+	return append(storageTxAddressPrefix, address.Bytes()...)
+}
+
 // preimageKey = preimagePrefix + hash
 func preimageKey(hash common.Hash) []byte {
 	return append(preimagePrefix, hash.Bytes()...)
@@ -17,6 +17,8 @@
 package core
 
 import (
+	"fmt"
+
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/consensus"
 	"github.com/DxChainNetwork/godx/consensus/misc"
@@ -79,11 +81,20 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 
 	// maintenance missed storage proof
 	height := header.Number.Uint64()
-	coinchargemaintenance.MaintenanceMissedProof(height, statedb)
+	if err := coinchargemaintenance.MaintenanceMissedProof(height, statedb); err != nil {
+		return nil, nil, 0, err
+	}
 
 	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
 	p.engine.Finalize(p.bc, header, statedb, block.Transactions(), block.Uncles(), receipts, block.DposCtx())
 
+	// check the dpos context to catch the state corruption early
+	if cfg.ValidateDposContext {
+		if err := block.DposCtx().Validate(); err != nil {
+			return nil, nil, 0, fmt.Errorf("invalid dpos context after block %d: %v", block.NumberU64(), err)
+		}
+	}
+
 	return receipts, allLogs, *usedGas, nil
 }
 
@@ -103,7 +114,7 @@ func ApplyTransaction(config *params.ChainConfig, bc ChainContext, coinbase *com
 	// about the transaction and calling mechanisms.
 	vmenv := vm.NewEVM(context, statedb, config, cfg)
 	// Apply the transaction to the current state (included in the env)
-	_, gas, failed, err := ApplyMessage(vmenv, msg, gp, dposContext)
+	ret, gas, failed, err := ApplyMessage(vmenv, msg, gp, dposContext)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -126,6 +137,13 @@ func ApplyTransaction(config *params.ChainConfig, bc ChainContext, coinbase *com
 	if msg.To() == nil {
 		receipt.ContractAddress = crypto.CreateAddress(vmenv.Context.Origin, tx.Nonce())
 	}
+	// if the transaction is sent to the precompiled storage or dpos contract, store the result returned,
+	// or the reason of the failure since the precompile failure block
+	if msg.To() != nil && (!failed || config.IsPrecompileFailureRecorded(header.Number)) {
+		if _, ok := vm.ActivePrecompiledTxType(config, header.Number, *msg.To()); ok {
+			receipt.ReturnData = ret
+		}
+	}
 	// Set the receipt logs and create a bloom for filtering
 	receipt.Logs = statedb.GetLogs(tx.Hash())
 	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
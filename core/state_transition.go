@@ -213,7 +213,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 
 	if contractCreation {
 		ret, _, st.gas, vmerr = evm.Create(sender, st.data, st.gas, st.value)
-	} else if p, ok := vm.PrecompiledStorageContracts[st.to()]; ok {
+	} else if p, ok := vm.ActiveStorageContractTxType(evm.ChainConfig(), evm.BlockNumber, st.to()); ok {
 		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
 		ret, st.gas, vmerr = evm.ApplyStorageContractTransaction(sender, p, st.data, st.gas)
 	} else if p, ok := vm.PrecompiledDPoSContracts[st.to()]; ok {
@@ -232,6 +232,10 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 		if vmerr == vm.ErrInsufficientBalance {
 			return nil, 0, false, vmerr
 		}
+		// return the reason of the failed precompiled contract tx, which is recorded in the receipt
+		if _, ok := vm.ActivePrecompiledTxType(evm.ChainConfig(), evm.BlockNumber, st.to()); ok && !contractCreation && evm.ChainConfig().IsPrecompileFailureRecorded(evm.BlockNumber) {
+			ret = vm.EncodePrecompileFailure(vmerr)
+		}
 	}
 	st.refundGas()
 	st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
@@ -0,0 +1,78 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package core
+
+import (
+	"runtime"
+
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+)
+
+// storageSigCacher is a concurrent storage tx signature recoverer and cacher.
+var storageSigCacher = newStorageSigCacher(runtime.NumCPU())
+
+// txStorageSigCacher is a helper structure to concurrently ecrecover the public keys
+// from the signatures of the storage txs on background threads, in the same manner
+// as txSenderCacher does for the tx senders
+type txStorageSigCacher struct {
+	threads int
+	tasks   chan []*types.Transaction
+}
+
+// newStorageSigCacher creates a new storage signature background cacher and starts
+// as many processing goroutines as the threads on construction.
+func newStorageSigCacher(threads int) *txStorageSigCacher {
+	cacher := &txStorageSigCacher{
+		tasks:   make(chan []*types.Transaction, threads),
+		threads: threads,
+	}
+	for i := 0; i < threads; i++ {
+		go cacher.cache()
+	}
+	return cacher
+}
+
+// cache is an infinite loop, caching the public keys recovered from the storage txs.
+func (cacher *txStorageSigCacher) cache() {
+	for txs := range cacher.tasks {
+		for _, tx := range txs {
+			vm.RecoverStorageSignatures(tx)
+		}
+	}
+}
+
+// recoverFromBlocks recovers the public keys from the signatures of the storage txs
+// in a batch of blocks. The storage txs are split evenly among the threads, as each
+// of them may carry multiple signatures. There is no validation being done, nor any
+// reaction to invalid signatures. That is up to the execution later.
+func (cacher *txStorageSigCacher) recoverFromBlocks(blocks []*types.Block) {
+	var txs []*types.Transaction
+	for _, block := range blocks {
+		for _, tx := range block.Transactions() {
+			if tx.To() == nil {
+				continue
+			}
+			if _, exists := vm.PrecompiledStorageContracts[*tx.To()]; exists {
+				txs = append(txs, tx)
+			}
+		}
+	}
+	if len(txs) == 0 {
+		return
+	}
+	tasks := cacher.threads
+	if len(txs) < tasks {
+		tasks = len(txs)
+	}
+	size := (len(txs) + tasks - 1) / tasks
+	for start := 0; start < len(txs); start += size {
+		end := start + size
+		if end > len(txs) {
+			end = len(txs)
+		}
+		cacher.tasks <- txs[start:end]
+	}
+}
@@ -30,10 +30,12 @@ import (
 	"github.com/DxChainNetwork/godx/consensus/dpos"
 	"github.com/DxChainNetwork/godx/core/state"
 	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
 	"github.com/DxChainNetwork/godx/event"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/metrics"
 	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
 )
 
 const (
@@ -77,6 +79,14 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrCandidateInsufficientDeposit is returned if the deposit of a candidate transaction
+	// is lower than the minimum candidate deposit defined in chain config
+	ErrCandidateInsufficientDeposit = errors.New("candidate deposit lower than the minimum deposit")
+
+	// ErrUnprotectedTx is returned if a precompiled contract transaction is not signed with the
+	// chain id, while the replay protection is enforced on the transaction type in chain config
+	ErrUnprotectedTx = errors.New("precompiled contract transaction not replay protected")
 )
 
 var (
@@ -627,6 +637,71 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if tx.Gas() < intrGas {
 		return ErrIntrinsicGas
 	}
+	if err := pool.validateDposTxGas(tx, from, intrGas); err != nil {
+		return err
+	}
+	if err := pool.validateReplayProtection(tx); err != nil {
+		return err
+	}
+	return pool.validateCandidateTx(tx)
+}
+
+// validateDposTxGas checks the gas of the dpos tx covers the gas of the storage operations on
+// top of the intrinsic gas, if the operation gas is metered at the next block. The malformed
+// transaction data is left for the execution to reject
+func (pool *TxPool) validateDposTxGas(tx *types.Transaction, from common.Address, intrGas uint64) error {
+	if tx.To() == nil {
+		return nil
+	}
+	txType, ok := vm.PrecompiledDPoSContracts[*tx.To()]
+	if !ok {
+		return nil
+	}
+	next := new(big.Int).Add(pool.chain.CurrentBlock().Number(), big.NewInt(1))
+	if !pool.chainconfig.Dpos.IsOperationGasMetered(next) {
+		return nil
+	}
+	opGas, err := vm.DposTxGas(pool.currentState, from, txType, tx.Data())
+	if err != nil {
+		return nil
+	}
+	if tx.Gas() < intrGas+opGas {
+		return ErrIntrinsicGas
+	}
+	return nil
+}
+
+// validateReplayProtection rejects the precompiled contract transaction with the legacy
+// signature, if the replay protection is enforced on the transaction type at the next block
+func (pool *TxPool) validateReplayProtection(tx *types.Transaction) error {
+	if tx.To() == nil || tx.Protected() {
+		return nil
+	}
+	next := new(big.Int).Add(pool.chain.CurrentBlock().Number(), big.NewInt(1))
+	if _, ok := vm.PrecompiledStorageContracts[*tx.To()]; ok && pool.chainconfig.IsStorageTxProtected(next) {
+		return ErrUnprotectedTx
+	}
+	if _, ok := vm.PrecompiledDPoSContracts[*tx.To()]; ok && pool.chainconfig.IsDposTxProtected(next) {
+		return ErrUnprotectedTx
+	}
+	return nil
+}
+
+// validateCandidateTx checks the deposit of the candidate transaction against the minimum
+// candidate deposit defined in chain config at the next block. The malformed transaction
+// data is left for the execution to reject
+func (pool *TxPool) validateCandidateTx(tx *types.Transaction) error {
+	if tx.To() == nil || *tx.To() != vm.ApplyCandidateContractAddress {
+		return nil
+	}
+	var data types.AddCandidateTxData
+	if err := rlp.DecodeBytes(tx.Data(), &data); err != nil {
+		return nil
+	}
+	next := new(big.Int).Add(pool.chain.CurrentBlock().Number(), big.NewInt(1))
+	if data.Deposit.Cmp(pool.chainconfig.Dpos.MinCandidateDeposit(next)) < 0 {
+		return ErrCandidateInsufficientDeposit
+	}
 	return nil
 }
 
@@ -22,10 +22,12 @@ import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/state"
 	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/ethdb"
 	"github.com/DxChainNetwork/godx/event"
 	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
 	"io/ioutil"
 	"math/big"
 	"math/rand"
@@ -258,6 +260,61 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+func TestCandidateTransactionMinDeposit(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	candidateTx := func(nonce uint64, deposit common.BigInt) *types.Transaction {
+		data, _ := rlp.EncodeToBytes(&types.AddCandidateTxData{Deposit: deposit, RewardRatio: 50})
+		tx, _ := types.SignTx(types.NewTransaction(nonce, vm.ApplyCandidateContractAddress, big.NewInt(0), 100000, big.NewInt(1), data), types.HomesteadSigner{}, key)
+		return tx
+	}
+	minDeposit := params.TestChainConfig.Dpos.MinCandidateDeposit(big.NewInt(1))
+
+	tx := candidateTx(0, minDeposit.SubInt64(1))
+	from, _ := deriveSender(tx)
+	pool.currentState.AddBalance(from, big.NewInt(0xffffffffffffff))
+	if err := pool.AddRemote(tx); err != ErrCandidateInsufficientDeposit {
+		t.Error("expected", ErrCandidateInsufficientDeposit, "got", err)
+	}
+	if err := pool.AddRemote(candidateTx(0, minDeposit)); err != nil {
+		t.Error("expected", nil, "got", err)
+	}
+}
+
+func TestPrecompiledTransactionReplayProtection(t *testing.T) {
+	t.Parallel()
+
+	config := *params.TestChainConfig
+	config.ReplayProtection = &params.ReplayProtectionConfig{StorageTxs: true}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+	pool := NewTxPool(testTxPoolConfig, &config, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(0xffffffffffffff))
+	precompiledTx := func(nonce uint64, to common.Address, signer types.Signer) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, to, big.NewInt(0), 100000, big.NewInt(1), nil), signer, key)
+		return tx
+	}
+	storageTx := common.BytesToAddress([]byte{12})
+
+	// the storage contract tx must be signed with the chain id
+	if err := pool.AddRemote(precompiledTx(0, storageTx, types.HomesteadSigner{})); err != ErrUnprotectedTx {
+		t.Error("expected", ErrUnprotectedTx, "got", err)
+	}
+	if err := pool.AddRemote(precompiledTx(0, storageTx, types.NewEIP155Signer(config.ChainID))); err != nil {
+		t.Error("expected", nil, "got", err)
+	}
+	// the replay protection is not enforced on the dpos tx
+	if err := pool.AddRemote(precompiledTx(1, vm.CancelVoteContractAddress, types.HomesteadSigner{})); err != nil {
+		t.Error("expected", nil, "got", err)
+	}
+}
+
 func TestTransactionQueue(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,77 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+// +build gofuzz
+
+package types
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/ethdb"
+)
+
+// fuzzAddressCount is the number of the addresses the fuzzed operations are applied to.
+// A small number makes the operations on the same candidate and delegator more likely
+const fuzzAddressCount = 8
+
+// FuzzDposContext is the entry point for the go-fuzz tool, applying a sequence of operations
+// decoded from the input to the DposContext. Each operation is applied on a snapshot, which is
+// reverted on failure as in the transaction processing. Each two bytes of the input make an
+// operation: the first byte selects the operation and the address, and the second byte
+// selects the other addresses involved.
+//
+// It panics if the DposContext fails the invariants check after any operation
+func FuzzDposContext(input []byte) int {
+	if len(input) < 2 {
+		return -1
+	}
+	dc, err := NewDposContext(ethdb.NewMemDatabase())
+	if err != nil {
+		panic(err)
+	}
+	addresses := make([]common.Address, fuzzAddressCount)
+	for i := range addresses {
+		addresses[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+	}
+
+	for ; len(input) >= 2; input = input[2:] {
+		op, arg := input[0], input[1]
+		addr := addresses[int(op>>3)%fuzzAddressCount]
+
+		snapshot := dc.Snapshot()
+		switch op % 5 {
+		case 0:
+			err = dc.BecomeCandidate(addr)
+		case 1:
+			// each bit of arg selects whether the address is voted
+			var candidates []common.Address
+			for i := range addresses {
+				if arg&(1<<uint(i)) != 0 {
+					candidates = append(candidates, addresses[i])
+				}
+			}
+			_, err = dc.Vote(addr, candidates)
+		case 2:
+			err = dc.CancelVote(addr)
+		case 3:
+			err = dc.KickoutCandidate(addr)
+		default:
+			validators := make([]common.Address, 0, int(arg)%fuzzAddressCount+1)
+			for i := 0; i < cap(validators); i++ {
+				validators = append(validators, addresses[(int(op>>3)+i)%fuzzAddressCount])
+			}
+			err = dc.SetValidators(validators)
+		}
+		if err != nil {
+			dc.RevertToSnapShot(snapshot)
+		}
+		if err := dc.Validate(); err != nil {
+			panic(fmt.Sprintf("dpos context corrupted after operation %d on %s: %v", op%5, addr.String(), err))
+		}
+	}
+	return 1
+}
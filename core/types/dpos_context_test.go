@@ -5,6 +5,8 @@
 package types
 
 import (
+	"math/big"
+	"math/rand"
 	"testing"
 
 	"github.com/DxChainNetwork/godx/common"
@@ -204,3 +206,91 @@ func TestDposContext_GetVotedCandidatesByAddress(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, addresses, candidateListFromTrie)
 }
+
+func TestDposContextValidate(t *testing.T) {
+	delegator := common.HexToAddress("0x666")
+	newDposContext := func() *DposContext {
+		dposContext, err := NewDposContext(ethdb.NewMemDatabase())
+		assert.Nil(t, err)
+		for _, candidate := range addresses {
+			assert.Nil(t, dposContext.BecomeCandidate(candidate))
+		}
+		_, err = dposContext.Vote(delegator, addresses)
+		assert.Nil(t, err)
+		assert.Nil(t, dposContext.SetValidators(addresses))
+		return dposContext
+	}
+
+	// the dpos context updated through the methods is valid
+	dposContext := newDposContext()
+	assert.Nil(t, dposContext.Validate())
+	assert.Nil(t, dposContext.KickoutCandidate(addresses[0]))
+	assert.Nil(t, dposContext.Validate())
+	assert.Nil(t, dposContext.CancelVote(delegator))
+	assert.Nil(t, dposContext.Validate())
+
+	// the vote to the candidate deleted without kicking out
+	dposContext = newDposContext()
+	assert.Nil(t, dposContext.candidateTrie.TryDelete(addresses[0].Bytes()))
+	assert.NotNil(t, dposContext.Validate())
+
+	// the vote without the delegate record
+	dposContext = newDposContext()
+	assert.Nil(t, dposContext.delegateTrie.TryDelete(append(addresses[1].Bytes(), delegator.Bytes()...)))
+	assert.NotNil(t, dposContext.Validate())
+
+	// the delegate record not in the vote list
+	dposContext = newDposContext()
+	assert.Nil(t, dposContext.delegateTrie.TryUpdate(append(addresses[1].Bytes(), notExistAddress.Bytes()...), notExistAddress.Bytes()))
+	assert.NotNil(t, dposContext.Validate())
+
+	// the duplicate validators
+	dposContext = newDposContext()
+	assert.Nil(t, dposContext.SetValidators([]common.Address{addresses[0], addresses[0]}))
+	assert.NotNil(t, dposContext.Validate())
+}
+
+// TestDposContextSnapshotRevertValidate applies random operations to the dpos context, reverting
+// to the snapshot on failure or by chance, and checks the invariants after each operation
+func TestDposContextSnapshotRevertValidate(t *testing.T) {
+	dposContext, err := NewDposContext(ethdb.NewMemDatabase())
+	assert.Nil(t, err)
+	accounts := make([]common.Address, 6)
+	for i := range accounts {
+		accounts[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		addr := accounts[r.Intn(len(accounts))]
+		snapshot := dposContext.Snapshot()
+		root := snapshot.Root()
+
+		var err error
+		switch r.Intn(4) {
+		case 0:
+			err = dposContext.BecomeCandidate(addr)
+		case 1:
+			var candidates []common.Address
+			for _, candidate := range accounts {
+				if r.Intn(2) == 0 {
+					candidates = append(candidates, candidate)
+				}
+			}
+			_, err = dposContext.Vote(addr, candidates)
+		case 2:
+			err = dposContext.CancelVote(addr)
+		default:
+			err = dposContext.KickoutCandidate(addr)
+		}
+		if err != nil || r.Intn(4) == 0 {
+			dposContext.RevertToSnapShot(snapshot)
+			if dposContext.Root() != root {
+				t.Fatalf("operation %d: root %x after revert, expect %x", i, dposContext.Root(), root)
+			}
+		}
+		if err := dposContext.Validate(); err != nil {
+			t.Fatalf("operation %d: %v", i, err)
+		}
+	}
+}
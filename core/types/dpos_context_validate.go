@@ -0,0 +1,123 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package types
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/trie"
+)
+
+// Validate checks the invariants across the tries of the DposContext, returning an error
+// describing the first violation found:
+//
+//  1. each key in candidateTrie is a candidate address mapping to itself
+//  2. each candidate voted in voteTrie is a candidate, and has the delegate record in delegateTrie
+//  3. each record in delegateTrie belongs to a candidate, and is in the vote list of the delegator
+//  4. the epoch validators are not duplicated
+//
+// Note the epoch validators are not required to be candidates, since the validator could cancel
+// the candidate within the epoch. The check iterates all the tries, so it is only meant for
+// debugging and testing
+func (dc *DposContext) Validate() error {
+	// collect all candidates
+	candidates := make(map[common.Address]struct{})
+	iter := trie.NewIterator(dc.candidateTrie.NodeIterator(nil))
+	for iter.Next() {
+		key, ok := trimKeyPrefix(iter.Key, candidatePrefix, common.AddressLength)
+		if !ok || !bytes.Equal(key, iter.Value) {
+			return fmt.Errorf("invalid candidate record: key %x, value %x", iter.Key, iter.Value)
+		}
+		candidates[common.BytesToAddress(key)] = struct{}{}
+	}
+	if iter.Err != nil {
+		return fmt.Errorf("failed to iterate candidateTrie: %v", iter.Err)
+	}
+
+	// check the vote records against the candidates and the delegate records
+	votes := make(map[common.Address]map[common.Address]struct{})
+	iter = trie.NewIterator(dc.voteTrie.NodeIterator(nil))
+	for iter.Next() {
+		key, ok := trimKeyPrefix(iter.Key, votePrefix, common.AddressLength)
+		if !ok {
+			return fmt.Errorf("invalid delegator in voteTrie: %x", iter.Key)
+		}
+		delegator := common.BytesToAddress(key)
+		var votedCandidates []common.Address
+		if err := rlp.DecodeBytes(iter.Value, &votedCandidates); err != nil {
+			return fmt.Errorf("failed to decode the vote of %s: %v", delegator.String(), err)
+		}
+		voted := make(map[common.Address]struct{})
+		for _, candidate := range votedCandidates {
+			if _, exist := voted[candidate]; exist {
+				return fmt.Errorf("delegator %s voted candidate %s more than once", delegator.String(), candidate.String())
+			}
+			voted[candidate] = struct{}{}
+			if _, exist := candidates[candidate]; !exist {
+				return fmt.Errorf("delegator %s voted non-candidate %s", delegator.String(), candidate.String())
+			}
+			value, err := dc.delegateTrie.TryGet(append(candidate.Bytes(), delegator.Bytes()...))
+			if err != nil {
+				return fmt.Errorf("failed to retrieve from delegateTrie: %v", err)
+			}
+			if !bytes.Equal(value, delegator.Bytes()) {
+				return fmt.Errorf("no delegate record of delegator %s to candidate %s", delegator.String(), candidate.String())
+			}
+		}
+		votes[delegator] = voted
+	}
+	if iter.Err != nil {
+		return fmt.Errorf("failed to iterate voteTrie: %v", iter.Err)
+	}
+
+	// check the delegate records against the candidates and the vote records
+	iter = trie.NewIterator(dc.delegateTrie.NodeIterator(nil))
+	for iter.Next() {
+		key, ok := trimKeyPrefix(iter.Key, delegatePrefix, 2*common.AddressLength)
+		if !ok || !bytes.Equal(key[common.AddressLength:], iter.Value) {
+			return fmt.Errorf("invalid delegate record: key %x, value %x", iter.Key, iter.Value)
+		}
+		candidate := common.BytesToAddress(key[:common.AddressLength])
+		delegator := common.BytesToAddress(iter.Value)
+		if _, exist := candidates[candidate]; !exist {
+			return fmt.Errorf("delegate record of delegator %s to non-candidate %s", delegator.String(), candidate.String())
+		}
+		if _, exist := votes[delegator][candidate]; !exist {
+			return fmt.Errorf("delegate record of delegator %s to candidate %s not in the vote list", delegator.String(), candidate.String())
+		}
+	}
+	if iter.Err != nil {
+		return fmt.Errorf("failed to iterate delegateTrie: %v", iter.Err)
+	}
+
+	// check the epoch validators if set
+	if dc.epochTrie.Get(keyValidator) == nil {
+		return nil
+	}
+	validators, err := dc.GetValidators()
+	if err != nil {
+		return err
+	}
+	validatorMap := make(map[common.Address]struct{})
+	for _, validator := range validators {
+		if _, exist := validatorMap[validator]; exist {
+			return fmt.Errorf("duplicate validator %s", validator.String())
+		}
+		validatorMap[validator] = struct{}{}
+	}
+	return nil
+}
+
+// trimKeyPrefix removes the trie prefix from the key returned by the trie iterator, and checks
+// the length of the key left
+func trimKeyPrefix(key []byte, prefix []byte, length int) ([]byte, bool) {
+	if !bytes.HasPrefix(key, prefix) || len(key) != len(prefix)+length {
+		return nil, false
+	}
+	return key[len(prefix):], true
+}
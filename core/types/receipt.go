@@ -60,6 +60,9 @@ type Receipt struct {
 	TxHash          common.Hash    `json:"transactionHash" gencodec:"required"`
 	ContractAddress common.Address `json:"contractAddress"`
 	GasUsed         uint64         `json:"gasUsed" gencodec:"required"`
+
+	// ReturnData is the rlp encoded result returned by the precompiled contract transaction
+	ReturnData []byte `json:"returnData,omitempty"`
 }
 
 type receiptMarshaling struct {
@@ -67,6 +70,7 @@ type receiptMarshaling struct {
 	Status            hexutil.Uint64
 	CumulativeGasUsed hexutil.Uint64
 	GasUsed           hexutil.Uint64
+	ReturnData        hexutil.Bytes
 }
 
 // receiptRLP is the consensus encoding of a receipt.
@@ -85,6 +89,10 @@ type receiptStorageRLP struct {
 	ContractAddress   common.Address
 	Logs              []*LogForStorage
 	GasUsed           uint64
+
+	// ReturnData holds at most one element, the tail is used so that the receipts
+	// stored before the return data was introduced could still be decoded
+	ReturnData [][]byte `rlp:"tail"`
 }
 
 // NewReceipt creates a barebone transaction receipt, copying the init fields.
@@ -148,7 +156,7 @@ func (r *Receipt) statusEncoding() []byte {
 // size returns the approximate memory used by all internal contents. It is used
 // to approximate and limit the memory consumption of various caches.
 func (r *Receipt) Size() common.StorageSize {
-	size := common.StorageSize(unsafe.Sizeof(*r)) + common.StorageSize(len(r.PostState))
+	size := common.StorageSize(unsafe.Sizeof(*r)) + common.StorageSize(len(r.PostState)) + common.StorageSize(len(r.ReturnData))
 
 	size += common.StorageSize(len(r.Logs)) * common.StorageSize(unsafe.Sizeof(Log{}))
 	for _, log := range r.Logs {
@@ -176,6 +184,9 @@ func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
 	for i, log := range r.Logs {
 		enc.Logs[i] = (*LogForStorage)(log)
 	}
+	if len(r.ReturnData) != 0 {
+		enc.ReturnData = [][]byte{r.ReturnData}
+	}
 	return rlp.Encode(w, enc)
 }
 
@@ -197,6 +208,9 @@ func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
 	}
 	// Assign the implementation fields
 	r.TxHash, r.ContractAddress, r.GasUsed = dec.TxHash, dec.ContractAddress, dec.GasUsed
+	if len(dec.ReturnData) != 0 {
+		r.ReturnData = dec.ReturnData[0]
+	}
 	return nil
 }
 
@@ -226,6 +240,7 @@ func (r Receipt) MarshalJSON() ([]byte, error) {
 		TxHash            common.Hash    `json:"transactionHash" gencodec:"required"`
 		ContractAddress   common.Address `json:"contractAddress"`
 		GasUsed           hexutil.Uint64 `json:"gasUsed" gencodec:"required"`
+		ReturnData        hexutil.Bytes  `json:"returnData,omitempty"`
 	}
 	var enc Receipt
 	enc.PostState = r.PostState
@@ -236,6 +251,7 @@ func (r Receipt) MarshalJSON() ([]byte, error) {
 	enc.TxHash = r.TxHash
 	enc.ContractAddress = r.ContractAddress
 	enc.GasUsed = hexutil.Uint64(r.GasUsed)
+	enc.ReturnData = r.ReturnData
 	return json.Marshal(&enc)
 }
 
@@ -250,6 +266,7 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 		TxHash            *common.Hash    `json:"transactionHash" gencodec:"required"`
 		ContractAddress   *common.Address `json:"contractAddress"`
 		GasUsed           *hexutil.Uint64 `json:"gasUsed" gencodec:"required"`
+		ReturnData        *hexutil.Bytes  `json:"returnData,omitempty"`
 	}
 	var dec Receipt
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -284,5 +301,8 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 		return errors.New("missing required field 'gasUsed' for Receipt")
 	}
 	r.GasUsed = uint64(*dec.GasUsed)
+	if dec.ReturnData != nil {
+		r.ReturnData = *dec.ReturnData
+	}
 	return nil
 }
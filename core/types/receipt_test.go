@@ -40,7 +40,7 @@ var testReceiptJsonData = map[string]struct {
 		rlpStr:  common.FromHex("f901a6a0c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470830f4240b9010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000080040020000f87cf87a94ecf8f87f810ecf450940c9f60066b4a7a501d6a7f842a0ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3efa000000000000000000000000080b2c9d7cbbf30a1b0fc8983c647d754c6525615a0000000000000000000000000000000000000000000000001a055690d9db80000"),
 		jsonStr: `{"root":"0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470","status":"0x1","cumulativeGasUsed":"0xf4240","logsBloom":"0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000080040020000","logs":[{"address":"0xecf8f87f810ecf450940c9f60066b4a7a501d6a7","topics":["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef","0x00000000000000000000000080b2c9d7cbbf30a1b0fc8983c647d754c6525615"],"data":"0x000000000000000000000000000000000000000000000001a055690d9db80000","blockNumber":"0x1ecfa4","transactionHash":"0x3b198bfd5d2907285af009e9ae84a0ecd63677110d89d7e030251acb87f6487e","transactionIndex":"0x3","blockHash":"0x656c34545f90a730a19008c0e7a7cd4fb3895064b48d6d69761bd5abad681056","logIndex":"0x2","removed":false}],"transactionHash":"0x1111111111111111111111111111111111111111111111111111111111111111","contractAddress":"0x2222222222222222222222222222222222222222","gasUsed":"0x7a120"}`,
 		fullRlp: common.FromHex("f90228a0c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470830f4240b9010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000080040020000a01111111111111111111111111111111111111111111111111111111111111111942222222222222222222222222222222222222222f8c4f8c294ecf8f87f810ecf450940c9f60066b4a7a501d6a7f842a0ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3efa000000000000000000000000080b2c9d7cbbf30a1b0fc8983c647d754c6525615a0000000000000000000000000000000000000000000000001a055690d9db80000831ecfa4a03b198bfd5d2907285af009e9ae84a0ecd63677110d89d7e030251acb87f6487e03a0656c34545f90a730a19008c0e7a7cd4fb3895064b48d6d69761bd5abad681056028307a120"),
-		size:    common.StorageSize(680),
+		size:    common.StorageSize(704),
 		r: &Receipt{
 			PostState:         crypto.Keccak256(nil),
 			Status:            ReceiptStatusSuccessful,
@@ -57,7 +57,7 @@ var testReceiptJsonData = map[string]struct {
 		rlpStr:  common.FromHex("f90207a0ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff88ffffffffffffffffb90100fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8d8f85a94ecf8f87f810ecf450940c9f60066b4a7a501d6a7f842a0ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3efa000000000000000000000000080b2c9d7cbbf30a1b0fc8983c647d754c652561580f87a94ecf8f87f810ecf450940c9f60066b4a7a501d6a7f842a0ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3efa000000000000000000000000080b2c9d7cbbf30a1b0fc8983c647d754c6525615a0000000000000000000000000000000000000000000000001a055690d9db80000"),
 		jsonStr: `{"root":"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff","status":"0x0","cumulativeGasUsed":"0xffffffffffffffff","logsBloom":"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff","logs":[{"address":"0xecf8f87f810ecf450940c9f60066b4a7a501d6a7","topics":["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef","0x00000000000000000000000080b2c9d7cbbf30a1b0fc8983c647d754c6525615"],"data":"0x","blockNumber":"0x1ecfa4","transactionHash":"0x3b198bfd5d2907285af009e9ae84a0ecd63677110d89d7e030251acb87f6487e","transactionIndex":"0x3","blockHash":"0x656c34545f90a730a19008c0e7a7cd4fb3895064b48d6d69761bd5abad681056","logIndex":"0x2","removed":false},{"address":"0xecf8f87f810ecf450940c9f60066b4a7a501d6a7","topics":["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef","0x00000000000000000000000080b2c9d7cbbf30a1b0fc8983c647d754c6525615"],"data":"0x000000000000000000000000000000000000000000000001a055690d9db80000","blockNumber":"0x1ecfa4","transactionHash":"0x3b198bfd5d2907285af009e9ae84a0ecd63677110d89d7e030251acb87f6487e","transactionIndex":"0x3","blockHash":"0x656c34545f90a730a19008c0e7a7cd4fb3895064b48d6d69761bd5abad681056","logIndex":"0x2","removed":false}],"transactionHash":"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff","contractAddress":"0xffffffffffffffffffffffffffffffffffffffff","gasUsed":"0xffffffffffffffff"}`,
 		fullRlp: common.FromHex("f902d7a0ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff88ffffffffffffffffb90100ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffa0ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff94fffffffffffffffffffffffffffffffffffffffff90168f8a294ecf8f87f810ecf450940c9f60066b4a7a501d6a7f842a0ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3efa000000000000000000000000080b2c9d7cbbf30a1b0fc8983c647d754c652561580831ecfa4a03b198bfd5d2907285af009e9ae84a0ecd63677110d89d7e030251acb87f6487e03a0656c34545f90a730a19008c0e7a7cd4fb3895064b48d6d69761bd5abad68105602f8c294ecf8f87f810ecf450940c9f60066b4a7a501d6a7f842a0ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3efa000000000000000000000000080b2c9d7cbbf30a1b0fc8983c647d754c6525615a0000000000000000000000000000000000000000000000001a055690d9db80000831ecfa4a03b198bfd5d2907285af009e9ae84a0ecd63677110d89d7e030251acb87f6487e03a0656c34545f90a730a19008c0e7a7cd4fb3895064b48d6d69761bd5abad6810560288ffffffffffffffff"),
-		size:    common.StorageSize(912),
+		size:    common.StorageSize(936),
 		r: &Receipt{
 			PostState:         bytes.Repeat([]byte{0xff}, common.HashLength),
 			Status:            ReceiptStatusFailed,
@@ -100,7 +100,7 @@ var testReceiptJsonData = map[string]struct {
 		rlpStr:  common.FromHex("f901068080b9010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000c0"),
 		jsonStr: `{"root":"0x","status":"0x0","cumulativeGasUsed":"0x0","logsBloom":"0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000","logs":[],"transactionHash":"0x0001020300010203000102030001020300010203000102030001020300010203","contractAddress":"0x0001020300010203000102030001020300010203","gasUsed":"0x0"}`,
 		fullRlp: common.FromHex("f9013d8080b9010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000a00001020300010203000102030001020300010203000102030001020300010203940001020300010203000102030001020300010203c080"),
-		size:    common.StorageSize(384),
+		size:    common.StorageSize(408),
 		r: &Receipt{
 			PostState:         []byte{},
 			Status:            ReceiptStatusFailed,
@@ -116,7 +116,7 @@ var testReceiptJsonData = map[string]struct {
 		rlpStr:  common.FromHex("f9018601830f4240b9010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000080040020000f87cf87a94ecf8f87f810ecf450940c9f60066b4a7a501d6a7f842a0ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3efa000000000000000000000000080b2c9d7cbbf30a1b0fc8983c647d754c6525615a0000000000000000000000000000000000000000000000001a055690d9db80000"),
 		jsonStr: `{"status":"0x1","cumulativeGasUsed":"0xf4240","logsBloom":"0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000080040020000","logs":[{"address":"0xecf8f87f810ecf450940c9f60066b4a7a501d6a7","topics":["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef","0x00000000000000000000000080b2c9d7cbbf30a1b0fc8983c647d754c6525615"],"data":"0x000000000000000000000000000000000000000000000001a055690d9db80000","blockNumber":"0x1ecfa4","transactionHash":"0x3b198bfd5d2907285af009e9ae84a0ecd63677110d89d7e030251acb87f6487e","transactionIndex":"0x3","blockHash":"0x656c34545f90a730a19008c0e7a7cd4fb3895064b48d6d69761bd5abad681056","logIndex":"0x2","removed":false}],"transactionHash":"0x1111111111111111111111111111111111111111111111111111111111111111","contractAddress":"0x2222222222222222222222222222222222222222","gasUsed":"0x7a120"}`,
 		fullRlp: common.FromHex("f9020801830f4240b9010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000080040020000a01111111111111111111111111111111111111111111111111111111111111111942222222222222222222222222222222222222222f8c4f8c294ecf8f87f810ecf450940c9f60066b4a7a501d6a7f842a0ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3efa000000000000000000000000080b2c9d7cbbf30a1b0fc8983c647d754c6525615a0000000000000000000000000000000000000000000000001a055690d9db80000831ecfa4a03b198bfd5d2907285af009e9ae84a0ecd63677110d89d7e030251acb87f6487e03a0656c34545f90a730a19008c0e7a7cd4fb3895064b48d6d69761bd5abad681056028307a120"),
-		size:    common.StorageSize(648),
+		size:    common.StorageSize(672),
 		r: &Receipt{
 			Status:            ReceiptStatusSuccessful,
 			CumulativeGasUsed: uint64(1000000),
@@ -345,6 +345,35 @@ func TestReceiptForStorage_DecodeRLP(t *testing.T) {
 	}
 }
 
+// TestReceiptForStorage_ReturnData test the return data of the precompiled contract transaction
+// is stored along with the receipt
+func TestReceiptForStorage_ReturnData(t *testing.T) {
+	r := *testReceiptJsonData["ok"].r
+	r.ReturnData = []byte{0xc2, 0x01, 0x02}
+
+	enc, err := rlp.EncodeToBytes((*ReceiptForStorage)(&r))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rfs ReceiptForStorage
+	if err := rlp.DecodeBytes(enc, &rfs); err != nil {
+		t.Fatal(err)
+	}
+	CheckEquality(t, "ReturnData", "ReceiptForStorage", rfs, ReceiptForStorage(r))
+
+	js, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec Receipt
+	if err := json.Unmarshal(js, &dec); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dec.ReturnData, r.ReturnData) {
+		t.Errorf("return data not expected. Got %x, Expect %x", dec.ReturnData, r.ReturnData)
+	}
+}
+
 // TestReceipt_MarshalJSON test Receipt.MarshalJSON
 func TestReceipt_MarshalJSON(t *testing.T) {
 	for name, test := range testReceiptJsonData {
@@ -77,6 +77,20 @@ type StorageProof struct {
 	Signature []byte
 }
 
+// StorageProofBatch carries the storage proofs of multiple storage contracts in a single
+// transaction. Each storage proof is validated separately, and the transaction succeeds
+// if any of the storage proofs is accepted
+type StorageProofBatch struct {
+	Proofs []StorageProof `json:"proofs"`
+}
+
+// StorageContractRenewal settles the old storage contract as if the storage proof was
+// submitted and creates the new storage contract carrying over the file of the old one
+type StorageContractRenewal struct {
+	OldContractID common.Hash     `json:"oldcontractid"`
+	NewContract   StorageContract `json:"newcontract"`
+}
+
 // RLPHash calculate the hash of HostAnnouncement
 func (ha HostAnnouncement) RLPHash() common.Hash {
 	return rlpHash([]interface{}{
@@ -42,6 +42,10 @@ const (
 	CommitRevisionTransaction = "CommitRevision"
 	//StorageProofTransaction host storage proof  transaction tag
 	StorageProofTransaction = "StorageProof"
+	//RenewContractTransaction client contract renew transaction tag
+	RenewContractTransaction = "RenewContract"
+	//StorageProofBatchTransaction host storage proofs of multiple contracts transaction tag
+	StorageProofBatchTransaction = "StorageProofBatch"
 
 	// DPoS consensus transaction tags
 
@@ -78,6 +82,25 @@ var PrecompiledStorageContracts = map[common.Address]string{
 	common.BytesToAddress([]byte{10}): ContractCreateTransaction,
 	common.BytesToAddress([]byte{11}): CommitRevisionTransaction,
 	common.BytesToAddress([]byte{12}): StorageProofTransaction,
+	common.BytesToAddress([]byte{17}): RenewContractTransaction,
+	common.BytesToAddress([]byte{18}): StorageProofBatchTransaction,
+}
+
+// ActiveStorageContractTxType returns the tx type of the precompiled storage contract address
+// active at block num. The storage contracts activated by the forks are normal addresses
+// before the fork blocks
+func ActiveStorageContractTxType(config *params.ChainConfig, num *big.Int, addr common.Address) (string, bool) {
+	txType, ok := PrecompiledStorageContracts[addr]
+	if !ok {
+		return "", false
+	}
+	switch {
+	case txType == RenewContractTransaction && !config.IsRenewContract(num):
+		return "", false
+	case txType == StorageProofBatchTransaction && !config.IsStorageProofBatch(num):
+		return "", false
+	}
+	return txType, true
 }
 
 // PrecompiledDPoSContracts contains some tx types required for DPoS consensus
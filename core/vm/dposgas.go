@@ -0,0 +1,79 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// Storage operations of the dpos txs, each costs params.SstoreSetGas once the operation
+// gas is metered
+const (
+	// applying candidate writes the candidate trie, the deposit and the reward ratio
+	candidateTxOps uint64 = 3
+
+	// canceling candidate writes the candidate trie, the thawing record, the deposit and
+	// the reward ratio
+	cancelCandidateTxOps uint64 = 4
+
+	// voting writes a vote record for each candidate, the deposit and the vote epoch
+	voteTxOps uint64 = 2
+
+	// canceling vote writes the vote trie, the thawing record, the deposit and the vote epoch
+	cancelVoteTxOps uint64 = 4
+
+	// changing the deposit writes the frozen assets or the thawing record
+	depositChangeOps uint64 = 1
+)
+
+// DposTxGas returns the gas of the storage operations performed by the dpos tx of txType sent
+// from the address, on top of the intrinsic gas of the tx data. The gas depends on the number
+// of candidates voted and whether the deposit is changed against the state, and is charged
+// ahead of the execution once the operation gas is metered. Both the tx pool validation and
+// the execution use the function, so that the tx accepted by the pool has enough gas
+func DposTxGas(state StateDB, from common.Address, txType string, data []byte) (uint64, error) {
+	switch txType {
+	case ApplyCandidate:
+		var candidateData types.AddCandidateTxData
+		if err := rlp.DecodeBytes(data, &candidateData); err != nil {
+			return 0, err
+		}
+		return candidateTxGas(state, from, candidateData), nil
+	case CancelCandidate:
+		return cancelCandidateTxOps * params.SstoreSetGas, nil
+	case Vote:
+		var voteData types.VoteTxData
+		if err := rlp.DecodeBytes(data, &voteData); err != nil {
+			return 0, err
+		}
+		return voteTxGas(state, from, voteData), nil
+	case CancelVote:
+		return cancelVoteTxOps * params.SstoreSetGas, nil
+	default:
+		return 0, errUnknownDposOperationTx
+	}
+}
+
+// candidateTxGas returns the gas of applying candidate, including the decoding of the tx data
+func candidateTxGas(state StateDB, from common.Address, candidateData types.AddCandidateTxData) uint64 {
+	ops := candidateTxOps
+	if candidateData.Deposit.Cmp(dpos.GetCandidateDeposit(state, from)) > 0 {
+		ops += depositChangeOps
+	}
+	return params.DecodeGas + ops*params.SstoreSetGas
+}
+
+// voteTxGas returns the gas of voting, including the decoding of the tx data
+func voteTxGas(state StateDB, from common.Address, voteData types.VoteTxData) uint64 {
+	ops := voteTxOps + uint64(len(voteData.Candidates))
+	if voteData.Deposit.Cmp(dpos.GetVoteDeposit(state, from)) != 0 {
+		ops += depositChangeOps
+	}
+	return params.DecodeGas + ops*params.SstoreSetGas
+}
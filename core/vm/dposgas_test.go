@@ -0,0 +1,100 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+func TestDposTxGas(t *testing.T) {
+	from := common.HexToAddress("0x1")
+	stateDB := mockState(ethdb.NewMemDatabase(), mockAccountAlloc([]common.Address{from}))
+	deposit := common.NewBigIntUint64(1e18)
+	dpos.SetVoteDeposit(stateDB, from, deposit)
+	dpos.SetCandidateDeposit(stateDB, from, deposit)
+
+	// the EncodeRLP of the tx data are of the pointer receivers, thus the pointers shall be encoded
+	encode := func(val interface{}) []byte {
+		b, err := rlp.EncodeToBytes(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	candidates := []common.Address{common.HexToAddress("0x2"), common.HexToAddress("0x3"), common.HexToAddress("0x4")}
+
+	tests := []struct {
+		txType string
+		data   []byte
+		ops    uint64
+		decode bool
+	}{
+		{ApplyCandidate, encode(&types.AddCandidateTxData{Deposit: deposit, RewardRatio: 50}), 3, true},
+		{ApplyCandidate, encode(&types.AddCandidateTxData{Deposit: deposit.MultInt64(2), RewardRatio: 50}), 4, true},
+		{CancelCandidate, nil, 4, false},
+		{Vote, encode(&types.VoteTxData{Deposit: deposit, Candidates: candidates[:1]}), 3, true},
+		{Vote, encode(&types.VoteTxData{Deposit: deposit, Candidates: candidates}), 5, true},
+		{Vote, encode(&types.VoteTxData{Deposit: deposit.DivUint64(2), Candidates: candidates}), 6, true},
+		{CancelVote, nil, 4, false},
+	}
+	for i, test := range tests {
+		gas, err := DposTxGas(stateDB, from, test.txType, test.data)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		expect := test.ops * params.SstoreSetGas
+		if test.decode {
+			expect += params.DecodeGas
+		}
+		if gas != expect {
+			t.Errorf("test %d: gas not expected. Got %v, Expect %v", i, gas, expect)
+		}
+	}
+
+	if _, err := DposTxGas(stateDB, from, Vote, []byte{0x1}); err == nil {
+		t.Errorf("malformed tx data shall fail")
+	}
+	if _, err := DposTxGas(stateDB, from, "unknown", nil); err != errUnknownDposOperationTx {
+		t.Errorf("error not expected. Got %v, Expect %v", err, errUnknownDposOperationTx)
+	}
+}
+
+// TestEVM_MeteredDposTx test the operation gas of the dpos tx is charged no matter the tx
+// succeeds or not once the operation gas is metered, and the gas left is returned
+func TestEVM_MeteredDposTx(t *testing.T) {
+	evm, _, _, err := mockEvmAndState(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := *evm.chainConfig
+	config.Dpos = &params.DposConfig{OperationGasBlock: big.NewInt(100)}
+	evm.chainConfig = &config
+	evm.Time = big.NewInt(86400)
+
+	dposContext, err := types.NewDposContext(ethdb.NewMemDatabase())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opGas := cancelVoteTxOps * params.SstoreSetGas
+	_, gasLeft, _ := evm.ApplyDposTransaction(CancelVote, dposContext, common.HexToAddress("0x1"), nil, gasOrigin, big.NewInt(0))
+	if gasLeft != gasOrigin-opGas {
+		t.Errorf("gas left not expected. Got %v, Expect %v", gasLeft, gasOrigin-opGas)
+	}
+	_, gasLeft, err = evm.ApplyDposTransaction(CancelVote, dposContext, common.HexToAddress("0x1"), nil, opGas-1, big.NewInt(0))
+	if err != ErrOutOfGas {
+		t.Errorf("error not expected. Got %v, Expect %v", err, ErrOutOfGas)
+	}
+	if gasLeft != opGas-1 {
+		t.Errorf("gas left not expected. Got %v, Expect %v", gasLeft, opGas-1)
+	}
+}
@@ -41,6 +41,8 @@ var (
 
 	errUnknownStorageContractTx = errors.New("unknown storage contract tx")
 	errUnknownDposOperationTx   = errors.New("unknown dpos operation tx")
+	errNoStorageProofAccepted   = errors.New("none of the storage proofs in the batch is accepted")
+	errExecutionCancelled       = errors.New("execution cancelled")
 )
 
 type (
@@ -182,6 +184,11 @@ func (evm *EVM) Cancel() {
 	atomic.StoreInt32(&evm.abort, 1)
 }
 
+// Cancelled returns true if Cancel has been called
+func (evm *EVM) Cancelled() bool {
+	return atomic.LoadInt32(&evm.abort) == 1
+}
+
 // Interpreter returns the current interpreter
 func (evm *EVM) Interpreter() Interpreter {
 	return evm.interpreter
@@ -481,6 +488,9 @@ func (evm *EVM) ChainConfig() *params.ChainConfig { return evm.chainConfig }
 
 // ApplyStorageContractTransaction distinguish and execute transactions
 func (evm *EVM) ApplyStorageContractTransaction(caller ContractRef, txType string, data []byte, gas uint64) (ret []byte, leftOverGas uint64, err error) {
+	if evm.Cancelled() {
+		return nil, gas, errExecutionCancelled
+	}
 	stateSnap := evm.StateDB.Snapshot()
 	defer func() {
 		if err != nil {
@@ -497,6 +507,10 @@ func (evm *EVM) ApplyStorageContractTransaction(caller ContractRef, txType strin
 		return evm.CommitRevisionTx(caller, data, gas)
 	case StorageProofTransaction:
 		return evm.StorageProofTx(caller, data, gas)
+	case RenewContractTransaction:
+		return evm.RenewContractTx(caller, data, gas)
+	case StorageProofBatchTransaction:
+		return evm.StorageProofBatchTx(caller, data, gas)
 	default:
 		return nil, gas, errUnknownStorageContractTx
 	}
@@ -504,6 +518,9 @@ func (evm *EVM) ApplyStorageContractTransaction(caller ContractRef, txType strin
 
 // ApplyDposTransaction handlers all dpos consensus txs
 func (evm *EVM) ApplyDposTransaction(txType string, dposContext *types.DposContext, from common.Address, data []byte, gas uint64, value *big.Int) (ret []byte, leftOverGas uint64, err error) {
+	if evm.Cancelled() {
+		return nil, gas, errExecutionCancelled
+	}
 	dposSnap := dposContext.Snapshot()
 	stateSnap := evm.StateDB.Snapshot()
 	defer func() {
@@ -513,6 +530,10 @@ func (evm *EVM) ApplyDposTransaction(txType string, dposContext *types.DposConte
 		}
 	}()
 
+	if evm.chainConfig.Dpos.IsOperationGasMetered(evm.BlockNumber) {
+		return evm.applyMeteredDposTransaction(txType, dposContext, from, data, gas)
+	}
+
 	switch txType {
 	case ApplyCandidate:
 		return evm.CandidateTx(from, data, gas, dposContext)
@@ -527,12 +548,56 @@ func (evm *EVM) ApplyDposTransaction(txType string, dposContext *types.DposConte
 	}
 }
 
+// applyMeteredDposTransaction executes the dpos tx with the gas metered by the storage operations.
+// The operation gas is charged ahead of the execution no matter the tx succeeds or not, and the
+// gas left is refunded
+func (evm *EVM) applyMeteredDposTransaction(txType string, dposContext *types.DposContext, from common.Address, data []byte, gas uint64) ([]byte, uint64, error) {
+	switch txType {
+	case ApplyCandidate:
+		var candidateData types.AddCandidateTxData
+		if err := rlp.DecodeBytes(data, &candidateData); err != nil {
+			return nil, gas, err
+		}
+		ok, gasRemain := DeductGas(gas, candidateTxGas(evm.StateDB, from, candidateData))
+		if !ok {
+			return nil, gas, ErrOutOfGas
+		}
+		ret, err := evm.processCandidate(from, candidateData, dposContext)
+		return ret, gasRemain, err
+	case CancelCandidate:
+		ok, gasRemain := DeductGas(gas, cancelCandidateTxOps*params.SstoreSetGas)
+		if !ok {
+			return nil, gas, ErrOutOfGas
+		}
+		return nil, gasRemain, evm.processCancelCandidate(from, dposContext)
+	case Vote:
+		var voteData types.VoteTxData
+		if err := rlp.DecodeBytes(data, &voteData); err != nil {
+			return nil, gas, err
+		}
+		ok, gasRemain := DeductGas(gas, voteTxGas(evm.StateDB, from, voteData))
+		if !ok {
+			return nil, gas, ErrOutOfGas
+		}
+		ret, err := evm.processVote(from, voteData, dposContext)
+		return ret, gasRemain, err
+	case CancelVote:
+		ok, gasRemain := DeductGas(gas, cancelVoteTxOps*params.SstoreSetGas)
+		if !ok {
+			return nil, gas, ErrOutOfGas
+		}
+		return nil, gasRemain, evm.processCancelVote(from, dposContext)
+	default:
+		return nil, gas, errUnknownDposOperationTx
+	}
+}
+
 // HostAnnounceTx host declares its own information on the chain
 func (evm *EVM) HostAnnounceTx(caller ContractRef, data []byte, gas uint64) ([]byte, uint64, error) {
 	log.Trace("Enter host announce tx executing ... ")
 
 	ha := types.HostAnnouncement{}
-	gasDecode, resultDecode := RemainGas(gas, rlp.DecodeBytes, data, &ha)
+	gasDecode, resultDecode := RemainGas(gas, evm.decodeStoragePayload, data, &ha)
 	errDec, _ := resultDecode[0].(error)
 	if errDec != nil {
 		return nil, gasDecode, errDec
@@ -548,25 +613,46 @@ func (evm *EVM) HostAnnounceTx(caller ContractRef, data []byte, gas uint64) ([]b
 	log.Trace("Host announce tx execution done", "remain_gas", gasCheck, "host_address", ha.NetAddress)
 
 	// return remain gas if everything is ok
-	return nil, gasCheck, nil
+	return encodePrecompileResult(HostAnnounceResult{NetAddress: ha.NetAddress}), gasCheck, nil
 }
 
 // CreateContractTx executes contract creation tx
 func (evm *EVM) CreateContractTx(caller ContractRef, data []byte, gas uint64) ([]byte, uint64, error) {
 	log.Trace("Enter create contract tx executing ... ")
-	var (
-		stateDB  = evm.StateDB
-		snapshot = stateDB.Snapshot()
-	)
 
 	// rlp decode and calculate gas used
 	sc := types.StorageContract{}
-	gasRemainDecode, resultDecode := RemainGas(gas, rlp.DecodeBytes, data, &sc)
+	gasRemainDecode, resultDecode := RemainGas(gas, evm.decodeStoragePayload, data, &sc)
 	errDecode, _ := resultDecode[0].(error)
 	if errDecode != nil {
 		return nil, gasRemainDecode, errDecode
 	}
 
+	gasRemainCreate, err := evm.createStorageContract(sc, gasRemainDecode)
+	if err != nil {
+		return nil, gasRemainCreate, err
+	}
+
+	// return remain gas if everything is ok
+	scID := sc.ID()
+	log.Trace("Create contract tx execution done", "remain_gas", gasRemainCreate, "storage_contract_id", scID.Hex())
+	result := ContractCreateResult{
+		ContractID:      scID,
+		ContractAddress: common.BytesToAddress(scID[12:]),
+		WindowStart:     sc.WindowStart,
+		WindowEnd:       sc.WindowEnd,
+	}
+	return encodePrecompileResult(result), gasRemainCreate, nil
+}
+
+// createStorageContract checks the storage contract, locks the collateral and stores the
+// storage contract in the state
+func (evm *EVM) createStorageContract(sc types.StorageContract, gas uint64) (uint64, error) {
+	var (
+		stateDB  = evm.StateDB
+		snapshot = stateDB.Snapshot()
+	)
+
 	// create the expired storage contract status address (e.g. "expired_storage_contract_1500")
 	windowEndStr := strconv.FormatUint(sc.WindowEnd, 10)
 	statusAddr := common.BytesToAddress([]byte(coinchargemaintenance.StrPrefixExpSC + windowEndStr))
@@ -585,7 +671,7 @@ func (evm *EVM) CreateContractTx(caller ContractRef, data []byte, gas uint64) ([
 
 	// check if this storage contract exist
 	if stateDB.Exist(contractAddr) {
-		return nil, gasRemainDecode, errors.New("this storage contract already exist")
+		return gas, errors.New("this storage contract already exist")
 	}
 	stateDB.CreateAccount(contractAddr)
 
@@ -594,12 +680,12 @@ func (evm *EVM) CreateContractTx(caller ContractRef, data []byte, gas uint64) ([
 
 	// check form contract and calculate gas used
 	currentHeight := evm.BlockNumber.Uint64()
-	gasRemainCheck, resultCheck := RemainGas(gasRemainDecode, CheckCreateContract, stateDB, sc, uint64(currentHeight))
+	gasRemainCheck, resultCheck := RemainGas(gas, CheckCreateContract, stateDB, sc, uint64(currentHeight))
 	errCheck, _ := resultCheck[0].(error)
 	if errCheck != nil {
 		stateDB.RevertToSnapshot(snapshot)
 		log.Error("Failed to check create contract", "err", errCheck)
-		return nil, gasRemainCheck, errCheck
+		return gasRemainCheck, errCheck
 	}
 
 	// set balances
@@ -618,36 +704,10 @@ func (evm *EVM) CreateContractTx(caller ContractRef, data []byte, gas uint64) ([
 	stateDB.SetState(statusAddr, scID, common.BytesToHash(notProofedStatus))
 
 	// store storage contract in this contractAddr's stateDB
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyClientAddress, common.BytesToHash(sc.ClientCollateral.Address.Bytes()))
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyHostAddress, common.BytesToHash(sc.HostCollateral.Address.Bytes()))
-
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyClientCollateral, common.BytesToHash(sc.ClientCollateral.Value.Bytes()))
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyHostCollateral, common.BytesToHash(sc.HostCollateral.Value.Bytes()))
-
-	uintBytes := Uint64ToBytes(sc.FileSize)
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyFileSize, common.BytesToHash(uintBytes))
-
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyUnlockHash, sc.UnlockHash)
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyFileMerkleRoot, sc.FileMerkleRoot)
-
-	uintBytes = Uint64ToBytes(sc.RevisionNumber)
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyRevisionNumber, common.BytesToHash(uintBytes))
-
-	uintBytes = Uint64ToBytes(sc.WindowStart)
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyWindowStart, common.BytesToHash(uintBytes))
-
-	uintBytes = Uint64ToBytes(sc.WindowEnd)
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyWindowEnd, common.BytesToHash(uintBytes))
-
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyClientValidProofOutput, common.BytesToHash(sc.ValidProofOutputs[0].Value.Bytes()))
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyHostValidProofOutput, common.BytesToHash(sc.ValidProofOutputs[1].Value.Bytes()))
-
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyClientMissedProofOutput, common.BytesToHash(sc.MissedProofOutputs[0].Value.Bytes()))
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyHostMissedProofOutput, common.BytesToHash(sc.MissedProofOutputs[1].Value.Bytes()))
-
-	// return remain gas if everything is ok
-	log.Trace("Create contract tx execution done", "remain_gas", gasRemainCheck, "storage_contract_id", scID.Hex())
-	return nil, gasRemainCheck, nil
+	if err := evm.storageContractState().SetContract(contractAddr, sc); err != nil {
+		return gasRemainCheck, err
+	}
+	return gasRemainCheck, nil
 }
 
 // CommitRevisionTx host sends a revision transaction
@@ -658,7 +718,7 @@ func (evm *EVM) CommitRevisionTx(caller ContractRef, data []byte, gas uint64) ([
 	)
 
 	scr := types.StorageContractRevision{}
-	gasRemainDecode, resultDecode := RemainGas(gas, rlp.DecodeBytes, data, &scr)
+	gasRemainDecode, resultDecode := RemainGas(gas, evm.decodeStoragePayload, data, &scr)
 	errDec, _ := resultDecode[0].(error)
 	if errDec != nil {
 		return nil, gasRemainDecode, errDec
@@ -680,22 +740,18 @@ func (evm *EVM) CommitRevisionTx(caller ContractRef, data []byte, gas uint64) ([
 	}
 
 	// update revision info
-	uintBytes := Uint64ToBytes(scr.NewFileSize)
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyFileSize, common.BytesToHash(uintBytes))
-
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyFileMerkleRoot, scr.NewFileMerkleRoot)
-
-	uintBytes = Uint64ToBytes(scr.NewRevisionNumber)
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyRevisionNumber, common.BytesToHash(uintBytes))
-
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyClientValidProofOutput, common.BytesToHash(scr.NewValidProofOutputs[0].Value.Bytes()))
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyHostValidProofOutput, common.BytesToHash(scr.NewValidProofOutputs[1].Value.Bytes()))
-
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyClientMissedProofOutput, common.BytesToHash(scr.NewMissedProofOutputs[0].Value.Bytes()))
-	stateDB.SetState(contractAddr, coinchargemaintenance.KeyHostMissedProofOutput, common.BytesToHash(scr.NewMissedProofOutputs[1].Value.Bytes()))
+	if err := evm.storageContractState().ApplyRevision(contractAddr, scr); err != nil {
+		return nil, gasRemainCheck, err
+	}
 
 	log.Trace("Storage contract reversion tx execution done", "remain_gas", gasRemainCheck, "storage_contract_id", scr.ParentID.Hex())
-	return nil, gasRemainCheck, nil
+	result := CommitRevisionResult{
+		ContractID:     scr.ParentID,
+		RevisionNumber: scr.NewRevisionNumber,
+		FileSize:       scr.NewFileSize,
+		FileMerkleRoot: scr.NewFileMerkleRoot,
+	}
+	return encodePrecompileResult(result), gasRemainCheck, nil
 }
 
 // StorageProofTx host send storage certificate transaction
@@ -706,7 +762,7 @@ func (evm *EVM) StorageProofTx(caller ContractRef, data []byte, gas uint64) ([]b
 	)
 
 	sp := types.StorageProof{}
-	gasRemainDec, resultDec := RemainGas(gas, rlp.DecodeBytes, data, &sp)
+	gasRemainDec, resultDec := RemainGas(gas, evm.decodeStoragePayload, data, &sp)
 	errDec, _ := resultDec[0].(error)
 	if errDec != nil {
 		return nil, gasRemainDec, errDec
@@ -719,16 +775,12 @@ func (evm *EVM) StorageProofTx(caller ContractRef, data []byte, gas uint64) ([]b
 		return nil, gasRemainDec, errors.New("no this storage contract account")
 	}
 
-	// retrieve origin data in storage contract
-	windowEndHash := stateDB.GetState(contractAddr, coinchargemaintenance.KeyWindowEnd)
-	clientValidOutputHash := stateDB.GetState(contractAddr, coinchargemaintenance.KeyClientValidProofOutput)
-	hostValidOutputHash := stateDB.GetState(contractAddr, coinchargemaintenance.KeyHostValidProofOutput)
-	clientAddressHash := stateDB.GetState(contractAddr, coinchargemaintenance.KeyClientAddress)
-	hostAddressHash := stateDB.GetState(contractAddr, coinchargemaintenance.KeyHostAddress)
-
 	// get status account address
-	windowEnd := new(big.Int).SetBytes(windowEndHash.Bytes()).Uint64()
-	windowEndStr := strconv.FormatUint(windowEnd, 10)
+	sc, err := evm.storageContractState().GetContract(contractAddr)
+	if err != nil {
+		return nil, gasRemainDec, err
+	}
+	windowEndStr := strconv.FormatUint(sc.WindowEnd, 10)
 	statusAddr := common.BytesToAddress([]byte(coinchargemaintenance.StrPrefixExpSC + windowEndStr))
 
 	gasRemainCheck, resultCheck := RemainGas(gasRemainDec, CheckStorageProof, stateDB, sp, uint64(currentHeight), statusAddr, contractAddr)
@@ -737,13 +789,153 @@ func (evm *EVM) StorageProofTx(caller ContractRef, data []byte, gas uint64) ([]b
 		return nil, gasRemainCheck, errCheck
 	}
 
+	clientPayout, hostPayout := evm.settleStorageContract(sp.ParentID, contractAddr, statusAddr, sc)
+
+	log.Trace("Storage proof tx execution done", "storage_contract_id", sp.ParentID.Hex())
+	result := StorageProofResult{
+		ContractID:   sp.ParentID,
+		ClientPayout: common.PtrBigInt(clientPayout),
+		HostPayout:   common.PtrBigInt(hostPayout),
+	}
+	return encodePrecompileResult(result), gasRemainCheck, nil
+}
+
+// StorageProofBatchTx host sends the storage proofs of multiple storage contracts in a single
+// transaction. Each storage proof is checked and settled separately, the rejected ones are
+// recorded in the result without affecting the others. The transaction fails only if the gas
+// runs out or none of the storage proofs is accepted
+func (evm *EVM) StorageProofBatchTx(caller ContractRef, data []byte, gas uint64) ([]byte, uint64, error) {
+	log.Trace("Enter storage proof batch tx executing ... ")
+	var (
+		stateDB = evm.StateDB
+	)
+
+	batch := types.StorageProofBatch{}
+	gasRemain, resultDec := RemainGas(gas, evm.decodeStoragePayload, data, &batch)
+	errDec, _ := resultDec[0].(error)
+	if errDec != nil {
+		return nil, gasRemain, errDec
+	}
+
+	currentHeight := evm.BlockNumber.Uint64()
+	scs := evm.storageContractState()
+
+	var result StorageProofBatchResult
+	for _, sp := range batch.Proofs {
+		// the simulation of the batch could be cancelled by the RPC timeout
+		if evm.Cancelled() {
+			return nil, gasRemain, errExecutionCancelled
+		}
+		contractAddr := common.BytesToAddress(sp.ParentID[12:])
+		if !stateDB.Exist(contractAddr) {
+			result.Failed = append(result.Failed, StorageProofFailure{ContractID: sp.ParentID, Reason: "no this storage contract account"})
+			continue
+		}
+
+		sc, err := scs.GetContract(contractAddr)
+		if err != nil {
+			result.Failed = append(result.Failed, StorageProofFailure{ContractID: sp.ParentID, Reason: err.Error()})
+			continue
+		}
+		windowEndStr := strconv.FormatUint(sc.WindowEnd, 10)
+		statusAddr := common.BytesToAddress([]byte(coinchargemaintenance.StrPrefixExpSC + windowEndStr))
+
+		var resultCheck []interface{}
+		gasRemain, resultCheck = RemainGas(gasRemain, CheckStorageProof, stateDB, sp, uint64(currentHeight), statusAddr, contractAddr)
+		errCheck, _ := resultCheck[0].(error)
+		if errCheck == errGasCalculationInsufficient {
+			return nil, gasRemain, errCheck
+		}
+		if errCheck != nil {
+			result.Failed = append(result.Failed, StorageProofFailure{ContractID: sp.ParentID, Reason: errCheck.Error()})
+			continue
+		}
+
+		clientPayout, hostPayout := evm.settleStorageContract(sp.ParentID, contractAddr, statusAddr, sc)
+		result.Proofs = append(result.Proofs, StorageProofResult{
+			ContractID:   sp.ParentID,
+			ClientPayout: common.PtrBigInt(clientPayout),
+			HostPayout:   common.PtrBigInt(hostPayout),
+		})
+	}
+	if len(result.Proofs) == 0 {
+		return nil, gasRemain, errNoStorageProofAccepted
+	}
+
+	log.Trace("Storage proof batch tx execution done", "accepted", len(result.Proofs), "rejected", len(result.Failed))
+	return encodePrecompileResult(result), gasRemain, nil
+}
+
+// RenewContractTx settles the old storage contract as if the storage proof was submitted and
+// creates the new storage contract in the same transaction, so that the file is never left
+// without a storage contract between the expiration of the old one and the creation of the new one
+func (evm *EVM) RenewContractTx(caller ContractRef, data []byte, gas uint64) ([]byte, uint64, error) {
+	log.Trace("Enter renew contract tx executing ... ")
+	var (
+		stateDB = evm.StateDB
+	)
+
+	renewal := types.StorageContractRenewal{}
+	gasRemainDec, resultDec := RemainGas(gas, evm.decodeStoragePayload, data, &renewal)
+	errDec, _ := resultDec[0].(error)
+	if errDec != nil {
+		return nil, gasRemainDec, errDec
+	}
+
+	contractAddr := common.BytesToAddress(renewal.OldContractID[12:])
+	if !stateDB.Exist(contractAddr) {
+		return nil, gasRemainDec, errors.New("no this storage contract account")
+	}
+
+	currentHeight := evm.BlockNumber.Uint64()
+	gasRemainCheck, resultCheck := RemainGas(gasRemainDec, CheckRenewContract, stateDB, renewal, uint64(currentHeight))
+	errCheck, _ := resultCheck[0].(error)
+	if errCheck != nil {
+		log.Error("Failed to check renew contract", "err", errCheck)
+		return nil, gasRemainCheck, errCheck
+	}
+
+	// settle the old storage contract first, so that the valid proof outputs returned could be
+	// used as the collateral of the new storage contract
+	oldContract, err := evm.storageContractState().GetContract(contractAddr)
+	if err != nil {
+		return nil, gasRemainCheck, err
+	}
+	windowEndStr := strconv.FormatUint(oldContract.WindowEnd, 10)
+	statusAddr := common.BytesToAddress([]byte(coinchargemaintenance.StrPrefixExpSC + windowEndStr))
+	evm.settleStorageContract(renewal.OldContractID, contractAddr, statusAddr, oldContract)
+
+	// any error afterwards will revert the settlement in ApplyStorageContractTransaction
+	gasRemainCreate, err := evm.createStorageContract(renewal.NewContract, gasRemainCheck)
+	if err != nil {
+		return nil, gasRemainCreate, err
+	}
+
+	newID := renewal.NewContract.ID()
+	log.Trace("Renew contract tx execution done", "remain_gas", gasRemainCreate, "old_storage_contract_id", renewal.OldContractID.Hex(), "new_storage_contract_id", newID.Hex())
+	result := RenewContractResult{
+		OldContractID:      renewal.OldContractID,
+		NewContractID:      newID,
+		NewContractAddress: common.BytesToAddress(newID[12:]),
+	}
+	return encodePrecompileResult(result), gasRemainCreate, nil
+}
+
+// settleStorageContract pays the valid proof outputs of the storage contract sc read from the
+// state and marks it as proofed. The valid proof outputs paid to the client and the host are returned
+func (evm *EVM) settleStorageContract(scID common.Hash, contractAddr, statusAddr common.Address, sc types.StorageContract) (*big.Int, *big.Int) {
+	var (
+		stateDB = evm.StateDB
+	)
+
+	// retrieve origin data in storage contract
+	clientValidOutput := sc.ValidProofOutputs[0].Value
+	hostValidOutput := sc.ValidProofOutputs[1].Value
+	clientAddress := sc.ClientCollateral.Address
+	hostAddress := sc.HostCollateral.Address
+
 	// effect valid proof outputs, first for client, second for host
-	clientValidOutput := new(big.Int).SetBytes(clientValidOutputHash.Bytes())
-	clientAddress := common.BytesToAddress(clientAddressHash.Bytes())
 	stateDB.AddBalance(clientAddress, clientValidOutput)
-
-	hostValidOutput := new(big.Int).SetBytes(hostValidOutputHash.Bytes())
-	hostAddress := common.BytesToAddress(hostAddressHash.Bytes())
 	stateDB.AddBalance(hostAddress, hostValidOutput)
 
 	totalValue := new(big.Int).SetInt64(0)
@@ -752,13 +944,25 @@ func (evm *EVM) StorageProofTx(caller ContractRef, data []byte, gas uint64) ([]b
 
 	// set completed for this storage contract
 	proofedStatus := append(coinchargemaintenance.ProofedStatus, contractAddr[:]...)
-	stateDB.SetState(statusAddr, sp.ParentID, common.BytesToHash(proofedStatus))
+	stateDB.SetState(statusAddr, scID, common.BytesToHash(proofedStatus))
 
 	// this contract is finished, so mark it empty account that will be deleted by stateDB
 	stateDB.SetNonce(contractAddr, 0)
+	evm.storageContractState().DeleteRecord(contractAddr)
+	return clientValidOutput, hostValidOutput
+}
 
-	log.Trace("Storage proof tx execution done", "storage_contract_id", sp.ParentID.Hex())
-	return nil, gasRemainCheck, nil
+// storageContractState returns the accessor of the storage contracts in the state, which writes
+// the storage contracts as the single record since the contract record fork
+func (evm *EVM) storageContractState() *coinchargemaintenance.StorageContractState {
+	record := evm.chainConfig.IsContractRecord(evm.BlockNumber)
+	return coinchargemaintenance.NewStorageContractState(evm.StateDB).WithRecord(record)
+}
+
+// decodeStoragePayload decodes the payload of the storage contract tx, whose sizes are checked
+// since the storage payload limit fork
+func (evm *EVM) decodeStoragePayload(data []byte, val interface{}) error {
+	return DecodeStoragePayloadAt(evm.chainConfig, evm.BlockNumber, data, val)
 }
 
 // Uint64ToBytes convert uint64 to bytes
@@ -777,8 +981,8 @@ func (evm *EVM) CandidateTx(caller common.Address, data []byte, gas uint64, dpos
 	if errDec != nil {
 		return nil, gasRemainDec, errDec
 	}
-	// Add candidate in dpos
-	if err := dpos.ProcessAddCandidate(evm.StateDB, dposContext, caller, voteData.Deposit, voteData.RewardRatio); err != nil {
+	ret, err := evm.processCandidate(caller, *voteData, dposContext)
+	if err != nil {
 		return nil, gasRemainDec, err
 	}
 	// defines that dposCtx.BecomeCandidate and SetState all cost params.SstoreSetGas
@@ -788,13 +992,22 @@ func (evm *EVM) CandidateTx(caller common.Address, data []byte, gas uint64, dpos
 	}
 
 	log.Trace("Candidate tx execution done")
-	return nil, gasRemain, nil
+	return ret, gasRemain, nil
+}
+
+// processCandidate adds the caller as a candidate with the deposit and reward ratio
+func (evm *EVM) processCandidate(caller common.Address, candidateData types.AddCandidateTxData, dposContext *types.DposContext) ([]byte, error) {
+	minDeposit := evm.chainConfig.Dpos.MinCandidateDeposit(evm.BlockNumber)
+	if err := dpos.ProcessAddCandidate(evm.StateDB, dposContext, caller, candidateData.Deposit, candidateData.RewardRatio, minDeposit); err != nil {
+		return nil, err
+	}
+	return encodePrecompileResult(CandidateResult{Deposit: candidateData.Deposit, RewardRatio: candidateData.RewardRatio}), nil
 }
 
 // CandidateCancelTx cancellation of candidate thawing assets requires a defrosting period.
 func (evm *EVM) CandidateCancelTx(caller common.Address, gas uint64, dposContext *types.DposContext) ([]byte, uint64, error) {
 	log.Trace("Enter cancel candidate tx executing ... ")
-	if err := dpos.ProcessCancelCandidate(evm.StateDB, dposContext, caller, evm.Time.Int64()); err != nil {
+	if err := evm.processCancelCandidate(caller, dposContext); err != nil {
 		return nil, gas, err
 	}
 	// defines that dposCtx.KickoutCandidate and markThawingAddress all cost params.SstoreSetGas
@@ -806,6 +1019,12 @@ func (evm *EVM) CandidateCancelTx(caller common.Address, gas uint64, dposContext
 	return nil, gasRemain, nil
 }
 
+// processCancelCandidate cancels the caller being a candidate and thaws the deposit
+func (evm *EVM) processCancelCandidate(caller common.Address, dposContext *types.DposContext) error {
+	validatorLock := evm.chainConfig.Dpos.IsValidatorLocked(evm.BlockNumber)
+	return dpos.ProcessCancelCandidate(evm.StateDB, dposContext, caller, evm.epochID(), validatorLock)
+}
+
 // VoteTx handles a new vote to some candidates that will remove last vote records
 func (evm *EVM) VoteTx(caller common.Address, dposCtx *types.DposContext, data []byte, gas uint64) ([]byte, uint64, error) {
 	log.Trace("Enter vote tx executing ... ")
@@ -815,7 +1034,7 @@ func (evm *EVM) VoteTx(caller common.Address, dposCtx *types.DposContext, data [
 	if errDec != nil {
 		return nil, gasRemainDec, errDec
 	}
-	successVote, err := dpos.ProcessVote(evm.StateDB, dposCtx, caller, voteData.Deposit, voteData.Candidates, evm.Time.Int64())
+	ret, err := evm.processVote(caller, *voteData, dposCtx)
 	if err != nil {
 		return nil, gasRemainDec, err
 	}
@@ -824,16 +1043,25 @@ func (evm *EVM) VoteTx(caller common.Address, dposCtx *types.DposContext, data [
 	if !ok {
 		return nil, gasRemainDec, ErrOutOfGas
 	}
+	return ret, gasRemain, nil
+}
+
+// processVote votes the candidates with the deposit, replacing the last vote of the caller
+func (evm *EVM) processVote(caller common.Address, voteData types.VoteTxData, dposCtx *types.DposContext) ([]byte, error) {
+	candidateCheck := evm.chainConfig.Dpos.IsVoteCandidateChecked(evm.BlockNumber)
+	voteExpiration := evm.chainConfig.Dpos.VoteExpirationAt(evm.BlockNumber) != nil
+	successVote, err := dpos.ProcessVote(evm.StateDB, dposCtx, caller, voteData.Deposit, voteData.Candidates, evm.epochID(), candidateCheck, voteExpiration)
+	if err != nil {
+		return nil, err
+	}
 	log.Trace("Vote tx execution done", "vote_count", successVote)
-	return nil, gasRemain, nil
+	return encodePrecompileResult(VoteResult{Deposit: voteData.Deposit, VoteCount: uint64(successVote)}), nil
 }
 
 // CancelVoteTx handles a cancel vote tx that will remove all vote records
 func (evm *EVM) CancelVoteTx(caller common.Address, dposCtx *types.DposContext, gas uint64) ([]byte, uint64, error) {
 	log.Trace("Enter cancel vote tx executing ... ")
-	// remove all vote record from dpos context
-
-	if err := dpos.ProcessCancelVote(evm.StateDB, dposCtx, caller, evm.Time.Int64()); err != nil {
+	if err := evm.processCancelVote(caller, dposCtx); err != nil {
 		return nil, gas, err
 	}
 	ok, gasRemain := DeductGas(gas, params.SstoreSetGas*2)
@@ -844,3 +1072,14 @@ func (evm *EVM) CancelVoteTx(caller common.Address, dposCtx *types.DposContext,
 	log.Trace("Cancel vote tx execution done")
 	return nil, gasRemain, nil
 }
+
+// epochID returns the dpos epoch of the block the tx is executed in
+func (evm *EVM) epochID() int64 {
+	return dpos.CalculateEpochID(evm.Time.Int64(), evm.chainConfig.Dpos.EpochPeriod())
+}
+
+// processCancelVote removes all vote records of the caller and thaws the deposit
+func (evm *EVM) processCancelVote(caller common.Address, dposCtx *types.DposContext) error {
+	voteExpiration := evm.chainConfig.Dpos.VoteExpirationAt(evm.BlockNumber) != nil
+	return dpos.ProcessCancelVote(evm.StateDB, dposCtx, caller, evm.epochID(), voteExpiration)
+}
@@ -632,6 +632,175 @@ func TestEVM_StorageProofTx(t *testing.T) {
 
 }
 
+func TestEVM_StorageProofBatchTx(t *testing.T) {
+
+	// mock evm, state, client and host address ...
+	evm, stateDB, prvAndAddresses, err := mockEvmAndState(1101)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// mock block hash at height 1101 into DB
+	db := stateDB.Database().TrieDB().DiskDB().(ethdb.Database)
+	mockBlockHash := common.HexToHash("0x877c3a381d5ad88ca76a7b3e33ab1611939de59c56c0506efb9021593618f6ab")
+	rawdb.WriteCanonicalHash(db, mockBlockHash, uint64(1000))
+
+	sc, err := mockStorageContract(prvAndAddresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockWriteStorageContractIntoState(*sc, stateDB)
+	sp, err := mockStorageProof(prvAndAddresses[1].Privkey, sc.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown, err := mockStorageProof(prvAndAddresses[1].Privkey, common.HexToHash("0x01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the batch without any valid storage proof should fail
+	rlpBytes, err := rlp.EncodeToBytes(types.StorageProofBatch{Proofs: []types.StorageProof{*unknown}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := evm.ApplyStorageContractTransaction(AccountRef{}, StorageProofBatchTransaction, rlpBytes, gasOrigin); err != errNoStorageProofAccepted {
+		t.Fatalf("error not expected. Got %v, Expect %v", err, errNoStorageProofAccepted)
+	}
+
+	// the valid storage proof is accepted, while the unknown contract and the repeated proof are rejected
+	rlpBytes, err = rlp.EncodeToBytes(types.StorageProofBatch{Proofs: []types.StorageProof{*sp, *unknown, *sp}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, gasLeft, err := evm.ApplyStorageContractTransaction(AccountRef{}, StorageProofBatchTransaction, rlpBytes, gasOrigin)
+	if err != nil {
+		t.Fatalf("failed to execute storage proof batch tx,error: %v", err)
+	}
+	if expect := gasOrigin - params.DecodeGas - 2*params.CheckFileGas; gasLeft != expect {
+		t.Errorf("gas left is not right after executing storage proof batch tx,wanted %d,getted %d", expect, gasLeft)
+	}
+
+	decoded, err := DecodePrecompileResult(StorageProofBatchTransaction, ret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := decoded.(*StorageProofBatchResult)
+	if len(result.Proofs) != 1 || result.Proofs[0].ContractID != sc.ID() {
+		t.Errorf("accepted storage proofs not expected. Got %+v", result.Proofs)
+	}
+	if len(result.Failed) != 2 || result.Failed[0].ContractID != unknown.ParentID || result.Failed[1].ContractID != sc.ID() {
+		t.Errorf("rejected storage proofs not expected. Got %+v", result.Failed)
+	}
+	if !coinchargemaintenance.NewStorageContractState(stateDB).Proofed(sc.ID(), sc.WindowEnd) {
+		t.Errorf("failed to set contract proofed after executing storage proof batch tx")
+	}
+}
+
+func TestEVM_RenewContractTx(t *testing.T) {
+
+	// mock evm, state, client and host address ...
+	evm, stateDB, prvAndAddresses, err := mockEvmAndState(1050)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// write the old storage contract into state with the collateral locked
+	oldSC, err := mockStorageContract(prvAndAddresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mockWriteStorageContractIntoState(*oldSC, stateDB)
+	oldContractAddr := common.BytesToAddress(oldSC.ID().Bytes()[12:])
+	stateDB.AddBalance(oldContractAddr, new(big.Int).Add(clientCollateral, hostCollateral))
+
+	// the renewed storage contract with the file carried over
+	newSC, err := mockRenewedStorageContract(*oldSC, prvAndAddresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the renewal carrying a different file should be rejected, and the old contract is not settled
+	badSC := *newSC
+	badSC.FileMerkleRoot = common.HexToHash("0x01")
+	rlpBytes, err := rlp.EncodeToBytes(types.StorageContractRenewal{OldContractID: oldSC.ID(), NewContract: badSC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := evm.ApplyStorageContractTransaction(AccountRef{}, RenewContractTransaction, rlpBytes, gasOrigin); err != errRenewFileMismatch {
+		t.Fatalf("error not expected. Got %v, Expect %v", err, errRenewFileMismatch)
+	}
+	scs := coinchargemaintenance.NewStorageContractState(stateDB)
+	if scs.Proofed(oldSC.ID(), oldSC.WindowEnd) {
+		t.Fatalf("the old storage contract should not be settled after the renewal failed")
+	}
+
+	rlpBytes, err = rlp.EncodeToBytes(types.StorageContractRenewal{OldContractID: oldSC.ID(), NewContract: *newSC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, gasLeft, err := evm.ApplyStorageContractTransaction(AccountRef{}, RenewContractTransaction, rlpBytes, gasOrigin)
+	if err != nil {
+		t.Fatalf("failed to execute renew contract tx,error: %v", err)
+	}
+	if expect := gasOrigin - params.DecodeGas - 2*params.CheckFileGas; gasLeft != expect {
+		t.Errorf("gas left is not right after executing renew contract tx,wanted %d,getted %d", expect, gasLeft)
+	}
+
+	// the old storage contract should be settled as proofed
+	if !scs.Proofed(oldSC.ID(), oldSC.WindowEnd) {
+		t.Errorf("the old storage contract is not settled after renew")
+	}
+	if stateDB.GetBalance(oldContractAddr).Sign() != 0 {
+		t.Errorf("the valid proof outputs of the old storage contract are not paid, balance left %v", stateDB.GetBalance(oldContractAddr))
+	}
+
+	// the new storage contract should carry the file of the old one
+	newContractAddr := common.BytesToAddress(newSC.ID().Bytes()[12:])
+	renewed, err := scs.GetContract(newContractAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed.FileMerkleRoot != oldSC.FileMerkleRoot || renewed.WindowEnd != newSC.WindowEnd {
+		t.Errorf("renewed storage contract not expected. Got %+v", renewed)
+	}
+
+	// valid proof outputs of the old contract are used as the collateral of the new one
+	if balance := stateDB.GetBalance(prvAndAddresses[0].Address); balance.Cmp(balanceOrigin) != 0 {
+		t.Errorf("client balance is not right after executing renew contract tx,wanted %v,getted %v", balanceOrigin, balance)
+	}
+	if balance := stateDB.GetBalance(prvAndAddresses[1].Address); balance.Cmp(balanceOrigin) != 0 {
+		t.Errorf("host balance is not right after executing renew contract tx,wanted %v,getted %v", balanceOrigin, balance)
+	}
+}
+
+// TestEVM_CancelledPrecompile test the precompiled contracts are not executed after the evm
+// is cancelled, e.g. by the timeout of the RPC call simulating the transaction
+func TestEVM_CancelledPrecompile(t *testing.T) {
+	evm, _, _, err := mockEvmAndState(1101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evm.Cancelled() {
+		t.Fatalf("evm shall not be cancelled")
+	}
+	evm.Cancel()
+	if !evm.Cancelled() {
+		t.Fatalf("evm shall be cancelled")
+	}
+
+	_, gasLeft, err := evm.ApplyStorageContractTransaction(AccountRef{}, StorageProofBatchTransaction, nil, gasOrigin)
+	if err != errExecutionCancelled {
+		t.Fatalf("error not expected. Got %v, Expect %v", err, errExecutionCancelled)
+	}
+	if gasLeft != gasOrigin {
+		t.Errorf("gas left not expected. Got %v, Expect %v", gasLeft, gasOrigin)
+	}
+	if _, _, err = evm.ApplyDposTransaction(Vote, nil, common.Address{}, nil, gasOrigin, big.NewInt(0)); err != errExecutionCancelled {
+		t.Fatalf("error not expected. Got %v, Expect %v", err, errExecutionCancelled)
+	}
+}
+
 func mockAccountAlloc(addrs []common.Address) AccountAlloc {
 	accounts := make(AccountAlloc)
 	for _, addr := range addrs {
@@ -749,6 +918,26 @@ func mockStorageContract(prvAndAddresses []PrivkeyAddress) (*types.StorageContra
 	return sc, nil
 }
 
+func mockRenewedStorageContract(sc types.StorageContract, prvAndAddresses []PrivkeyAddress) (*types.StorageContract, error) {
+	renewed := sc
+	renewed.WindowStart = sc.WindowEnd + 900
+	renewed.WindowEnd = sc.WindowEnd + 1000
+	renewed.Signatures = nil
+
+	signByClient, err := crypto.Sign(renewed.RLPHash().Bytes(), prvAndAddresses[0].Privkey)
+	if err != nil {
+		return nil, fmt.Errorf("client failed to sign storage contract,error: %v", err)
+	}
+
+	signByHost, err := crypto.Sign(renewed.RLPHash().Bytes(), prvAndAddresses[1].Privkey)
+	if err != nil {
+		return nil, fmt.Errorf("host failed to sign storage contract,error: %v", err)
+	}
+
+	renewed.Signatures = [][]byte{signByClient, signByHost}
+	return &renewed, nil
+}
+
 func mockStorageRevision(sc types.StorageContract, cost *big.Int, prvKeyClient, prvKeyHost *ecdsa.PrivateKey) (*types.StorageContractRevision, error) {
 	scr := &types.StorageContractRevision{
 		ParentID: sc.ID(),
@@ -180,6 +180,29 @@ func RemainGas(args ...interface{}) (uint64, []interface{}) {
 		result = append(result, nil)
 		return gas, result
 
+		//CheckRenewContract
+	case func(StateDB, types.StorageContractRenewal, uint64) error:
+		if gas < params.CheckFileGas {
+			result = append(result, errGasCalculationInsufficient)
+			return gas, result
+		}
+
+		if len(args) != 5 {
+			result = append(result, errGasCalculationParamsNumberWrong)
+			return gas, result
+		}
+		state, _ := args[2].(StateDB)
+		renewal, _ := args[3].(types.StorageContractRenewal)
+		bl, _ := args[4].(uint64)
+		gas -= params.CheckFileGas
+		err := i(state, renewal, bl)
+		if err != nil {
+			result = append(result, err)
+			return gas, result
+		}
+		result = append(result, nil)
+		return gas, result
+
 		//CheckMultiSignatures
 	case func(types.StorageContractRLPHash, [][]byte) error:
 		if gas < params.CheckMultiSignaturesGas {
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"hash"
 	"sync/atomic"
+	"time"
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/math"
@@ -46,6 +47,13 @@ type Config struct {
 	EWASMInterpreter string
 	// Type of the EVM interpreter
 	EVMInterpreter string
+
+	// OpProfiler collects the execution count and time of each opcode if not nil
+	OpProfiler *OpProfiler
+
+	// ValidateDposContext enables checking the invariants of the dpos context
+	// after processing each block
+	ValidateDposContext bool
 }
 
 // Interpreter is used to run Ethereum based contracts and will utilise the
@@ -254,7 +262,14 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		}
 
 		// execute the operation
+		var start time.Time
+		if in.cfg.OpProfiler != nil {
+			start = time.Now()
+		}
 		res, err := operation.execute(&pc, in, contract, mem, stack)
+		if in.cfg.OpProfiler != nil {
+			in.cfg.OpProfiler.Record(op, time.Since(start))
+		}
 		// verifyPool is a build flag. Pool verification makes sure the integrity
 		// of the integer pool by comparing values to a default value.
 		if verifyPool {
@@ -0,0 +1,69 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// OpStat is the execution statistics of an opcode collected by the OpProfiler
+type OpStat struct {
+	Op      string        `json:"op"`
+	Count   uint64        `json:"count"`
+	Total   time.Duration `json:"total"`
+	Average time.Duration `json:"average"`
+}
+
+// OpProfiler collects the execution count and time of each opcode executed by the
+// interpreter. It is safe for concurrent use by multiple interpreters
+type OpProfiler struct {
+	counts    [256]uint64
+	durations [256]int64
+}
+
+// NewOpProfiler creates an empty OpProfiler
+func NewOpProfiler() *OpProfiler {
+	return &OpProfiler{}
+}
+
+// Record records a single execution of the opcode with the time elapsed
+func (p *OpProfiler) Record(op OpCode, elapsed time.Duration) {
+	atomic.AddUint64(&p.counts[op], 1)
+	atomic.AddInt64(&p.durations[op], int64(elapsed))
+}
+
+// Reset clears all statistics collected
+func (p *OpProfiler) Reset() {
+	for i := range p.counts {
+		atomic.StoreUint64(&p.counts[i], 0)
+		atomic.StoreInt64(&p.durations[i], 0)
+	}
+}
+
+// Stats returns the statistics of the executed opcodes, sorted by the total execution
+// time in descending order. If limit is positive, only the top limit opcodes are returned
+func (p *OpProfiler) Stats(limit int) []OpStat {
+	var stats []OpStat
+	for i := range p.counts {
+		count := atomic.LoadUint64(&p.counts[i])
+		if count == 0 {
+			continue
+		}
+		total := time.Duration(atomic.LoadInt64(&p.durations[i]))
+		stats = append(stats, OpStat{
+			Op:      OpCode(i).String(),
+			Count:   count,
+			Total:   total,
+			Average: total / time.Duration(count),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Total > stats[j].Total })
+	if limit > 0 && len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats
+}
@@ -0,0 +1,34 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOpProfiler(t *testing.T) {
+	p := NewOpProfiler()
+	p.Record(ADD, time.Millisecond)
+	p.Record(ADD, 3*time.Millisecond)
+	p.Record(SSTORE, 10*time.Millisecond)
+	p.Record(PUSH1, time.Microsecond)
+
+	stats := p.Stats(2)
+	if len(stats) != 2 {
+		t.Fatalf("number of stats not expected. Got %v, Expect %v", len(stats), 2)
+	}
+	if stats[0].Op != SSTORE.String() || stats[1].Op != ADD.String() {
+		t.Errorf("stats should be sorted by total time. Got %v", stats)
+	}
+	if stats[1].Count != 2 || stats[1].Total != 4*time.Millisecond || stats[1].Average != 2*time.Millisecond {
+		t.Errorf("stats of ADD not expected. Got %+v", stats[1])
+	}
+
+	p.Reset()
+	if stats := p.Stats(0); len(stats) != 0 {
+		t.Errorf("stats should be cleared after reset. Got %v", stats)
+	}
+}
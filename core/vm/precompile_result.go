@@ -0,0 +1,163 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// HostAnnounceResult is the result returned by the host announce transaction
+type HostAnnounceResult struct {
+	NetAddress string `json:"netAddress"`
+}
+
+// ContractCreateResult is the result returned by the contract create transaction
+type ContractCreateResult struct {
+	ContractID      common.Hash    `json:"contractID"`
+	ContractAddress common.Address `json:"contractAddress"`
+	WindowStart     uint64         `json:"windowStart"`
+	WindowEnd       uint64         `json:"windowEnd"`
+}
+
+// CommitRevisionResult is the result returned by the commit revision transaction
+type CommitRevisionResult struct {
+	ContractID     common.Hash `json:"contractID"`
+	RevisionNumber uint64      `json:"revisionNumber"`
+	FileSize       uint64      `json:"fileSize"`
+	FileMerkleRoot common.Hash `json:"fileMerkleRoot"`
+}
+
+// StorageProofResult is the result returned by the storage proof transaction, with the
+// valid proof outputs paid to the storage client and the storage host
+type StorageProofResult struct {
+	ContractID   common.Hash   `json:"contractID"`
+	ClientPayout common.BigInt `json:"clientPayout"`
+	HostPayout   common.BigInt `json:"hostPayout"`
+}
+
+// StorageProofFailure is the storage proof rejected in the storage proof batch transaction,
+// with the reason of the rejection
+type StorageProofFailure struct {
+	ContractID common.Hash `json:"contractID"`
+	Reason     string      `json:"reason"`
+}
+
+// StorageProofBatchResult is the result returned by the storage proof batch transaction,
+// with the storage proofs accepted and rejected
+type StorageProofBatchResult struct {
+	Proofs []StorageProofResult  `json:"proofs"`
+	Failed []StorageProofFailure `json:"failed"`
+}
+
+// RenewContractResult is the result returned by the renew contract transaction
+type RenewContractResult struct {
+	OldContractID      common.Hash    `json:"oldContractID"`
+	NewContractID      common.Hash    `json:"newContractID"`
+	NewContractAddress common.Address `json:"newContractAddress"`
+}
+
+// CandidateResult is the result returned by the apply candidate transaction
+type CandidateResult struct {
+	Deposit     common.BigInt `json:"deposit"`
+	RewardRatio uint64        `json:"rewardRatio"`
+}
+
+// VoteResult is the result returned by the vote transaction, with the number of
+// candidates successfully voted
+type VoteResult struct {
+	Deposit   common.BigInt `json:"deposit"`
+	VoteCount uint64        `json:"voteCount"`
+}
+
+// PrecompileFailure is the result returned by the failed precompiled contract transaction,
+// with the reason of the failure
+type PrecompileFailure struct {
+	Reason string `json:"reason"`
+}
+
+// PrecompiledTxType returns the tx type of the precompiled storage or dpos contract address,
+// whose transactions return the rlp encoded result
+func PrecompiledTxType(addr common.Address) (string, bool) {
+	if txType, ok := PrecompiledStorageContracts[addr]; ok {
+		return txType, true
+	}
+	txType, ok := PrecompiledDPoSContracts[addr]
+	return txType, ok
+}
+
+// ActivePrecompiledTxType returns the tx type of the precompiled storage or dpos contract
+// address active at block num
+func ActivePrecompiledTxType(config *params.ChainConfig, num *big.Int, addr common.Address) (string, bool) {
+	if _, ok := PrecompiledStorageContracts[addr]; ok {
+		return ActiveStorageContractTxType(config, num, addr)
+	}
+	txType, ok := PrecompiledDPoSContracts[addr]
+	return txType, ok
+}
+
+// encodePrecompileResult rlp encodes the result of the precompiled contract transaction.
+// The result is only informative, so encoding failure does not fail the transaction
+func encodePrecompileResult(result interface{}) []byte {
+	data, err := rlp.EncodeToBytes(result)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// EncodePrecompileFailure rlp encodes the error failing the precompiled contract transaction
+// as the failure reason
+func EncodePrecompileFailure(err error) []byte {
+	return encodePrecompileResult(PrecompileFailure{Reason: err.Error()})
+}
+
+// DecodePrecompileFailure decodes the return data of the failed precompiled contract transaction
+func DecodePrecompileFailure(ret []byte) (*PrecompileFailure, error) {
+	var failure PrecompileFailure
+	if err := rlp.DecodeBytes(ret, &failure); err != nil {
+		return nil, err
+	}
+	return &failure, nil
+}
+
+// DecodePrecompileResult decodes the return data of the precompiled contract transaction
+// with the tx type. Nil is returned for the tx types which do not return any result
+func DecodePrecompileResult(txType string, ret []byte) (interface{}, error) {
+	var result interface{}
+	switch txType {
+	case HostAnnounceTransaction:
+		result = &HostAnnounceResult{}
+	case ContractCreateTransaction:
+		result = &ContractCreateResult{}
+	case CommitRevisionTransaction:
+		result = &CommitRevisionResult{}
+	case StorageProofTransaction:
+		result = &StorageProofResult{}
+	case RenewContractTransaction:
+		result = &RenewContractResult{}
+	case StorageProofBatchTransaction:
+		result = &StorageProofBatchResult{}
+	case ApplyCandidate:
+		result = &CandidateResult{}
+	case Vote:
+		result = &VoteResult{}
+	case CancelCandidate, CancelVote:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown precompiled contract tx type: %s", txType)
+	}
+	if len(ret) == 0 {
+		return nil, nil
+	}
+	if err := rlp.DecodeBytes(ret, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
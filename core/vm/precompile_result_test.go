@@ -0,0 +1,96 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/params"
+)
+
+func TestDecodePrecompileResult(t *testing.T) {
+	tests := []struct {
+		txType string
+		result interface{}
+	}{
+		{HostAnnounceTransaction, &HostAnnounceResult{NetAddress: "enode://127.0.0.1:36000"}},
+		{ContractCreateTransaction, &ContractCreateResult{ContractID: common.HexToHash("0x01"), ContractAddress: common.HexToAddress("0x01"), WindowStart: 100, WindowEnd: 200}},
+		{CommitRevisionTransaction, &CommitRevisionResult{ContractID: common.HexToHash("0x01"), RevisionNumber: 3, FileSize: 4096, FileMerkleRoot: common.HexToHash("0x02")}},
+		{StorageProofTransaction, &StorageProofResult{ContractID: common.HexToHash("0x01"), ClientPayout: common.NewBigInt(10), HostPayout: common.NewBigInt(20)}},
+		{StorageProofBatchTransaction, &StorageProofBatchResult{
+			Proofs: []StorageProofResult{{ContractID: common.HexToHash("0x01"), ClientPayout: common.NewBigInt(10), HostPayout: common.NewBigInt(20)}},
+			Failed: []StorageProofFailure{{ContractID: common.HexToHash("0x02"), Reason: "invalid storage proof"}},
+		}},
+		{RenewContractTransaction, &RenewContractResult{OldContractID: common.HexToHash("0x01"), NewContractID: common.HexToHash("0x02"), NewContractAddress: common.HexToAddress("0x02")}},
+		{ApplyCandidate, &CandidateResult{Deposit: common.NewBigInt(1e6), RewardRatio: 50}},
+		{Vote, &VoteResult{Deposit: common.NewBigInt(1e6), VoteCount: 2}},
+	}
+	for _, test := range tests {
+		result, err := DecodePrecompileResult(test.txType, encodePrecompileResult(test.result))
+		if err != nil {
+			t.Fatalf("%s: failed to decode the result: %v", test.txType, err)
+		}
+		if !reflect.DeepEqual(result, test.result) {
+			t.Errorf("%s: result not expected. Got %+v, Expect %+v", test.txType, result, test.result)
+		}
+	}
+
+	// the cancel txs do not return any result
+	if result, err := DecodePrecompileResult(CancelVote, nil); result != nil || err != nil {
+		t.Errorf("cancel vote should not return result. Got %v, %v", result, err)
+	}
+	if _, err := DecodePrecompileResult("unknown", []byte{0xc0}); err == nil {
+		t.Errorf("unknown tx type should return error")
+	}
+}
+
+func TestPrecompiledTxType(t *testing.T) {
+	if txType, ok := PrecompiledTxType(common.BytesToAddress([]byte{10})); !ok || txType != ContractCreateTransaction {
+		t.Errorf("tx type not expected. Got %v, Expect %v", txType, ContractCreateTransaction)
+	}
+	if txType, ok := PrecompiledTxType(VoteContractAddress); !ok || txType != Vote {
+		t.Errorf("tx type not expected. Got %v, Expect %v", txType, Vote)
+	}
+	if _, ok := PrecompiledTxType(common.HexToAddress("0x1234")); ok {
+		t.Errorf("normal address should not be precompiled contract address")
+	}
+}
+
+func TestActivePrecompiledTxType(t *testing.T) {
+	config := &params.ChainConfig{RenewContractBlock: big.NewInt(100), StorageProofBatchBlock: big.NewInt(200)}
+	renew := common.BytesToAddress([]byte{17})
+	if _, ok := ActivePrecompiledTxType(config, big.NewInt(99), renew); ok {
+		t.Errorf("renew contract should not be active before the fork block")
+	}
+	if txType, ok := ActivePrecompiledTxType(config, big.NewInt(100), renew); !ok || txType != RenewContractTransaction {
+		t.Errorf("tx type not expected. Got %v, Expect %v", txType, RenewContractTransaction)
+	}
+	batch := common.BytesToAddress([]byte{18})
+	if _, ok := ActivePrecompiledTxType(config, big.NewInt(199), batch); ok {
+		t.Errorf("storage proof batch contract should not be active before the fork block")
+	}
+	if txType, ok := ActivePrecompiledTxType(config, big.NewInt(200), batch); !ok || txType != StorageProofBatchTransaction {
+		t.Errorf("tx type not expected. Got %v, Expect %v", txType, StorageProofBatchTransaction)
+	}
+	if txType, ok := ActivePrecompiledTxType(config, big.NewInt(0), common.BytesToAddress([]byte{10})); !ok || txType != ContractCreateTransaction {
+		t.Errorf("tx type not expected. Got %v, Expect %v", txType, ContractCreateTransaction)
+	}
+}
+
+func TestDecodePrecompileFailure(t *testing.T) {
+	failure, err := DecodePrecompileFailure(EncodePrecompileFailure(errNoStorageProofAccepted))
+	if err != nil {
+		t.Fatalf("failed to decode the failure: %v", err)
+	}
+	if failure.Reason != errNoStorageProofAccepted.Error() {
+		t.Errorf("failure reason not expected. Got %v, Expect %v", failure.Reason, errNoStorageProofAccepted)
+	}
+	if _, err := DecodePrecompileFailure([]byte{0x01}); err == nil {
+		t.Errorf("invalid return data should return error")
+	}
+}
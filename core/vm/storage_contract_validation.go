@@ -7,7 +7,6 @@ package vm
 import (
 	"bytes"
 	"crypto/ecdsa"
-	"crypto/sha256"
 	"errors"
 	"fmt"
 	"hash"
@@ -24,24 +23,29 @@ import (
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
+	"github.com/DxChainNetwork/godx/storage/storageerr"
 )
 
 var (
-	errZeroCollateral                          = errors.New("the payout of storage contract is less 0")
-	errZeroOutput                              = errors.New("the output of storage contract is less 0")
-	errStorageContractValidOutputSumViolation  = errors.New("storage contract has invalid valid proof output sums")
-	errStorageContractMissedOutputSumViolation = errors.New("storage contract has invalid missed proof output sums")
-	errRevisionOutputSumViolation              = errors.New("the missed proof output sum and valid proof output sum equal")
-	errStorageContractWindowEndViolation       = errors.New("storage contract window must end at least one block after it starts")
-	errStorageContractWindowStartViolation     = errors.New("storage contract window must start in the future")
-	errLateRevision                            = errors.New("storage contract revision submitted after deadline")
-	errLowRevisionNumber                       = errors.New("transaction has a storage contract with an outdated revision number")
-	errRevisionValidPayouts                    = errors.New("storage contract revision has altered valid payout")
-	errRevisionMissedPayouts                   = errors.New("storage contract revision has altered missed payout")
-	errWrongUnlockCondition                    = errors.New("the unlock hash of storage contract not match unlock condition")
-	errNoStorageContractType                   = errors.New("no this storage contract type")
-	errInvalidStorageProof                     = errors.New("invalid storage proof")
-	errUnfinishedStorageContract               = errors.New("storage contract has not yet opened")
+	errZeroCollateral                          = storageerr.New(storageerr.CodeInvalidContract, "the payout of storage contract is less 0")
+	errZeroOutput                              = storageerr.New(storageerr.CodeInvalidContract, "the output of storage contract is less 0")
+	errStorageContractValidOutputSumViolation  = storageerr.New(storageerr.CodeInvalidContract, "storage contract has invalid valid proof output sums")
+	errStorageContractMissedOutputSumViolation = storageerr.New(storageerr.CodeInvalidContract, "storage contract has invalid missed proof output sums")
+	errRevisionOutputSumViolation              = storageerr.New(storageerr.CodeInvalidRevision, "the missed proof output sum and valid proof output sum equal")
+	errStorageContractWindowEndViolation       = storageerr.New(storageerr.CodeInvalidContract, "storage contract window must end at least one block after it starts")
+	errStorageContractWindowStartViolation     = storageerr.New(storageerr.CodeInvalidContract, "storage contract window must start in the future")
+	errLateRevision                            = storageerr.New(storageerr.CodeInvalidRevision, "storage contract revision submitted after deadline")
+	errLowRevisionNumber                       = storageerr.New(storageerr.CodeInvalidRevision, "transaction has a storage contract with an outdated revision number")
+	errRevisionValidPayouts                    = storageerr.New(storageerr.CodeInvalidRevision, "storage contract revision has altered valid payout")
+	errRevisionMissedPayouts                   = storageerr.New(storageerr.CodeInvalidRevision, "storage contract revision has altered missed payout")
+	errWrongUnlockCondition                    = storageerr.New(storageerr.CodeInvalidContract, "the unlock hash of storage contract not match unlock condition")
+	errNoStorageContractType                   = storageerr.New(storageerr.CodeInvalidContract, "no this storage contract type")
+	errInvalidStorageProof                     = storageerr.New(storageerr.CodeInvalidProof, "invalid storage proof")
+	errUnfinishedStorageContract               = storageerr.New(storageerr.CodeInvalidContract, "storage contract has not yet opened")
+	errRenewProofedContract                    = storageerr.New(storageerr.CodeInvalidContract, "can not renew the storage contract after storage proof")
+	errLateRenew                               = storageerr.New(storageerr.CodeInvalidContract, "storage contract renew submitted after the proof window closed")
+	errRenewPartyMismatch                      = storageerr.New(storageerr.CodeInvalidContract, "the renewed storage contract is not signed between the same client and host")
+	errRenewFileMismatch                       = storageerr.New(storageerr.CodeInvalidContract, "the renewed storage contract does not carry over the file of the old one")
 )
 
 // CheckCreateContract checks whether a new StorageContract is valid
@@ -98,12 +102,12 @@ func CheckCreateContract(state StateDB, sc types.StorageContract, currentHeight
 
 	clientBalance := state.GetBalance(clientAddr)
 	if clientBalance.Cmp(clientCollateralAmount) == -1 {
-		return errors.New("client has not enough balance for storage contract collateral")
+		return storageerr.New(storageerr.CodeInsufficientFunds, "client has not enough balance for storage contract collateral")
 	}
 
 	hostBalance := state.GetBalance(hostAddr)
 	if hostBalance.Cmp(hostCollateralAmount) == -1 {
-		return errors.New("host has not enough balance for storage contract collateral")
+		return storageerr.New(storageerr.CodeInsufficientFunds, "host has not enough balance for storage contract collateral")
 	}
 
 	err := CheckMultiSignatures(sc, sc.Signatures)
@@ -162,31 +166,26 @@ func CheckRevisionContract(state StateDB, scr types.StorageContractRevision, cur
 	}
 
 	// retrieve origin storage contract
-	windowStartHash := state.GetState(contractAddr, coinchargemaintenance.KeyWindowStart)
-	revisionNumHash := state.GetState(contractAddr, coinchargemaintenance.KeyRevisionNumber)
-	unHash := state.GetState(contractAddr, coinchargemaintenance.KeyUnlockHash)
-	clientVpoHash := state.GetState(contractAddr, coinchargemaintenance.KeyClientValidProofOutput)
-	hostVpoHash := state.GetState(contractAddr, coinchargemaintenance.KeyHostValidProofOutput)
-	clientMpoHash := state.GetState(contractAddr, coinchargemaintenance.KeyClientMissedProofOutput)
-	hostMpoHash := state.GetState(contractAddr, coinchargemaintenance.KeyHostMissedProofOutput)
+	sc, err := coinchargemaintenance.NewStorageContractState(state).GetContract(contractAddr)
+	if err != nil {
+		return err
+	}
 
 	// Check that the height is less than sc.WindowStart - revisions are
 	// not allowed to be submitted once the storage proof window has
 	// opened.  This reduces complexity for unconfirmed transactions.
-	wStart := new(big.Int).SetBytes(windowStartHash.Bytes()).Uint64()
-	if currentHeight > wStart {
+	if currentHeight > sc.WindowStart {
 		return errLateRevision
 	}
 
 	// Check that the revision number of the revision is greater than the
 	// revision number of the existing storage contract.
-	reNum := new(big.Int).SetBytes(revisionNumHash.Bytes()).Uint64()
-	if reNum > scr.NewRevisionNumber {
+	if sc.RevisionNumber > scr.NewRevisionNumber {
 		return errLowRevisionNumber
 	}
 
 	// Check that the unlock conditions match the unlock hash.
-	if scr.UnlockConditions.UnlockHash() != unHash {
+	if scr.UnlockConditions.UnlockHash() != sc.UnlockHash {
 		return errWrongUnlockCondition
 	}
 
@@ -195,13 +194,8 @@ func CheckRevisionContract(state StateDB, scr types.StorageContractRevision, cur
 	oldValidPayout := new(big.Int).SetInt64(0)
 	oldMissedPayout := new(big.Int).SetInt64(0)
 
-	clientVpo := new(big.Int).SetBytes(clientVpoHash.Bytes())
-	hostVpo := new(big.Int).SetBytes(hostVpoHash.Bytes())
-	oldValidPayout.Add(clientVpo, hostVpo)
-
-	clientMpo := new(big.Int).SetBytes(clientMpoHash.Bytes())
-	hostMpo := new(big.Int).SetBytes(hostMpoHash.Bytes())
-	oldMissedPayout.Add(clientMpo, hostMpo)
+	oldValidPayout.Add(sc.ValidProofOutputs[0].Value, sc.ValidProofOutputs[1].Value)
+	oldMissedPayout.Add(sc.MissedProofOutputs[0].Value, sc.MissedProofOutputs[1].Value)
 
 	if validProofOutputSum.Cmp(oldValidPayout) != 0 {
 		return errRevisionValidPayouts
@@ -216,6 +210,42 @@ func CheckRevisionContract(state StateDB, scr types.StorageContractRevision, cur
 	return nil
 }
 
+// CheckRenewContract checks whether the StorageContractRenewal is valid. The new storage
+// contract itself is checked by CheckCreateContract after the old one is settled
+func CheckRenewContract(state StateDB, renewal types.StorageContractRenewal, currentHeight uint64) error {
+	scs := coinchargemaintenance.NewStorageContractState(state)
+	contractAddr := coinchargemaintenance.ContractAddress(renewal.OldContractID)
+	if !state.Exist(contractAddr) {
+		return coinchargemaintenance.ErrContractNotExist
+	}
+
+	oc, err := scs.GetContract(contractAddr)
+	if err != nil {
+		return err
+	}
+
+	// the old storage contract could only be renewed before it is settled
+	if scs.Proofed(renewal.OldContractID, oc.WindowEnd) {
+		return errRenewProofedContract
+	}
+	if currentHeight > oc.WindowEnd {
+		return errLateRenew
+	}
+
+	// the new storage contract must be between the same client and host
+	nc := renewal.NewContract
+	if nc.ClientCollateral.Address != oc.ClientCollateral.Address || nc.HostCollateral.Address != oc.HostCollateral.Address {
+		return errRenewPartyMismatch
+	}
+
+	// the file stored under the old storage contract is carried over to the new one
+	if nc.FileSize != oc.FileSize || nc.FileMerkleRoot != oc.FileMerkleRoot {
+		return errRenewFileMismatch
+	}
+
+	return nil
+}
+
 // CheckMultiSignatures checks whether a new StorageContractRevision is valid
 func CheckMultiSignatures(originalData types.StorageContractRLPHash, signatures [][]byte) error {
 	if len(signatures) == 0 {
@@ -235,7 +265,7 @@ func CheckMultiSignatures(originalData types.StorageContractRLPHash, signatures
 		singleSig = signatures[0]
 
 		// if we can recover the public key, indicate that check sig is ok
-		recoverKey, err := crypto.SigToPub(dataHash.Bytes(), singleSig)
+		recoverKey, err := recoverPubkey(dataHash, singleSig)
 		if err != nil {
 			return err
 		}
@@ -255,11 +285,11 @@ func CheckMultiSignatures(originalData types.StorageContractRLPHash, signatures
 	} else if len(signatures) == 2 {
 		clientSig = signatures[0]
 		hostSig = signatures[1]
-		clientPubkey, err = crypto.SigToPub(dataHash.Bytes(), clientSig)
+		clientPubkey, err = recoverPubkey(dataHash, clientSig)
 		if err != nil {
 			return err
 		}
-		hostPubkey, err = crypto.SigToPub(dataHash.Bytes(), hostSig)
+		hostPubkey, err = recoverPubkey(dataHash, hostSig)
 		if err != nil {
 			return err
 		}
@@ -298,16 +328,14 @@ func CheckStorageProof(state StateDB, sp types.StorageProof, currentHeight uint6
 	}
 
 	// retrieve the storage contract info
-	windowStartHash := state.GetState(contractAddr, coinchargemaintenance.KeyWindowStart)
-	windowStart := new(big.Int).SetBytes(windowStartHash.Bytes()).Uint64()
-
-	windowEndHash := state.GetState(contractAddr, coinchargemaintenance.KeyWindowEnd)
-	windowEnd := new(big.Int).SetBytes(windowEndHash.Bytes()).Uint64()
-
-	fileMerkleRoot := state.GetState(contractAddr, coinchargemaintenance.KeyFileMerkleRoot)
-
-	fileSizeHash := state.GetState(contractAddr, coinchargemaintenance.KeyFileSize)
-	fileSize := new(big.Int).SetBytes(fileSizeHash.Bytes()).Uint64()
+	sc, err := coinchargemaintenance.NewStorageContractState(state).GetContract(contractAddr)
+	if err != nil {
+		return err
+	}
+	windowStart := sc.WindowStart
+	windowEnd := sc.WindowEnd
+	fileMerkleRoot := sc.FileMerkleRoot
+	fileSize := sc.FileSize
 
 	if windowStart > currentHeight {
 		return errors.New("too early to submit storage proof")
@@ -318,7 +346,7 @@ func CheckStorageProof(state StateDB, sp types.StorageProof, currentHeight uint6
 	}
 
 	// check signature
-	err := CheckMultiSignatures(sp, [][]byte{sp.Signature})
+	err = CheckMultiSignatures(sp, [][]byte{sp.Signature})
 	if err != nil {
 		log.Error("failed to check signature for storage proof", "err", err)
 		return err
@@ -361,14 +389,7 @@ func CheckStorageProof(state StateDB, sp types.StorageProof, currentHeight uint6
 
 // VerifySegment checks whether host has really stored the file
 func VerifySegment(segment []byte, hashSet []common.Hash, leaves, segmentIndex uint64, merkleRoot common.Hash) bool {
-
-	// convert base and hashSet to proofSet
-	proofSet := make([][]byte, len(hashSet)+1)
-	proofSet[0] = segment
-	for i := range hashSet {
-		proofSet[i+1] = hashSet[i][:]
-	}
-	return VerifyProof(merkleRoot[:], proofSet, segmentIndex, leaves)
+	return merkle.NewSegmentProof(segmentIndex, leaves, hashSet).VerifyData(segment, merkleRoot) == nil
 }
 
 // get segment index by random
@@ -404,70 +425,6 @@ func CalculateLeaves(fileSize uint64) uint64 {
 	return numSegments
 }
 
-// VerifyProof verifys merkle root of given segment
-func VerifyProof(merkleRoot []byte, proofSet [][]byte, proofIndex uint64, numLeaves uint64) bool {
-	hasher := sha256.New()
-
-	if merkleRoot == nil {
-		return false
-	}
-
-	if proofIndex >= numLeaves {
-		return false
-	}
-
-	height := 0
-	if len(proofSet) <= height {
-		return false
-	}
-
-	// proofSet[0] is the segment of the file
-	sum := leafHash(hasher, proofSet[height])
-	height++
-	stableEnd := proofIndex
-
-	for {
-		subTreeStartIndex := (proofIndex / (1 << uint(height))) * (1 << uint(height))
-		subTreeEndIndex := subTreeStartIndex + (1 << (uint(height))) - 1
-		if subTreeEndIndex >= numLeaves {
-			break
-		}
-
-		stableEnd = subTreeEndIndex
-
-		if len(proofSet) <= height {
-			return false
-		}
-
-		if proofIndex-subTreeStartIndex < 1<<uint(height-1) {
-			sum = nodeHash(hasher, sum, proofSet[height])
-		} else {
-			sum = nodeHash(hasher, proofSet[height], sum)
-		}
-		height++
-	}
-
-	if stableEnd != numLeaves-1 {
-		if len(proofSet) <= height {
-			return false
-		}
-
-		sum = nodeHash(hasher, sum, proofSet[height])
-		height++
-	}
-
-	for height < len(proofSet) {
-		sum = nodeHash(hasher, proofSet[height], sum)
-		height++
-	}
-
-	if bytes.Equal(sum, merkleRoot) {
-		return true
-	}
-
-	return false
-}
-
 // HashSum returns the hash of the input data using the specified algorithm.
 func HashSum(h hash.Hash, data ...[]byte) []byte {
 	h.Reset()
@@ -477,11 +434,3 @@ func HashSum(h hash.Hash, data ...[]byte) []byte {
 	}
 	return h.Sum(nil)
 }
-
-func leafHash(h hash.Hash, data []byte) []byte {
-	return HashSum(h, []byte{0x00}, data)
-}
-
-func nodeHash(h hash.Hash, a, b []byte) []byte {
-	return HashSum(h, []byte{0x01}, a, b)
-}
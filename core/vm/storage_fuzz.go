@@ -0,0 +1,52 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+// +build gofuzz
+
+package vm
+
+import (
+	"bytes"
+
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// FuzzStoragePayload is the entry point for the go-fuzz tool, decoding the payload of the
+// storage contract transactions. The first byte of the input selects the payload type.
+//
+// This returns 1 if the payload is decoded and passes the size check, 0 otherwise. It
+// panics if the decoded payload could not be encoded back to the same payload
+func FuzzStoragePayload(input []byte) int {
+	if len(input) == 0 {
+		return -1
+	}
+	var payload interface{}
+	switch input[0] % 6 {
+	case 0:
+		payload = new(types.HostAnnouncement)
+	case 1:
+		payload = new(types.StorageContract)
+	case 2:
+		payload = new(types.StorageContractRevision)
+	case 3:
+		payload = new(types.StorageProof)
+	case 4:
+		payload = new(types.StorageProofBatch)
+	default:
+		payload = new(types.StorageContractRenewal)
+	}
+	data := input[1:]
+	if err := DecodeStoragePayload(data, payload); err != nil {
+		return 0
+	}
+	encoded, err := rlp.EncodeToBytes(payload)
+	if err != nil {
+		panic(err)
+	}
+	if !bytes.Equal(encoded, data) {
+		panic("storage payload re-encoded to different bytes")
+	}
+	return 1
+}
@@ -0,0 +1,141 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+const (
+	// maxStoragePayloadSize is the max size of the payload of the storage contract transaction
+	maxStoragePayloadSize = 32 * 1024
+
+	// maxNetAddressLength is the max length of the enode url in the host announcement
+	maxNetAddressLength = 512
+
+	// maxStorageProofHashes is the max number of hashes in the storage proof, which is the
+	// max height of the merkle tree of the file
+	maxStorageProofHashes = 64
+
+	// storageProofOutputs is the number of the proof outputs in the storage contract and
+	// revision, which are the outputs of the storage client and the storage host
+	storageProofOutputs = 2
+
+	// storageSignatureLength is the max length of the signature in the storage payload
+	storageSignatureLength = 65
+
+	// maxStorageProofBatchSize is the max number of storage proofs in the storage proof batch
+	maxStorageProofBatchSize = 64
+)
+
+var (
+	errStoragePayloadTooLarge  = errors.New("storage contract transaction payload too large")
+	errNetAddressTooLong       = errors.New("host announcement net address too long")
+	errInvalidProofOutputs     = errors.New("storage contract must have the proof outputs of the client and the host")
+	errTooManySignatures       = errors.New("too many signatures in the storage contract transaction")
+	errInvalidSignatureLength  = errors.New("invalid signature length in the storage contract transaction")
+	errTooManyStorageProofHash = errors.New("too many hashes in the storage proof")
+	errTooManyUnlockAddresses  = errors.New("too many payment addresses in the unlock conditions")
+	errEmptyStorageProofBatch  = errors.New("no storage proof in the storage proof batch")
+	errTooManyStorageProofs    = errors.New("too many storage proofs in the storage proof batch")
+)
+
+// DecodeStoragePayload decodes the rlp encoded payload of the storage contract transaction
+// into val, and checks the sizes of the decoded fields, so that the payload fed by the hostile
+// peer is rejected before being processed
+func DecodeStoragePayload(data []byte, val interface{}) error {
+	if len(data) > maxStoragePayloadSize {
+		return errStoragePayloadTooLarge
+	}
+	if err := rlp.DecodeBytes(data, val); err != nil {
+		return err
+	}
+	return validateStoragePayload(val)
+}
+
+// DecodeStoragePayloadAt decodes the payload of the storage contract transaction included at
+// block num. The sizes of the decoded fields are checked from the storage payload limit fork,
+// before which the payload is only rlp decoded. The storage proof batch is always checked,
+// since the limits come along with the storage proof batch contract
+func DecodeStoragePayloadAt(config *params.ChainConfig, num *big.Int, data []byte, val interface{}) error {
+	if _, batch := val.(*types.StorageProofBatch); batch || config.IsStoragePayloadLimited(num) {
+		return DecodeStoragePayload(data, val)
+	}
+	return rlp.DecodeBytes(data, val)
+}
+
+// validateStoragePayload checks the sizes of the fields of the decoded storage payload
+func validateStoragePayload(val interface{}) error {
+	switch payload := val.(type) {
+	case *types.HostAnnouncement:
+		if len(payload.NetAddress) > maxNetAddressLength {
+			return errNetAddressTooLong
+		}
+		return validateSignatures([][]byte{payload.Signature})
+	case *types.StorageContract:
+		return validateStorageContractPayload(*payload)
+	case *types.StorageContractRevision:
+		if len(payload.NewValidProofOutputs) != storageProofOutputs || len(payload.NewMissedProofOutputs) != storageProofOutputs {
+			return errInvalidProofOutputs
+		}
+		if len(payload.UnlockConditions.PaymentAddresses) > storageProofOutputs {
+			return errTooManyUnlockAddresses
+		}
+		return validateSignatures(payload.Signatures)
+	case *types.StorageProof:
+		return validateStorageProofPayload(*payload)
+	case *types.StorageProofBatch:
+		if len(payload.Proofs) == 0 {
+			return errEmptyStorageProofBatch
+		}
+		if len(payload.Proofs) > maxStorageProofBatchSize {
+			return errTooManyStorageProofs
+		}
+		for _, sp := range payload.Proofs {
+			if err := validateStorageProofPayload(sp); err != nil {
+				return err
+			}
+		}
+	case *types.StorageContractRenewal:
+		return validateStorageContractPayload(payload.NewContract)
+	}
+	return nil
+}
+
+// validateStorageContractPayload checks the sizes of the fields of the storage contract
+func validateStorageContractPayload(sc types.StorageContract) error {
+	if len(sc.ValidProofOutputs) != storageProofOutputs || len(sc.MissedProofOutputs) != storageProofOutputs {
+		return errInvalidProofOutputs
+	}
+	return validateSignatures(sc.Signatures)
+}
+
+// validateStorageProofPayload checks the sizes of the fields of the storage proof
+func validateStorageProofPayload(sp types.StorageProof) error {
+	if len(sp.HashSet) > maxStorageProofHashes {
+		return errTooManyStorageProofHash
+	}
+	return validateSignatures([][]byte{sp.Signature})
+}
+
+// validateSignatures checks the number of the signatures, which are signed by the storage
+// client and the storage host at most, and the length of each signature
+func validateSignatures(signatures [][]byte) error {
+	if len(signatures) > storageProofOutputs {
+		return errTooManySignatures
+	}
+	for i, sig := range signatures {
+		if len(sig) > storageSignatureLength {
+			return fmt.Errorf("%v: signature %d has %d bytes", errInvalidSignatureLength, i, len(sig))
+		}
+	}
+	return nil
+}
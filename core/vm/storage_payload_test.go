@@ -0,0 +1,78 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+func TestDecodeStoragePayload(t *testing.T) {
+	outputs := []types.DxcoinCharge{{Value: big.NewInt(1)}, {Value: big.NewInt(2)}}
+	sig := make([]byte, storageSignatureLength)
+
+	tests := []struct {
+		payload interface{}
+		decoded interface{}
+		err     bool
+	}{
+		{types.HostAnnouncement{NetAddress: "enode://", Signature: sig}, new(types.HostAnnouncement), false},
+		{types.HostAnnouncement{NetAddress: string(make([]byte, maxNetAddressLength+1))}, new(types.HostAnnouncement), true},
+		{types.StorageContract{ValidProofOutputs: outputs, MissedProofOutputs: outputs, Signatures: [][]byte{sig, sig}}, new(types.StorageContract), false},
+		{types.StorageContract{ValidProofOutputs: outputs[:1], MissedProofOutputs: outputs}, new(types.StorageContract), true},
+		{types.StorageContract{ValidProofOutputs: outputs, MissedProofOutputs: outputs, Signatures: [][]byte{sig, sig, sig}}, new(types.StorageContract), true},
+		{types.StorageContractRevision{NewValidProofOutputs: outputs, NewMissedProofOutputs: outputs}, new(types.StorageContractRevision), false},
+		{types.StorageContractRevision{NewValidProofOutputs: outputs}, new(types.StorageContractRevision), true},
+		{types.StorageProof{HashSet: make([]common.Hash, maxStorageProofHashes), Signature: sig}, new(types.StorageProof), false},
+		{types.StorageProof{HashSet: make([]common.Hash, maxStorageProofHashes+1)}, new(types.StorageProof), true},
+		{types.StorageProof{Signature: make([]byte, storageSignatureLength+1)}, new(types.StorageProof), true},
+		{types.StorageProofBatch{Proofs: []types.StorageProof{{Signature: sig}, {Signature: sig}}}, new(types.StorageProofBatch), false},
+		{types.StorageProofBatch{}, new(types.StorageProofBatch), true},
+		{types.StorageProofBatch{Proofs: make([]types.StorageProof, maxStorageProofBatchSize+1)}, new(types.StorageProofBatch), true},
+		{types.StorageProofBatch{Proofs: []types.StorageProof{{HashSet: make([]common.Hash, maxStorageProofHashes+1)}}}, new(types.StorageProofBatch), true},
+		{types.StorageContractRenewal{NewContract: types.StorageContract{ValidProofOutputs: outputs}}, new(types.StorageContractRenewal), true},
+	}
+	for i, test := range tests {
+		data, err := rlp.EncodeToBytes(test.payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = DecodeStoragePayload(data, test.decoded); (err != nil) != test.err {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+	}
+
+	if err := DecodeStoragePayload(make([]byte, maxStoragePayloadSize+1), new(types.StorageProof)); err != errStoragePayloadTooLarge {
+		t.Errorf("oversized payload should be rejected. Got %v", err)
+	}
+}
+
+func TestDecodeStoragePayloadAt(t *testing.T) {
+	config := &params.ChainConfig{StoragePayloadLimitBlock: big.NewInt(100)}
+	data, err := rlp.EncodeToBytes(types.HostAnnouncement{NetAddress: string(make([]byte, maxNetAddressLength+1))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = DecodeStoragePayloadAt(config, big.NewInt(99), data, new(types.HostAnnouncement)); err != nil {
+		t.Errorf("payload before the fork should not be limited. Got %v", err)
+	}
+	if err = DecodeStoragePayloadAt(config, big.NewInt(100), data, new(types.HostAnnouncement)); err != errNetAddressTooLong {
+		t.Errorf("error not expected. Got %v, Expect %v", err, errNetAddressTooLong)
+	}
+
+	// the storage proof batch is limited regardless of the fork
+	data, err = rlp.EncodeToBytes(types.StorageProofBatch{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = DecodeStoragePayloadAt(config, big.NewInt(99), data, new(types.StorageProofBatch)); err != errEmptyStorageProofBatch {
+		t.Errorf("error not expected. Got %v, Expect %v", err, errEmptyStorageProofBatch)
+	}
+}
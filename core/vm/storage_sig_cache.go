@@ -0,0 +1,118 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/crypto"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// storageSigCacheSize is the number of the recovered public keys kept in the cache
+const storageSigCacheSize = 16384
+
+// storageSigCache caches the public keys recovered from the signatures of the storage txs, keyed
+// by the hash of the signed data hash and the signature. The recovery is deterministic, thus the
+// keys could be recovered ahead of the execution, e.g. concurrently on block import
+var storageSigCache, _ = lru.NewARC(storageSigCacheSize)
+
+// storageSig is the signature of the storage tx payload with the hash of the data signed
+type storageSig struct {
+	hash common.Hash
+	sig  []byte
+}
+
+// recoverPubkey recovers the public key from the signature of the hash, and the public key
+// recovered is cached
+func recoverPubkey(hash common.Hash, sig []byte) (*ecdsa.PublicKey, error) {
+	key := crypto.Keccak256Hash(hash.Bytes(), sig)
+	if pubkey, known := storageSigCache.Get(key); known {
+		return pubkey.(*ecdsa.PublicKey), nil
+	}
+	pubkey, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		return nil, err
+	}
+	storageSigCache.Add(key, pubkey)
+	return pubkey, nil
+}
+
+// RecoverStorageSignatures recovers and caches the public keys from the signatures of the
+// storage tx, so that the signatures are not recovered again when the tx is executed. The tx
+// not sent to the storage precompiled contracts, or with the invalid payload, is ignored, and
+// the error is left to the execution
+func RecoverStorageSignatures(tx *types.Transaction) {
+	if tx.To() == nil {
+		return
+	}
+	txType, exists := PrecompiledStorageContracts[*tx.To()]
+	if !exists {
+		return
+	}
+	for _, s := range storageTxSignatures(txType, tx.Data()) {
+		recoverPubkey(s.hash, s.sig)
+	}
+}
+
+// storageTxSignatures decodes the payload of the storage tx, and returns the signatures
+// checked when the tx is executed
+func storageTxSignatures(txType string, data []byte) []storageSig {
+	switch txType {
+	case HostAnnounceTransaction:
+		var ha types.HostAnnouncement
+		if DecodeStoragePayload(data, &ha) != nil {
+			return nil
+		}
+		return payloadSignatures(ha, [][]byte{ha.Signature})
+	case ContractCreateTransaction:
+		var sc types.StorageContract
+		if DecodeStoragePayload(data, &sc) != nil {
+			return nil
+		}
+		return payloadSignatures(sc, sc.Signatures)
+	case CommitRevisionTransaction:
+		var scr types.StorageContractRevision
+		if DecodeStoragePayload(data, &scr) != nil {
+			return nil
+		}
+		return payloadSignatures(scr, scr.Signatures)
+	case StorageProofTransaction:
+		var sp types.StorageProof
+		if DecodeStoragePayload(data, &sp) != nil {
+			return nil
+		}
+		return payloadSignatures(sp, [][]byte{sp.Signature})
+	case StorageProofBatchTransaction:
+		var batch types.StorageProofBatch
+		if DecodeStoragePayload(data, &batch) != nil {
+			return nil
+		}
+		var sigs []storageSig
+		for _, sp := range batch.Proofs {
+			sigs = append(sigs, payloadSignatures(sp, [][]byte{sp.Signature})...)
+		}
+		return sigs
+	case RenewContractTransaction:
+		var renewal types.StorageContractRenewal
+		if DecodeStoragePayload(data, &renewal) != nil {
+			return nil
+		}
+		return payloadSignatures(renewal.NewContract, renewal.NewContract.Signatures)
+	}
+	return nil
+}
+
+// payloadSignatures pairs the signatures with the hash of the data signed
+func payloadSignatures(data types.StorageContractRLPHash, signatures [][]byte) []storageSig {
+	hash := data.RLPHash()
+	sigs := make([]storageSig, 0, len(signatures))
+	for _, sig := range signatures {
+		sigs = append(sigs, storageSig{hash: hash, sig: sig})
+	}
+	return sigs
+}
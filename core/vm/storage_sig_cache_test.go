@@ -0,0 +1,59 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// TestRecoverStorageSignatures test the public keys recovered from the storage tx are cached,
+// and the same keys are returned when the signatures are checked
+func TestRecoverStorageSignatures(t *testing.T) {
+	prvKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := types.StorageProof{
+		ParentID: common.HexToHash("0x01"),
+		Segment:  [64]byte{1},
+	}
+	sp.Signature, err = crypto.Sign(sp.RLPHash().Bytes(), prvKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := rlp.EncodeToBytes(types.StorageProofBatch{Proofs: []types.StorageProof{sp}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.BytesToAddress([]byte{18})
+	tx := types.NewTransaction(0, to, new(big.Int), 0, new(big.Int), data)
+
+	key := crypto.Keccak256Hash(sp.RLPHash().Bytes(), sp.Signature)
+	storageSigCache.Remove(key)
+	RecoverStorageSignatures(tx)
+	cached, known := storageSigCache.Get(key)
+	if !known {
+		t.Fatal("public key not cached")
+	}
+	pubkey, err := recoverPubkey(sp.RLPHash(), sp.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pubkey != cached {
+		t.Errorf("cached public key not returned")
+	}
+	if !crypto.IsEqualPublicKey(pubkey, &prvKey.PublicKey) {
+		t.Errorf("recovered public key not match")
+	}
+
+	// the tx not sent to the storage precompiled contracts is ignored
+	RecoverStorageSignatures(types.NewTransaction(0, common.BytesToAddress([]byte{1}), new(big.Int), 0, new(big.Int), data))
+}
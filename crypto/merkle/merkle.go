@@ -41,6 +41,18 @@ func NewTree(h hash.Hash) *Tree {
 	}
 }
 
+// Reset clears the tree to the empty state, keeping the hash state so that the
+// tree could be reused without allocating a new one
+func (t *Tree) Reset() {
+	t.top = nil
+	t.hash.Reset()
+	t.leafIndex = 0
+	t.storageProofIndex = 0
+	t.storageProofList = nil
+	t.usedAsProof = false
+	t.usedAsCached = false
+}
+
 // SetStorageProofIndex must be called on an empty tree.
 func (t *Tree) SetStorageProofIndex(i uint64) error {
 	if t.top != nil {
@@ -0,0 +1,284 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package merkle
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// ProofVersion is the version of the proof serialization format. The version is the first byte
+// of the serialized proof, so that the proofs serialized by the older versions could still be
+// decoded once the format is changed
+const ProofVersion uint8 = 1
+
+// ProofType is the type of the merkle proof, which decides the data proven by the proof
+type ProofType uint8
+
+const (
+	// ProofTypeSegment proves a single leaf of the data, e.g. the segment in a storage proof
+	ProofTypeSegment ProofType = iota + 1
+
+	// ProofTypeRange proves the data within a range of leaves
+	ProofTypeRange
+
+	// ProofTypeSectorRange proves the sector roots within a range of the sector roots
+	ProofTypeSectorRange
+
+	// ProofTypeDiff proves the sector roots within multiple ranges of the sector roots
+	ProofTypeDiff
+)
+
+// maxProofItems limits the number of ranges and hashes decoded, so that a malformed proof could
+// not make the decoder allocate a huge amount of memory
+const maxProofItems = 1 << 20
+
+var (
+	errProofVersion = errors.New("unsupported merkle proof version")
+	errProofType    = errors.New("unknown merkle proof type")
+	errProofTrailer = errors.New("trailing bytes after the merkle proof")
+	errProofTooMany = errors.New("too many items in the merkle proof")
+	errProofInvalid = errors.New("invalid merkle proof")
+)
+
+// Proof is the canonical representation of the merkle proofs, which could be serialized into a
+// versioned binary format portable across tools. Ranges are the ranges of leaves proven, which is
+// a single leaf for the segment proof, and NumLeaves is the number of leaves of the merkle tree,
+// which is not needed by the range proofs
+type Proof struct {
+	Type      ProofType
+	NumLeaves uint64
+	Ranges    []SubTreeLimit
+	Hashes    []common.Hash
+}
+
+// NewSegmentProof returns the proof of the leaf at the index in the tree with numLeaves leaves
+func NewSegmentProof(index, numLeaves uint64, hashes []common.Hash) Proof {
+	return Proof{
+		Type:      ProofTypeSegment,
+		NumLeaves: numLeaves,
+		Ranges:    []SubTreeLimit{{Left: index, Right: index + 1}},
+		Hashes:    hashes,
+	}
+}
+
+// NewRangeProof returns the proof of the data within the leaves [start, end)
+func NewRangeProof(start, end uint64, hashes []common.Hash) Proof {
+	return Proof{
+		Type:   ProofTypeRange,
+		Ranges: []SubTreeLimit{{Left: start, Right: end}},
+		Hashes: hashes,
+	}
+}
+
+// NewSectorRangeProof returns the proof of the sector roots within [start, end)
+func NewSectorRangeProof(start, end uint64, hashes []common.Hash) Proof {
+	return Proof{
+		Type:   ProofTypeSectorRange,
+		Ranges: []SubTreeLimit{{Left: start, Right: end}},
+		Hashes: hashes,
+	}
+}
+
+// NewDiffProof returns the proof of the sector roots within the ranges in the tree with
+// numLeaves sector roots
+func NewDiffProof(ranges []SubTreeLimit, numLeaves uint64, hashes []common.Hash) Proof {
+	return Proof{
+		Type:      ProofTypeDiff,
+		NumLeaves: numLeaves,
+		Ranges:    ranges,
+		Hashes:    hashes,
+	}
+}
+
+// VerifyData verifies the data is proven by the segment or range proof under the merkle root.
+// The data is the leaf for the segment proof, and the data of the leaves in range for the
+// range proof
+func (p Proof) VerifyData(data []byte, root common.Hash) error {
+	if len(p.Ranges) != 1 {
+		return errProofInvalid
+	}
+	r := p.Ranges[0]
+	switch p.Type {
+	case ProofTypeSegment:
+		if !Sha256VerifyDataPiece(data, p.Hashes, p.NumLeaves, r.Left, root) {
+			return errProofInvalid
+		}
+		return nil
+	case ProofTypeRange:
+		verified, err := Sha256VerifyRangeProof(data, p.Hashes, int(r.Left), int(r.Right), root)
+		return proofResult(verified, err)
+	default:
+		return fmt.Errorf("%v: type %v proves sector roots", errProofType, p.Type)
+	}
+}
+
+// VerifyRoots verifies the sector roots are proven by the sector range or diff proof under the
+// merkle root. The roots are the sector roots within the ranges, in order
+func (p Proof) VerifyRoots(roots []common.Hash, root common.Hash) error {
+	switch p.Type {
+	case ProofTypeSectorRange:
+		if len(p.Ranges) != 1 {
+			return errProofInvalid
+		}
+		verified, err := Sha256VerifySectorRangeProof(roots, p.Hashes, int(p.Ranges[0].Left), int(p.Ranges[0].Right), root)
+		return proofResult(verified, err)
+	case ProofTypeDiff:
+		return Sha256VerifyDiffProof(p.Ranges, p.NumLeaves, p.Hashes, roots, root)
+	default:
+		return fmt.Errorf("%v: type %v proves data", errProofType, p.Type)
+	}
+}
+
+// proofResult converts the verification result into the error
+func proofResult(verified bool, err error) error {
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return errProofInvalid
+	}
+	return nil
+}
+
+// MarshalBinary serializes the proof into the versioned binary format:
+//
+//	version (1 byte) | type (1 byte) | numLeaves (uvarint)
+//	| len(ranges) (uvarint) | [left (uvarint) | right (uvarint)]...
+//	| len(hashes) (uvarint) | [hash (32 bytes)]...
+func (p Proof) MarshalBinary() ([]byte, error) {
+	if p.Type < ProofTypeSegment || p.Type > ProofTypeDiff {
+		return nil, errProofType
+	}
+	buf := make([]byte, 0, 2+binary.MaxVarintLen64*(2+2*len(p.Ranges))+common.HashLength*len(p.Hashes))
+	buf = append(buf, ProofVersion, byte(p.Type))
+	buf = appendUvarint(buf, p.NumLeaves)
+	buf = appendUvarint(buf, uint64(len(p.Ranges)))
+	for _, r := range p.Ranges {
+		buf = appendUvarint(buf, r.Left)
+		buf = appendUvarint(buf, r.Right)
+	}
+	buf = appendUvarint(buf, uint64(len(p.Hashes)))
+	for _, h := range p.Hashes {
+		buf = append(buf, h.Bytes()...)
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary decodes the proof serialized by MarshalBinary
+func (p *Proof) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return io.ErrUnexpectedEOF
+	}
+	if data[0] != ProofVersion {
+		return fmt.Errorf("%v: %v", errProofVersion, data[0])
+	}
+	proof := Proof{Type: ProofType(data[1])}
+	if proof.Type < ProofTypeSegment || proof.Type > ProofTypeDiff {
+		return fmt.Errorf("%v: %v", errProofType, data[1])
+	}
+	d := proofDecoder{data: data[2:]}
+	proof.NumLeaves = d.uvarint()
+	if numRanges := d.count(2); numRanges != 0 {
+		proof.Ranges = make([]SubTreeLimit, numRanges)
+		for i := range proof.Ranges {
+			proof.Ranges[i].Left, proof.Ranges[i].Right = d.uvarint(), d.uvarint()
+		}
+	}
+	if numHashes := d.count(common.HashLength); numHashes != 0 {
+		proof.Hashes = make([]common.Hash, numHashes)
+		for i := range proof.Hashes {
+			proof.Hashes[i] = common.BytesToHash(d.bytes(common.HashLength))
+		}
+	}
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.data) != 0 {
+		return errProofTrailer
+	}
+	*p = proof
+	return nil
+}
+
+// EncodeRLP encodes the proof as the rlp string of the binary format, so that the proof embedded
+// in the rlp messages is serialized in the same format
+func (p Proof) EncodeRLP(w io.Writer) error {
+	data, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, data)
+}
+
+// DecodeRLP decodes the proof encoded by EncodeRLP
+func (p *Proof) DecodeRLP(s *rlp.Stream) error {
+	data, err := s.Bytes()
+	if err != nil {
+		return err
+	}
+	return p.UnmarshalBinary(data)
+}
+
+// appendUvarint appends the uvarint encoding of x to buf
+func appendUvarint(buf []byte, x uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], x)
+	return append(buf, b[:n]...)
+}
+
+// proofDecoder reads the fields of the serialized proof, keeping the first error encountered
+type proofDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *proofDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	x, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	d.data = d.data[n:]
+	return x
+}
+
+// count reads the number of items, each of which takes at least minSize bytes
+func (d *proofDecoder) count(minSize int) int {
+	n := d.uvarint()
+	if d.err != nil {
+		return 0
+	}
+	if n > maxProofItems {
+		d.err = errProofTooMany
+		return 0
+	}
+	if n*uint64(minSize) > uint64(len(d.data)) {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	return int(n)
+}
+
+func (d *proofDecoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.data) < n {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
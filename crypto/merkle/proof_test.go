@@ -0,0 +1,97 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package merkle
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// TestProof_Encoding test the proof decoded is the same as the proof encoded, in both the binary
+// and the rlp encoding, and the proof serialized is still verifiable after decoded
+func TestProof_Encoding(t *testing.T) {
+	data := randomDataGenerator(16 * LeafSize)
+	root := Sha256MerkleTreeRoot(data)
+	hashes, err := Sha256RangeProof(data, 3, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rangeProof := NewRangeProof(3, 9, hashes)
+
+	proofs := []Proof{
+		rangeProof,
+		NewSegmentProof(0, 1, nil),
+		NewSectorRangeProof(1<<40, 1<<40+1, []common.Hash{{1}, {2}}),
+		NewDiffProof([]SubTreeLimit{{Left: 0, Right: 2}, {Left: 300, Right: 301}}, 1000, []common.Hash{{3}}),
+	}
+	for i, proof := range proofs {
+		enc, err := proof.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if enc[0] != ProofVersion {
+			t.Errorf("test %v: expect version %v, got %v", i, ProofVersion, enc[0])
+		}
+		var decoded Proof
+		if err := decoded.UnmarshalBinary(enc); err != nil {
+			t.Fatalf("test %v: %v", i, err)
+		}
+		if !reflect.DeepEqual(decoded, proof) {
+			t.Errorf("test %v: expect %+v, got %+v", i, proof, decoded)
+		}
+
+		rlpEnc, err := rlp.EncodeToBytes(proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded = Proof{}
+		if err := rlp.DecodeBytes(rlpEnc, &decoded); err != nil {
+			t.Fatalf("test %v: %v", i, err)
+		}
+		if !reflect.DeepEqual(decoded, proof) {
+			t.Errorf("test %v: rlp expect %+v, got %+v", i, proof, decoded)
+		}
+	}
+
+	enc, _ := rangeProof.MarshalBinary()
+	var decoded Proof
+	if err := decoded.UnmarshalBinary(enc); err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.VerifyData(data[3*LeafSize:9*LeafSize], root); err != nil {
+		t.Errorf("decoded proof failed to verify: %v", err)
+	}
+	if err := decoded.VerifyData(data[2*LeafSize:8*LeafSize], root); err == nil {
+		t.Error("proof verified with the wrong data")
+	}
+	if err := decoded.VerifyRoots(nil, root); err == nil {
+		t.Error("range proof should not verify the sector roots")
+	}
+}
+
+// TestProof_UnmarshalBinaryInvalid test the malformed proofs are rejected
+func TestProof_UnmarshalBinaryInvalid(t *testing.T) {
+	valid, err := NewSectorRangeProof(0, 1, []common.Hash{{1}}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string][]byte{
+		"empty":     {},
+		"version":   append([]byte{ProofVersion + 1}, valid[1:]...),
+		"type":      {ProofVersion, 0, 0, 0, 0},
+		"truncated": valid[:len(valid)-1],
+		"trailing":  append(append([]byte{}, valid...), 0),
+		"too many":  {ProofVersion, byte(ProofTypeDiff), 0, 0, 0xff, 0xff, 0xff, 0xff, 0x0f},
+	}
+	for name, enc := range tests {
+		var p Proof
+		if err := p.UnmarshalBinary(enc); err == nil {
+			t.Errorf("%v: malformed proof decoded", name)
+		}
+	}
+}
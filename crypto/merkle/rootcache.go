@@ -0,0 +1,80 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package merkle
+
+import (
+	"crypto/sha256"
+	"sync"
+
+	"github.com/DxChainNetwork/godx/common"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// DefaultRootCacheSize is the number of sector roots kept in the default sector root cache
+const DefaultRootCacheSize = 1024
+
+// treePool is the pool of the sha256 merkle trees. The trees together with their
+// hash states are reused in order to reduce the allocations while building trees
+var treePool = sync.Pool{
+	New: func() interface{} {
+		return NewSha256MerkleTree()
+	},
+}
+
+// defaultRootCache is the sector root cache shared by the upload negotiation and
+// the proof construction
+var defaultRootCache = NewSectorRootCache(DefaultRootCacheSize)
+
+// SectorRootCache is a concurrent LRU cache of the merkle roots of sectors, keyed by the
+// sha256 hash of the sector data. Hashing the sector is much cheaper than building the
+// merkle tree of the sector
+type SectorRootCache struct {
+	cache *lru.Cache
+}
+
+// NewSectorRootCache creates a SectorRootCache with the size provided
+func NewSectorRootCache(size int) *SectorRootCache {
+	cache, err := lru.New(size)
+	if err != nil {
+		panic(err)
+	}
+	return &SectorRootCache{
+		cache: cache,
+	}
+}
+
+// Root returns the merkle root of the data. If the root is not cached, it will be
+// calculated and added to the cache
+func (rc *SectorRootCache) Root(data []byte) (root common.Hash) {
+	key := common.Hash(sha256.Sum256(data))
+	if cached, ok := rc.cache.Get(key); ok {
+		return cached.(common.Hash)
+	}
+	root = Sha256MerkleTreeRoot(data)
+	rc.cache.Add(key, root)
+	return
+}
+
+// Len returns the number of roots in the cache
+func (rc *SectorRootCache) Len() int {
+	return rc.cache.Len()
+}
+
+// CachedSha256MerkleTreeRoot returns the merkle root of the data, using the default
+// sector root cache
+func CachedSha256MerkleTreeRoot(data []byte) common.Hash {
+	return defaultRootCache.Root(data)
+}
+
+// acquireTree gets an empty sha256 merkle tree from the tree pool
+func acquireTree() *Sha256MerkleTree {
+	return treePool.Get().(*Sha256MerkleTree)
+}
+
+// releaseTree resets the tree and puts it back to the tree pool
+func releaseTree(mt *Sha256MerkleTree) {
+	mt.Reset()
+	treePool.Put(mt)
+}
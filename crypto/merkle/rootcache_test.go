@@ -0,0 +1,64 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package merkle
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+)
+
+func TestSectorRootCache_Root(t *testing.T) {
+	rc := NewSectorRootCache(2)
+	sectors := [][]byte{
+		randomDataGenerator(SectorSize / 16),
+		randomDataGenerator(SectorSize / 16),
+		randomDataGenerator(SectorSize / 16),
+	}
+
+	for round := 0; round < 2; round++ {
+		for i, sector := range sectors {
+			// the pooled tree used for proof should not affect the root calculation
+			if _, _, _, err := Sha256MerkleTreeProof(sector, uint64(i)); err != nil {
+				t.Fatal(err)
+			}
+			if root, expect := rc.Root(sector), expectedRoot(sector); root != expect {
+				t.Errorf("round %d sector %d: root not expected. Got %v, Expect %v", round, i, root, expect)
+			}
+		}
+	}
+	if rc.Len() != 2 {
+		t.Errorf("cache size not expected. Got %v, Expect %v", rc.Len(), 2)
+	}
+}
+
+// expectedRoot calculates the merkle root of the data with a new tree
+func expectedRoot(data []byte) common.Hash {
+	mt := NewSha256MerkleTree()
+	buf := bytes.NewBuffer(data)
+	for buf.Len() > 0 {
+		mt.PushLeaf(buf.Next(LeafSize))
+	}
+	return mt.Root()
+}
+
+func BenchmarkSha256MerkleTreeRoot(b *testing.B) {
+	data := randomDataGenerator(SectorSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Sha256MerkleTreeRoot(data)
+	}
+}
+
+func BenchmarkCachedSha256MerkleTreeRoot(b *testing.B) {
+	data := randomDataGenerator(SectorSize)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		CachedSha256MerkleTreeRoot(data)
+	}
+}
@@ -100,7 +100,9 @@ func (ct *Sha256CachedTree) Prove(proofData []byte, cachedHashProofSet []common.
 
 // Sha256MerkleTreeRoot will calculates the root of a data
 func Sha256MerkleTreeRoot(b []byte) (h common.Hash) {
-	mt := NewSha256MerkleTree()
+	mt := acquireTree()
+	defer releaseTree(mt)
+
 	buf := bytes.NewBuffer(b)
 	for buf.Len() > 0 {
 		mt.PushLeaf(buf.Next(LeafSize))
@@ -137,7 +139,8 @@ func Sha256CachedTreeRoot2(roots []common.Hash) (root common.Hash) {
 // in the proof set to check the integrity of the data
 func Sha256MerkleTreeProof(data []byte, proofIndex uint64) (proofData []byte, hashProofSet []common.Hash, leavesCount uint64, err error) {
 	// create a tree, and set the proofIndex
-	t := NewSha256MerkleTree()
+	t := acquireTree()
+	defer releaseTree(t)
 	if err = t.SetStorageProofIndex(proofIndex); err != nil {
 		return
 	}
@@ -26,6 +26,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/hexutil"
@@ -33,6 +34,7 @@ import (
 	"github.com/DxChainNetwork/godx/core/rawdb"
 	"github.com/DxChainNetwork/godx/core/state"
 	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
 	"github.com/DxChainNetwork/godx/internal/ethapi"
 	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rlp"
@@ -75,6 +77,13 @@ func (api *PublicEthereumAPI) ChainId() hexutil.Uint64 {
 	return (hexutil.Uint64)(chainID.Uint64())
 }
 
+// StorageTransactions returns the storage contract and dpos transactions sent by the address,
+// filtered by the transaction type if provided. Only the transactions in the blocks already
+// indexed are returned
+func (api *PublicEthereumAPI) StorageTransactions(address common.Address, txType string) []StorageTx {
+	return readStorageTxs(api.e.chainDb, address, txType)
+}
+
 // Ethereum returns the pointer for current full node object
 func (api *PublicEthereumAPI) Ethereum() *Ethereum {
 	return api.e
@@ -253,6 +262,12 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// StoragePeerBans returns the peers currently banned for the storage misbehavior,
+// such as providing the invalid proofs or sending the malformed messages
+func (api *PrivateAdminAPI) StoragePeerBans() []PeerBan {
+	return api.eth.protocolManager.misbehavior.bannedPeers(time.Now())
+}
+
 // PublicDebugAPI is the collection of Ethereum full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
@@ -341,6 +356,35 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs,
 	return results, nil
 }
 
+// errOpcodeProfilingDisabled is returned if the opcode profile is requested while the
+// opcode profiling is not enabled
+var errOpcodeProfilingDisabled = errors.New("opcode profiling is not enabled, restart with --vmprofile")
+
+// OpcodeProfile returns the execution count and time of the opcodes executed by the VM
+// during block processing, sorted by the total execution time. If limit is provided and
+// positive, only the top limit opcodes are returned
+func (api *PrivateDebugAPI) OpcodeProfile(limit *int) ([]vm.OpStat, error) {
+	profiler := api.eth.BlockChain().GetVMConfig().OpProfiler
+	if profiler == nil {
+		return nil, errOpcodeProfilingDisabled
+	}
+	var n int
+	if limit != nil {
+		n = *limit
+	}
+	return profiler.Stats(n), nil
+}
+
+// ResetOpcodeProfile clears the opcode statistics collected
+func (api *PrivateDebugAPI) ResetOpcodeProfile() error {
+	profiler := api.eth.BlockChain().GetVMConfig().OpProfiler
+	if profiler == nil {
+		return errOpcodeProfilingDisabled
+	}
+	profiler.Reset()
+	return nil
+}
+
 // StorageRangeResult is the result of a debug_storageRangeAt API call.
 type StorageRangeResult struct {
 	Storage storageMap   `json:"storage"`
@@ -0,0 +1,315 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/state"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
+)
+
+// defaultReplayScanBlocks is the number of blocks scanned back from the head by default for the
+// transactions of the storage contract, which covers the max duration of the storage contract
+var defaultReplayScanBlocks = uint64(storage.DefaultMaxDuration + storage.ProofWindowSize)
+
+// the type of the replay step of the missed proof maintenance, which is not a transaction
+const replayStepMaintenance = "MissedProofMaintenance"
+
+// ReplayConfig holds extra parameters to the storage contract replay
+type ReplayConfig struct {
+	Reexec *uint64
+	Blocks *uint64
+}
+
+// ReplayContractState is the state of the storage contract and the parties at a replay step
+type ReplayContractState struct {
+	Exists          bool         `json:"exists"`
+	Status          string       `json:"status"`
+	RevisionNumber  uint64       `json:"revisionNumber"`
+	FileSize        uint64       `json:"fileSize"`
+	ContractBalance *hexutil.Big `json:"contractBalance"`
+	ClientBalance   *hexutil.Big `json:"clientBalance"`
+	HostBalance     *hexutil.Big `json:"hostBalance"`
+}
+
+// ReplayStep is a transaction or maintenance affecting the storage contract, re-executed
+// against the historical state
+type ReplayStep struct {
+	BlockNumber uint64               `json:"blockNumber"`
+	BlockHash   common.Hash          `json:"blockHash"`
+	TxHash      *common.Hash         `json:"txHash,omitempty"`
+	Type        string               `json:"type"`
+	Failed      bool                 `json:"failed"`
+	Error       string               `json:"error,omitempty"`
+	GasUsed     uint64               `json:"gasUsed"`
+	Before      *ReplayContractState `json:"before"`
+	After       *ReplayContractState `json:"after"`
+}
+
+// StorageContractReplay is the step by step report of the storage contract execution.
+// Complete is false if the contract create transaction is not found in the blocks scanned
+type StorageContractReplay struct {
+	ContractID    common.Hash    `json:"contractID"`
+	ClientAddress common.Address `json:"clientAddress"`
+	HostAddress   common.Address `json:"hostAddress"`
+	WindowEnd     uint64         `json:"windowEnd"`
+	Complete      bool           `json:"complete"`
+	Steps         []ReplayStep   `json:"steps"`
+}
+
+// replayTx is a transaction found affecting the storage contract
+type replayTx struct {
+	block  *types.Block
+	index  int
+	txType string
+}
+
+// ReplayStorageContract re-executes the transactions affecting the storage contract against
+// the historical state, reporting the balances, revision number and status of the contract
+// before and after each step. The missed proof maintenance at the window end is reported as
+// a step as well. The transactions are searched in the blocks scanned back from the head,
+// so the historical state of the blocks must be available or re-computable
+func (api *PrivateDebugAPI) ReplayStorageContract(ctx context.Context, contractID common.Hash, config *ReplayConfig) (*StorageContractReplay, error) {
+	reexec, blocks := defaultTraceReexec, defaultReplayScanBlocks
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	if config != nil && config.Blocks != nil {
+		blocks = *config.Blocks
+	}
+
+	// search the transactions affecting the contract, until the contract create transaction
+	// is found or the blocks are all scanned
+	report := &StorageContractReplay{ContractID: contractID}
+	var txs []replayTx
+	head := api.eth.blockchain.CurrentBlock()
+	for block := head; block != nil && head.NumberU64()-block.NumberU64() < blocks; block = api.eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		found := storageContractTxs(api.eth.blockchain.Config(), block, contractID)
+		for i := len(found) - 1; i >= 0; i-- {
+			txs = append(txs, found[i])
+			if sc, created := createdStorageContract(found[i], contractID); created {
+				report.Complete = true
+				report.ClientAddress = sc.ClientCollateral.Address
+				report.HostAddress = sc.HostCollateral.Address
+				report.WindowEnd = sc.WindowEnd
+				break
+			}
+		}
+		if report.Complete || block.NumberU64() == 0 {
+			break
+		}
+	}
+
+	// re-execute the transactions in the chronological order
+	for i := len(txs) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		step, err := api.replayStorageContractTx(txs[i], report, reexec)
+		if err != nil {
+			return nil, err
+		}
+		report.Steps = append(report.Steps, *step)
+	}
+
+	// report the missed proof maintenance at the window end
+	if report.Complete && report.WindowEnd <= head.NumberU64() {
+		step, err := api.replayMissedProofMaintenance(report, reexec)
+		if err != nil {
+			return nil, err
+		}
+		report.Steps = append(report.Steps, *step)
+	}
+	return report, nil
+}
+
+// replayStorageContractTx re-executes the transaction on top of the state the transaction is
+// executed against in the chain
+func (api *PrivateDebugAPI) replayStorageContractTx(rt replayTx, report *StorageContractReplay, reexec uint64) (*ReplayStep, error) {
+	msg, vmctx, statedb, dposCtx, err := api.computeTxEnv(rt.block.Hash(), rt.index, reexec)
+	if err != nil {
+		return nil, err
+	}
+	before, err := replayContractState(statedb, report)
+	if err != nil {
+		return nil, err
+	}
+	txHash := rt.block.Transactions()[rt.index].Hash()
+	step := &ReplayStep{
+		BlockNumber: rt.block.NumberU64(),
+		BlockHash:   rt.block.Hash(),
+		TxHash:      &txHash,
+		Type:        rt.txType,
+		Before:      before,
+	}
+
+	vmenv := vm.NewEVM(vmctx, statedb, api.config, vm.Config{})
+	_, gas, failed, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas()), dposCtx)
+	if err != nil {
+		step.Failed, step.Error = true, err.Error()
+	} else {
+		step.Failed, step.GasUsed = failed, gas
+	}
+	statedb.Finalise(true)
+	if step.After, err = replayContractState(statedb, report); err != nil {
+		return nil, err
+	}
+	return step, nil
+}
+
+// replayMissedProofMaintenance reports the state of the storage contract before and after the
+// block at the window end, where the missed proof outputs are paid if the storage proof is
+// not submitted
+func (api *PrivateDebugAPI) replayMissedProofMaintenance(report *StorageContractReplay, reexec uint64) (*ReplayStep, error) {
+	block := api.eth.blockchain.GetBlockByNumber(report.WindowEnd)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", report.WindowEnd)
+	}
+	parent := api.eth.blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	before, err := api.computeStateDB(parent, reexec)
+	if err != nil {
+		return nil, err
+	}
+	after, err := api.computeStateDB(block, reexec)
+	if err != nil {
+		return nil, err
+	}
+	step := &ReplayStep{
+		BlockNumber: block.NumberU64(),
+		BlockHash:   block.Hash(),
+		Type:        replayStepMaintenance,
+	}
+	if step.Before, err = replayContractState(before, report); err != nil {
+		return nil, err
+	}
+	if step.After, err = replayContractState(after, report); err != nil {
+		return nil, err
+	}
+	return step, nil
+}
+
+// replayContractState returns the state of the storage contract in the state db
+func replayContractState(statedb *state.StateDB, report *StorageContractReplay) (*ReplayContractState, error) {
+	contractAddr := coinchargemaintenance.ContractAddress(report.ContractID)
+	scs := coinchargemaintenance.NewStorageContractState(statedb)
+	cs := &ReplayContractState{
+		Exists:          statedb.Exist(contractAddr),
+		ContractBalance: (*hexutil.Big)(statedb.GetBalance(contractAddr)),
+		ClientBalance:   (*hexutil.Big)(statedb.GetBalance(report.ClientAddress)),
+		HostBalance:     (*hexutil.Big)(statedb.GetBalance(report.HostAddress)),
+	}
+	if cs.Exists {
+		sc, err := scs.GetContract(contractAddr)
+		if err != nil {
+			return nil, err
+		}
+		cs.RevisionNumber, cs.FileSize = sc.RevisionNumber, sc.FileSize
+	}
+
+	// the status of the contract is recorded in the status account of the window end
+	statusAddr := coinchargemaintenance.ExpiredStatusAddress(report.WindowEnd)
+	status := statedb.GetState(statusAddr, report.ContractID)
+	switch {
+	case status == (common.Hash{}):
+		cs.Status = "none"
+	case bytes.Equal(status.Bytes()[11:12], coinchargemaintenance.ProofedStatus):
+		cs.Status = "proofed"
+	default:
+		cs.Status = "notProofed"
+	}
+	return cs, nil
+}
+
+// storageContractTxs returns the transactions in the block affecting the storage contract
+func storageContractTxs(config *params.ChainConfig, block *types.Block, contractID common.Hash) []replayTx {
+	var txs []replayTx
+	for i, tx := range block.Transactions() {
+		if tx.To() == nil {
+			continue
+		}
+		txType, ok := vm.ActiveStorageContractTxType(config, block.Number(), *tx.To())
+		if !ok {
+			continue
+		}
+		for _, id := range storageTxContractIDs(txType, tx.Data()) {
+			if id == contractID {
+				txs = append(txs, replayTx{block: block, index: i, txType: txType})
+				break
+			}
+		}
+	}
+	return txs
+}
+
+// createdStorageContract returns the storage contract if the transaction creates the contract
+func createdStorageContract(rt replayTx, contractID common.Hash) (types.StorageContract, bool) {
+	data := rt.block.Transactions()[rt.index].Data()
+	switch rt.txType {
+	case vm.ContractCreateTransaction:
+		var sc types.StorageContract
+		if vm.DecodeStoragePayload(data, &sc) == nil && sc.ID() == contractID {
+			return sc, true
+		}
+	case vm.RenewContractTransaction:
+		var renewal types.StorageContractRenewal
+		if vm.DecodeStoragePayload(data, &renewal) == nil && renewal.NewContract.ID() == contractID {
+			return renewal.NewContract, true
+		}
+	}
+	return types.StorageContract{}, false
+}
+
+// storageTxContractIDs returns the ids of the storage contracts affected by the storage
+// contract transaction. Nil is returned if the payload could not be decoded
+func storageTxContractIDs(txType string, data []byte) []common.Hash {
+	switch txType {
+	case vm.ContractCreateTransaction:
+		var sc types.StorageContract
+		if vm.DecodeStoragePayload(data, &sc) == nil {
+			return []common.Hash{sc.ID()}
+		}
+	case vm.CommitRevisionTransaction:
+		var scr types.StorageContractRevision
+		if vm.DecodeStoragePayload(data, &scr) == nil {
+			return []common.Hash{scr.ParentID}
+		}
+	case vm.StorageProofTransaction:
+		var sp types.StorageProof
+		if vm.DecodeStoragePayload(data, &sp) == nil {
+			return []common.Hash{sp.ParentID}
+		}
+	case vm.StorageProofBatchTransaction:
+		var batch types.StorageProofBatch
+		if vm.DecodeStoragePayload(data, &batch) == nil {
+			ids := make([]common.Hash, 0, len(batch.Proofs))
+			for _, sp := range batch.Proofs {
+				ids = append(ids, sp.ParentID)
+			}
+			return ids
+		}
+	case vm.RenewContractTransaction:
+		var renewal types.StorageContractRenewal
+		if vm.DecodeStoragePayload(data, &renewal) == nil {
+			return []common.Hash{renewal.OldContractID, renewal.NewContract.ID()}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package eth
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+func TestStorageTxContractIDs(t *testing.T) {
+	outputs := []types.DxcoinCharge{{Value: big.NewInt(1)}, {Value: big.NewInt(2)}}
+	sc := types.StorageContract{
+		FileSize:           64,
+		WindowStart:        100,
+		WindowEnd:          200,
+		ClientCollateral:   types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{Value: big.NewInt(10)}},
+		HostCollateral:     types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{Value: big.NewInt(20)}},
+		ValidProofOutputs:  outputs,
+		MissedProofOutputs: outputs,
+	}
+	id1, id2 := common.HexToHash("0x01"), common.HexToHash("0x02")
+
+	tests := []struct {
+		txType  string
+		payload interface{}
+		ids     []common.Hash
+	}{
+		{vm.ContractCreateTransaction, sc, []common.Hash{sc.ID()}},
+		{vm.CommitRevisionTransaction, types.StorageContractRevision{ParentID: id1, NewValidProofOutputs: outputs, NewMissedProofOutputs: outputs}, []common.Hash{id1}},
+		{vm.StorageProofTransaction, types.StorageProof{ParentID: id1}, []common.Hash{id1}},
+		{vm.StorageProofBatchTransaction, types.StorageProofBatch{Proofs: []types.StorageProof{{ParentID: id1}, {ParentID: id2}}}, []common.Hash{id1, id2}},
+		{vm.RenewContractTransaction, types.StorageContractRenewal{OldContractID: id1, NewContract: sc}, []common.Hash{id1, sc.ID()}},
+		{vm.HostAnnounceTransaction, types.HostAnnouncement{NetAddress: "enode://"}, nil},
+	}
+	for _, test := range tests {
+		data, err := rlp.EncodeToBytes(test.payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := storageTxContractIDs(test.txType, data); !reflect.DeepEqual(ids, test.ids) {
+			t.Errorf("%s: expect contract ids %v, got %v", test.txType, test.ids, ids)
+		}
+	}
+
+	// the payload failed to decode affects no contract
+	if ids := storageTxContractIDs(vm.StorageProofTransaction, []byte{0x01, 0x02}); ids != nil {
+		t.Errorf("expect no contract ids of the invalid payload, got %v", ids)
+	}
+}
@@ -57,6 +57,7 @@ import (
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
 	"github.com/DxChainNetwork/godx/storage/storagehost"
 )
 
@@ -93,6 +94,8 @@ type Ethereum struct {
 	bloomRequests chan chan *bloombits.Retrieval // Channel receiving bloom data retrieval requests
 	bloomIndexer  *core.ChainIndexer             // Bloom indexer operating during block imports
 
+	storageTxIndexer *core.ChainIndexer // Storage transaction indexer operating during block imports
+
 	APIBackend *EthAPIBackend
 
 	miner     *miner.Miner
@@ -149,7 +152,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		chainConfig:    chainConfig,
 		eventMux:       ctx.EventMux,
 		accountManager: ctx.AccountManager,
-		engine:         dpos.New(chainConfig.Dpos, chainDb),
+		engine:         dpos.New(chainConfig.Dpos, chainDb, ctx.EventMux),
 		shutdownChan:   make(chan bool),
 		networkID:      config.NetworkId,
 		gasPrice:       config.MinerGasPrice,
@@ -158,6 +161,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		bloomRequests:  make(chan chan *bloombits.Retrieval),
 		bloomIndexer:   NewBloomIndexer(chainDb, params.BloomBitsBlocks, params.BloomConfirms),
 	}
+	eth.storageTxIndexer = NewStorageTxIndexer(chainDb, chainConfig)
 
 	log.Info("Initialising Ethereum protocol", "versions", ProtocolVersions, "network", config.NetworkId)
 
@@ -175,9 +179,13 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 			EnablePreimageRecording: config.EnablePreimageRecording,
 			EWASMInterpreter:        config.EWASMInterpreter,
 			EVMInterpreter:          config.EVMInterpreter,
+			ValidateDposContext:     config.ValidateDposContext,
 		}
 		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieCleanLimit: config.TrieCleanCache, TrieDirtyLimit: config.TrieDirtyCache, TrieTimeLimit: config.TrieTimeout}
 	)
+	if config.EnableOpcodeProfiling {
+		vmConfig.OpProfiler = vm.NewOpProfiler()
+	}
 	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, eth.chainConfig, eth.engine, vmConfig, eth.shouldPreserve)
 	if err != nil {
 		return nil, err
@@ -189,6 +197,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		rawdb.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
+	eth.storageTxIndexer.Start(eth.blockchain)
 
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
@@ -216,6 +225,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		if err != nil {
 			return nil, err
 		}
+		eth.storageClient.SetDrainTimeout(config.StorageDrainTimeout)
 	}
 
 	// Initialize StorageHost based on the configuration
@@ -225,6 +235,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		if err != nil {
 			return nil, err
 		}
+		eth.storageHost.SetDrainTimeout(config.StorageDrainTimeout)
 	}
 
 	return eth, nil
@@ -372,6 +383,21 @@ func (s *Ethereum) APIs() []rpc.API {
 					Version:   "1.0",
 					Service:   filesystem.NewPublicFileSystemAPI(s.storageClient.GetFileSystem()),
 					Public:    true,
+				}, {
+					Namespace: "clientfiles",
+					Version:   "1.0",
+					Service:   filesystem.NewPrivateFileSystemAPI(s.storageClient.GetFileSystem()),
+					Public:    false,
+				}, {
+					Namespace: "storagehostmanager",
+					Version:   "1.0",
+					Service:   storagehostmanager.NewPublicStorageHostManagerAPI(s.storageClient.GetStorageHostManager()),
+					Public:    true,
+				}, {
+					Namespace: "storagehostmanager",
+					Version:   "1.0",
+					Service:   storagehostmanager.NewPrivateStorageHostManagerAPI(s.storageClient.GetStorageHostManager()),
+					Public:    false,
 				},
 			}
 			s.registeredAPIs = append(s.registeredAPIs, storageClientAPIs...)
@@ -694,9 +720,16 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 func (s *Ethereum) Stop() error {
 	var fullErr error
 
+	// the storage negotiations in progress rely on the network and the chain, drain them
+	// before anything is stopped
+	s.drainStorageNegotiations()
+
 	err := s.bloomIndexer.Close()
 	fullErr = common.ErrCompose(fullErr, err)
 
+	err = s.storageTxIndexer.Close()
+	fullErr = common.ErrCompose(fullErr, err)
+
 	s.blockchain.Stop()
 
 	err = s.engine.Close()
@@ -728,6 +761,27 @@ func (s *Ethereum) Stop() error {
 	return nil
 }
 
+// drainStorageNegotiations stops the storage client and host from starting new negotiations,
+// and waits for the negotiations in progress to finish within the drain timeout
+func (s *Ethereum) drainStorageNegotiations() {
+	var wg sync.WaitGroup
+	if s.config.StorageClient {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.storageClient.DrainNegotiations()
+		}()
+	}
+	if s.config.StorageHost {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.storageHost.DrainNegotiations()
+		}()
+	}
+	wg.Wait()
+}
+
 // TryToRenewOrRevise is used to check if the contract is currently
 // revising
 func (s *Ethereum) TryToRenewOrRevise(hostID enode.ID) bool {
@@ -739,7 +793,7 @@ func (s *Ethereum) TryToRenewOrRevise(hostID enode.ID) bool {
 	}
 
 	// otherwise, check if the current connection is revising the contract
-	return peer.TryToRenewOrRevise()
+	return peer.tryToRenew()
 }
 
 // RevisionOrRenewingDone indicates the renew finished
@@ -751,11 +805,12 @@ func (s *Ethereum) RevisionOrRenewingDone(hostID enode.ID) {
 	}
 
 	// finished renewing
-	peer.RevisionOrRenewingDone()
+	peer.renewDone()
 }
 
 // SetupConnection will establish p2p static connection between storage client and storage host
-// only storage is able to initiate the set up connection operation
+// only storage is able to initiate the set up connection operation. A new negotiation session over
+// the connection is returned, which must be closed once the negotiation finished
 func (s *Ethereum) SetupConnection(enodeURL string) (storagePeer storage.Peer, err error) {
 	// get the peer ID
 	var destNode *enode.Node
@@ -773,8 +828,8 @@ func (s *Ethereum) SetupConnection(enodeURL string) (storagePeer storage.Peer, e
 		// if the connection already existed, convert the connection
 		// to the static connection
 		s.server.SetStatic(destNode)
-		// connection is already established
-		storagePeer = peer
+		// connection is already established, start a new negotiation session over it
+		storagePeer = peer.newClientSession()
 		return
 	}
 
@@ -789,8 +844,8 @@ func (s *Ethereum) SetupConnection(enodeURL string) (storagePeer storage.Peer, e
 			if !peer.IsStaticConn() {
 				s.server.SetStatic(destNode)
 			}
-			// assign the peer and return
-			storagePeer = peer
+			// start a new negotiation session and return
+			storagePeer = peer.newClientSession()
 			return
 		}
 
@@ -816,6 +871,7 @@ func (s *Ethereum) GetStorageHostSetting(enodeID enode.ID, enodeURL string, conf
 	if err != nil {
 		return fmt.Errorf("failed to get the storage host configuration: %s", err.Error())
 	}
+	defer sp.Close()
 
 	// check if the client is currently requesting the host config
 	// once done, release the channel
@@ -824,8 +880,35 @@ func (s *Ethereum) GetStorageHostSetting(enodeID enode.ID, enodeURL string, conf
 	}
 	defer sp.RequestHostConfigDone()
 
-	// send storage host config request information
-	if err := sp.RequestStorageHostConfig(); err != nil {
+	// send storage host config request information, carrying the stamp of the config
+	// cached, so that the storage host could send the fields changed only
+	if err := requestStorageHostConfig(sp, storage.NewHostConfigRequest(*config), config); err != nil {
+		// the config delta could not be applied to the config cached, request the whole config
+		if _, ok := err.(errHostConfigDelta); !ok {
+			return err
+		}
+		log.Debug("Failed to apply the storage host config delta", "err", err)
+		if err := requestStorageHostConfig(sp, storage.HostConfigRequest{}, config); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Successfully get the storage host settings")
+
+	// check the connection and update the connection
+	// type if necessary
+	s.CheckAndUpdateConnection(sp.PeerNode())
+
+	return nil
+}
+
+// errHostConfigDelta is the error of applying the host config delta to the config cached
+type errHostConfigDelta struct{ error }
+
+// requestStorageHostConfig sends the host config request to the storage host, and decodes the
+// config or the config delta responded into the config
+func requestStorageHostConfig(sp storage.Peer, req storage.HostConfigRequest, config *storage.HostExtConfig) error {
+	if err := sp.RequestStorageHostConfig(req); err != nil {
 		return fmt.Errorf("failed to request storage host configuration: %s", err)
 	}
 
@@ -835,16 +918,13 @@ func (s *Ethereum) GetStorageHostSetting(enodeID enode.ID, enodeURL string, conf
 		return fmt.Errorf("received error while waiting for retriving storage host config: %s", err.Error())
 	}
 
-	if err := msg.Decode(config); err != nil {
-		return fmt.Errorf("error decoding the storage configuration: %s", err.Error())
+	if err := storage.DecodeHostConfig(msg, config); err != nil {
+		err = fmt.Errorf("error decoding the storage configuration: %s", err.Error())
+		if msg.Code == storage.HostConfigDeltaMsg {
+			return errHostConfigDelta{err}
+		}
+		return err
 	}
-
-	log.Info("Successfully get the storage host settings")
-
-	// check the connection and update the connection
-	// type if necessary
-	s.CheckAndUpdateConnection(sp.PeerNode())
-
 	return nil
 }
 
@@ -32,6 +32,7 @@ import (
 	"github.com/DxChainNetwork/godx/eth/gasprice"
 	"github.com/DxChainNetwork/godx/node"
 	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient"
 )
 
@@ -61,9 +62,14 @@ var DefaultConfig = Config{
 		Blocks:     20,
 		Percentile: 60,
 	},
+	RPCGasCap:        25000000,
+	RPCEVMTimeout:    5 * time.Second,
 	StorageClientDir: storageclient.PersistDirectory,
 	StorageClient:    true,
 	StorageHost:      true,
+
+	StorageCompression:  storage.DefaultCompressionConfig,
+	StorageDrainTimeout: storage.DefaultDrainTimeout,
 }
 
 func init() {
@@ -132,6 +138,12 @@ type Config struct {
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
+	// Enables collecting the execution count and time of each opcode in the VM
+	EnableOpcodeProfiling bool
+
+	// Enables checking the invariants of the dpos context after processing each block
+	ValidateDposContext bool
+
 	// Miscellaneous options
 	DocRoot string `toml:"-"`
 
@@ -141,6 +153,13 @@ type Config struct {
 	// Type of the EVM interpreter ("" for default)
 	EVMInterpreter string
 
+	// RPCGasCap is the gas cap of eth_call and eth_estimateGas, including the calls to
+	// the storage and dpos precompiled contracts (0 = no cap)
+	RPCGasCap uint64
+
+	// RPCEVMTimeout is the timeout of eth_call and eth_estimateGas (0 = no timeout)
+	RPCEVMTimeout time.Duration
+
 	// Constantinople block override (TODO: remove after the fork)
 	ConstantinopleOverride *big.Int
 
@@ -150,6 +169,14 @@ type Config struct {
 	// Role, can only be one of the two roles
 	StorageClient bool
 	StorageHost   bool
+
+	// StorageCompression is the compression of the negotiation messages requested in the
+	// storage handshake
+	StorageCompression storage.CompressionConfig
+
+	// StorageDrainTimeout is the time waited for the storage negotiations in progress to
+	// finish on shutdown
+	StorageDrainTimeout time.Duration
 }
 
 type configMarshaling struct {
@@ -8,15 +8,18 @@ import (
 	"errors"
 	"github.com/DxChainNetwork/godx/storage/storagehost"
 
-	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p"
 	"github.com/DxChainNetwork/godx/storage"
 )
 
 var hostHandlers = map[uint64]func(h *storagehost.StorageHost, sp storage.Peer, msg p2p.Msg){
-	storage.ContractCreateReqMsg:   storagehost.ContractCreateHandler,
-	storage.ContractUploadReqMsg:   storagehost.UploadHandler,
-	storage.ContractDownloadReqMsg: storagehost.DownloadHandler,
+	storage.ContractCreateReqMsg:        storagehost.ContractCreateHandler,
+	storage.ContractUploadReqMsg:        storagehost.UploadHandler,
+	storage.ContractDownloadReqMsg:      storagehost.DownloadHandler,
+	storage.ContractDownloadBatchReqMsg: storagehost.DownloadBatchHandler,
+	storage.PublicReadReqMsg:            storagehost.PublicReadHandler,
+	storage.ContractSectorCheckReqMsg:   storagehost.SectorCheckHandler,
+	storage.ContractRevisionReqMsg:      storagehost.ContractRevisionHandler,
 }
 
 func (pm *ProtocolManager) msgDispatch(msg p2p.Msg, p *peer) error {
@@ -33,6 +36,10 @@ func (pm *ProtocolManager) msgDispatch(msg p2p.Msg, p *peer) error {
 		// hostMsgSchedule
 		return pm.hostMsgSchedule(msg, p)
 
+	case msg.Code < 0x50:
+		// clientMsgSchedule, the client handle message set continued
+		return pm.clientMsgSchedule(msg, p)
+
 	default:
 		// message code exceed the range
 		return errors.New("invalid message code")
@@ -55,10 +62,17 @@ func (pm *ProtocolManager) ethMsgSchedule(msg p2p.Msg, p *peer) error {
 }
 
 func (pm *ProtocolManager) clientMsgSchedule(msg p2p.Msg, p *peer) error {
-	// if the message is hostConfigRespMsg, try to push it to the channel
-	// if failed, discard the message right away, meaning the last config
+	// if the message is hostConfigRespMsg or hostConfigDeltaMsg, try to push it to
+	// the channel if failed, discard the message right away, meaning the last config
 	// message handling is not finished yet
-	if msg.Code == storage.HostConfigRespMsg {
+	if msg.Code == storage.HostConfigRespMsg || msg.Code == storage.HostConfigDeltaMsg {
+		if p.compression.Enabled {
+			var err error
+			if _, msg, err = decodeSessionMsg(msg); err != nil {
+				p.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+				return err
+			}
+		}
 		select {
 		case p.clientConfigMsg <- msg:
 			return nil
@@ -67,18 +81,22 @@ func (pm *ProtocolManager) clientMsgSchedule(msg p2p.Msg, p *peer) error {
 		}
 	}
 
-	// otherwise, push the message into clientContractMsg channel
+	// the host config update is pushed by the host, which is not a part of any session
+	if msg.Code == storage.HostConfigUpdateMsg {
+		return pm.hostConfigUpdateHandler(p, msg)
+	}
+
+	// otherwise, push the message into the contract message channel of the session
 	// similarly, if the channel is full, meaning the previous message
 	// handling was not complete, trigger the error directly because the
 	// client should not receive the request before the handling finished
-	select {
-	case p.clientContractMsg <- msg:
-		return nil
-	default:
-		err := errors.New("clientMsgSchedule error: message received before finishing the previous message handling")
-		log.Error("error handling clientContractMsg", "err", err.Error())
+	id, sessionMsg, err := p.readSessionMsg(msg)
+	if err != nil {
+		p.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
 		return err
 	}
+	p.deliverSessionMsg(false, id, sessionMsg)
+	return nil
 }
 
 func (pm *ProtocolManager) hostMsgSchedule(msg p2p.Msg, p *peer) error {
@@ -88,14 +106,21 @@ func (pm *ProtocolManager) hostMsgSchedule(msg p2p.Msg, p *peer) error {
 		return pm.hostConfigMsgHandler(p, msg)
 	}
 
+	// the negotiation messages are tagged with the session id
+	id, sessionMsg, err := p.readSessionMsg(msg)
+	if err != nil {
+		p.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		return err
+	}
+
 	// gets the handler based on the message code,
 	// if the handler does not exists, meaning it is not request message
 	// handle it as a dialogue message
 	handler, exists := hostHandlers[msg.Code]
 	if !exists {
-		return pm.contractMsgHandler(p, msg)
+		return pm.contractMsgHandler(p, id, sessionMsg)
 	}
 
-	// if handler exists, handle it as the request
-	return pm.contractReqHandler(handler, p, msg)
+	// if handler exists, handle it as the request, which starts a new session
+	return pm.contractReqHandler(handler, p, id, sessionMsg)
 }
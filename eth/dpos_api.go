@@ -38,6 +38,15 @@ type ValidatorInfo struct {
 	RewardRatio uint64         `json:"reward_distribution"`
 }
 
+// EpochRewardInfo stores the block reward distribution record of an address in an epoch
+type EpochRewardInfo struct {
+	Address         common.Address `json:"address"`
+	EpochID         int64          `json:"epoch"`
+	ValidatorReward common.BigInt  `json:"validator_reward"`
+	SharedReward    common.BigInt  `json:"shared_reward"`
+	DelegatorReward common.BigInt  `json:"delegator_reward"`
+}
+
 // NewPublicDposAPI will create a PublicDposAPI object that is used
 // to access all DPOS API Method
 func NewPublicDposAPI(e *Ethereum) *PublicDposAPI {
@@ -78,7 +87,8 @@ func (d *PublicDposAPI) Validator(validatorAddress common.Address, blockNr *rpc.
 	}
 
 	// get the detailed information
-	votes, rewardRatio, minedCount, epochID, err := dpos.GetValidatorInfo(statedb, validatorAddress, d.e.ChainDb(), header)
+	epochInterval := d.e.BlockChain().Config().Dpos.EpochPeriod()
+	votes, rewardRatio, minedCount, epochID, err := dpos.GetValidatorInfo(statedb, validatorAddress, d.e.ChainDb(), header, epochInterval)
 	if err != nil {
 		return ValidatorInfo{}, err
 	}
@@ -175,7 +185,32 @@ func (d *PublicDposAPI) EpochID(blockNr *rpc.BlockNumber) (int64, error) {
 	}
 
 	// calculate epochID and return
-	return dpos.CalculateEpochID(header.Time.Int64()), nil
+	return dpos.CalculateEpochID(header.Time.Int64(), d.e.BlockChain().Config().Dpos.EpochPeriod()), nil
+}
+
+// EpochReward will return the block reward distribution record of the address in the epoch,
+// including the reward received as a validator and as a delegator
+func (d *PublicDposAPI) EpochReward(address common.Address, epochID int64, blockNr *rpc.BlockNumber) (EpochRewardInfo, error) {
+	// get the block header information based on the block number
+	header, err := getHeaderBasedOnNumber(blockNr, d.e)
+	if err != nil {
+		return EpochRewardInfo{}, err
+	}
+
+	// based on the block header root, get the statedb
+	statedb, err := d.e.BlockChain().StateAt(header.Root)
+	if err != nil {
+		return EpochRewardInfo{}, err
+	}
+
+	record := dpos.GetEpochRewardRecord(statedb, address, epochID)
+	return EpochRewardInfo{
+		Address:         address,
+		EpochID:         epochID,
+		ValidatorReward: record.ValidatorReward,
+		SharedReward:    record.SharedReward,
+		DelegatorReward: record.DelegatorReward,
+	}, nil
 }
 
 // getHeaderBasedOnNumber will return the block header information based on the block number provided
@@ -40,6 +40,7 @@ import (
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
 )
 
 const (
@@ -50,6 +51,9 @@ const (
 	// The number is referenced from the size of tx pool.
 	txChanSize = 4096
 
+	// hostConfigChanSize is the size of channel listening to the host config updates.
+	hostConfigChanSize = 16
+
 	// minimim number of peers to broadcast new blocks to
 	minBroadcastPeers = 4
 )
@@ -83,11 +87,20 @@ type ProtocolManager struct {
 	peers        *peerSet
 	SubProtocols []p2p.Protocol
 
+	// misbehavior scores the misbehavior of the storage peers
+	misbehavior *misbehaviorScorer
+
+	// compression is the compression of the negotiation messages requested in the storage handshake
+	compression storage.CompressionConfig
+
 	eventMux      *event.TypeMux
 	txsCh         chan core.NewTxsEvent
 	txsSub        event.Subscription
 	minedBlockSub *event.TypeMuxSubscription
 
+	hostConfigCh  chan storage.HostExtConfig
+	hostConfigSub event.Subscription
+
 	whitelist map[uint64]common.Hash
 
 	// channels for fetcher, syncer, txsyncLoop
@@ -113,12 +126,16 @@ func NewProtocolManager(eth *Ethereum, config *params.ChainConfig, mode download
 		blockchain:  blockchain,
 		chainconfig: config,
 		peers:       newPeerSet(),
+		misbehavior: newMisbehaviorScorer(),
 		whitelist:   whitelist,
 		newPeerCh:   make(chan *peer),
 		noMorePeers: make(chan struct{}),
 		txsyncCh:    make(chan *txsync),
 		quitSync:    make(chan struct{}),
 	}
+	if eth != nil {
+		manager.compression = eth.config.StorageCompression
+	}
 	// Figure out whether to allow fast sync or not
 	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
 		log.Warn("Blockchain not empty, fast sync disabled")
@@ -219,6 +236,13 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.minedBlockSub = pm.eventMux.Subscribe(core.NewMinedBlockEvent{})
 	go pm.minedBroadcastLoop()
 
+	// push the host config updates to the storage clients
+	if pm.eth != nil && pm.eth.storageHost != nil {
+		pm.hostConfigCh = make(chan storage.HostExtConfig, hostConfigChanSize)
+		pm.hostConfigSub = pm.eth.storageHost.SubscribeConfigUpdate(pm.hostConfigCh)
+		go pm.hostConfigUpdateLoop()
+	}
+
 	// start sync handlers
 	go pm.syncer()
 	go pm.txsyncLoop()
@@ -229,6 +253,9 @@ func (pm *ProtocolManager) Stop() {
 
 	pm.txsSub.Unsubscribe()        // quits txBroadcastLoop
 	pm.minedBlockSub.Unsubscribe() // quits blockBroadcastLoop
+	if pm.hostConfigSub != nil {
+		pm.hostConfigSub.Unsubscribe() // quits hostConfigUpdateLoop
+	}
 
 	// Quit the sync loop.
 	// After this send has completed, no new peers will be accepted.
@@ -250,7 +277,9 @@ func (pm *ProtocolManager) Stop() {
 }
 
 func (pm *ProtocolManager) newPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
-	return newPeer(pv, p, newMeteredMsgWriter(rw))
+	peer := newPeer(pv, p, newMeteredMsgWriter(rw))
+	peer.misbehavior = pm.misbehavior
+	return peer
 }
 
 // handle is the callback invoked to manage the life cycle of an eth peer. When
@@ -260,6 +289,11 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	if pm.peers.Len() >= pm.maxPeers && !p.Peer.Info().Network.Trusted {
 		return p2p.DiscTooManyPeers
 	}
+	// Reject the peer banned for the storage misbehavior until the ban expires
+	if pm.misbehavior.banned(p.ID(), time.Now()) {
+		p.Log().Debug("Rejecting banned storage peer")
+		return p2p.DiscUselessPeer
+	}
 	p.Log().Debug("Ethereum peer connected", "name", p.Name())
 
 	// Execute the Ethereum handshake
@@ -274,6 +308,12 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		p.Log().Debug("Ethereum handshake failed", "err", err)
 		return err
 	}
+	if p.sessionSupported() {
+		if err := p.StorageHandshake(pm.compression); err != nil {
+			p.Log().Debug("Storage handshake failed", "err", err)
+			return err
+		}
+	}
 	if rw, ok := p.rw.(*meteredMsgReadWriter); ok {
 		rw.Init(p.version)
 	}
@@ -372,6 +412,10 @@ func (pm *ProtocolManager) handleEthMsg(p *peer, msg p2p.Msg) error {
 		// Status messages should never arrive after the handshake
 		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
 
+	case msg.Code == StorageStatusMsg:
+		// Storage status messages should never arrive after the handshake as well
+		return errResp(ErrExtraStatusMsg, "uncontrolled storage status message")
+
 	// Block header query, collect the requested headers and reply
 	case msg.Code == GetBlockHeadersMsg:
 		// Decode the complex header query
@@ -229,6 +229,15 @@ func (p *testPeer) handshake(t *testing.T, td *big.Int, head common.Hash, genesi
 	if err := p2p.Send(p.app, StatusMsg, msg); err != nil {
 		t.Fatalf("status send: %v", err)
 	}
+	if p.version < eth65 {
+		return
+	}
+	if err := p2p.ExpectMsg(p.app, StorageStatusMsg, &storageStatusData{}); err != nil {
+		t.Fatalf("storage status recv: %v", err)
+	}
+	if err := p2p.Send(p.app, StorageStatusMsg, &storageStatusData{}); err != nil {
+		t.Fatalf("storage status send: %v", err)
+	}
 }
 
 // close terminates the local side of the peer, notifying the remote protocol
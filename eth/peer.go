@@ -27,6 +27,7 @@ import (
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/p2p"
 	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
 	mapset "github.com/deckarep/golang-set"
 )
@@ -57,6 +58,10 @@ const (
 	maxQueuedAnns = 4
 
 	handshakeTimeout = 5 * time.Second
+
+	// maxHostSessions is the maximum number of negotiation sessions initiated by a
+	// peer that the storage host handles concurrently
+	maxHostSessions = 4
 )
 
 // PeerInfo represents a short summary of the Ethereum sub-protocol metadata known
@@ -94,9 +99,7 @@ type peer struct {
 	term        chan struct{}             // Termination channel to stop the broadcaster
 
 	// eth and storage message channel
-	clientConfigMsg   chan p2p.Msg
-	clientContractMsg chan p2p.Msg
-	hostContractMsg   chan p2p.Msg
+	clientConfigMsg chan p2p.Msg
 
 	ethMsgBuffer      []p2p.Msg
 	ethStartIndicator chan struct{}
@@ -105,37 +108,59 @@ type peer struct {
 	hostConfigProcessing   chan struct{}
 	hostContractProcessing chan struct{}
 
-	contractRevisingOrRenewing chan struct{}
-	hostConfigRequesting       chan struct{}
+	hostConfigRequesting chan struct{}
+
+	// contract revisions could be done concurrently, while renewing is exclusive
+	renewLock sync.Mutex
+	revising  int
+	renewing  bool
+
+	// storage negotiation sessions over the connection, the client sessions are
+	// initiated by this node, and the host sessions are initiated by the peer
+	sessionLock    sync.Mutex
+	nextSessionID  uint64
+	clientSessions map[uint64]*storageSession
+	hostSessions   map[uint64]*storageSession
+
+	// legacySession is held by the client session with the peer not supporting the
+	// sessions, whose negotiation messages could not be told apart
+	legacySession chan struct{}
 
 	// error channel
 	errMsg chan error
 
+	// misbehavior is the scorer of the storage misbehavior, shared by all peers
+	misbehavior *misbehaviorScorer
+
+	// compression is the compression of the negotiation messages negotiated
+	// during the storage handshake
+	compression storage.CompressionConfig
+
 	checkPeerStopHook func(*peer) error
 }
 
 func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 	return &peer{
-		Peer:                       p,
-		rw:                         rw,
-		version:                    version,
-		id:                         fmt.Sprintf("%x", p.ID().Bytes()[:8]),
-		knownTxs:                   mapset.NewSet(),
-		knownBlocks:                mapset.NewSet(),
-		queuedTxs:                  make(chan []*types.Transaction, maxQueuedTxs),
-		queuedProps:                make(chan *propEvent, maxQueuedProps),
-		queuedAnns:                 make(chan *types.Block, maxQueuedAnns),
-		term:                       make(chan struct{}),
-		clientConfigMsg:            make(chan p2p.Msg, 1),
-		clientContractMsg:          make(chan p2p.Msg, 1),
-		hostContractMsg:            make(chan p2p.Msg, 1),
-		ethStartIndicator:          make(chan struct{}, 1),
-		hostConfigProcessing:       make(chan struct{}, 1),
-		hostContractProcessing:     make(chan struct{}, 1),
-		errMsg:                     make(chan error, 1),
-		contractRevisingOrRenewing: make(chan struct{}, 1),
-		hostConfigRequesting:       make(chan struct{}, 1),
-		checkPeerStopHook:          checkPeerStop,
+		Peer:                   p,
+		rw:                     rw,
+		version:                version,
+		id:                     fmt.Sprintf("%x", p.ID().Bytes()[:8]),
+		knownTxs:               mapset.NewSet(),
+		knownBlocks:            mapset.NewSet(),
+		queuedTxs:              make(chan []*types.Transaction, maxQueuedTxs),
+		queuedProps:            make(chan *propEvent, maxQueuedProps),
+		queuedAnns:             make(chan *types.Block, maxQueuedAnns),
+		term:                   make(chan struct{}),
+		clientConfigMsg:        make(chan p2p.Msg, 1),
+		ethStartIndicator:      make(chan struct{}, 1),
+		hostConfigProcessing:   make(chan struct{}, 1),
+		hostContractProcessing: make(chan struct{}, maxHostSessions),
+		errMsg:                 make(chan error, 1),
+		hostConfigRequesting:   make(chan struct{}, 1),
+		clientSessions:         make(map[uint64]*storageSession),
+		hostSessions:           make(map[uint64]*storageSession),
+		legacySession:          make(chan struct{}, 1),
+		checkPeerStopHook:      checkPeerStop,
 	}
 }
 
@@ -443,6 +468,59 @@ func (p *peer) readStatus(network uint64, status *statusData, genesis common.Has
 	return nil
 }
 
+// StorageHandshake executes the storage handshake, negotiating the compression of the storage
+// negotiation messages. The compression is used if it is requested by either side, and the
+// payloads smaller than the local threshold are sent as is
+func (p *peer) StorageHandshake(compression storage.CompressionConfig) error {
+	errc := make(chan error, 2)
+	var status storageStatusData // safe to read after two values have been received from errc
+
+	go func() {
+		errc <- p2p.Send(p.rw, StorageStatusMsg, &storageStatusData{
+			Compression:        compression.Enabled,
+			CompressSectorData: compression.SectorData,
+		})
+	}()
+	go func() {
+		errc <- p.readStorageStatus(&status)
+	}()
+	timeout := time.NewTimer(handshakeTimeout)
+	defer timeout.Stop()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errc:
+			if err != nil {
+				return err
+			}
+		case <-timeout.C:
+			return p2p.DiscReadTimeout
+		}
+	}
+	p.compression = storage.CompressionConfig{
+		Enabled:    compression.Enabled || status.Compression,
+		SectorData: compression.SectorData || status.CompressSectorData,
+		Threshold:  compression.Threshold,
+	}
+	return nil
+}
+
+func (p *peer) readStorageStatus(status *storageStatusData) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Code != StorageStatusMsg {
+		return errResp(ErrNoStatusMsg, "first storage msg has code %x (!= %x)", msg.Code, StorageStatusMsg)
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	if err := msg.Decode(status); err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	return nil
+}
+
 // String implements fmt.Stringer.
 func (p *peer) String() string {
 	return fmt.Sprintf("Peer %s [%s]", p.id,
@@ -508,6 +586,18 @@ func (ps *peerSet) Peer(id string) *peer {
 	return ps.peers[id]
 }
 
+// Peers retrieves all the registered peers.
+func (ps *peerSet) Peers() []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		list = append(list, p)
+	}
+	return list
+}
+
 // Len returns if the current number of peers in the set.
 func (ps *peerSet) Len() int {
 	ps.lock.RLock()
@@ -0,0 +1,148 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package eth
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+const (
+	// misbehaviorBanScore is the score at which the misbehaving peer is banned
+	misbehaviorBanScore = 100
+
+	// misbehaviorDecayInterval is the interval a point of the misbehavior score is forgiven
+	misbehaviorDecayInterval = 30 * time.Second
+
+	// misbehaviorBanDuration is the duration the misbehaving peer is banned for
+	misbehaviorBanDuration = time.Hour
+)
+
+// misbehaviorPenalties is the score added for each kind of misbehavior. The invalid proof
+// is hardly an accident, while the timeout could be caused by the slow connection
+var misbehaviorPenalties = map[storage.Misbehavior]int{
+	storage.MisbehaviorInvalidProof: 50,
+	storage.MisbehaviorMalformedMsg: 25,
+	storage.MisbehaviorTimeout:      10,
+}
+
+var errPeerBanned = errors.New("storage peer banned for misbehaving")
+
+// PeerBan is the information of the peer banned for misbehaving
+type PeerBan struct {
+	ID     enode.ID  `json:"id"`
+	Reason string    `json:"reason"`
+	Until  time.Time `json:"until"`
+}
+
+// misbehaviorScore is the misbehavior score of a peer at the time updated
+type misbehaviorScore struct {
+	score   int
+	updated time.Time
+}
+
+// misbehaviorScorer scores the misbehavior of the storage peers, and bans the peer whose
+// score reaches misbehaviorBanScore. The score decays over time, so that the occasional
+// misbehavior does not lead to the ban
+type misbehaviorScorer struct {
+	scores map[enode.ID]*misbehaviorScore
+	bans   map[enode.ID]PeerBan
+	lock   sync.Mutex
+}
+
+// newMisbehaviorScorer creates a new misbehaviorScorer
+func newMisbehaviorScorer() *misbehaviorScorer {
+	return &misbehaviorScorer{
+		scores: make(map[enode.ID]*misbehaviorScore),
+		bans:   make(map[enode.ID]PeerBan),
+	}
+}
+
+// report adds the penalty of the misbehavior to the score of the peer, returning true if
+// the peer is banned
+func (ms *misbehaviorScorer) report(id enode.ID, m storage.Misbehavior, now time.Time) bool {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	if ms.isBanned(id, now) {
+		return true
+	}
+	s, exists := ms.scores[id]
+	if !exists {
+		s = &misbehaviorScore{updated: now}
+		ms.scores[id] = s
+	}
+	s.decay(now)
+	s.score += misbehaviorPenalties[m]
+	if s.score < misbehaviorBanScore {
+		return false
+	}
+
+	// ban the peer, and reset the score so that the peer starts over once the ban expires
+	delete(ms.scores, id)
+	ms.bans[id] = PeerBan{
+		ID:     id,
+		Reason: m.String(),
+		Until:  now.Add(misbehaviorBanDuration),
+	}
+	return true
+}
+
+// banned checks whether the peer is currently banned
+func (ms *misbehaviorScorer) banned(id enode.ID, now time.Time) bool {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+	return ms.isBanned(id, now)
+}
+
+// bannedPeers returns the peers currently banned, sorted by the expiry of the ban
+func (ms *misbehaviorScorer) bannedPeers(now time.Time) []PeerBan {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	bans := make([]PeerBan, 0, len(ms.bans))
+	for id, ban := range ms.bans {
+		if ms.isBanned(id, now) {
+			bans = append(bans, ban)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Until.Before(bans[j].Until)
+	})
+	return bans
+}
+
+// isBanned checks whether the peer is banned, removing the expired ban. The lock must be
+// held by the caller
+func (ms *misbehaviorScorer) isBanned(id enode.ID, now time.Time) bool {
+	ban, exists := ms.bans[id]
+	if !exists {
+		return false
+	}
+	if now.Before(ban.Until) {
+		return true
+	}
+	delete(ms.bans, id)
+	return false
+}
+
+// decay forgives a point of the score for each misbehaviorDecayInterval passed since the
+// score was last updated
+func (s *misbehaviorScore) decay(now time.Time) {
+	points := int(now.Sub(s.updated) / misbehaviorDecayInterval)
+	if points <= 0 {
+		return
+	}
+	s.score -= points
+	if s.score < 0 {
+		s.score = 0
+	}
+	s.updated = s.updated.Add(time.Duration(points) * misbehaviorDecayInterval)
+}
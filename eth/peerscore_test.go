@@ -0,0 +1,51 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package eth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestMisbehaviorScorer(t *testing.T) {
+	ms := newMisbehaviorScorer()
+	id, other := enode.ID{1}, enode.ID{2}
+	now := time.Now()
+
+	// the occasional timeouts are forgiven over time
+	for i := 0; i < 20; i++ {
+		now = now.Add(10 * misbehaviorDecayInterval)
+		if ms.report(id, storage.MisbehaviorTimeout, now) {
+			t.Fatalf("peer banned for the occasional timeouts")
+		}
+	}
+
+	// the repeated invalid proofs lead to the ban
+	if ms.report(id, storage.MisbehaviorInvalidProof, now) {
+		t.Fatalf("peer banned for a single invalid proof")
+	}
+	if !ms.report(id, storage.MisbehaviorInvalidProof, now) {
+		t.Fatalf("peer not banned for the repeated invalid proofs")
+	}
+	if !ms.banned(id, now) || ms.banned(other, now) {
+		t.Fatalf("unexpected banned status")
+	}
+	bans := ms.bannedPeers(now)
+	if len(bans) != 1 || bans[0].ID != id || bans[0].Reason != storage.MisbehaviorInvalidProof.String() {
+		t.Fatalf("unexpected bans: %+v", bans)
+	}
+
+	// the ban expires, and the peer starts over
+	now = now.Add(misbehaviorBanDuration)
+	if ms.banned(id, now) || len(ms.bannedPeers(now)) != 0 {
+		t.Fatalf("ban not expired")
+	}
+	if ms.report(id, storage.MisbehaviorMalformedMsg, now) {
+		t.Fatalf("peer banned right after the ban expired")
+	}
+}
@@ -33,13 +33,14 @@ const (
 	eth62 = 62
 	eth63 = 63
 	eth64 = 64
+	eth65 = 65
 )
 
 // ProtocolVersions are the supported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{eth64, eth63, eth62}
+var ProtocolVersions = []uint{eth65, eth64, eth63, eth62}
 
 // ProtocolLengths are the number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{100, 17, 8}
+var ProtocolLengths = []uint64{100, 100, 17, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -60,6 +61,10 @@ const (
 	NodeDataMsg    = 0x0e
 	GetReceiptsMsg = 0x0f
 	ReceiptsMsg    = 0x10
+
+	// Protocol messages belonging to eth/65, from which the storage negotiation messages
+	// are tagged with the session id
+	StorageStatusMsg = 0x11
 )
 
 type errCode int
@@ -115,6 +120,13 @@ type statusData struct {
 	GenesisBlock    common.Hash
 }
 
+// storageStatusData is the network packet for the storage status message, which negotiates
+// the compression of the storage negotiation messages
+type storageStatusData struct {
+	Compression        bool
+	CompressSectorData bool
+}
+
 // newBlockHashesData is the network packet for the block announcements.
 type newBlockHashesData []struct {
 	Hash   common.Hash // Hash of one particular block being announced
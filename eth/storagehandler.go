@@ -15,6 +15,11 @@ func (pm *ProtocolManager) hostConfigMsgHandler(p *peer, configMsg p2p.Msg) erro
 		return err
 	}
 
+	// the request of the clients before the config stamp is introduced is empty, in
+	// which case the whole config is sent
+	var req storage.HostConfigRequest
+	_ = configMsg.Decode(&req)
+
 	// start the go routine, handle the host config request
 	// once done, release the channel
 	go func() {
@@ -22,6 +27,12 @@ func (pm *ProtocolManager) hostConfigMsgHandler(p *peer, configMsg p2p.Msg) erro
 		defer pm.wg.Done()
 		defer p.HostConfigProcessingDone()
 		config := pm.eth.storageHost.RetrieveExternalConfig()
+		if delta, ok := pm.eth.storageHost.ExternalConfigDelta(req.Stamp, config); ok {
+			if err := p.SendStorageHostConfigDelta(delta); err != nil {
+				p.TriggerError(err)
+			}
+			return
+		}
 		if err := p.SendStorageHostConfig(config); err != nil {
 			p.TriggerError(err)
 		}
@@ -30,36 +41,53 @@ func (pm *ProtocolManager) hostConfigMsgHandler(p *peer, configMsg p2p.Msg) erro
 	return nil
 }
 
-func (pm *ProtocolManager) contractMsgHandler(p *peer, msg p2p.Msg) error {
-	// send the message to the hostContractMsg channel if the handler
-	// does not exist
-	select {
-	case p.hostContractMsg <- msg:
-	default:
-		err := errors.New("hostMsgSchedule error: message received before finishing the previous message handling")
-		log.Error("error handling hostContractMsg", "err", err.Error())
-		return err
-	}
+func (pm *ProtocolManager) contractMsgHandler(p *peer, id uint64, msg p2p.Msg) error {
+	// send the message to the contract message channel of the session
+	// if the handler does not exist
+	p.deliverSessionMsg(true, id, msg)
 	return nil
 }
 
-func (pm *ProtocolManager) contractReqHandler(handler func(h *storagehost.StorageHost, sp storage.Peer, msg p2p.Msg), p *peer, msg p2p.Msg) error {
+func (pm *ProtocolManager) contractReqHandler(handler func(h *storagehost.StorageHost, sp storage.Peer, msg p2p.Msg), p *peer, id uint64, msg p2p.Msg) error {
+	session, err := p.newHostSession(id)
+	if err != nil && !p.sessionSupported() {
+		// the peer not supporting the sessions negotiates in the session 0, the new
+		// request before the previous negotiation finished is rejected as busy
+		_ = (&storageSession{peer: p, id: id, host: true}).SendHostBusyHandleRequestErr()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// reject the new negotiation if the host is shutting down, the peer is kept connected
+	// so that the negotiations in progress could finish
+	if err := pm.eth.storageHost.BeginNegotiation(); err != nil {
+		_ = session.SendHostBusyHandleRequestErr()
+		session.Close()
+		return nil
+	}
+
 	// avoid continuously contract related requests attack
 	// generate too many go routines and used all resources
 	if err := p.HostContractProcessing(); err != nil {
 		// error is ignored intentionally. If error occurred,
 		// the client must wait until time out
-		_ = p.SendHostBusyHandleRequestErr()
+		_ = session.SendHostBusyHandleRequestErr()
+		session.Close()
+		pm.eth.storageHost.EndNegotiation()
 		return err
 	}
 
 	// start the go routine, handle the host contract request
-	// once done, release the channel
+	// once done, release the channel and close the session
 	go func() {
 		pm.wg.Add(1)
 		defer pm.wg.Done()
+		defer pm.eth.storageHost.EndNegotiation()
 		defer p.HostContractProcessingDone()
-		handler(pm.eth.storageHost, p, msg)
+		defer session.Close()
+		handler(pm.eth.storageHost, session, msg)
 	}()
 
 	return nil
@@ -99,3 +127,59 @@ func (pm *ProtocolManager) waitEthStartIndicator(p *peer) error {
 		return errors.New("protocol manager sync quit")
 	}
 }
+
+// hostConfigUpdateLoop pushes the host config updates to the connected storage clients
+func (pm *ProtocolManager) hostConfigUpdateLoop() {
+	for {
+		select {
+		case config := <-pm.hostConfigCh:
+			pm.broadcastHostConfigUpdate(config)
+
+		// Err() channel will be closed when unsubscribing.
+		case <-pm.hostConfigSub.Err():
+			return
+		}
+	}
+}
+
+// broadcastHostConfigUpdate signs the host config update with the node key, and sends it to
+// the connected storage clients which signed the contracts with the host
+func (pm *ProtocolManager) broadcastHostConfigUpdate(config storage.HostExtConfig) {
+	update := storage.HostConfigUpdate{Config: config}
+	sig, err := pm.eth.SignWithNodeSk(update.SigHash().Bytes())
+	if err != nil {
+		log.Warn("Failed to sign the host config update", "err", err)
+		return
+	}
+	update.Signature = sig
+
+	for _, p := range pm.peers.Peers() {
+		if !pm.eth.storageHost.IsContractSignedWithClient(p.Node()) {
+			continue
+		}
+		if err := p.SendHostConfigUpdate(update); err != nil {
+			p.Log().Debug("Failed to send the host config update", "err", err)
+		}
+	}
+}
+
+// hostConfigUpdateHandler refreshes the config of the storage host cached by the storage client
+// with the config update pushed by the host
+func (pm *ProtocolManager) hostConfigUpdateHandler(p *peer, msg p2p.Msg) error {
+	var update storage.HostConfigUpdate
+	if err := msg.Decode(&update); err != nil {
+		p.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		return err
+	}
+	if err := update.Verify(p.ID()); err != nil {
+		p.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		return err
+	}
+	if pm.eth.storageClient == nil {
+		return nil
+	}
+	if err := pm.eth.storageClient.UpdateHostConfig(p.ID(), update.Config); err != nil {
+		p.Log().Debug("Failed to update the host config", "err", err)
+	}
+	return nil
+}
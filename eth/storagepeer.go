@@ -8,6 +8,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/p2p"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
@@ -23,22 +24,62 @@ func (p *peer) TriggerError(err error) {
 	}
 }
 
+// ReportMisbehavior scores the misbehavior of the peer. If the peer is banned for
+// misbehaving repeatedly, the connection will be torn down
+func (p *peer) ReportMisbehavior(m storage.Misbehavior, err error) {
+	if p.misbehavior == nil {
+		return
+	}
+	p.Log().Debug("Storage peer misbehaved", "misbehavior", m, "err", err)
+	if p.misbehavior.report(p.ID(), m, time.Now()) {
+		p.Log().Warn("Banning misbehaving storage peer", "misbehavior", m, "duration", misbehaviorBanDuration)
+		p.TriggerError(errPeerBanned)
+	}
+}
+
 // SendStorageHostConfig will send the storage host configuration to the client
-// once the host got the request from the storage client
+// once the host got the request from the storage client. If the compression is negotiated
+// with the peer, the configuration is wrapped as the session message, which could be compressed
 func (p *peer) SendStorageHostConfig(config storage.HostExtConfig) error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
+	if err = p.checkPeerStopHook(p); err != nil {
+		return err
+	}
+	if !p.compression.Enabled {
 		return p2p.Send(p.rw, storage.HostConfigRespMsg, config)
 	}
-	return err
+	msg, err := p.encodeSessionMsg(0, storage.HostConfigRespMsg, config)
+	if err != nil {
+		return err
+	}
+	return p2p.Send(p.rw, storage.HostConfigRespMsg, msg)
+}
+
+// SendStorageHostConfigDelta will send the fields of the storage host configuration changed
+// since the configuration cached by the client. Same as the whole configuration, it is wrapped
+// as the session message if the compression is negotiated
+func (p *peer) SendStorageHostConfigDelta(delta storage.HostConfigDelta) error {
+	var err error
+	if err = p.checkPeerStopHook(p); err != nil {
+		return err
+	}
+	if !p.compression.Enabled {
+		return p2p.Send(p.rw, storage.HostConfigDeltaMsg, delta)
+	}
+	msg, err := p.encodeSessionMsg(0, storage.HostConfigDeltaMsg, delta)
+	if err != nil {
+		return err
+	}
+	return p2p.Send(p.rw, storage.HostConfigDeltaMsg, msg)
 }
 
 // RequestStorageHostConfig is used when the client is trying to request host's
-// configuration. The HostConfigReqMsg will be sent to the storage host
-func (p *peer) RequestStorageHostConfig() error {
+// configuration. The HostConfigReqMsg will be sent to the storage host, carrying
+// the stamp of the configuration cached by the client
+func (p *peer) RequestStorageHostConfig(req storage.HostConfigRequest) error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.HostConfigReqMsg, struct{}{})
+		return p2p.Send(p.rw, storage.HostConfigReqMsg, req)
 	}
 	return err
 }
@@ -46,41 +87,41 @@ func (p *peer) RequestStorageHostConfig() error {
 // RequestContractCreate will be used when the storage client is trying to create
 // the contract with desired storage host. ContractCreateReqMsg will be sent to the
 // storage host
-func (p *peer) RequestContractCreation(req storage.ContractCreateRequest) error {
+func (s *storageSession) RequestContractCreation(req storage.ContractCreateRequest) error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ContractCreateReqMsg, req)
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractCreateReqMsg, req)
 	}
 	return err
 }
 
 // SendContractCreateClientRevisionSig will be used once the storage client drafted and
 // signed a contract revision and requesting the validation and signature from the storage host
-func (p *peer) SendContractCreateClientRevisionSign(revisionSign []byte) error {
+func (s *storageSession) SendContractCreateClientRevisionSign(revisionSign []byte) error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ContractCreateClientRevisionSign, revisionSign)
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractCreateClientRevisionSign, revisionSign)
 	}
 	return err
 }
 
 // SendContractCreationHostSign will be used once the host received the ContractCreateReqMsg
 // message from the client. The host will validated the contract, sign it, and sent back to
-// the storage client
-func (p *peer) SendContractCreationHostSign(contractSign []byte) error {
+// the storage client along with the cost breakdown of the contract
+func (s *storageSession) SendContractCreationHostSign(resp storage.ContractCreateResponse) error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ContractCreateHostSign, contractSign)
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractCreateHostSign, resp)
 	}
 	return err
 }
 
 // SendContractCreationHostRevisionSign will be used once the host received the revised
 // contract from the storage client. Host will validate it, sign it, and send it back
-func (p *peer) SendContractCreationHostRevisionSign(revisionSign []byte) error {
+func (s *storageSession) SendContractCreationHostRevisionSign(revisionSign []byte) error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ContractCreateRevisionSign, revisionSign)
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractCreateRevisionSign, revisionSign)
 	}
 	return err
 }
@@ -88,30 +129,30 @@ func (p *peer) SendContractCreationHostRevisionSign(revisionSign []byte) error {
 // RequestContractUpload is used when the client is trying to upload data
 // to the corresponded storage host. Upload request must be sent to the storage
 // host first
-func (p *peer) RequestContractUpload(req storage.UploadRequest) error {
+func (s *storageSession) RequestContractUpload(req storage.UploadRequest) error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ContractUploadReqMsg, req)
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractUploadReqMsg, req)
 	}
 	return err
 }
 
 // SendContractUploadClientRevisionSign will be sent by the storage client
 // once the client received the merkle proof sent by the storage host
-func (p *peer) SendContractUploadClientRevisionSign(revisionSign []byte) error {
+func (s *storageSession) SendContractUploadClientRevisionSign(revisionSign []byte) error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ContractUploadClientRevisionSign, revisionSign)
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractUploadClientRevisionSign, revisionSign)
 	}
 	return err
 }
 
 // SendUploadMerkleProof is sent by the storage host to prove that it has the data
 // that storage client needed
-func (p *peer) SendUploadMerkleProof(merkleProof storage.UploadMerkleProof) error {
+func (s *storageSession) SendUploadMerkleProof(merkleProof storage.UploadMerkleProof) error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ContractUploadMerkleProofMsg, merkleProof)
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractUploadMerkleProofMsg, merkleProof)
 	}
 	return err
 }
@@ -119,103 +160,206 @@ func (p *peer) SendUploadMerkleProof(merkleProof storage.UploadMerkleProof) erro
 // SendUploadHostRevisionSign will be used once the storage host received the contract upload client
 // revision sign sent by the storage client. Host will validate the revised contract, sign it, and
 // send it back to the storage client
-func (p *peer) SendUploadHostRevisionSign(revisionSign []byte) error {
+func (s *storageSession) SendUploadHostRevisionSign(revisionSign []byte) error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ContractUploadRevisionSign, revisionSign)
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractUploadRevisionSign, revisionSign)
 	}
 	return err
 }
 
 // RequestContractDownload will be used when the storage client wants to download
 // data pieces from the corresponded storage host
-func (p *peer) RequestContractDownload(req storage.DownloadRequest) error {
+func (s *storageSession) RequestContractDownload(req storage.DownloadRequest) error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ContractDownloadReqMsg, req)
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractDownloadReqMsg, req)
+	}
+	return err
+}
+
+// RequestContractDownloadBatch will be used when the storage client wants to download
+// multiple data pieces from the corresponded storage host within a single negotiation
+func (s *storageSession) RequestContractDownloadBatch(req storage.DownloadBatchRequest) error {
+	var err error
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractDownloadBatchReqMsg, req)
+	}
+	return err
+}
+
+// RequestPublicRead will be used when the storage client wants to read the public
+// sectors from the storage host without a contract
+func (s *storageSession) RequestPublicRead(req storage.PublicReadRequest) error {
+	var err error
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.PublicReadReqMsg, req)
+	}
+	return err
+}
+
+// RequestSectorCheck will be used when the storage client wants to know whether the
+// sector is already stored by the storage host under the contract before uploading it
+func (s *storageSession) RequestSectorCheck(req storage.SectorCheckRequest) error {
+	var err error
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractSectorCheckReqMsg, req)
+	}
+	return err
+}
+
+// SendSectorStored is sent by the storage host as the response of the sector check,
+// with the merkle proof of the sector root if the sector is stored
+func (s *storageSession) SendSectorStored(resp storage.SectorStoredResponse) error {
+	var err error
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractSectorStoredMsg, resp)
+	}
+	return err
+}
+
+// RequestContractRevision will be used when the storage client wants to reconcile the
+// revision of the interrupted negotiation with the latest revision of the storage host
+func (s *storageSession) RequestContractRevision(req storage.ContractRevisionRequest) error {
+	if !s.sessionSupported() {
+		return storage.ErrContractRevisionUnsupported
+	}
+	var err error
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractRevisionReqMsg, req)
+	}
+	return err
+}
+
+// SendContractRevision is sent by the storage host as the response of the contract
+// revision request, with the latest revision of the contract
+func (s *storageSession) SendContractRevision(rev types.StorageContractRevision) error {
+	var err error
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractRevisionMsg, rev)
 	}
 	return err
 }
 
 // SendContractDownloadData is sent by the client. Data piece requested by the
 // storage client will be included
-func (p *peer) SendContractDownloadData(resp storage.DownloadResponse) error {
+func (s *storageSession) SendContractDownloadData(resp storage.DownloadResponse) error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ContractDownloadDataMsg, resp)
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractDownloadDataMsg, resp)
+	}
+	return err
+}
+
+// SendContractDownloadBatchData is sent by the host. Data pieces of all sections requested
+// in the download batch by the storage client will be included
+func (s *storageSession) SendContractDownloadBatchData(resp storage.DownloadBatchResponse) error {
+	var err error
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ContractDownloadBatchDataMsg, resp)
 	}
 	return err
 }
 
 // SendHostBusyHandleRequestErr will send a error message to client, stating that
 // the host is currently busy handling the previous error message
-func (p *peer) SendHostBusyHandleRequestErr() error {
+func (s *storageSession) SendHostBusyHandleRequestErr() error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.HostBusyHandleReqMsg, "error handling")
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.HostBusyHandleReqMsg, "error handling")
 	}
 	return err
 }
 
 // SendClientNegotiateErrorMsg will send client negotiate error msg
-func (p *peer) SendClientNegotiateErrorMsg() error {
+func (s *storageSession) SendClientNegotiateErrorMsg() error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ClientNegotiateErrorMsg, storage.ErrClientNegotiate.Error())
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ClientNegotiateErrorMsg, storage.ErrClientNegotiate.Error())
 	}
 	return err
 }
 
 // SendClientCommitFailedMsg will send a error msg to Host, indicating that client occurs exception
 // when executing 'Commit Action'
-func (p *peer) SendClientCommitFailedMsg() error {
+func (s *storageSession) SendClientCommitFailedMsg() error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ClientCommitFailedMsg, storage.ErrClientCommit.Error())
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ClientCommitFailedMsg, storage.ErrClientCommit.Error())
 	}
 	return err
 }
 
 // SendClientCommitSuccessMsg will send a success msg to Host, indicating that client has no error after 'Commit Action'
-func (p *peer) SendClientCommitSuccessMsg() error {
+func (s *storageSession) SendClientCommitSuccessMsg() error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ClientCommitSuccessMsg, "commit success")
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ClientCommitSuccessMsg, "commit success")
 	}
 	return err
 }
 
 // SendClientCommitSuccessMsg will send host commit failed msg to client
-func (p *peer) SendHostCommitFailedMsg() error {
+func (s *storageSession) SendHostCommitFailedMsg() error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.HostCommitFailedMsg, storage.ErrHostCommit.Error())
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.HostCommitFailedMsg, storage.ErrHostCommit.Error())
 	}
 	return err
 }
 
-func (p *peer) SendClientAckMsg() error {
+func (s *storageSession) SendClientAckMsg() error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.ClientAckMsg, "client ack")
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.ClientAckMsg, "client ack")
 	}
 	return err
 }
 
 // SendHostAckMsg will send host ack msg to client as the last negotiate msg no matter what success or failed
-func (p *peer) SendHostAckMsg() error {
+func (s *storageSession) SendHostAckMsg() error {
 	var err error
-	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.HostAckMsg, "host ack")
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.HostAckMsg, "host ack")
 	}
 	return err
 }
 
 // SendHostNegotiateErrorMsg will send host negotiate error msg
-func (p *peer) SendHostNegotiateErrorMsg() error {
+func (s *storageSession) SendHostNegotiateErrorMsg() error {
+	var err error
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.HostNegotiateErrorMsg, storage.ErrHostNegotiate.Error())
+	}
+	return err
+}
+
+// SendHostConfigChangedMsg will send the message to the client, stating that the host
+// config used by the client is outdated
+func (s *storageSession) SendHostConfigChangedMsg() error {
+	var err error
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.HostConfigChangedMsg, storage.ErrHostConfigChanged.Error())
+	}
+	return err
+}
+
+// SendHostFullMsg will send the message to the client, stating that the host is running
+// out of the free space for the data appended
+func (s *storageSession) SendHostFullMsg() error {
+	var err error
+	if err = s.checkPeerStopHook(s.peer); err == nil {
+		return s.send(storage.HostFullMsg, storage.ErrHostFull.Error())
+	}
+	return err
+}
+
+// SendHostConfigUpdate pushes the signed host config update to the storage client, once
+// the host changed the prices or the acceptance of the contracts
+func (p *peer) SendHostConfigUpdate(update storage.HostConfigUpdate) error {
 	var err error
 	if err = p.checkPeerStopHook(p); err == nil {
-		return p2p.Send(p.rw, storage.HostNegotiateErrorMsg, storage.ErrHostNegotiate.Error())
+		return p2p.Send(p.rw, storage.HostConfigUpdateMsg, update)
 	}
 	return err
 }
@@ -229,6 +373,7 @@ func (p *peer) WaitConfigResp() (msg p2p.Msg, err error) {
 		return
 	case <-timeout:
 		err = errors.New("timeout -> client waits too long for config response from the host")
+		p.ReportMisbehavior(storage.MisbehaviorTimeout, err)
 		return
 	case <-p.StopChan():
 		err = coinchargemaintenance.ErrProgramExit
@@ -238,15 +383,16 @@ func (p *peer) WaitConfigResp() (msg p2p.Msg, err error) {
 
 // ClientWaitContractResp is used by the storage client. The method will block the current
 // process until the response was sent back from the storage host
-func (p *peer) ClientWaitContractResp() (msg p2p.Msg, err error) {
+func (s *storageSession) ClientWaitContractResp() (msg p2p.Msg, err error) {
 	timeout := time.After(1 * time.Minute)
 	select {
-	case msg = <-p.clientContractMsg:
+	case msg = <-s.contractMsg:
 		return
 	case <-timeout:
 		err = errors.New("timeout -> client waits too long for contract response from the host")
+		s.ReportMisbehavior(storage.MisbehaviorTimeout, err)
 		return
-	case <-p.StopChan():
+	case <-s.StopChan():
 		err = coinchargemaintenance.ErrProgramExit
 		return
 	}
@@ -254,15 +400,16 @@ func (p *peer) ClientWaitContractResp() (msg p2p.Msg, err error) {
 
 // HostWaitContractResp is used by the storage host. The method will block the current
 // process until the response was sent back from the storage client
-func (p *peer) HostWaitContractResp() (msg p2p.Msg, err error) {
+func (s *storageSession) HostWaitContractResp() (msg p2p.Msg, err error) {
 	timeout := time.After(1 * time.Minute)
 	select {
-	case msg = <-p.hostContractMsg:
+	case msg = <-s.contractMsg:
 		return
 	case <-timeout:
 		err = errors.New("timeout -> host waits too long for contract response from the host")
+		s.ReportMisbehavior(storage.MisbehaviorTimeout, err)
 		return
-	case <-p.StopChan():
+	case <-s.StopChan():
 		err = coinchargemaintenance.ErrProgramExit
 		return
 	}
@@ -314,23 +461,48 @@ func (p *peer) HostContractProcessingDone() {
 	}
 }
 
-// TryToRenewOrRevise will try to renew or revise the contract, if failed
-// the renew process and revision process will be interrupted immediately
+// TryToRenewOrRevise will try to revise the contract. The revisions could be done concurrently
+// in different sessions, but if the contract is renewing, the revision will be interrupted immediately
 func (p *peer) TryToRenewOrRevise() bool {
-	select {
-	case p.contractRevisingOrRenewing <- struct{}{}:
-		return true
-	default:
+	p.renewLock.Lock()
+	defer p.renewLock.Unlock()
+
+	if p.renewing {
 		return false
 	}
+	p.revising++
+	return true
 }
 
-// RevisionOrRenewingDone indicates the revision or renewing operation has been finished
+// RevisionOrRenewingDone indicates the revision operation has been finished
 func (p *peer) RevisionOrRenewingDone() {
-	select {
-	case <-p.contractRevisingOrRenewing:
-	default:
+	p.renewLock.Lock()
+	defer p.renewLock.Unlock()
+
+	if p.revising > 0 {
+		p.revising--
+	}
+}
+
+// tryToRenew will try to renew the contract. Renewing is exclusive, it will fail if the
+// contract is revising or renewing
+func (p *peer) tryToRenew() bool {
+	p.renewLock.Lock()
+	defer p.renewLock.Unlock()
+
+	if p.renewing || p.revising > 0 {
+		return false
 	}
+	p.renewing = true
+	return true
+}
+
+// renewDone indicates the renewing operation has been finished
+func (p *peer) renewDone() {
+	p.renewLock.Lock()
+	defer p.renewLock.Unlock()
+
+	p.renewing = false
 }
 
 // TryRequestHostConfig is used to check if the client is currently requesting storage
@@ -0,0 +1,179 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package eth
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// storageSession is a negotiation session over the peer connection. The negotiation
+// messages are tagged with the session id, and demultiplexed to the session by the peer,
+// so that multiple negotiations with the same peer could be done concurrently. With the
+// peer of the protocol version before eth65, the messages are sent as is, and the
+// negotiations with the peer are done one at a time in the session 0
+type storageSession struct {
+	*peer
+
+	id          uint64
+	host        bool
+	contractMsg chan p2p.Msg
+}
+
+// sessionSupported checks if the negotiation messages with the peer are tagged with the
+// session id, which is supported from the protocol version eth65
+func (p *peer) sessionSupported() bool {
+	return p.version >= eth65
+}
+
+// newClientSession creates a new session initiated by the storage client. If the peer does
+// not support the sessions, it waits until the previous session with the peer is closed
+func (p *peer) newClientSession() *storageSession {
+	if !p.sessionSupported() {
+		p.legacySession <- struct{}{}
+	}
+
+	p.sessionLock.Lock()
+	defer p.sessionLock.Unlock()
+
+	s := &storageSession{
+		peer:        p,
+		contractMsg: make(chan p2p.Msg, 1),
+	}
+	if p.sessionSupported() {
+		p.nextSessionID++
+		s.id = p.nextSessionID
+	}
+	p.clientSessions[s.id] = s
+	return s
+}
+
+// newHostSession creates the session initiated by the storage client of the peer, which
+// will be handled by the storage host
+func (p *peer) newHostSession(id uint64) (*storageSession, error) {
+	p.sessionLock.Lock()
+	defer p.sessionLock.Unlock()
+
+	if _, exists := p.hostSessions[id]; exists {
+		return nil, fmt.Errorf("storage session %v already exists", id)
+	}
+	s := &storageSession{
+		peer:        p,
+		id:          id,
+		host:        true,
+		contractMsg: make(chan p2p.Msg, 1),
+	}
+	p.hostSessions[id] = s
+	return s, nil
+}
+
+// Close removes the session from the peer. The messages received afterwards for the
+// session will be discarded
+func (s *storageSession) Close() {
+	s.sessionLock.Lock()
+	defer s.sessionLock.Unlock()
+
+	if s.host {
+		delete(s.hostSessions, s.id)
+		return
+	}
+	if s.clientSessions[s.id] != s {
+		return
+	}
+	delete(s.clientSessions, s.id)
+	if !s.sessionSupported() {
+		<-s.legacySession
+	}
+}
+
+// send sends the negotiation message tagged with the session id. If the peer does not
+// support the sessions, the message is sent as is
+func (s *storageSession) send(msgCode uint64, data interface{}) error {
+	if !s.sessionSupported() {
+		return p2p.Send(s.rw, msgCode, data)
+	}
+	sessionMsg, err := s.encodeSessionMsg(s.id, msgCode, data)
+	if err != nil {
+		return err
+	}
+	return p2p.Send(s.rw, msgCode, sessionMsg)
+}
+
+// encodeSessionMsg encodes the negotiation message tagged with the session id. The payload
+// is compressed if it is compressible under the compression negotiated with the peer
+func (p *peer) encodeSessionMsg(id uint64, msgCode uint64, data interface{}) (storage.SessionMsg, error) {
+	payload, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		return storage.SessionMsg{}, err
+	}
+	if !p.compression.Compressible(msgCode, len(payload)) {
+		return storage.SessionMsg{SessionID: id, Payload: payload}, nil
+	}
+	if payload, err = storage.CompressPayload(payload); err != nil {
+		return storage.SessionMsg{}, err
+	}
+	return storage.SessionMsg{SessionID: id, Compressed: true, Payload: payload}, nil
+}
+
+// deliverSessionMsg delivers the negotiation message to the session it belongs to. If the
+// session has already been closed, the message is discarded
+func (p *peer) deliverSessionMsg(host bool, id uint64, msg p2p.Msg) {
+	p.sessionLock.Lock()
+	s, exists := p.clientSessions[id]
+	if host {
+		s, exists = p.hostSessions[id]
+	}
+	p.sessionLock.Unlock()
+
+	if !exists {
+		log.Debug("discard the message of the closed storage session", "session", id, "code", msg.Code)
+		return
+	}
+
+	// if the channel is full, meaning the previous message handling was not complete,
+	// the peer should not send the message before the handling finished. Only the
+	// negotiation of the session is affected, which will fail on the timeout, thus the
+	// message is discarded while the connection with the peer is kept
+	select {
+	case s.contractMsg <- msg:
+	default:
+		log.Warn("discard the storage session message received before finishing the previous one", "session", id, "code", msg.Code)
+	}
+}
+
+// readSessionMsg reads the session id and the negotiation message from the message received
+// from the peer. If the peer does not support the sessions, the message is of the session 0
+func (p *peer) readSessionMsg(msg p2p.Msg) (uint64, p2p.Msg, error) {
+	if !p.sessionSupported() {
+		return 0, msg, nil
+	}
+	return decodeSessionMsg(msg)
+}
+
+// decodeSessionMsg decodes the session id and the negotiation message wrapped
+func decodeSessionMsg(msg p2p.Msg) (uint64, p2p.Msg, error) {
+	var sessionMsg storage.SessionMsg
+	if err := msg.Decode(&sessionMsg); err != nil {
+		return 0, p2p.Msg{}, fmt.Errorf("failed to decode the storage session message: %s", err.Error())
+	}
+	if sessionMsg.Compressed {
+		payload, err := storage.DecompressPayload(sessionMsg.Payload)
+		if err != nil {
+			return 0, p2p.Msg{}, fmt.Errorf("failed to decompress the storage session message: %s", err.Error())
+		}
+		sessionMsg.Payload = payload
+	}
+	return sessionMsg.SessionID, p2p.Msg{
+		Code:       msg.Code,
+		Size:       uint32(len(sessionMsg.Payload)),
+		Payload:    bytes.NewReader(sessionMsg.Payload),
+		ReceivedAt: msg.ReceivedAt,
+	}, nil
+}
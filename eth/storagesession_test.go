@@ -0,0 +1,185 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package eth
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func newTestSessionPeer(version int, rw p2p.MsgReadWriter) *peer {
+	return &peer{
+		rw:                rw,
+		version:           version,
+		clientSessions:    make(map[uint64]*storageSession),
+		hostSessions:      make(map[uint64]*storageSession),
+		legacySession:     make(chan struct{}, 1),
+		checkPeerStopHook: func(*peer) error { return nil },
+	}
+}
+
+func TestStorageSession(t *testing.T) {
+	clientRW, hostRW := p2p.MsgPipe()
+	defer clientRW.Close()
+	client, host := newTestSessionPeer(eth65, clientRW), newTestSessionPeer(eth65, hostRW)
+
+	// two sessions of the client negotiate with the host concurrently
+	s1, s2 := client.newClientSession(), client.newClientSession()
+	if s1.id == s2.id {
+		t.Fatalf("session id should be unique")
+	}
+	go func() {
+		_ = s2.RequestPublicRead(storage.PublicReadRequest{MerkleProof: true})
+		_ = s1.SendClientAckMsg()
+	}()
+
+	for _, expect := range []*storageSession{s2, s1} {
+		msg, err := hostRW.ReadMsg()
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, sessionMsg, err := decodeSessionMsg(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != expect.id {
+			t.Fatalf("session id not expected. Got %v, Expect %v", id, expect.id)
+		}
+		if _, err := host.newHostSession(id); err != nil {
+			t.Fatal(err)
+		}
+		host.deliverSessionMsg(true, id, sessionMsg)
+	}
+
+	// the messages should be delivered to the corresponding host sessions
+	var req storage.PublicReadRequest
+	if err := (<-host.hostSessions[s2.id].contractMsg).Decode(&req); err != nil || !req.MerkleProof {
+		t.Errorf("public read request not expected. Got %+v, err %v", req, err)
+	}
+	if msg := <-host.hostSessions[s1.id].contractMsg; msg.Code != storage.ClientAckMsg {
+		t.Errorf("message code not expected. Got %v, Expect %v", msg.Code, storage.ClientAckMsg)
+	}
+	if _, err := host.newHostSession(s1.id); err == nil {
+		t.Errorf("duplicated session should be rejected")
+	}
+
+	// the message of the closed session should be discarded
+	s1.Close()
+	if _, exists := client.clientSessions[s1.id]; exists {
+		t.Errorf("closed session should be removed")
+	}
+	client.deliverSessionMsg(false, s1.id, p2p.Msg{Code: storage.HostAckMsg})
+
+	// the message received before the previous one is handled is discarded
+	client.deliverSessionMsg(false, s2.id, p2p.Msg{Code: storage.HostAckMsg})
+	client.deliverSessionMsg(false, s2.id, p2p.Msg{Code: storage.HostFullMsg})
+	if msg := <-s2.contractMsg; msg.Code != storage.HostAckMsg {
+		t.Errorf("message code not expected. Got %v, Expect %v", msg.Code, storage.HostAckMsg)
+	}
+	select {
+	case msg := <-s2.contractMsg:
+		t.Errorf("message received before finishing the previous one should be discarded, got %v", msg.Code)
+	default:
+	}
+}
+
+func TestStorageSessionLegacy(t *testing.T) {
+	clientRW, hostRW := p2p.MsgPipe()
+	defer clientRW.Close()
+	client, host := newTestSessionPeer(eth64, clientRW), newTestSessionPeer(eth64, hostRW)
+
+	// the negotiation message is sent as is to the peer not supporting the sessions
+	s1 := client.newClientSession()
+	go func() {
+		_ = s1.RequestPublicRead(storage.PublicReadRequest{MerkleProof: true})
+	}()
+	msg, err := hostRW.ReadMsg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, legacyMsg, err := host.readSessionMsg(msg)
+	if err != nil || id != 0 {
+		t.Fatalf("legacy message should be of the session 0. Got %v, err %v", id, err)
+	}
+	var req storage.PublicReadRequest
+	if err := legacyMsg.Decode(&req); err != nil || !req.MerkleProof {
+		t.Errorf("public read request not expected. Got %+v, err %v", req, err)
+	}
+
+	// the next session waits until the previous one is closed
+	created := make(chan *storageSession)
+	go func() {
+		created <- client.newClientSession()
+	}()
+	select {
+	case <-created:
+		t.Fatal("session created before the previous session with the legacy peer is closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	s1.Close()
+	s1.Close()
+	select {
+	case s2 := <-created:
+		s2.Close()
+	case <-time.After(time.Second):
+		t.Fatal("session not created after the previous session with the legacy peer is closed")
+	}
+}
+
+func TestStorageSessionCompression(t *testing.T) {
+	clientRW, hostRW := p2p.MsgPipe()
+	defer clientRW.Close()
+	client, host := newTestSessionPeer(eth65, clientRW), newTestSessionPeer(eth65, hostRW)
+	client.compression = storage.CompressionConfig{Enabled: true, Threshold: 1024}
+	host.compression = client.compression
+
+	s := host.newClientSession()
+	proof := storage.UploadMerkleProof{OldSubtreeHashes: make([]common.Hash, 128)}
+	go func() {
+		_ = s.SendUploadMerkleProof(proof)
+		_ = s.SendHostAckMsg()
+	}()
+
+	// the merkle proof list is compressed, while the small ack message is sent as is
+	for _, compressed := range []bool{true, false} {
+		msg, err := clientRW.ReadMsg()
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload, err := ioutil.ReadAll(msg.Payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sessionMsg storage.SessionMsg
+		if err := rlp.DecodeBytes(payload, &sessionMsg); err != nil {
+			t.Fatal(err)
+		}
+		if sessionMsg.Compressed != compressed {
+			t.Errorf("message %v compressed %v, expect %v", msg.Code, sessionMsg.Compressed, compressed)
+		}
+		msg.Payload = bytes.NewReader(payload)
+		_, decoded, err := decodeSessionMsg(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.Code != storage.ContractUploadMerkleProofMsg {
+			continue
+		}
+		var got storage.UploadMerkleProof
+		if err := decoded.Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.OldSubtreeHashes) != len(proof.OldSubtreeHashes) {
+			t.Errorf("decompressed merkle proof not expected. Got %v hashes", len(got.OldSubtreeHashes))
+		}
+	}
+}
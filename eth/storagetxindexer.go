@@ -0,0 +1,237 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+const (
+	// storageTxIndexSectionSize is the number of blocks processed in a single section
+	// by the storage transaction indexer
+	storageTxIndexSectionSize = 64
+
+	// storageTxIndexConfirms is the number of confirmation blocks before a section is indexed
+	storageTxIndexConfirms = 16
+)
+
+// StorageTx is a storage contract or dpos transaction recorded by the storage
+// transaction indexer
+type StorageTx struct {
+	BlockNumber uint64         `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"txHash"`
+	TxIndex     uint64         `json:"txIndex"`
+	From        common.Address `json:"from"`
+	Type        string         `json:"type"`
+	ContractID  common.Hash    `json:"contractID"`
+	Summary     string         `json:"summary"`
+}
+
+// StorageTxIndexer implements a core.ChainIndexer, recording the executed storage contract
+// and dpos transactions of each block, together with the locations of the transactions
+// sent by each address, so that the transactions could be queried without re-executing blocks
+type StorageTxIndexer struct {
+	db          ethdb.Database
+	chainConfig *params.ChainConfig
+
+	entries   map[common.Hash][]rawdb.StorageTxEntry // block hash -> storage txs of the block in the section
+	numbers   map[common.Hash]uint64                 // block hash -> block number in the section
+	locations map[common.Address][]rawdb.StorageTxLocation
+}
+
+// NewStorageTxIndexer returns a chain indexer that records the storage transactions of
+// the canonical chain
+func NewStorageTxIndexer(db ethdb.Database, chainConfig *params.ChainConfig) *core.ChainIndexer {
+	backend := &StorageTxIndexer{
+		db:          db,
+		chainConfig: chainConfig,
+	}
+	table := ethdb.NewTable(db, string(rawdb.StorageTxIndexPrefix))
+
+	return core.NewChainIndexer(db, table, backend, storageTxIndexSectionSize, storageTxIndexConfirms, bloomThrottling, "storagetx")
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new storage transaction index section
+func (s *StorageTxIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	s.entries = make(map[common.Hash][]rawdb.StorageTxEntry)
+	s.numbers = make(map[common.Hash]uint64)
+	s.locations = make(map[common.Address][]rawdb.StorageTxLocation)
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, recording the successfully executed
+// storage transactions of the block
+func (s *StorageTxIndexer) Process(ctx context.Context, header *types.Header) error {
+	hash, number := header.Hash(), header.Number.Uint64()
+	body := rawdb.ReadBody(s.db, hash, number)
+	if body == nil {
+		return fmt.Errorf("block body of %x not found", hash)
+	}
+	receipts := rawdb.ReadReceipts(s.db, hash, number)
+	if len(receipts) != len(body.Transactions) {
+		return fmt.Errorf("receipts of %x not match the transactions", hash)
+	}
+
+	signer := types.MakeSigner(s.chainConfig, header.Number)
+	for i, tx := range body.Transactions {
+		txType, ok := storageTxType(s.chainConfig, header.Number, tx)
+		if !ok || receipts[i].Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		contractID, summary := summarizeStorageTx(txType, tx.Data())
+		s.entries[hash] = append(s.entries[hash], rawdb.StorageTxEntry{
+			TxHash:     tx.Hash(),
+			TxIndex:    uint64(i),
+			From:       from,
+			Type:       txType,
+			ContractID: contractID,
+			Summary:    summary,
+		})
+		s.numbers[hash] = number
+		s.locations[from] = append(s.locations[from], rawdb.StorageTxLocation{
+			BlockNumber: number,
+			BlockHash:   hash,
+			TxIndex:     uint64(i),
+		})
+	}
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, writing the storage transactions of the
+// section into the database
+func (s *StorageTxIndexer) Commit() error {
+	batch := s.db.NewBatch()
+	for hash, entries := range s.entries {
+		rawdb.WriteStorageTxEntries(batch, hash, s.numbers[hash], entries)
+	}
+	for address, locations := range s.locations {
+		existing := rawdb.ReadStorageTxLocations(s.db, address)
+		rawdb.WriteStorageTxLocations(batch, address, mergeStorageTxLocations(existing, locations))
+	}
+	return batch.Write()
+}
+
+// mergeStorageTxLocations appends the new locations to the existing ones. The locations
+// already recorded, which happens when a section is re-processed, are skipped
+func mergeStorageTxLocations(existing, locations []rawdb.StorageTxLocation) []rawdb.StorageTxLocation {
+	recorded := make(map[rawdb.StorageTxLocation]struct{})
+	for _, loc := range existing {
+		recorded[loc] = struct{}{}
+	}
+	for _, loc := range locations {
+		if _, exists := recorded[loc]; exists {
+			continue
+		}
+		recorded[loc] = struct{}{}
+		existing = append(existing, loc)
+	}
+	return existing
+}
+
+// storageTxType returns the type of the storage contract or dpos transaction in the block
+// of the number. If the transaction is not sent to the precompiled contracts active at the
+// block, false is returned
+func storageTxType(config *params.ChainConfig, num *big.Int, tx *types.Transaction) (string, bool) {
+	if tx.To() == nil {
+		return "", false
+	}
+	if txType, ok := vm.ActiveStorageContractTxType(config, num, *tx.To()); ok {
+		return txType, true
+	}
+	txType, ok := vm.PrecompiledDPoSContracts[*tx.To()]
+	return txType, ok
+}
+
+// summarizeStorageTx decodes the payload of the storage transaction, returning the
+// storage contract involved and a short summary of the payload
+func summarizeStorageTx(txType string, data []byte) (common.Hash, string) {
+	switch txType {
+	case vm.HostAnnounceTransaction:
+		var ha types.HostAnnouncement
+		if err := rlp.DecodeBytes(data, &ha); err == nil {
+			return common.Hash{}, fmt.Sprintf("netaddress=%s", ha.NetAddress)
+		}
+	case vm.ContractCreateTransaction:
+		var sc types.StorageContract
+		if err := rlp.DecodeBytes(data, &sc); err == nil {
+			return sc.ID(), fmt.Sprintf("filesize=%d windowstart=%d windowend=%d", sc.FileSize, sc.WindowStart, sc.WindowEnd)
+		}
+	case vm.CommitRevisionTransaction:
+		var scr types.StorageContractRevision
+		if err := rlp.DecodeBytes(data, &scr); err == nil {
+			return scr.ParentID, fmt.Sprintf("revision=%d filesize=%d", scr.NewRevisionNumber, scr.NewFileSize)
+		}
+	case vm.StorageProofTransaction:
+		var sp types.StorageProof
+		if err := rlp.DecodeBytes(data, &sp); err == nil {
+			return sp.ParentID, fmt.Sprintf("hashset=%d", len(sp.HashSet))
+		}
+	case vm.StorageProofBatchTransaction:
+		var batch types.StorageProofBatch
+		if err := rlp.DecodeBytes(data, &batch); err == nil {
+			return common.Hash{}, fmt.Sprintf("proofs=%d", len(batch.Proofs))
+		}
+	case vm.RenewContractTransaction:
+		var renewal types.StorageContractRenewal
+		if err := rlp.DecodeBytes(data, &renewal); err == nil {
+			return renewal.NewContract.ID(), fmt.Sprintf("renewed=%s", renewal.OldContractID.Hex())
+		}
+	case vm.ApplyCandidate:
+		var ac types.AddCandidateTxData
+		if err := rlp.DecodeBytes(data, &ac); err == nil {
+			return common.Hash{}, fmt.Sprintf("deposit=%v rewardratio=%d", ac.Deposit, ac.RewardRatio)
+		}
+	case vm.Vote:
+		var vote types.VoteTxData
+		if err := rlp.DecodeBytes(data, &vote); err == nil {
+			return common.Hash{}, fmt.Sprintf("deposit=%v candidates=%d", vote.Deposit, len(vote.Candidates))
+		}
+	}
+	return common.Hash{}, ""
+}
+
+// readStorageTxs returns the indexed storage transactions sent by the address on the
+// canonical chain. If txType is not empty, only the transactions of the type are returned
+func readStorageTxs(db ethdb.Database, address common.Address, txType string) []StorageTx {
+	var txs []StorageTx
+	for _, loc := range rawdb.ReadStorageTxLocations(db, address) {
+		// the section might be indexed before a reorg
+		if rawdb.ReadCanonicalHash(db, loc.BlockNumber) != loc.BlockHash {
+			continue
+		}
+		for _, entry := range rawdb.ReadStorageTxEntries(db, loc.BlockHash, loc.BlockNumber) {
+			if entry.TxIndex != loc.TxIndex || (txType != "" && entry.Type != txType) {
+				continue
+			}
+			txs = append(txs, StorageTx{
+				BlockNumber: loc.BlockNumber,
+				BlockHash:   loc.BlockHash,
+				TxHash:      entry.TxHash,
+				TxIndex:     entry.TxIndex,
+				From:        entry.From,
+				Type:        entry.Type,
+				ContractID:  entry.ContractID,
+				Summary:     entry.Summary,
+			})
+		}
+	}
+	return txs
+}
@@ -0,0 +1,80 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+func TestStorageTxIndexer(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	header := &types.Header{Number: big.NewInt(1)}
+	signer := types.MakeSigner(params.TestChainConfig, header.Number)
+
+	proof := types.StorageProof{ParentID: common.HexToHash("0x01"), HashSet: []common.Hash{{}, {}}}
+	proofData, _ := rlp.EncodeToBytes(proof)
+	txs := []*types.Transaction{
+		types.NewTransaction(0, common.HexToAddress("0x1234"), big.NewInt(1), 21000, big.NewInt(1), nil),
+		types.NewTransaction(1, common.BytesToAddress([]byte{12}), big.NewInt(0), 100000, big.NewInt(1), proofData),
+		types.NewTransaction(2, vm.CancelVoteContractAddress, big.NewInt(0), 100000, big.NewInt(1), nil),
+	}
+	receipts := make(types.Receipts, len(txs))
+	for i := range txs {
+		txs[i], _ = types.SignTx(txs[i], signer, key)
+		receipts[i] = &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: txs[i].Hash()}
+	}
+	// the failed transaction should not be recorded
+	receipts[2].Status = types.ReceiptStatusFailed
+
+	block := types.NewBlock(header, txs, nil, receipts)
+	rawdb.WriteBlock(db, block)
+	rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts)
+	rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+
+	indexer := &StorageTxIndexer{db: db, chainConfig: params.TestChainConfig}
+	// process the section twice to simulate re-indexing
+	for i := 0; i < 2; i++ {
+		if err := indexer.Reset(context.Background(), 0, common.Hash{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := indexer.Process(context.Background(), block.Header()); err != nil {
+			t.Fatal(err)
+		}
+		if err := indexer.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stxs := readStorageTxs(db, from, "")
+	if len(stxs) != 1 {
+		t.Fatalf("storage txs size not expected. Got %v, Expect %v", len(stxs), 1)
+	}
+	stx := stxs[0]
+	if stx.TxHash != txs[1].Hash() || stx.Type != vm.StorageProofTransaction || stx.ContractID != proof.ParentID || stx.Summary != "hashset=2" {
+		t.Errorf("storage tx not expected: %+v", stx)
+	}
+	if stxs := readStorageTxs(db, from, vm.ContractCreateTransaction); len(stxs) != 0 {
+		t.Errorf("storage txs should be filtered by type. Got %v", stxs)
+	}
+
+	// after reorg, the transactions of the non-canonical block should not be returned
+	rawdb.WriteCanonicalHash(db, common.HexToHash("0x02"), block.NumberU64())
+	if stxs := readStorageTxs(db, from, ""); len(stxs) != 0 {
+		t.Errorf("storage txs of non-canonical block should not be returned. Got %v", stxs)
+	}
+}
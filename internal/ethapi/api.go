@@ -652,6 +652,10 @@ func blockToStorageContract(block *types.Block) (map[string]interface{}, error)
 			fields[tx.Hash().String()] = vm.CommitRevisionTransaction
 		case vm.StorageProofTransaction:
 			fields[tx.Hash().String()] = vm.StorageProofTransaction
+		case vm.RenewContractTransaction:
+			fields[tx.Hash().String()] = vm.RenewContractTransaction
+		case vm.StorageProofBatchTransaction:
+			fields[tx.Hash().String()] = vm.StorageProofBatchTransaction
 		case vm.HostAnnounceTransaction:
 			fields[tx.Hash().String()] = vm.HostAnnounceTransaction
 		default:
@@ -759,11 +763,16 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 			}
 		}
 	}
-	// Set default gas & gas price if none were set
+	// Set default gas & gas price if none were set, and cap the gas with the global gas cap,
+	// so that the call could not stall the node with a pathological payload
 	gas, gasPrice := uint64(args.Gas), args.GasPrice.ToInt()
 	if gas == 0 {
 		gas = math.MaxUint64 / 2
 	}
+	if gasCap := s.b.RPCGasCap(); gasCap != 0 && gas > gasCap {
+		log.Debug("Caller gas above allowance, capping", "requested", gas, "cap", gasCap)
+		gas = gasCap
+	}
 	if gasPrice.Sign() == 0 {
 		gasPrice = new(big.Int).SetUint64(defaultGasPrice)
 	}
@@ -795,21 +804,35 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 		evm.Cancel()
 	}()
 
+	// The dpos precompiled contracts are simulated against a copy of the dpos context of
+	// the block, which is discarded afterwards
+	var dposContext *types.DposContext
+	if args.To != nil {
+		if _, ok := vm.PrecompiledDPoSContracts[*args.To]; ok {
+			if dposContext, err = types.NewDposContextFromProto(s.b.ChainDb(), header.DposContext); err != nil {
+				return nil, 0, false, err
+			}
+		}
+	}
+
 	// Setup the gas pool (also for unmetered requests)
 	// and apply the message.
 	gp := new(core.GasPool).AddGas(math.MaxUint64)
-	dposContext := s.b.CurrentBlock().DposCtx()
 	res, gas, failed, err := core.ApplyMessage(evm, msg, gp, dposContext)
 	if err := vmError(); err != nil {
 		return nil, 0, false, err
 	}
+	// If the timer caused an abort, return an appropriate error message
+	if evm.Cancelled() {
+		return nil, 0, false, ErrExecutionAborted
+	}
 	return res, gas, failed, err
 }
 
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
 func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := s.doCall(ctx, args, blockNr, 5*time.Second)
+	result, _, _, err := s.doCall(ctx, args, blockNr, s.b.RPCEVMTimeout())
 	return (hexutil.Bytes)(result), err
 }
 
@@ -832,22 +855,35 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 		}
 		hi = block.GasLimit()
 	}
+	if gasCap := s.b.RPCGasCap(); gasCap != 0 && hi > gasCap {
+		log.Debug("Caller gas above allowance, capping", "requested", hi, "cap", gasCap)
+		hi = gasCap
+	}
 	cap = hi
 
-	// Create a helper to check if a gas allowance results in an executable transaction
-	executable := func(gas uint64) bool {
+	// Create a helper to check if a gas allowance results in an executable transaction.
+	// The aborted execution is not a failure of the gas allowance, but terminates the search
+	timeout := s.b.RPCEVMTimeout()
+	executable := func(gas uint64) (bool, error) {
 		args.Gas = hexutil.Uint64(gas)
 
-		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, 0)
+		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, timeout)
+		if err == ErrExecutionAborted {
+			return false, err
+		}
 		if err != nil || failed {
-			return false
+			return false, nil
 		}
-		return true
+		return true, nil
 	}
 	// Execute the binary search and hone in on an executable gas limit
 	for lo+1 < hi {
 		mid := (hi + lo) / 2
-		if !executable(mid) {
+		ok, err := executable(mid)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
 			lo = mid
 		} else {
 			hi = mid
@@ -855,7 +891,11 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	}
 	// Reject the transaction as invalid if it still fails at the highest allowance
 	if hi == cap {
-		if !executable(hi) {
+		ok, err := executable(hi)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
 			return 0, fmt.Errorf("gas required exceeds allowance or always failing transaction")
 		}
 	}
@@ -1222,6 +1262,29 @@ func transactionToStorageContract(transaction *types.Transaction) (map[string]in
 		}
 		fields["ContractID"] = spf.ParentID
 		fields["StorageContractStorageProof"] = spf
+	case vm.RenewContractTransaction:
+		fields[transaction.Hash().String()] = vm.RenewContractTransaction
+		var renewal types.StorageContractRenewal
+		err := rlp.DecodeBytes(transaction.Data(), &renewal)
+		if err != nil {
+			return fields, errors.New("the data field in the transaction is decoded abnormally")
+		}
+		fields["ContractID"] = renewal.NewContract.RLPHash()
+		fields["OldContractID"] = renewal.OldContractID
+		fields["StorageContract"] = renewal.NewContract
+	case vm.StorageProofBatchTransaction:
+		fields[transaction.Hash().String()] = vm.StorageProofBatchTransaction
+		var batch types.StorageProofBatch
+		err := rlp.DecodeBytes(transaction.Data(), &batch)
+		if err != nil {
+			return fields, errors.New("the data field in the transaction is decoded abnormally")
+		}
+		contractIDs := make([]common.Hash, 0, len(batch.Proofs))
+		for _, sp := range batch.Proofs {
+			contractIDs = append(contractIDs, sp.ParentID)
+		}
+		fields["ContractIDs"] = contractIDs
+		fields["StorageContractStorageProofs"] = batch.Proofs
 	case vm.HostAnnounceTransaction:
 		fields[transaction.Hash().String()] = vm.HostAnnounceTransaction
 		var ha types.HostAnnouncement
@@ -1298,6 +1361,20 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
+	// Decode the result returned by the precompiled storage or dpos contract transaction, or the
+	// reason of the failure
+	if len(receipt.ReturnData) != 0 && tx.To() != nil {
+		fields["returnData"] = hexutil.Bytes(receipt.ReturnData)
+		if len(receipt.PostState) == 0 && receipt.Status == types.ReceiptStatusFailed {
+			if failure, err := vm.DecodePrecompileFailure(receipt.ReturnData); err == nil {
+				fields["failureReason"] = failure.Reason
+			}
+		} else if txType, ok := vm.PrecompiledTxType(*tx.To()); ok {
+			if result, err := vm.DecodePrecompileResult(txType, receipt.ReturnData); err == nil && result != nil {
+				fields["result"] = result
+			}
+		}
+	}
 	return fields, nil
 }
 
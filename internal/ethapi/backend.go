@@ -20,6 +20,7 @@ package ethapi
 import (
 	"context"
 	"math/big"
+	"time"
 
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
@@ -44,6 +45,8 @@ type Backend interface {
 	ChainDb() ethdb.Database
 	EventMux() *event.TypeMux
 	AccountManager() *accounts.Manager
+	RPCGasCap() uint64            // global gas cap for eth_call and eth_estimateGas
+	RPCEVMTimeout() time.Duration // global timeout for eth_call and eth_estimateGas
 
 	// BlockChain API
 	SetHead(number uint64)
@@ -77,6 +80,7 @@ type Backend interface {
 
 func GetAPIs(apiBackend Backend) []rpc.API {
 	nonceLock := new(AddrLocker)
+	resubmitter := NewTxResubmitter(apiBackend, nonceLock)
 	return []rpc.API{
 		{
 			Namespace: "eth",
@@ -121,12 +125,22 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			// only use in system, not for out rpc
 			Namespace: "storagetx",
 			Version:   "1.0",
-			Service:   NewPrivateStorageContractTxAPI(apiBackend, nonceLock),
+			Service:   NewPrivateStorageContractTxAPI(apiBackend, nonceLock, resubmitter),
 			Public:    false,
 		}, {
 			Namespace: "dpos",
 			Version:   "1.0",
-			Service:   NewPublicDposTxAPI(apiBackend, nonceLock),
+			Service:   NewPublicDposTxAPI(apiBackend, nonceLock, resubmitter),
+			Public:    true,
+		}, {
+			Namespace: "txbuilder",
+			Version:   "1.0",
+			Service:   NewPublicTxBuilderAPI(apiBackend),
+			Public:    true,
+		}, {
+			Namespace: "sc",
+			Version:   "1.0",
+			Service:   NewPublicStorageContractAPI(apiBackend),
 			Public:    true,
 		},
 	}
@@ -8,6 +8,6 @@ const (
 	// StorageContractTxGas defines the default gas for storage contract tx
 	StorageContractTxGas = 90000
 
-	// DposTxGas defines the default gas for dpos tx
+	// DposTxGas defines the default gas for dpos tx, used before the operation gas is metered
 	DposTxGas = 1000000
 )
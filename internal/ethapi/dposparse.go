@@ -19,8 +19,9 @@ import (
 	"github.com/DxChainNetwork/godx/rlp"
 )
 
-// ParseAndValidateCandidateApplyTxArgs will parse and validate the candidate apply transaction arguments
-func ParseAndValidateCandidateApplyTxArgs(to common.Address, gas uint64, fields map[string]string, stateDB *state.StateDB, account *accounts.Manager) (*PrecompiledContractTxArgs, error) {
+// ParseAndValidateCandidateApplyTxArgs will parse and validate the candidate apply transaction arguments.
+// minDeposit is the minimum candidate deposit defined in chain config
+func ParseAndValidateCandidateApplyTxArgs(to common.Address, gas uint64, fields map[string]string, stateDB *state.StateDB, account *accounts.Manager, minDeposit common.BigInt) (*PrecompiledContractTxArgs, error) {
 	// parse the candidateAddress field
 	var candidateAddress common.Address
 	if fromStr, ok := fields["from"]; ok {
@@ -42,7 +43,7 @@ func ParseAndValidateCandidateApplyTxArgs(to common.Address, gas uint64, fields
 	}
 
 	// validate candidate tx data
-	if err := dpos.CandidateTxDataValidation(stateDB, addCandidateTxData, candidateAddress); err != nil {
+	if err := dpos.CandidateTxDataValidation(stateDB, addCandidateTxData, candidateAddress, minDeposit); err != nil {
 		return nil, err
 	}
 
@@ -55,8 +56,9 @@ func ParseAndValidateCandidateApplyTxArgs(to common.Address, gas uint64, fields
 	return NewPrecompiledContractTxArgs(candidateAddress, to, data, nil, gas), nil
 }
 
-// ParseAndValidateVoteTxArgs will parse and validate the vote transaction arguments
-func ParseAndValidateVoteTxArgs(to common.Address, gas uint64, fields map[string]string, stateDB *state.StateDB, account *accounts.Manager) (*PrecompiledContractTxArgs, error) {
+// ParseAndValidateVoteTxArgs will parse and validate the vote transaction arguments. All voted
+// candidates must exist in the dposCtx
+func ParseAndValidateVoteTxArgs(to common.Address, gas uint64, fields map[string]string, stateDB *state.StateDB, dposCtx *types.DposContext, account *accounts.Manager) (*PrecompiledContractTxArgs, error) {
 	// parse the delegator account address
 	var delegatorAddress common.Address
 	if fromStr, ok := fields["from"]; ok {
@@ -81,6 +83,9 @@ func ParseAndValidateVoteTxArgs(to common.Address, gas uint64, fields map[string
 	if err := dpos.VoteTxDepositValidation(stateDB, delegatorAddress, voteTxData); err != nil {
 		return nil, err
 	}
+	if err := dpos.VoteTxCandidatesValidation(dposCtx, voteTxData); err != nil {
+		return nil, err
+	}
 
 	// encode and return the data
 	data, err := rlp.EncodeToBytes(&voteTxData)
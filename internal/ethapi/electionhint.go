@@ -0,0 +1,42 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package ethapi
+
+import (
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/params"
+)
+
+// voteElectionMarginBlocks is the number of blocks before the election snapshot, within which
+// the pending vote is unlikely to be mined in time, since it competes with the other txs in
+// the tx pool
+const voteElectionMarginBlocks = 3
+
+// isVoteTx returns whether the tx changes the votes counted in the election
+func isVoteTx(tx *types.Transaction) bool {
+	to := tx.To()
+	return to != nil && (*to == vm.VoteContractAddress || *to == vm.CancelVoteContractAddress)
+}
+
+// lateForElection returns the schedule of the next election, and whether the vote pending at
+// the head block is unlikely to be mined before the election snapshot
+func lateForElection(config *params.DposConfig, head *types.Header, now int64) (dpos.ElectionSchedule, bool) {
+	schedule := dpos.NextElection(config, head, now)
+	return schedule, schedule.SnapshotBlock <= head.Number.Uint64()+voteElectionMarginBlocks
+}
+
+// warnLateVote warns if the vote tx sent is unlikely to be mined before the next election
+func warnLateVote(b Backend, hash common.Hash) {
+	if schedule, late := lateForElection(b.ChainConfig().Dpos, b.CurrentBlock().Header(), time.Now().Unix()); late {
+		log.Warn("The vote is unlikely to be mined before the next epoch election, and may only count from the epoch after",
+			"hash", hash, "snapshotBlock", schedule.SnapshotBlock, "eta", time.Duration(schedule.ETA)*time.Second)
+	}
+}
@@ -61,4 +61,18 @@ var (
 
 	// ErrUnknownParameter is returned if input unknown parameter name in dpos tx
 	ErrUnknownParameter = errors.New("unknown parameter name,cannot parse it")
+
+	// ErrExecutionAborted is returned if the execution of eth_call or eth_estimateGas
+	// is aborted for exceeding the RPC EVM timeout
+	ErrExecutionAborted = errors.New("execution aborted, RPC EVM timeout exceeded")
+
+	// ErrLatestHeaderNotFound is returned if the header of the latest block is not found
+	ErrLatestHeaderNotFound = errors.New("latest block header not found")
+
+	// ErrUnknownTxType is returned if the tx builder is requested to build a tx of unknown type
+	ErrUnknownTxType = errors.New("unknown transaction type")
+
+	// ErrBuildTxWithoutFrom is returned if the tx builder is requested to build a tx without
+	// the address from
+	ErrBuildTxWithoutFrom = errors.New("the address from of the transaction is not provided")
 )
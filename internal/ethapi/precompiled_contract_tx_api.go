@@ -14,21 +14,24 @@ import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/hexutil"
 	"github.com/DxChainNetwork/godx/consensus/dpos"
+	"github.com/DxChainNetwork/godx/core"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rlp"
 	"github.com/DxChainNetwork/godx/rpc"
 )
 
 // PrivateStorageContractTxAPI exposes the storage contract tx methods for the RPC interface
 type PrivateStorageContractTxAPI struct {
-	b         Backend
-	nonceLock *AddrLocker
+	b           Backend
+	nonceLock   *AddrLocker
+	resubmitter *TxResubmitter
 }
 
 // NewPrivateStorageContractTxAPI creates a private RPC service with methods specific for storage contract tx.
-func NewPrivateStorageContractTxAPI(b Backend, nonceLock *AddrLocker) *PrivateStorageContractTxAPI {
-	return &PrivateStorageContractTxAPI{b, nonceLock}
+func NewPrivateStorageContractTxAPI(b Backend, nonceLock *AddrLocker, resubmitter *TxResubmitter) *PrivateStorageContractTxAPI {
+	return &PrivateStorageContractTxAPI{b, nonceLock, resubmitter}
 }
 
 // SendHostAnnounceTX submit a host announce tx to txpool, only for outer request, need to open cmd and RPC API
@@ -57,7 +60,7 @@ func (psc *PrivateStorageContractTxAPI) SendHostAnnounceTX(from common.Address)
 
 	// construct args
 	args := NewPrecompiledContractTxArgs(from, to, payload, nil, StorageContractTxGas)
-	txHash, err := sendPrecompiledContractTx(ctx, psc.b, psc.nonceLock, args)
+	txHash, err := sendPrecompiledContractTx(ctx, psc.b, psc.nonceLock, psc.resubmitter, args)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -72,7 +75,7 @@ func (psc *PrivateStorageContractTxAPI) SendContractCreateTX(from common.Address
 
 	// construct args
 	args := NewPrecompiledContractTxArgs(from, to, input, nil, StorageContractTxGas)
-	txHash, err := sendPrecompiledContractTx(ctx, psc.b, psc.nonceLock, args)
+	txHash, err := sendPrecompiledContractTx(ctx, psc.b, psc.nonceLock, psc.resubmitter, args)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -87,7 +90,7 @@ func (psc *PrivateStorageContractTxAPI) SendContractRevisionTX(from common.Addre
 
 	// construct args
 	args := NewPrecompiledContractTxArgs(from, to, input, nil, StorageContractTxGas)
-	txHash, err := sendPrecompiledContractTx(ctx, psc.b, psc.nonceLock, args)
+	txHash, err := sendPrecompiledContractTx(ctx, psc.b, psc.nonceLock, psc.resubmitter, args)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -102,42 +105,82 @@ func (psc *PrivateStorageContractTxAPI) SendStorageProofTX(from common.Address,
 
 	// construct args
 	args := NewPrecompiledContractTxArgs(from, to, input, nil, StorageContractTxGas)
-	txHash, err := sendPrecompiledContractTx(ctx, psc.b, psc.nonceLock, args)
+	txHash, err := sendPrecompiledContractTx(ctx, psc.b, psc.nonceLock, psc.resubmitter, args)
 	if err != nil {
 		return common.Hash{}, err
 	}
 	return txHash, nil
 }
 
+// SendStorageProofBatchTX submit a tx carrying the storage proofs of multiple storage contracts, only
+// triggered when host received consensus change, not for outer request
+func (psc *PrivateStorageContractTxAPI) SendStorageProofBatchTX(from common.Address, input []byte) (common.Hash, error) {
+	var batch types.StorageProofBatch
+	if err := rlp.DecodeBytes(input, &batch); err != nil {
+		return common.Hash{}, err
+	}
+	if len(batch.Proofs) == 0 {
+		return common.Hash{}, errors.New("no storage proof in the batch")
+	}
+	to := common.Address{}
+	to.SetBytes([]byte{18})
+	ctx := context.Background()
+
+	// each storage proof costs at most the gas of a single storage proof tx, and the
+	// unused gas is refunded
+	gas := StorageContractTxGas * uint64(len(batch.Proofs))
+	args := NewPrecompiledContractTxArgs(from, to, input, nil, gas)
+	txHash, err := sendPrecompiledContractTx(ctx, psc.b, psc.nonceLock, psc.resubmitter, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return txHash, nil
+}
+
+// SendFeeReserveTopUpTX submit a tx transferring the value to the fee reserve of the host, only
+// triggered when host received consensus change, not for outer request
+func (psc *PrivateStorageContractTxAPI) SendFeeReserveTopUpTX(from, to common.Address, value *big.Int) (common.Hash, error) {
+	ctx := context.Background()
+	args := NewPrecompiledContractTxArgs(from, to, nil, value, params.TxGas)
+	txHash, err := sendPrecompiledContractTx(ctx, psc.b, psc.nonceLock, psc.resubmitter, args)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return txHash, nil
+}
+
+// PrioritizeStorageTX resubmits the pending storage tx with the gas price bumped right away, and
+// keeps bumping the gas price on every block up to maxGasPrice until the tx is included. It is
+// triggered when the storage proof is at risk of missing the proof window, not for outer request
+func (psc *PrivateStorageContractTxAPI) PrioritizeStorageTX(hash common.Hash, maxGasPrice *big.Int) (common.Hash, error) {
+	if psc.resubmitter == nil {
+		return common.Hash{}, errTxNotTracked
+	}
+	return psc.resubmitter.Prioritize(hash, maxGasPrice)
+}
+
 // PublicDposTxAPI exposes the dpos tx methods for the RPC interface
 type PublicDposTxAPI struct {
-	b         Backend
-	nonceLock *AddrLocker
+	b           Backend
+	nonceLock   *AddrLocker
+	resubmitter *TxResubmitter
 }
 
 // NewPublicDposTxAPI construct a PublicDposTxAPI object
-func NewPublicDposTxAPI(b Backend, nonceLock *AddrLocker) *PublicDposTxAPI {
-	return &PublicDposTxAPI{b, nonceLock}
+func NewPublicDposTxAPI(b Backend, nonceLock *AddrLocker, resubmitter *TxResubmitter) *PublicDposTxAPI {
+	return &PublicDposTxAPI{b, nonceLock, resubmitter}
 }
 
 // SendApplyCandidateTx submit a apply candidate tx.
 // the parameter ratio is the award distribution ratio that candidate state.
 func (pd *PublicDposTxAPI) SendApplyCandidateTx(fields map[string]string) (common.Hash, error) {
-	to := vm.ApplyCandidateContractAddress
 	ctx := context.Background()
-
-	stateDB, _, err := pd.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	args, err := applyCandidateTxArgs(ctx, pd.b, fields)
 	if err != nil {
 		return common.Hash{}, err
 	}
 
-	// parse precompile contract tx args
-	args, err := ParseAndValidateCandidateApplyTxArgs(to, DposTxGas, fields, stateDB, pd.b.AccountManager())
-	if err != nil {
-		return common.Hash{}, err
-	}
-
-	txHash, err := sendPrecompiledContractTx(ctx, pd.b, pd.nonceLock, args)
+	txHash, err := sendPrecompiledContractTx(ctx, pd.b, pd.nonceLock, pd.resubmitter, args)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -146,25 +189,14 @@ func (pd *PublicDposTxAPI) SendApplyCandidateTx(fields map[string]string) (commo
 
 // SendCancelCandidateTx submit a cancel candidate tx
 func (pd *PublicDposTxAPI) SendCancelCandidateTx(from common.Address) (common.Hash, error) {
-	to := vm.CancelCandidateContractAddress
 	ctx := context.Background()
-
-	// construct args
-	args := NewPrecompiledContractTxArgs(from, to, nil, nil, DposTxGas)
-
-	// get the latest block header
-	header, err := pd.b.HeaderByNumber(ctx, rpc.LatestBlockNumber)
-	if header == nil || err != nil {
+	args, err := cancelCandidateTxArgs(ctx, pd.b, from)
+	if err != nil {
 		return common.Hash{}, err
 	}
 
-	// check if the address is the candidate address
-	if !dpos.IsCandidate(args.From, header, pd.b.ChainDb()) {
-		return common.Hash{}, ErrNotCandidate
-	}
-
 	// send contract transaction
-	txHash, err := sendPrecompiledContractTx(ctx, pd.b, pd.nonceLock, args)
+	txHash, err := sendPrecompiledContractTx(ctx, pd.b, pd.nonceLock, pd.resubmitter, args)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -173,67 +205,152 @@ func (pd *PublicDposTxAPI) SendCancelCandidateTx(from common.Address) (common.Ha
 
 // SendVoteTx submit a vote tx
 func (pd *PublicDposTxAPI) SendVoteTx(fields map[string]string) (common.Hash, error) {
-	to := vm.VoteContractAddress
 	ctx := context.Background()
+	args, err := voteTxArgs(ctx, pd.b, fields)
+	if err != nil {
+		return common.Hash{}, err
+	}
 
-	stateDB, _, err := pd.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	txHash, err := sendPrecompiledContractTx(ctx, pd.b, pd.nonceLock, pd.resubmitter, args)
 	if err != nil {
 		return common.Hash{}, err
 	}
+	warnLateVote(pd.b, txHash)
+	return txHash, nil
+}
 
-	// parse precompile contract tx args
-	args, err := ParseAndValidateVoteTxArgs(to, DposTxGas, fields, stateDB, pd.b.AccountManager())
+// SendCancelVoteTx submit a cancel vote tx
+func (pd *PublicDposTxAPI) SendCancelVoteTx(from common.Address) (common.Hash, error) {
+	ctx := context.Background()
+	args, err := cancelVoteTxArgs(ctx, pd.b, from)
 	if err != nil {
 		return common.Hash{}, err
 	}
 
-	txHash, err := sendPrecompiledContractTx(ctx, pd.b, pd.nonceLock, args)
+	// send the contract transaction
+	txHash, err := sendPrecompiledContractTx(ctx, pd.b, pd.nonceLock, pd.resubmitter, args)
 	if err != nil {
 		return common.Hash{}, err
 	}
+	warnLateVote(pd.b, txHash)
 	return txHash, nil
 }
 
-// SendCancelVoteTx submit a cancel vote tx
-func (pd *PublicDposTxAPI) SendCancelVoteTx(from common.Address) (common.Hash, error) {
-	to := vm.CancelVoteContractAddress
-	ctx := context.Background()
+// applyCandidateTxArgs parses and validates the fields of the apply candidate tx, and returns
+// the args of the tx with the gas set
+func applyCandidateTxArgs(ctx context.Context, b Backend, fields map[string]string) (*PrecompiledContractTxArgs, error) {
+	stateDB, header, err := b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// the transaction will be executed in the following blocks
+	minDeposit := b.ChainConfig().Dpos.MinCandidateDeposit(new(big.Int).Add(header.Number, big.NewInt(1)))
 
+	// parse precompile contract tx args
+	args, err := ParseAndValidateCandidateApplyTxArgs(vm.ApplyCandidateContractAddress, DposTxGas, fields, stateDB, b.AccountManager(), minDeposit)
+	if err != nil {
+		return nil, err
+	}
+	if err := setDposTxGas(ctx, b, args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// cancelCandidateTxArgs validates the address from could cancel the candidate, and returns
+// the args of the cancel candidate tx with the gas set
+func cancelCandidateTxArgs(ctx context.Context, b Backend, from common.Address) (*PrecompiledContractTxArgs, error) {
 	// construct args
-	args := NewPrecompiledContractTxArgs(from, to, nil, nil, DposTxGas)
+	args := NewPrecompiledContractTxArgs(from, vm.CancelCandidateContractAddress, nil, nil, DposTxGas)
 
 	// get the latest block header
-	header, err := pd.b.HeaderByNumber(ctx, rpc.LatestBlockNumber)
-	if header == nil || err != nil {
-		return common.Hash{}, err
+	header, err := b.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, ErrLatestHeaderNotFound
 	}
 
-	// check if the delegator has voted before
-	if !dpos.HasVoted(args.From, header, pd.b.ChainDb()) {
-		return common.Hash{}, fmt.Errorf("failed to send cancel vote transaction, %v has not voted before", args.From)
+	// check if the address is the candidate address
+	if !dpos.IsCandidate(args.From, header, b.ChainDb()) {
+		return nil, ErrNotCandidate
 	}
 
-	// send the contract transaction
-	txHash, err := sendPrecompiledContractTx(ctx, pd.b, pd.nonceLock, args)
+	// check if the candidate is locked as the validator of the current epoch, the
+	// transaction will be executed in the following blocks
+	validatorLock := b.ChainConfig().Dpos.IsValidatorLocked(new(big.Int).Add(header.Number, big.NewInt(1)))
+	dposCtx, err := types.NewDposContextFromProto(b.ChainDb(), header.DposContext)
 	if err != nil {
-		return common.Hash{}, err
+		return nil, err
 	}
-	return txHash, nil
+	if err := dpos.CancelCandidateTxValidation(dposCtx, args.From, validatorLock); err != nil {
+		return nil, err
+	}
+	if err := setDposTxGas(ctx, b, args); err != nil {
+		return nil, err
+	}
+	return args, nil
 }
 
-// sendPrecompiledContractTx send precompiled contract tx，mostly need from、to、value、input（rlp encoded）
-//
-// NOTE: this is general func, you can construct different args to send detailed tx, like host announce、form contract、contract revision、storage proof.
-// Actually, it need to set different PrecompiledContractTxArgs, like from、to、value、input
-func sendPrecompiledContractTx(ctx context.Context, b Backend, nonceLock *AddrLocker, args *PrecompiledContractTxArgs) (common.Hash, error) {
+// voteTxArgs parses and validates the fields of the vote tx, and returns the args of the tx
+// with the gas set
+func voteTxArgs(ctx context.Context, b Backend, fields map[string]string) (*PrecompiledContractTxArgs, error) {
+	stateDB, header, err := b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	dposCtx, err := types.NewDposContextFromProto(b.ChainDb(), header.DposContext)
+	if err != nil {
+		return nil, err
+	}
 
-	// find the account of the address from
-	account := accounts.Account{Address: args.From}
-	wallet, err := b.AccountManager().Find(account)
+	// parse precompile contract tx args, rejecting the votes for the addresses which are
+	// not candidates
+	args, err := ParseAndValidateVoteTxArgs(vm.VoteContractAddress, DposTxGas, fields, stateDB, dposCtx, b.AccountManager())
 	if err != nil {
-		return common.Hash{}, err
+		return nil, err
 	}
+	if err := setDposTxGas(ctx, b, args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// cancelVoteTxArgs validates the address from has voted, and returns the args of the cancel
+// vote tx with the gas set
+func cancelVoteTxArgs(ctx context.Context, b Backend, from common.Address) (*PrecompiledContractTxArgs, error) {
+	// construct args
+	args := NewPrecompiledContractTxArgs(from, vm.CancelVoteContractAddress, nil, nil, DposTxGas)
 
+	// get the latest block header
+	header, err := b.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, ErrLatestHeaderNotFound
+	}
+
+	// check if the delegator has voted before
+	if !dpos.HasVoted(args.From, header, b.ChainDb()) {
+		return nil, fmt.Errorf("failed to send cancel vote transaction, %v has not voted before", args.From)
+	}
+	if err := setDposTxGas(ctx, b, args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// sendPrecompiledContractTx send precompiled contract tx，mostly need from、to、value、input（rlp encoded）
+//
+// NOTE: this is general func, you can construct different args to send detailed tx, like host announce、form contract、contract revision、storage proof.
+// Actually, it need to set different PrecompiledContractTxArgs, like from、to、value、input
+//
+// The tx sent is tracked by the resubmitter if not nil, so that the tx stuck in the tx pool
+// will be resubmitted with the gas price bumped
+func sendPrecompiledContractTx(ctx context.Context, b Backend, nonceLock *AddrLocker, resubmitter *TxResubmitter, args *PrecompiledContractTxArgs) (common.Hash, error) {
 	nonceLock.LockAddr(args.From)
 	defer nonceLock.UnlockAddr(args.From)
 
@@ -243,14 +360,8 @@ func sendPrecompiledContractTx(ctx context.Context, b Backend, nonceLock *AddrLo
 		return common.Hash{}, err
 	}
 
-	// get chain ID
-	var chainID *big.Int
-	if config := b.ChainConfig(); config.IsEIP155(b.CurrentBlock().Number()) {
-		chainID = config.ChainID
-	}
-
 	// sign the tx by using from's wallet
-	signed, err := wallet.SignTx(account, tx, chainID)
+	signed, err := signPrecompiledContractTx(b, args.From, tx)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -260,9 +371,57 @@ func sendPrecompiledContractTx(ctx context.Context, b Backend, nonceLock *AddrLo
 		return common.Hash{}, err
 	}
 
+	if resubmitter != nil {
+		resubmitter.track(args.From, signed)
+	}
 	return signed.Hash(), nil
 }
 
+// setDposTxGas sets the gas of the dpos tx to the intrinsic gas plus the gas of the storage
+// operations, if the operation gas is metered at the next block. An extra deposit change is
+// counted in case the deposit is changed by another tx before the tx is executed, and the gas
+// unused is refunded. Otherwise the fixed DposTxGas is kept
+func setDposTxGas(ctx context.Context, b Backend, args *PrecompiledContractTxArgs) error {
+	stateDB, header, err := b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if stateDB == nil || err != nil {
+		return err
+	}
+	next := new(big.Int).Add(header.Number, big.NewInt(1))
+	if !b.ChainConfig().Dpos.IsOperationGasMetered(next) {
+		return nil
+	}
+	intrGas, err := core.IntrinsicGas(*args.Input, false, b.ChainConfig().IsHomestead(next))
+	if err != nil {
+		return err
+	}
+	opGas, err := vm.DposTxGas(stateDB, args.From, vm.PrecompiledDPoSContracts[args.To], *args.Input)
+	if err != nil {
+		return err
+	}
+	*(*uint64)(args.Gas) = intrGas + opGas + params.SstoreSetGas
+	return nil
+}
+
+// signPrecompiledContractTx signs the precompiled contract tx by the wallet of the address from
+func signPrecompiledContractTx(b Backend, from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	// find the account of the address from
+	account := accounts.Account{Address: from}
+	wallet, err := b.AccountManager().Find(account)
+	if err != nil {
+		return nil, err
+	}
+
+	// get chain ID. The tx is signed with the chain ID as long as it could be included in the
+	// next block with the replay protection, so that the tx is not rejected by the tx pool
+	// right after the EIP155 block when the replay protection is enforced
+	var chainID *big.Int
+	next := new(big.Int).Add(b.CurrentBlock().Number(), big.NewInt(1))
+	if config := b.ChainConfig(); config.IsEIP155(next) {
+		chainID = config.ChainID
+	}
+	return wallet.SignTx(account, tx, chainID)
+}
+
 // PrecompiledContractTxArgs represents the arguments to submit a precompiled contract tx into the transaction pool.
 type PrecompiledContractTxArgs struct {
 	From     common.Address  `json:"from"`
@@ -0,0 +1,109 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/rpc"
+	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
+)
+
+// StorageContractInfo is the storage contract info stored in the contract account
+type StorageContractInfo struct {
+	ContractID     common.Hash    `json:"contractID"`
+	ClientAddress  common.Address `json:"clientAddress"`
+	HostAddress    common.Address `json:"hostAddress"`
+	FileSize       uint64         `json:"fileSize"`
+	FileMerkleRoot common.Hash    `json:"fileMerkleRoot"`
+	WindowStart    uint64         `json:"windowStart"`
+	WindowEnd      uint64         `json:"windowEnd"`
+	RevisionNumber uint64         `json:"revisionNumber"`
+
+	ClientValidProofOutput  *hexutil.Big `json:"clientValidProofOutput"`
+	HostValidProofOutput    *hexutil.Big `json:"hostValidProofOutput"`
+	ClientMissedProofOutput *hexutil.Big `json:"clientMissedProofOutput"`
+	HostMissedProofOutput   *hexutil.Big `json:"hostMissedProofOutput"`
+
+	Proofed bool `json:"proofed"`
+}
+
+// ExpiringContract is the storage contract expiring at a block height, along with its proof status
+type ExpiringContract struct {
+	ContractID common.Hash `json:"contractID"`
+	Proofed    bool        `json:"proofed"`
+}
+
+// PublicStorageContractAPI provides the methods to inspect the storage contracts on chain
+type PublicStorageContractAPI struct {
+	b Backend
+}
+
+// NewPublicStorageContractAPI creates a public RPC service to inspect the storage contracts
+func NewPublicStorageContractAPI(b Backend) *PublicStorageContractAPI {
+	return &PublicStorageContractAPI{b}
+}
+
+// GetContract returns the storage contract with the given id in the latest state
+func (sc *PublicStorageContractAPI) GetContract(ctx context.Context, contractID common.Hash) (*StorageContractInfo, error) {
+	state, _, err := sc.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, err
+	}
+
+	scs := coinchargemaintenance.NewStorageContractState(state)
+	contractAddr := coinchargemaintenance.ContractAddress(contractID)
+	contract, err := scs.GetContract(contractAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StorageContractInfo{
+		ContractID:              contractID,
+		ClientAddress:           contract.ClientCollateral.Address,
+		HostAddress:             contract.HostCollateral.Address,
+		FileSize:                contract.FileSize,
+		FileMerkleRoot:          contract.FileMerkleRoot,
+		WindowStart:             contract.WindowStart,
+		WindowEnd:               contract.WindowEnd,
+		RevisionNumber:          contract.RevisionNumber,
+		ClientValidProofOutput:  (*hexutil.Big)(contract.ValidProofOutputs[0].Value),
+		HostValidProofOutput:    (*hexutil.Big)(contract.ValidProofOutputs[1].Value),
+		ClientMissedProofOutput: (*hexutil.Big)(contract.MissedProofOutputs[0].Value),
+		HostMissedProofOutput:   (*hexutil.Big)(contract.MissedProofOutputs[1].Value),
+		Proofed:                 scs.Proofed(contractID, contract.WindowEnd),
+	}, nil
+}
+
+// GetExpiringContracts returns the storage contracts whose proof window ends at the given block
+// height, and whether the storage proof of each contract has been submitted. Note that the
+// status account is removed once the missed proof maintenance of the height is done
+func (sc *PublicStorageContractAPI) GetExpiringContracts(ctx context.Context, blockNumber uint64) ([]ExpiringContract, error) {
+	state, _, err := sc.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if state == nil || err != nil {
+		return nil, err
+	}
+
+	contracts := make([]ExpiringContract, 0)
+	statusAddr := coinchargemaintenance.ExpiredStatusAddress(blockNumber)
+	if !state.Exist(statusAddr) {
+		return contracts, nil
+	}
+
+	err = state.ForEachStorage(statusAddr, func(key, value common.Hash) bool {
+		contracts = append(contracts, ExpiringContract{
+			ContractID: key,
+			Proofed:    bytes.Equal(value.Bytes()[11:12], coinchargemaintenance.ProofedStatus),
+		})
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return contracts, nil
+}
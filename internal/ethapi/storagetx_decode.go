@@ -0,0 +1,113 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/core/rawdb"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+var errNotPrecompiledTx = errors.New("not a storage contract or dpos transaction")
+
+// DecodedStorageTx is the storage contract or dpos transaction with the payload decoded
+type DecodedStorageTx struct {
+	Hash        common.Hash     `json:"hash"`
+	BlockHash   *common.Hash    `json:"blockHash"`
+	BlockNumber *hexutil.Big    `json:"blockNumber"`
+	To          common.Address  `json:"to"`
+	Type        string          `json:"type"`
+	Data        hexutil.Bytes   `json:"data"`
+	Payload     interface{}     `json:"payload"`
+	TxIndex     *hexutil.Uint64 `json:"transactionIndex"`
+}
+
+// DecodeStorageTx retrieves the storage contract or dpos transaction, either mined or pending,
+// and decodes the payload into the type of the transaction. BlockHash and BlockNumber are nil
+// for the pending transaction
+func (api *PublicDebugAPI) DecodeStorageTx(ctx context.Context, hash common.Hash) (*DecodedStorageTx, error) {
+	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(api.b.ChainDb(), hash)
+	if tx == nil {
+		if tx = api.b.GetPoolTransaction(hash); tx == nil {
+			return nil, errors.New("transaction unknown")
+		}
+	}
+	if tx.To() == nil {
+		return nil, errNotPrecompiledTx
+	}
+	txType, ok := vm.PrecompiledStorageContracts[*tx.To()]
+	if !ok {
+		if txType, ok = vm.PrecompiledDPoSContracts[*tx.To()]; !ok {
+			return nil, errNotPrecompiledTx
+		}
+	}
+	payload, err := decodeStorageTxPayload(txType, tx.Data())
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the %s payload: %v", txType, err)
+	}
+
+	decoded := &DecodedStorageTx{
+		Hash:    tx.Hash(),
+		To:      *tx.To(),
+		Type:    txType,
+		Data:    hexutil.Bytes(tx.Data()),
+		Payload: payload,
+	}
+	if blockHash != (common.Hash{}) {
+		decoded.BlockHash = &blockHash
+		decoded.BlockNumber = (*hexutil.Big)(new(big.Int).SetUint64(blockNumber))
+		decoded.TxIndex = (*hexutil.Uint64)(&index)
+	}
+	return decoded, nil
+}
+
+// decodeStorageTxPayload decodes the payload of the storage contract or dpos transaction of
+// the type. The storage contract payload is checked as in the transaction processing. Nil is
+// returned for the transaction without payload
+func decodeStorageTxPayload(txType string, data []byte) (interface{}, error) {
+	var payload interface{}
+	switch txType {
+	case vm.HostAnnounceTransaction:
+		payload = new(types.HostAnnouncement)
+	case vm.ContractCreateTransaction:
+		payload = new(types.StorageContract)
+	case vm.CommitRevisionTransaction:
+		payload = new(types.StorageContractRevision)
+	case vm.StorageProofTransaction:
+		payload = new(types.StorageProof)
+	case vm.StorageProofBatchTransaction:
+		payload = new(types.StorageProofBatch)
+	case vm.RenewContractTransaction:
+		payload = new(types.StorageContractRenewal)
+	case vm.ApplyCandidate:
+		var ac types.AddCandidateTxData
+		if err := rlp.DecodeBytes(data, &ac); err != nil {
+			return nil, err
+		}
+		return &ac, nil
+	case vm.Vote:
+		var vote types.VoteTxData
+		if err := rlp.DecodeBytes(data, &vote); err != nil {
+			return nil, err
+		}
+		return &vote, nil
+	case vm.CancelCandidate, vm.CancelVote:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown transaction type %s", txType)
+	}
+	if err := vm.DecodeStoragePayload(data, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package ethapi
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+func TestDecodeStorageTxPayload(t *testing.T) {
+	vote := types.VoteTxData{
+		Deposit:    common.NewBigIntUint64(1000),
+		Candidates: []common.Address{common.HexToAddress("0x1"), common.HexToAddress("0x2")},
+	}
+	tests := []struct {
+		txType string
+		val    interface{}
+		expect interface{}
+		err    bool
+	}{
+		{vm.HostAnnounceTransaction, ha, &ha, false},
+		{vm.CommitRevisionTransaction, scr, &scr, false},
+		{vm.StorageProofTransaction, spf, &spf, false},
+		{vm.StorageProofBatchTransaction, types.StorageProofBatch{Proofs: []types.StorageProof{spf, spf}}, &types.StorageProofBatch{Proofs: []types.StorageProof{spf, spf}}, false},
+		{vm.Vote, &vote, &vote, false},
+		{vm.CancelVote, nil, nil, false},
+		// the storage contract has only one proof output
+		{vm.ContractCreateTransaction, sc, nil, true},
+		{vm.StorageProofTransaction, ha, nil, true},
+		{"unknown", ha, nil, true},
+	}
+	for i, test := range tests {
+		var data []byte
+		if test.val != nil {
+			var err error
+			if data, err = rlp.EncodeToBytes(test.val); err != nil {
+				t.Fatalf("test %d: failed to encode the payload: %v", i, err)
+			}
+		}
+		payload, err := decodeStorageTxPayload(test.txType, data)
+		if (err != nil) != test.err {
+			t.Errorf("test %d: expect error %v, got %v", i, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if test.expect == nil {
+			if payload != nil {
+				t.Errorf("test %d: expect no payload, got %+v", i, payload)
+			}
+			continue
+		}
+		// the payloads are compared by the encodings, as the nil slices are decoded as empty ones
+		expect, _ := rlp.EncodeToBytes(test.expect)
+		got, err := rlp.EncodeToBytes(payload)
+		if err != nil || !bytes.Equal(got, expect) {
+			t.Errorf("test %d: expect payload %+v, got %+v", i, test.expect, payload)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// TransferTransaction is the tx type of the normal transfer or contract call built by the
+// tx builder
+const TransferTransaction = "Transfer"
+
+// PublicTxBuilderAPI builds the unsigned transactions of all types, so that the transactions
+// could be signed offline, e.g. by the hardware wallet, and submitted via
+// eth_sendRawTransaction
+type PublicTxBuilderAPI struct {
+	b Backend
+}
+
+// NewPublicTxBuilderAPI construct a PublicTxBuilderAPI object
+func NewPublicTxBuilderAPI(b Backend) *PublicTxBuilderAPI {
+	return &PublicTxBuilderAPI{b}
+}
+
+// TxBuildArgs is the typed request of building a transaction. Type is either Transfer or the
+// type of the precompiled contract tx, e.g. Vote or ContractCreate. Fields is the fields of
+// ApplyCandidate and Vote tx as in the dpos API, and Input is the rlp encoded payload of the
+// storage contract tx. Gas, GasPrice and Nonce are filled in if not provided
+type TxBuildArgs struct {
+	Type     string            `json:"type"`
+	From     common.Address    `json:"from"`
+	To       *common.Address   `json:"to"`
+	Value    *hexutil.Big      `json:"value"`
+	Gas      *hexutil.Uint64   `json:"gas"`
+	GasPrice *hexutil.Big      `json:"gasPrice"`
+	Nonce    *hexutil.Uint64   `json:"nonce"`
+	Input    *hexutil.Bytes    `json:"input"`
+	Fields   map[string]string `json:"fields"`
+}
+
+// UnsignedTxResult is the unsigned transaction built. SigHash is the hash to be signed with the
+// chain ID, which is nil if the tx is signed without the replay protection
+type UnsignedTxResult struct {
+	Raw     hexutil.Bytes      `json:"raw"`
+	Tx      *types.Transaction `json:"tx"`
+	ChainID *hexutil.Big       `json:"chainId"`
+	SigHash common.Hash        `json:"sigHash"`
+}
+
+// BuildTx builds the unsigned transaction of the request with the to address, payload, gas and
+// nonce set. The payload of the dpos tx is validated the same way as sending the tx via the
+// dpos API, and the payload of the storage contract tx is checked to be decodable
+func (api *PublicTxBuilderAPI) BuildTx(ctx context.Context, args TxBuildArgs) (*UnsignedTxResult, error) {
+	if args.From == (common.Address{}) {
+		return nil, ErrBuildTxWithoutFrom
+	}
+	sendArgs, err := txBuildSendArgs(ctx, api.b, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// the gas, gas price and nonce provided take precedence
+	if args.Gas != nil {
+		sendArgs.Gas = args.Gas
+	}
+	sendArgs.GasPrice, sendArgs.Nonce = args.GasPrice, args.Nonce
+	if err := sendArgs.setDefaults(ctx, api.b); err != nil {
+		return nil, err
+	}
+	tx := sendArgs.toTransaction()
+
+	data, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	result := &UnsignedTxResult{Raw: data, Tx: tx}
+
+	// the tx shall be signed as it is included in the next block
+	config := api.b.ChainConfig()
+	next := new(big.Int).Add(api.b.CurrentBlock().Number(), big.NewInt(1))
+	if config.IsEIP155(next) {
+		result.ChainID = (*hexutil.Big)(config.ChainID)
+	}
+	result.SigHash = types.MakeSigner(config, next).Hash(tx)
+	return result, nil
+}
+
+// txBuildSendArgs returns the args of the tx of the type, without the gas price and nonce
+func txBuildSendArgs(ctx context.Context, b Backend, args TxBuildArgs) (*SendTxArgs, error) {
+	switch args.Type {
+	case TransferTransaction:
+		return &SendTxArgs{From: args.From, To: args.To, Value: args.Value, Input: args.Input}, nil
+	case vm.ApplyCandidate, vm.Vote:
+		// the address from is always used, instead of the default account of the node
+		fields := map[string]string{"from": args.From.String()}
+		for key, value := range args.Fields {
+			if key != "from" {
+				fields[key] = value
+			}
+		}
+		var precompiledArgs *PrecompiledContractTxArgs
+		var err error
+		if args.Type == vm.ApplyCandidate {
+			precompiledArgs, err = applyCandidateTxArgs(ctx, b, fields)
+		} else {
+			precompiledArgs, err = voteTxArgs(ctx, b, fields)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return precompiledSendArgs(precompiledArgs), nil
+	case vm.CancelCandidate:
+		precompiledArgs, err := cancelCandidateTxArgs(ctx, b, args.From)
+		if err != nil {
+			return nil, err
+		}
+		return precompiledSendArgs(precompiledArgs), nil
+	case vm.CancelVote:
+		precompiledArgs, err := cancelVoteTxArgs(ctx, b, args.From)
+		if err != nil {
+			return nil, err
+		}
+		return precompiledSendArgs(precompiledArgs), nil
+	}
+
+	to, ok := storageContractAddress(args.Type)
+	if !ok {
+		return nil, ErrUnknownTxType
+	}
+	var input []byte
+	if args.Input != nil {
+		input = *args.Input
+	}
+	payload, err := decodeStorageTxPayload(args.Type, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the %s payload: %v", args.Type, err)
+	}
+
+	// each storage proof of the batch costs at most the gas of a single storage proof tx
+	gas := uint64(StorageContractTxGas)
+	if batch, ok := payload.(*types.StorageProofBatch); ok {
+		if len(batch.Proofs) == 0 {
+			return nil, errors.New("no storage proof in the batch")
+		}
+		gas *= uint64(len(batch.Proofs))
+	}
+	return precompiledSendArgs(NewPrecompiledContractTxArgs(args.From, to, input, nil, gas)), nil
+}
+
+// precompiledSendArgs converts the precompiled contract tx args to the send tx args
+func precompiledSendArgs(args *PrecompiledContractTxArgs) *SendTxArgs {
+	to := args.To
+	return &SendTxArgs{
+		From:  args.From,
+		To:    &to,
+		Gas:   args.Gas,
+		Value: args.Value,
+		Input: args.Input,
+	}
+}
+
+// storageContractAddress returns the address of the precompiled storage contract of the tx type
+func storageContractAddress(txType string) (common.Address, bool) {
+	for addr, t := range vm.PrecompiledStorageContracts {
+		if t == txType {
+			return addr, true
+		}
+	}
+	return common.Address{}, false
+}
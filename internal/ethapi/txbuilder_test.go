@@ -0,0 +1,70 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// TestTxBuildSendArgs test the storage contract tx and the transfer are built with the to
+// address, payload and gas of the tx type
+func TestTxBuildSendArgs(t *testing.T) {
+	from := common.HexToAddress("0x1")
+	to := common.HexToAddress("0x2")
+	encode := func(val interface{}) *hexutil.Bytes {
+		data, err := rlp.EncodeToBytes(val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return (*hexutil.Bytes)(&data)
+	}
+
+	tests := []struct {
+		args   TxBuildArgs
+		to     *common.Address
+		gas    uint64
+		hasGas bool
+		err    bool
+	}{
+		{TxBuildArgs{Type: TransferTransaction, From: from, To: &to}, &to, 0, false, false},
+		{TxBuildArgs{Type: vm.StorageProofTransaction, From: from, Input: encode(spf)}, addrPtr(common.BytesToAddress([]byte{12})), StorageContractTxGas, true, false},
+		{TxBuildArgs{Type: vm.HostAnnounceTransaction, From: from, Input: encode(ha)}, addrPtr(common.BytesToAddress([]byte{9})), StorageContractTxGas, true, false},
+		{TxBuildArgs{Type: vm.StorageProofBatchTransaction, From: from, Input: encode(types.StorageProofBatch{Proofs: []types.StorageProof{spf, spf, spf}})}, addrPtr(common.BytesToAddress([]byte{18})), 3 * StorageContractTxGas, true, false},
+		{TxBuildArgs{Type: vm.StorageProofBatchTransaction, From: from, Input: encode(types.StorageProofBatch{})}, nil, 0, false, true},
+		{TxBuildArgs{Type: vm.StorageProofTransaction, From: from, Input: encode(ha)}, nil, 0, false, true},
+		{TxBuildArgs{Type: "unknown", From: from}, nil, 0, false, true},
+	}
+	for i, test := range tests {
+		args, err := txBuildSendArgs(context.Background(), nil, test.args)
+		if (err != nil) != test.err {
+			t.Errorf("test %d: expect error %v, got %v", i, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if args.From != from || *args.To != *test.to {
+			t.Errorf("test %d: expect from %v to %v, got from %v to %v", i, from, *test.to, args.From, *args.To)
+		}
+		if test.hasGas && (args.Gas == nil || uint64(*args.Gas) != test.gas) {
+			t.Errorf("test %d: expect gas %v, got %v", i, test.gas, args.Gas)
+		}
+		if test.args.Input != nil && !bytes.Equal(*args.Input, *test.args.Input) {
+			t.Errorf("test %d: payload not expected", i)
+		}
+	}
+}
+
+func addrPtr(addr common.Address) *common.Address {
+	return &addr
+}
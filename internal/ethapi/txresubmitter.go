@@ -0,0 +1,309 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rpc"
+)
+
+const (
+	// resubmitBlocks is the number of blocks a precompiled contract tx could stay pending
+	// before it is resubmitted
+	resubmitBlocks = 5
+
+	// resubmitPriceBump is the percentage of the gas price bumped for each resubmission, which
+	// must be higher than the price bump required by the tx pool to replace the pending tx
+	resubmitPriceBump = 20
+
+	// maxResubmitPriceMultiplier caps the gas price of the resubmission as the multiple of
+	// the original gas price
+	maxResubmitPriceMultiplier = 10
+
+	// priorityResubmitBlocks is the number of blocks a prioritized tx could stay pending
+	// before it is resubmitted
+	priorityResubmitBlocks = 1
+)
+
+// errTxNotTracked is returned when prioritizing the tx not tracked by the TxResubmitter
+var errTxNotTracked = errors.New("the tx is not pending or not sent by the node")
+
+// resubmittedTx is a precompiled contract tx originated from the node and tracked by the
+// TxResubmitter until it is included in the chain
+type resubmittedTx struct {
+	from        common.Address
+	tx          *types.Transaction
+	maxGasPrice *big.Int
+	maxFee      *big.Int
+	submitBlock uint64
+	cancelling  bool
+
+	// lateWarnedEpoch is the epoch the vote tx is warned to be late for the election of
+	lateWarnedEpoch int64
+
+	// priority is set for the tx which must be included in time, e.g. the storage proof with
+	// the proof window closing. The prioritized tx is resubmitted on every block with the gas
+	// price bumped up to the cap, and is never cancelled
+	priority bool
+}
+
+// resubmitBlocks returns the number of blocks the tx could stay pending before resubmitted
+func (rt *resubmittedTx) resubmitBlocks() uint64 {
+	if rt.priority {
+		return priorityResubmitBlocks
+	}
+	return resubmitBlocks
+}
+
+// replacement returns the unsigned tx to replace the stuck tx with the gas price bumped. Once
+// the gas price reaches the cap, the tx is cancelled by a transfer to the sender itself with the
+// same nonce, so that the txs with the higher nonces are not blocked by the stuck one. The fee of
+// the cancellation is capped by the max fee of the original tx. Nil is returned if the fee cap
+// is reached. The prioritized tx is bumped up to the gas price cap, and nil is returned once
+// the cap is reached
+func (rt *resubmittedTx) replacement() *types.Transaction {
+	price := bumpGasPrice(rt.tx.GasPrice())
+	if rt.priority {
+		if price.Cmp(rt.maxGasPrice) > 0 {
+			price = new(big.Int).Set(rt.maxGasPrice)
+		}
+		if price.Cmp(rt.tx.GasPrice()) <= 0 {
+			return nil
+		}
+		return types.NewTransaction(rt.tx.Nonce(), *rt.tx.To(), rt.tx.Value(), rt.tx.Gas(), price, rt.tx.Data())
+	}
+	if !rt.cancelling && price.Cmp(rt.maxGasPrice) <= 0 {
+		return types.NewTransaction(rt.tx.Nonce(), *rt.tx.To(), rt.tx.Value(), rt.tx.Gas(), price, rt.tx.Data())
+	}
+	if new(big.Int).Mul(price, new(big.Int).SetUint64(params.TxGas)).Cmp(rt.maxFee) > 0 {
+		return nil
+	}
+	return types.NewTransaction(rt.tx.Nonce(), rt.from, new(big.Int), params.TxGas, price, nil)
+}
+
+// bumpGasPrice returns the gas price bumped by resubmitPriceBump percent
+func bumpGasPrice(price *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(price, big.NewInt(100+resubmitPriceBump))
+	bumped.Div(bumped, big.NewInt(100))
+	if bumped.Cmp(price) <= 0 {
+		bumped.Add(price, common.Big1)
+	}
+	return bumped
+}
+
+// TxResubmitter tracks the precompiled contract txs sent by the node, e.g. the storage proofs
+// sent by the storage host. The tx stuck in the tx pool, because of the gas price spike or being
+// dropped from the tx pool, will block all the txs sent later by the same account. So the stuck
+// tx is resubmitted with the gas price bumped, or cancelled once the gas price reaches the cap
+type TxResubmitter struct {
+	b         Backend
+	nonceLock *AddrLocker
+
+	pending map[common.Address]map[uint64]*resubmittedTx
+	running bool
+	lock    sync.Mutex
+}
+
+// NewTxResubmitter creates the TxResubmitter sharing the nonce lock with the tx APIs
+func NewTxResubmitter(b Backend, nonceLock *AddrLocker) *TxResubmitter {
+	return &TxResubmitter{
+		b:         b,
+		nonceLock: nonceLock,
+		pending:   make(map[common.Address]map[uint64]*resubmittedTx),
+	}
+}
+
+// track starts tracking the signed precompiled contract tx. The tracking loop is only running
+// when there are txs tracked
+func (r *TxResubmitter) track(from common.Address, tx *types.Transaction) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.pending[from] == nil {
+		r.pending[from] = make(map[uint64]*resubmittedTx)
+	}
+	maxGasPrice := new(big.Int).Mul(tx.GasPrice(), big.NewInt(maxResubmitPriceMultiplier))
+	r.pending[from][tx.Nonce()] = &resubmittedTx{
+		from:        from,
+		tx:          tx,
+		maxGasPrice: maxGasPrice,
+		maxFee:      new(big.Int).Mul(maxGasPrice, new(big.Int).SetUint64(tx.Gas())),
+		submitBlock: r.b.CurrentBlock().NumberU64(),
+	}
+	if !r.running {
+		r.running = true
+		go r.loop()
+	}
+}
+
+// loop checks the tracked txs on each new chain head, until no tx is tracked
+func (r *TxResubmitter) loop() {
+	heads := make(chan core.ChainHeadEvent, 1)
+	sub := r.b.SubscribeChainHeadEvent(heads)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case head := <-heads:
+			if !r.check(head.Block.NumberU64()) {
+				return
+			}
+		case <-sub.Err():
+			r.lock.Lock()
+			r.running = false
+			r.lock.Unlock()
+			return
+		}
+	}
+}
+
+// check removes the tracked txs included in the chain, and resubmits the txs pending for more
+// than resubmitBlocks blocks. The vote txs still pending close to the election of the next epoch
+// are warned once per epoch. False is returned if no tx is tracked anymore
+func (r *TxResubmitter) check(number uint64) bool {
+	stateDB, header, err := r.b.StateAndHeaderByNumber(context.Background(), rpc.LatestBlockNumber)
+	if err != nil || stateDB == nil || header == nil {
+		return true
+	}
+	schedule, late := lateForElection(r.b.ChainConfig().Dpos, header, time.Now().Unix())
+
+	r.lock.Lock()
+	var stuck []*resubmittedTx
+	for from, txs := range r.pending {
+		nonce := stateDB.GetNonce(from)
+		for n, rt := range txs {
+			switch {
+			case n < nonce:
+				delete(txs, n)
+			case number >= rt.submitBlock+rt.resubmitBlocks():
+				stuck = append(stuck, rt)
+			}
+			if n >= nonce && late && isVoteTx(rt.tx) && rt.lateWarnedEpoch != schedule.NextEpochID {
+				rt.lateWarnedEpoch = schedule.NextEpochID
+				log.Warn("The pending vote is unlikely to be mined before the next epoch election", "hash", rt.tx.Hash(),
+					"snapshotBlock", schedule.SnapshotBlock, "eta", time.Duration(schedule.ETA)*time.Second)
+			}
+		}
+		if len(txs) == 0 {
+			delete(r.pending, from)
+		}
+	}
+	if len(r.pending) == 0 {
+		r.running = false
+		r.lock.Unlock()
+		return false
+	}
+	r.lock.Unlock()
+
+	for _, rt := range stuck {
+		r.resubmit(rt, number)
+	}
+	return true
+}
+
+// resubmit resubmits the stuck tx. The tx dropped from the tx pool is sent again as it is to fill
+// the nonce gap, and the tx still in the tx pool is replaced with the gas price bumped
+func (r *TxResubmitter) resubmit(rt *resubmittedTx, number uint64) {
+	r.nonceLock.LockAddr(rt.from)
+	defer r.nonceLock.UnlockAddr(rt.from)
+
+	ctx := context.Background()
+	if r.b.GetPoolTransaction(rt.tx.Hash()) == nil {
+		if err := r.b.SendTx(ctx, rt.tx); err == nil {
+			log.Info("Resent the precompiled contract tx dropped from tx pool", "hash", rt.tx.Hash(), "nonce", rt.tx.Nonce())
+			r.resubmitted(rt, rt.tx, number)
+			return
+		}
+	}
+
+	tx := rt.replacement()
+	if tx == nil && rt.priority {
+		log.Warn("The prioritized precompiled contract tx reached the gas price cap", "hash", rt.tx.Hash(), "gasPrice", rt.tx.GasPrice())
+		r.resubmitted(rt, rt.tx, number)
+		return
+	}
+	if tx == nil {
+		log.Error("Gave up resubmitting the stuck precompiled contract tx", "hash", rt.tx.Hash(), "nonce", rt.tx.Nonce())
+		r.untrack(rt)
+		return
+	}
+	signed, err := signPrecompiledContractTx(r.b, rt.from, tx)
+	if err != nil {
+		log.Warn("Failed to sign the resubmitted precompiled contract tx", "nonce", tx.Nonce(), "err", err)
+		return
+	}
+	if err := r.b.SendTx(ctx, signed); err != nil {
+		log.Warn("Failed to resubmit the precompiled contract tx", "nonce", tx.Nonce(), "err", err)
+		return
+	}
+
+	cancelling := *signed.To() == rt.from
+	if cancelling && !rt.cancelling {
+		log.Warn("Cancelled the stuck precompiled contract tx", "hash", rt.tx.Hash(), "nonce", rt.tx.Nonce(), "cancel", signed.Hash())
+	} else {
+		log.Info("Resubmitted the stuck precompiled contract tx", "hash", rt.tx.Hash(), "replacement", signed.Hash(), "gasPrice", signed.GasPrice())
+	}
+	r.lock.Lock()
+	rt.cancelling = cancelling
+	r.lock.Unlock()
+	r.resubmitted(rt, signed, number)
+}
+
+// Prioritize raises the gas price cap of the pending tx with the hash to maxGasPrice, and
+// resubmits the tx with the gas price bumped right away. Afterwards, the tx is resubmitted on
+// every block until included. The hash of the tx resubmitted is returned
+func (r *TxResubmitter) Prioritize(hash common.Hash, maxGasPrice *big.Int) (common.Hash, error) {
+	r.lock.Lock()
+	var rt *resubmittedTx
+	for _, txs := range r.pending {
+		for _, tracked := range txs {
+			if tracked.tx.Hash() == hash {
+				rt = tracked
+			}
+		}
+	}
+	if rt == nil || rt.cancelling {
+		r.lock.Unlock()
+		return common.Hash{}, errTxNotTracked
+	}
+	if maxGasPrice != nil && maxGasPrice.Cmp(rt.maxGasPrice) > 0 {
+		rt.maxGasPrice = new(big.Int).Set(maxGasPrice)
+		rt.maxFee = new(big.Int).Mul(maxGasPrice, new(big.Int).SetUint64(rt.tx.Gas()))
+	}
+	rt.priority = true
+	r.lock.Unlock()
+
+	r.resubmit(rt, r.b.CurrentBlock().NumberU64())
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return rt.tx.Hash(), nil
+}
+
+// resubmitted records the tx resubmitted at the block number
+func (r *TxResubmitter) resubmitted(rt *resubmittedTx, tx *types.Transaction, number uint64) {
+	r.lock.Lock()
+	rt.tx, rt.submitBlock = tx, number
+	r.lock.Unlock()
+}
+
+// untrack stops tracking the tx
+func (r *TxResubmitter) untrack(rt *resubmittedTx) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if txs := r.pending[rt.from]; txs != nil && txs[rt.tx.Nonce()] == rt {
+		delete(txs, rt.tx.Nonce())
+	}
+}
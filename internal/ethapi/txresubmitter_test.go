@@ -0,0 +1,150 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package ethapi
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/params"
+)
+
+func TestBumpGasPrice(t *testing.T) {
+	tests := []struct {
+		price  int64
+		bumped int64
+	}{
+		{100, 120},
+		{1, 2},
+		{0, 1},
+		{1000000000, 1200000000},
+	}
+	for _, test := range tests {
+		if bumped := bumpGasPrice(big.NewInt(test.price)); bumped.Int64() != test.bumped {
+			t.Errorf("bump gas price %d: expect %d, got %d", test.price, test.bumped, bumped.Int64())
+		}
+	}
+}
+
+func TestResubmittedTx_Replacement(t *testing.T) {
+	from := common.HexToAddress("0x1")
+	to := common.HexToAddress("0x9")
+	data := []byte("storage proof")
+	tx := types.NewTransaction(3, to, new(big.Int), 100000, big.NewInt(100), data)
+
+	maxGasPrice := big.NewInt(100 * maxResubmitPriceMultiplier)
+	rt := &resubmittedTx{
+		from:        from,
+		tx:          tx,
+		maxGasPrice: maxGasPrice,
+		maxFee:      new(big.Int).Mul(maxGasPrice, new(big.Int).SetUint64(tx.Gas())),
+	}
+
+	// the gas price is bumped until reaching the cap
+	var bumps int
+	for {
+		replacement := rt.replacement()
+		if replacement == nil {
+			t.Fatal("the replacement should not be nil before cancelling")
+		}
+		if *replacement.To() == from {
+			break
+		}
+		if replacement.GasPrice().Cmp(rt.tx.GasPrice()) <= 0 || replacement.GasPrice().Cmp(maxGasPrice) > 0 {
+			t.Fatalf("invalid bumped gas price %v", replacement.GasPrice())
+		}
+		if replacement.Nonce() != tx.Nonce() || *replacement.To() != to || replacement.Gas() != tx.Gas() || !bytes.Equal(replacement.Data(), data) {
+			t.Fatal("the bumped replacement should keep the nonce, recipient, gas and data of the tx")
+		}
+		rt.tx = replacement
+		bumps++
+	}
+	if bumps == 0 {
+		t.Fatal("the gas price should be bumped before cancelling")
+	}
+
+	// the tx is cancelled by the self transfer with the same nonce once the cap is reached
+	rt.cancelling = true
+	cancel := rt.replacement()
+	if cancel.Nonce() != tx.Nonce() || cancel.Gas() != params.TxGas || cancel.Value().Sign() != 0 || len(cancel.Data()) != 0 {
+		t.Fatal("the cancel tx should be the zero value self transfer with the same nonce")
+	}
+	if cancel.GasPrice().Cmp(rt.tx.GasPrice()) <= 0 {
+		t.Fatal("the gas price of the cancel tx should be bumped")
+	}
+
+	// nil is returned once the fee of the cancel tx exceeds the max fee
+	rt.tx = types.NewTransaction(3, from, new(big.Int), params.TxGas, new(big.Int).Div(rt.maxFee, new(big.Int).SetUint64(params.TxGas)), nil)
+	if rt.replacement() != nil {
+		t.Fatal("the replacement should be nil once the max fee is reached")
+	}
+}
+
+func TestResubmittedTx_PriorityReplacement(t *testing.T) {
+	from := common.HexToAddress("0x1")
+	to := common.HexToAddress("0x9")
+	tx := types.NewTransaction(3, to, new(big.Int), 100000, big.NewInt(100), []byte("storage proof"))
+
+	maxGasPrice := big.NewInt(150)
+	rt := &resubmittedTx{
+		from:        from,
+		tx:          tx,
+		maxGasPrice: maxGasPrice,
+		maxFee:      new(big.Int).Mul(maxGasPrice, new(big.Int).SetUint64(tx.Gas())),
+		priority:    true,
+	}
+	if rt.resubmitBlocks() != priorityResubmitBlocks {
+		t.Fatalf("the prioritized tx should be resubmitted every %d blocks", priorityResubmitBlocks)
+	}
+
+	// the gas price is bumped and capped at the max gas price
+	expects := []int64{120, 144, 150}
+	for _, expect := range expects {
+		replacement := rt.replacement()
+		if replacement == nil || *replacement.To() != to {
+			t.Fatal("the prioritized tx should be bumped instead of cancelled")
+		}
+		if replacement.GasPrice().Int64() != expect {
+			t.Fatalf("expect gas price %d, got %v", expect, replacement.GasPrice())
+		}
+		rt.tx = replacement
+	}
+
+	// nil is returned once the cap is reached, and the tx is kept instead of cancelled
+	if rt.replacement() != nil {
+		t.Fatal("the replacement should be nil once the gas price cap is reached")
+	}
+}
+
+// TestLateForElection test the vote pending within voteElectionMarginBlocks blocks before the
+// election snapshot is late for the election
+func TestLateForElection(t *testing.T) {
+	epochEnd := 2 * dpos.EpochInterval
+	tests := []struct {
+		headTime int64
+		late     bool
+	}{
+		{epochEnd - (voteElectionMarginBlocks+1)*dpos.BlockInterval, false},
+		{epochEnd - voteElectionMarginBlocks*dpos.BlockInterval, true},
+		{epochEnd - dpos.BlockInterval, true},
+	}
+	for i, test := range tests {
+		head := &types.Header{Number: big.NewInt(100), Time: big.NewInt(test.headTime)}
+		if _, late := lateForElection(nil, head, test.headTime); late != test.late {
+			t.Errorf("test %d: expect late %v, got %v", i, test.late, late)
+		}
+	}
+
+	vote := types.NewTransaction(0, vm.VoteContractAddress, new(big.Int), 100000, big.NewInt(1), nil)
+	transfer := types.NewTransaction(0, common.HexToAddress("0x1"), new(big.Int), 21000, big.NewInt(1), nil)
+	if !isVoteTx(vote) || isVoteTx(transfer) {
+		t.Error("vote tx not recognized")
+	}
+}
@@ -33,6 +33,8 @@ var Modules = map[string]string{
 	"swarmfs":    SWARMFS_JS,
 	"txpool":     TxPool_JS,
 	"dpos":       Dpos_JS,
+	"sc":         Sc_JS,
+	"txbuilder":  TxBuilder_JS,
 }
 
 const Chequebook_JS = `
@@ -191,6 +193,24 @@ web3._extend({
 			outputFormatter: web3._extend.utils.toBigNumber
 		}),
 
+		new web3._extend.Method({
+			name: 'getProductionSchedule',
+			call: 'dpos_getProductionSchedule',
+			params: 1,
+		}),
+
+		new web3._extend.Method({
+			name: 'getForkStatus',
+			call: 'dpos_getForkStatus',
+			params: 0,
+		}),
+
+		new web3._extend.Method({
+			name: 'getElectionSchedule',
+			call: 'dpos_getElectionSchedule',
+			params: 0,
+		}),
+
 		new web3._extend.Method({
 			name: 'getVotedCandidatesByAddress',
 			call: 'getVotedCandidatesByAddress',
@@ -236,6 +256,45 @@ web3._extend({
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+
+		new web3._extend.Method({
+			name: 'epochReward',
+			call: 'dpos_epochReward',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+	]
+});
+`
+
+const Sc_JS = `
+web3._extend({
+	property: 'sc',
+	methods: [
+		new web3._extend.Method({
+			name: 'getContract',
+			call: 'sc_getContract',
+			params: 1,
+		}),
+
+		new web3._extend.Method({
+			name: 'getExpiringContracts',
+			call: 'sc_getExpiringContracts',
+			params: 1,
+		}),
+	]
+});
+`
+
+const TxBuilder_JS = `
+web3._extend({
+	property: 'txbuilder',
+	methods: [
+		new web3._extend.Method({
+			name: 'buildTx',
+			call: 'txbuilder_buildTx',
+			params: 1,
+		}),
 	]
 });
 `
@@ -527,6 +586,17 @@ web3._extend({
 			call: 'debug_getBadBlocks',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'opcodeProfile',
+			call: 'debug_opcodeProfile',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'resetOpcodeProfile',
+			call: 'debug_resetOpcodeProfile',
+			params: 0,
+		}),
 		new web3._extend.Method({
 			name: 'storageRangeAt',
 			call: 'debug_storageRangeAt',
@@ -611,6 +681,12 @@ web3._extend({
 			params: 3,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'storageTransactions',
+			call: 'eth_storageTransactions',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
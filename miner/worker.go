@@ -837,7 +837,9 @@ func (w *worker) commit(uncles []*types.Header, interval func(), update bool, st
 
 	// maintenance missed storage proof
 	height := w.current.header.Number.Uint64()
-	coinchargemaintenance.MaintenanceMissedProof(height, s)
+	if err := coinchargemaintenance.MaintenanceMissedProof(height, s); err != nil {
+		return err
+	}
 
 	block, err := w.engine.Finalize(w.chain, w.current.header, s, w.current.txs, uncles, w.current.receipts, d)
 	if err != nil {
@@ -126,6 +126,11 @@ type Config struct {
 	// interface.
 	HTTPTimeouts rpc.HTTPTimeouts
 
+	// HTTPPolicies restricts the methods and the call rate of the API modules exposed via
+	// the HTTP RPC interface, keyed by the namespace. E.g. the read-only storage client
+	// queries could be exposed publicly without the methods sending transactions.
+	HTTPPolicies map[string]rpc.NamespacePolicy `toml:",omitempty"`
+
 	// WSHost is the host interface on which to start the websocket RPC server. If
 	// this field is empty, no websocket API endpoint will be started.
 	WSHost string `toml:",omitempty"`
@@ -152,6 +157,10 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// WSPolicies restricts the methods and the call rate of the API modules exposed via
+	// the websocket RPC interface, keyed by the namespace.
+	WSPolicies map[string]rpc.NamespacePolicy `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 
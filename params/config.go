@@ -127,16 +127,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, nil, nil, new(EthashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, nil, nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, nil, nil, nil, new(EthashConfig), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -176,12 +176,49 @@ type ChainConfig struct {
 	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"` // Constantinople switch block (nil = no fork, 0 = already activated)
 	EWASMBlock          *big.Int `json:"ewasmBlock,omitempty"`          // EWASM switch block (nil = no fork, 0 = already activated)
 
+	// ReplayProtection enforces the EIP155 replay protection on the precompiled contract txs
+	ReplayProtection *ReplayProtectionConfig `json:"replayProtection,omitempty"`
+
+	// PrecompileFailureBlock records the reason of the failed precompiled contract txs in the
+	// receipts from the block. The reason is not part of the receipt consensus encoding, so the
+	// block could be rescheduled without rewinding the chain
+	PrecompileFailureBlock *big.Int `json:"precompileFailureBlock,omitempty"`
+
+	// ContractRecordBlock stores the storage contracts created or updated from the block as a
+	// single versioned record of the contract account, instead of a storage slot per field. The
+	// storage contracts created before the block are migrated on the next update
+	ContractRecordBlock *big.Int `json:"contractRecordBlock,omitempty"`
+
+	// RenewContractBlock activates the precompiled renew contract of address 17 from the block.
+	// The txs sent to the address before the block are executed as normal calls
+	RenewContractBlock *big.Int `json:"renewContractBlock,omitempty"`
+
+	// StorageProofBatchBlock activates the precompiled storage proof batch contract of address
+	// 18 from the block. The txs sent to the address before the block are executed as normal calls
+	StorageProofBatchBlock *big.Int `json:"storageProofBatchBlock,omitempty"`
+
+	// StoragePayloadLimitBlock limits the sizes of the payloads of the storage contract txs from
+	// the block. The payloads before the block are only rlp decoded
+	StoragePayloadLimitBlock *big.Int `json:"storagePayloadLimitBlock,omitempty"`
+
+	// StorageProtocolForks activate the storage protocol versions from the fork blocks, which
+	// are negotiated between the storage clients and hosts
+	StorageProtocolForks []StorageProtocolFork `json:"storageProtocolForks,omitempty"`
+
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
 	Dpos   *DposConfig   `json:"dpos,omitempty"`
 }
 
+// ReplayProtectionConfig is the enforcement of the EIP155 replay protection on the precompiled
+// contract txs. The txs of the enforced types must be signed with the chain id, and the legacy
+// signatures are rejected by the tx pool. The enforcement takes effect since the EIP155 block
+type ReplayProtectionConfig struct {
+	StorageTxs bool `json:"storageTxs,omitempty"` // Enforce on the storage contract txs
+	DposTxs    bool `json:"dposTxs,omitempty"`    // Enforce on the dpos txs
+}
+
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
 type EthashConfig struct{}
 
@@ -248,6 +285,46 @@ func (c *ChainConfig) IsEIP155(num *big.Int) bool {
 	return isForked(c.EIP155Block, num)
 }
 
+// IsStorageTxProtected returns whether the storage contract txs at block num must be signed with
+// the chain id.
+func (c *ChainConfig) IsStorageTxProtected(num *big.Int) bool {
+	return c.ReplayProtection != nil && c.ReplayProtection.StorageTxs && c.IsEIP155(num)
+}
+
+// IsDposTxProtected returns whether the dpos txs at block num must be signed with the chain id.
+func (c *ChainConfig) IsDposTxProtected(num *big.Int) bool {
+	return c.ReplayProtection != nil && c.ReplayProtection.DposTxs && c.IsEIP155(num)
+}
+
+// IsPrecompileFailureRecorded returns whether the reason of the failed precompiled contract
+// txs at block num is recorded in the receipts.
+func (c *ChainConfig) IsPrecompileFailureRecorded(num *big.Int) bool {
+	return isForked(c.PrecompileFailureBlock, num)
+}
+
+// IsContractRecord returns whether the storage contracts written at block num are stored as
+// a single record.
+func (c *ChainConfig) IsContractRecord(num *big.Int) bool {
+	return isForked(c.ContractRecordBlock, num)
+}
+
+// IsRenewContract returns whether the precompiled renew contract is active at block num.
+func (c *ChainConfig) IsRenewContract(num *big.Int) bool {
+	return isForked(c.RenewContractBlock, num)
+}
+
+// IsStorageProofBatch returns whether the precompiled storage proof batch contract is active
+// at block num.
+func (c *ChainConfig) IsStorageProofBatch(num *big.Int) bool {
+	return isForked(c.StorageProofBatchBlock, num)
+}
+
+// IsStoragePayloadLimited returns whether the sizes of the payloads of the storage contract
+// txs at block num are limited.
+func (c *ChainConfig) IsStoragePayloadLimited(num *big.Int) bool {
+	return isForked(c.StoragePayloadLimitBlock, num)
+}
+
 // IsEIP158 returns whether num is either equal to the EIP158 fork block or greater.
 func (c *ChainConfig) IsEIP158(num *big.Int) bool {
 	return isForked(c.EIP158Block, num)
@@ -336,6 +413,24 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.EWASMBlock, newcfg.EWASMBlock, head) {
 		return newCompatError("ewasm fork block", c.EWASMBlock, newcfg.EWASMBlock)
 	}
+	if isForkIncompatible(c.ContractRecordBlock, newcfg.ContractRecordBlock, head) {
+		return newCompatError("contract record fork block", c.ContractRecordBlock, newcfg.ContractRecordBlock)
+	}
+	if isForkIncompatible(c.RenewContractBlock, newcfg.RenewContractBlock, head) {
+		return newCompatError("renew contract fork block", c.RenewContractBlock, newcfg.RenewContractBlock)
+	}
+	if isForkIncompatible(c.StorageProofBatchBlock, newcfg.StorageProofBatchBlock, head) {
+		return newCompatError("storage proof batch fork block", c.StorageProofBatchBlock, newcfg.StorageProofBatchBlock)
+	}
+	if isForkIncompatible(c.StoragePayloadLimitBlock, newcfg.StoragePayloadLimitBlock, head) {
+		return newCompatError("storage payload limit fork block", c.StoragePayloadLimitBlock, newcfg.StoragePayloadLimitBlock)
+	}
+	if err := c.checkStorageProtocolCompatible(newcfg, head); err != nil {
+		return err
+	}
+	if err := c.Dpos.checkCompatible(newcfg.Dpos, head); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -6,6 +6,7 @@ package params
 
 import (
 	"encoding/json"
+	"math/big"
 	"testing"
 
 	"github.com/DxChainNetwork/godx/common"
@@ -29,3 +30,96 @@ func TestValidatorConfig_MarshalJSON(t *testing.T) {
 	}
 
 }
+
+func TestContractRecordCompatible(t *testing.T) {
+	stored := &ChainConfig{ContractRecordBlock: big.NewInt(100)}
+	tests := []struct {
+		block  *big.Int
+		head   uint64
+		compat bool
+	}{
+		{big.NewInt(100), 200, true},
+		{big.NewInt(150), 50, true},
+		{nil, 50, true},
+		{big.NewInt(150), 120, false},
+		{nil, 120, false},
+	}
+	for i, test := range tests {
+		err := stored.CheckCompatible(&ChainConfig{ContractRecordBlock: test.block}, test.head)
+		if (err == nil) != test.compat {
+			t.Errorf("test %d: expect compatible %v, got error %v", i, test.compat, err)
+		}
+	}
+	if stored.IsContractRecord(big.NewInt(99)) || !stored.IsContractRecord(big.NewInt(100)) {
+		t.Error("contract record fork not activated at the fork block")
+	}
+}
+
+func TestRenewContractCompatible(t *testing.T) {
+	stored := &ChainConfig{RenewContractBlock: big.NewInt(100)}
+	tests := []struct {
+		block  *big.Int
+		head   uint64
+		compat bool
+	}{
+		{big.NewInt(100), 200, true},
+		{big.NewInt(150), 50, true},
+		{big.NewInt(150), 120, false},
+		{nil, 120, false},
+	}
+	for i, test := range tests {
+		err := stored.CheckCompatible(&ChainConfig{RenewContractBlock: test.block}, test.head)
+		if (err == nil) != test.compat {
+			t.Errorf("test %d: expect compatible %v, got error %v", i, test.compat, err)
+		}
+	}
+	if stored.IsRenewContract(big.NewInt(99)) || !stored.IsRenewContract(big.NewInt(100)) {
+		t.Error("renew contract fork not activated at the fork block")
+	}
+}
+
+func TestStorageProofBatchCompatible(t *testing.T) {
+	stored := &ChainConfig{StorageProofBatchBlock: big.NewInt(100)}
+	tests := []struct {
+		block  *big.Int
+		head   uint64
+		compat bool
+	}{
+		{big.NewInt(100), 200, true},
+		{big.NewInt(150), 50, true},
+		{big.NewInt(150), 120, false},
+		{nil, 120, false},
+	}
+	for i, test := range tests {
+		err := stored.CheckCompatible(&ChainConfig{StorageProofBatchBlock: test.block}, test.head)
+		if (err == nil) != test.compat {
+			t.Errorf("test %d: expect compatible %v, got error %v", i, test.compat, err)
+		}
+	}
+	if stored.IsStorageProofBatch(big.NewInt(99)) || !stored.IsStorageProofBatch(big.NewInt(100)) {
+		t.Error("storage proof batch fork not activated at the fork block")
+	}
+}
+
+func TestStoragePayloadLimitCompatible(t *testing.T) {
+	stored := &ChainConfig{StoragePayloadLimitBlock: big.NewInt(100)}
+	tests := []struct {
+		block  *big.Int
+		head   uint64
+		compat bool
+	}{
+		{big.NewInt(100), 200, true},
+		{big.NewInt(150), 50, true},
+		{big.NewInt(150), 120, false},
+		{nil, 120, false},
+	}
+	for i, test := range tests {
+		err := stored.CheckCompatible(&ChainConfig{StoragePayloadLimitBlock: test.block}, test.head)
+		if (err == nil) != test.compat {
+			t.Errorf("test %d: expect compatible %v, got error %v", i, test.compat, err)
+		}
+	}
+	if stored.IsStoragePayloadLimited(big.NewInt(99)) || !stored.IsStoragePayloadLimited(big.NewInt(100)) {
+		t.Error("storage payload limit fork not activated at the fork block")
+	}
+}
@@ -7,6 +7,7 @@ package params
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/DxChainNetwork/godx/common"
@@ -123,10 +124,97 @@ var (
 	}
 )
 
+const (
+	// DefaultMaxValidatorSize is the number of validators elected per epoch if neither the
+	// validator size nor the validator size fork is configured
+	DefaultMaxValidatorSize = 21
+
+	// DefaultBlockInterval is the seconds between two blocks if no block interval is configured
+	DefaultBlockInterval = 10
+
+	// DefaultEpochInterval is the seconds of an epoch if no epoch interval is configured
+	DefaultEpochInterval = 86400
+)
+
+// DefaultMinCandidateDeposit is the minimum deposit of a candidate if no minimum deposit
+// fork is configured
+var DefaultMinCandidateDeposit = common.NewBigIntUint64(1e18).MultInt64(10000)
+
 // DposConfig is the consensus engine configs for delegated proof-of-stake based sealing.
 type DposConfig struct {
 	//Validators []common.Address `json:"validators"` // Genesis validator list
 	Validators []ValidatorConfig `json:"validators"` // Genesis validator list
+
+	// Candidates are the genesis candidates besides the validators of the first epoch, which
+	// could be elected from the following epochs
+	Candidates []ValidatorConfig `json:"candidates,omitempty"`
+
+	// Votes are the genesis votes of the delegators for the validators and the candidates
+	Votes []VoteConfig `json:"votes,omitempty"`
+
+	// ValidatorSizeForks adjust the number of validators elected per epoch from the fork blocks
+	ValidatorSizeForks []ValidatorSizeFork `json:"validatorSizeForks,omitempty"`
+
+	// MinDepositForks adjust the minimum deposit of a candidate from the fork blocks
+	MinDepositForks []MinDepositFork `json:"minDepositForks,omitempty"`
+
+	// VoteExpiration makes the votes not refreshed by the delegators stop counting in election
+	VoteExpiration *VoteExpiration `json:"voteExpiration,omitempty"`
+
+	// ValidatorLockBlock forbids the validators of the current epoch to cancel being candidates
+	// from the block, so that the deposit could not be withdrawn while producing blocks
+	ValidatorLockBlock *big.Int `json:"validatorLockBlock,omitempty"`
+
+	// OperationGasBlock meters the gas of the dpos txs by the storage operations performed from
+	// the block, e.g. the number of candidates voted and the deposit changes, instead of the fixed
+	// gas of each tx type
+	OperationGasBlock *big.Int `json:"operationGasBlock,omitempty"`
+
+	// VoteCandidateCheckBlock rejects the vote txs voting any address which is not a candidate
+	// from the block, instead of voting only the existing candidates with the whole deposit
+	VoteCandidateCheckBlock *big.Int `json:"voteCandidateCheckBlock,omitempty"`
+
+	// EpochRewardRecordBlock records the block rewards distributed to the validators and the
+	// delegators in each epoch from the block
+	EpochRewardRecordBlock *big.Int `json:"epochRewardRecordBlock,omitempty"`
+
+	// CheckpointBlock makes the validators sign the checkpoint of the epoch in the extra data of
+	// the blocks produced from the block
+	CheckpointBlock *big.Int `json:"checkpointBlock,omitempty"`
+
+	// BlockInterval and EpochInterval are the seconds between two blocks and the seconds of an
+	// epoch. They could be shortened for the private deployments and tests, but must not be
+	// changed once the chain has blocks
+	BlockInterval uint64 `json:"blockInterval,omitempty"`
+	EpochInterval uint64 `json:"epochInterval,omitempty"`
+
+	// ValidatorSize is the number of validators elected per epoch before any validator size fork
+	ValidatorSize uint64 `json:"maxValidatorSize,omitempty"`
+
+	// MaxReorgDepth is the max number of blocks dropped from the canonical chain by a reorg,
+	// 0 means no limit. The reorg dropping the confirmed block is refused regardless
+	MaxReorgDepth uint64 `json:"maxReorgDepth,omitempty"`
+}
+
+// ValidatorSizeFork defines the number of validators elected per epoch starting from the block
+type ValidatorSizeFork struct {
+	Block *big.Int `json:"block"`
+	Size  uint64   `json:"size"`
+}
+
+// VoteExpiration defines the expiration of the votes starting from the block. The votes not
+// refreshed for Epochs epochs stop counting in election. If Decay is set, the votes decay
+// linearly within the epochs instead of expiring at once
+type VoteExpiration struct {
+	Block  *big.Int `json:"block"`
+	Epochs uint64   `json:"epochs"`
+	Decay  bool     `json:"decay,omitempty"`
+}
+
+// MinDepositFork defines the minimum deposit of a candidate starting from the block
+type MinDepositFork struct {
+	Block   *big.Int      `json:"block"`
+	Deposit common.BigInt `json:"deposit"`
 }
 
 type ValidatorConfig struct {
@@ -135,6 +223,14 @@ type ValidatorConfig struct {
 	RewardRatio uint64         `json:"rewardRatio"`
 }
 
+// VoteConfig is the genesis vote of the delegator, with the deposit frozen from the balance
+// allocated in genesis
+type VoteConfig struct {
+	Delegator  common.Address   `json:"delegator" gencodec:"required"`
+	Deposit    common.BigInt    `json:"deposit" gencodec:"required"`
+	Candidates []common.Address `json:"candidates" gencodec:"required"`
+}
+
 func DefaultDposConfig() *DposConfig {
 	return &DposConfig{
 		Validators: DefaultValidators,
@@ -148,6 +244,263 @@ func (d *DposConfig) ParseValidators() (validators []common.Address) {
 	return
 }
 
+// BlockPeriod returns the seconds between two blocks, DefaultBlockInterval is returned if
+// the block interval is not configured
+func (d *DposConfig) BlockPeriod() int64 {
+	if d == nil || d.BlockInterval == 0 {
+		return DefaultBlockInterval
+	}
+	return int64(d.BlockInterval)
+}
+
+// EpochPeriod returns the seconds of an epoch, DefaultEpochInterval is returned if the epoch
+// interval is not configured
+func (d *DposConfig) EpochPeriod() int64 {
+	if d == nil || d.EpochInterval == 0 {
+		return DefaultEpochInterval
+	}
+	return int64(d.EpochInterval)
+}
+
+// ReorgDepthLimit returns the max number of blocks dropped by a reorg, 0 means no limit
+func (d *DposConfig) ReorgDepthLimit() uint64 {
+	if d == nil {
+		return 0
+	}
+	return d.MaxReorgDepth
+}
+
+// ValidateTiming checks that the epoch is made up of whole block slots, and that each of the
+// validators elected has at least one slot in the epoch
+func (d *DposConfig) ValidateTiming() error {
+	blockPeriod, epochPeriod := d.BlockPeriod(), d.EpochPeriod()
+	if epochPeriod%blockPeriod != 0 {
+		return fmt.Errorf("dpos epoch interval %d is not a multiple of block interval %d", epochPeriod, blockPeriod)
+	}
+	slots := uint64(epochPeriod / blockPeriod)
+	sizes := []uint64{d.MaxValidatorSize(common.Big0)}
+	if d != nil {
+		for _, fork := range d.ValidatorSizeForks {
+			sizes = append(sizes, fork.Size)
+		}
+	}
+	for _, size := range sizes {
+		if size > slots {
+			return fmt.Errorf("dpos epoch has %d block slots, less than the validator size %d", slots, size)
+		}
+	}
+	return nil
+}
+
+// CheckGenesisCompatible checks whether the block interval, epoch interval or the validator
+// size applied from the genesis are changed in the new config. Unlike the forks, they could
+// not be rescheduled by rewinding the chain, since they apply to all the blocks in the chain
+func (d *DposConfig) CheckGenesisCompatible(newcfg *DposConfig) error {
+	if d.BlockPeriod() != newcfg.BlockPeriod() {
+		return fmt.Errorf("mismatching dpos block interval in database (have %d, want %d)", d.BlockPeriod(), newcfg.BlockPeriod())
+	}
+	if d.EpochPeriod() != newcfg.EpochPeriod() {
+		return fmt.Errorf("mismatching dpos epoch interval in database (have %d, want %d)", d.EpochPeriod(), newcfg.EpochPeriod())
+	}
+	if stored, updated := d.MaxValidatorSize(common.Big0), newcfg.MaxValidatorSize(common.Big0); stored != updated {
+		return fmt.Errorf("mismatching dpos validator size in database (have %d, want %d)", stored, updated)
+	}
+	return nil
+}
+
+// MaxValidatorSize returns the number of validators elected per epoch at the given block.
+// The size of the latest fork activated at the block is used. If no fork is activated, the
+// configured validator size or DefaultMaxValidatorSize is returned
+func (d *DposConfig) MaxValidatorSize(num *big.Int) uint64 {
+	if d == nil {
+		return DefaultMaxValidatorSize
+	}
+	var (
+		size      uint64 = DefaultMaxValidatorSize
+		forkBlock *big.Int
+	)
+	if d.ValidatorSize != 0 {
+		size = d.ValidatorSize
+	}
+	for _, fork := range d.ValidatorSizeForks {
+		if fork.Size == 0 || !isForked(fork.Block, num) {
+			continue
+		}
+		if forkBlock == nil || fork.Block.Cmp(forkBlock) >= 0 {
+			size, forkBlock = fork.Size, fork.Block
+		}
+	}
+	return size
+}
+
+// MinCandidateDeposit returns the minimum deposit of a candidate at the given block. The
+// deposit of the latest fork activated at the block is used, and DefaultMinCandidateDeposit
+// is returned if no fork is activated
+func (d *DposConfig) MinCandidateDeposit(num *big.Int) common.BigInt {
+	if d == nil {
+		return DefaultMinCandidateDeposit
+	}
+	var (
+		deposit   = DefaultMinCandidateDeposit
+		forkBlock *big.Int
+	)
+	for _, fork := range d.MinDepositForks {
+		if !isForked(fork.Block, num) {
+			continue
+		}
+		if forkBlock == nil || fork.Block.Cmp(forkBlock) >= 0 {
+			deposit, forkBlock = fork.Deposit, fork.Block
+		}
+	}
+	return deposit
+}
+
+// VoteExpirationAt returns the vote expiration applied at the given block. nil is returned
+// if the vote expiration is not configured or not activated yet
+func (d *DposConfig) VoteExpirationAt(num *big.Int) *VoteExpiration {
+	if d == nil || d.VoteExpiration == nil || d.VoteExpiration.Epochs == 0 {
+		return nil
+	}
+	if !isForked(d.VoteExpiration.Block, num) {
+		return nil
+	}
+	return d.VoteExpiration
+}
+
+// IsValidatorLocked returns whether the validators of the current epoch are forbidden to cancel
+// being candidates at the given block
+func (d *DposConfig) IsValidatorLocked(num *big.Int) bool {
+	return d != nil && isForked(d.ValidatorLockBlock, num)
+}
+
+// IsOperationGasMetered returns whether the gas of the dpos txs is metered by the storage
+// operations at the given block
+func (d *DposConfig) IsOperationGasMetered(num *big.Int) bool {
+	return d != nil && isForked(d.OperationGasBlock, num)
+}
+
+// IsVoteCandidateChecked returns whether the vote txs voting the addresses which are not
+// candidates are rejected at the given block
+func (d *DposConfig) IsVoteCandidateChecked(num *big.Int) bool {
+	return d != nil && isForked(d.VoteCandidateCheckBlock, num)
+}
+
+// IsEpochRewardRecorded returns whether the block rewards distributed in each epoch are recorded
+// at the given block
+func (d *DposConfig) IsEpochRewardRecorded(num *big.Int) bool {
+	return d != nil && isForked(d.EpochRewardRecordBlock, num)
+}
+
+// IsCheckpointSigned returns whether the checkpoint of the epoch is signed in the extra data of
+// the block at the given block
+func (d *DposConfig) IsCheckpointSigned(num *big.Int) bool {
+	return d != nil && isForked(d.CheckpointBlock, num)
+}
+
+// checkCompatible checks whether the validator size forks, minimum deposit forks, vote
+// expiration, validator lock, operation gas, vote candidate check, epoch reward record and
+// checkpoint already activated at head are rescheduled or changed in the new config
+func (d *DposConfig) checkCompatible(newcfg *DposConfig, head *big.Int) *ConfigCompatError {
+	var forks []ValidatorSizeFork
+	if d != nil {
+		forks = append(forks, d.ValidatorSizeForks...)
+	}
+	if newcfg != nil {
+		forks = append(forks, newcfg.ValidatorSizeForks...)
+	}
+	for _, fork := range forks {
+		if !isForked(fork.Block, head) {
+			continue
+		}
+		if d.MaxValidatorSize(fork.Block) != newcfg.MaxValidatorSize(fork.Block) {
+			return newCompatError("dpos validator size fork block", fork.Block, fork.Block)
+		}
+	}
+
+	var depositForks []MinDepositFork
+	if d != nil {
+		depositForks = append(depositForks, d.MinDepositForks...)
+	}
+	if newcfg != nil {
+		depositForks = append(depositForks, newcfg.MinDepositForks...)
+	}
+	for _, fork := range depositForks {
+		if !isForked(fork.Block, head) {
+			continue
+		}
+		if d.MinCandidateDeposit(fork.Block).Cmp(newcfg.MinCandidateDeposit(fork.Block)) != 0 {
+			return newCompatError("dpos minimum deposit fork block", fork.Block, fork.Block)
+		}
+	}
+
+	stored, updated := d.VoteExpirationAt(head), newcfg.VoteExpirationAt(head)
+	switch {
+	case stored == nil && updated == nil:
+	case stored == nil:
+		return newCompatError("dpos vote expiration block", nil, updated.Block)
+	case updated == nil:
+		return newCompatError("dpos vote expiration block", stored.Block, nil)
+	case stored.Block.Cmp(updated.Block) != 0 || stored.Epochs != updated.Epochs || stored.Decay != updated.Decay:
+		return newCompatError("dpos vote expiration block", stored.Block, updated.Block)
+	}
+
+	var storedLock, updatedLock *big.Int
+	if d != nil {
+		storedLock = d.ValidatorLockBlock
+	}
+	if newcfg != nil {
+		updatedLock = newcfg.ValidatorLockBlock
+	}
+	if isForkIncompatible(storedLock, updatedLock, head) {
+		return newCompatError("dpos validator lock block", storedLock, updatedLock)
+	}
+
+	var storedGas, updatedGas *big.Int
+	if d != nil {
+		storedGas = d.OperationGasBlock
+	}
+	if newcfg != nil {
+		updatedGas = newcfg.OperationGasBlock
+	}
+	if isForkIncompatible(storedGas, updatedGas, head) {
+		return newCompatError("dpos operation gas block", storedGas, updatedGas)
+	}
+
+	var storedCheck, updatedCheck *big.Int
+	if d != nil {
+		storedCheck = d.VoteCandidateCheckBlock
+	}
+	if newcfg != nil {
+		updatedCheck = newcfg.VoteCandidateCheckBlock
+	}
+	if isForkIncompatible(storedCheck, updatedCheck, head) {
+		return newCompatError("dpos vote candidate check block", storedCheck, updatedCheck)
+	}
+
+	var storedRecord, updatedRecord *big.Int
+	if d != nil {
+		storedRecord = d.EpochRewardRecordBlock
+	}
+	if newcfg != nil {
+		updatedRecord = newcfg.EpochRewardRecordBlock
+	}
+	if isForkIncompatible(storedRecord, updatedRecord, head) {
+		return newCompatError("dpos epoch reward record block", storedRecord, updatedRecord)
+	}
+
+	var storedCheckpoint, updatedCheckpoint *big.Int
+	if d != nil {
+		storedCheckpoint = d.CheckpointBlock
+	}
+	if newcfg != nil {
+		updatedCheckpoint = newcfg.CheckpointBlock
+	}
+	if isForkIncompatible(storedCheckpoint, updatedCheckpoint, head) {
+		return newCompatError("dpos checkpoint block", storedCheckpoint, updatedCheckpoint)
+	}
+	return nil
+}
+
 // String implements the stringer interface, returning the consensus engine details.
 func (d *DposConfig) String() string {
 	return "dpos"
@@ -187,3 +540,35 @@ func (vc *ValidatorConfig) UnmarshalJSON(input []byte) error {
 	vc.Address = devc.Address
 	return nil
 }
+
+func (vc VoteConfig) MarshalJSON() ([]byte, error) {
+	type VoteConfig struct {
+		Delegator  common.Address        `json:"delegator" gencodec:"required"`
+		Deposit    *math.HexOrDecimal256 `json:"deposit" gencodec:"required"`
+		Candidates []common.Address      `json:"candidates" gencodec:"required"`
+	}
+	var enc VoteConfig
+	enc.Delegator = vc.Delegator
+	enc.Deposit = (*math.HexOrDecimal256)(vc.Deposit.BigIntPtr())
+	enc.Candidates = vc.Candidates
+	return json.Marshal(&enc)
+}
+
+func (vc *VoteConfig) UnmarshalJSON(input []byte) error {
+	type VoteConfig struct {
+		Delegator  common.Address        `json:"delegator" gencodec:"required"`
+		Deposit    *math.HexOrDecimal256 `json:"deposit" gencodec:"required"`
+		Candidates []common.Address      `json:"candidates" gencodec:"required"`
+	}
+	var dec VoteConfig
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.Deposit == nil {
+		return errors.New("missing required field 'deposit' for VoteConfig")
+	}
+	vc.Delegator = dec.Delegator
+	vc.Deposit = common.PtrBigInt((*big.Int)(dec.Deposit))
+	vc.Candidates = dec.Candidates
+	return nil
+}
@@ -6,8 +6,11 @@ package params
 
 import (
 	"encoding/json"
+	"math/big"
 	"reflect"
 	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
 )
 
 func TestValidatorConfig_JSON(t *testing.T) {
@@ -24,3 +27,297 @@ func TestValidatorConfig_JSON(t *testing.T) {
 		t.Errorf("cannot recover")
 	}
 }
+
+func TestDposConfig_MaxValidatorSize(t *testing.T) {
+	config := &DposConfig{
+		ValidatorSizeForks: []ValidatorSizeFork{
+			{Block: big.NewInt(200), Size: 31},
+			{Block: big.NewInt(100), Size: 5},
+		},
+	}
+	tests := []struct {
+		number int64
+		expect uint64
+	}{
+		{0, DefaultMaxValidatorSize},
+		{99, DefaultMaxValidatorSize},
+		{100, 5},
+		{199, 5},
+		{200, 31},
+		{1000, 31},
+	}
+	for _, test := range tests {
+		if size := config.MaxValidatorSize(big.NewInt(test.number)); size != test.expect {
+			t.Errorf("block %v: max validator size not expected. Got %v, Expect %v", test.number, size, test.expect)
+		}
+	}
+
+	var nilConfig *DposConfig
+	if size := nilConfig.MaxValidatorSize(big.NewInt(100)); size != DefaultMaxValidatorSize {
+		t.Errorf("nil config: max validator size not expected. Got %v, Expect %v", size, DefaultMaxValidatorSize)
+	}
+}
+
+func TestDposConfig_MinCandidateDeposit(t *testing.T) {
+	config := &DposConfig{
+		MinDepositForks: []MinDepositFork{
+			{Block: big.NewInt(100), Deposit: common.NewBigIntUint64(1e18)},
+			{Block: big.NewInt(200), Deposit: common.NewBigIntUint64(1e18).MultInt64(50000)},
+		},
+	}
+	tests := []struct {
+		number int64
+		expect common.BigInt
+	}{
+		{0, DefaultMinCandidateDeposit},
+		{99, DefaultMinCandidateDeposit},
+		{100, common.NewBigIntUint64(1e18)},
+		{199, common.NewBigIntUint64(1e18)},
+		{200, common.NewBigIntUint64(1e18).MultInt64(50000)},
+	}
+	for _, test := range tests {
+		if deposit := config.MinCandidateDeposit(big.NewInt(test.number)); deposit.Cmp(test.expect) != 0 {
+			t.Errorf("block %v: min candidate deposit not expected. Got %v, Expect %v", test.number, deposit, test.expect)
+		}
+	}
+
+	var nilConfig *DposConfig
+	if deposit := nilConfig.MinCandidateDeposit(big.NewInt(100)); deposit.Cmp(DefaultMinCandidateDeposit) != 0 {
+		t.Errorf("nil config: min candidate deposit not expected. Got %v, Expect %v", deposit, DefaultMinCandidateDeposit)
+	}
+}
+
+func TestDposConfig_VoteExpirationAt(t *testing.T) {
+	expiration := &VoteExpiration{Block: big.NewInt(100), Epochs: 3}
+	tests := []struct {
+		config *DposConfig
+		number int64
+		expect *VoteExpiration
+	}{
+		{nil, 100, nil},
+		{&DposConfig{}, 100, nil},
+		{&DposConfig{VoteExpiration: expiration}, 99, nil},
+		{&DposConfig{VoteExpiration: expiration}, 100, expiration},
+		{&DposConfig{VoteExpiration: &VoteExpiration{Block: big.NewInt(100)}}, 100, nil},
+	}
+	for i, test := range tests {
+		if got := test.config.VoteExpirationAt(big.NewInt(test.number)); got != test.expect {
+			t.Errorf("test %d: vote expiration not expected. Got %v, Expect %v", i, got, test.expect)
+		}
+	}
+}
+
+func TestDposConfig_IsValidatorLocked(t *testing.T) {
+	tests := []struct {
+		config *DposConfig
+		number int64
+		expect bool
+	}{
+		{nil, 100, false},
+		{&DposConfig{}, 100, false},
+		{&DposConfig{ValidatorLockBlock: big.NewInt(100)}, 99, false},
+		{&DposConfig{ValidatorLockBlock: big.NewInt(100)}, 100, true},
+	}
+	for i, test := range tests {
+		if got := test.config.IsValidatorLocked(big.NewInt(test.number)); got != test.expect {
+			t.Errorf("test %d: validator lock not expected. Got %v, Expect %v", i, got, test.expect)
+		}
+	}
+}
+
+func TestDposConfig_IsOperationGasMetered(t *testing.T) {
+	tests := []struct {
+		config *DposConfig
+		number int64
+		expect bool
+	}{
+		{nil, 100, false},
+		{&DposConfig{}, 100, false},
+		{&DposConfig{OperationGasBlock: big.NewInt(100)}, 99, false},
+		{&DposConfig{OperationGasBlock: big.NewInt(100)}, 100, true},
+	}
+	for i, test := range tests {
+		if got := test.config.IsOperationGasMetered(big.NewInt(test.number)); got != test.expect {
+			t.Errorf("test %d: operation gas not expected. Got %v, Expect %v", i, got, test.expect)
+		}
+	}
+}
+
+func TestDposConfig_IsVoteCandidateChecked(t *testing.T) {
+	tests := []struct {
+		config *DposConfig
+		number int64
+		expect bool
+	}{
+		{nil, 100, false},
+		{&DposConfig{}, 100, false},
+		{&DposConfig{VoteCandidateCheckBlock: big.NewInt(100)}, 99, false},
+		{&DposConfig{VoteCandidateCheckBlock: big.NewInt(100)}, 100, true},
+	}
+	for i, test := range tests {
+		if got := test.config.IsVoteCandidateChecked(big.NewInt(test.number)); got != test.expect {
+			t.Errorf("test %d: vote candidate check not expected. Got %v, Expect %v", i, got, test.expect)
+		}
+	}
+}
+
+func TestDposConfig_IsEpochRewardRecorded(t *testing.T) {
+	tests := []struct {
+		config *DposConfig
+		number int64
+		expect bool
+	}{
+		{nil, 100, false},
+		{&DposConfig{}, 100, false},
+		{&DposConfig{EpochRewardRecordBlock: big.NewInt(100)}, 99, false},
+		{&DposConfig{EpochRewardRecordBlock: big.NewInt(100)}, 100, true},
+	}
+	for i, test := range tests {
+		if got := test.config.IsEpochRewardRecorded(big.NewInt(test.number)); got != test.expect {
+			t.Errorf("test %d: epoch reward record not expected. Got %v, Expect %v", i, got, test.expect)
+		}
+	}
+}
+
+func TestDposConfig_IsCheckpointSigned(t *testing.T) {
+	tests := []struct {
+		config *DposConfig
+		number int64
+		expect bool
+	}{
+		{nil, 100, false},
+		{&DposConfig{}, 100, false},
+		{&DposConfig{CheckpointBlock: big.NewInt(100)}, 99, false},
+		{&DposConfig{CheckpointBlock: big.NewInt(100)}, 100, true},
+	}
+	for i, test := range tests {
+		if got := test.config.IsCheckpointSigned(big.NewInt(test.number)); got != test.expect {
+			t.Errorf("test %d: checkpoint not expected. Got %v, Expect %v", i, got, test.expect)
+		}
+	}
+}
+
+func TestDposConfig_checkCompatible(t *testing.T) {
+	stored := &DposConfig{
+		ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+	}
+	tests := []struct {
+		newcfg     *DposConfig
+		head       int64
+		compatible bool
+	}{
+		{stored, 200, true},
+		{&DposConfig{ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(100), Size: 7}}}, 50, true},
+		{&DposConfig{ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(100), Size: 7}}}, 200, false},
+		{&DposConfig{ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(150), Size: 5}}}, 120, false},
+		{&DposConfig{}, 200, false},
+		{&DposConfig{
+			ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			MinDepositForks:    []MinDepositFork{{Block: big.NewInt(150), Deposit: common.NewBigIntUint64(1e18)}},
+		}, 120, true},
+		{&DposConfig{
+			ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			MinDepositForks:    []MinDepositFork{{Block: big.NewInt(150), Deposit: common.NewBigIntUint64(1e18)}},
+		}, 200, false},
+		{&DposConfig{
+			ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			VoteExpiration:     &VoteExpiration{Block: big.NewInt(300), Epochs: 3},
+		}, 200, true},
+		{&DposConfig{
+			ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			VoteExpiration:     &VoteExpiration{Block: big.NewInt(150), Epochs: 3},
+		}, 200, false},
+		{&DposConfig{
+			ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			ValidatorLockBlock: big.NewInt(300),
+		}, 200, true},
+		{&DposConfig{
+			ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			ValidatorLockBlock: big.NewInt(150),
+		}, 200, false},
+		{&DposConfig{
+			ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			OperationGasBlock:  big.NewInt(300),
+		}, 200, true},
+		{&DposConfig{
+			ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			OperationGasBlock:  big.NewInt(150),
+		}, 200, false},
+		{&DposConfig{
+			ValidatorSizeForks:      []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			VoteCandidateCheckBlock: big.NewInt(300),
+		}, 200, true},
+		{&DposConfig{
+			ValidatorSizeForks:      []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			VoteCandidateCheckBlock: big.NewInt(150),
+		}, 200, false},
+		{&DposConfig{
+			ValidatorSizeForks:     []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			EpochRewardRecordBlock: big.NewInt(300),
+		}, 200, true},
+		{&DposConfig{
+			ValidatorSizeForks:     []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			EpochRewardRecordBlock: big.NewInt(150),
+		}, 200, false},
+		{&DposConfig{
+			ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			CheckpointBlock:    big.NewInt(300),
+		}, 200, true},
+		{&DposConfig{
+			ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(100), Size: 5}},
+			CheckpointBlock:    big.NewInt(150),
+		}, 200, false},
+	}
+	for i, test := range tests {
+		err := stored.checkCompatible(test.newcfg, big.NewInt(test.head))
+		if (err == nil) != test.compatible {
+			t.Errorf("test %d: compatibility not expected. Got error %v", i, err)
+		}
+	}
+}
+
+func TestDposConfig_Timing(t *testing.T) {
+	var nilConfig *DposConfig
+	if nilConfig.BlockPeriod() != DefaultBlockInterval || nilConfig.EpochPeriod() != DefaultEpochInterval {
+		t.Errorf("nil config should use the default intervals")
+	}
+	if err := nilConfig.ValidateTiming(); err != nil {
+		t.Errorf("default intervals should be valid: %v", err)
+	}
+
+	config := &DposConfig{BlockInterval: 1, EpochInterval: 60, ValidatorSize: 3}
+	if config.BlockPeriod() != 1 || config.EpochPeriod() != 60 {
+		t.Errorf("intervals not expected. Got %v, %v", config.BlockPeriod(), config.EpochPeriod())
+	}
+	if size := config.MaxValidatorSize(big.NewInt(100)); size != 3 {
+		t.Errorf("max validator size not expected. Got %v, Expect %v", size, 3)
+	}
+	if err := config.ValidateTiming(); err != nil {
+		t.Errorf("config should be valid: %v", err)
+	}
+
+	invalid := []*DposConfig{
+		{BlockInterval: 7, EpochInterval: 60},
+		{BlockInterval: 10, EpochInterval: 100},
+		{BlockInterval: 10, EpochInterval: 300, ValidatorSizeForks: []ValidatorSizeFork{{Block: big.NewInt(10), Size: 31}}},
+	}
+	for i, cfg := range invalid {
+		if err := cfg.ValidateTiming(); err == nil {
+			t.Errorf("config %d should be invalid", i)
+		}
+	}
+
+	if err := config.CheckGenesisCompatible(&DposConfig{BlockInterval: 1, EpochInterval: 60, ValidatorSize: 3}); err != nil {
+		t.Errorf("same config should be compatible: %v", err)
+	}
+	changed := []*DposConfig{
+		{BlockInterval: 2, EpochInterval: 60, ValidatorSize: 3},
+		{BlockInterval: 1, EpochInterval: 120, ValidatorSize: 3},
+		{BlockInterval: 1, EpochInterval: 60},
+	}
+	for i, cfg := range changed {
+		if err := config.CheckGenesisCompatible(cfg); err == nil {
+			t.Errorf("changed config %d should not be compatible", i)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package params
+
+import "math/big"
+
+// StorageProtocolV1 is the storage protocol version used since the genesis
+const StorageProtocolV1 uint64 = 1
+
+// StorageProtocolFork activates the storage protocol version starting from the block. The
+// storage protocol parameters, such as the sector size and the proof window size, are
+// defined by the version
+type StorageProtocolFork struct {
+	Block   *big.Int `json:"block"`
+	Version uint64   `json:"version"`
+}
+
+// StorageProtocolVersion returns the storage protocol version active at the given block. The
+// version of the latest fork activated at the block is used, and StorageProtocolV1 is returned
+// if no fork is activated
+func (c *ChainConfig) StorageProtocolVersion(num *big.Int) uint64 {
+	if c == nil {
+		return StorageProtocolV1
+	}
+	var (
+		version   = StorageProtocolV1
+		forkBlock *big.Int
+	)
+	for _, fork := range c.StorageProtocolForks {
+		if fork.Version == 0 || !isForked(fork.Block, num) {
+			continue
+		}
+		if forkBlock == nil || fork.Block.Cmp(forkBlock) >= 0 {
+			version, forkBlock = fork.Version, fork.Block
+		}
+	}
+	return version
+}
+
+// checkStorageProtocolCompatible checks whether the storage protocol version activated at any
+// fork block before the head is changed in the new config
+func (c *ChainConfig) checkStorageProtocolCompatible(newcfg *ChainConfig, head *big.Int) *ConfigCompatError {
+	forks := append(append([]StorageProtocolFork{}, c.StorageProtocolForks...), newcfg.StorageProtocolForks...)
+	for _, fork := range forks {
+		if !isForked(fork.Block, head) {
+			continue
+		}
+		if c.StorageProtocolVersion(fork.Block) != newcfg.StorageProtocolVersion(fork.Block) {
+			return newCompatError("storage protocol fork block", fork.Block, fork.Block)
+		}
+	}
+	return nil
+}
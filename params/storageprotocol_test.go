@@ -0,0 +1,67 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package params
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestChainConfig_StorageProtocolVersion(t *testing.T) {
+	config := &ChainConfig{
+		StorageProtocolForks: []StorageProtocolFork{
+			{Block: big.NewInt(200), Version: 3},
+			{Block: big.NewInt(100), Version: 2},
+		},
+	}
+	tests := []struct {
+		number int64
+		expect uint64
+	}{
+		{0, StorageProtocolV1},
+		{99, StorageProtocolV1},
+		{100, 2},
+		{199, 2},
+		{200, 3},
+		{1000, 3},
+	}
+	for _, test := range tests {
+		if version := config.StorageProtocolVersion(big.NewInt(test.number)); version != test.expect {
+			t.Errorf("block %v: storage protocol version not expected. Got %v, Expect %v", test.number, version, test.expect)
+		}
+	}
+
+	var nilConfig *ChainConfig
+	if version := nilConfig.StorageProtocolVersion(big.NewInt(100)); version != StorageProtocolV1 {
+		t.Errorf("nil config: storage protocol version not expected. Got %v, Expect %v", version, StorageProtocolV1)
+	}
+}
+
+func TestChainConfig_checkStorageProtocolCompatible(t *testing.T) {
+	stored := &ChainConfig{
+		StorageProtocolForks: []StorageProtocolFork{{Block: big.NewInt(100), Version: 2}},
+	}
+	tests := []struct {
+		newcfg     *ChainConfig
+		head       int64
+		compatible bool
+	}{
+		{stored, 200, true},
+		{&ChainConfig{StorageProtocolForks: []StorageProtocolFork{{Block: big.NewInt(100), Version: 3}}}, 50, true},
+		{&ChainConfig{StorageProtocolForks: []StorageProtocolFork{{Block: big.NewInt(100), Version: 3}}}, 200, false},
+		{&ChainConfig{StorageProtocolForks: []StorageProtocolFork{{Block: big.NewInt(150), Version: 2}}}, 120, false},
+		{&ChainConfig{}, 200, false},
+		{&ChainConfig{StorageProtocolForks: []StorageProtocolFork{
+			{Block: big.NewInt(100), Version: 2},
+			{Block: big.NewInt(300), Version: 3},
+		}}, 200, true},
+	}
+	for i, test := range tests {
+		err := stored.checkStorageProtocolCompatible(test.newcfg, big.NewInt(test.head))
+		if (err == nil) != test.compatible {
+			t.Errorf("test %d: compatibility not expected. Got error %v", i, err)
+		}
+	}
+}
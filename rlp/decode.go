@@ -838,6 +838,47 @@ func (s *Stream) Bytes() ([]byte, error) {
 	}
 }
 
+// BytesReader reads the header of an RLP string and returns a reader of its contents
+// along with the content size. It is used to process large strings while the bytes are
+// arriving, instead of buffering the whole string first.
+// NOTE: the contents must be fully consumed before reading the next value from the stream
+func (s *Stream) BytesReader() (io.Reader, uint64, error) {
+	kind, size, err := s.Kind()
+	if err != nil {
+		return nil, 0, err
+	}
+	switch kind {
+	case Byte:
+		s.kind = -1 // re-initialize Kind
+		return bytes.NewReader([]byte{s.byteval}), 1, nil
+	case String:
+		return &stringReader{s: s, remaining: size}, size, nil
+	default:
+		return nil, 0, ErrExpectedString
+	}
+}
+
+// stringReader reads the contents of an RLP string from the stream
+type stringReader struct {
+	s         *Stream
+	remaining uint64
+}
+
+// Read reads at most the remaining contents of the string into buf
+func (r *stringReader) Read(buf []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+	if uint64(len(buf)) > r.remaining {
+		buf = buf[:r.remaining]
+	}
+	if err := r.s.readFull(buf); err != nil {
+		return 0, err
+	}
+	r.remaining -= uint64(len(buf))
+	return len(buf), nil
+}
+
 // function on decoding Raw type variable
 // NOTE: rawValue stores pre-encoded data
 func (s *Stream) Raw() ([]byte, error) {
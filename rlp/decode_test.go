@@ -905,6 +905,45 @@ func TestStreamRaw(t *testing.T) {
 	}
 }
 
+func TestStreamBytesReader(t *testing.T) {
+	// list of a 64 bytes string followed by a single byte
+	s := NewStream(bytes.NewReader(unhex("F843B8400101010101010101010101010101010101010101010101010101010101010101010101010101010101010101010101010101010101010101010101010101010102")), 0)
+	if _, err := s.List(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, size, err := s.BytesReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 64 {
+		t.Fatalf("size mismatch: got %d, want %d", size, 64)
+	}
+	// read the contents in small chunks
+	var contents []byte
+	buf := make([]byte, 10)
+	for {
+		n, err := r.Read(buf)
+		contents = append(contents, buf[:n]...)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := bytes.Repeat([]byte{0x01}, 64); !bytes.Equal(contents, want) {
+		t.Errorf("contents mismatch: got %x, want %x", contents, want)
+	}
+
+	// the stream should continue with the next value
+	if v, err := s.Uint(); err != nil || v != 2 {
+		t.Errorf("next value mismatch: got %d, err %v", v, err)
+	}
+	if err := s.ListEnd(); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestDecodeErrors(t *testing.T) {
 	r := bytes.NewReader(nil)
 
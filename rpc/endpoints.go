@@ -23,8 +23,8 @@ import (
 )
 
 // StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules
-// Register allowed API Services
-func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, timeouts HTTPTimeouts) (net.Listener, *Server, error) {
+// Register allowed API Services, restricted by the namespace policies
+func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, timeouts HTTPTimeouts, policies map[string]NamespacePolicy) (net.Listener, *Server, error) {
 	// Generate the whitelist based on the allowed modules
 	// modules contained a list of API.NameSpace
 	whitelist := make(map[string]bool)
@@ -34,6 +34,7 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 
 	// Register all the APIs exposed by the services
 	handler := NewServer()
+	handler.SetPolicies(policies)
 	for _, api := range apis {
 		// if no allowed modules defined in whitelist, but the api contained methods for public use
 		// or the service is contained in white list
@@ -60,8 +61,8 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 	return listener, handler, err
 }
 
-// StartWSEndpoint starts a websocket endpoint
-func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool) (net.Listener, *Server, error) {
+// StartWSEndpoint starts a websocket endpoint, restricted by the namespace policies
+func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool, policies map[string]NamespacePolicy) (net.Listener, *Server, error) {
 
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
@@ -70,6 +71,7 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 	}
 	// Register all the APIs exposed by the services
 	handler := NewServer()
+	handler.SetPolicies(policies)
 	for _, api := range apis {
 		if exposeAll || whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
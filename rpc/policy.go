@@ -0,0 +1,197 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+)
+
+// maxRemoteLimiters is the max number of remote addresses whose rate limiters are kept for
+// the single shot connections, e.g. http. The limiters idle longer than remoteLimiterIdle are
+// pruned once the number is exceeded
+const (
+	maxRemoteLimiters = 4096
+	remoteLimiterIdle = time.Minute
+)
+
+// NamespacePolicy restricts the calls of a namespace, so that the operators could expose part
+// of a namespace, e.g. the read-only storage queries, on a public endpoint without exposing
+// the methods sending transactions or mutating the config
+type NamespacePolicy struct {
+	// Methods is the allowlist of the methods of the namespace, without the namespace prefix,
+	// e.g. "fileList" for sclient_fileList. Empty list allows all methods
+	Methods []string `toml:",omitempty"`
+
+	// RateLimit is the max number of calls of the namespace per second per connection. The
+	// http requests are limited per remote address. Zero means no limit
+	RateLimit float64 `toml:",omitempty"`
+
+	// Burst is the max number of calls made at once within the rate limit. Zero burst allows
+	// a single call at once
+	Burst int `toml:",omitempty"`
+}
+
+// connLimitersKey is the context key of the rate limiters of the connection
+type connLimitersKey struct{}
+
+// serverPolicy is the compiled namespace policies of the server
+type serverPolicy struct {
+	policies map[string]NamespacePolicy
+	methods  map[string]map[string]bool
+
+	// remotes is the rate limiters of the single shot connections keyed by the remote host
+	remotes map[string]*connLimiters
+	mu      sync.Mutex
+}
+
+// connLimiters is the rate limiters of the namespaces of a connection
+type connLimiters struct {
+	buckets  map[string]*tokenBucket
+	lastUsed time.Time
+	mu       sync.Mutex
+}
+
+// tokenBucket is the token bucket rate limiter
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// SetPolicies sets the policies of the namespaces served, keyed by the namespace. The
+// namespaces without a policy are not restricted. It shall be called before serving
+func (s *Server) SetPolicies(policies map[string]NamespacePolicy) {
+	if len(policies) == 0 {
+		s.policy = nil
+		return
+	}
+	policy := &serverPolicy{
+		policies: make(map[string]NamespacePolicy),
+		methods:  make(map[string]map[string]bool),
+		remotes:  make(map[string]*connLimiters),
+	}
+	for namespace, p := range policies {
+		policy.policies[namespace] = p
+		if len(p.Methods) == 0 {
+			continue
+		}
+		allowed := make(map[string]bool)
+		for _, method := range p.Methods {
+			allowed[method] = true
+		}
+		policy.methods[namespace] = allowed
+	}
+	s.policy = policy
+}
+
+// allowMethod checks whether the method is in the allowlist of the namespace
+func (p *serverPolicy) allowMethod(service, method string) Error {
+	if p == nil {
+		return nil
+	}
+	allowed, exist := p.methods[service]
+	if exist && !allowed[method] {
+		return &methodNotAllowedError{service, method}
+	}
+	return nil
+}
+
+// allowCall consumes a call of the namespace from the rate limiter of the connection
+func (p *serverPolicy) allowCall(ctx context.Context, service string) Error {
+	if p == nil {
+		return nil
+	}
+	policy, exist := p.policies[service]
+	if !exist || policy.RateLimit <= 0 {
+		return nil
+	}
+	limiters := p.connLimiters(ctx)
+	if limiters == nil {
+		return nil
+	}
+	if !limiters.allow(service, policy, time.Now()) {
+		return &rateLimitedError{service}
+	}
+	return nil
+}
+
+// connLimiters returns the rate limiters of the connection. The limiters of the multi shot
+// connections are stored in the context, and the limiters of the single shot connections
+// are shared by the requests from the same remote host
+func (p *serverPolicy) connLimiters(ctx context.Context) *connLimiters {
+	if limiters, ok := ctx.Value(connLimitersKey{}).(*connLimiters); ok {
+		return limiters
+	}
+	remote, ok := ctx.Value("remote").(string)
+	if !ok {
+		return nil
+	}
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	limiters, exist := p.remotes[remote]
+	if !exist {
+		if len(p.remotes) >= maxRemoteLimiters {
+			p.pruneRemotes(time.Now())
+		}
+		limiters = newConnLimiters()
+		p.remotes[remote] = limiters
+	}
+	return limiters
+}
+
+// pruneRemotes removes the rate limiters of the remote hosts idle longer than
+// remoteLimiterIdle
+func (p *serverPolicy) pruneRemotes(now time.Time) {
+	for remote, limiters := range p.remotes {
+		limiters.mu.Lock()
+		idle := now.Sub(limiters.lastUsed) > remoteLimiterIdle
+		limiters.mu.Unlock()
+		if idle {
+			delete(p.remotes, remote)
+		}
+	}
+}
+
+func newConnLimiters() *connLimiters {
+	return &connLimiters{buckets: make(map[string]*tokenBucket)}
+}
+
+// allow consumes a token from the bucket of the namespace
+func (cl *connLimiters) allow(service string, policy NamespacePolicy, now time.Time) bool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	cl.lastUsed = now
+	bucket, exist := cl.buckets[service]
+	if !exist {
+		burst := float64(policy.Burst)
+		if burst < 1 {
+			burst = 1
+		}
+		bucket = &tokenBucket{rate: policy.RateLimit, burst: burst, tokens: burst, last: now}
+		cl.buckets[service] = bucket
+	}
+	return bucket.take(now)
+}
+
+// take refills the bucket by the time elapsed and takes a token from the bucket
+func (b *tokenBucket) take(now time.Time) bool {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestPolicyMethodAllowlist test the methods not in the allowlist of the namespace are
+// rejected, while the other namespaces are not restricted
+func TestPolicyMethodAllowlist(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	defer server.Stop()
+	if err := server.RegisterName("other", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	server.SetPolicies(map[string]NamespacePolicy{
+		"service": {Methods: []string{"echo"}},
+	})
+	client := DialInProc(server)
+	defer client.Close()
+
+	var resp Result
+	if err := client.Call(&resp, "service_echo", "hello", 10, &Args{"world"}); err != nil {
+		t.Fatalf("allowed method rejected: %v", err)
+	}
+	var str string
+	if err := client.Call(&str, "service_rets"); err == nil {
+		t.Fatal("method not in the allowlist is called")
+	}
+	if err := client.Call(&str, "other_rets"); err != nil {
+		t.Fatalf("method of the namespace without policy rejected: %v", err)
+	}
+}
+
+// TestPolicyRateLimit test the calls of the namespace exceeding the burst are rejected
+func TestPolicyRateLimit(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	defer server.Stop()
+	server.SetPolicies(map[string]NamespacePolicy{
+		"service": {RateLimit: 0.001, Burst: 3},
+	})
+	client := DialInProc(server)
+	defer client.Close()
+
+	var resp Result
+	for i := 0; i < 3; i++ {
+		if err := client.Call(&resp, "service_echo", "hello", 10, &Args{"world"}); err != nil {
+			t.Fatalf("call %d within the burst rejected: %v", i, err)
+		}
+	}
+	err := client.Call(&resp, "service_echo", "hello", 10, &Args{"world"})
+	if err == nil {
+		t.Fatal("call exceeding the rate limit is not rejected")
+	}
+	if ec, ok := err.(Error); !ok || ec.ErrorCode() != (&rateLimitedError{}).ErrorCode() {
+		t.Errorf("expect rate limited error, got %v", err)
+	}
+
+	// a new connection has its own rate limit
+	client2 := DialInProc(server)
+	defer client2.Close()
+	if err := client2.Call(&resp, "service_echo", "hello", 10, &Args{"world"}); err != nil {
+		t.Fatalf("call of another connection rejected: %v", err)
+	}
+}
+
+// TestPolicyRemoteLimiters test the single shot requests from the same remote host share the
+// rate limiter
+func TestPolicyRemoteLimiters(t *testing.T) {
+	server := NewServer()
+	server.SetPolicies(map[string]NamespacePolicy{
+		"service": {RateLimit: 0.001},
+	})
+	ctx1 := context.WithValue(context.Background(), "remote", "10.0.0.1:30001")
+	ctx2 := context.WithValue(context.Background(), "remote", "10.0.0.1:30002")
+	ctx3 := context.WithValue(context.Background(), "remote", "10.0.0.2:30001")
+
+	if err := server.policy.allowCall(ctx1, "service"); err != nil {
+		t.Fatalf("first call rejected: %v", err)
+	}
+	if err := server.policy.allowCall(ctx2, "service"); err == nil {
+		t.Error("call from the same host exceeding the rate limit is not rejected")
+	}
+	if err := server.policy.allowCall(ctx3, "service"); err != nil {
+		t.Errorf("call from another host rejected: %v", err)
+	}
+	if err := server.policy.allowCall(ctx1, "other"); err != nil {
+		t.Errorf("call of the namespace without policy rejected: %v", err)
+	}
+}
+
+// TestTokenBucket test the tokens are refilled by the rate and capped by the burst
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	bucket := &tokenBucket{rate: 2, burst: 2, tokens: 2, last: now}
+
+	if !bucket.take(now) || !bucket.take(now) {
+		t.Fatal("tokens within the burst not taken")
+	}
+	if bucket.take(now) {
+		t.Fatal("token taken from the empty bucket")
+	}
+	if !bucket.take(now.Add(500 * time.Millisecond)) {
+		t.Fatal("token not refilled by the rate")
+	}
+	now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		if !bucket.take(now) {
+			t.Fatalf("token %d not refilled", i)
+		}
+	}
+	if bucket.take(now) {
+		t.Fatal("tokens refilled exceed the burst")
+	}
+}
@@ -228,6 +228,11 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 		ctx = context.WithValue(ctx, notifierKey{}, newNotifier(codec))
 	}
 
+	// the calls of the multi shot connection share the rate limiters of the connection
+	if s.policy != nil && !singleShot {
+		ctx = context.WithValue(ctx, connLimitersKey{}, newConnLimiters())
+	}
+
 	s.codecsMu.Lock()
 	if atomic.LoadInt32(&s.run) != 1 { // server stopped
 		s.codecsMu.Unlock()
@@ -356,6 +361,11 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, req.err), nil
 	}
 
+	// calls exceeding the rate limit of the service are rejected
+	if err := s.policy.allowCall(ctx, req.svcname); err != nil {
+		return codec.CreateErrorResponse(&req.id, err), nil
+	}
+
 	// if the request is for canceling subscription
 	if req.isUnsubscribe { // cancel subscription, first param must be the subscription id
 		if len(req.args) >= 1 && req.args[0].Kind() == reflect.String {
@@ -444,6 +454,10 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.callb.errPos >= 0 {
 		if !reply[req.callb.errPos].IsNil() {
 			e := reply[req.callb.errPos].Interface().(error)
+			// keep the error code if the error returned by the function carries one
+			if rpcErr, ok := e.(Error); ok {
+				return codec.CreateErrorResponse(&req.id, rpcErr), nil
+			}
 			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
 			return res, nil
 		}
@@ -552,6 +566,12 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 			continue
 		}
 
+		// check if the method is in the allowlist of the service
+		if err := s.policy.allowMethod(r.service, r.method); err != nil {
+			requests[i] = &serverRequest{id: r.id, err: err}
+			continue
+		}
+
 		// if the request is PubSub
 		// get id, svcname, callb (where callb stores in the service subscriptions field)
 		if r.isPubSub { // eth_subscribe, r.method contains the subscription method name
@@ -78,6 +78,8 @@ type Server struct {
 	run      int32 // if 1, indicates the server is running
 	codecsMu sync.Mutex
 	codecs   mapset.Set // unordered and unique
+
+	policy *serverPolicy // restrictions of the namespaces, nil if not restricted
 }
 
 // rpcRequest represents a raw incoming RPC request
@@ -0,0 +1,174 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package coinchargemaintenance
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+const (
+	// contractRecordVersion1 is the version of the rlp encoded contractRecord
+	contractRecordVersion1 = 1
+
+	// maxContractRecordSlots is the max number of the storage slots the encoded record is
+	// stored in, which is far more than the record of the current version takes
+	maxContractRecordSlots = 32
+)
+
+var errContractRecordTooLarge = errors.New("storage contract record too large")
+
+// contractRecord is all fields of the storage contract stored as a single record in the
+// contract account, instead of a storage slot per field
+type contractRecord struct {
+	ClientAddress           common.Address
+	HostAddress             common.Address
+	ClientCollateral        *big.Int
+	HostCollateral          *big.Int
+	FileSize                uint64
+	UnlockHash              common.Hash
+	FileMerkleRoot          common.Hash
+	RevisionNumber          uint64
+	WindowStart             uint64
+	WindowEnd               uint64
+	ClientValidProofOutput  *big.Int
+	HostValidProofOutput    *big.Int
+	ClientMissedProofOutput *big.Int
+	HostMissedProofOutput   *big.Int
+}
+
+// contractRecordHeader is the content of the KeyContractRecord storage slot, which is the
+// version and the size of the encoded record. The zero header means no record is stored
+type contractRecordHeader struct {
+	version byte
+	size    uint64
+}
+
+// encode encodes the header into the storage slot, with the version in the first byte and
+// the size in the last 8 bytes
+func (header contractRecordHeader) encode() common.Hash {
+	var slot common.Hash
+	slot[0] = header.version
+	binary.BigEndian.PutUint64(slot[common.HashLength-8:], header.size)
+	return slot
+}
+
+// decodeContractRecordHeader decodes the header from the storage slot
+func decodeContractRecordHeader(slot common.Hash) contractRecordHeader {
+	return contractRecordHeader{
+		version: slot[0],
+		size:    binary.BigEndian.Uint64(slot[common.HashLength-8:]),
+	}
+}
+
+// slots returns the number of the storage slots the encoded record is stored in
+func (header contractRecordHeader) slots() uint64 {
+	n := header.size / common.HashLength
+	if header.size%common.HashLength != 0 {
+		n++
+	}
+	return n
+}
+
+// contractRecordSlotKey returns the key of the i-th storage slot the encoded record is stored
+// in, which follows the KeyContractRecord slot
+func contractRecordSlotKey(i int) common.Hash {
+	return common.BigToHash(new(big.Int).Add(KeyContractRecord.Big(), big.NewInt(int64(i+1))))
+}
+
+// encodeContractRecord encodes the contract record of the latest version into the header and
+// the content split into the storage slots
+func encodeContractRecord(rec *contractRecord) (contractRecordHeader, []common.Hash, error) {
+	data, err := rlp.EncodeToBytes(rec)
+	if err != nil {
+		return contractRecordHeader{}, nil, err
+	}
+	header := contractRecordHeader{version: contractRecordVersion1, size: uint64(len(data))}
+	if header.slots() > maxContractRecordSlots {
+		return contractRecordHeader{}, nil, errContractRecordTooLarge
+	}
+	slots := make([]common.Hash, header.slots())
+	for i := range slots {
+		copy(slots[i][:], data[i*common.HashLength:])
+	}
+	return header, slots, nil
+}
+
+// decodeContractRecord decodes the contract record of the version it is encoded with, from
+// the content read from the storage slots
+func decodeContractRecord(header contractRecordHeader, slots []common.Hash) (*contractRecord, error) {
+	if header.slots() > maxContractRecordSlots || header.slots() != uint64(len(slots)) {
+		return nil, fmt.Errorf("invalid contract record size %d", header.size)
+	}
+	switch header.version {
+	case contractRecordVersion1:
+		data := make([]byte, 0, len(slots)*common.HashLength)
+		for _, slot := range slots {
+			data = append(data, slot[:]...)
+		}
+		var rec contractRecord
+		if err := rlp.DecodeBytes(data[:header.size], &rec); err != nil {
+			return nil, err
+		}
+		return &rec, nil
+	default:
+		return nil, fmt.Errorf("unknown contract record version %d", header.version)
+	}
+}
+
+// newContractRecord creates the contract record of the storage contract
+func newContractRecord(sc types.StorageContract) *contractRecord {
+	return &contractRecord{
+		ClientAddress:           sc.ClientCollateral.Address,
+		HostAddress:             sc.HostCollateral.Address,
+		ClientCollateral:        sc.ClientCollateral.Value,
+		HostCollateral:          sc.HostCollateral.Value,
+		FileSize:                sc.FileSize,
+		UnlockHash:              sc.UnlockHash,
+		FileMerkleRoot:          sc.FileMerkleRoot,
+		RevisionNumber:          sc.RevisionNumber,
+		WindowStart:             sc.WindowStart,
+		WindowEnd:               sc.WindowEnd,
+		ClientValidProofOutput:  sc.ValidProofOutputs[0].Value,
+		HostValidProofOutput:    sc.ValidProofOutputs[1].Value,
+		ClientMissedProofOutput: sc.MissedProofOutputs[0].Value,
+		HostMissedProofOutput:   sc.MissedProofOutputs[1].Value,
+	}
+}
+
+// storageContract returns the storage contract of the record, the same as the one read from
+// the storage slots
+func (rec *contractRecord) storageContract() types.StorageContract {
+	return types.StorageContract{
+		FileSize:       rec.FileSize,
+		FileMerkleRoot: rec.FileMerkleRoot,
+		WindowStart:    rec.WindowStart,
+		WindowEnd:      rec.WindowEnd,
+		ClientCollateral: types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{
+			Address: rec.ClientAddress,
+			Value:   rec.ClientCollateral,
+		}},
+		HostCollateral: types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{
+			Address: rec.HostAddress,
+			Value:   rec.HostCollateral,
+		}},
+		ValidProofOutputs: []types.DxcoinCharge{
+			{Address: rec.ClientAddress, Value: rec.ClientValidProofOutput},
+			{Address: rec.HostAddress, Value: rec.HostValidProofOutput},
+		},
+		MissedProofOutputs: []types.DxcoinCharge{
+			{Address: rec.ClientAddress, Value: rec.ClientMissedProofOutput},
+			{Address: rec.HostAddress, Value: rec.HostMissedProofOutput},
+		},
+		UnlockHash:     rec.UnlockHash,
+		RevisionNumber: rec.RevisionNumber,
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package coinchargemaintenance
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+)
+
+// ContractStateDB is the state access needed to read and write the storage contract fields.
+// Both state.StateDB and vm.StateDB satisfy the interface
+type ContractStateDB interface {
+	Exist(common.Address) bool
+	GetState(common.Address, common.Hash) common.Hash
+	SetState(common.Address, common.Hash, common.Hash)
+}
+
+// StorageContractState is the typed accessor of the storage contract fields stored
+// in the contract account, which hides the layout of the fields from the caller.
+//
+// The fields are stored either in a storage slot per field, or as a single record under the
+// KeyContractRecord storage slot. The fields are read from whichever layout the contract is
+// stored in. With the record enabled, the contracts are written as the record, and the contracts
+// stored in the storage slots are migrated to the record on the first write
+type StorageContractState struct {
+	db     ContractStateDB
+	record bool
+}
+
+// NewStorageContractState create a StorageContractState on the given state
+func NewStorageContractState(db ContractStateDB) *StorageContractState {
+	return &StorageContractState{db: db}
+}
+
+// WithRecord set whether the storage contracts are written as the single record, which is
+// enabled since the contract record fork
+func (scs *StorageContractState) WithRecord(record bool) *StorageContractState {
+	scs.record = record
+	return scs
+}
+
+// ContractAddress return the account address of the storage contract with the given id
+func ContractAddress(scID common.Hash) common.Address {
+	return common.BytesToAddress(scID[12:])
+}
+
+// ExpiredStatusAddress return the address of the status account, which records the proof
+// status of the storage contracts expiring at the given height
+func ExpiredStatusAddress(windowEnd uint64) common.Address {
+	return common.BytesToAddress([]byte(StrPrefixExpSC + strconv.FormatUint(windowEnd, 10)))
+}
+
+// Proofed return whether the storage proof of the storage contract has been submitted
+func (scs *StorageContractState) Proofed(scID common.Hash, windowEnd uint64) bool {
+	status := scs.db.GetState(ExpiredStatusAddress(windowEnd), scID)
+	return bytes.Equal(status.Bytes()[11:12], ProofedStatus)
+}
+
+// GetContract return the storage contract stored in the contract account. The addresses of
+// the proof outputs are filled with the client and host address, and the signatures
+// are not stored in the state
+func (scs *StorageContractState) GetContract(contractAddr common.Address) (types.StorageContract, error) {
+	if !scs.db.Exist(contractAddr) {
+		return types.StorageContract{}, ErrContractNotExist
+	}
+	rec, err := scs.contract(contractAddr)
+	if err != nil {
+		return types.StorageContract{}, err
+	}
+	return rec.storageContract(), nil
+}
+
+// SetContract store all fields of the storage contract into the contract account
+func (scs *StorageContractState) SetContract(contractAddr common.Address, sc types.StorageContract) error {
+	return scs.update(contractAddr, func(rec *contractRecord) { *rec = *newContractRecord(sc) })
+}
+
+// ApplyRevision update the fields of the storage contract changed by the revision
+func (scs *StorageContractState) ApplyRevision(contractAddr common.Address, scr types.StorageContractRevision) error {
+	return scs.update(contractAddr, func(rec *contractRecord) {
+		rec.FileSize = scr.NewFileSize
+		rec.FileMerkleRoot = scr.NewFileMerkleRoot
+		rec.RevisionNumber = scr.NewRevisionNumber
+		rec.ClientValidProofOutput = scr.NewValidProofOutputs[0].Value
+		rec.HostValidProofOutput = scr.NewValidProofOutputs[1].Value
+		rec.ClientMissedProofOutput = scr.NewMissedProofOutputs[0].Value
+		rec.HostMissedProofOutput = scr.NewMissedProofOutputs[1].Value
+	})
+}
+
+// DeleteRecord delete the record of the finished storage contract, so that no storage is
+// left in the contract account deleted as the empty account
+func (scs *StorageContractState) DeleteRecord(contractAddr common.Address) {
+	header := decodeContractRecordHeader(scs.db.GetState(contractAddr, KeyContractRecord))
+	if header.version == 0 {
+		return
+	}
+	if header.slots() <= maxContractRecordSlots {
+		scs.clearRecordSlots(contractAddr, 0, int(header.slots()))
+	}
+	scs.db.SetState(contractAddr, KeyContractRecord, common.Hash{})
+}
+
+// Migrate migrate the storage contract stored in the storage slots to the record, and clear the
+// storage slots. It returns false if the record is not enabled or the contract does not exist
+func (scs *StorageContractState) Migrate(contractAddr common.Address) (bool, error) {
+	if !scs.db.Exist(contractAddr) {
+		return false, nil
+	}
+	_, stored, err := scs.getRecord(contractAddr)
+	if err != nil || (!stored && !scs.record) {
+		return false, err
+	}
+	return true, scs.update(contractAddr, func(*contractRecord) {})
+}
+
+// contract return all fields of the storage contract, read from whichever layout the contract
+// is stored in. The fields of the contract not stored are all zero
+func (scs *StorageContractState) contract(contractAddr common.Address) (*contractRecord, error) {
+	rec, stored, err := scs.getRecord(contractAddr)
+	if err != nil {
+		return nil, err
+	}
+	if stored {
+		return rec, nil
+	}
+	return scs.getSlots(contractAddr), nil
+}
+
+// update update the fields of the storage contract with fn. The contract is written as the
+// record if it is already stored as the record or the record is enabled, in which case the
+// contract stored in the storage slots is migrated to the record
+func (scs *StorageContractState) update(contractAddr common.Address, fn func(rec *contractRecord)) error {
+	rec, stored, err := scs.getRecord(contractAddr)
+	if err != nil {
+		return err
+	}
+	if !stored {
+		rec = scs.getSlots(contractAddr)
+	}
+	fn(rec)
+	if !stored && !scs.record {
+		scs.setSlots(contractAddr, rec)
+		return nil
+	}
+	if err := scs.setRecord(contractAddr, rec); err != nil {
+		return err
+	}
+	if !stored {
+		scs.clearSlots(contractAddr)
+	}
+	return nil
+}
+
+// getRecord return the record of the storage contract, and false if the contract is not stored
+// as the record. The error is returned if the record could not be decoded
+func (scs *StorageContractState) getRecord(contractAddr common.Address) (*contractRecord, bool, error) {
+	header := decodeContractRecordHeader(scs.db.GetState(contractAddr, KeyContractRecord))
+	if header.version == 0 {
+		return nil, false, nil
+	}
+	if header.slots() > maxContractRecordSlots {
+		return nil, true, fmt.Errorf("storage contract %v: invalid record size %d", contractAddr.String(), header.size)
+	}
+	slots := make([]common.Hash, header.slots())
+	for i := range slots {
+		slots[i] = scs.db.GetState(contractAddr, contractRecordSlotKey(i))
+	}
+	rec, err := decodeContractRecord(header, slots)
+	if err != nil {
+		return nil, true, fmt.Errorf("storage contract %v: %v", contractAddr.String(), err)
+	}
+	return rec, true, nil
+}
+
+// setRecord write the record of the storage contract, and clear the slots left by the previous
+// record which is larger
+func (scs *StorageContractState) setRecord(contractAddr common.Address, rec *contractRecord) error {
+	header, slots, err := encodeContractRecord(rec)
+	if err != nil {
+		return err
+	}
+	prev := decodeContractRecordHeader(scs.db.GetState(contractAddr, KeyContractRecord))
+	for i, slot := range slots {
+		scs.db.SetState(contractAddr, contractRecordSlotKey(i), slot)
+	}
+	if prev.slots() <= maxContractRecordSlots {
+		scs.clearRecordSlots(contractAddr, len(slots), int(prev.slots()))
+	}
+	scs.db.SetState(contractAddr, KeyContractRecord, header.encode())
+	return nil
+}
+
+// clearRecordSlots clear the storage slots of the record from start to end
+func (scs *StorageContractState) clearRecordSlots(contractAddr common.Address, start, end int) {
+	for i := start; i < end; i++ {
+		scs.db.SetState(contractAddr, contractRecordSlotKey(i), common.Hash{})
+	}
+}
+
+// getSlots read the fields of the storage contract from the storage slots as the record
+func (scs *StorageContractState) getSlots(contractAddr common.Address) *contractRecord {
+	return &contractRecord{
+		ClientAddress:           scs.getAddress(contractAddr, KeyClientAddress),
+		HostAddress:             scs.getAddress(contractAddr, KeyHostAddress),
+		ClientCollateral:        scs.getBig(contractAddr, KeyClientCollateral),
+		HostCollateral:          scs.getBig(contractAddr, KeyHostCollateral),
+		FileSize:                scs.getUint64(contractAddr, KeyFileSize),
+		UnlockHash:              scs.db.GetState(contractAddr, KeyUnlockHash),
+		FileMerkleRoot:          scs.db.GetState(contractAddr, KeyFileMerkleRoot),
+		RevisionNumber:          scs.getUint64(contractAddr, KeyRevisionNumber),
+		WindowStart:             scs.getUint64(contractAddr, KeyWindowStart),
+		WindowEnd:               scs.getUint64(contractAddr, KeyWindowEnd),
+		ClientValidProofOutput:  scs.getBig(contractAddr, KeyClientValidProofOutput),
+		HostValidProofOutput:    scs.getBig(contractAddr, KeyHostValidProofOutput),
+		ClientMissedProofOutput: scs.getBig(contractAddr, KeyClientMissedProofOutput),
+		HostMissedProofOutput:   scs.getBig(contractAddr, KeyHostMissedProofOutput),
+	}
+}
+
+// setSlots write the fields of the storage contract into the storage slots
+func (scs *StorageContractState) setSlots(contractAddr common.Address, rec *contractRecord) {
+	scs.setAddress(contractAddr, KeyClientAddress, rec.ClientAddress)
+	scs.setAddress(contractAddr, KeyHostAddress, rec.HostAddress)
+	scs.setBig(contractAddr, KeyClientCollateral, rec.ClientCollateral)
+	scs.setBig(contractAddr, KeyHostCollateral, rec.HostCollateral)
+	scs.setUint64(contractAddr, KeyFileSize, rec.FileSize)
+	scs.db.SetState(contractAddr, KeyUnlockHash, rec.UnlockHash)
+	scs.db.SetState(contractAddr, KeyFileMerkleRoot, rec.FileMerkleRoot)
+	scs.setUint64(contractAddr, KeyRevisionNumber, rec.RevisionNumber)
+	scs.setUint64(contractAddr, KeyWindowStart, rec.WindowStart)
+	scs.setUint64(contractAddr, KeyWindowEnd, rec.WindowEnd)
+	scs.setBig(contractAddr, KeyClientValidProofOutput, rec.ClientValidProofOutput)
+	scs.setBig(contractAddr, KeyHostValidProofOutput, rec.HostValidProofOutput)
+	scs.setBig(contractAddr, KeyClientMissedProofOutput, rec.ClientMissedProofOutput)
+	scs.setBig(contractAddr, KeyHostMissedProofOutput, rec.HostMissedProofOutput)
+}
+
+// clearSlots clear the storage slots of the fields of the storage contract
+func (scs *StorageContractState) clearSlots(contractAddr common.Address) {
+	for _, key := range contractFieldKeys {
+		if scs.db.GetState(contractAddr, key) != (common.Hash{}) {
+			scs.db.SetState(contractAddr, key, common.Hash{})
+		}
+	}
+}
+
+func (scs *StorageContractState) getAddress(contractAddr common.Address, key common.Hash) common.Address {
+	return common.BytesToAddress(scs.db.GetState(contractAddr, key).Bytes())
+}
+
+func (scs *StorageContractState) setAddress(contractAddr common.Address, key common.Hash, addr common.Address) {
+	scs.db.SetState(contractAddr, key, common.BytesToHash(addr.Bytes()))
+}
+
+func (scs *StorageContractState) getBig(contractAddr common.Address, key common.Hash) *big.Int {
+	return new(big.Int).SetBytes(scs.db.GetState(contractAddr, key).Bytes())
+}
+
+func (scs *StorageContractState) setBig(contractAddr common.Address, key common.Hash, value *big.Int) {
+	scs.db.SetState(contractAddr, key, common.BytesToHash(value.Bytes()))
+}
+
+func (scs *StorageContractState) getUint64(contractAddr common.Address, key common.Hash) uint64 {
+	return new(big.Int).SetBytes(scs.db.GetState(contractAddr, key).Bytes()).Uint64()
+}
+
+func (scs *StorageContractState) setUint64(contractAddr common.Address, key common.Hash, value uint64) {
+	var buf = make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, value)
+	scs.db.SetState(contractAddr, key, common.BytesToHash(buf))
+}
@@ -0,0 +1,258 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package coinchargemaintenance
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/ethdb"
+)
+
+func TestStorageContractState(t *testing.T) {
+	prvAndAddresses, err := mockClientAndHostAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientAddress := prvAndAddresses[0].Address
+	hostAddress := prvAndAddresses[1].Address
+
+	stateDB := mockState(ethdb.NewMemDatabase(), mockAccountAlloc([]common.Address{clientAddress, hostAddress}))
+	scs := NewStorageContractState(stateDB)
+
+	contractAddr := ContractAddress(common.HexToHash("0x5e109495581395e5d86c377efb05c2aef6ab6f2046f1bd7336e1ab1bfd96b6ed"))
+	if _, err := scs.GetContract(contractAddr); err != ErrContractNotExist {
+		t.Fatalf("expect error %v, got %v", ErrContractNotExist, err)
+	}
+
+	sc := types.StorageContract{
+		FileSize:       2048,
+		FileMerkleRoot: common.HexToHash("0x01"),
+		WindowStart:    100,
+		WindowEnd:      200,
+		ClientCollateral: types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{
+			Address: clientAddress,
+			Value:   big.NewInt(1000),
+		}},
+		HostCollateral: types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{
+			Address: hostAddress,
+			Value:   big.NewInt(500),
+		}},
+		ValidProofOutputs: []types.DxcoinCharge{
+			{Address: clientAddress, Value: big.NewInt(1000)},
+			{Address: hostAddress, Value: big.NewInt(500)},
+		},
+		MissedProofOutputs: []types.DxcoinCharge{
+			{Address: clientAddress, Value: big.NewInt(1000)},
+			{Address: hostAddress, Value: big.NewInt(400)},
+		},
+		UnlockHash:     common.HexToHash("0x02"),
+		RevisionNumber: 1,
+	}
+	stateDB.CreateAccount(contractAddr)
+	stateDB.SetNonce(contractAddr, 1)
+	if err := scs.SetContract(contractAddr, sc); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := scs.GetContract(contractAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, sc) {
+		t.Fatalf("storage contract not expected.\n\tGot %+v\n\tExpect %+v", got, sc)
+	}
+
+	// apply a revision which moves 100 from the client to the host
+	scr := types.StorageContractRevision{
+		NewRevisionNumber: 2,
+		NewFileSize:       4096,
+		NewFileMerkleRoot: common.HexToHash("0x03"),
+		NewValidProofOutputs: []types.DxcoinCharge{
+			{Address: clientAddress, Value: big.NewInt(900)},
+			{Address: hostAddress, Value: big.NewInt(600)},
+		},
+		NewMissedProofOutputs: []types.DxcoinCharge{
+			{Address: clientAddress, Value: big.NewInt(900)},
+			{Address: hostAddress, Value: big.NewInt(400)},
+		},
+	}
+	if err := scs.ApplyRevision(contractAddr, scr); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err = scs.GetContract(contractAddr); err != nil {
+		t.Fatal(err)
+	}
+	if got.RevisionNumber != scr.NewRevisionNumber {
+		t.Errorf("revision number not expected. Got %v, Expect %v", got.RevisionNumber, scr.NewRevisionNumber)
+	}
+	if got.FileSize != scr.NewFileSize {
+		t.Errorf("file size not expected. Got %v, Expect %v", got.FileSize, scr.NewFileSize)
+	}
+	if got.FileMerkleRoot != scr.NewFileMerkleRoot {
+		t.Errorf("file merkle root not expected. Got %v, Expect %v", got.FileMerkleRoot, scr.NewFileMerkleRoot)
+	}
+	if got.ValidProofOutputs[1].Value.Cmp(big.NewInt(600)) != 0 {
+		t.Errorf("host valid proof output not expected. Got %v, Expect %v", got.ValidProofOutputs[1].Value, 600)
+	}
+	if got.MissedProofOutputs[0].Value.Cmp(big.NewInt(900)) != 0 {
+		t.Errorf("client missed proof output not expected. Got %v, Expect %v", got.MissedProofOutputs[0].Value, 900)
+	}
+	if got.WindowEnd != sc.WindowEnd {
+		t.Errorf("window end not expected. Got %v, Expect %v", got.WindowEnd, sc.WindowEnd)
+	}
+}
+
+// TestStorageContractRecord test the storage contract is written as the single record with the
+// record enabled, and the contract stored in the storage slots is migrated on the first write
+func TestStorageContractRecord(t *testing.T) {
+	prvAndAddresses, err := mockClientAndHostAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientAddress := prvAndAddresses[0].Address
+	hostAddress := prvAndAddresses[1].Address
+	stateDB := mockState(ethdb.NewMemDatabase(), mockAccountAlloc([]common.Address{clientAddress, hostAddress}))
+
+	sc := types.StorageContract{
+		FileSize:    2048,
+		WindowStart: 100,
+		WindowEnd:   200,
+		ClientCollateral: types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{
+			Address: clientAddress,
+			Value:   big.NewInt(1000),
+		}},
+		HostCollateral: types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{
+			Address: hostAddress,
+			Value:   big.NewInt(500),
+		}},
+		ValidProofOutputs: []types.DxcoinCharge{
+			{Address: clientAddress, Value: big.NewInt(1000)},
+			{Address: hostAddress, Value: big.NewInt(500)},
+		},
+		MissedProofOutputs: []types.DxcoinCharge{
+			{Address: clientAddress, Value: big.NewInt(1000)},
+			{Address: hostAddress, Value: big.NewInt(400)},
+		},
+		UnlockHash:     common.HexToHash("0x02"),
+		RevisionNumber: 1,
+	}
+	scr := types.StorageContractRevision{
+		NewRevisionNumber: 2,
+		NewFileSize:       4096,
+		NewFileMerkleRoot: common.HexToHash("0x03"),
+		NewValidProofOutputs: []types.DxcoinCharge{
+			{Address: clientAddress, Value: big.NewInt(900)},
+			{Address: hostAddress, Value: big.NewInt(600)},
+		},
+		NewMissedProofOutputs: []types.DxcoinCharge{
+			{Address: clientAddress, Value: big.NewInt(900)},
+			{Address: hostAddress, Value: big.NewInt(400)},
+		},
+	}
+	revised := sc
+	revised.FileSize, revised.FileMerkleRoot, revised.RevisionNumber = scr.NewFileSize, scr.NewFileMerkleRoot, scr.NewRevisionNumber
+	revised.ValidProofOutputs, revised.MissedProofOutputs = scr.NewValidProofOutputs, scr.NewMissedProofOutputs
+
+	// the contract created with the record enabled
+	recordAddr := ContractAddress(common.HexToHash("0x01"))
+	stateDB.CreateAccount(recordAddr)
+	stateDB.SetNonce(recordAddr, 1)
+	if err := NewStorageContractState(stateDB).WithRecord(true).SetContract(recordAddr, sc); err != nil {
+		t.Fatal(err)
+	}
+
+	// the contract created in the storage slots, and migrated by the revision
+	legacyAddr := ContractAddress(common.HexToHash("0x02"))
+	stateDB.CreateAccount(legacyAddr)
+	stateDB.SetNonce(legacyAddr, 1)
+	if err := NewStorageContractState(stateDB).SetContract(legacyAddr, sc); err != nil {
+		t.Fatal(err)
+	}
+	if stateDB.GetState(legacyAddr, KeyContractRecord) != (common.Hash{}) {
+		t.Fatal("contract written as the record without the record enabled")
+	}
+
+	for _, addr := range []common.Address{recordAddr, legacyAddr} {
+		if err := NewStorageContractState(stateDB).WithRecord(true).ApplyRevision(addr, scr); err != nil {
+			t.Fatal(err)
+		}
+		if stateDB.GetState(addr, KeyContractRecord) == (common.Hash{}) {
+			t.Fatalf("contract %v not stored as the record", addr)
+		}
+		for _, key := range contractFieldKeys {
+			if stateDB.GetState(addr, key) != (common.Hash{}) {
+				t.Errorf("contract %v: storage slot %v not cleared", addr, key)
+			}
+		}
+		// the record is read regardless of the record enabled
+		got, err := NewStorageContractState(stateDB).GetContract(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, revised) {
+			t.Errorf("contract %v not expected.\n\tGot %+v\n\tExpect %+v", addr, got, revised)
+		}
+	}
+
+	// the record of the finished contract is deleted
+	NewStorageContractState(stateDB).DeleteRecord(recordAddr)
+	if err := stateDB.ForEachStorage(recordAddr, func(key, value common.Hash) bool {
+		if value != (common.Hash{}) {
+			t.Errorf("storage slot %v of the finished contract not deleted", key)
+		}
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the record could not be decoded is reported to the caller
+	stateDB.SetState(legacyAddr, KeyContractRecord, contractRecordHeader{version: contractRecordVersion1 + 1, size: 1}.encode())
+	if _, err := NewStorageContractState(stateDB).GetContract(legacyAddr); err == nil {
+		t.Error("record of unknown version decoded")
+	}
+	if err := NewStorageContractState(stateDB).ApplyRevision(legacyAddr, scr); err == nil {
+		t.Error("revision applied to the record of unknown version")
+	}
+}
+
+func TestContractRecordCodec(t *testing.T) {
+	rec := &contractRecord{
+		ClientAddress:           common.HexToAddress("0x01"),
+		ClientCollateral:        big.NewInt(1),
+		HostCollateral:          big.NewInt(2),
+		FileSize:                3,
+		WindowEnd:               4,
+		ClientValidProofOutput:  big.NewInt(5),
+		HostValidProofOutput:    big.NewInt(6),
+		ClientMissedProofOutput: big.NewInt(7),
+		HostMissedProofOutput:   big.NewInt(8),
+	}
+	header, slots, err := encodeContractRecord(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decodeContractRecordHeader(header.encode()) != header {
+		t.Fatalf("header not expected. Got %+v, Expect %+v", decodeContractRecordHeader(header.encode()), header)
+	}
+	decoded, err := decodeContractRecord(header, slots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, rec) {
+		t.Errorf("decoded record not expected.\n\tGot %+v\n\tExpect %+v", decoded, rec)
+	}
+
+	if _, err := decodeContractRecord(contractRecordHeader{version: contractRecordVersion1 + 1, size: header.size}, slots); err == nil {
+		t.Error("record of unknown version decoded")
+	}
+	if _, err := decodeContractRecord(contractRecordHeader{version: contractRecordVersion1, size: header.size + common.HashLength}, slots); err == nil {
+		t.Error("record with the size mismatched decoded")
+	}
+}
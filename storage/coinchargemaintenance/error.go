@@ -9,4 +9,7 @@ import "errors"
 var (
 	// ErrProgramExit indicates that the program will exit (Ctrl + c)
 	ErrProgramExit = errors.New("program exist")
+
+	// ErrContractNotExist indicates that the storage contract account does not exist in the state
+	ErrContractNotExist = errors.New("storage contract not exist")
 )
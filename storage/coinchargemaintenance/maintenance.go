@@ -65,30 +65,45 @@ var (
 
 	// KeyHostMissedProofOutput is the key to store host missed proof output into trie
 	KeyHostMissedProofOutput = common.BytesToHash([]byte("HostMissedProofOutput"))
+
+	// KeyContractRecord is the key to store the header of the storage contract record into
+	// trie, followed by the slots of the encoded record
+	KeyContractRecord = common.BytesToHash([]byte("ContractRecord"))
+
+	// contractFieldKeys is the keys of all storage contract fields stored in the storage slots
+	contractFieldKeys = []common.Hash{
+		KeyClientCollateral, KeyHostCollateral, KeyFileSize, KeyUnlockHash, KeyFileMerkleRoot,
+		KeyRevisionNumber, KeyWindowStart, KeyWindowEnd, KeyClientAddress, KeyHostAddress,
+		KeyClientValidProofOutput, KeyClientMissedProofOutput, KeyHostValidProofOutput,
+		KeyHostMissedProofOutput,
+	}
 )
 
-// MaintenanceMissedProof maintains missed storage proof
-func MaintenanceMissedProof(height uint64, state *state.StateDB) {
+// MaintenanceMissedProof maintains missed storage proof. The error is returned if the storage
+// contract could not be read from the state
+func MaintenanceMissedProof(height uint64, state *state.StateDB) error {
 	windowEndStr := strconv.FormatUint(height, 10)
 	statusAddr := common.BytesToAddress([]byte(StrPrefixExpSC + windowEndStr))
 
 	if state.Exist(statusAddr) {
+		var err error
+		scs := NewStorageContractState(state)
 		state.ForEachStorage(statusAddr, func(key, value common.Hash) bool {
 			flag := value.Bytes()[11:12]
 			if bytes.Equal(flag, NotProofedStatus) {
 				contractAddr := common.BytesToAddress(value[12:])
 
 				// retrieve storage contract filed data
-				clientAddressHash := state.GetState(contractAddr, KeyClientAddress)
-				hostAddressHash := state.GetState(contractAddr, KeyHostAddress)
-				clientMpoHash := state.GetState(contractAddr, KeyClientMissedProofOutput)
-				hostMpoHash := state.GetState(contractAddr, KeyHostMissedProofOutput)
+				var rec *contractRecord
+				if rec, err = scs.contract(contractAddr); err != nil {
+					return false
+				}
+				clientMpo := rec.ClientMissedProofOutput
+				hostMpo := rec.HostMissedProofOutput
 
 				// return back the remain amount to client and host
-				clientMpo := new(big.Int).SetBytes(clientMpoHash.Bytes())
-				hostMpo := new(big.Int).SetBytes(hostMpoHash.Bytes())
-				state.AddBalance(common.BytesToAddress(clientAddressHash.Bytes()), clientMpo)
-				state.AddBalance(common.BytesToAddress(hostAddressHash.Bytes()), hostMpo)
+				state.AddBalance(rec.ClientAddress, clientMpo)
+				state.AddBalance(rec.HostAddress, hostMpo)
 
 				// deduct the sum missed output from contract account
 				totalValue := new(big.Int).Add(clientMpo, hostMpo)
@@ -96,8 +111,12 @@ func MaintenanceMissedProof(height uint64, state *state.StateDB) {
 			}
 			return true
 		})
+		if err != nil {
+			return err
+		}
 
 		// mark the statusAddr as empty account, that will be deleted by stateDB
 		state.SetNonce(statusAddr, 0)
 	}
+	return nil
 }
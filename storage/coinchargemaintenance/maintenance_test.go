@@ -55,7 +55,9 @@ func TestMaintenanceMissedProof(t *testing.T) {
 	// mock write missed storage proof
 	contractAddr := mockMissedStorageProof(1000, stateDB, prvAndAddresses)
 
-	MaintenanceMissedProof(1000, stateDB)
+	if err := MaintenanceMissedProof(1000, stateDB); err != nil {
+		t.Fatal(err)
+	}
 
 	// check balance
 	afterContractBal := stateDB.GetBalance(contractAddr)
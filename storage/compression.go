@@ -0,0 +1,89 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/golang/snappy"
+)
+
+// maxDecompressedSize is the max size of the decompressed payload, which is larger than the
+// max protocol message size, so that any payload could be sent compressed or not
+const maxDecompressedSize = 16 * 1024 * 1024
+
+// CompressionConfig is the configuration of the snappy compression of the large negotiation
+// payloads. The compression is negotiated during the storage handshake, and is used on the
+// connection if either side of it enabled the compression
+type CompressionConfig struct {
+	// Enabled indicates whether the negotiation payloads are compressed
+	Enabled bool
+
+	// SectorData indicates whether the payloads carrying the sector data are compressed as
+	// well. The sector data uploaded is encrypted, which barely benefits from the compression
+	SectorData bool
+
+	// Threshold is the min size of the payload compressed in bytes
+	Threshold int
+}
+
+// DefaultCompressionConfig is the default compression config. The compression is disabled
+// unless requested by the peer, in which case the payloads larger than 1 KiB are compressed
+var DefaultCompressionConfig = CompressionConfig{
+	Enabled:    false,
+	SectorData: false,
+	Threshold:  1024,
+}
+
+// compressibleMsgs are the negotiation messages with the large payloads of the merkle proof
+// lists and the host config
+var compressibleMsgs = map[uint64]struct{}{
+	HostConfigRespMsg:            {},
+	HostConfigDeltaMsg:           {},
+	ContractUploadMerkleProofMsg: {},
+	ContractSectorStoredMsg:      {},
+}
+
+// sectorDataMsgs are the negotiation messages carrying the sector data
+var sectorDataMsgs = map[uint64]struct{}{
+	ContractUploadReqMsg:         {},
+	ContractDownloadDataMsg:      {},
+	ContractDownloadBatchDataMsg: {},
+}
+
+// Compressible returns whether the payload of the message with the size should be compressed
+func (c CompressionConfig) Compressible(msgCode uint64, size int) bool {
+	if !c.Enabled || size < c.Threshold {
+		return false
+	}
+	if _, exists := compressibleMsgs[msgCode]; exists {
+		return true
+	}
+	_, exists := sectorDataMsgs[msgCode]
+	return exists && c.SectorData
+}
+
+// CompressPayload compresses the rlp encoded payload. The compressed payload is wrapped as
+// the rlp string, so that it could still be carried as the rlp value
+func CompressPayload(payload []byte) ([]byte, error) {
+	return rlp.EncodeToBytes(snappy.Encode(nil, payload))
+}
+
+// DecompressPayload decompresses the payload compressed by CompressPayload
+func DecompressPayload(payload []byte) ([]byte, error) {
+	var compressed []byte
+	if err := rlp.DecodeBytes(payload, &compressed); err != nil {
+		return nil, fmt.Errorf("failed to decode the compressed payload: %s", err.Error())
+	}
+	size, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed payload: %s", err.Error())
+	}
+	if size > maxDecompressedSize {
+		return nil, fmt.Errorf("decompressed payload has %d bytes, exceeding the limit %d", size, maxDecompressedSize)
+	}
+	return snappy.Decode(nil, compressed)
+}
@@ -5,31 +5,43 @@
 package storage
 
 import (
-	"errors"
 	"math/big"
 	"time"
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/math"
 	"github.com/DxChainNetwork/godx/common/unit"
+	"github.com/DxChainNetwork/godx/storage/storageerr"
 )
 
 var (
 	// ErrHostBusyHandleReq defines that client sent the contract request too frequently. If this error is occurred
 	// the host's evaluation will not be deducted
-	ErrHostBusyHandleReq = errors.New("client must wait until the host finish its's previous request")
+	ErrHostBusyHandleReq = storageerr.New(storageerr.CodeHostBusy, "client must wait until the host finish its's previous request")
 
 	// ErrClientNegotiate defines that client occurs error while negotiate
-	ErrClientNegotiate = errors.New("client negotiate error")
+	ErrClientNegotiate = storageerr.New(storageerr.CodeNegotiationFailed, "client negotiate error")
 
 	// ErrClientCommit defines that client occurs error while commit(finalize)
-	ErrClientCommit = errors.New("client commit error")
+	ErrClientCommit = storageerr.New(storageerr.CodeCommitFailed, "client commit error")
 
 	// ErrHostNegotiate defines that client occurs error while negotiate
-	ErrHostNegotiate = errors.New("host negotiate error")
+	ErrHostNegotiate = storageerr.New(storageerr.CodeNegotiationFailed, "host negotiate error")
 
 	// ErrHostCommit defines that host occurs error while commit(finalize)
-	ErrHostCommit = errors.New("host commit error")
+	ErrHostCommit = storageerr.New(storageerr.CodeCommitFailed, "host commit error")
+
+	// ErrHostConfigChanged defines that the host config used by client in negotiation is outdated.
+	// The client should refresh the host config before the next negotiation
+	ErrHostConfigChanged = storageerr.New(storageerr.CodeHostConfigChanged, "host config changed")
+
+	// ErrHostFull defines that the host is running out of the free space, and rejects the data
+	// appended. The host's evaluation will not be deducted
+	ErrHostFull = storageerr.New(storageerr.CodeHostFull, "host is full")
+
+	// ErrContractRevisionUnsupported defines that the host does not support the request of its
+	// latest contract revision, which is supported from the session tagged protocol
+	ErrContractRevisionUnsupported = storageerr.New(storageerr.CodeNegotiationFailed, "host does not support the contract revision request")
 )
 
 // Negotiation related messages
@@ -45,6 +57,15 @@ const (
 	HostCommitFailedMsg          = 0x27
 	HostAckMsg                   = 0x28
 	HostNegotiateErrorMsg        = 0x29
+	HostConfigChangedMsg         = 0x2a
+	ContractSectorStoredMsg      = 0x2b
+	HostFullMsg                  = 0x2c
+	HostConfigUpdateMsg          = 0x2d
+	HostConfigDeltaMsg           = 0x2e
+	ContractDownloadBatchDataMsg = 0x2f
+
+	// Client Handle Message Set, continued after the host handle message set
+	ContractRevisionMsg = 0x40
 
 	// Host Handle Message Set
 	HostConfigReqMsg                 = 0x30
@@ -57,6 +78,10 @@ const (
 	ClientCommitFailedMsg            = 0x37
 	ClientAckMsg                     = 0x38
 	ClientNegotiateErrorMsg          = 0x39
+	PublicReadReqMsg                 = 0x3a
+	ContractSectorCheckReqMsg        = 0x3b
+	ContractDownloadBatchReqMsg      = 0x3c
+	ContractRevisionReqMsg           = 0x3d
 )
 
 const (
@@ -91,6 +116,17 @@ var (
 	DefaultMaxDuration          = unit.BlocksPerDay * 30 // 30 days
 	DefaultMaxDownloadBatchSize = 17 * (1 << 20)         // 17 MB
 	DefaultMaxReviseBatchSize   = 17 * (1 << 20)         // 17 MB
+	DefaultReadCacheSize        = 64 * SectorSize        // 256 MB
+
+	// download limits default value
+	DefaultMaxDownloadSections  = uint64(256)
+	DefaultMaxDownloadSize      = uint64(64 * (1 << 20)) // 64 MB
+	DefaultMaxDownloadProofSize = uint64(1 << 20)        // 1 MB
+
+	// read io default value
+	DefaultReadWorkers         = uint64(4)
+	DefaultReadahead           = uint64(2)
+	DefaultDiskReadConcurrency = uint64(4)
 
 	// deposit defaults value
 	DefaultDeposit       = common.PtrBigInt(math.BigPow(10, 3))  // 173 dx per TB per month
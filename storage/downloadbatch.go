@@ -0,0 +1,68 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import "github.com/DxChainNetwork/godx/common"
+
+// The download of a single section is negotiated with DownloadRequest and DownloadResponse,
+// which are served by all storage hosts. The download of multiple sections is negotiated
+// with DownloadBatchRequest and DownloadBatchResponse, which are only sent to the hosts
+// advertising the max download batch size. The storage host handles both of them as a batch
+
+// Batch converts the download request to the download batch request of the single section
+func (req DownloadRequest) Batch() DownloadBatchRequest {
+	return DownloadBatchRequest{
+		StorageContractID:    req.StorageContractID,
+		Sections:             []DownloadRequestSector{req.Sector},
+		MerkleProof:          req.MerkleProof,
+		NewRevisionNumber:    req.NewRevisionNumber,
+		NewValidProofValues:  req.NewValidProofValues,
+		NewMissedProofValues: req.NewMissedProofValues,
+		Signature:            req.Signature,
+		HostConfigHash:       req.HostConfigHash,
+	}
+}
+
+// Single converts the download batch request of a single section to the download request.
+// Only the first section is kept, thus it shall only be called with a single section
+func (req DownloadBatchRequest) Single() DownloadRequest {
+	single := DownloadRequest{
+		StorageContractID:    req.StorageContractID,
+		MerkleProof:          req.MerkleProof,
+		NewRevisionNumber:    req.NewRevisionNumber,
+		NewValidProofValues:  req.NewValidProofValues,
+		NewMissedProofValues: req.NewMissedProofValues,
+		Signature:            req.Signature,
+		HostConfigHash:       req.HostConfigHash,
+	}
+	if len(req.Sections) > 0 {
+		single.Sector = req.Sections[0]
+	}
+	return single
+}
+
+// Batch converts the download response to the download batch response of the single section
+func (resp DownloadResponse) Batch() DownloadBatchResponse {
+	batch := DownloadBatchResponse{
+		Signature: resp.Signature,
+		Data:      resp.Data,
+	}
+	if resp.MerkleProof != nil {
+		batch.MerkleProofs = [][]common.Hash{resp.MerkleProof}
+	}
+	return batch
+}
+
+// Single converts the download batch response of a single section to the download response
+func (resp DownloadBatchResponse) Single() DownloadResponse {
+	single := DownloadResponse{
+		Signature: resp.Signature,
+		Data:      resp.Data,
+	}
+	if len(resp.MerkleProofs) > 0 {
+		single.MerkleProof = resp.MerkleProofs[0]
+	}
+	return single
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// TestDownloadRequestBatch test the single section download request is converted to the batch
+// request and back without loss, while the wire format of the two requests differ
+func TestDownloadRequestBatch(t *testing.T) {
+	req := DownloadRequest{
+		StorageContractID:    common.HexToHash("0x1"),
+		Sector:               DownloadRequestSector{MerkleRoot: common.HexToHash("0x2"), Offset: 64, Length: 128},
+		MerkleProof:          true,
+		NewRevisionNumber:    3,
+		NewValidProofValues:  []*big.Int{big.NewInt(1), big.NewInt(2)},
+		NewMissedProofValues: []*big.Int{big.NewInt(3), big.NewInt(4)},
+		Signature:            []byte{1},
+		HostConfigHash:       common.HexToHash("0x5"),
+	}
+	batch := req.Batch()
+	if len(batch.Sections) != 1 || batch.Sections[0] != req.Sector {
+		t.Fatalf("unexpected sections of the batch request: %+v", batch.Sections)
+	}
+	if single := batch.Single(); !reflect.DeepEqual(single, req) {
+		t.Errorf("download request not restored from the batch request. Got %+v, Expect %+v", single, req)
+	}
+
+	reqBytes, err := rlp.EncodeToBytes(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rlp.DecodeBytes(reqBytes, new(DownloadBatchRequest)); err == nil {
+		t.Error("download request decoded as the batch request")
+	}
+}
+
+// TestDownloadResponseBatch test the single section download response is converted to the
+// batch response and back without loss
+func TestDownloadResponseBatch(t *testing.T) {
+	resp := DownloadResponse{
+		Signature:   []byte{1},
+		Data:        []byte{2, 3},
+		MerkleProof: []common.Hash{common.HexToHash("0x4")},
+	}
+	batch := resp.Batch()
+	if len(batch.MerkleProofs) != 1 || !reflect.DeepEqual(batch.MerkleProofs[0], resp.MerkleProof) {
+		t.Fatalf("unexpected merkle proofs of the batch response: %v", batch.MerkleProofs)
+	}
+	if single := batch.Single(); !reflect.DeepEqual(single, resp) {
+		t.Errorf("download response not restored from the batch response. Got %+v, Expect %+v", single, resp)
+	}
+	if batch := (DownloadResponse{Data: resp.Data}).Batch(); batch.MerkleProofs != nil {
+		t.Errorf("merkle proofs of the response without proof: %v", batch.MerkleProofs)
+	}
+}
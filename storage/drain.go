@@ -0,0 +1,81 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage/storageerr"
+)
+
+// DefaultDrainTimeout is the default time waited for the negotiations in progress to finish
+// on shutdown
+const DefaultDrainTimeout = 30 * time.Second
+
+// ErrNegotiationDraining is returned when a negotiation is started while the negotiations
+// are drained for shutdown. The host's evaluation will not be deducted
+var ErrNegotiationDraining = storageerr.New(storageerr.CodeHostBusy, "storage negotiations are draining for shutdown")
+
+// NegotiationDrain tracks the negotiations in progress, so that they could finish on shutdown
+// instead of being cut off in the middle. Once draining, no new negotiation could be started
+type NegotiationDrain struct {
+	active   int
+	draining bool
+	deadline time.Time
+	done     chan struct{}
+	lock     sync.Mutex
+}
+
+// Begin starts tracking a negotiation. ErrNegotiationDraining is returned if draining
+func (nd *NegotiationDrain) Begin() error {
+	nd.lock.Lock()
+	defer nd.lock.Unlock()
+
+	if nd.draining {
+		return ErrNegotiationDraining
+	}
+	nd.active++
+	return nil
+}
+
+// End finishes tracking a negotiation started by Begin
+func (nd *NegotiationDrain) End() {
+	nd.lock.Lock()
+	defer nd.lock.Unlock()
+
+	nd.active--
+	if nd.draining && nd.active == 0 {
+		close(nd.done)
+	}
+}
+
+// Drain stops new negotiations from being started, and waits for the negotiations in progress
+// to finish within the timeout. The number of negotiations not finished in time is returned.
+// The calls after the first one wait until the deadline set by the first call
+func (nd *NegotiationDrain) Drain(timeout time.Duration) int {
+	nd.lock.Lock()
+	if !nd.draining {
+		nd.draining = true
+		nd.deadline = time.Now().Add(timeout)
+		nd.done = make(chan struct{})
+		if nd.active == 0 {
+			close(nd.done)
+		}
+	}
+	done, wait := nd.done, time.Until(nd.deadline)
+	nd.lock.Unlock()
+
+	if wait > 0 {
+		select {
+		case <-done:
+		case <-time.After(wait):
+		}
+	}
+
+	nd.lock.Lock()
+	defer nd.lock.Unlock()
+	return nd.active
+}
@@ -0,0 +1,59 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNegotiationDrain test the negotiations in progress are waited on drain, and no new
+// negotiation could be started once draining
+func TestNegotiationDrain(t *testing.T) {
+	var nd NegotiationDrain
+	for i := 0; i < 2; i++ {
+		if err := nd.Begin(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		nd.End()
+		nd.End()
+	}()
+	start := time.Now()
+	if left := nd.Drain(time.Second); left != 0 {
+		t.Errorf("expect all negotiations finished, got %v left", left)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("drain waited until the timeout")
+	}
+	if err := nd.Begin(); err != ErrNegotiationDraining {
+		t.Errorf("expect error %v, got %v", ErrNegotiationDraining, err)
+	}
+}
+
+// TestNegotiationDrain_Timeout test the drain returns the negotiations not finished in time,
+// and the calls after the first one wait until the same deadline
+func TestNegotiationDrain_Timeout(t *testing.T) {
+	var nd NegotiationDrain
+	if err := nd.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if left := nd.Drain(50 * time.Millisecond); left != 1 {
+		t.Errorf("expect 1 negotiation left, got %v", left)
+	}
+	if left := nd.Drain(time.Minute); left != 1 {
+		t.Errorf("expect 1 negotiation left, got %v", left)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("the second drain did not respect the deadline of the first one")
+	}
+	nd.End()
+	if left := nd.Drain(time.Minute); left != 0 {
+		t.Errorf("expect no negotiation left, got %v", left)
+	}
+}
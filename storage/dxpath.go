@@ -21,6 +21,7 @@ var (
 
 	reservedNames = []string{
 		".dxdir",
+		DxManifestFileName,
 	}
 )
 
@@ -0,0 +1,50 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+// +build gofuzz
+
+package storage
+
+import (
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// validator is the negotiation message that could check its own field sizes
+type validator interface {
+	Validate() error
+}
+
+// FuzzNegotiationMsg is the entry point for the go-fuzz tool, decoding the negotiation
+// messages sent by the peer. The first byte of the input selects the message type.
+//
+// This returns 1 if the message is decoded and passes the size check, 0 otherwise
+func FuzzNegotiationMsg(input []byte) int {
+	if len(input) == 0 {
+		return -1
+	}
+	var msg validator
+	switch input[0] % 7 {
+	case 0:
+		msg = new(UploadRequest)
+	case 1:
+		msg = new(DownloadRequest)
+	case 2:
+		msg = new(UploadMerkleProof)
+	case 3:
+		msg = new(ContractCreateRequest)
+	case 4:
+		msg = new(SectorStoredResponse)
+	case 5:
+		msg = new(DownloadBatchRequest)
+	default:
+		msg = new(PublicReadRequest)
+	}
+	if err := rlp.DecodeBytes(input[1:], msg); err != nil {
+		return 0
+	}
+	if err := msg.Validate(); err != nil {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,102 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// emptyConfigStamp is the stamp of the empty host config
+var emptyConfigStamp = HostExtConfig{}.Stamp()
+
+// Stamp returns the version stamp of the whole host config, including the fields derived from
+// the runtime status of the host. Unlike Hash, any change of the config changes the stamp
+func (config HostExtConfig) Stamp() common.Hash {
+	enc, _ := rlp.EncodeToBytes(config)
+	return crypto.Keccak256Hash(enc)
+}
+
+// NewHostConfigRequest returns the request of the host config, where the config cached by the
+// client is stamped. The whole config is requested if nothing is cached
+func NewHostConfigRequest(cached HostExtConfig) HostConfigRequest {
+	stamp := cached.Stamp()
+	if stamp == emptyConfigStamp {
+		return HostConfigRequest{}
+	}
+	return HostConfigRequest{Stamp: stamp}
+}
+
+// NewHostConfigDelta returns the delta containing the fields of the config changed since the
+// base config
+func NewHostConfigDelta(base, config HostExtConfig) (HostConfigDelta, error) {
+	delta := HostConfigDelta{
+		BaseStamp: base.Stamp(),
+		Stamp:     config.Stamp(),
+	}
+	baseValue, value := reflect.ValueOf(base), reflect.ValueOf(config)
+	for i := 0; i < value.NumField(); i++ {
+		baseEnc, err := rlp.EncodeToBytes(baseValue.Field(i).Interface())
+		if err != nil {
+			return HostConfigDelta{}, err
+		}
+		enc, err := rlp.EncodeToBytes(value.Field(i).Interface())
+		if err != nil {
+			return HostConfigDelta{}, err
+		}
+		if !bytes.Equal(baseEnc, enc) {
+			delta.Fields = append(delta.Fields, HostConfigField{Name: value.Type().Field(i).Name, Value: enc})
+		}
+	}
+	return delta, nil
+}
+
+// Apply applies the fields changed to the base config, and verifies the config updated has
+// the stamp of the delta
+func (delta HostConfigDelta) Apply(base HostExtConfig) (HostExtConfig, error) {
+	if base.Stamp() != delta.BaseStamp {
+		return HostExtConfig{}, fmt.Errorf("host config delta is based on config %x, not the config cached", delta.BaseStamp)
+	}
+	config := base
+	value := reflect.ValueOf(&config).Elem()
+	for _, f := range delta.Fields {
+		field := value.FieldByName(f.Name)
+		if !field.IsValid() {
+			return HostExtConfig{}, fmt.Errorf("unknown host config field %v", f.Name)
+		}
+		if err := rlp.DecodeBytes(f.Value, field.Addr().Interface()); err != nil {
+			return HostExtConfig{}, fmt.Errorf("failed to decode host config field %v: %s", f.Name, err.Error())
+		}
+	}
+	if stamp := config.Stamp(); stamp != delta.Stamp {
+		return HostExtConfig{}, fmt.Errorf("host config stamp %x after the delta applied, expect %x", stamp, delta.Stamp)
+	}
+	return config, nil
+}
+
+// DecodeHostConfig decodes the host config response into the config. If the response is the
+// config delta, the fields changed are applied to the config cached. The config is not changed
+// if the delta could not be applied
+func DecodeHostConfig(msg p2p.Msg, config *HostExtConfig) error {
+	if msg.Code != HostConfigDeltaMsg {
+		return msg.Decode(config)
+	}
+	var delta HostConfigDelta
+	if err := msg.Decode(&delta); err != nil {
+		return err
+	}
+	updated, err := delta.Apply(*config)
+	if err != nil {
+		return err
+	}
+	*config = updated
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// TestHostConfigDelta test the delta contains the fields changed only, and the config cached
+// is updated to the latest config once the delta is applied
+func TestHostConfigDelta(t *testing.T) {
+	base := HostExtConfig{
+		AcceptingContracts: true,
+		MaxDuration:        100,
+		PaymentAddress:     common.Address{1},
+		RemainingStorage:   1 << 30,
+		TotalStorage:       1 << 40,
+		StoragePrice:       common.NewBigInt(10),
+		MaxDeposit:         common.NewBigInt(1000),
+		Version:            "1.0.0",
+	}
+	config := base
+	config.RemainingStorage = 1 << 20
+	config.MaxDeposit = common.NewBigInt(900)
+	config.Region = "eu-west"
+
+	delta, err := NewHostConfigDelta(base, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(delta.Fields) != 3 {
+		t.Fatalf("expect 3 fields changed, got %+v", delta.Fields)
+	}
+	updated, err := delta.Apply(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Stamp() != config.Stamp() || updated.Region != "eu-west" || updated.MaxDeposit.Cmp(config.MaxDeposit) != 0 {
+		t.Errorf("config updated not expected: %+v", updated)
+	}
+
+	// the delta of the same config is empty
+	if delta, err := NewHostConfigDelta(config, config); err != nil || len(delta.Fields) != 0 {
+		t.Errorf("expect empty delta, got %+v, %v", delta, err)
+	}
+
+	// the delta could not be applied to the other configs
+	if _, err := delta.Apply(config); err == nil {
+		t.Error("delta applied to the config not based on")
+	}
+
+	// the delta tampered does not result in the stamp expected
+	delta.Fields[0].Value, _ = rlp.EncodeToBytes(uint64(1))
+	if _, err := delta.Apply(base); err == nil {
+		t.Error("tampered delta applied")
+	}
+	delta.Fields[0].Name = "Unknown"
+	if _, err := delta.Apply(base); err == nil {
+		t.Error("delta of the unknown field applied")
+	}
+
+	// nothing cached requests the whole config
+	if req := NewHostConfigRequest(HostExtConfig{}); req.Stamp != (common.Hash{}) {
+		t.Errorf("expect empty stamp, got %x", req.Stamp)
+	}
+	if req := NewHostConfigRequest(base); req.Stamp != base.Stamp() {
+		t.Errorf("expect stamp %x, got %x", base.Stamp(), req.Stamp)
+	}
+}
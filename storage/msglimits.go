@@ -0,0 +1,125 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Defines the size limits of the negotiation messages, which are decoded from the messages
+// sent by the peer, and checked before being processed
+const (
+	// MaxUploadActions is the max number of actions in an upload request
+	MaxUploadActions = 128
+
+	// MaxDownloadSections is the max number of sections in a download or public read request
+	MaxDownloadSections = 1024
+
+	// MaxMerkleProofHashes is the max number of hashes in the upload merkle proof
+	MaxMerkleProofHashes = 8192
+
+	// proofValuesCount is the number of proof values in the revision, which are the values
+	// of the storage client and the storage host
+	proofValuesCount = 2
+
+	// maxSignatureLength is the max length of the signature in the negotiation message
+	maxSignatureLength = 65
+)
+
+var (
+	// ErrTooManyUploadActions is returned if the upload request has too many actions
+	ErrTooManyUploadActions = fmt.Errorf("upload request has more than %d actions", MaxUploadActions)
+
+	errTooManyDownloadSections = fmt.Errorf("download request has more than %d sections", MaxDownloadSections)
+	errTooManyProofHashes      = fmt.Errorf("merkle proof has more than %d hashes", MaxMerkleProofHashes)
+	errInvalidProofValues      = errors.New("revision must have the proof values of the client and the host")
+	errInvalidSignatureLength  = errors.New("invalid signature length")
+	errInvalidSectionBoundary  = errors.New("download section out of the sector boundary")
+)
+
+// Validate checks the sizes of the fields of the upload request
+func (req UploadRequest) Validate() error {
+	if len(req.Actions) > MaxUploadActions {
+		return ErrTooManyUploadActions
+	}
+	for _, action := range req.Actions {
+		if action.Type != UploadActionAppend {
+			return fmt.Errorf("unknown upload action type: %s", action.Type)
+		}
+		if uint64(len(action.Data)) > SectorSize {
+			return fmt.Errorf("upload action data has %d bytes, exceeding the sector size", len(action.Data))
+		}
+	}
+	if len(req.NewValidProofValues) != proofValuesCount || len(req.NewMissedProofValues) != proofValuesCount {
+		return errInvalidProofValues
+	}
+	return nil
+}
+
+// Validate checks the sizes of the fields of the download request
+func (req DownloadRequest) Validate() error {
+	return req.Batch().Validate()
+}
+
+// Validate checks the sizes of the fields of the download batch request
+func (req DownloadBatchRequest) Validate() error {
+	if err := validateSections(req.Sections); err != nil {
+		return err
+	}
+	if len(req.NewValidProofValues) != proofValuesCount || len(req.NewMissedProofValues) != proofValuesCount {
+		return errInvalidProofValues
+	}
+	if len(req.Signature) > maxSignatureLength {
+		return errInvalidSignatureLength
+	}
+	return nil
+}
+
+// Validate checks the sizes of the fields of the public read request
+func (req PublicReadRequest) Validate() error {
+	return validateSections(req.Sections)
+}
+
+// Validate checks the sizes of the fields of the upload merkle proof
+func (mp UploadMerkleProof) Validate() error {
+	if len(mp.OldSubtreeHashes)+len(mp.OldLeafHashes) > MaxMerkleProofHashes {
+		return errTooManyProofHashes
+	}
+	return nil
+}
+
+// Validate checks the sizes of the fields of the sector stored response
+func (resp SectorStoredResponse) Validate() error {
+	if len(resp.MerkleProof) > MaxMerkleProofHashes {
+		return errTooManyProofHashes
+	}
+	return nil
+}
+
+// Validate checks the sizes of the fields of the contract create request
+func (req ContractCreateRequest) Validate() error {
+	sc := req.StorageContract
+	if len(sc.ValidProofOutputs) != proofValuesCount || len(sc.MissedProofOutputs) != proofValuesCount {
+		return errInvalidProofValues
+	}
+	if len(req.Sign) > maxSignatureLength || len(sc.Signatures) > proofValuesCount {
+		return errInvalidSignatureLength
+	}
+	return nil
+}
+
+// validateSections checks the number of the download sections, and the boundary of each section
+func validateSections(sections []DownloadRequestSector) error {
+	if len(sections) > MaxDownloadSections {
+		return errTooManyDownloadSections
+	}
+	for _, sec := range sections {
+		if uint64(sec.Offset)+uint64(sec.Length) > SectorSize {
+			return errInvalidSectionBoundary
+		}
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ package storage
 import (
 	"errors"
 
+	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/p2p"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 )
@@ -16,23 +17,64 @@ import (
 // should not be deducted.
 var ErrRequestingHostConfig = errors.New("host configuration should only be requested one at a time")
 
+// Misbehavior is the kind of the misbehavior of the storage peer. The misbehavior reported
+// is scored by the peer, and the peer misbehaving repeatedly is banned for a while. It is not
+// related to the evaluation of the storage host, which is used for the host selection
+type Misbehavior int
+
+const (
+	// MisbehaviorInvalidProof means the peer provided the invalid merkle proof
+	MisbehaviorInvalidProof Misbehavior = iota
+
+	// MisbehaviorMalformedMsg means the peer sent the message could not be decoded or validated
+	MisbehaviorMalformedMsg
+
+	// MisbehaviorTimeout means the peer did not respond within the protocol timeout
+	MisbehaviorTimeout
+)
+
+// String returns the name of the misbehavior
+func (m Misbehavior) String() string {
+	switch m {
+	case MisbehaviorInvalidProof:
+		return "invalid proof"
+	case MisbehaviorMalformedMsg:
+		return "malformed message"
+	case MisbehaviorTimeout:
+		return "timeout"
+	default:
+		return "unknown"
+	}
+}
+
 // Peer is the interface returned by the SetupConnection. The use of it is to allow eth.peer object
 // to be used in the storage model. All the methods provided in the Peer interface is used for negotiation
-// during the contract create, contract revision, contract renew, and configuration request
+// during the contract create, contract revision, contract renew, and configuration request.
+// Each Peer returned is a negotiation session over the connection, multiple sessions with the same
+// node could negotiate concurrently. The session must be closed once the negotiation finished
 type Peer interface {
 	TriggerError(error)
+	ReportMisbehavior(m Misbehavior, err error)
 	SendStorageHostConfig(config HostExtConfig) error
-	RequestStorageHostConfig() error
+	SendStorageHostConfigDelta(delta HostConfigDelta) error
+	RequestStorageHostConfig(req HostConfigRequest) error
 	SendUploadMerkleProof(merkleProof UploadMerkleProof) error
 	RequestContractCreation(req ContractCreateRequest) error
 	SendContractCreateClientRevisionSign(revisionSign []byte) error
-	SendContractCreationHostSign(contractSign []byte) error
+	SendContractCreationHostSign(resp ContractCreateResponse) error
 	SendContractCreationHostRevisionSign(revisionSign []byte) error
 	RequestContractUpload(req UploadRequest) error
 	SendContractUploadClientRevisionSign(revisionSign []byte) error
 	SendUploadHostRevisionSign(revisionSign []byte) error
 	RequestContractDownload(req DownloadRequest) error
+	RequestContractDownloadBatch(req DownloadBatchRequest) error
+	RequestPublicRead(req PublicReadRequest) error
+	RequestSectorCheck(req SectorCheckRequest) error
+	SendSectorStored(resp SectorStoredResponse) error
+	RequestContractRevision(req ContractRevisionRequest) error
+	SendContractRevision(rev types.StorageContractRevision) error
 	SendContractDownloadData(resp DownloadResponse) error
+	SendContractDownloadBatchData(resp DownloadBatchResponse) error
 	SendHostBusyHandleRequestErr() error
 	SendClientNegotiateErrorMsg() error
 	SendClientCommitFailedMsg() error
@@ -41,6 +83,8 @@ type Peer interface {
 	SendClientAckMsg() error
 	SendHostAckMsg() error
 	SendHostNegotiateErrorMsg() error
+	SendHostConfigChangedMsg() error
+	SendHostFullMsg() error
 	WaitConfigResp() (p2p.Msg, error)
 	ClientWaitContractResp() (msg p2p.Msg, err error)
 	HostWaitContractResp() (msg p2p.Msg, err error)
@@ -50,4 +94,5 @@ type Peer interface {
 	RequestHostConfigDone()
 	PeerNode() *enode.Node
 	IsStaticConn() bool
+	Close()
 }
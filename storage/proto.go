@@ -7,6 +7,7 @@ package storage
 import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/rlp"
 	"math/big"
 )
 
@@ -16,12 +17,31 @@ const (
 )
 
 type (
+	// SessionMsg wraps the negotiation message with the id of the session it belongs to, so
+	// that multiple negotiations with the same node could be done over one connection. It is
+	// only used with the nodes of the protocol version eth65 and later, the messages with the
+	// nodes before are sent unwrapped. If the payload is compressed, it is the rlp string of
+	// the snappy compressed payload
+	SessionMsg struct {
+		SessionID  uint64
+		Compressed bool
+		Payload    rlp.RawValue
+	}
+
 	// ContractCreateRequest contains storage contract info and client pk
 	ContractCreateRequest struct {
 		StorageContract types.StorageContract
 		Sign            []byte
 		Renew           bool
 		OldContractID   common.Hash
+		HostConfigHash  common.Hash
+	}
+
+	// ContractCreateResponse is the response of the ContractCreateRequest, which contains the
+	// storage host's signature of the contract, and the itemized cost of the contract
+	ContractCreateResponse struct {
+		Sign []byte
+		Cost ContractCostBreakdown
 	}
 
 	// UploadRequest contains the request parameters for RPCUpload.
@@ -32,6 +52,11 @@ type (
 		NewRevisionNumber    uint64
 		NewValidProofValues  []*big.Int
 		NewMissedProofValues []*big.Int
+		HostConfigHash       common.Hash
+
+		// Public marks the appended sectors as public, which could be read by
+		// anyone through the public read negotiation without a contract
+		Public bool
 	}
 
 	// UploadAction is a generic Write action. The meaning of each field
@@ -60,9 +85,83 @@ type (
 		NewValidProofValues  []*big.Int
 		NewMissedProofValues []*big.Int
 		Signature            []byte
+		HostConfigHash       common.Hash
+	}
+
+	// DownloadBatchRequest contains the request parameters for RPCDownload of multiple
+	// sections within a single download negotiation
+	DownloadBatchRequest struct {
+		StorageContractID common.Hash
+		Sections          []DownloadRequestSector
+		MerkleProof       bool
+
+		NewRevisionNumber    uint64
+		NewValidProofValues  []*big.Int
+		NewMissedProofValues []*big.Int
+		Signature            []byte
+		HostConfigHash       common.Hash
 	}
 
-	// DownloadRequestSector is a section requested in DownloadRequest.
+	// PublicReadRequest contains the request parameters for reading the public sectors
+	// from the storage host. No contract revision is involved
+	PublicReadRequest struct {
+		Sections    []DownloadRequestSector
+		MerkleProof bool
+	}
+
+	// SectorCheckRequest asks the storage host whether the sector is already stored under
+	// the contract, before the sector data is uploaded
+	SectorCheckRequest struct {
+		StorageContractID common.Hash
+		SectorRoot        common.Hash
+	}
+
+	// SectorStoredResponse is the response of the SectorCheckRequest. If the sector is stored,
+	// MerkleProof proves that the sector root is at Index of the sector roots of the contract
+	SectorStoredResponse struct {
+		Stored      bool
+		Index       uint64
+		MerkleProof []common.Hash
+	}
+
+	// ContractRevisionRequest asks the storage host for its latest revision of the contract,
+	// with which the revision of the negotiation interrupted by the client is reconciled
+	ContractRevisionRequest struct {
+		StorageContractID common.Hash
+	}
+
+	// HostConfigUpdate is pushed by the storage host to the connected storage clients once
+	// the prices or the acceptance of the contracts changed. It is signed by the node key of
+	// the storage host, so that the clients could refresh the cached config
+	HostConfigUpdate struct {
+		Config    HostExtConfig
+		Signature []byte
+	}
+
+	// HostConfigRequest is sent by the storage client to request the host config. Stamp is the
+	// stamp of the config cached by the client, with which the storage host could respond with
+	// the fields changed only. Empty stamp requests the whole config
+	HostConfigRequest struct {
+		Stamp common.Hash
+	}
+
+	// HostConfigDelta is the response of the HostConfigRequest, containing the fields of the
+	// host config changed since the config with BaseStamp. Stamp is the stamp of the config
+	// after the fields are applied, with which the client verifies the config updated
+	HostConfigDelta struct {
+		BaseStamp common.Hash
+		Stamp     common.Hash
+		Fields    []HostConfigField
+	}
+
+	// HostConfigField is a field of the host config changed, where Value is the rlp encoded
+	// value of the field
+	HostConfigField struct {
+		Name  string
+		Value rlp.RawValue
+	}
+
+	// DownloadRequestSector is a section requested in DownloadRequest and DownloadBatchRequest.
 	DownloadRequestSector struct {
 		MerkleRoot [32]byte
 		Offset     uint32
@@ -75,4 +174,13 @@ type (
 		Data        []byte
 		MerkleProof []common.Hash
 	}
+
+	// DownloadBatchResponse contains the response data for DownloadBatchRequest. Data is
+	// the concatenated data of all requested sections, and MerkleProofs contains the
+	// Merkle proof of each section in the request order.
+	DownloadBatchResponse struct {
+		Signature    []byte
+		Data         []byte
+		MerkleProofs [][]common.Hash
+	}
 )
@@ -0,0 +1,73 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/params"
+)
+
+// ProtocolParams is the set of the storage protocol parameters of a protocol version. The
+// version active at a block height is scheduled by the storage protocol forks in the chain
+// config, and the storage client only negotiates with the hosts on the same version
+type ProtocolParams struct {
+	Version uint64 `json:"version"`
+
+	// SectorSize is the size of the data sector uploaded to the storage host
+	SectorSize uint64 `json:"sectorSize"`
+
+	// SegmentSize is the size of the leaf taken in the Merkle root of a sector
+	SegmentSize uint64 `json:"segmentSize"`
+
+	// ProofWindowSize is the min number of blocks for the storage host to submit the proof
+	ProofWindowSize uint64 `json:"proofWindowSize"`
+}
+
+// ProtocolParamsV1 is the storage protocol parameters used since the genesis, which are the
+// values of the SectorSize, SegmentSize and ProofWindowSize constants
+var ProtocolParamsV1 = ProtocolParams{
+	Version:         params.StorageProtocolV1,
+	SectorSize:      SectorSize,
+	SegmentSize:     SegmentSize,
+	ProofWindowSize: ProofWindowSize,
+}
+
+// protocolParams is the storage protocol parameters of all versions supported
+var protocolParams = map[uint64]ProtocolParams{
+	params.StorageProtocolV1: ProtocolParamsV1,
+}
+
+// ProtocolParamsByVersion returns the storage protocol parameters of the version. Version
+// zero is reported by the storage hosts before the versions are negotiated, and is regarded
+// as version 1
+func ProtocolParamsByVersion(version uint64) (ProtocolParams, error) {
+	if version == 0 {
+		version = params.StorageProtocolV1
+	}
+	pp, exists := protocolParams[version]
+	if !exists {
+		return ProtocolParams{}, fmt.Errorf("storage protocol version %v is not supported, please upgrade the node", version)
+	}
+	return pp, nil
+}
+
+// ActiveProtocolParams returns the storage protocol parameters active at the block height
+func ActiveProtocolParams(config *params.ChainConfig, height uint64) (ProtocolParams, error) {
+	return ProtocolParamsByVersion(config.StorageProtocolVersion(new(big.Int).SetUint64(height)))
+}
+
+// CheckProtocolVersion checks the storage protocol version reported by the storage host is the
+// same as the version active locally
+func CheckProtocolVersion(hostVersion, activeVersion uint64) error {
+	if hostVersion == 0 {
+		hostVersion = params.StorageProtocolV1
+	}
+	if hostVersion != activeVersion {
+		return fmt.Errorf("storage host is on storage protocol version %v, expect version %v", hostVersion, activeVersion)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/params"
+)
+
+// TestActiveProtocolParams test the storage protocol parameters are activated by the forks in
+// the chain config, and the unsupported versions are rejected
+func TestActiveProtocolParams(t *testing.T) {
+	config := &params.ChainConfig{
+		StorageProtocolForks: []params.StorageProtocolFork{{Block: big.NewInt(100), Version: 99}},
+	}
+	pp, err := ActiveProtocolParams(config, 99)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pp != ProtocolParamsV1 {
+		t.Errorf("expect protocol params %+v, got %+v", ProtocolParamsV1, pp)
+	}
+	if _, err := ActiveProtocolParams(config, 100); err == nil {
+		t.Error("unsupported storage protocol version should be rejected")
+	}
+	if pp, err := ProtocolParamsByVersion(0); err != nil || pp != ProtocolParamsV1 {
+		t.Errorf("version 0 should be regarded as version 1, got %+v, %v", pp, err)
+	}
+}
+
+// TestCheckProtocolVersion test the storage host on another storage protocol version is rejected
+func TestCheckProtocolVersion(t *testing.T) {
+	tests := []struct {
+		host, active uint64
+		valid        bool
+	}{
+		{0, params.StorageProtocolV1, true},
+		{params.StorageProtocolV1, params.StorageProtocolV1, true},
+		{0, 2, false},
+		{2, params.StorageProtocolV1, false},
+		{2, 2, true},
+	}
+	for i, test := range tests {
+		if err := CheckProtocolVersion(test.host, test.active); (err == nil) != test.valid {
+			t.Errorf("test %d: expect valid %v, got error %v", i, test.valid, err)
+		}
+	}
+
+	// the hash of the version 1 hosts is not changed by reporting the version
+	config := HostExtConfig{MaxDuration: 100}
+	v1 := config
+	v1.ProtocolVersion = params.StorageProtocolV1
+	if config.Hash() != v1.Hash() {
+		t.Error("the hash of the version 1 host changed")
+	}
+	v2 := config
+	v2.ProtocolVersion = 2
+	if config.Hash() == v2.Hash() {
+		t.Error("the hash of the version 2 host should differ from version 1")
+	}
+}
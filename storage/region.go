@@ -0,0 +1,55 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxRegionLength is the max length of the host region tag
+const maxRegionLength = 32
+
+// ParseRegion normalizes the host region tag, which is case insensitive and consists of
+// letters, digits and hyphens, e.g. "us-east". Empty string means no region
+func ParseRegion(str string) (string, error) {
+	region := strings.ToLower(strings.TrimSpace(str))
+	if len(region) > maxRegionLength {
+		return "", fmt.Errorf("region %q is longer than %v characters", region, maxRegionLength)
+	}
+	for _, c := range region {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return "", fmt.Errorf("region %q contains invalid character %q", region, c)
+		}
+	}
+	return region, nil
+}
+
+// ParseRegions parses the comma separated list of the region tags. Empty string gives
+// the empty list
+func ParseRegions(str string) ([]string, error) {
+	var regions []string
+	for _, s := range strings.Split(str, ",") {
+		region, err := ParseRegion(s)
+		if err != nil {
+			return nil, err
+		}
+		if region == "" || RegionMatch(regions, region) {
+			continue
+		}
+		regions = append(regions, region)
+	}
+	return regions, nil
+}
+
+// RegionMatch checks if the region is one of the regions
+func RegionMatch(regions []string, region string) bool {
+	for _, r := range regions {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
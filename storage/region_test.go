@@ -0,0 +1,37 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseRegions test the region tags are normalized and validated
+func TestParseRegions(t *testing.T) {
+	tests := []struct {
+		str     string
+		regions []string
+		valid   bool
+	}{
+		{"", nil, true},
+		{"us-east", []string{"us-east"}, true},
+		{" US-East , eu-west2,,us-east", []string{"us-east", "eu-west2"}, true},
+		{"us_east", nil, false},
+		{"us east", nil, false},
+		{strings.Repeat("a", maxRegionLength+1), nil, false},
+	}
+	for i, test := range tests {
+		regions, err := ParseRegions(test.str)
+		if (err == nil) != test.valid {
+			t.Errorf("test %d: expect valid %v, got error %v", i, test.valid, err)
+			continue
+		}
+		if !reflect.DeepEqual(regions, test.regions) {
+			t.Errorf("test %d: expect regions %v, got %v", i, test.regions, regions)
+		}
+	}
+}
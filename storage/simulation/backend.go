@@ -0,0 +1,314 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+// Package simulation provides the in-memory implementation of the storage.EthBackend,
+// which is used to integration test the storage client without spinning up the devp2p
+// nodes. The chain is simulated in memory with the fake dpos engine, and the storage
+// hosts are connected in process
+package simulation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/DxChainNetwork/godx/accounts"
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/consensus/dpos"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/core/vm"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/event"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rpc"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storagehost"
+)
+
+// defaultGasPrice is the gas price suggested by the simulated backend
+var defaultGasPrice = big.NewInt(1)
+
+var (
+	errUnknownHost  = errors.New("storage host is not added to the simulated backend")
+	errInvalidNonce = errors.New("invalid transaction nonce")
+)
+
+// Backend is the in-memory storage.EthBackend. The transactions sent are kept pending
+// until Commit is called, which mines them into a new block and posts the chain change
+// event to the subscribers
+type Backend struct {
+	config     *params.ChainConfig
+	db         ethdb.Database
+	blockchain *core.BlockChain
+	am         *accounts.Manager
+	self       *enode.Node
+
+	chainFeed event.Feed
+
+	lock    sync.Mutex
+	pending []*types.Transaction
+	hosts   map[enode.ID]*host
+	conns   map[enode.ID]*connection
+}
+
+// host is the storage host connected in process
+type host struct {
+	node *enode.Node
+	host *storagehost.StorageHost
+}
+
+// New creates the simulated backend with the genesis allocation. The self node is the
+// local node of the storage client, and the account manager is used by the storage
+// client to sign the contracts and transactions
+func New(alloc core.GenesisAlloc, am *accounts.Manager, self *enode.Node) (*Backend, error) {
+	config := params.DposChainConfig
+	db := ethdb.NewMemDatabase()
+	gspec := core.Genesis{
+		Config: config,
+		Alloc:  core.MakeAlloc(alloc, config),
+	}
+	gspec.MustCommit(db)
+
+	blockchain, err := core.NewBlockChain(db, nil, config, dpos.NewDposFaker(), vm.Config{}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the simulated blockchain: %s", err.Error())
+	}
+
+	return &Backend{
+		config:     config,
+		db:         db,
+		blockchain: blockchain,
+		am:         am,
+		self:       self,
+		hosts:      make(map[enode.ID]*host),
+		conns:      make(map[enode.ID]*connection),
+	}, nil
+}
+
+// AddHost adds the storage host, which could be connected through the enode url of the node
+func (b *Backend) AddHost(node *enode.Node, h *storagehost.StorageHost) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.hosts[node.ID()] = &host{node: node, host: h}
+}
+
+// Commit mines the pending transactions into a new block, and posts the chain change
+// event with the block applied
+func (b *Backend) Commit() error {
+	b.lock.Lock()
+	pending := b.pending
+	b.pending = nil
+	b.lock.Unlock()
+
+	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), dpos.NewDposFaker(), b.db, 1, func(i int, block *core.BlockGen) {
+		for _, tx := range pending {
+			block.AddTxWithChain(b.blockchain, tx)
+		}
+	})
+	if _, err := b.blockchain.InsertChain(blocks); err != nil {
+		return fmt.Errorf("failed to insert the simulated block: %s", err.Error())
+	}
+
+	b.chainFeed.Send(core.ChainChangeEvent{
+		AppliedBlockHashes: []common.Hash{blocks[0].Hash()},
+	})
+	return nil
+}
+
+// APIs returns no APIs, the simulated backend is not served over rpc
+func (b *Backend) APIs() []rpc.API {
+	return nil
+}
+
+// GetStorageHostSetting requests the storage host configuration through the in process
+// connection
+func (b *Backend) GetStorageHostSetting(hostEnodeID enode.ID, hostEnodeURL string, config *storage.HostExtConfig) error {
+	sp, err := b.SetupConnection(hostEnodeURL)
+	if err != nil {
+		return fmt.Errorf("failed to get the storage host configuration: %s", err.Error())
+	}
+	defer sp.Close()
+
+	if err := sp.TryRequestHostConfig(); err != nil {
+		return err
+	}
+	defer sp.RequestHostConfigDone()
+
+	if err := sp.RequestStorageHostConfig(storage.NewHostConfigRequest(*config)); err != nil {
+		return fmt.Errorf("failed to request storage host configuration: %s", err)
+	}
+	msg, err := sp.WaitConfigResp()
+	if err != nil {
+		return fmt.Errorf("received error while waiting for retriving storage host config: %s", err.Error())
+	}
+	if err := storage.DecodeHostConfig(msg, config); err != nil {
+		return fmt.Errorf("error decoding the storage configuration: %s", err.Error())
+	}
+	return nil
+}
+
+// SubscribeChainChangeEvent subscribes the chain change event posted on Commit
+func (b *Backend) SubscribeChainChangeEvent(ch chan<- core.ChainChangeEvent) event.Subscription {
+	return b.chainFeed.Subscribe(ch)
+}
+
+// GetBlockByHash returns the block by hash
+func (b *Backend) GetBlockByHash(blockHash common.Hash) (*types.Block, error) {
+	block := b.blockchain.GetBlockByHash(blockHash)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", blockHash)
+	}
+	return block, nil
+}
+
+// GetBlockChain returns the simulated blockchain
+func (b *Backend) GetBlockChain() *core.BlockChain {
+	return b.blockchain
+}
+
+// GetBlockByNumber returns the block by number
+func (b *Backend) GetBlockByNumber(number uint64) (*types.Block, error) {
+	block := b.blockchain.GetBlockByNumber(number)
+	if block == nil {
+		return nil, fmt.Errorf("block %v not found", number)
+	}
+	return block, nil
+}
+
+// AccountManager returns the account manager passed in on construction
+func (b *Backend) AccountManager() *accounts.Manager {
+	return b.am
+}
+
+// GetCurrentBlockHeight returns the height of the current block
+func (b *Backend) GetCurrentBlockHeight() uint64 {
+	return b.blockchain.CurrentHeader().Number.Uint64()
+}
+
+// ChainConfig returns the chain config of the simulated chain
+func (b *Backend) ChainConfig() *params.ChainConfig {
+	return b.config
+}
+
+// CurrentBlock returns the latest block
+func (b *Backend) CurrentBlock() *types.Block {
+	return b.blockchain.CurrentBlock()
+}
+
+// SendTx adds the signed transaction to the pending transactions, which are mined on Commit.
+// The transaction with the nonce not following the pending nonce of the sender is rejected
+func (b *Backend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	signer := types.MakeSigner(b.config, b.blockchain.CurrentBlock().Number())
+	from, err := types.Sender(signer, signedTx)
+	if err != nil {
+		return fmt.Errorf("invalid transaction sender: %s", err.Error())
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	nonce, err := b.poolNonce(from)
+	if err != nil {
+		return err
+	}
+	if signedTx.Nonce() != nonce {
+		return errInvalidNonce
+	}
+	b.pending = append(b.pending, signedTx)
+	return nil
+}
+
+// SuggestPrice returns the constant gas price
+func (b *Backend) SuggestPrice(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(defaultGasPrice), nil
+}
+
+// GetPoolNonce returns the nonce of the account, including the pending transactions
+func (b *Backend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.poolNonce(addr)
+}
+
+// poolNonce returns the state nonce of the account plus the number of the pending
+// transactions sent from it. The lock must be held by the caller
+func (b *Backend) poolNonce(addr common.Address) (uint64, error) {
+	statedb, err := b.blockchain.State()
+	if err != nil {
+		return 0, err
+	}
+	nonce := statedb.GetNonce(addr)
+	signer := types.MakeSigner(b.config, b.blockchain.CurrentBlock().Number())
+	for _, tx := range b.pending {
+		if from, _ := types.Sender(signer, tx); from == addr {
+			nonce++
+		}
+	}
+	return nonce, nil
+}
+
+// SetupConnection connects to the storage host added in process, and returns the client
+// side of a new negotiation session, which must be closed once the negotiation finished
+func (b *Backend) SetupConnection(enodeURL string) (storage.Peer, error) {
+	node, err := enode.ParseV4(enodeURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the enodeURL: %s", err.Error())
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	h, exists := b.hosts[node.ID()]
+	if !exists {
+		return nil, errUnknownHost
+	}
+	conn, exists := b.conns[node.ID()]
+	if !exists {
+		conn = newConnection()
+		b.conns[node.ID()] = conn
+	}
+	client, _ := newSessionPair(conn, b.self, h.node, h.host)
+	return client, nil
+}
+
+// TryToRenewOrRevise tries to renew the contract with the host. It fails if the
+// connection is revising or renewing
+func (b *Backend) TryToRenewOrRevise(hostID enode.ID) bool {
+	b.lock.Lock()
+	conn, exists := b.conns[hostID]
+	b.lock.Unlock()
+
+	if !exists {
+		return false
+	}
+	return conn.tryToRenew()
+}
+
+// RevisionOrRenewingDone indicates the renew finished
+func (b *Backend) RevisionOrRenewingDone(hostID enode.ID) {
+	b.lock.Lock()
+	conn, exists := b.conns[hostID]
+	b.lock.Unlock()
+
+	if exists {
+		conn.renewDone()
+	}
+}
+
+// SetStatic does nothing, the in process connections are never dropped
+func (b *Backend) SetStatic(node *enode.Node) {}
+
+// CheckAndUpdateConnection does nothing, the in process connections are never dropped
+func (b *Backend) CheckAndUpdateConnection(peerNode *enode.Node) {}
+
+// SelfEnodeURL returns the url of the local node
+func (b *Backend) SelfEnodeURL() string {
+	return b.self.String()
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package simulation
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestBackendCommit test the transactions sent are mined on commit, and the chain change
+// event is posted
+func TestBackendCommit(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	self := enode.NewV4(&key.PublicKey, net.ParseIP("127.0.0.1"), 30303, 30303)
+	alloc := core.GenesisAlloc{from: {Balance: big.NewInt(1000000000)}}
+	b, err := New(alloc, nil, self)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan core.ChainChangeEvent, 1)
+	sub := b.SubscribeChainChangeEvent(ch)
+	defer sub.Unsubscribe()
+
+	signer := types.MakeSigner(b.ChainConfig(), b.CurrentBlock().Number())
+	for i := uint64(0); i < 2; i++ {
+		nonce, err := b.GetPoolNonce(context.Background(), from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nonce != i {
+			t.Fatalf("pool nonce %v, expect %v", nonce, i)
+		}
+		tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{1}, big.NewInt(1), params.TxGas, big.NewInt(1), nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.SendTx(context.Background(), tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{1}, big.NewInt(1), params.TxGas, big.NewInt(1), nil), signer, key)
+	if err := b.SendTx(context.Background(), tx); err != errInvalidNonce {
+		t.Errorf("send tx with used nonce, got error %v", err)
+	}
+
+	if err := b.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if height := b.GetCurrentBlockHeight(); height != 1 {
+		t.Errorf("block height %v, expect 1", height)
+	}
+	if num := len(b.CurrentBlock().Transactions()); num != 2 {
+		t.Errorf("%v transactions mined, expect 2", num)
+	}
+	event := <-ch
+	if len(event.AppliedBlockHashes) != 1 || event.AppliedBlockHashes[0] != b.CurrentBlock().Hash() {
+		t.Errorf("unexpected chain change event: %+v", event)
+	}
+	if nonce, _ := b.GetPoolNonce(context.Background(), from); nonce != 2 {
+		t.Errorf("pool nonce %v after commit, expect 2", nonce)
+	}
+}
+
+// TestSessionMessages test the messages are delivered between both sides of the session,
+// and the message sent before the session closed is still received
+func TestSessionMessages(t *testing.T) {
+	client, host := newSessionPair(newConnection(), nil, nil, nil)
+
+	if err := host.SendHostAckMsg(); err != nil {
+		t.Fatal(err)
+	}
+	host.Close()
+	msg, err := client.ClientWaitContractResp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ack string
+	if msg.Code != storage.HostAckMsg || msg.Decode(&ack) != nil || ack != "host ack" {
+		t.Errorf("unexpected message received: %v, %v", msg.Code, ack)
+	}
+
+	if _, err := client.ClientWaitContractResp(); err != errSessionClosed {
+		t.Errorf("wait on the session closed, got error %v", err)
+	}
+	if err := host.SendHostAckMsg(); err != errSessionClosed {
+		t.Errorf("send on the session closed, got error %v", err)
+	}
+}
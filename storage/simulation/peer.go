@@ -0,0 +1,428 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package simulation
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storagehost"
+)
+
+// negotiationTimeout is the time waiting for the response from the other side of the session
+var negotiationTimeout = 1 * time.Minute
+
+var errSessionClosed = errors.New("negotiation session closed")
+
+// hostHandlers are the storage host handlers of the request messages, which start the
+// negotiation on the host side of the session
+var hostHandlers = map[uint64]func(h *storagehost.StorageHost, sp storage.Peer, msg p2p.Msg){
+	storage.ContractCreateReqMsg:        storagehost.ContractCreateHandler,
+	storage.ContractUploadReqMsg:        storagehost.UploadHandler,
+	storage.ContractDownloadReqMsg:      storagehost.DownloadHandler,
+	storage.ContractDownloadBatchReqMsg: storagehost.DownloadBatchHandler,
+	storage.PublicReadReqMsg:            storagehost.PublicReadHandler,
+	storage.ContractSectorCheckReqMsg:   storagehost.SectorCheckHandler,
+	storage.ContractRevisionReqMsg:      storagehost.ContractRevisionHandler,
+}
+
+// connection is the in process connection between the storage client and a storage host,
+// shared by all negotiation sessions with the host
+type connection struct {
+	renewLock sync.Mutex
+	renewing  bool
+	revising  int
+
+	hostConfigRequesting chan struct{}
+}
+
+// newConnection creates the connection
+func newConnection() *connection {
+	return &connection{
+		hostConfigRequesting: make(chan struct{}, 1),
+	}
+}
+
+// tryToRenew will try to renew the contract. Renewing is exclusive, it will fail if the
+// contract is revising or renewing
+func (c *connection) tryToRenew() bool {
+	c.renewLock.Lock()
+	defer c.renewLock.Unlock()
+
+	if c.renewing || c.revising > 0 {
+		return false
+	}
+	c.renewing = true
+	return true
+}
+
+// renewDone indicates the renewing operation has been finished
+func (c *connection) renewDone() {
+	c.renewLock.Lock()
+	defer c.renewLock.Unlock()
+
+	c.renewing = false
+}
+
+// session is one side of the in process negotiation session, implementing storage.Peer.
+// The messages sent are rlp encoded and delivered to the other side of the session
+type session struct {
+	conn   *connection
+	remote *session
+
+	// node is the node of the other side of the session
+	node *enode.Node
+
+	// host is the storage host handling the requests received, which is only
+	// set on the host side of the session
+	host *storagehost.StorageHost
+
+	configMsg   chan p2p.Msg
+	contractMsg chan p2p.Msg
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// newSessionPair creates both sides of the negotiation session between the client node and
+// the host node. The requests received on the host side are handled by the storage host
+func newSessionPair(conn *connection, clientNode, hostNode *enode.Node, h *storagehost.StorageHost) (client, host *session) {
+	client = newSession(conn, hostNode, nil)
+	host = newSession(conn, clientNode, h)
+	client.remote, host.remote = host, client
+	return
+}
+
+// newSession creates one side of the negotiation session
+func newSession(conn *connection, node *enode.Node, h *storagehost.StorageHost) *session {
+	return &session{
+		conn:        conn,
+		node:        node,
+		host:        h,
+		configMsg:   make(chan p2p.Msg, 1),
+		contractMsg: make(chan p2p.Msg, 1),
+		closed:      make(chan struct{}),
+	}
+}
+
+// send encodes the data and delivers the message to the other side of the session
+func (s *session) send(msgCode uint64, data interface{}) error {
+	select {
+	case <-s.closed:
+		return errSessionClosed
+	default:
+	}
+
+	size, r, err := rlp.EncodeToReader(data)
+	if err != nil {
+		return err
+	}
+	return s.remote.deliver(p2p.Msg{Code: msgCode, Size: uint32(size), Payload: r, ReceivedAt: time.Now()})
+}
+
+// deliver handles the message received. On the host side, the configuration request and the
+// contract requests are handled by the storage host, other messages are pushed to the message
+// channel of the session
+func (s *session) deliver(msg p2p.Msg) error {
+	if s.host != nil {
+		if msg.Code == storage.HostConfigReqMsg {
+			var req storage.HostConfigRequest
+			_ = msg.Decode(&req)
+			go func() {
+				config := s.host.RetrieveExternalConfig()
+				if delta, ok := s.host.ExternalConfigDelta(req.Stamp, config); ok {
+					if err := s.SendStorageHostConfigDelta(delta); err != nil {
+						s.TriggerError(err)
+					}
+					return
+				}
+				if err := s.SendStorageHostConfig(config); err != nil {
+					s.TriggerError(err)
+				}
+			}()
+			return nil
+		}
+		if handler, exists := hostHandlers[msg.Code]; exists {
+			go func() {
+				defer s.Close()
+				handler(s.host, s, msg)
+			}()
+			return nil
+		}
+	}
+
+	ch := s.contractMsg
+	if msg.Code == storage.HostConfigRespMsg || msg.Code == storage.HostConfigDeltaMsg {
+		ch = s.configMsg
+	}
+	select {
+	case ch <- msg:
+		return nil
+	case <-s.closed:
+		return errSessionClosed
+	}
+}
+
+// wait waits for the message from the other side of the session. The message sent before
+// the other side closed the session is still received
+func (s *session) wait(ch chan p2p.Msg) (msg p2p.Msg, err error) {
+	select {
+	case msg = <-ch:
+		return msg, nil
+	case <-time.After(negotiationTimeout):
+		err = errors.New("timeout -> waits too long for the response of the negotiation")
+		s.ReportMisbehavior(storage.MisbehaviorTimeout, err)
+		return msg, err
+	case <-s.closed:
+		return msg, errSessionClosed
+	case <-s.remote.closed:
+		select {
+		case msg = <-ch:
+			return msg, nil
+		default:
+			return msg, errSessionClosed
+		}
+	}
+}
+
+// TriggerError closes the session, as the in process connection could not be torn down
+func (s *session) TriggerError(err error) {
+	log.Debug("Simulated storage session error", "err", err)
+	s.Close()
+}
+
+// ReportMisbehavior logs the misbehavior of the other side of the session
+func (s *session) ReportMisbehavior(m storage.Misbehavior, err error) {
+	log.Debug("Simulated storage peer misbehaved", "misbehavior", m, "err", err)
+}
+
+// SendStorageHostConfig sends the storage host configuration to the client
+func (s *session) SendStorageHostConfig(config storage.HostExtConfig) error {
+	return s.send(storage.HostConfigRespMsg, config)
+}
+
+// SendStorageHostConfigDelta sends the fields of the storage host configuration changed
+func (s *session) SendStorageHostConfigDelta(delta storage.HostConfigDelta) error {
+	return s.send(storage.HostConfigDeltaMsg, delta)
+}
+
+// RequestStorageHostConfig requests the configuration of the storage host
+func (s *session) RequestStorageHostConfig(req storage.HostConfigRequest) error {
+	return s.send(storage.HostConfigReqMsg, req)
+}
+
+// SendUploadMerkleProof sends the merkle proof of the data uploaded to the client
+func (s *session) SendUploadMerkleProof(merkleProof storage.UploadMerkleProof) error {
+	return s.send(storage.ContractUploadMerkleProofMsg, merkleProof)
+}
+
+// RequestContractCreation requests the contract creation from the storage host
+func (s *session) RequestContractCreation(req storage.ContractCreateRequest) error {
+	return s.send(storage.ContractCreateReqMsg, req)
+}
+
+// SendContractCreateClientRevisionSign sends the revision signed by the client to the host
+func (s *session) SendContractCreateClientRevisionSign(revisionSign []byte) error {
+	return s.send(storage.ContractCreateClientRevisionSign, revisionSign)
+}
+
+// SendContractCreationHostSign sends the contract signed by the host to the client
+func (s *session) SendContractCreationHostSign(resp storage.ContractCreateResponse) error {
+	return s.send(storage.ContractCreateHostSign, resp)
+}
+
+// SendContractCreationHostRevisionSign sends the revision signed by the host to the client
+func (s *session) SendContractCreationHostRevisionSign(revisionSign []byte) error {
+	return s.send(storage.ContractCreateRevisionSign, revisionSign)
+}
+
+// RequestContractUpload requests the data upload from the storage host
+func (s *session) RequestContractUpload(req storage.UploadRequest) error {
+	return s.send(storage.ContractUploadReqMsg, req)
+}
+
+// SendContractUploadClientRevisionSign sends the upload revision signed by the client to the host
+func (s *session) SendContractUploadClientRevisionSign(revisionSign []byte) error {
+	return s.send(storage.ContractUploadClientRevisionSign, revisionSign)
+}
+
+// SendUploadHostRevisionSign sends the upload revision signed by the host to the client
+func (s *session) SendUploadHostRevisionSign(revisionSign []byte) error {
+	return s.send(storage.ContractUploadRevisionSign, revisionSign)
+}
+
+// RequestContractDownload requests the data download from the storage host
+func (s *session) RequestContractDownload(req storage.DownloadRequest) error {
+	return s.send(storage.ContractDownloadReqMsg, req)
+}
+
+// RequestContractDownloadBatch requests the data download of multiple sections from the storage host
+func (s *session) RequestContractDownloadBatch(req storage.DownloadBatchRequest) error {
+	return s.send(storage.ContractDownloadBatchReqMsg, req)
+}
+
+// RequestPublicRead requests the public sectors from the storage host
+func (s *session) RequestPublicRead(req storage.PublicReadRequest) error {
+	return s.send(storage.PublicReadReqMsg, req)
+}
+
+// RequestSectorCheck requests whether the sector is stored by the storage host
+func (s *session) RequestSectorCheck(req storage.SectorCheckRequest) error {
+	return s.send(storage.ContractSectorCheckReqMsg, req)
+}
+
+// SendSectorStored sends the response of the sector check to the client
+func (s *session) SendSectorStored(resp storage.SectorStoredResponse) error {
+	return s.send(storage.ContractSectorStoredMsg, resp)
+}
+
+// SendContractDownloadData sends the data downloaded to the client
+func (s *session) SendContractDownloadData(resp storage.DownloadResponse) error {
+	return s.send(storage.ContractDownloadDataMsg, resp)
+}
+
+// RequestContractRevision requests the latest revision of the contract from the storage host
+func (s *session) RequestContractRevision(req storage.ContractRevisionRequest) error {
+	return s.send(storage.ContractRevisionReqMsg, req)
+}
+
+// SendContractRevision sends the latest revision of the contract to the client
+func (s *session) SendContractRevision(rev types.StorageContractRevision) error {
+	return s.send(storage.ContractRevisionMsg, rev)
+}
+
+// SendContractDownloadBatchData sends the data of the download batch to the client
+func (s *session) SendContractDownloadBatchData(resp storage.DownloadBatchResponse) error {
+	return s.send(storage.ContractDownloadBatchDataMsg, resp)
+}
+
+// SendHostBusyHandleRequestErr sends the host busy error to the client
+func (s *session) SendHostBusyHandleRequestErr() error {
+	return s.send(storage.HostBusyHandleReqMsg, "error handling")
+}
+
+// SendClientNegotiateErrorMsg sends the client negotiate error to the host
+func (s *session) SendClientNegotiateErrorMsg() error {
+	return s.send(storage.ClientNegotiateErrorMsg, storage.ErrClientNegotiate.Error())
+}
+
+// SendClientCommitFailedMsg sends the client commit failed error to the host
+func (s *session) SendClientCommitFailedMsg() error {
+	return s.send(storage.ClientCommitFailedMsg, storage.ErrClientCommit.Error())
+}
+
+// SendClientCommitSuccessMsg sends the client commit success message to the host
+func (s *session) SendClientCommitSuccessMsg() error {
+	return s.send(storage.ClientCommitSuccessMsg, "commit success")
+}
+
+// SendHostCommitFailedMsg sends the host commit failed error to the client
+func (s *session) SendHostCommitFailedMsg() error {
+	return s.send(storage.HostCommitFailedMsg, storage.ErrHostCommit.Error())
+}
+
+// SendClientAckMsg sends the client ack message to the host
+func (s *session) SendClientAckMsg() error {
+	return s.send(storage.ClientAckMsg, "client ack")
+}
+
+// SendHostAckMsg sends the host ack message to the client
+func (s *session) SendHostAckMsg() error {
+	return s.send(storage.HostAckMsg, "host ack")
+}
+
+// SendHostNegotiateErrorMsg sends the host negotiate error to the client
+func (s *session) SendHostNegotiateErrorMsg() error {
+	return s.send(storage.HostNegotiateErrorMsg, storage.ErrHostNegotiate.Error())
+}
+
+// SendHostConfigChangedMsg sends the host config changed error to the client
+func (s *session) SendHostConfigChangedMsg() error {
+	return s.send(storage.HostConfigChangedMsg, storage.ErrHostConfigChanged.Error())
+}
+
+// SendHostFullMsg sends the host full error to the client
+func (s *session) SendHostFullMsg() error {
+	return s.send(storage.HostFullMsg, storage.ErrHostFull.Error())
+}
+
+// WaitConfigResp waits for the configuration response from the storage host
+func (s *session) WaitConfigResp() (p2p.Msg, error) {
+	return s.wait(s.configMsg)
+}
+
+// ClientWaitContractResp waits for the contract response from the storage host
+func (s *session) ClientWaitContractResp() (msg p2p.Msg, err error) {
+	return s.wait(s.contractMsg)
+}
+
+// HostWaitContractResp waits for the contract response from the storage client
+func (s *session) HostWaitContractResp() (msg p2p.Msg, err error) {
+	return s.wait(s.contractMsg)
+}
+
+// TryToRenewOrRevise will try to revise the contract. It fails if the contract is renewing
+func (s *session) TryToRenewOrRevise() bool {
+	s.conn.renewLock.Lock()
+	defer s.conn.renewLock.Unlock()
+
+	if s.conn.renewing {
+		return false
+	}
+	s.conn.revising++
+	return true
+}
+
+// RevisionOrRenewingDone indicates the revision operation has been finished
+func (s *session) RevisionOrRenewingDone() {
+	s.conn.renewLock.Lock()
+	defer s.conn.renewLock.Unlock()
+
+	if s.conn.revising > 0 {
+		s.conn.revising--
+	}
+}
+
+// TryRequestHostConfig checks if the client is currently requesting the host configuration
+func (s *session) TryRequestHostConfig() error {
+	select {
+	case s.conn.hostConfigRequesting <- struct{}{}:
+		return nil
+	default:
+		return storage.ErrRequestingHostConfig
+	}
+}
+
+// RequestHostConfigDone indicates the host configuration request is finished
+func (s *session) RequestHostConfigDone() {
+	select {
+	case <-s.conn.hostConfigRequesting:
+	default:
+	}
+}
+
+// PeerNode returns the node of the other side of the session
+func (s *session) PeerNode() *enode.Node {
+	return s.node
+}
+
+// IsStaticConn returns true, the in process connections are never dropped
+func (s *session) IsStaticConn() bool {
+	return true
+}
+
+// Close closes the session
+func (s *session) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+}
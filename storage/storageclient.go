@@ -59,6 +59,7 @@ type ClientBackend interface {
 	SendStorageContractCreateTx(clientAddr common.Address, input []byte) (common.Hash, error)
 	GetHostAnnouncementWithBlockHash(blockHash common.Hash) (hostAnnouncements []types.HostAnnouncement, number uint64, errGet error)
 	GetPaymentAddress() (common.Address, error)
+	GetBalance(address common.Address) (common.BigInt, error)
 	TryToRenewOrRevise(hostID enode.ID) bool
 	RevisionOrRenewingDone(hostID enode.ID)
 	CheckAndUpdateConnection(peerNode *enode.Node)
@@ -69,4 +70,7 @@ type ClientBackend interface {
 type DownloadParameters struct {
 	RemoteFilePath   string
 	WriteToLocalPath string
+
+	// Version is the version of the file overwritten to download. Zero means the current file
+	Version uint64
 }
@@ -8,6 +8,8 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/DxChainNetwork/godx/common/unit"
 
@@ -15,7 +17,9 @@ import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/contractmanager"
 	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
+	"github.com/DxChainNetwork/godx/storage/storageerr"
 )
 
 // ActiveContractsAPIDisplay is used to re-format the contract information that is going to
@@ -77,6 +81,24 @@ func (api *PublicStorageClientAPI) HostRank() (evaluation []storagehostmanager.S
 	return api.sc.storageHostManager.StorageHostRanks()
 }
 
+// PreviewHostSelection shows the storage hosts that would be selected under each host selection
+// strategy, with the rent payment of the current client settings updated by the settings given.
+// No contract is formed
+func (api *PublicStorageClientAPI) PreviewHostSelection(settings map[string]string) (map[string][]storagehostmanager.SelectedHost, error) {
+	setting, err := parseClientSetting(settings, api.sc.RetrieveClientSetting())
+	if err != nil {
+		return nil, fmt.Errorf("form data parsing failed: %s", err.Error())
+	}
+	return api.sc.storageHostManager.PreviewHostSelection(setting.RentPayment), nil
+}
+
+// SimulateEvaluation replays the storage host scans and interactions recorded before the cutoff,
+// and shows the storage hosts that would have been selected under the default and the weighted
+// evaluation, along with their uptime and failed interactions after the cutoff
+func (api *PublicStorageClientAPI) SimulateEvaluation(args storagehostmanager.SimulationArgs) (storagehostmanager.SimulationResult, error) {
+	return api.sc.storageHostManager.SimulateEvaluation(args)
+}
+
 // Contracts will retrieve all active contracts and display their general information
 func (api *PublicStorageClientAPI) Contracts() (activeContracts []ActiveContractsAPIDisplay) {
 	activeContracts = api.sc.ActiveContracts()
@@ -95,7 +117,7 @@ func (api *PublicStorageClientAPI) Contract(contractID string) (detail ContractM
 	// get the contract detail
 	contract, exists := api.sc.ContractDetail(convertContractID)
 	if !exists {
-		err = fmt.Errorf("the contract with %v does not exist", contractID)
+		err = storageerr.Errorf(storageerr.CodeContractNotFound, "the contract with %v does not exist", contractID)
 		return
 	}
 
@@ -105,11 +127,78 @@ func (api *PublicStorageClientAPI) Contract(contractID string) (detail ContractM
 	return
 }
 
+// ContractTranscript returns the negotiation transcript of the contract recorded and signed
+// by the storage client
+func (api *PublicStorageClientAPI) ContractTranscript(contractID string) ([]storage.TranscriptEntry, error) {
+	id, err := storage.StringToContractID(contractID)
+	if err != nil {
+		return nil, fmt.Errorf("the contract id provided is invalid: %s", err.Error())
+	}
+	transcript, exists, err := api.sc.ContractTranscript(id)
+	if !exists {
+		return nil, storageerr.Errorf(storageerr.CodeContractNotFound, "the contract with %v does not exist", contractID)
+	}
+	return transcript, err
+}
+
+// ContractFiles returns the dxfiles and segment indexes stored under the contract
+func (api *PublicStorageClientAPI) ContractFiles(contractID string) ([]contractmanager.FileSegments, error) {
+	id, err := storage.StringToContractID(contractID)
+	if err != nil {
+		return nil, fmt.Errorf("the contract id provided is invalid: %s", err.Error())
+	}
+	return api.sc.contractManager.ContractFiles(id), nil
+}
+
+// HostFiles returns the dxfiles and segment indexes stored on the host, which will be
+// lost if the host disappears
+func (api *PublicStorageClientAPI) HostFiles(id string) ([]contractmanager.FileSegments, error) {
+	var enodeid enode.ID
+
+	// convert the hex string back to the enode.ID type
+	idSlice, err := hex.DecodeString(id)
+	if err != nil {
+		return nil, errors.New("the hostID provided is not valid")
+	}
+	copy(enodeid[:], idSlice)
+
+	return api.sc.contractManager.HostFiles(enodeid), nil
+}
+
+// FileContracts returns the contracts storing the segments of the dxfile
+func (api *PublicStorageClientAPI) FileContracts(dxPath string) ([]storage.ContractID, error) {
+	path, err := storage.NewDxPath(dxPath)
+	if err != nil {
+		return nil, err
+	}
+	return api.sc.contractManager.FileContracts(path), nil
+}
+
+// UnreferencedSectors returns the sectors stored under the contract which are marked as
+// unreferenced by the sector garbage collection
+func (api *PublicStorageClientAPI) UnreferencedSectors(contractID string) ([]common.Hash, error) {
+	id, err := storage.StringToContractID(contractID)
+	if err != nil {
+		return nil, fmt.Errorf("the contract id provided is invalid: %s", err.Error())
+	}
+	return api.sc.contractManager.UnreferencedSectors(id), nil
+}
+
 // PaymentAddress get the account address used to sign the storage contract. If not configured, the first address in the local wallet will be used as the paymentAddress by default.
 func (api *PublicStorageClientAPI) PaymentAddress() (common.Address, error) {
 	return api.sc.GetPaymentAddress()
 }
 
+// FundReservations returns the balance of the payment address reserved for the storage contracts
+// being formed or renewed, and the balance still available for new contracts
+func (api *PublicStorageClientAPI) FundReservations() (contractmanager.FundReservationStatus, error) {
+	address, err := api.sc.GetPaymentAddress()
+	if err != nil {
+		return contractmanager.FundReservationStatus{}, err
+	}
+	return api.sc.contractManager.FundReservations(address)
+}
+
 // DownloadSync is used to download remote file by sync mode
 // NOTE: RPC not support async download, because it is stateless, should block until download task done.
 func (api *PublicStorageClientAPI) DownloadSync(remoteFilePath, localPath string) (string, error) {
@@ -127,6 +216,32 @@ func (api *PublicStorageClientAPI) DownloadSync(remoteFilePath, localPath string
 	return "File downloaded successfully", nil
 }
 
+// DownloadVersionSync is used to download the version of the remote file overwritten by sync mode
+func (api *PublicStorageClientAPI) DownloadVersionSync(remoteFilePath, localPath string, version uint64) (string, error) {
+	if version == 0 {
+		return "【ERROR】failed to download", errors.New("version id shall be positive")
+	}
+	p := storage.DownloadParameters{
+		WriteToLocalPath: localPath,
+		RemoteFilePath:   remoteFilePath,
+		Version:          version,
+	}
+	if err := api.sc.DownloadSync(p); err != nil {
+		return "【ERROR】failed to download", err
+	}
+	return "File version downloaded successfully", nil
+}
+
+// Downloads returns the pending downloads, which can be cancelled or re-prioritized by the id
+func (api *PublicStorageClientAPI) Downloads() []DownloadInfo {
+	return api.sc.PendingDownloads()
+}
+
+// Workers returns the workers of the storage client, including the cooldown state of each worker
+func (api *PublicStorageClientAPI) Workers() []WorkerInfo {
+	return api.sc.Workers()
+}
+
 // Upload their local files to hosts made contract with
 func (api *PublicStorageClientAPI) Upload(source string, dxPath string) (string, error) {
 	path, err := storage.NewDxPath(dxPath)
@@ -144,6 +259,30 @@ func (api *PublicStorageClientAPI) Upload(source string, dxPath string) (string,
 	return "success", nil
 }
 
+// UploadDir uploads all files within the local directory recursively, and returns the manifest
+// containing the relative path and hash of each uploaded file
+func (api *PublicStorageClientAPI) UploadDir(source string, dxPath string) (*DirManifest, error) {
+	path, err := storage.NewDxPath(dxPath)
+	if err != nil {
+		return nil, err
+	}
+	return api.sc.UploadDir(source, path)
+}
+
+// PublicReadSector reads the public sector with the merkle root from the host without a
+// contract, and saves the sector data to the local path
+func (api *PublicStorageClientAPI) PublicReadSector(enodeURL string, root common.Hash, localPath string) (string, error) {
+	file, err := os.OpenFile(localPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if err := api.sc.PublicReadSector(enodeURL, root, file); err != nil {
+		return "【ERROR】failed to read the public sector", err
+	}
+	return "Sector read successfully", nil
+}
+
 // GetRenewWindow return the renew window value
 func (api *PublicStorageClientAPI) GetRenewWindow() string {
 	return unit.FormatTime(storage.RenewWindow)
@@ -209,6 +348,117 @@ func (api *PrivateStorageClientAPI) PeriodCost() storage.PeriodCost {
 	return api.sc.contractManager.RetrievePeriodCost()
 }
 
+// CancelDownload cancels the pending download with the given id
+func (api *PrivateStorageClientAPI) CancelDownload(id string) (string, error) {
+	if err := api.sc.CancelDownload(id); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("download %s is cancelled", id), nil
+}
+
+// SetDownloadPriority changes the priority of the pending download with the given id. The download
+// with higher priority will be processed first
+func (api *PrivateStorageClientAPI) SetDownloadPriority(id string, priority uint64) (string, error) {
+	if err := api.sc.SetDownloadPriority(id, priority); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("the priority of download %s is set to %d", id, priority), nil
+}
+
+// AddWebhook adds the webhook the events are POSTed to. The events are the event types subscribed,
+// which are all events if empty. The request body is signed with the secret if not empty. The
+// file.health.low event is sent when the health of a file drops below the healthThreshold, which
+// is the repair health threshold if 0
+func (api *PrivateStorageClientAPI) AddWebhook(url string, secret string, events []string, healthThreshold uint32) (string, error) {
+	id, err := api.sc.webhooks.add(Webhook{
+		URL:             url,
+		Secret:          secret,
+		Events:          events,
+		HealthThreshold: healthThreshold,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("webhook %s is added", id), nil
+}
+
+// RemoveWebhook removes the webhook with the given id
+func (api *PrivateStorageClientAPI) RemoveWebhook(id string) (string, error) {
+	if err := api.sc.webhooks.remove(id); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("webhook %s is removed", id), nil
+}
+
+// Webhooks returns the webhooks added, with the secrets hidden
+func (api *PrivateStorageClientAPI) Webhooks() []Webhook {
+	return api.sc.webhooks.webhooks()
+}
+
+// PauseUploads pauses the uploads and repairs for the duration, e.g. 2h30m. The sectors being
+// uploaded are finished, and no more sectors are uploaded until resumed or the duration elapsed
+func (api *PrivateStorageClientAPI) PauseUploads(duration string) (string, error) {
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return "", fmt.Errorf("invalid duration %v: %s", duration, err.Error())
+	}
+	until, err := api.sc.PauseUploads(d)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("uploads and repairs are paused until %v", until.Format(time.RFC3339)), nil
+}
+
+// ResumeUploads resumes the uploads and repairs paused
+func (api *PrivateStorageClientAPI) ResumeUploads() string {
+	if !api.sc.ResumeUploads() {
+		return "uploads and repairs are not paused"
+	}
+	return "uploads and repairs are resumed"
+}
+
+// UnlockPersist unlocks the keyring sealing the secrets persisted by the storage client with the
+// passphrase. The files with the cipher keys sealed could not be uploaded or downloaded, and no
+// file or contract could be created while the keyring is locked
+func (api *PrivateStorageClientAPI) UnlockPersist(passphrase string) (string, error) {
+	if err := api.sc.UnlockPersist(passphrase); err != nil {
+		return "", err
+	}
+	return "the storage client keyring is unlocked", nil
+}
+
+// LockPersist locks the keyring, dropping the key from the memory
+func (api *PrivateStorageClientAPI) LockPersist() string {
+	api.sc.LockPersist()
+	return "the storage client keyring is locked"
+}
+
+// SetPersistPassphrase sets the passphrase sealing the secrets persisted by the storage client.
+// The old passphrase is ignored if the passphrase is set for the first time
+func (api *PrivateStorageClientAPI) SetPersistPassphrase(oldPassphrase, newPassphrase string) (string, error) {
+	if err := api.sc.SetPersistPassphrase(oldPassphrase, newPassphrase); err != nil {
+		return "", err
+	}
+	return "the storage client passphrase is set", nil
+}
+
+// CollectSectorGarbage runs a sector garbage collection pass, returning the number of sectors
+// marked as unreferenced under each contract. A sector is marked only if found unreferenced
+// in two consecutive passes
+func (api *PrivateStorageClientAPI) CollectSectorGarbage() (map[string]int, error) {
+	marked, err := api.sc.collectSectorGarbage()
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for id, roots := range marked {
+		if len(roots) != 0 {
+			counts[id.String()] = len(roots)
+		}
+	}
+	return counts, nil
+}
+
 // CancelAllContracts will cancel all contracts signed with storage client by
 // marking all active contracts as canceled, not good for uploading, and not good
 // for renewing
@@ -6,10 +6,13 @@ package storageclient
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/unit"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
 )
 
 // parseClientSetting will take client settings in a map format, where both key and value are strings. Then, those value will be parsed
@@ -75,6 +78,113 @@ func parseClientSetting(settings map[string]string, prevSetting storage.ClientSe
 			}
 			clientSetting.MaxDownloadSpeed = downloadSpeed
 
+		case key == "maxmemory":
+			var maxMemory uint64
+			maxMemory, err = unit.ParseStorage(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the max memory: %s", err.Error())
+				break
+			}
+			clientSetting.MaxMemory = maxMemory
+
+		case key == "uploadbatchsize":
+			var batchSize uint64
+			batchSize, err = unit.ParseStorage(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the upload batch size: %s", err.Error())
+				break
+			}
+			clientSetting.UploadBatchSize = batchSize
+
+		case key == "segmentcachesize":
+			var cacheSize uint64
+			cacheSize, err = unit.ParseStorage(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the segment cache size: %s", err.Error())
+				break
+			}
+			clientSetting.SegmentCacheSize = cacheSize
+
+		case key == "segmentcachedisksize":
+			var cacheSize uint64
+			cacheSize, err = unit.ParseStorage(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the segment cache disk size: %s", err.Error())
+				break
+			}
+			clientSetting.SegmentCacheDiskSize = cacheSize
+
+		case key == "verifyuploads":
+			var status bool
+			status, err = unit.ParseBool(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the upload verification: %s", err.Error())
+				break
+			}
+			clientSetting.VerifyUploads = status
+
+		case key == "hostfundratio":
+			var ratio float64
+			ratio, err = parseHostFundRatio(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the host fund ratio: %s", err.Error())
+				break
+			}
+			clientSetting.RentPayment.MaxHostFundRatio = ratio
+
+		case key == "preferregions":
+			var regions []string
+			regions, err = storage.ParseRegions(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the preferred regions: %s", err.Error())
+				break
+			}
+			clientSetting.RentPayment.PreferRegions = regions
+
+		case key == "requireregions":
+			var regions []string
+			regions, err = storage.ParseRegions(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the required regions: %s", err.Error())
+				break
+			}
+			clientSetting.RentPayment.RequireRegions = regions
+
+		case key == "evictionratio":
+			var ratio float64
+			ratio, err = parseHostFundRatio(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the eviction evaluation ratio: %s", err.Error())
+				break
+			}
+			clientSetting.RentPayment.EvictionEvalRatio = ratio
+
+		case key == "evictionhours":
+			var hours uint64
+			hours, err = unit.ParseUint64(value, 1, "h")
+			if err != nil {
+				err = fmt.Errorf("failed to parse the eviction offline hours: %s", err.Error())
+				break
+			}
+			clientSetting.RentPayment.EvictionOfflineHours = hours
+
+		case key == "mirror":
+			var status bool
+			status, err = unit.ParseBool(value)
+			if err != nil {
+				err = fmt.Errorf("failed to parse the mirror contracts: %s", err.Error())
+				break
+			}
+			clientSetting.RentPayment.MirrorContracts = status
+
+		case key == "hostselection":
+			strategy := strings.ToLower(strings.TrimSpace(value))
+			if _, err = storagehosttree.StrategyByName(strategy); err != nil {
+				err = fmt.Errorf("failed to parse the host selection: %s", err.Error())
+				break
+			}
+			clientSetting.RentPayment.HostSelection = strategy
+
 		default:
 			err = fmt.Errorf("the key entered: %s is not valid. Here is a list of available keys: %+v",
 				key, keys)
@@ -95,6 +205,19 @@ func parseStorageHosts(hosts string) (parsed uint64, err error) {
 	return unit.ParseUint64(hosts, 1, "")
 }
 
+// parseHostFundRatio will parse the string version of the max host fund ratio into float64 type.
+// The ratio must be within the range of [0, 1], where 0 means no limit. It is also used for the
+// eviction evaluation ratio, where 0 means disabled
+func parseHostFundRatio(ratio string) (parsed float64, err error) {
+	if parsed, err = strconv.ParseFloat(strings.TrimSpace(ratio), 64); err != nil {
+		return
+	}
+	if parsed < 0 || parsed > 1 {
+		err = fmt.Errorf("the ratio %v must be within the range of [0, 1]", parsed)
+	}
+	return
+}
+
 // clientSettingGetDefault will take the clientSetting and check if any filed in the RentPayment is zero
 // if so, set the value to default value
 func clientSettingGetDefault(setting storage.ClientSetting) (newSetting storage.ClientSetting) {
@@ -126,5 +249,13 @@ func clientSettingGetDefault(setting storage.ClientSetting) (newSetting storage.
 		setting.RentPayment.ExpectedRedundancy = storage.DefaultRentPayment.ExpectedRedundancy
 	}
 
+	if setting.MaxMemory == 0 {
+		setting.MaxMemory = DefaultMaxMemory
+	}
+
+	if setting.UploadBatchSize == 0 {
+		setting.UploadBatchSize = DefaultUploadBatchSize
+	}
+
 	return setting
 }
@@ -7,12 +7,14 @@ package storageclient
 import (
 	"fmt"
 	"math/rand"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/unit"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
 )
 
 func TestStorageClient_ParseClientSetting(t *testing.T) {
@@ -127,7 +129,7 @@ func randomValue(selectedKeys map[string]string) (settings map[string]string, er
 			value = rand.Uint64()
 			granularity = unit.TimeUnit[rand.Intn(len(unit.TimeUnit))]
 			break
-		case key == "violation":
+		case key == "violation" || key == "mirror":
 			value = rand.Intn(2) == 0
 			granularity = ""
 			break
@@ -139,6 +141,38 @@ func randomValue(selectedKeys map[string]string) (settings map[string]string, er
 			value = rand.Int63()
 			granularity = unit.SpeedUnit[rand.Intn(len(unit.SpeedUnit))]
 			break
+		case key == "maxmemory":
+			value = rand.Uint32()
+			granularity = "mb"
+			break
+		case key == "uploadbatchsize":
+			value = 4 + rand.Intn(5)
+			granularity = "mib"
+			break
+		case key == "segmentcachesize" || key == "segmentcachedisksize":
+			value = rand.Uint32()
+			granularity = "mib"
+			break
+		case key == "hostfundratio":
+			value = rand.Float64()
+			granularity = ""
+			break
+		case key == "evictionratio":
+			value = rand.Float64()
+			granularity = ""
+			break
+		case key == "evictionhours":
+			value = rand.Uint32()
+			granularity = "h"
+			break
+		case key == "hostselection":
+			value = storagehosttree.StrategyNames()[rand.Intn(len(storagehosttree.StrategyNames()))]
+			granularity = ""
+			break
+		case key == "preferregions" || key == "requireregions":
+			value = "us-east,EU-West"
+			granularity = ""
+			break
 		default:
 			err = fmt.Errorf("the key received is not valid: %s", key)
 			return
@@ -181,6 +215,39 @@ func clientSettingValidation(key string, prevSetting storage.ClientSetting, curr
 	case "downloadspeed":
 		valid = currentSetting.MaxDownloadSpeed == prevSetting.MaxDownloadSpeed
 		return
+	case "maxmemory":
+		valid = currentSetting.MaxMemory == prevSetting.MaxMemory
+		return
+	case "uploadbatchsize":
+		valid = currentSetting.UploadBatchSize == prevSetting.UploadBatchSize
+		return
+	case "segmentcachesize":
+		valid = currentSetting.SegmentCacheSize == prevSetting.SegmentCacheSize
+		return
+	case "segmentcachedisksize":
+		valid = currentSetting.SegmentCacheDiskSize == prevSetting.SegmentCacheDiskSize
+		return
+	case "hostfundratio":
+		valid = currentSetting.RentPayment.MaxHostFundRatio == prevSetting.RentPayment.MaxHostFundRatio
+		return
+	case "evictionratio":
+		valid = currentSetting.RentPayment.EvictionEvalRatio == prevSetting.RentPayment.EvictionEvalRatio
+		return
+	case "evictionhours":
+		valid = currentSetting.RentPayment.EvictionOfflineHours == prevSetting.RentPayment.EvictionOfflineHours
+		return
+	case "mirror":
+		valid = currentSetting.RentPayment.MirrorContracts == prevSetting.RentPayment.MirrorContracts
+		return
+	case "hostselection":
+		valid = currentSetting.RentPayment.HostSelection == prevSetting.RentPayment.HostSelection
+		return
+	case "preferregions":
+		valid = reflect.DeepEqual(currentSetting.RentPayment.PreferRegions, prevSetting.RentPayment.PreferRegions)
+		return
+	case "requireregions":
+		valid = reflect.DeepEqual(currentSetting.RentPayment.RequireRegions, prevSetting.RentPayment.RequireRegions)
+		return
 	default:
 		err = fmt.Errorf("the provided key is invalid: %s", key)
 		return
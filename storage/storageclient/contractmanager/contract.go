@@ -136,6 +136,10 @@ func (cm *ContractManager) maintainExpiration() {
 		expired := currentBh > contract.EndHeight
 		// update the expired contract list
 		if expired || renewed {
+			// the data stored under the contract expired without being renewed is lost
+			if !renewed {
+				cm.fileIndex.lose(contract.ID)
+			}
 			cm.updateExpiredContracts(contract)
 			expiredContractsIDs = append(expiredContractsIDs, contract.ID)
 			expiredContracts = append(expiredContracts, contract)
@@ -316,6 +320,12 @@ func (cm *ContractManager) maintainContractStatus(hostsAmount int) (err error) {
 	// update the contract status
 	for _, contract := range cm.activeContracts.RetrieveAllContractsMetaData() {
 		newStatus := cm.checkContractStatus(contract, evalBaseline)
+
+		// the contract with the evicted host is no longer used for upload and renew
+		if cm.checkEviction(contract, evalBaseline) {
+			newStatus.UploadAbility = false
+			newStatus.RenewAbility = false
+		}
 		if err = cm.updateContractStatus(contract.ID, newStatus); err != nil {
 			return
 		}
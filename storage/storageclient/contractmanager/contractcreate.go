@@ -5,6 +5,7 @@
 package contractmanager
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/internal/ethapi"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/rlp"
@@ -104,6 +106,13 @@ func (cm *ContractManager) createContract(host storage.HostInfo, contractFund co
 		return
 	}
 
+	// validate the fund committed to the storage host operator
+	if err = cm.checkHostFundCap(host, contractFund, rentPayment, storage.ContractID{}); err != nil {
+		formCost = common.BigInt0
+		err = fmt.Errorf("failed to create the contract with host: %v, %s", host.EnodeID, err.Error())
+		return
+	}
+
 	// 2. form the contract create parameters
 	// The reason to get the newest blockHeight here is that during the checking time period
 	// many blocks may be generated already, which is unfair to the storage client.
@@ -156,7 +165,8 @@ func (cm *ContractManager) createContract(host storage.HostInfo, contractFund co
 	return
 }
 
-// randomHostsForContractForm will randomly retrieve some storage hosts from the storage host pool
+// randomHostsForContractForm will retrieve some storage hosts from the storage host pool with the
+// host selection strategy of the rent payment
 func (cm *ContractManager) randomHostsForContractForm(neededContracts int) (randomHosts []storage.HostInfo, err error) {
 	// for all active contracts, the storage host will be added to be blacklist
 	// for all active contracts which are not canceled, good for uploading, and renewing
@@ -176,8 +186,22 @@ func (cm *ContractManager) randomHostsForContractForm(neededContracts int) (rand
 	}
 	cm.lock.RUnlock()
 
-	// randomly retrieve some hosts
-	return cm.hostManager.RetrieveRandomHosts(neededContracts*randomStorageHostsFactor+randomStorageHostsBackup, blackList, addressBlackList)
+	// retrieve some hosts with the host selection strategy
+	return cm.hostManager.RetrieveHosts(neededContracts*randomStorageHostsFactor+randomStorageHostsBackup, blackList, addressBlackList)
+}
+
+// checkProtocolVersion checks the storage host is on the storage protocol version active at
+// the current block height, the contracts are only negotiated on the same version
+func (cm *ContractManager) checkProtocolVersion(config storage.HostExtConfig) error {
+	cm.lock.RLock()
+	blockHeight := cm.blockHeight
+	cm.lock.RUnlock()
+
+	pp, err := storage.ActiveProtocolParams(cm.b.ChainConfig(), blockHeight)
+	if err != nil {
+		return err
+	}
+	return storage.CheckProtocolVersion(config.ProtocolVersion, pp.Version)
 }
 
 // ContractCreate will try to create the contract with the storage host manager provided
@@ -185,6 +209,17 @@ func (cm *ContractManager) randomHostsForContractForm(neededContracts int) (rand
 func (cm *ContractManager) ContractCreate(params storage.ContractParams) (md storage.ContractMetaData, err error) {
 	rentPayment, funding, clientPaymentAddress, startHeight, endHeight, host := params.RentPayment, params.Funding, params.ClientPaymentAddress, params.StartHeight, params.EndHeight, params.Host
 
+	// get the host config used in negotiation, its hash is carried in the request so that the
+	// host could reject the negotiation if the config has been changed
+	config, configHash, err := cm.hostManager.HostConfig(host)
+	if err != nil {
+		return storage.ContractMetaData{}, fmt.Errorf("failed to get the storage host config: %s", err.Error())
+	}
+	if err = cm.checkProtocolVersion(config); err != nil {
+		return storage.ContractMetaData{}, err
+	}
+	host.HostExtConfig = config
+
 	// Calculate the payouts for the client, host, and whole contract
 	period := endHeight - startHeight
 	expectedStorage := rentPayment.ExpectedStorage / rentPayment.StorageHosts
@@ -229,12 +264,25 @@ func (cm *ContractManager) ContractCreate(params storage.ContractParams) (md sto
 		return storage.ContractMetaData{}, storagehost.ExtendErr("find client account error", err)
 	}
 
+	// reserve the client collateral and the transaction fee against the balance, so that the
+	// contracts formed concurrently will not spend the same funds
+	reservationID := storage.ContractID(storageContract.ID())
+	if err = cm.reserveFunds(reservationID, clientPaymentAddress, clientPayout.Add(cm.contractTxFeeEstimate())); err != nil {
+		return storage.ContractMetaData{}, err
+	}
+	defer func() {
+		if err != nil {
+			cm.releaseUnsentFunds(reservationID)
+		}
+	}()
+
 	// set up the connection with the storage host and remove the operation once done
 	sp, err := cm.b.SetupConnection(host.EnodeURL)
 	if err != nil {
 		cm.log.Error("contract create failed, failed to set up connection", "err", err)
 		return storage.ContractMetaData{}, storagehost.ExtendErr("setup connection failed while creating the contract", err)
 	}
+	defer sp.Close()
 
 	// Increase Successful/Failed interactions accordingly
 	// Ignore the send negotiate network error, we expect that client will wait for host
@@ -270,6 +318,7 @@ func (cm *ContractManager) ContractCreate(params storage.ContractParams) (md sto
 		StorageContract: storageContract,
 		Sign:            clientContractSign,
 		Renew:           false,
+		HostConfigHash:  configHash,
 	}
 
 	if err := sp.RequestContractCreation(req); err != nil {
@@ -278,7 +327,7 @@ func (cm *ContractManager) ContractCreate(params storage.ContractParams) (md sto
 		return storage.ContractMetaData{}, err
 	}
 
-	var hostSign []byte
+	var resp storage.ContractCreateResponse
 	msg, err := sp.ClientWaitContractResp()
 	if err != nil {
 		err = fmt.Errorf("contract create read message error: %s", err.Error())
@@ -291,18 +340,26 @@ func (cm *ContractManager) ContractCreate(params storage.ContractParams) (md sto
 		return storage.ContractMetaData{}, storage.ErrHostBusyHandleReq
 	}
 
+	// the host config used is outdated, refresh it before the next negotiation
+	if msg.Code == storage.HostConfigChangedMsg {
+		cm.hostManager.InvalidateHostConfig(host.EnodeID)
+		return storage.ContractMetaData{}, storage.ErrHostConfigChanged
+	}
+
 	// if host send some negotiation error, client should handler it
 	if msg.Code == storage.HostNegotiateErrorMsg {
 		hostNegotiateErr = storage.ErrHostNegotiate
 		return storage.ContractMetaData{}, hostNegotiateErr
 	}
 
-	if err := msg.Decode(&hostSign); err != nil {
+	if err := msg.Decode(&resp); err != nil {
 		hostNegotiateErr = fmt.Errorf("failed to decode host signature: %s", err.Error())
 		return storage.ContractMetaData{}, hostNegotiateErr
 
 	}
-	storageContract.Signatures = [][]byte{clientContractSign, hostSign}
+	storageContract.Signatures = [][]byte{clientContractSign, resp.Sign}
+	cost := resp.Cost
+	cost.TxFeeEstimate = cm.contractTxFeeEstimate()
 
 	// Assemble init revision and sign it
 	storageContractRevision := types.StorageContractRevision{
@@ -354,10 +411,12 @@ func (cm *ContractManager) ContractCreate(params storage.ContractParams) (md sto
 		return storage.ContractMetaData{}, clientNegotiateErr
 	}
 
-	if _, err := cm.b.SendStorageContractCreateTx(clientPaymentAddress, scBytes); err != nil {
+	txHash, err := cm.b.SendStorageContractCreateTx(clientPaymentAddress, scBytes)
+	if err != nil {
 		clientNegotiateErr = storagehost.ExtendErr("Send storage contract creation transaction error", err)
 		return storage.ContractMetaData{}, clientNegotiateErr
 	}
+	cm.fundsSent(reservationID, txHash)
 
 	pubKey, err := crypto.UnmarshalPubkey(host.NodePubKey)
 	if err != nil {
@@ -372,7 +431,9 @@ func (cm *ContractManager) ContractCreate(params storage.ContractParams) (md sto
 		EnodeID:                PubkeyToEnodeID(pubKey),
 		StartHeight:            startHeight,
 		TotalCost:              funding,
+		GasFee:                 cost.TxFeeEstimate,
 		ContractFee:            host.ContractPrice,
+		CostBreakdown:          cost,
 		LatestContractRevision: storageContractRevision,
 		Status: storage.ContractStatus{
 			UploadAbility: true,
@@ -424,6 +485,17 @@ func (cm *ContractManager) ContractCreate(params storage.ContractParams) (md sto
 	}
 }
 
+// contractTxFeeEstimate estimates the fee of the contract create transaction with the suggested
+// gas price. Zero is returned if the gas price is not available
+func (cm *ContractManager) contractTxFeeEstimate() common.BigInt {
+	price, err := cm.b.SuggestPrice(context.Background())
+	if err != nil {
+		cm.log.Warn("failed to get the suggested gas price", "err", err)
+		return common.BigInt0
+	}
+	return common.PtrBigInt(price).MultUint64(ethapi.StorageContractTxGas)
+}
+
 func rollbackContractSet(contractSet *contractset.StorageContractSet, id storage.ContractID) error {
 	if c, exist := contractSet.Acquire(id); exist {
 		if err := contractSet.Delete(c); err != nil {
@@ -9,10 +9,13 @@ import (
 	"os"
 	"sync"
 
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/event"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/contractset"
+	"github.com/DxChainNetwork/godx/storage/storageclient/keyring"
 	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
 )
 
@@ -43,12 +46,25 @@ type ContractManager struct {
 	// hostID to contractID mapping
 	hostToContract map[enode.ID]storage.ContractID
 
+	// storage hosts evicted by the eviction policy of the rent payment
+	evictedHosts map[enode.ID]struct{}
+
+	// index between the contracts and the dxfile segments stored under them, which is
+	// created by New and must be set by any other construction of the contract manager
+	fileIndex *fileIndex
+
+	// sectors stored under the contracts but no longer referenced by any dxfile
+	unreferencedSectors map[storage.ContractID][]common.Hash
+
 	// contract renew related, where renewed from connect [new] -> old
 	// and renewed to connect [old] -> new
 	renewedFrom      map[storage.ContractID]storage.ContractID
 	renewedTo        map[storage.ContractID]storage.ContractID
 	failedRenewCount map[storage.ContractID]uint64
 
+	// renewFeed sends the ContractRenewEvent for each contract renewed
+	renewFeed event.Feed
+
 	// used to acquire storage contract
 	blockHeight   uint64
 	currentPeriod uint64
@@ -56,6 +72,10 @@ type ContractManager struct {
 	// storage client period cost
 	periodCost storage.PeriodCost
 
+	// balance reserved for the contracts being formed or renewed
+	fundReservations map[storage.ContractID]*FundReservation
+	reservationLock  sync.Mutex
+
 	// utils
 	log  log.Logger
 	lock sync.RWMutex
@@ -63,8 +83,9 @@ type ContractManager struct {
 	quit chan struct{}
 }
 
-// New will initialize the ContractManager object, which is used for contract maintenance
-func New(persistDir string, hm *storagehostmanager.StorageHostManager) (cm *ContractManager, err error) {
+// New will initialize the ContractManager object, which is used for contract maintenance. The
+// keyring seals the contract secrets persisted
+func New(persistDir string, hm *storagehostmanager.StorageHostManager, kr *keyring.Keyring) (cm *ContractManager, err error) {
 	// contract manager initialization
 	cm = &ContractManager{
 		persistDir:       persistDir,
@@ -75,14 +96,19 @@ func New(persistDir string, hm *storagehostmanager.StorageHostManager) (cm *Cont
 		renewedTo:        make(map[storage.ContractID]storage.ContractID),
 		failedRenewCount: make(map[storage.ContractID]uint64),
 		hostToContract:   make(map[enode.ID]storage.ContractID),
+		evictedHosts:     make(map[enode.ID]struct{}),
+		fileIndex:        newFileIndex(),
+		fundReservations: make(map[storage.ContractID]*FundReservation),
 		quit:             make(chan struct{}),
+
+		unreferencedSectors: make(map[storage.ContractID][]common.Hash),
 	}
 
 	// initialize log
 	cm.log = log.New("module", "contract manager")
 
 	// initialize contract set
-	cs, err := contractset.New(persistDir)
+	cs, err := contractset.New(persistDir, kr)
 	if err != nil {
 		err = fmt.Errorf("error initialize contract set: %s", err.Error())
 		return
@@ -166,6 +192,16 @@ func (cm *ContractManager) RetrieveActiveContracts() (cms []storage.ContractMeta
 	return cm.activeContracts.RetrieveAllContractsMetaData()
 }
 
+// RetrieveExpiredContracts will return the contracts expired or renewed
+func (cm *ContractManager) RetrieveExpiredContracts() (cms []storage.ContractMetaData) {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+	for _, contract := range cm.expiredContracts {
+		cms = append(cms, contract)
+	}
+	return
+}
+
 // RetrieveActiveContract will return the contract meta data based on the contract id provided
 func (cm *ContractManager) RetrieveActiveContract(contractID storage.ContractID) (contract storage.ContractMetaData, exists bool) {
 	return cm.activeContracts.RetrieveContractMetaData(contractID)
@@ -206,7 +242,7 @@ func (cm *ContractManager) HostHealthMapByID(hostIDs []enode.ID) (infoTable stor
 
 		// save the information into HostHealthInfo table
 		infoTable[id] = storage.HostHealthInfo{
-			Offline:      isOffline(info),
+			Offline:      isOffline(info) || cm.isEvicted(id),
 			GoodForRenew: contract.Status.RenewAbility,
 		}
 	}
@@ -226,7 +262,7 @@ func (cm *ContractManager) HostHealthMap() (infoTable storage.HostHealthInfoTabl
 
 		// save the information into HostHealthInfo Table
 		infoTable[contract.EnodeID] = storage.HostHealthInfo{
-			Offline:      isOffline(info),
+			Offline:      isOffline(info) || cm.isEvicted(contract.EnodeID),
 			GoodForRenew: contract.Status.RenewAbility,
 		}
 	}
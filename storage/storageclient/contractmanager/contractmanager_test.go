@@ -84,10 +84,12 @@ func newContractManagerTest(hm *storagehostmanager.StorageHostManager) (cm *Cont
 		renewedTo:        make(map[storage.ContractID]storage.ContractID),
 		failedRenewCount: make(map[storage.ContractID]uint64),
 		hostToContract:   make(map[enode.ID]storage.ContractID),
+		fundReservations: make(map[storage.ContractID]*FundReservation),
+		fileIndex:        newFileIndex(),
 		quit:             make(chan struct{}),
 		log:              log.New(),
 	}
-	cs, err := contractset.New("test")
+	cs, err := contractset.New("test", nil)
 	if err != nil {
 		err = fmt.Errorf("failed to create contract set: %s", err.Error())
 		return
@@ -105,14 +107,16 @@ func newContractManagerTest(hm *storagehostmanager.StorageHostManager) (cm *Cont
 }
 
 func newStorageHostManagerTest() (shm *storagehostmanager.StorageHostManager, err error) {
-	shm = storagehostmanager.New("test")
+	shm = storagehostmanager.New("test", nil)
 	if err = shm.Start(&storageClientBackendContractManager{}); err != nil {
 		return
 	}
 	return
 }
 
-type storageClientBackendContractManager struct{}
+type storageClientBackendContractManager struct {
+	balance common.BigInt
+}
 
 func (st *storageClientBackendContractManager) Online() bool {
 	return true
@@ -186,6 +190,10 @@ func (st *storageClientBackendContractManager) GetPaymentAddress() (address comm
 	return
 }
 
+func (st *storageClientBackendContractManager) GetBalance(address common.Address) (common.BigInt, error) {
+	return st.balance, nil
+}
+
 func (st *storageClientBackendContractManager) TryToRenewOrRevise(hostID enode.ID) bool {
 	return false
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/DxChainNetwork/godx/common/math"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/event"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/rlp"
 	"github.com/DxChainNetwork/godx/storage"
@@ -23,6 +24,13 @@ import (
 	dberrors "github.com/syndtr/goleveldb/leveldb/errors"
 )
 
+// ContractRenewEvent is the event sent when a contract is renewed
+type ContractRenewEvent struct {
+	OldContractID storage.ContractID
+	NewContractID storage.ContractID
+	HostID        enode.ID
+}
+
 // checkForContractRenew will loop through all active contracts and filter out those needs to be renewed.
 // There are two types of contract needs to be renewed
 // 		1. contracts that are about to expired. they need to be renewed
@@ -50,6 +58,12 @@ func (cm *ContractManager) checkForContractRenew(rentPayment storage.RentPayment
 		// for contract that is about to expire, it will be added to the priorityRenews
 		// calculate the renewCostEstimation and update the priorityRenews
 		if currentBlockHeight+storage.RenewWindow >= contract.EndHeight {
+			// the contract storing only the sectors of the deleted files is left to expire,
+			// instead of paying for the storage of the garbage in the renewed contract
+			if cm.allSectorsUnreferenced(contract) {
+				cm.log.Info("contract storing no referenced sector will not be renewed", "contractID", contract.ID)
+				continue
+			}
 			estimateContractRenewCost := cm.renewCostEstimation(host, contract, currentBlockHeight, rentPayment)
 			closeToExpireRenews = append(closeToExpireRenews, contractRenewRecord{
 				id:   contract.ID,
@@ -245,6 +259,9 @@ func (cm *ContractManager) contractRenewStart(record contractRenewRecord, curren
 	cm.expiredContracts[oldContract.Metadata().ID] = oldContract.Metadata()
 	cm.lock.Unlock()
 
+	// the data stored under the old contract is carried to the renewed contract
+	cm.fileIndex.migrate(oldContract.Metadata().ID, renewedContract.ID)
+
 	// save the information persistently
 	if err = cm.saveSettings(); err != nil {
 		cm.log.Error("failed to save the settings persistently", "err", err.Error())
@@ -255,10 +272,21 @@ func (cm *ContractManager) contractRenewStart(record contractRenewRecord, curren
 		cm.log.Error("failed to delete the contract from the active oldContract list after renew", "err", err.Error())
 	}
 
+	cm.renewFeed.Send(ContractRenewEvent{
+		OldContractID: renewContractID,
+		NewContractID: renewedContract.ID,
+		HostID:        renewedContract.EnodeID,
+	})
+
 	err = nil
 	return
 }
 
+// SubscribeContractRenewEvent subscribes the events of the contracts renewed
+func (cm *ContractManager) SubscribeContractRenewEvent(ch chan<- ContractRenewEvent) event.Subscription {
+	return cm.renewFeed.Subscribe(ch)
+}
+
 // renew will start to perform the contract renew operation:
 // 		1. contract renewAbility validation
 // 		2. storage host validation
@@ -289,6 +317,8 @@ func (cm *ContractManager) renew(renewContract *contractset.Contract, rentPaymen
 	} else if host.MaxDuration < rentPayment.Period {
 		err = fmt.Errorf("the max duration cannot be smaller than the storage contract period")
 		return
+	} else if err = cm.checkHostFundCap(host, contractFund, rentPayment, contractMeta.ID); err != nil {
+		return
 	}
 
 	// validate the storage host max deposit
@@ -386,6 +416,17 @@ func (cm *ContractManager) ContractRenew(oldContract *contractset.Contract, para
 	// Extract vars from params, for convenience
 	rentPayment, funding, startHeight, endHeight, host := params.RentPayment, params.Funding, params.StartHeight, params.EndHeight, params.Host
 
+	// get the host config used in negotiation, its hash is carried in the request so that the
+	// host could reject the negotiation if the config has been changed
+	config, configHash, err := cm.hostManager.HostConfig(host)
+	if err != nil {
+		return storage.ContractMetaData{}, fmt.Errorf("failed to get the storage host config: %s", err.Error())
+	}
+	if err = cm.checkProtocolVersion(config); err != nil {
+		return storage.ContractMetaData{}, err
+	}
+	host.HostExtConfig = config
+
 	var basePrice, baseCollateral common.BigInt
 	if endHeight+host.WindowSize > lastRev.NewWindowEnd {
 		timeExtension := uint64(endHeight+host.WindowSize) - lastRev.NewWindowEnd
@@ -438,12 +479,24 @@ func (cm *ContractManager) ContractRenew(oldContract *contractset.Contract, para
 		return storage.ContractMetaData{}, storagehost.ExtendErr("find client account error", err)
 	}
 
+	// reserve the client collateral and the transaction fee against the balance
+	reservationID := storage.ContractID(storageContract.ID())
+	if err = cm.reserveFunds(reservationID, clientAddr, clientPayout.Add(cm.contractTxFeeEstimate())); err != nil {
+		return storage.ContractMetaData{}, err
+	}
+	defer func() {
+		if err != nil {
+			cm.releaseUnsentFunds(reservationID)
+		}
+	}()
+
 	// Setup connection with storage host
 	sp, err := cm.b.SetupConnection(host.EnodeURL)
 	if err != nil {
 		cm.log.Error("contract create failed, failed to set up connection", "err", err.Error())
 		return storage.ContractMetaData{}, storagehost.ExtendErr("setup connection with host failed", err)
 	}
+	defer sp.Close()
 
 	// Increase Successful/Failed interactions accordingly
 	var clientNegotiateErr, hostNegotiateErr, hostCommitErr error
@@ -478,13 +531,14 @@ func (cm *ContractManager) ContractRenew(oldContract *contractset.Contract, para
 		Sign:            clientContractSign,
 		Renew:           true,
 		OldContractID:   lastRev.ParentID,
+		HostConfigHash:  configHash,
 	}
 
 	if err := sp.RequestContractCreation(req); err != nil {
 		return storage.ContractMetaData{}, err
 	}
 
-	var hostSign []byte
+	var resp storage.ContractCreateResponse
 	msg, err := sp.ClientWaitContractResp()
 	if err != nil {
 		return storage.ContractMetaData{}, err
@@ -496,18 +550,26 @@ func (cm *ContractManager) ContractRenew(oldContract *contractset.Contract, para
 		return storage.ContractMetaData{}, storage.ErrHostBusyHandleReq
 	}
 
+	// the host config used is outdated, refresh it before the next negotiation
+	if msg.Code == storage.HostConfigChangedMsg {
+		cm.hostManager.InvalidateHostConfig(host.EnodeID)
+		return storage.ContractMetaData{}, storage.ErrHostConfigChanged
+	}
+
 	// if host send some negotiation error, client should handler it
 	if msg.Code == storage.HostNegotiateErrorMsg {
 		hostNegotiateErr = storage.ErrHostNegotiate
 		return storage.ContractMetaData{}, hostNegotiateErr
 	}
 
-	if err := msg.Decode(&hostSign); err != nil {
+	if err := msg.Decode(&resp); err != nil {
 		hostNegotiateErr = err
 		return storage.ContractMetaData{}, err
 	}
 
-	storageContract.Signatures = [][]byte{clientContractSign, hostSign}
+	storageContract.Signatures = [][]byte{clientContractSign, resp.Sign}
+	cost := resp.Cost
+	cost.TxFeeEstimate = cm.contractTxFeeEstimate()
 
 	// Assemble init revision and sign it
 	storageContractRevision := types.StorageContractRevision{
@@ -557,10 +619,12 @@ func (cm *ContractManager) ContractRenew(oldContract *contractset.Contract, para
 		return storage.ContractMetaData{}, err
 	}
 
-	if _, err := cm.b.SendStorageContractCreateTx(clientAddr, scBytes); err != nil {
+	txHash, err := cm.b.SendStorageContractCreateTx(clientAddr, scBytes)
+	if err != nil {
 		clientNegotiateErr = storagehost.ExtendErr("Send storage contract creation transaction error", err)
 		return storage.ContractMetaData{}, clientNegotiateErr
 	}
+	cm.fundsSent(reservationID, txHash)
 
 	pubKey, err := crypto.UnmarshalPubkey(host.NodePubKey)
 	if err != nil {
@@ -575,12 +639,15 @@ func (cm *ContractManager) ContractRenew(oldContract *contractset.Contract, para
 		EnodeID:                PubkeyToEnodeID(pubKey),
 		StartHeight:            startHeight,
 		TotalCost:              funding,
+		GasFee:                 cost.TxFeeEstimate,
 		ContractFee:            host.ContractPrice,
+		CostBreakdown:          cost,
 		LatestContractRevision: storageContractRevision,
 		Status: storage.ContractStatus{
 			UploadAbility: true,
 			RenewAbility:  true,
 		},
+		MirrorOf: oldContract.Header().MirrorOf,
 	}
 
 	oldRoots, err := oldContract.MerkleRoots()
@@ -40,6 +40,10 @@ const (
 
 	// if a contract failed to renew for 12 times, consider to replace the contract
 	consecutiveRenewFailsBeforeReplacement = 12
+
+	// fundReservationExpiry is the number of blocks the balance is reserved for a contract
+	// whose transaction is not seen on chain, after which the reservation is released
+	fundReservationExpiry = uint64(30)
 )
 
 // rentPayment related constants
@@ -0,0 +1,75 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// checkEviction checks the storage host signed the contract with against the eviction policy
+// of the rent payment, and returns whether the host is evicted. Once the host is evicted, the
+// files stored under the contract are scheduled for repair, so that the data is moved onto
+// better hosts before the downloads start failing
+func (cm *ContractManager) checkEviction(contract storage.ContractMetaData, evalBaseline int64) (evicted bool) {
+	host, exists := cm.hostManager.RetrieveHostInfo(contract.EnodeID)
+	if !exists {
+		return false
+	}
+
+	cm.lock.Lock()
+	rent := cm.rentPayment
+	evicted = shouldEvict(rent, host, cm.hostManager.Evaluate(host), evalBaseline, time.Now())
+	_, prevEvicted := cm.evictedHosts[contract.EnodeID]
+	if evicted {
+		cm.evictedHosts[contract.EnodeID] = struct{}{}
+	} else {
+		delete(cm.evictedHosts, contract.EnodeID)
+	}
+	cm.lock.Unlock()
+
+	if evicted && !prevEvicted {
+		cm.log.Info("storage host evicted, repairing the data stored on it", "hostID", contract.EnodeID, "contractID", contract.ID)
+		cm.fileIndex.scheduleRepair(contract.ID)
+	}
+	return
+}
+
+// isEvicted checks whether the storage host has been evicted
+func (cm *ContractManager) isEvicted(hostID enode.ID) bool {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	_, evicted := cm.evictedHosts[hostID]
+	return evicted
+}
+
+// shouldEvict checks whether the storage host should be evicted, which happens if the evaluation
+// of the host drops below the ratio of the evaluation baseline, or the host has been failing the
+// scans for longer than the offline hours
+func shouldEvict(rent storage.RentPayment, host storage.HostInfo, eval int64, evalBaseline int64, now time.Time) bool {
+	if rent.EvictionEvalRatio > 0 && evalBaseline > 0 && float64(eval) < float64(evalBaseline)*rent.EvictionEvalRatio {
+		return true
+	}
+	if rent.EvictionOfflineHours > 0 && offlineDuration(host, now) >= time.Duration(rent.EvictionOfflineHours)*time.Hour {
+		return true
+	}
+	return false
+}
+
+// offlineDuration returns how long the storage host has been failing the scans, counted from
+// the first failed scan after the latest successful one
+func offlineDuration(host storage.HostInfo, now time.Time) time.Duration {
+	i := len(host.ScanRecords)
+	for i > 0 && !host.ScanRecords[i-1].Success {
+		i--
+	}
+	if i == len(host.ScanRecords) {
+		return 0
+	}
+	return now.Sub(host.ScanRecords[i].Timestamp)
+}
@@ -0,0 +1,49 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package contractmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestShouldEvict(t *testing.T) {
+	now := time.Now()
+	scans := func(offline ...time.Duration) []storage.HostPoolScan {
+		records := []storage.HostPoolScan{{Timestamp: now.Add(-48 * time.Hour), Success: true}}
+		for _, d := range offline {
+			records = append(records, storage.HostPoolScan{Timestamp: now.Add(-d)})
+		}
+		return records
+	}
+
+	var tables = []struct {
+		rent         storage.RentPayment
+		scans        []storage.HostPoolScan
+		eval         int64
+		evalBaseline int64
+		evict        bool
+	}{
+		// eviction disabled
+		{storage.RentPayment{}, scans(30*time.Hour, time.Hour), 1, 100, false},
+		// evaluation dropped below the ratio of the baseline
+		{storage.RentPayment{EvictionEvalRatio: 0.5}, scans(), 49, 100, true},
+		{storage.RentPayment{EvictionEvalRatio: 0.5}, scans(), 50, 100, false},
+		{storage.RentPayment{EvictionEvalRatio: 0.5}, scans(), 1, 0, false},
+		// failed the scans for the hours
+		{storage.RentPayment{EvictionOfflineHours: 24}, scans(30*time.Hour, time.Hour), 100, 100, true},
+		{storage.RentPayment{EvictionOfflineHours: 24}, scans(20*time.Hour, time.Hour), 100, 100, false},
+		{storage.RentPayment{EvictionOfflineHours: 24}, append(scans(30*time.Hour), storage.HostPoolScan{Timestamp: now, Success: true}), 100, 100, false},
+	}
+
+	for i, table := range tables {
+		host := storage.HostInfo{ScanRecords: table.scans}
+		if evict := shouldEvict(table.rent, host, table.eval, table.evalBaseline, now); evict != table.evict {
+			t.Errorf("test %v: expect evict %v, got %v", i, table.evict, evict)
+		}
+	}
+}
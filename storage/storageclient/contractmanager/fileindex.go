@@ -0,0 +1,301 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// FileSegments is the dxfile and the indexes of its segments stored under a contract
+type FileSegments struct {
+	DxPath   string   `json:"dxpath"`
+	Segments []uint64 `json:"segments"`
+}
+
+// fileIndex is the index between the contracts and the dxfile segments stored under them
+type fileIndex struct {
+	// contractToFiles maps the contract id to the dxfiles and segment indexes stored under the contract
+	contractToFiles map[storage.ContractID]map[storage.DxPath]map[uint64]struct{}
+
+	// fileToContracts maps the dxfile to the contracts that store the segments of the file
+	fileToContracts map[storage.DxPath]map[storage.ContractID]struct{}
+
+	// filesToRepair are the files that lost segments because the contract expired without being renewed,
+	// or the files stored on the evicted hosts
+	filesToRepair map[storage.DxPath]struct{}
+	repairNeeded  chan struct{}
+
+	lock sync.RWMutex
+}
+
+// newFileIndex creates an empty fileIndex
+func newFileIndex() *fileIndex {
+	return &fileIndex{
+		contractToFiles: make(map[storage.ContractID]map[storage.DxPath]map[uint64]struct{}),
+		fileToContracts: make(map[storage.DxPath]map[storage.ContractID]struct{}),
+		filesToRepair:   make(map[storage.DxPath]struct{}),
+		repairNeeded:    make(chan struct{}, 1),
+	}
+}
+
+// add adds the segment of the dxfile stored under the contract to the index
+func (fi *fileIndex) add(id storage.ContractID, dxPath storage.DxPath, segmentIndex uint64) {
+	fi.lock.Lock()
+	defer fi.lock.Unlock()
+
+	files, exists := fi.contractToFiles[id]
+	if !exists {
+		files = make(map[storage.DxPath]map[uint64]struct{})
+		fi.contractToFiles[id] = files
+	}
+	if _, exists := files[dxPath]; !exists {
+		files[dxPath] = make(map[uint64]struct{})
+	}
+	files[dxPath][segmentIndex] = struct{}{}
+
+	if _, exists := fi.fileToContracts[dxPath]; !exists {
+		fi.fileToContracts[dxPath] = make(map[storage.ContractID]struct{})
+	}
+	fi.fileToContracts[dxPath][id] = struct{}{}
+}
+
+// removeFile removes the dxfile from the index
+func (fi *fileIndex) removeFile(dxPath storage.DxPath) {
+	fi.lock.Lock()
+	defer fi.lock.Unlock()
+
+	for id := range fi.fileToContracts[dxPath] {
+		delete(fi.contractToFiles[id], dxPath)
+		if len(fi.contractToFiles[id]) == 0 {
+			delete(fi.contractToFiles, id)
+		}
+	}
+	delete(fi.fileToContracts, dxPath)
+	delete(fi.filesToRepair, dxPath)
+}
+
+// renameFile moves the index of the dxfile from prevPath to newPath
+func (fi *fileIndex) renameFile(prevPath, newPath storage.DxPath) {
+	fi.lock.Lock()
+	defer fi.lock.Unlock()
+
+	contracts, exists := fi.fileToContracts[prevPath]
+	if !exists {
+		return
+	}
+	for id := range contracts {
+		fi.contractToFiles[id][newPath] = fi.contractToFiles[id][prevPath]
+		delete(fi.contractToFiles[id], prevPath)
+	}
+	fi.fileToContracts[newPath] = contracts
+	delete(fi.fileToContracts, prevPath)
+}
+
+// migrate moves the segments stored under the old contract to the new contract. It is
+// called after the contract is renewed, since the data is carried to the renewed contract
+func (fi *fileIndex) migrate(oldID, newID storage.ContractID) {
+	fi.lock.Lock()
+	defer fi.lock.Unlock()
+
+	files, exists := fi.contractToFiles[oldID]
+	if !exists {
+		return
+	}
+	delete(fi.contractToFiles, oldID)
+	if _, exists := fi.contractToFiles[newID]; !exists {
+		fi.contractToFiles[newID] = make(map[storage.DxPath]map[uint64]struct{})
+	}
+	for dxPath, segments := range files {
+		if _, exists := fi.contractToFiles[newID][dxPath]; !exists {
+			fi.contractToFiles[newID][dxPath] = make(map[uint64]struct{})
+		}
+		for index := range segments {
+			fi.contractToFiles[newID][dxPath][index] = struct{}{}
+		}
+		delete(fi.fileToContracts[dxPath], oldID)
+		fi.fileToContracts[dxPath][newID] = struct{}{}
+	}
+}
+
+// lose removes the contract from the index since the data stored under it is no longer
+// available. The affected files are scheduled for repair
+func (fi *fileIndex) lose(id storage.ContractID) {
+	fi.lock.Lock()
+	defer fi.lock.Unlock()
+
+	files, exists := fi.contractToFiles[id]
+	if !exists {
+		return
+	}
+	delete(fi.contractToFiles, id)
+	for dxPath := range files {
+		delete(fi.fileToContracts[dxPath], id)
+		if len(fi.fileToContracts[dxPath]) == 0 {
+			delete(fi.fileToContracts, dxPath)
+		}
+		fi.filesToRepair[dxPath] = struct{}{}
+	}
+	fi.signalRepair()
+}
+
+// scheduleRepair schedules the files stored under the contract for repair. Unlike lose, the
+// index is kept since the data is still available under the contract
+func (fi *fileIndex) scheduleRepair(id storage.ContractID) {
+	fi.lock.Lock()
+	defer fi.lock.Unlock()
+
+	files, exists := fi.contractToFiles[id]
+	if !exists {
+		return
+	}
+	for dxPath := range files {
+		fi.filesToRepair[dxPath] = struct{}{}
+	}
+	fi.signalRepair()
+}
+
+// signalRepair signals that some files are scheduled for repair
+func (fi *fileIndex) signalRepair() {
+	select {
+	case fi.repairNeeded <- struct{}{}:
+	default:
+	}
+}
+
+// contractFiles returns the dxfiles and segment indexes stored under the contract
+func (fi *fileIndex) contractFiles(id storage.ContractID) (files []FileSegments) {
+	fi.lock.RLock()
+	defer fi.lock.RUnlock()
+
+	for dxPath, segments := range fi.contractToFiles[id] {
+		files = append(files, FileSegments{
+			DxPath:   dxPath.Path,
+			Segments: sortedSegments(segments),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].DxPath < files[j].DxPath })
+	return
+}
+
+// fileContracts returns the contracts storing the segments of the dxfile
+func (fi *fileIndex) fileContracts(dxPath storage.DxPath) (ids []storage.ContractID) {
+	fi.lock.RLock()
+	defer fi.lock.RUnlock()
+
+	for id := range fi.fileToContracts[dxPath] {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+	return
+}
+
+// popFilesToRepair returns and clears the files scheduled for repair
+func (fi *fileIndex) popFilesToRepair() (paths []storage.DxPath) {
+	fi.lock.Lock()
+	defer fi.lock.Unlock()
+
+	for dxPath := range fi.filesToRepair {
+		paths = append(paths, dxPath)
+	}
+	fi.filesToRepair = make(map[storage.DxPath]struct{})
+	return
+}
+
+// persist converts the index into the format used for saving persistently
+func (fi *fileIndex) persist() map[string]map[string][]uint64 {
+	fi.lock.RLock()
+	defer fi.lock.RUnlock()
+
+	persist := make(map[string]map[string][]uint64)
+	for id, files := range fi.contractToFiles {
+		persist[id.String()] = make(map[string][]uint64)
+		for dxPath, segments := range files {
+			persist[id.String()][dxPath.Path] = sortedSegments(segments)
+		}
+	}
+	return persist
+}
+
+// load loads the persisted index. Invalid entries are ignored
+func (fi *fileIndex) load(persist map[string]map[string][]uint64) {
+	for idStr, files := range persist {
+		id, err := storage.StringToContractID(idStr)
+		if err != nil {
+			continue
+		}
+		for path, segments := range files {
+			dxPath, err := storage.NewDxPath(path)
+			if err != nil {
+				continue
+			}
+			for _, index := range segments {
+				fi.add(id, dxPath, index)
+			}
+		}
+	}
+}
+
+// sortedSegments converts the segment index set to a sorted slice
+func sortedSegments(segments map[uint64]struct{}) []uint64 {
+	indexes := make([]uint64, 0, len(segments))
+	for index := range segments {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	return indexes
+}
+
+// AddFileSegment records that the segment of the dxfile is stored under the contract
+func (cm *ContractManager) AddFileSegment(id storage.ContractID, dxPath storage.DxPath, segmentIndex uint64) {
+	cm.fileIndex.add(id, dxPath, segmentIndex)
+}
+
+// RemoveFile removes the dxfile from the contract file index
+func (cm *ContractManager) RemoveFile(dxPath storage.DxPath) {
+	cm.fileIndex.removeFile(dxPath)
+}
+
+// RenameFile updates the contract file index after the dxfile is renamed
+func (cm *ContractManager) RenameFile(prevPath, newPath storage.DxPath) {
+	cm.fileIndex.renameFile(prevPath, newPath)
+}
+
+// ContractFiles returns the dxfiles and segment indexes stored under the contract
+func (cm *ContractManager) ContractFiles(id storage.ContractID) []FileSegments {
+	return cm.fileIndex.contractFiles(id)
+}
+
+// HostFiles returns the dxfiles and segment indexes stored on the host, which will
+// be lost if the host disappears
+func (cm *ContractManager) HostFiles(hostID enode.ID) []FileSegments {
+	cm.lock.RLock()
+	id, exists := cm.hostToContract[hostID]
+	cm.lock.RUnlock()
+	if !exists {
+		return nil
+	}
+	return cm.fileIndex.contractFiles(id)
+}
+
+// FileContracts returns the contracts storing the segments of the dxfile
+func (cm *ContractManager) FileContracts(dxPath storage.DxPath) []storage.ContractID {
+	return cm.fileIndex.fileContracts(dxPath)
+}
+
+// RepairNeededChan returns the channel signaled when some files lost the segments
+// because of contracts expired without being renewed, or the hosts evicted
+func (cm *ContractManager) RepairNeededChan() <-chan struct{} {
+	return cm.fileIndex.repairNeeded
+}
+
+// FilesToRepair returns and clears the files lost segments because of contracts
+// expired without being renewed, or the hosts evicted
+func (cm *ContractManager) FilesToRepair() []storage.DxPath {
+	return cm.fileIndex.popFilesToRepair()
+}
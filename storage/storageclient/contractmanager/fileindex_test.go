@@ -0,0 +1,83 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package contractmanager
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestFileIndex(t *testing.T) {
+	fi := newFileIndex()
+	oldID, newID := storage.ContractID{1}, storage.ContractID{2}
+	file1, _ := storage.NewDxPath("dir/file1")
+	file2, _ := storage.NewDxPath("dir/file2")
+
+	fi.add(oldID, file1, 1)
+	fi.add(oldID, file1, 0)
+	fi.add(oldID, file2, 3)
+	expect := []FileSegments{
+		{DxPath: file1.Path, Segments: []uint64{0, 1}},
+		{DxPath: file2.Path, Segments: []uint64{3}},
+	}
+	if files := fi.contractFiles(oldID); !reflect.DeepEqual(files, expect) {
+		t.Fatalf("contract files not expected. Got %v, Expect %v", files, expect)
+	}
+
+	// after renew, the segments should be carried to the new contract
+	fi.migrate(oldID, newID)
+	if files := fi.contractFiles(oldID); len(files) != 0 {
+		t.Errorf("old contract should not have files after renew. Got %v", files)
+	}
+	if ids := fi.fileContracts(file1); !reflect.DeepEqual(ids, []storage.ContractID{newID}) {
+		t.Errorf("file contracts not expected. Got %v, Expect %v", ids, []storage.ContractID{newID})
+	}
+
+	// rename and remove the files
+	renamed, _ := storage.NewDxPath("dir/renamed")
+	fi.renameFile(file1, renamed)
+	fi.removeFile(file2)
+	expect = []FileSegments{{DxPath: renamed.Path, Segments: []uint64{0, 1}}}
+	if files := fi.contractFiles(newID); !reflect.DeepEqual(files, expect) {
+		t.Fatalf("contract files not expected. Got %v, Expect %v", files, expect)
+	}
+
+	// the index should be recovered from the persisted data
+	loaded := newFileIndex()
+	loaded.load(fi.persist())
+	if !reflect.DeepEqual(loaded.contractFiles(newID), fi.contractFiles(newID)) {
+		t.Errorf("loaded index not expected. Got %v, Expect %v", loaded.contractFiles(newID), fi.contractFiles(newID))
+	}
+
+	// the host is evicted, the files should be scheduled for repair with the index kept
+	fi.scheduleRepair(newID)
+	select {
+	case <-fi.repairNeeded:
+	default:
+		t.Fatalf("repair should be signaled")
+	}
+	if paths := fi.popFilesToRepair(); !reflect.DeepEqual(paths, []storage.DxPath{renamed}) {
+		t.Errorf("files to repair not expected. Got %v, Expect %v", paths, []storage.DxPath{renamed})
+	}
+	if ids := fi.fileContracts(renamed); !reflect.DeepEqual(ids, []storage.ContractID{newID}) {
+		t.Errorf("evicted contract should be kept in the index. Got %v", ids)
+	}
+
+	// the contract expired without being renewed, the files should be scheduled for repair
+	fi.lose(newID)
+	select {
+	case <-fi.repairNeeded:
+	default:
+		t.Fatalf("repair should be signaled")
+	}
+	if paths := fi.popFilesToRepair(); !reflect.DeepEqual(paths, []storage.DxPath{renamed}) {
+		t.Errorf("files to repair not expected. Got %v, Expect %v", paths, []storage.DxPath{renamed})
+	}
+	if ids := fi.fileContracts(renamed); len(ids) != 0 {
+		t.Errorf("lost contract should be removed from the index. Got %v", ids)
+	}
+}
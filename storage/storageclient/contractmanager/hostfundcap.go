@@ -0,0 +1,60 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/unit"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// checkHostFundCap checks if committing the contract fund to the storage host will exceed the max
+// fund allowed for a single host operator. The contracts signed with the hosts that share the public
+// key or the IP network with the storage host are counted together. The excluded contract is the one
+// being renewed, whose fund will be replaced by the renewed contract
+func (cm *ContractManager) checkHostFundCap(host storage.HostInfo, contractFund common.BigInt, rent storage.RentPayment, excluded storage.ContractID) error {
+	if rent.MaxHostFundRatio <= 0 || rent.MaxHostFundRatio >= 1 {
+		return nil
+	}
+	fundCap := rent.Fund.MultFloat64(rent.MaxHostFundRatio)
+	committed := hostFundCommitted(host, cm.activeContracts.RetrieveAllContractsMetaData(), excluded, cm.hostManager.RetrieveHostInfo)
+
+	if committed.Add(contractFund).Cmp(fundCap) > 0 {
+		return fmt.Errorf("the fund committed to the host operator %v plus the contract fund %v exceeds the cap %v",
+			unit.FormatCurrency(committed), unit.FormatCurrency(contractFund), unit.FormatCurrency(fundCap))
+	}
+	return nil
+}
+
+// hostFundCommitted sums up the fund of the contracts signed with the same operator as the storage host
+func hostFundCommitted(host storage.HostInfo, contracts []storage.ContractMetaData, excluded storage.ContractID, retrieveHost func(enode.ID) (storage.HostInfo, bool)) common.BigInt {
+	committed := common.BigInt0
+	for _, contract := range contracts {
+		if contract.ID == excluded {
+			continue
+		}
+		if contract.EnodeID != host.EnodeID {
+			contractHost, exists := retrieveHost(contract.EnodeID)
+			if !exists || !sameHostOperator(host, contractHost) {
+				continue
+			}
+		}
+		committed = committed.Add(contract.TotalCost)
+	}
+	return committed
+}
+
+// sameHostOperator checks if the two storage hosts are run by the same operator, which is
+// considered as true if they share the public key or the IP network
+func sameHostOperator(a, b storage.HostInfo) bool {
+	if len(a.NodePubKey) != 0 && bytes.Equal(a.NodePubKey, b.NodePubKey) {
+		return true
+	}
+	return a.IPNetwork != "" && a.IPNetwork == b.IPNetwork
+}
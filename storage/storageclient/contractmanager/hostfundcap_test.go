@@ -0,0 +1,64 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestHostFundCommitted(t *testing.T) {
+	target := storage.HostInfo{EnodeID: enode.ID{1}, NodePubKey: []byte{1}, IPNetwork: "10.0.0.0/24"}
+	hosts := map[enode.ID]storage.HostInfo{
+		{2}: {EnodeID: enode.ID{2}, NodePubKey: []byte{2}, IPNetwork: "10.0.0.0/24"},
+		{3}: {EnodeID: enode.ID{3}, NodePubKey: []byte{1}, IPNetwork: "10.0.1.0/24"},
+		{4}: {EnodeID: enode.ID{4}, NodePubKey: []byte{4}, IPNetwork: "10.0.2.0/24"},
+	}
+	retrieveHost := func(id enode.ID) (storage.HostInfo, bool) {
+		info, exists := hosts[id]
+		return info, exists
+	}
+	contracts := []storage.ContractMetaData{
+		{ID: storage.ContractID{1}, EnodeID: enode.ID{1}, TotalCost: common.NewBigInt(1)},
+		{ID: storage.ContractID{2}, EnodeID: enode.ID{2}, TotalCost: common.NewBigInt(10)},
+		{ID: storage.ContractID{3}, EnodeID: enode.ID{3}, TotalCost: common.NewBigInt(100)},
+		{ID: storage.ContractID{4}, EnodeID: enode.ID{4}, TotalCost: common.NewBigInt(1000)},
+		{ID: storage.ContractID{5}, EnodeID: enode.ID{5}, TotalCost: common.NewBigInt(10000)},
+	}
+
+	tables := []struct {
+		excluded storage.ContractID
+		expected common.BigInt
+	}{
+		{storage.ContractID{}, common.NewBigInt(111)},
+		{storage.ContractID{1}, common.NewBigInt(110)},
+		{storage.ContractID{4}, common.NewBigInt(111)},
+	}
+	for _, table := range tables {
+		committed := hostFundCommitted(target, contracts, table.excluded, retrieveHost)
+		if committed.Cmp(table.expected) != 0 {
+			t.Errorf("committed fund not expected. Got %v, Expect %v", committed, table.expected)
+		}
+	}
+}
+
+func TestRentPaymentValidation_MaxHostFundRatio(t *testing.T) {
+	rent := rentPaymentTest
+	for _, ratio := range []float64{0, 0.2, 1} {
+		rent.MaxHostFundRatio = ratio
+		if err := RentPaymentValidation(rent); err != nil {
+			t.Errorf("ratio %v should be valid: %v", ratio, err)
+		}
+	}
+	for _, ratio := range []float64{-0.1, 1.5} {
+		rent.MaxHostFundRatio = ratio
+		if err := RentPaymentValidation(rent); err == nil {
+			t.Errorf("ratio %v should be invalid", ratio)
+		}
+	}
+}
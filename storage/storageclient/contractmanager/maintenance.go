@@ -7,6 +7,7 @@ package contractmanager
 import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
 	"reflect"
 )
@@ -28,6 +29,7 @@ type contractRenewRecord struct {
 // 		one can be saved
 // 		5. filter out contracts need to be renewed, renew contract
 // 		6. check out how many more contracts need to be created, create the contracts
+// 		7. form the mirror contracts for the contracts not mirrored, if the mirror mode is enabled
 func (cm *ContractManager) contractMaintenance() {
 	// if the maintenance is running, return directly
 	// otherwise, start the maintaining job
@@ -51,6 +53,9 @@ func (cm *ContractManager) contractMaintenance() {
 	cm.removeDuplications()
 	cm.maintainHostToContractIDMapping()
 	cm.removeHostWithDuplicateNetworkAddress()
+	if err := cm.promoteOrphanMirrors(); err != nil {
+		cm.log.Warn("failed to promote the orphan mirror contracts", "err", err.Error())
+	}
 
 	// get the rentPayment, this rentPayment will be used for all future
 	// contract renew and contract create
@@ -108,31 +113,36 @@ func (cm *ContractManager) contractMaintenance() {
 	}
 
 	// find out how many contracts are good for data uploading. Based on that data
-	// calculate how many extra contracts are needed
+	// calculate how many extra contracts are needed. The mirror contracts are not counted
 	var uploadableContracts uint64
 	for _, contract := range cm.activeContracts.RetrieveAllContractsMetaData() {
-		if contract.Status.UploadAbility {
+		if contract.Status.UploadAbility && contract.MirrorOf == (enode.ID{}) {
 			uploadableContracts++
 		}
 	}
 
 	// get the number of contracts that needed to be formed
 	neededContracts := int(rentPayment.StorageHosts - uploadableContracts)
-	if neededContracts <= 0 {
-		return
-	}
+	if neededContracts > 0 {
+		// prepare to for forming contract based on the number of extract contracts needed
+		terminated, err := cm.prepareCreateContract(neededContracts, clientRemainingFund, rentPayment)
+		if err != nil {
+			cm.log.Error("failed to create the contract", "err", err.Error())
+			return
+		}
 
-	// prepare to for forming contract based on the number of extract contracts needed
-	terminated, err := cm.prepareCreateContract(neededContracts, clientRemainingFund, rentPayment)
-	if err != nil {
-		cm.log.Error("failed to create the contract", "err", err.Error())
-		return
+		// why terminated is checked explicitly?
+		// in case more codes need to be added in the future after this function
+		if terminated {
+			return
+		}
 	}
 
-	// why terminated is checked explicitly?
-	// in case more codes need to be added in the future after this function
-	if terminated {
-		return
+	// the mirrors are formed after the primary contracts, which take the priority of the fund
+	if rentPayment.MirrorContracts {
+		if _, err := cm.maintainMirrors(rentPayment); err != nil {
+			cm.log.Error("failed to create the mirror contract", "err", err.Error())
+		}
 	}
 }
 
@@ -0,0 +1,174 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// errNoMirror is returned when the primary contract is not mirrored
+var errNoMirror = errors.New("the contract is not mirrored")
+
+// MirrorContract returns the active contract mirroring the primary contract formed with the host
+func (cm *ContractManager) MirrorContract(primary enode.ID) (storage.ContractMetaData, bool) {
+	if primary == (enode.ID{}) {
+		return storage.ContractMetaData{}, false
+	}
+	for _, contract := range cm.activeContracts.RetrieveAllContractsMetaData() {
+		if contract.MirrorOf == primary {
+			return contract, true
+		}
+	}
+	return storage.ContractMetaData{}, false
+}
+
+// PromoteMirror promotes the mirror contract of the primary contract formed with the host to
+// a primary contract, which takes over the uploads. The contracts with the primary host are
+// canceled, so that they are neither used for the uploads nor renewed
+func (cm *ContractManager) PromoteMirror(primary enode.ID) (err error) {
+	mirror, exists := cm.MirrorContract(primary)
+	if !exists {
+		return errNoMirror
+	}
+	if err = cm.updateContractMirror(mirror.ID, enode.ID{}); err != nil {
+		return
+	}
+	for _, contract := range cm.activeContracts.RetrieveAllContractsMetaData() {
+		if contract.EnodeID != primary || contract.MirrorOf != (enode.ID{}) {
+			continue
+		}
+		status := contract.Status
+		status.UploadAbility = false
+		status.RenewAbility = false
+		status.Canceled = true
+		if err = cm.updateContractStatus(contract.ID, status); err != nil {
+			return
+		}
+	}
+	cm.log.Info("Mirror contract promoted", "primary", primary, "mirror", mirror.EnodeID, "contractID", mirror.ID)
+	return
+}
+
+// promoteOrphanMirrors promotes the mirror contracts whose primary contract is gone, e.g. the
+// primary contract is expired without being renewed, as the data stored under the primary
+// contract is lost
+func (cm *ContractManager) promoteOrphanMirrors() (err error) {
+	contracts := cm.activeContracts.RetrieveAllContractsMetaData()
+	primaries := make(map[enode.ID]struct{})
+	for _, contract := range contracts {
+		if contract.MirrorOf == (enode.ID{}) && !contract.Status.Canceled {
+			primaries[contract.EnodeID] = struct{}{}
+		}
+	}
+	for _, contract := range contracts {
+		if contract.MirrorOf == (enode.ID{}) {
+			continue
+		}
+		if _, exists := primaries[contract.MirrorOf]; exists {
+			continue
+		}
+		if err = cm.updateContractMirror(contract.ID, enode.ID{}); err != nil {
+			return
+		}
+		cm.log.Info("Orphan mirror contract promoted", "primary", contract.MirrorOf, "mirror", contract.EnodeID)
+	}
+	return
+}
+
+// maintainMirrors forms the mirror contracts for the primary contracts good for uploading but
+// not mirrored yet
+func (cm *ContractManager) maintainMirrors(rentPayment storage.RentPayment) (terminated bool, err error) {
+	contracts := cm.activeContracts.RetrieveAllContractsMetaData()
+	mirrored := make(map[enode.ID]bool)
+	for _, contract := range contracts {
+		if contract.MirrorOf != (enode.ID{}) {
+			mirrored[contract.MirrorOf] = true
+		}
+	}
+	var unmirrored []enode.ID
+	for _, contract := range contracts {
+		if contract.MirrorOf == (enode.ID{}) && contract.Status.UploadAbility && !mirrored[contract.EnodeID] {
+			unmirrored = append(unmirrored, contract.EnodeID)
+			mirrored[contract.EnodeID] = true
+		}
+	}
+	if len(unmirrored) == 0 {
+		return
+	}
+
+	// the fund committed by the contracts formed and renewed in the maintenance is excluded
+	clientRemainingFund := rentPayment.Fund.Sub(cm.CalculatePeriodCost(rentPayment).ContractFund)
+	if clientRemainingFund.IsNeg() {
+		clientRemainingFund = common.BigInt0
+	}
+
+	randomHosts, err := cm.randomHostsForContractForm(len(unmirrored))
+	if err != nil {
+		return
+	}
+
+	cm.lock.RLock()
+	contractFund := rentPayment.Fund.DivUint64(rentPayment.StorageHosts).DivUint64(3)
+	contractEndHeight := cm.currentPeriod + rentPayment.Period + storage.RenewWindow
+	cm.lock.RUnlock()
+
+	for _, host := range randomHosts {
+		if len(unmirrored) == 0 {
+			break
+		}
+		if contractFund.Cmp(clientRemainingFund) > 0 {
+			err = fmt.Errorf("the contract fund %v is larger than client remaining fund %v. Impossible to create mirror contract",
+				contractFund, clientRemainingFund)
+			return
+		}
+
+		formCost, contract, errFormContract := cm.createContract(host, contractFund, contractEndHeight, rentPayment)
+		if errFormContract != nil {
+			cm.log.Warn("failed to create the mirror contract", "err", errFormContract.Error())
+			continue
+		}
+
+		clientRemainingFund = clientRemainingFund.Sub(formCost)
+		if err = cm.markNewlyFormedContractStats(contract.ID); err != nil {
+			return
+		}
+		if err = cm.updateContractMirror(contract.ID, unmirrored[0]); err != nil {
+			return
+		}
+		cm.log.Info("Mirror contract formed", "primary", unmirrored[0], "mirror", contract.EnodeID, "contractID", contract.ID)
+		unmirrored = unmirrored[1:]
+
+		if failedSave := cm.saveSettings(); failedSave != nil {
+			cm.log.Warn("after created the mirror contract, failed to save the contract manager settings")
+		}
+
+		if terminated = cm.checkMaintenanceTermination(); terminated {
+			break
+		}
+	}
+	return
+}
+
+// updateContractMirror will update the host of the primary contract mirrored by the contract
+// with provided id
+func (cm *ContractManager) updateContractMirror(id storage.ContractID, mirrorOf enode.ID) (err error) {
+	contract, exists := cm.activeContracts.Acquire(id)
+	if !exists {
+		return fmt.Errorf("failed to acquire the contract: contract does not exist")
+	}
+
+	defer func() {
+		if err := cm.activeContracts.Return(contract); err != nil {
+			cm.log.Warn("failed to return the contract, it has been deleted already", "err", err.Error())
+		}
+	}()
+
+	return contract.UpdateMirror(mirrorOf)
+}
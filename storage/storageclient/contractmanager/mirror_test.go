@@ -0,0 +1,72 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/contractset"
+)
+
+// TestContractManager_PromoteMirror test the mirror contract takes over the primary contract
+// once promoted, and the orphan mirror is promoted during the maintenance
+func TestContractManager_PromoteMirror(t *testing.T) {
+	cm, err := createNewContractManager()
+	if err != nil {
+		t.Fatalf("failed to create contract manager: %s", err.Error())
+	}
+	defer os.RemoveAll("test")
+	defer cm.activeContracts.Close()
+	defer cm.activeContracts.EmptyDB()
+
+	primary := randomContractGenerator(100)
+	mirror := randomContractGenerator(100)
+	mirror.MirrorOf = primary.EnodeID
+	orphan := randomContractGenerator(100)
+	orphan.MirrorOf = randomEnodeIDGenerator()
+	for _, ch := range []contractset.ContractHeader{primary, mirror, orphan} {
+		if _, err := cm.activeContracts.InsertContract(ch, randomRootsGenerator(10)); err != nil {
+			t.Fatalf("failed to insert contract: %s", err.Error())
+		}
+		if err := cm.markNewlyFormedContractStats(ch.ID); err != nil {
+			t.Fatalf("failed to mark the contract status: %s", err.Error())
+		}
+	}
+
+	if meta, exists := cm.MirrorContract(primary.EnodeID); !exists || meta.ID != mirror.ID {
+		t.Fatalf("mirror contract of the primary contract not found")
+	}
+	if _, exists := cm.MirrorContract(enode.ID{}); exists {
+		t.Fatalf("primary contract found as the mirror of the empty host")
+	}
+
+	// the orphan mirror is promoted, while the mirror of the existing primary contract is kept
+	if err := cm.promoteOrphanMirrors(); err != nil {
+		t.Fatalf("failed to promote the orphan mirrors: %s", err.Error())
+	}
+	if meta, _ := cm.activeContracts.RetrieveContractMetaData(orphan.ID); meta.MirrorOf != (enode.ID{}) {
+		t.Errorf("orphan mirror contract not promoted")
+	}
+	if meta, _ := cm.activeContracts.RetrieveContractMetaData(mirror.ID); meta.MirrorOf != primary.EnodeID {
+		t.Errorf("mirror contract of the existing primary contract promoted")
+	}
+
+	if err := cm.PromoteMirror(primary.EnodeID); err != nil {
+		t.Fatalf("failed to promote the mirror: %s", err.Error())
+	}
+	if meta, _ := cm.activeContracts.RetrieveContractMetaData(mirror.ID); meta.MirrorOf != (enode.ID{}) {
+		t.Errorf("mirror contract not promoted")
+	}
+	meta, _ := cm.activeContracts.RetrieveContractMetaData(primary.ID)
+	if meta.Status != (storage.ContractStatus{Canceled: true}) {
+		t.Errorf("primary contract not canceled after the promotion, got status %+v", meta.Status)
+	}
+	if err := cm.PromoteMirror(primary.EnodeID); err != errNoMirror {
+		t.Errorf("promoting the mirror twice, expect error %v, got %v", errNoMirror, err)
+	}
+}
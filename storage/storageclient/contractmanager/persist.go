@@ -17,12 +17,13 @@ var settingsMetadata = common.Metadata{
 }
 
 type persistence struct {
-	Rent             storage.RentPayment           `json:"rentPayment"`
-	BlockHeight      uint64                        `json:"blockheight"`
-	CurrentPeriod    uint64                        `json:"currentperiod"`
-	ExpiredContracts []storage.ContractMetaData    `json:"expiredcontracts"`
-	RenewedFrom      map[string]storage.ContractID `json:"renewedfrom"`
-	RenewedTo        map[string]storage.ContractID `json:"renewedto"`
+	Rent             storage.RentPayment            `json:"rentPayment"`
+	BlockHeight      uint64                         `json:"blockheight"`
+	CurrentPeriod    uint64                         `json:"currentperiod"`
+	ExpiredContracts []storage.ContractMetaData     `json:"expiredcontracts"`
+	RenewedFrom      map[string]storage.ContractID  `json:"renewedfrom"`
+	RenewedTo        map[string]storage.ContractID  `json:"renewedto"`
+	FileIndex        map[string]map[string][]uint64 `json:"fileindex"`
 }
 
 func (cm *ContractManager) persistUpdate() (persist persistence) {
@@ -32,6 +33,7 @@ func (cm *ContractManager) persistUpdate() (persist persistence) {
 		CurrentPeriod: cm.currentPeriod,
 		RenewedFrom:   make(map[string]storage.ContractID),
 		RenewedTo:     make(map[string]storage.ContractID),
+		FileIndex:     cm.fileIndex.persist(),
 	}
 
 	// update the renewedFrom
@@ -115,5 +117,8 @@ func (cm *ContractManager) loadSettings() (err error) {
 	}
 	cm.lock.Unlock()
 
+	// load the contract file index
+	cm.fileIndex.load(data.FileIndex)
+
 	return
 }
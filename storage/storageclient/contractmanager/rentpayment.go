@@ -120,6 +120,10 @@ func RentPaymentValidation(rent storage.RentPayment) (err error) {
 		return errors.New("storage period cannot be set to 0")
 	case storage.RenewWindow > rent.Period:
 		return fmt.Errorf("storage period must be greater than %v", unit.FormatTime(storage.RenewWindow))
+	case rent.MaxHostFundRatio < 0 || rent.MaxHostFundRatio > 1:
+		return fmt.Errorf("max host fund ratio %v must be within the range of [0, 1]", rent.MaxHostFundRatio)
+	case rent.EvictionEvalRatio < 0 || rent.EvictionEvalRatio > 1:
+		return fmt.Errorf("eviction evaluation ratio %v must be within the range of [0, 1]", rent.EvictionEvalRatio)
 	default:
 		return
 	}
@@ -0,0 +1,160 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageerr"
+)
+
+// FundReservation is the balance reserved for a storage contract being formed or renewed. The
+// balance is reserved before the negotiation, so that the contracts formed concurrently will
+// not spend the same funds and fail at the transaction execution
+type FundReservation struct {
+	ContractID storage.ContractID `json:"contractID"`
+	Address    common.Address     `json:"address"`
+	Amount     common.BigInt      `json:"amount"`
+	Height     uint64             `json:"height"`
+
+	// TxHash is the hash of the contract create transaction, empty if not sent yet
+	TxHash common.Hash `json:"txHash"`
+}
+
+// FundReservationStatus is the balance of the address, along with the balance reserved for the
+// storage contracts pending and the balance still available for new contracts
+type FundReservationStatus struct {
+	Address      common.Address    `json:"address"`
+	Balance      common.BigInt     `json:"balance"`
+	Reserved     common.BigInt     `json:"reserved"`
+	Available    common.BigInt     `json:"available"`
+	Reservations []FundReservation `json:"reservations"`
+}
+
+// reserveFunds reserves the amount of the address for the storage contract. Error with the
+// insufficient funds code is returned if the balance not reserved yet is less than the amount
+func (cm *ContractManager) reserveFunds(id storage.ContractID, address common.Address, amount common.BigInt) error {
+	balance, err := cm.b.GetBalance(address)
+	if err != nil {
+		return fmt.Errorf("failed to get the balance of %v: %s", address.String(), err.Error())
+	}
+
+	cm.lock.RLock()
+	blockHeight := cm.blockHeight
+	cm.lock.RUnlock()
+
+	cm.reservationLock.Lock()
+	defer cm.reservationLock.Unlock()
+
+	available := balance.Sub(cm.reservedFunds(address))
+	if available.Cmp(amount) < 0 {
+		return storageerr.New(storageerr.CodeInsufficientFunds, fmt.Sprintf("balance available %v is not enough for the contract fund %v, %v in total reserved for the pending contracts",
+			available, amount, balance.Sub(available)))
+	}
+	cm.fundReservations[id] = &FundReservation{
+		ContractID: id,
+		Address:    address,
+		Amount:     amount,
+		Height:     blockHeight,
+	}
+	return nil
+}
+
+// reservedFunds returns the total amount reserved for the address. The reservationLock must be held
+func (cm *ContractManager) reservedFunds(address common.Address) common.BigInt {
+	reserved := common.BigInt0
+	for _, r := range cm.fundReservations {
+		if r.Address == address {
+			reserved = reserved.Add(r.Amount)
+		}
+	}
+	return reserved
+}
+
+// fundsSent records the contract create transaction sent for the reservation. The reservation
+// is kept until the transaction is included in a block, where the balance is deducted
+func (cm *ContractManager) fundsSent(id storage.ContractID, txHash common.Hash) {
+	cm.reservationLock.Lock()
+	defer cm.reservationLock.Unlock()
+
+	if r, exists := cm.fundReservations[id]; exists {
+		r.TxHash = txHash
+	}
+}
+
+// releaseUnsentFunds releases the reservation if the contract create transaction is not sent.
+// It is called when the negotiation failed
+func (cm *ContractManager) releaseUnsentFunds(id storage.ContractID) {
+	cm.reservationLock.Lock()
+	defer cm.reservationLock.Unlock()
+
+	if r, exists := cm.fundReservations[id]; exists && r.TxHash == (common.Hash{}) {
+		delete(cm.fundReservations, id)
+	}
+}
+
+// updateFundReservations releases the reservations whose transactions are included in the
+// blocks applied, and the reservations not seen on chain for fundReservationExpiry blocks
+func (cm *ContractManager) updateFundReservations(blockHeight uint64, appliedTxs map[common.Hash]struct{}) {
+	cm.reservationLock.Lock()
+	defer cm.reservationLock.Unlock()
+
+	for id, r := range cm.fundReservations {
+		_, included := appliedTxs[r.TxHash]
+		if included || r.Height+fundReservationExpiry <= blockHeight {
+			delete(cm.fundReservations, id)
+		}
+	}
+}
+
+// appliedTransactions returns the hashes of the transactions in the blocks applied
+func (cm *ContractManager) appliedTransactions(blockHashes []common.Hash) map[common.Hash]struct{} {
+	txs := make(map[common.Hash]struct{})
+	for _, hash := range blockHashes {
+		blockTxs, err := cm.b.GetTxByBlockHash(hash)
+		if err != nil {
+			cm.log.Warn("failed to get the transactions of the block", "hash", hash, "err", err)
+			continue
+		}
+		for _, tx := range blockTxs {
+			txs[tx.Hash()] = struct{}{}
+		}
+	}
+	return txs
+}
+
+// FundReservations returns the balance reserved and available of the address
+func (cm *ContractManager) FundReservations(address common.Address) (FundReservationStatus, error) {
+	balance, err := cm.b.GetBalance(address)
+	if err != nil {
+		return FundReservationStatus{}, fmt.Errorf("failed to get the balance of %v: %s", address.String(), err.Error())
+	}
+
+	cm.reservationLock.Lock()
+	defer cm.reservationLock.Unlock()
+
+	status := FundReservationStatus{
+		Address:      address,
+		Balance:      balance,
+		Reserved:     cm.reservedFunds(address),
+		Reservations: make([]FundReservation, 0),
+	}
+	status.Available = balance.Sub(status.Reserved)
+	if status.Available.Sign() < 0 {
+		status.Available = common.BigInt0
+	}
+	for _, r := range cm.fundReservations {
+		if r.Address == address {
+			status.Reservations = append(status.Reservations, *r)
+		}
+	}
+	sort.Slice(status.Reservations, func(i, j int) bool {
+		return status.Reservations[i].Height < status.Reservations[j].Height
+	})
+	return status, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageerr"
+)
+
+// TestFundReservations test the balance reserved for the pending contracts could not be
+// reserved again, and is released once the transaction is included or the reservation expires
+func TestFundReservations(t *testing.T) {
+	cm := &ContractManager{
+		b:                &storageClientBackendContractManager{balance: common.NewBigInt(100)},
+		fundReservations: make(map[storage.ContractID]*FundReservation),
+		log:              log.New(),
+	}
+	address := common.Address{1}
+
+	if err := cm.reserveFunds(storage.ContractID{1}, address, common.NewBigInt(60)); err != nil {
+		t.Fatal(err)
+	}
+	err := cm.reserveFunds(storage.ContractID{2}, address, common.NewBigInt(50))
+	if storageerr.CodeOf(err) != storageerr.CodeInsufficientFunds {
+		t.Fatalf("expect insufficient funds error, got %v", err)
+	}
+	// the reservations of the other addresses are not counted
+	if err := cm.reserveFunds(storage.ContractID{3}, common.Address{2}, common.NewBigInt(50)); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := cm.FundReservations(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Reserved.Cmp(common.NewBigInt(60)) != 0 || status.Available.Cmp(common.NewBigInt(40)) != 0 || len(status.Reservations) != 1 {
+		t.Fatalf("status not expected: %+v", status)
+	}
+
+	// the reservation is kept after the transaction is sent, until the transaction is included
+	txHash := common.Hash{1}
+	cm.fundsSent(storage.ContractID{1}, txHash)
+	cm.releaseUnsentFunds(storage.ContractID{1})
+	cm.releaseUnsentFunds(storage.ContractID{3})
+	if len(cm.fundReservations) != 1 {
+		t.Fatalf("expect 1 reservation, got %v", len(cm.fundReservations))
+	}
+	cm.updateFundReservations(1, map[common.Hash]struct{}{{2}: {}})
+	if len(cm.fundReservations) != 1 {
+		t.Fatalf("reservation released before the transaction included")
+	}
+	cm.updateFundReservations(2, map[common.Hash]struct{}{txHash: {}})
+	if len(cm.fundReservations) != 0 {
+		t.Fatalf("reservation not released after the transaction included")
+	}
+
+	// the reservation expires if the transaction is not seen on chain
+	cm.blockHeight = 10
+	if err := cm.reserveFunds(storage.ContractID{4}, address, common.NewBigInt(100)); err != nil {
+		t.Fatal(err)
+	}
+	cm.updateFundReservations(10+fundReservationExpiry-1, nil)
+	if len(cm.fundReservations) != 1 {
+		t.Fatalf("reservation released before expiry")
+	}
+	cm.updateFundReservations(10+fundReservationExpiry, nil)
+	if len(cm.fundReservations) != 0 {
+		t.Fatalf("reservation not released after expiry")
+	}
+}
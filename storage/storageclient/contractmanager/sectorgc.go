@@ -0,0 +1,50 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractmanager
+
+import (
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// MarkUnreferencedSectors replaces the sectors marked as unreferenced with the result of the
+// latest garbage collection pass, which maps the contract id to the sectors stored under the
+// contract but no longer referenced by any dxfile
+func (cm *ContractManager) MarkUnreferencedSectors(unreferenced map[storage.ContractID][]common.Hash) {
+	marked := make(map[storage.ContractID][]common.Hash)
+	for id, roots := range unreferenced {
+		if len(roots) != 0 {
+			marked[id] = roots
+		}
+	}
+
+	cm.lock.Lock()
+	defer cm.lock.Unlock()
+	cm.unreferencedSectors = marked
+}
+
+// UnreferencedSectors returns the sectors stored under the contract which are marked as
+// unreferenced by the garbage collection
+func (cm *ContractManager) UnreferencedSectors(id storage.ContractID) []common.Hash {
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+
+	roots := make([]common.Hash, len(cm.unreferencedSectors[id]))
+	copy(roots, cm.unreferencedSectors[id])
+	return roots
+}
+
+// allSectorsUnreferenced checks whether the contract stores some sectors, and all of them are
+// marked as unreferenced. The data of such contract is no longer needed by the storage client
+func (cm *ContractManager) allSectorsUnreferenced(contract storage.ContractMetaData) bool {
+	numSectors := contract.LatestContractRevision.NewFileSize / storage.SectorSize
+	if numSectors == 0 {
+		return false
+	}
+
+	cm.lock.RLock()
+	defer cm.lock.RUnlock()
+	return uint64(len(cm.unreferencedSectors[contract.ID])) >= numSectors
+}
@@ -50,8 +50,12 @@ func (cm *ContractManager) analyzeChainEventChange(change core.ChainChangeEvent)
 	if cm.blockHeight >= cm.currentPeriod+cm.rentPayment.Period {
 		cm.currentPeriod += cm.rentPayment.Period
 	}
+	blockHeight := cm.blockHeight
 	cm.lock.Unlock()
 
+	// release the balance reserved for the contracts whose transactions are included
+	cm.updateFundReservations(blockHeight, cm.appliedTransactions(change.AppliedBlockHashes))
+
 	// save the newest settings (blockHeight) persistently
 	if err := cm.saveSettings(); err != nil {
 		cm.log.Warn("failed to save the current contract manager settings while analyzing the chain change event", "err", err.Error())
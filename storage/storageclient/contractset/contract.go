@@ -15,6 +15,7 @@ import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/writeaheadlog"
 	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
 )
 
@@ -25,6 +26,10 @@ type Contract struct {
 	merkleRoots   *merkleRoots
 	unappliedTxns []*writeaheadlog.Transaction
 
+	// pendingIntents are the revision intents left by the negotiations interrupted before
+	// the restart, which are reconciled with the storage host at the next negotiation
+	pendingIntents []*writeaheadlog.Transaction
+
 	db   *DB
 	lock sync.Mutex
 	wal  *writeaheadlog.Wal
@@ -41,6 +46,15 @@ type walRootsEntry struct {
 	Index uint64
 }
 
+// walRevisionIntentEntry is the entry recorded in the first phase of the revision
+// update. It contains the contract header before the negotiation and the revision
+// that is about to be signed and sent to the storage host
+type walRevisionIntentEntry struct {
+	ID          storage.ContractID
+	OldHeader   ContractHeader
+	NewRevision types.StorageContractRevision
+}
+
 // Status will return the current status of the contract
 func (c *Contract) Status() (stats storage.ContractStatus) {
 	c.headerLock.Lock()
@@ -64,6 +78,17 @@ func (c *Contract) UpdateStatus(status storage.ContractStatus) (err error) {
 	return
 }
 
+// UpdateMirror updates the host of the primary contract mirrored by the contract, the empty
+// host turns the contract into a primary contract
+func (c *Contract) UpdateMirror(mirrorOf enode.ID) (err error) {
+	c.headerLock.Lock()
+	contractHeader := c.header
+	c.headerLock.Unlock()
+
+	contractHeader.MirrorOf = mirrorOf
+	return c.contractHeaderUpdate(contractHeader)
+}
+
 // CommitRevision unify the CommitUpload and CommitDownload signature and use memory snapshot instead of WAL.Transaction log
 func (c *Contract) CommitRevision(signedRevision types.StorageContractRevision, costs ...common.BigInt) (err error) {
 	// get the contract header information
@@ -91,6 +116,186 @@ func (c *Contract) CommitRevision(signedRevision types.StorageContractRevision,
 	return
 }
 
+// AppendTranscript will append the negotiation entry, signed by the sign function, to the
+// negotiation transcript of the contract. It is called once the revision is acknowledged
+// by the storage host. The contract must be acquired from the contract set
+func (c *Contract) AppendTranscript(request, response interface{}, revisionNumber uint64, sign func(hash []byte) ([]byte, error)) (err error) {
+	transcript, err := c.db.FetchTranscript(c.header.ID)
+	if err != nil {
+		return
+	}
+	entry := storage.NewTranscriptEntry(transcript, request, response, revisionNumber)
+	if entry.Signature, err = sign(entry.Hash().Bytes()); err != nil {
+		return
+	}
+	return c.db.StoreTranscript(c.header.ID, append(transcript, entry))
+}
+
+// PrepareRevision is the first phase of the two-phase commit of the revision update.
+// Before the client's signature of the new revision is sent to the storage host, the
+// contract header before negotiation together with the new revision is committed to
+// the wal. The returned transaction must be either released by ReleaseRevision once the
+// host acknowledged the revision, or rolled back by RollbackRevision if the negotiation
+// failed. If the program crashed in between, the revision intent is kept on restart and
+// reconciled with the storage host's latest revision by ReconcileRevision
+func (c *Contract) PrepareRevision(newRev types.StorageContractRevision) (t *writeaheadlog.Transaction, err error) {
+	// get the contract header
+	c.headerLock.Lock()
+	contractHeader := c.header
+	c.headerLock.Unlock()
+
+	data, err := json.Marshal(walRevisionIntentEntry{
+		ID:          contractHeader.ID,
+		OldHeader:   contractHeader,
+		NewRevision: newRev,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to encode the revision intent entry: %s", err.Error())
+		return
+	}
+
+	if t, err = c.wal.NewTransaction([]writeaheadlog.Operation{{Name: dbRevisionIntent, Data: data}}); err != nil {
+		return
+	}
+
+	if err = <-t.Commit(); err != nil {
+		return
+	}
+
+	// append the transaction into the un-applied transactions
+	c.unappliedTxns = append(c.unappliedTxns, t)
+
+	return
+}
+
+// ReleaseRevision is the last step of the two-phase commit. It is called after the revision
+// has been committed by CommitRevision and acknowledged by the storage host. The revision
+// intent will be released from the wal
+func (c *Contract) ReleaseRevision(t *writeaheadlog.Transaction) (err error) {
+	if err = t.Release(); err != nil {
+		return
+	}
+	c.removeUnappliedTxn(t)
+	return
+}
+
+// RollbackRevision will roll back the contract header to the one recorded in the revision
+// intent, and release the revision intent from the wal
+func (c *Contract) RollbackRevision(t *writeaheadlog.Transaction) (err error) {
+	for _, op := range t.Operations {
+		if op.Name != dbRevisionIntent {
+			continue
+		}
+		var intent walRevisionIntentEntry
+		if err = json.Unmarshal(op.Data, &intent); err != nil {
+			return
+		}
+		if err = c.contractHeaderUpdate(intent.OldHeader); err != nil {
+			return
+		}
+	}
+	return c.ReleaseRevision(t)
+}
+
+// PendingRevision checks if the contract has the revision intents left by the negotiation
+// interrupted before the restart, which shall be reconciled before the next negotiation
+func (c *Contract) PendingRevision() bool {
+	return len(c.pendingIntents) != 0
+}
+
+// ReconcileRevision reconciles the revision intents left by the interrupted negotiation with
+// the storage host's latest revision. If the storage host has the new revision of the intent,
+// the revision committed by the client is kept. If the storage host still has the revision
+// before the negotiation, the contract header is rolled back to the one before negotiation.
+// The revision intents are released once reconciled
+func (c *Contract) ReconcileRevision(hostRev types.StorageContractRevision) (err error) {
+	for len(c.pendingIntents) != 0 {
+		t := c.pendingIntents[0]
+		var intent walRevisionIntentEntry
+		if intent, err = decodeRevisionIntent(t); err != nil {
+			return
+		}
+
+		// verify the unlock hash between two contract revisions
+		if hostRev.UnlockConditions.UnlockHash() != intent.NewRevision.UnlockConditions.UnlockHash() {
+			return errors.New("unlock conditions do not match")
+		}
+
+		switch hostRev.NewRevisionNumber {
+		case intent.NewRevision.NewRevisionNumber:
+			// the storage host only commits the revision after the client has committed it
+			if c.Header().LatestContractRevision.NewRevisionNumber != hostRev.NewRevisionNumber {
+				return fmt.Errorf("storage host's contract revision number %v is not committed by the client", hostRev.NewRevisionNumber)
+			}
+			err = t.Release()
+		case intent.OldHeader.LatestContractRevision.NewRevisionNumber:
+			if err = c.contractHeaderUpdate(intent.OldHeader); err != nil {
+				return
+			}
+			err = t.Release()
+		default:
+			err = fmt.Errorf("storage host's contract revision number %v matches neither revision number %v nor %v of the revision intent",
+				hostRev.NewRevisionNumber, intent.OldHeader.LatestContractRevision.NewRevisionNumber, intent.NewRevision.NewRevisionNumber)
+		}
+		if err != nil {
+			return
+		}
+		c.pendingIntents = c.pendingIntents[1:]
+	}
+	return
+}
+
+// RollbackPendingRevision rolls back the revision intents left by the interrupted negotiation,
+// which is used when the storage host's latest revision could not be retrieved to reconcile
+// with. The storage host rolls back the revision not acknowledged by the client, thus the
+// contract header is rolled back to the one before negotiation
+func (c *Contract) RollbackPendingRevision() (err error) {
+	for len(c.pendingIntents) != 0 {
+		if err = c.RollbackRevision(c.pendingIntents[0]); err != nil {
+			return
+		}
+		c.pendingIntents = c.pendingIntents[1:]
+	}
+	return
+}
+
+// releasePendingRevision releases the revision intents left by the interrupted negotiation
+// without reconciliation, which is used once the contract is deleted
+func (c *Contract) releasePendingRevision() (err error) {
+	for len(c.pendingIntents) != 0 {
+		if err = c.pendingIntents[0].Release(); err != nil {
+			return
+		}
+		c.pendingIntents = c.pendingIntents[1:]
+	}
+	return
+}
+
+// decodeRevisionIntent decodes the revision intent entry recorded in the transaction
+func decodeRevisionIntent(t *writeaheadlog.Transaction) (intent walRevisionIntentEntry, err error) {
+	for _, op := range t.Operations {
+		if op.Name != dbRevisionIntent {
+			continue
+		}
+		if err = json.Unmarshal(op.Data, &intent); err != nil {
+			err = fmt.Errorf("failed to decode the revision intent: %s", err.Error())
+		}
+		return
+	}
+	err = errors.New("no revision intent recorded in the transaction")
+	return
+}
+
+// removeUnappliedTxn remove the transaction from the un-applied transactions
+func (c *Contract) removeUnappliedTxn(t *writeaheadlog.Transaction) {
+	for i, txn := range c.unappliedTxns {
+		if txn == t {
+			c.unappliedTxns = append(c.unappliedTxns[:i], c.unappliedTxns[i+1:]...)
+			break
+		}
+	}
+}
+
 // UndoRevisionLog will record pre-revision contract revision, which
 // is not stored in the database. once negotiation has completed, CommitUpload/CommitDownload
 // will be called to record the actual contract revision and store it into database
@@ -304,7 +509,10 @@ func (c *Contract) Metadata() (meta storage.ContractMetaData) {
 		TotalCost:    c.header.TotalCost,
 		GasCost:      c.header.GasFee,
 		ContractFee:  c.header.ContractFee,
-		Status:       c.header.Status,
+
+		CostBreakdown: c.header.CostBreakdown,
+		Status:        c.header.Status,
+		MirrorOf:      c.header.MirrorOf,
 	}
 	return
 }
@@ -212,6 +212,142 @@ func TestContract_CommitUploadAndRollback(t *testing.T) {
 	}
 }
 
+func TestContract_PrepareRevisionAndRollback(t *testing.T) {
+	contract, err := newContract()
+	if err != nil {
+		t.Fatalf("failed to generate new contract: %s", err.Error())
+	}
+
+	defer contract.db.Close()
+	defer contract.db.EmptyDB()
+
+	originHeader := contract.Header()
+	revision := storageContractRevisionGenerator()
+
+	wt, err := contract.PrepareRevision(revision)
+	if err != nil {
+		t.Fatalf("failed to prepare the revision: %s", err.Error())
+	}
+	if len(contract.unappliedTxns) != 1 {
+		t.Fatalf("expected 1 unapplied transaction, got %v", len(contract.unappliedTxns))
+	}
+
+	if err := contract.CommitRevision(revision, common.RandomBigInt()); err != nil {
+		t.Fatalf("failed to commit the revision: %s", err.Error())
+	}
+	if contract.Header().LatestContractRevision.RLPHash() != revision.RLPHash() {
+		t.Fatal("the committed revision is not equal to the revision prepared")
+	}
+
+	// the host failed to acknowledge the revision, the revision shall be rolled back
+	if err := contract.RollbackRevision(wt); err != nil {
+		t.Fatalf("failed to roll back the revision: %s", err.Error())
+	}
+	if err := contractHeaderComparator(contract.Header(), originHeader); err != nil {
+		t.Fatalf("the contract header is not rolled back: %s", err.Error())
+	}
+	fetchedHeader, err := contract.db.FetchContractHeader(originHeader.ID)
+	if err != nil {
+		t.Fatalf("failed to fetch the contract header: %s", err.Error())
+	}
+	if err := contractHeaderComparator(fetchedHeader, originHeader); err != nil {
+		t.Fatalf("the contract header in db is not rolled back: %s", err.Error())
+	}
+	if len(contract.unappliedTxns) != 0 {
+		t.Fatalf("expected 0 unapplied transaction, got %v", len(contract.unappliedTxns))
+	}
+}
+
+func TestContract_ReconcileRevision(t *testing.T) {
+	contract, err := newContract()
+	if err != nil {
+		t.Fatalf("failed to generate new contract: %s", err.Error())
+	}
+
+	defer contract.db.Close()
+	defer contract.db.EmptyDB()
+
+	originHeader := contract.Header()
+	oldRev := originHeader.LatestContractRevision
+	newRev := oldRev
+	newRev.NewRevisionNumber++
+
+	// the negotiation is interrupted after the client committed the revision
+	prepareInterrupted := func() {
+		wt, err := contract.PrepareRevision(newRev)
+		if err != nil {
+			t.Fatalf("failed to prepare the revision: %s", err.Error())
+		}
+		if err := contract.CommitRevision(newRev, common.RandomBigInt()); err != nil {
+			t.Fatalf("failed to commit the revision: %s", err.Error())
+		}
+		contract.removeUnappliedTxn(wt)
+		contract.pendingIntents = append(contract.pendingIntents, wt)
+	}
+
+	// the storage host does not have the revision, thus it is rolled back
+	prepareInterrupted()
+	if err := contract.ReconcileRevision(oldRev); err != nil {
+		t.Fatalf("failed to reconcile the revision: %s", err.Error())
+	}
+	if err := contractHeaderComparator(contract.Header(), originHeader); err != nil {
+		t.Fatalf("the contract header is not rolled back: %s", err.Error())
+	}
+	if contract.PendingRevision() {
+		t.Fatal("the revision intent is not released after reconciled")
+	}
+
+	// the storage host has committed the revision, thus it is kept
+	prepareInterrupted()
+	if err := contract.ReconcileRevision(newRev); err != nil {
+		t.Fatalf("failed to reconcile the revision: %s", err.Error())
+	}
+	if contract.Header().LatestContractRevision.NewRevisionNumber != newRev.NewRevisionNumber {
+		t.Fatalf("expected revision number %v kept, got %v", newRev.NewRevisionNumber, contract.Header().LatestContractRevision.NewRevisionNumber)
+	}
+	if contract.PendingRevision() {
+		t.Fatal("the revision intent is not released after reconciled")
+	}
+
+	// the revision of the storage host matches neither revision of the intent
+	prepareInterrupted()
+	unknownRev := newRev
+	unknownRev.NewRevisionNumber += 10
+	if err := contract.ReconcileRevision(unknownRev); err == nil {
+		t.Fatal("the unknown revision of the storage host is reconciled")
+	}
+	if !contract.PendingRevision() {
+		t.Fatal("the revision intent is released without reconciled")
+	}
+}
+
+func TestGroupRevisionIntents(t *testing.T) {
+	contract, err := newContract()
+	if err != nil {
+		t.Fatalf("failed to generate new contract: %s", err.Error())
+	}
+
+	defer contract.db.Close()
+	defer contract.db.EmptyDB()
+
+	wt, err := contract.PrepareRevision(storageContractRevisionGenerator())
+	if err != nil {
+		t.Fatalf("failed to prepare the revision: %s", err.Error())
+	}
+	undoTxn, err := contract.UndoRevisionLog(contract.Header())
+	if err != nil {
+		t.Fatalf("failed to record the undo revision log: %s", err.Error())
+	}
+
+	intents, err := groupRevisionIntents([]*writeaheadlog.Transaction{wt, undoTxn})
+	if err != nil {
+		t.Fatalf("failed to group the revision intents: %s", err.Error())
+	}
+	if len(intents) != 1 || len(intents[contract.Header().ID]) != 1 || intents[contract.Header().ID][0] != wt {
+		t.Fatalf("unexpected revision intents grouped: %v", intents)
+	}
+}
+
 /*
  _____  _____  _______      __  _______ ______      ______ _    _ _   _  _____ _______ _____ ____  _   _
 |  __ \|  __ \|_   _\ \    / /\|__   __|  ____|    |  ____| |  | | \ | |/ ____|__   __|_   _/ __ \| \ | |
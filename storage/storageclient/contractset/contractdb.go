@@ -81,6 +81,11 @@ func (db *DB) DeleteHeaderAndRoots(id storage.ContractID) (err error) {
 		return
 	}
 
+	// delete the negotiation transcript from the database
+	if err = db.DeleteTranscript(id); err != nil {
+		return
+	}
+
 	return
 }
 
@@ -88,7 +93,7 @@ func (db *DB) DeleteHeaderAndRoots(id storage.ContractID) (err error) {
 func (db *DB) FetchAllContractID() (ids []storage.ContractID) {
 	iter := db.lvl.NewIterator(nil, nil)
 	for iter.Next() {
-		if bytes.HasSuffix(iter.Key(), []byte(dbMerkleRoot)) {
+		if bytes.HasSuffix(iter.Key(), []byte(dbMerkleRoot)) || bytes.HasSuffix(iter.Key(), []byte(dbTranscript)) {
 			continue
 		}
 
@@ -305,6 +310,47 @@ func (db *DB) DeleteMerkleRoots(id storage.ContractID) (err error) {
 	return db.lvl.Delete(key, nil)
 }
 
+// StoreTranscript will store the negotiation transcript of the contract into the database
+func (db *DB) StoreTranscript(id storage.ContractID, transcript []storage.TranscriptEntry) (err error) {
+	key, err := makeKey(id, dbTranscript)
+	if err != nil {
+		return
+	}
+	blob, err := json.Marshal(transcript)
+	if err != nil {
+		return
+	}
+	return db.lvl.Put(key, blob, nil)
+}
+
+// FetchTranscript will retrieve the negotiation transcript of the contract. If no negotiation
+// has been recorded, empty transcript will be returned
+func (db *DB) FetchTranscript(id storage.ContractID) (transcript []storage.TranscriptEntry, err error) {
+	key, err := makeKey(id, dbTranscript)
+	if err != nil {
+		return
+	}
+
+	blob, err := db.lvl.Get(key, nil)
+	if err == errors.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(blob, &transcript)
+	return
+}
+
+// DeleteTranscript will delete the negotiation transcript of the contract from the database
+func (db *DB) DeleteTranscript(id storage.ContractID) (err error) {
+	key, err := makeKey(id, dbTranscript)
+	if err != nil {
+		return
+	}
+	return db.lvl.Delete(key, nil)
+}
+
 // newPersistentDB will initialize a new DB object which is used
 // to store storage contract information
 func newPersistentDB(path string) (db *DB, err error) {
@@ -25,6 +25,9 @@ type ContractHeader struct {
 	//
 	//Encode Private Key to String: hex.EncodeToString(crypto.FromECDSA(k.PrivateKey))
 	//Change the string back to private key: privkey, err := crypto.HexToECDSA(keyJSON.PrivateKey)
+	//
+	// The key is sealed once the contract is inserted if the keyring is enabled, which could be
+	// retrieved with StorageContractSet.RetrievePrivateKey
 	PrivateKey string
 
 	StartHeight uint64
@@ -37,9 +40,16 @@ type ContractHeader struct {
 	GasFee       common.BigInt
 	ContractFee  common.BigInt
 
+	// CostBreakdown is the itemized cost negotiated when the contract is created
+	CostBreakdown storage.ContractCostBreakdown
+
 	// status specifies if the contract is good for file uploading or renewing.
 	// it also specifies if the contract is canceled
 	Status storage.ContractStatus
+
+	// MirrorOf is the host of the primary contract mirrored by the contract, which is
+	// empty for the primary contracts
+	MirrorOf enode.ID
 }
 
 func (ch *ContractHeader) validation() (err error) {
@@ -5,6 +5,7 @@
 package contractset
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -15,6 +16,7 @@ import (
 	"github.com/DxChainNetwork/godx/common/writeaheadlog"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/keyring"
 	dberrors "github.com/syndtr/goleveldb/leveldb/errors"
 )
 
@@ -27,11 +29,15 @@ type StorageContractSet struct {
 	lock             sync.Mutex
 	rl               *RateLimit
 	wal              *writeaheadlog.Wal
+
+	// keyring seals the private keys of the contracts persisted
+	keyring *keyring.Keyring
 }
 
 // New will initialize the StorageContractSet object, as well as
-// loading the data that already stored in the database
-func New(persistDir string) (scs *StorageContractSet, err error) {
+// loading the data that already stored in the database. The private keys of the contracts
+// inserted are sealed by the keyring
+func New(persistDir string, kr *keyring.Keyring) (scs *StorageContractSet, err error) {
 	// initialize the directory
 	if err = os.MkdirAll(persistDir, 0700); err != nil {
 		err = fmt.Errorf("error initializing directory: %s", err.Error())
@@ -58,6 +64,7 @@ func New(persistDir string) (scs *StorageContractSet, err error) {
 		persistDir:       persistDir,
 		db:               db,
 		wal:              wal,
+		keyring:          kr,
 	}
 
 	// initialize rateLimit object
@@ -93,6 +100,12 @@ func (scs *StorageContractSet) InsertContract(ch ContractHeader, roots []common.
 		return
 	}
 
+	// seal the private key before the header is persisted
+	if ch.PrivateKey, err = sealPrivateKey(scs.keyring, ch.PrivateKey); err != nil {
+		err = fmt.Errorf("failed to seal the contract private key: %s", err.Error())
+		return
+	}
+
 	// save the contract header and roots information
 	if err = scs.db.StoreContractHeader(ch); err != nil {
 		err = fmt.Errorf("failed to store contract header information into database: %s",
@@ -175,6 +188,10 @@ func (scs *StorageContractSet) Delete(c *Contract) (err error) {
 	// delete memory contract information
 	delete(scs.contracts, c.header.ID)
 
+	// the revision intents of the deleted contract are no longer reconciled. Those failed
+	// to be released are released on restart as the contract no longer exists
+	releaseErr := c.releasePendingRevision()
+
 	c.lock.Unlock()
 
 	// delete disk contract information
@@ -182,7 +199,7 @@ func (scs *StorageContractSet) Delete(c *Contract) (err error) {
 		return
 	}
 
-	return
+	return releaseErr
 }
 
 // IDs return a list of storage contract id stored in the contract set
@@ -219,6 +236,19 @@ func (scs *StorageContractSet) RetrieveContractMetaData(id storage.ContractID) (
 	return
 }
 
+// RetrieveTranscript will return the negotiation transcript of the contract
+func (scs *StorageContractSet) RetrieveTranscript(id storage.ContractID) (transcript []storage.TranscriptEntry, exist bool, err error) {
+	scs.lock.Lock()
+	_, exist = scs.contracts[id]
+	scs.lock.Unlock()
+
+	if !exist {
+		return
+	}
+	transcript, err = scs.db.FetchTranscript(id)
+	return
+}
+
 // RetrieveAllContractsMetaData will return all ContractMetaData stored in the contract set
 // in the form of list
 func (scs *StorageContractSet) RetrieveAllContractsMetaData() (cms []storage.ContractMetaData) {
@@ -239,6 +269,12 @@ func (scs *StorageContractSet) loadContract(walTxns []*writeaheadlog.Transaction
 	// get all the contract id
 	ids := scs.db.FetchAllContractID()
 
+	// group the revision intents by contract id
+	intents, err := groupRevisionIntents(walTxns)
+	if err != nil {
+		return
+	}
+
 	// iterate through all contract id
 	var ch ContractHeader
 	var roots []common.Hash
@@ -260,10 +296,6 @@ func (scs *StorageContractSet) loadContract(walTxns []*writeaheadlog.Transaction
 			return fmt.Errorf("failed to load merkle roots, load contract failed: %s", err.Error())
 		}
 
-		// TODO (mzhang): currently, un-applied WAL transaction will be ignored
-		// in the future, they should be handled, however, the negotiation process
-		// needs to be modified
-
 		// initialize contract
 		c := &Contract{
 			header:      ch,
@@ -272,16 +304,52 @@ func (scs *StorageContractSet) loadContract(walTxns []*writeaheadlog.Transaction
 			wal:         scs.wal,
 		}
 
+		// the revision intents left in the wal are negotiations interrupted before the
+		// storage host acknowledged the revision. Whether the storage host has committed the
+		// revision is unknown until asked, thus the intents are kept and reconciled with the
+		// storage host's latest revision at the next negotiation
+		c.pendingIntents = intents[id]
+		delete(intents, id)
+
 		// update contract set
 		scs.contracts[id] = c
 		scs.hostToContractID[c.header.EnodeID] = c.header.ID
 
 	}
 
+	// release the revision intents whose contract no longer exists
+	for _, txns := range intents {
+		for _, t := range txns {
+			if err = t.Release(); err != nil {
+				return
+			}
+		}
+	}
+
 	err = nil
 	return
 }
 
+// groupRevisionIntents will group the revision intent transactions in the wal by the
+// contract id. Other transactions are ignored
+func groupRevisionIntents(walTxns []*writeaheadlog.Transaction) (intents map[storage.ContractID][]*writeaheadlog.Transaction, err error) {
+	intents = make(map[storage.ContractID][]*writeaheadlog.Transaction)
+	for _, t := range walTxns {
+		for _, op := range t.Operations {
+			if op.Name != dbRevisionIntent {
+				continue
+			}
+			var intent walRevisionIntentEntry
+			if err = json.Unmarshal(op.Data, &intent); err != nil {
+				return nil, fmt.Errorf("failed to decode the revision intent: %s", err.Error())
+			}
+			intents[intent.ID] = append(intents[intent.ID], t)
+			break
+		}
+	}
+	return
+}
+
 // Contracts is used to get all active contracts signed by the storage client
 func (scs *StorageContractSet) Contracts() map[storage.ContractID]*Contract {
 	scs.lock.Lock()
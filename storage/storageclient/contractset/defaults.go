@@ -15,6 +15,8 @@ const (
 
 	dbContractHeader = ":contractheader"
 	dbMerkleRoot     = ":roots"
+	dbRevisionIntent = ":revisionintent"
+	dbTranscript     = ":transcript"
 )
 
 const (
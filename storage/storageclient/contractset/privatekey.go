@@ -0,0 +1,61 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package contractset
+
+import (
+	"encoding/hex"
+
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/keyring"
+)
+
+// sealPrivateKey seals the hex encoded private key of the contract header with the keyring. The
+// sealed key is hex encoded as well, so that it could be persisted in the JSON encoded header.
+// The key already sealed, or not sealed by the disabled keyring, is returned as is
+func sealPrivateKey(kr *keyring.Keyring, privateKey string) (string, error) {
+	if privateKey == "" || isSealedPrivateKey(privateKey) {
+		return privateKey, nil
+	}
+	sealed, err := kr.Seal([]byte(privateKey))
+	if err != nil {
+		return "", err
+	}
+	if !keyring.IsSealed(sealed) {
+		return privateKey, nil
+	}
+	return hex.EncodeToString(sealed), nil
+}
+
+// openPrivateKey opens the private key sealed by sealPrivateKey
+func openPrivateKey(kr *keyring.Keyring, privateKey string) (string, error) {
+	if !isSealedPrivateKey(privateKey) {
+		return privateKey, nil
+	}
+	sealed, _ := hex.DecodeString(privateKey)
+	opened, err := kr.Open(sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(opened), nil
+}
+
+func isSealedPrivateKey(privateKey string) bool {
+	sealed, err := hex.DecodeString(privateKey)
+	return err == nil && keyring.IsSealed(sealed)
+}
+
+// RetrievePrivateKey returns the hex encoded private key of the contract, which is opened with
+// the keyring if sealed. keyring.ErrLocked is returned if the keyring is locked
+func (scs *StorageContractSet) RetrievePrivateKey(id storage.ContractID) (privateKey string, exist bool, err error) {
+	scs.lock.Lock()
+	contract, exist := scs.contracts[id]
+	scs.lock.Unlock()
+
+	if !exist {
+		return
+	}
+	privateKey, err = openPrivateKey(scs.keyring, contract.Header().PrivateKey)
+	return
+}
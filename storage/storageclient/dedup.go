@@ -0,0 +1,163 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+)
+
+var dedupIndexMetadata = common.Metadata{
+	Header:  "storage client sector dedup index",
+	Version: PersistStorageClientVersion,
+}
+
+type (
+	// sectorDedupIndex is the content addressed index of the sectors uploaded under the
+	// contracts. The sectors are encrypted with random nonces before uploaded, thus the index
+	// is keyed by the hash of the cipher key and the sector content before encryption. The
+	// sector indexed could be referenced by the file encrypted with the same cipher key
+	// instead of being uploaded again
+	sectorDedupIndex struct {
+		sectors    map[common.Hash]dedupSector
+		persistDir string
+		lock       sync.Mutex
+	}
+
+	// dedupSector is the location of the sector indexed
+	dedupSector struct {
+		HostID     enode.ID    `json:"hostID"`
+		MerkleRoot common.Hash `json:"merkleRoot"`
+	}
+
+	// persistDedupSector is the persisted entry of the sector dedup index
+	persistDedupSector struct {
+		Key common.Hash `json:"key"`
+		dedupSector
+	}
+)
+
+// newSectorDedupIndex creates the sector dedup index persisted in persistDir
+func newSectorDedupIndex(persistDir string) *sectorDedupIndex {
+	return &sectorDedupIndex{
+		sectors:    make(map[common.Hash]dedupSector),
+		persistDir: persistDir,
+	}
+}
+
+// sectorDedupKey returns the key of the sector content encrypted with the cipher key
+func sectorDedupKey(key crypto.CipherKey, data []byte) common.Hash {
+	return crypto.Keccak256Hash([]byte(key.CodeName()), key.Key(), data)
+}
+
+// add indexes the sector uploaded to the host
+func (di *sectorDedupIndex) add(key common.Hash, hostID enode.ID, root common.Hash) {
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	di.sectors[key] = dedupSector{HostID: hostID, MerkleRoot: root}
+}
+
+// lookup returns the sector indexed with the key
+func (di *sectorDedupIndex) lookup(key common.Hash) (dedupSector, bool) {
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	sector, exists := di.sectors[key]
+	return sector, exists
+}
+
+// prune removes the sectors no longer referenced by any dxfile, which might be trimmed from
+// the contracts, and returns the number of sectors removed
+func (di *sectorDedupIndex) prune(referenced map[enode.ID]map[common.Hash]struct{}) int {
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	var pruned int
+	for key, sector := range di.sectors {
+		if _, exists := referenced[sector.HostID][sector.MerkleRoot]; !exists {
+			delete(di.sectors, key)
+			pruned++
+		}
+	}
+	return pruned
+}
+
+// load loads the sector dedup index from the persist file
+func (di *sectorDedupIndex) load() error {
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	var sectors []persistDedupSector
+	err := common.LoadDxJSON(dedupIndexMetadata, filepath.Join(di.persistDir, DedupIndexFilename), &sectors)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, sector := range sectors {
+		di.sectors[sector.Key] = sector.dedupSector
+	}
+	return nil
+}
+
+// save saves the sector dedup index to the persist file
+func (di *sectorDedupIndex) save() error {
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	sectors := make([]persistDedupSector, 0, len(di.sectors))
+	for key, sector := range di.sectors {
+		sectors = append(sectors, persistDedupSector{Key: key, dedupSector: sector})
+	}
+	return common.SaveDxJSON(dedupIndexMetadata, filepath.Join(di.persistDir, DedupIndexFilename), sectors)
+}
+
+// dedupSegmentSectors references the sectors of the segment already stored under the contracts
+// instead of uploading them again. The sector is referenced only if the contract with the host
+// storing it is good for renew, the host is online, and the host does not store another sector
+// of the segment. The dedup keys of the sectors to be uploaded are kept in the segment, so that
+// the sectors are indexed once uploaded. The caller must hold no lock of the segment, and the segment shall not be dispatched
+// yet. The number of the sectors referenced is returned
+func (client *StorageClient) dedupSegmentSectors(uc *unfinishedUploadSegment, key crypto.CipherKey) (deduped int) {
+	contractSet := client.contractManager.GetStorageContractSet()
+	uc.dedupKeys = make([]common.Hash, len(uc.sectorSlotsStatus))
+	for i := 0; i < len(uc.sectorSlotsStatus); i++ {
+		if uc.sectorSlotsStatus[i] || uc.physicalSegmentData[i] == nil {
+			continue
+		}
+		uc.dedupKeys[i] = sectorDedupKey(key, uc.physicalSegmentData[i])
+		sector, exists := client.dedupIndex.lookup(uc.dedupKeys[i])
+		if !exists {
+			continue
+		}
+		contractID := contractSet.GetContractIDByHostID(sector.HostID)
+		if meta, ok := contractSet.RetrieveContractMetaData(contractID); !ok || !meta.Status.RenewAbility {
+			continue
+		}
+		if _, unused := uc.unusedHosts[sector.HostID.String()]; !unused {
+			continue
+		}
+		if health, exists := client.contractManager.HostHealthMapByID([]enode.ID{sector.HostID})[sector.HostID]; !exists || health.Offline {
+			continue
+		}
+		if err := uc.fileEntry.AddSector(sector.HostID, sector.MerkleRoot, int(uc.index), i); err != nil {
+			client.log.Warn("failed to reference the deduplicated sector", "err", err)
+			continue
+		}
+		client.contractManager.AddFileSegment(contractID, uc.fileEntry.DxPath(), uc.index)
+		delete(uc.unusedHosts, sector.HostID.String())
+		uc.sectorSlotsStatus[i] = true
+		uc.sectorsCompletedNum++
+		uc.physicalSegmentData[i] = nil
+		deduped++
+	}
+	return
+}
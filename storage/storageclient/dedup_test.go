@@ -0,0 +1,81 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+)
+
+// TestSectorDedupKey test the dedup key differs by both the cipher key and the sector content
+func TestSectorDedupKey(t *testing.T) {
+	key1, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("sector data")
+	if sectorDedupKey(key1, data) != sectorDedupKey(key1, data) {
+		t.Errorf("dedup key of the same content not equal")
+	}
+	if sectorDedupKey(key1, data) == sectorDedupKey(key2, data) {
+		t.Errorf("dedup key not differ by the cipher key")
+	}
+	if sectorDedupKey(key1, data) == sectorDedupKey(key1, []byte("other data")) {
+		t.Errorf("dedup key not differ by the sector content")
+	}
+}
+
+// TestSectorDedupIndex test the sectors indexed are pruned if not referenced, and persisted
+func TestSectorDedupIndex(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "storageclient", t.Name())
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	di := newSectorDedupIndex(dir)
+
+	host := enode.ID{1}
+	kept, pruned := common.Hash{1}, common.Hash{2}
+	di.add(kept, host, common.Hash{11})
+	di.add(pruned, host, common.Hash{12})
+	if sector, exists := di.lookup(kept); !exists || sector.HostID != host || sector.MerkleRoot != (common.Hash{11}) {
+		t.Fatalf("unexpected sector looked up: %+v, %v", sector, exists)
+	}
+
+	referenced := map[enode.ID]map[common.Hash]struct{}{
+		host: {common.Hash{11}: {}},
+	}
+	if num := di.prune(referenced); num != 1 {
+		t.Errorf("pruned %v sectors, expect 1", num)
+	}
+	if _, exists := di.lookup(pruned); exists {
+		t.Errorf("sector not referenced is not pruned")
+	}
+
+	if err := di.save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded := newSectorDedupIndex(dir)
+	if err := loaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	if sector, exists := loaded.lookup(kept); !exists || sector.HostID != host || sector.MerkleRoot != (common.Hash{11}) {
+		t.Errorf("unexpected sector loaded: %+v, %v", sector, exists)
+	}
+	if len(loaded.sectors) != 1 {
+		t.Errorf("loaded %v sectors, expect 1", len(loaded.sectors))
+	}
+}
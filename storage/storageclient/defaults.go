@@ -6,12 +6,17 @@ package storageclient
 
 import (
 	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
 )
 
 // Files and directories related constant
 const (
 	PersistDirectory            = "storageclient"
 	PersistFilename             = "storageclient.json"
+	WebhookFilename             = "webhooks.json"
+	DedupIndexFilename          = "dedupindex.json"
+	SegmentCacheDirectory       = "segmentcache"
 	PersistStorageClientVersion = "1.0"
 	DxPathRoot                  = "dxfiles"
 )
@@ -31,14 +36,79 @@ const (
 	// how long to wait for a worker after a worker failed to perform a download task.
 	DownloadFailureCooldown = time.Second * 3
 
+	// how long to wait for a worker after the negotiation with the host failed, before probing the host.
+	HostFailureCooldown = time.Second * 3
+
 	// how many times a bad host's timeout/cool down can be doubled before a maximum cool down is reached.
 	MaxConsecutivePenalty = 10
+
+	// the max number of sectors a worker coalesces into a single download request
+	MaxDownloadBatchSectors = 4
+
+	// a worker defers the sector to the workers whose hosts' download throughput is at least
+	// the ratio of its host's throughput
+	fasterHostThroughputRatio = 2
+
+	// the max number of files uploaded concurrently when uploading a directory
+	MaxConcurrentDirUploads = 4
+
+	// the size of the buffer used to hash a file when uploading a directory
+	DirUploadBufferSize = 1 << 20
+)
+
+// Sector garbage collection related constants
+const (
+	// the interval between the passes collecting the sectors no longer referenced by any dxfile.
+	// A sector is marked as unreferenced only if found unreferenced in two consecutive passes,
+	// so that the sectors uploaded but not yet recorded in the dxfile are not marked
+	SectorGCInterval = time.Hour
+)
+
+// Spot check related constants
+const (
+	// the interval between the passes spot checking the hosts of the active contracts
+	SpotCheckInterval = 30 * time.Minute
+
+	// the max number of segments downloaded in one spot check
+	SpotCheckMaxSegments = 4
+)
+
+// Mirror contract related constants
+const (
+	// the interval between the passes checking whether the storage proofs of the mirrored
+	// contracts are missed. It must be short enough for the state at the end of the proof
+	// window to be still available
+	MirrorProofCheckInterval = 10 * time.Minute
+)
+
+// Webhook related constants
+const (
+	// the max number of attempts to deliver a webhook event
+	WebhookMaxAttempts = 5
+
+	// the interval before the first retry of the failed webhook delivery, which is doubled for
+	// each retry
+	WebhookRetryInterval = 5 * time.Second
+
+	// the timeout of each webhook delivery
+	WebhookTimeout = 10 * time.Second
 )
 
 const (
 	// DefaultMaxMemory available
 	DefaultMaxMemory = uint64(3 * 1 << 28)
 	extraRatio       = 0.02
+
+	// MaxUploadBatchSize is the max byte budget of the sectors in one upload request, so
+	// that the upload request fits in the max protocol message size of 10 MiB
+	MaxUploadBatchSize = 2 * storage.SectorSize
+
+	// DefaultUploadBatchSize is the default byte budget of the sectors in one upload request
+	DefaultUploadBatchSize = MaxUploadBatchSize
+
+	// segmentCacheFileExt is the extension of the segment files in the disk tier of the
+	// segment cache
+	segmentCacheFileExt = ".segment"
 )
 
 // Default params about upload/download process
@@ -71,4 +141,6 @@ var (
 	UploadFailureCoolDown = 3 * time.Second
 )
 
-var keys = []string{"fund", "hosts", "period", "violation", "uploadspeed", "downloadspeed"}
+var keys = []string{"fund", "hosts", "period", "violation", "uploadspeed", "downloadspeed", "hostfundratio",
+	"preferregions", "requireregions", "maxmemory", "evictionratio", "evictionhours", "uploadbatchsize",
+	"segmentcachesize", "segmentcachedisksize", "mirror", "hostselection"}
@@ -6,11 +6,23 @@ package storageclient
 
 import (
 	"container/heap"
+	"sort"
 	"time"
 
 	"github.com/DxChainNetwork/godx/log"
 )
 
+// DownloadInfo is the information of a pending download
+type DownloadInfo struct {
+	ID                string    `json:"id"`
+	DxPath            string    `json:"dxPath"`
+	Destination       string    `json:"destination"`
+	Length            uint64    `json:"length"`
+	Priority          uint64    `json:"priority"`
+	SegmentsRemaining uint64    `json:"segmentsRemaining"`
+	StartTime         time.Time `json:"startTime"`
+}
+
 // download tasks are added to the downloadSegmentHeap.
 // As resources become available to execute downloads,
 // segments are pulled off of the heap and distributed to workers.
@@ -147,6 +159,13 @@ func (client *StorageClient) distributeDownloadSegmentToWorkers(uds *unfinishedD
 	client.lock.Lock()
 	uds.mu.Lock()
 	uds.workersRemaining = uint32(len(client.workerPool))
+	uds.hostThroughput = make(map[string]float64)
+	for _, worker := range client.workerPool {
+		hostID := worker.hostID.String()
+		if _, exist := uds.segmentMap[hostID]; exist {
+			uds.hostThroughput[hostID] = client.storageHostManager.DownloadThroughput(worker.hostID)
+		}
+	}
 	uds.mu.Unlock()
 	for _, worker := range client.workerPool {
 		worker.queueDownloadSegment(uds)
@@ -169,6 +188,86 @@ func (client *StorageClient) addSegmentToDownloadHeap(uds *unfinishedDownloadSeg
 
 	// put the segment into the segment heap.
 	client.downloadHeapMu.Lock()
-	client.downloadHeap.Push(uds)
+	heap.Push(client.downloadHeap, uds)
+	client.downloadHeapMu.Unlock()
+}
+
+// addPendingDownload track the download by its id until the download is complete
+func (client *StorageClient) addPendingDownload(d *download) {
+	client.downloadHeapMu.Lock()
+	client.downloads[d.id] = d
+	client.downloadHeapMu.Unlock()
+
+	d.onComplete(func(_ error) error {
+		client.downloadHeapMu.Lock()
+		delete(client.downloads, d.id)
+		client.downloadHeapMu.Unlock()
+		return nil
+	})
+}
+
+// PendingDownloads returns the information of the downloads which are not completed yet,
+// sorted by the start time
+func (client *StorageClient) PendingDownloads() []DownloadInfo {
+	client.downloadHeapMu.Lock()
+	infos := make([]DownloadInfo, 0, len(client.downloads))
+	downloads := make([]*download, 0, len(client.downloads))
+	for _, d := range client.downloads {
+		infos = append(infos, DownloadInfo{
+			ID:          d.id,
+			DxPath:      d.dxFile.DxPath().Path,
+			Destination: d.destinationString,
+			Length:      d.length,
+			Priority:    d.priority,
+			StartTime:   d.startTime,
+		})
+		downloads = append(downloads, d)
+	}
+	client.downloadHeapMu.Unlock()
+
+	for i, d := range downloads {
+		d.mu.Lock()
+		infos[i].SegmentsRemaining = d.segmentsRemaining
+		d.mu.Unlock()
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartTime.Before(infos[j].StartTime)
+	})
+	return infos
+}
+
+// CancelDownload cancels the pending download with the given id. The segments of the download
+// remaining in the download heap will be dropped, and the workers will stop working on the download
+func (client *StorageClient) CancelDownload(id string) error {
+	client.downloadHeapMu.Lock()
+	d, exist := client.downloads[id]
 	client.downloadHeapMu.Unlock()
+	if !exist {
+		return errDownloadNotFound
+	}
+
+	if !d.cancel() {
+		return errDownloadNotFound
+	}
+	return nil
+}
+
+// SetDownloadPriority changes the priority of the pending download with the given id. The segments of
+// the download which are not distributed to workers yet will be re-ordered in the download heap
+func (client *StorageClient) SetDownloadPriority(id string, priority uint64) error {
+	client.downloadHeapMu.Lock()
+	defer client.downloadHeapMu.Unlock()
+
+	d, exist := client.downloads[id]
+	if !exist {
+		return errDownloadNotFound
+	}
+	d.priority = priority
+	for _, uds := range *client.downloadHeap {
+		if uds.download == d {
+			uds.priority = priority
+		}
+	}
+	heap.Init(client.downloadHeap)
+	return nil
 }
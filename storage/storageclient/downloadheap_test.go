@@ -0,0 +1,71 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"container/heap"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/log"
+)
+
+func TestStorageClient_SetDownloadPriorityAndCancel(t *testing.T) {
+	client := &StorageClient{
+		downloadHeap: new(downloadSegmentHeap),
+		downloads:    make(map[string]*download),
+	}
+
+	// the large download is queued before the urgent one with a higher priority
+	large := newTestDownload(5, time.Now())
+	urgent := newTestDownload(1, time.Now().Add(time.Second))
+	for _, d := range []*download{large, urgent} {
+		client.addPendingDownload(d)
+		for i := uint64(0); i < 3; i++ {
+			heap.Push(client.downloadHeap, &unfinishedDownloadSegment{segmentIndex: i, priority: d.priority, download: d})
+		}
+	}
+	if len(client.downloads) != 2 {
+		t.Fatalf("pending downloads not expected. Got %v, Expect %v", len(client.downloads), 2)
+	}
+
+	// boost the priority of the urgent download, its segments should be processed first
+	if err := client.SetDownloadPriority(urgent.id, 10); err != nil {
+		t.Fatal(err)
+	}
+	if uds := client.nextDownloadSegment(); uds.download != urgent {
+		t.Fatalf("segment of the urgent download should be processed first")
+	}
+
+	// cancel the large download, its segments should be dropped
+	if err := client.CancelDownload(large.id); err != nil {
+		t.Fatal(err)
+	}
+	if large.Err() != errDownloadCancelled {
+		t.Errorf("download error not expected. Got %v, Expect %v", large.Err(), errDownloadCancelled)
+	}
+	for uds := client.nextDownloadSegment(); uds != nil; uds = client.nextDownloadSegment() {
+		if uds.download == large {
+			t.Fatalf("segment of the cancelled download should not be processed")
+		}
+	}
+	if err := client.CancelDownload(large.id); err != errDownloadNotFound {
+		t.Errorf("cancelled download should be removed. Got %v, Expect %v", err, errDownloadNotFound)
+	}
+	if err := client.SetDownloadPriority("unknown", 1); err != errDownloadNotFound {
+		t.Errorf("error not expected. Got %v, Expect %v", err, errDownloadNotFound)
+	}
+}
+
+// newTestDownload creates a download used for testing
+func newTestDownload(priority uint64, startTime time.Time) *download {
+	return &download{
+		id:           newDownloadID(),
+		completeChan: make(chan struct{}),
+		startTime:    startTime,
+		priority:     priority,
+		log:          log.New(),
+	}
+}
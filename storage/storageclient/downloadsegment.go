@@ -62,8 +62,10 @@ type unfinishedDownloadSegment struct {
 	// whether or not the segment successfully downloaded
 	failed bool
 
-	// the data used to recover the logical data
+	// the data used to recover the logical data, and the checksums of the data verified
+	// before the recovery
 	physicalSegmentData [][]byte
+	sectorChecksums     []uint64
 
 	// which sectors in the segment are fetching
 	sectorUsage []bool
@@ -83,9 +85,19 @@ type unfinishedDownloadSegment struct {
 	// backup workers that can be used to download when other workers fail
 	workersStandby []*worker
 
+	// the measured download throughput of the hosts of the workers holding the sectors,
+	// and the workers already deferred to the faster hosts
+	hostThroughput  map[string]float64
+	workersDeferred map[string]struct{}
+
 	// record how much memory allocated
 	memoryAllocated uint64
 
+	// the segment cache the recovered segment is added to with the cache key. Nil if the
+	// segment is not cacheable
+	segmentCache *segmentCache
+	cacheKey     common.Hash
+
 	// used to update download progress
 	download *download
 	mu       sync.Mutex
@@ -142,6 +154,39 @@ func (uds *unfinishedDownloadSegment) cleanUp() {
 	}
 }
 
+// deferToFasterHosts decides whether the worker of the host should be put on standby in favor of
+// the workers of the significantly faster hosts, which hold enough sectors not taken yet to fill
+// the needed sectors. Each worker is deferred at most once, so that it takes the sector when put
+// back from standby, e.g. the faster workers failed. The caller must hold the uds lock
+func (uds *unfinishedDownloadSegment) deferToFasterHosts(hostID string, needed uint32) bool {
+	if _, deferred := uds.workersDeferred[hostID]; deferred {
+		return false
+	}
+	throughput := uds.hostThroughput[hostID]
+	if throughput == 0 {
+		return false
+	}
+
+	var faster uint32
+	for id, t := range uds.hostThroughput {
+		if t < throughput*fasterHostThroughputRatio {
+			continue
+		}
+		index := uds.segmentMap[id].index
+		if !uds.sectorUsage[index] && !uds.completedSectors[index] {
+			faster++
+		}
+	}
+	if faster < needed {
+		return false
+	}
+	if uds.workersDeferred == nil {
+		uds.workersDeferred = make(map[string]struct{})
+	}
+	uds.workersDeferred[hostID] = struct{}{}
+	return true
+}
+
 // fail will set the segment status to failed
 func (uds *unfinishedDownloadSegment) fail(err error) {
 	uds.failed = true
@@ -204,7 +249,19 @@ func (uds *unfinishedDownloadSegment) recoverLogicalData() error {
 	defer uds.cleanUp()
 
 	// NOTE: for not supporting partial encoding, we directly recover the whole sector
-	// recover the sectors into the logical segment data.
+	// verify and decrypt the sectors, then recover them into the logical segment data.
+	if err := uds.verifyPhysicalSegmentData(); err != nil {
+		uds.mu.Lock()
+		uds.fail(err)
+		uds.mu.Unlock()
+		return err
+	}
+	if err := uds.decryptPhysicalSegmentData(); err != nil {
+		uds.mu.Lock()
+		uds.fail(err)
+		uds.mu.Unlock()
+		return err
+	}
 	recoverWriter := new(bytes.Buffer)
 	err := uds.erasureCode.Recover(uds.physicalSegmentData, int(uds.segmentSize), recoverWriter)
 	if err != nil {
@@ -219,20 +276,28 @@ func (uds *unfinishedDownloadSegment) recoverLogicalData() error {
 		uds.physicalSegmentData[i] = nil
 	}
 
-	// get recovered data
+	// get recovered data, and cache it for the following downloads
 	recoveredData := recoverWriter.Bytes()
+	recoverWriter = nil
+	if uds.segmentCache != nil && uint64(len(recoveredData)) == uds.segmentSize {
+		uds.segmentCache.add(uds.cacheKey, recoveredData)
+	}
+	return uds.writeLogicalData(recoveredData)
+}
 
+// writeLogicalData writes the requested part of the recovered segment data to the destination,
+// and signals the completion of the segment
+func (uds *unfinishedDownloadSegment) writeLogicalData(recoveredData []byte) error {
 	// write the bytes to the requested output.
 	start := uds.fetchOffset
 	end := start + uds.fetchLength
-	_, err = uds.destination.WriteAt(recoveredData[start:end], uds.writeOffset)
+	_, err := uds.destination.WriteAt(recoveredData[start:end], uds.writeOffset)
 	if err != nil {
 		uds.mu.Lock()
 		uds.fail(err)
 		uds.mu.Unlock()
 		return fmt.Errorf("unable to write to download destination,error: %v", err)
 	}
-	recoverWriter = nil
 
 	uds.mu.Lock()
 	uds.recoveryComplete = true
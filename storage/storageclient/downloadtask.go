@@ -5,6 +5,10 @@
 package storageclient
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -13,11 +17,22 @@ import (
 	"github.com/DxChainNetwork/godx/storage/storageclient/memorymanager"
 )
 
+var (
+	// errDownloadNotFound is returned if the download does not exist or has been completed
+	errDownloadNotFound = errors.New("download not found or already completed")
+
+	// errDownloadCancelled is the error of the download cancelled by the user
+	errDownloadCancelled = errors.New("download is cancelled")
+)
+
 type (
 
 	// a file download that has been queued by the client.
 	download struct {
 
+		// the unique identifier of the download, used to cancel or re-prioritize the download
+		id string
+
 		// incremented as data completes, will stop at 100% file progress.
 		dataReceived uint64
 
@@ -132,6 +147,29 @@ func (d *download) fail(err error) {
 	d.markComplete()
 }
 
+// cancel will mark the download as complete with errDownloadCancelled. If the download
+// has already completed, false is returned
+func (d *download) cancel() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.isComplete() {
+		return false
+	}
+	d.err = errDownloadCancelled
+	d.markComplete()
+	return true
+}
+
+// newDownloadID creates a random id for the download
+func newDownloadID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
 // return whether or not the download has completed.
 func (d *download) isComplete() bool {
 	select {
@@ -7,7 +7,9 @@ package filesystem
 import (
 	"fmt"
 
+	"github.com/DxChainNetwork/godx/common/unit"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
 )
 
 // PublicFileSystemDebugAPI is the APIs for the file system
@@ -146,3 +148,93 @@ func (api *PublicFileSystemAPI) Delete(path string) string {
 	}
 	return fmt.Sprintf("File %v deleted", path)
 }
+
+// Versions returns the versions retained for the file specified by the path, which could be
+// downloaded or restored until the contracts storing the sectors expire
+func (api *PublicFileSystemAPI) Versions(path string) ([]dxfile.VersionInfo, error) {
+	dxPath, err := storage.NewDxPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("path not valid: %v", path)
+	}
+	return api.fs.FileVersions(dxPath)
+}
+
+// PrivateFileSystemAPI is the private api for file system
+type PrivateFileSystemAPI struct {
+	fs FileSystem
+}
+
+// NewPrivateFileSystemAPI creates a new private file system api
+func NewPrivateFileSystemAPI(fs FileSystem) *PrivateFileSystemAPI {
+	return &PrivateFileSystemAPI{fs}
+}
+
+// SetQuota sets the quota of the directory specified by the path. The maxSize is the storage
+// string, e.g. "10GiB", and the maxFiles is the max number of files. Zero means no limit, and
+// the quota is removed if both are zero
+func (api *PrivateFileSystemAPI) SetQuota(path string, maxSize string, maxFiles uint64) string {
+	dxPath := storage.RootDxPath()
+	if path != "" && path != "/" {
+		var err error
+		if dxPath, err = storage.NewDxPath(path); err != nil {
+			return fmt.Sprintf("Path not valid: %v", path)
+		}
+	}
+	var size uint64
+	if maxSize != "" && maxSize != "0" {
+		var err error
+		if size, err = unit.ParseStorage(maxSize); err != nil {
+			return fmt.Sprintf("Invalid storage string: %v", err)
+		}
+	}
+	if err := api.fs.SetQuota(dxPath, DirQuota{MaxSize: size, MaxFiles: maxFiles}); err != nil {
+		return fmt.Sprintf("Cannot set the quota of %v: %v", path, err)
+	}
+	return fmt.Sprintf("Quota of directory %v set", path)
+}
+
+// RestoreVersion restores the version of the file specified by the path as the current file. The
+// current file is retained as a new version
+func (api *PrivateFileSystemAPI) RestoreVersion(path string, id uint64) string {
+	dxPath, err := storage.NewDxPath(path)
+	if err != nil {
+		return fmt.Sprintf("Path not valid: %v", path)
+	}
+	if err = api.fs.RestoreDxFileVersion(dxPath, id); err != nil {
+		return fmt.Sprintf("Cannot restore version %v of file %v: %v", id, path, err)
+	}
+	if parent, err := dxPath.Parent(); err == nil {
+		if err = api.fs.InitAndUpdateDirMetadata(parent); err != nil {
+			api.fs.getLogger().Warn("InitAndUpdateDirMetadata error", "error", err)
+		}
+	}
+	return fmt.Sprintf("Version %v of file %v restored", id, path)
+}
+
+// Quotas returns the quotas of the directories
+func (api *PrivateFileSystemAPI) Quotas() map[string]DirQuota {
+	return api.fs.Quotas()
+}
+
+// SetRedundancy sets the redundancy target of the file or directory specified by the path. The
+// files uploaded to the path are encoded into numSectors sectors, any minSectors of which could
+// recover the file. The target is removed if both are zero
+func (api *PrivateFileSystemAPI) SetRedundancy(path string, minSectors uint32, numSectors uint32) string {
+	dxPath := storage.RootDxPath()
+	if path != "" && path != "/" {
+		var err error
+		if dxPath, err = storage.NewDxPath(path); err != nil {
+			return fmt.Sprintf("Path not valid: %v", path)
+		}
+	}
+	target := RedundancyTarget{MinSectors: minSectors, NumSectors: numSectors}
+	if err := api.fs.SetRedundancy(dxPath, target); err != nil {
+		return fmt.Sprintf("Cannot set the redundancy target of %v: %v", path, err)
+	}
+	return fmt.Sprintf("Redundancy target of %v set", path)
+}
+
+// Redundancies returns the redundancy targets of the files and directories
+func (api *PrivateFileSystemAPI) Redundancies() map[string]RedundancyTarget {
+	return api.fs.Redundancies()
+}
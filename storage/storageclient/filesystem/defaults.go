@@ -35,6 +35,12 @@ const (
 
 	// updateWalName is the fileName for the updateWal
 	updateWalName = "update.wal"
+
+	// quotaFileName is the fileName for the directory quotas
+	quotaFileName = "quota.json"
+
+	// redundancyFileName is the fileName for the redundancy targets of the files and directories
+	redundancyFileName = "redundancy.json"
 )
 
 const (
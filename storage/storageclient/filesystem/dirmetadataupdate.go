@@ -472,6 +472,7 @@ func (fs *fileSystem) calculateDxFileMetadata(path storage.DxPath, filename stri
 	if err = file.MarkAllHealthySegmentsAsUnstuck(healthInfoTable); err != nil {
 		return nil, fmt.Errorf("cannot mark unstuck segments for file %v: %v", fileDxPath.Path, err)
 	}
+	prevHealth := file.GetHealth()
 	health, stuckHealth, numStuckSegments := file.Health(healthInfoTable)
 	redundancy := file.Redundancy(healthInfoTable)
 
@@ -484,6 +485,13 @@ func (fs *fileSystem) calculateDxFileMetadata(path storage.DxPath, filename stri
 		StuckHealth: stuckHealth,
 		Redundancy:  redundancy,
 	}
+	if health != prevHealth {
+		fs.healthFeed.Send(FileHealthEvent{
+			DxPath:     fileDxPath,
+			PrevHealth: prevHealth,
+			Health:     health,
+		})
+	}
 	// apply cached metadata and return
 	return &metadataForUpdate{
 		numFiles:            1,
@@ -19,6 +19,7 @@ import (
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+	"github.com/DxChainNetwork/godx/storage/storageclient/keyring"
 )
 
 const (
@@ -50,6 +51,9 @@ type (
 		ID      FileID
 		wal     *writeaheadlog.Wal
 
+		// keyring seals the cipher key persisted in the metadata
+		keyring *keyring.Keyring
+
 		// filePath is full file path
 		filePath storage.SysPath
 
@@ -81,10 +85,10 @@ type (
 
 // New creates a new dxfile.
 // filePath is the file where DxFile locates, dxPath is the user input dxPath.
-// sourcePath is the file of the original data. wal is the writeaheadlog.
-// erasureCode is the erasure coder for encoding. cipherKey is the key for encryption.
+// sourcePath is the file of the original data. wal is the writeaheadlog. kr is the keyring sealing
+// the cipher key persisted. erasureCode is the erasure coder for encoding. cipherKey is the key for encryption.
 // fileSize is the size of the original data file. fileMode is the file privilege mode (e.g. 0777)
-func New(filePath storage.SysPath, dxPath storage.DxPath, sourcePath storage.SysPath, wal *writeaheadlog.Wal, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode) (*DxFile, error) {
+func New(filePath storage.SysPath, dxPath storage.DxPath, sourcePath storage.SysPath, wal *writeaheadlog.Wal, kr *keyring.Keyring, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode) (*DxFile, error) {
 	currentTime := uint64(time.Now().Unix())
 	// create the params for erasureCode and cipherKey
 	minSectors, numSectors, extra, err := erasureCodeToParams(erasureCode)
@@ -92,6 +96,10 @@ func New(filePath storage.SysPath, dxPath storage.DxPath, sourcePath storage.Sys
 		return nil, err
 	}
 	cipherKeyCode := crypto.CipherCodeByName(cipherKey.CodeName())
+	sealedKey, err := kr.Seal(cipherKey.Key())
+	if err != nil {
+		return nil, fmt.Errorf("cannot seal the cipher key: %v", err)
+	}
 	// create a random FileID
 	var id FileID
 	_, err = rand.Read(id[:])
@@ -109,7 +117,7 @@ func New(filePath storage.SysPath, dxPath storage.DxPath, sourcePath storage.Sys
 		LocalPath:       sourcePath,
 		DxPath:          dxPath,
 		CipherKeyCode:   cipherKeyCode,
-		CipherKey:       cipherKey.Key(),
+		CipherKey:       sealedKey,
 		TimeModify:      currentTime,
 		TimeCreate:      currentTime,
 		FileMode:        fileMode,
@@ -128,6 +136,7 @@ func New(filePath storage.SysPath, dxPath storage.DxPath, sourcePath storage.Sys
 		deleted:     false,
 		ID:          id,
 		wal:         wal,
+		keyring:     kr,
 		filePath:    filePath,
 		erasureCode: erasureCode,
 		cipherKey:   cipherKey,
@@ -8,13 +8,17 @@ import (
 	"bytes"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/DxChainNetwork/godx/common/writeaheadlog"
+	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/rlp"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+	"github.com/DxChainNetwork/godx/storage/storageclient/keyring"
 )
 
 // TestPruneSegment test df.pruneSegment
@@ -100,7 +104,7 @@ func TestAddSector(t *testing.T) {
 	}
 	filename := testDir.Join(path)
 	wal := df.wal
-	recoveredDF, err := readDxFile(filename, wal)
+	recoveredDF, err := readDxFile(filename, wal, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -160,7 +164,7 @@ func TestMarkAllUnhealthySegmentsAsStuck(t *testing.T) {
 		}
 		filename := testDir.Join(path)
 		wal := df.wal
-		recoveredDF, err := readDxFile(filename, wal)
+		recoveredDF, err := readDxFile(filename, wal, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -191,7 +195,7 @@ func TestMarkAllHealthySegmentsAsUnstuck(t *testing.T) {
 		}
 		filename := testDir.Join(path)
 		wal := df.wal
-		recoveredDF, err := readDxFile(filename, wal)
+		recoveredDF, err := readDxFile(filename, wal, nil)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -275,7 +279,7 @@ func TestSetStuckByIndex(t *testing.T) {
 			t.Fatal(err)
 		}
 		filename := testDir.Join(path)
-		recoveredDF, err := readDxFile(filename, df.wal)
+		recoveredDF, err := readDxFile(filename, df.wal, nil)
 		if err != nil {
 			t.Fatalf("test %d: %v", i, err)
 		}
@@ -399,7 +403,7 @@ func TestRename(t *testing.T) {
 		t.Errorf("file %v should have been deleted", oldDxFilePath)
 	}
 
-	recoveredDF, err := readDxFile(newDxFilePath, df.wal)
+	recoveredDF, err := readDxFile(newDxFilePath, df.wal, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -442,7 +446,7 @@ func TestApplyCachedHealthMetadata(t *testing.T) {
 	}
 	filename := testDir.Join(path)
 
-	recoveredDF, err := readDxFile(filename, df.wal)
+	recoveredDF, err := readDxFile(filename, df.wal, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -450,3 +454,56 @@ func TestApplyCachedHealthMetadata(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+// TestSealedCipherKey test the cipher key is sealed by the keyring when persisted, and the
+// DxFile could still be read while the keyring is locked
+func TestSealedCipherKey(t *testing.T) {
+	krDir := tempDir(t.Name() + "keyring")
+	kr, err := keyring.New(string(krDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kr.SetPassphrase("", "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	ec, _ := erasurecode.New(erasurecode.ECTypeStandard, 10, 30)
+	ck, _ := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	path, err := storage.NewDxPath(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := testDir.Join(path)
+	wal, txns, _ := writeaheadlog.New(filepath.Join(string(testDir), t.Name()+".wal"))
+	for _, txn := range txns {
+		txn.Release()
+	}
+	if _, err := New(filename, path, "", wal, kr, ec, ck, SectorSize*10, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	// read the DxFile with the keyring locked
+	locked, err := keyring.New(string(krDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	df, err := readDxFile(filename, wal, locked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !keyring.IsSealed(df.metadata.CipherKey) {
+		t.Fatal("cipher key persisted not sealed")
+	}
+	if _, err := df.CipherKey(); err != keyring.ErrLocked {
+		t.Fatalf("expect %v, got %v", keyring.ErrLocked, err)
+	}
+	if err := locked.Unlock("passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	key, err := df.CipherKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.Key(), ck.Key()) {
+		t.Errorf("cipher key not expected: %x != %x", key.Key(), ck.Key())
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+	"github.com/DxChainNetwork/godx/storage/storageclient/keyring"
 )
 
 const threadDepth = 3
@@ -41,6 +42,9 @@ type (
 
 		lock sync.Mutex
 		wal  *writeaheadlog.Wal
+
+		// keyring seals the cipher keys of the DxFiles
+		keyring *keyring.Keyring
 	}
 
 	// fileSetEntry is an entry for fileSet. fileSetEntry extends DxFile.
@@ -68,17 +72,18 @@ type (
 	}
 )
 
-// NewFileSet create a new DxFileSet with provided rootDir and wal.
-func NewFileSet(rootDir storage.SysPath, wal *writeaheadlog.Wal) *FileSet {
+// NewFileSet create a new DxFileSet with provided rootDir, wal and keyring.
+func NewFileSet(rootDir storage.SysPath, wal *writeaheadlog.Wal, kr *keyring.Keyring) *FileSet {
 	return &FileSet{
 		rootDir:  rootDir,
 		filesMap: make(map[storage.DxPath]*fileSetEntry),
 		wal:      wal,
+		keyring:  kr,
 	}
 }
 
 // NewDxFile create a DxFile based on the params given. Return a FileSetEntryWithID that has been
-// registered with threadID in FileSetEntry
+// registered with threadID in FileSetEntry. The file overwritten is retained as a version
 func (fs *FileSet) NewDxFile(dxPath storage.DxPath, sourcePath storage.SysPath, force bool, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode) (*FileSetEntryWithID, error) {
 	fs.lock.Lock()
 	defer fs.lock.Unlock()
@@ -86,8 +91,13 @@ func (fs *FileSet) NewDxFile(dxPath storage.DxPath, sourcePath storage.SysPath,
 	if exists && !force {
 		return nil, ErrFileExist
 	}
+	if exists {
+		if err := fs.retainVersion(dxPath); err != nil {
+			return nil, err
+		}
+	}
 	// Create a new DxFile
-	df, err := New(fs.filepath(dxPath), dxPath, sourcePath, fs.wal, erasureCode, cipherKey, fileSize, fileMode)
+	df, err := New(fs.filepath(dxPath), dxPath, sourcePath, fs.wal, fs.keyring, erasureCode, cipherKey, fileSize, fileMode)
 	if err != nil {
 		return nil, err
 	}
@@ -129,7 +139,7 @@ func (fs *FileSet) open(dxPath storage.DxPath) (*FileSetEntryWithID, error) {
 	entry, exist := fs.filesMap[dxPath]
 	if !exist {
 		// file not loaded or not exist. Try to read DxFile from disk.
-		df, err := readDxFile(fs.filepath(dxPath), fs.wal)
+		df, err := readDxFile(fs.filepath(dxPath), fs.wal, fs.keyring)
 		if os.IsNotExist(err) {
 			return nil, ErrUnknownFile
 		}
@@ -153,7 +163,8 @@ func (fs *FileSet) open(dxPath storage.DxPath) (*FileSetEntryWithID, error) {
 	}, nil
 }
 
-// Delete delete a file with dxPath from the file set. Also the DxFile specified by dxPath on disk is also deleted
+// Delete delete a file with dxPath from the file set. Also the DxFile specified by dxPath on disk is also deleted,
+// along with the versions retained
 func (fs *FileSet) Delete(dxPath storage.DxPath) error {
 	fs.lock.Lock()
 	defer fs.lock.Unlock()
@@ -174,7 +185,7 @@ func (fs *FileSet) Delete(dxPath storage.DxPath) error {
 	}
 
 	delete(fs.filesMap, entry.metadata.DxPath)
-	return nil
+	return fs.deleteVersions(dxPath)
 }
 
 // Exists is the public function that returns whether the dxPath exists (cached then on disk)
@@ -195,7 +206,7 @@ func (fs *FileSet) exists(dxPath storage.DxPath) bool {
 	return !os.IsNotExist(err)
 }
 
-// Rename rename the file with dxPath to newDxPath.
+// Rename rename the file with dxPath to newDxPath. The versions retained are moved along with the file
 func (fs *FileSet) Rename(dxPath, newDxPath storage.DxPath) error {
 	fs.lock.Lock()
 	defer fs.lock.Unlock()
@@ -213,7 +224,10 @@ func (fs *FileSet) Rename(dxPath, newDxPath storage.DxPath) error {
 	fs.filesMap[newDxPath] = entry.fileSetEntry
 	delete(fs.filesMap, dxPath)
 
-	return entry.Rename(newDxPath, fs.filepath(newDxPath))
+	if err = entry.Rename(newDxPath, fs.filepath(newDxPath)); err != nil {
+		return err
+	}
+	return fs.renameVersions(dxPath, newDxPath)
 }
 
 // Close close a FileSetEntryWithID
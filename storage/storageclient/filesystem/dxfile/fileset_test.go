@@ -20,7 +20,7 @@ import (
 // return the added DxFile and the new FileSet.
 func newTestFileSet(t *testing.T) (*FileSetEntryWithID, *FileSet) {
 	wal, _ := newWal(t)
-	fs := NewFileSet(testDir, wal)
+	fs := NewFileSet(testDir, wal, nil)
 	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 10, 30)
 	if err != nil {
 		t.Fatal(err)
@@ -70,7 +70,7 @@ func newTestDxFile(t *testing.T, fileSize uint64, minSectors, numSectors uint32,
 	for _, txn := range txns {
 		txn.Release()
 	}
-	df, err := New(filename, path, storage.SysPath(filepath.Join("~/tmp", t.Name())), wal, ec, ck, fileSize, 0777)
+	df, err := New(filename, path, storage.SysPath(filepath.Join("~/tmp", t.Name())), wal, nil, ec, ck, fileSize, 0777)
 	if err != nil {
 		return nil, err
 	}
@@ -13,6 +13,7 @@ import (
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+	"github.com/DxChainNetwork/godx/storage/storageclient/keyring"
 )
 
 type (
@@ -219,7 +220,8 @@ func (df *DxFile) SegmentSize() uint64 {
 	return df.metadata.segmentSize()
 }
 
-// CipherKey return the cipher key
+// CipherKey return the cipher key. keyring.ErrLocked is returned if the cipher key is sealed
+// and the keyring is locked
 func (df *DxFile) CipherKey() (crypto.CipherKey, error) {
 	df.lock.RLock()
 	defer df.lock.RUnlock()
@@ -227,7 +229,10 @@ func (df *DxFile) CipherKey() (crypto.CipherKey, error) {
 	if df.cipherKey != nil {
 		return df.cipherKey, nil
 	}
-	key, err := crypto.NewCipherKey(df.metadata.CipherKeyCode, df.metadata.CipherKey)
+	key, err := df.openCipherKey()
+	if err == keyring.ErrLocked {
+		return nil, err
+	}
 	if err != nil {
 		// this should never happen
 		log.Error("New Cipher Key return an error: %v", err)
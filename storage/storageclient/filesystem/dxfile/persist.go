@@ -189,9 +189,14 @@ func erasureCodeToParams(ec erasurecode.ErasureCoder) (uint32, uint32, []byte, e
 	}
 }
 
-// newCipherKey create a new cipher key based on metadata params
-func (md Metadata) newCipherKey() (crypto.CipherKey, error) {
-	return crypto.NewCipherKey(md.CipherKeyCode, md.CipherKey)
+// openCipherKey create a new cipher key based on metadata params, opening the key sealed
+// by the keyring
+func (df *DxFile) openCipherKey() (crypto.CipherKey, error) {
+	key, err := df.keyring.Open(df.metadata.CipherKey)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.NewCipherKey(df.metadata.CipherKeyCode, key)
 }
 
 // segmentSize is the helper function to calculate the Segment size based on metadata info
@@ -12,14 +12,17 @@ import (
 	"github.com/DxChainNetwork/godx/common/writeaheadlog"
 	"github.com/DxChainNetwork/godx/rlp"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/keyring"
 )
 
 // readDxFile create a new DxFile with a random ID, then open and read the dxfile from filepath
-// and load all params from the file.
-func readDxFile(filepath storage.SysPath, wal *writeaheadlog.Wal) (*DxFile, error) {
+// and load all params from the file. The cipher key sealed by the keyring is opened once the
+// keyring is unlocked, so that the file could still be read while the keyring is locked.
+func readDxFile(filepath storage.SysPath, wal *writeaheadlog.Wal, kr *keyring.Keyring) (*DxFile, error) {
 	df := &DxFile{
 		filePath: filepath,
 		wal:      wal,
+		keyring:  kr,
 	}
 	f, err := os.OpenFile(string(filepath), os.O_RDONLY, 0777)
 	if os.IsNotExist(err) {
@@ -44,7 +47,7 @@ func readDxFile(filepath storage.SysPath, wal *writeaheadlog.Wal) (*DxFile, erro
 		return nil, fmt.Errorf("cannot new erasureCode: %v", err)
 	}
 	// New cipher key
-	if df.cipherKey, err = df.metadata.newCipherKey(); err != nil {
+	if df.cipherKey, err = df.openCipherKey(); err != nil && err != keyring.ErrLocked {
 		return nil, fmt.Errorf("cannot new cipherKey: %v", err)
 	}
 	return df, nil
@@ -38,7 +38,7 @@ func TestPersist(t *testing.T) {
 		}
 		filename := testDir.Join(path)
 		wal := df.wal
-		newDF, err := readDxFile(filename, wal)
+		newDF, err := readDxFile(filename, wal, nil)
 		if err != nil {
 			t.Fatalf(err.Error())
 		}
@@ -118,7 +118,7 @@ func TestDxFile_SaveHostTableUpdate(t *testing.T) {
 		}
 		filename := testDir.Join(path)
 		wal := df.wal
-		newDF, err := readDxFile(filename, wal)
+		newDF, err := readDxFile(filename, wal, nil)
 		if err != nil {
 			t.Fatalf("test %d: %v", i, err)
 		}
@@ -150,7 +150,7 @@ func TestDxFile_SaveSegment(t *testing.T) {
 		t.Fatal(err)
 	}
 	filename := testDir.Join(path)
-	newDF, err := readDxFile(filename, df.wal)
+	newDF, err := readDxFile(filename, df.wal, nil)
 	if err != nil {
 		t.Fatalf("%v", err)
 	}
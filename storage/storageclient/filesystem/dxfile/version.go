@@ -0,0 +1,255 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package dxfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// ErrUnknownVersion is the error for opening a file version that not exists on disk
+var ErrUnknownVersion = errors.New("file version not known")
+
+// VersionInfo is the brief info of a version retained when the DxFile is overwritten
+type VersionInfo struct {
+	ID         uint64
+	FileSize   uint64
+	TimeModify time.Time
+}
+
+// Versions returns the versions retained for the dxPath, sorted by the version id
+func (fs *FileSet) Versions(dxPath storage.DxPath) ([]VersionInfo, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	ids, err := fs.versionIDs(dxPath)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]VersionInfo, 0, len(ids))
+	for _, id := range ids {
+		df, err := readDxFile(fs.versionFilePath(dxPath, id), fs.wal, fs.keyring)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read version %v of %v: %v", id, dxPath.Path, err)
+		}
+		versions = append(versions, VersionInfo{
+			ID:         id,
+			FileSize:   df.FileSize(),
+			TimeModify: df.TimeModify(),
+		})
+	}
+	return versions, nil
+}
+
+// OpenVersion opens the version of the dxPath. The DxFile returned is only meant to be read,
+// e.g. creating the snapshot for download
+func (fs *FileSet) OpenVersion(dxPath storage.DxPath, id uint64) (*DxFile, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	df, err := readDxFile(fs.versionFilePath(dxPath, id), fs.wal, fs.keyring)
+	if os.IsNotExist(err) {
+		return nil, ErrUnknownVersion
+	}
+	return df, err
+}
+
+// RestoreVersion restores the version of the dxPath as the current file. The current file is
+// retained as a new version before replaced, so the restore could be undone
+func (fs *FileSet) RestoreVersion(dxPath storage.DxPath, id uint64) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	version, err := readDxFile(fs.versionFilePath(dxPath, id), fs.wal, fs.keyring)
+	if os.IsNotExist(err) {
+		return ErrUnknownVersion
+	}
+	if err != nil {
+		return err
+	}
+	if fs.exists(dxPath) {
+		if err = fs.retainVersion(dxPath); err != nil {
+			return err
+		}
+	}
+	df, err := version.copyTo(fs.filepath(dxPath))
+	if err != nil {
+		return err
+	}
+	fs.filesMap[dxPath] = fs.newFileSetEntry(df)
+	return nil
+}
+
+// DeleteVersion deletes the version of the dxPath
+func (fs *FileSet) DeleteVersion(dxPath storage.DxPath, id uint64) error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	err := os.Remove(string(fs.versionFilePath(dxPath, id)))
+	if os.IsNotExist(err) {
+		return ErrUnknownVersion
+	}
+	return err
+}
+
+// retainVersion copies the current DxFile of the dxPath to a new version before the file is
+// overwritten. Only the segment map is copied, while the sectors are shared by the version and
+// the file until the file is repaired or re-uploaded, which is copy-on-write in nature
+func (fs *FileSet) retainVersion(dxPath storage.DxPath) error {
+	entry, err := fs.open(dxPath)
+	if err != nil {
+		return err
+	}
+	defer fs.closeEntry(entry)
+
+	ids, err := fs.versionIDs(dxPath)
+	if err != nil {
+		return err
+	}
+	var id uint64 = 1
+	if len(ids) != 0 {
+		id = ids[len(ids)-1] + 1
+	}
+	if _, err = entry.copyTo(fs.versionFilePath(dxPath, id)); err != nil {
+		return fmt.Errorf("cannot retain version %v of %v: %v", id, dxPath.Path, err)
+	}
+	return nil
+}
+
+// renameVersions moves the versions of the dxPath along with the file renamed to newDxPath
+func (fs *FileSet) renameVersions(dxPath, newDxPath storage.DxPath) error {
+	ids, err := fs.versionIDs(dxPath)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		version, err := readDxFile(fs.versionFilePath(dxPath, id), fs.wal, fs.keyring)
+		if err != nil {
+			return err
+		}
+		if err = version.rename(newDxPath, fs.versionFilePath(newDxPath, id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteVersions deletes all versions of the dxPath along with the file deleted
+func (fs *FileSet) deleteVersions(dxPath storage.DxPath) error {
+	ids, err := fs.versionIDs(dxPath)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err = os.Remove(string(fs.versionFilePath(dxPath, id))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// versionIDs returns the ids of the versions of the dxPath on disk in ascending order
+func (fs *FileSet) versionIDs(dxPath storage.DxPath) ([]uint64, error) {
+	prefix := string(fs.filepath(dxPath)) + "."
+	matches, err := filepath.Glob(prefix + "*" + storage.DxFileVersionExt)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint64
+	for _, match := range matches {
+		str := strings.TrimSuffix(strings.TrimPrefix(match, prefix), storage.DxFileVersionExt)
+		id, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// versionFilePath returns the path of the version file, which is placed next to the DxFile
+func (fs *FileSet) versionFilePath(dxPath storage.DxPath, id uint64) storage.SysPath {
+	return storage.SysPath(fmt.Sprintf("%s.%d%s", fs.filepath(dxPath), id, storage.DxFileVersionExt))
+}
+
+// ParseVersionFilePath parses the DxPath and the version id from the path of the version file
+// relative to the root directory
+func ParseVersionFilePath(path string) (storage.DxPath, uint64, error) {
+	str := strings.TrimSuffix(path, storage.DxFileVersionExt)
+	i := strings.LastIndex(str, ".")
+	if i < 0 || !strings.HasSuffix(str[:i], storage.DxFileExt) {
+		return storage.DxPath{}, 0, fmt.Errorf("invalid version file path: %v", path)
+	}
+	id, err := strconv.ParseUint(str[i+1:], 10, 64)
+	if err != nil {
+		return storage.DxPath{}, 0, fmt.Errorf("invalid version id: %v", err)
+	}
+	dxPath, err := storage.NewDxPath(strings.TrimSuffix(str[:i], storage.DxFileExt))
+	if err != nil {
+		return storage.DxPath{}, 0, err
+	}
+	return dxPath, id, nil
+}
+
+// copyTo copies the DxFile to the file path, and returns the DxFile copied
+func (df *DxFile) copyTo(filePath storage.SysPath) (*DxFile, error) {
+	df.lock.RLock()
+	defer df.lock.RUnlock()
+
+	if df.deleted {
+		return nil, errors.New("file already deleted")
+	}
+	md := *df.metadata
+	copied := &DxFile{
+		metadata:    &md,
+		hostTable:   make(hostTable, len(df.hostTable)),
+		segments:    make([]*Segment, len(df.segments)),
+		ID:          df.ID,
+		wal:         df.wal,
+		keyring:     df.keyring,
+		filePath:    filePath,
+		erasureCode: df.erasureCode,
+		cipherKey:   df.cipherKey,
+	}
+	for host, used := range df.hostTable {
+		copied.hostTable[host] = used
+	}
+	for i, seg := range df.segments {
+		sectors := make([][]*Sector, len(seg.Sectors))
+		for j := range seg.Sectors {
+			for _, sector := range seg.Sectors[j] {
+				sectors[j] = append(sectors[j], &Sector{MerkleRoot: sector.MerkleRoot, HostID: sector.HostID})
+			}
+		}
+		copied.segments[i] = &Segment{Sectors: sectors, Index: seg.Index, Stuck: seg.Stuck}
+	}
+	return copied, copied.saveAll()
+}
+
+// SectorHosts returns the hosts storing the sectors of the DxFile
+func (df *DxFile) SectorHosts() map[enode.ID]struct{} {
+	df.lock.RLock()
+	defer df.lock.RUnlock()
+
+	hosts := make(map[enode.ID]struct{})
+	for _, seg := range df.segments {
+		for _, sectors := range seg.Sectors {
+			for _, sector := range sectors {
+				hosts[sector.HostID] = struct{}{}
+			}
+		}
+	}
+	return hosts
+}
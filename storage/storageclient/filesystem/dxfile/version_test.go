@@ -0,0 +1,104 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package dxfile
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+)
+
+// TestFileSet_Versions test the file overwritten is retained as a version, which could be
+// opened, restored, and is moved and deleted along with the file
+func TestFileSet_Versions(t *testing.T) {
+	entry, fs := newTestFileSet(t)
+	dxPath := entry.DxPath()
+	sector := randomSector()
+	if err := entry.AddSector(sector.HostID, sector.MerkleRoot, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	entry.Close()
+
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 10, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ck, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overwritten, err := fs.NewDxFile(dxPath, "", true, ec, ck, 1<<20, 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overwritten.Close()
+
+	versions, err := fs.Versions(dxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 || versions[0].ID != 1 || versions[0].FileSize != 1<<24 {
+		t.Fatalf("unexpected versions: %+v", versions)
+	}
+	version, err := fs.OpenVersion(dxPath, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := version.SectorHosts()[sector.HostID]; !exists {
+		t.Errorf("sectors not retained in the version")
+	}
+
+	// restore the version, and the file overwritten is retained as version 2
+	if err = fs.RestoreVersion(dxPath, 1); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := fs.Open(dxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.FileSize() != 1<<24 {
+		t.Errorf("file size %v, expect %v", restored.FileSize(), 1<<24)
+	}
+	restored.Close()
+	if versions, err = fs.Versions(dxPath); err != nil || len(versions) != 2 || versions[1].FileSize != 1<<20 {
+		t.Fatalf("unexpected versions: %+v, %v", versions, err)
+	}
+
+	// the versions are renamed and deleted along with the file
+	newPath := randomDxPath()
+	if err = fs.Rename(dxPath, newPath); err != nil {
+		t.Fatal(err)
+	}
+	if versions, err = fs.Versions(newPath); err != nil || len(versions) != 2 {
+		t.Fatalf("versions not renamed: %+v, %v", versions, err)
+	}
+	if _, err = fs.OpenVersion(dxPath, 1); err != ErrUnknownVersion {
+		t.Errorf("expect error %v, got %v", ErrUnknownVersion, err)
+	}
+	if err = fs.Delete(newPath); err != nil {
+		t.Fatal(err)
+	}
+	if versions, err = fs.Versions(newPath); err != nil || len(versions) != 0 {
+		t.Fatalf("versions not deleted: %+v, %v", versions, err)
+	}
+}
+
+// TestParseVersionFilePath test the DxPath and the version id parsed from the version file path
+func TestParseVersionFilePath(t *testing.T) {
+	_, fs := newTestFileSet(t)
+	dxPath := randomDxPath()
+	path := string(fs.versionFilePath(dxPath, 12))[len(fs.rootDir):]
+	parsed, id, err := ParseVersionFilePath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != dxPath || id != 12 {
+		t.Errorf("parsed %v %v, expect %v %v", parsed, id, dxPath, 12)
+	}
+	if _, _, err = ParseVersionFilePath("/a.dxversion"); err == nil {
+		t.Errorf("expect error parsing the invalid path")
+	}
+}
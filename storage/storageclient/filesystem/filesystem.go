@@ -21,11 +21,14 @@ import (
 	"github.com/DxChainNetwork/godx/common/threadmanager"
 	"github.com/DxChainNetwork/godx/common/writeaheadlog"
 	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/event"
 	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxdir"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+	"github.com/DxChainNetwork/godx/storage/storageclient/keyring"
 )
 
 // ErrNoRepairNeeded is the error that no repair is needed
@@ -77,6 +80,27 @@ type fileSystem struct {
 
 	// stuckFound is the channel to signal a stuck segment is found
 	stuckFound chan struct{}
+
+	// quotas is the mapping from the path of the directory to the quota of the directory
+	quotas    map[string]DirQuota
+	quotaLock sync.Mutex
+
+	// redundancies is the mapping from the path of the file or directory to the redundancy target
+	redundancies   map[string]RedundancyTarget
+	redundancyLock sync.Mutex
+
+	// keyring seals the cipher keys of the files persisted. Nil keyring persists the keys in plain
+	keyring *keyring.Keyring
+
+	// healthFeed sends the FileHealthEvent when the health of a file is changed
+	healthFeed event.Feed
+}
+
+// FileHealthEvent is the event sent when the health of the file is changed in the health check
+type FileHealthEvent struct {
+	DxPath     storage.DxPath
+	PrevHealth uint32
+	Health     uint32
 }
 
 // newFileSystem creates a new file system with the standardDisrupter
@@ -92,6 +116,8 @@ func newFileSystem(persistDir string, contractor contractManager, disrupter disr
 		unfinishedUpdates: make(map[storage.DxPath]*dirMetadataUpdate),
 		repairNeeded:      make(chan struct{}, 1),
 		stuckFound:        make(chan struct{}, 1),
+		quotas:            make(map[string]DirQuota),
+		redundancies:      make(map[string]RedundancyTarget),
 	}
 }
 
@@ -107,11 +133,19 @@ func (fs *fileSystem) Start() error {
 	if fs.dirSet, err = dxdir.NewDirSet(fs.fileRootDir, fs.fileWal); err != nil {
 		return fmt.Errorf("cannot start the file system dirSet: %v", err)
 	}
-	fs.fileSet = dxfile.NewFileSet(fs.fileRootDir, fs.fileWal)
+	fs.fileSet = dxfile.NewFileSet(fs.fileRootDir, fs.fileWal, fs.keyring)
 	// open the updateWal
 	if err := fs.loadUpdateWal(); err != nil {
 		return fmt.Errorf("cannot start the file system: %v", err)
 	}
+	// load the directory quotas
+	if err := fs.loadQuotas(); err != nil {
+		return fmt.Errorf("cannot load the directory quotas: %v", err)
+	}
+	// load the redundancy targets
+	if err := fs.loadRedundancies(); err != nil {
+		return fmt.Errorf("cannot load the redundancy targets: %v", err)
+	}
 	// Start the repair loop
 	go fs.loopRepairUnfinishedDirMetadataUpdate()
 	return nil
@@ -147,9 +181,43 @@ func (fs *fileSystem) PersistDir() storage.SysPath {
 	return fs.persistDir
 }
 
-// NewDxFile creates a new dxfile in the file system
+// NewDxFile creates a new dxfile in the file system. The file is rejected if the quotas of the
+// directories containing the file are exceeded
 func (fs *fileSystem) NewDxFile(dxPath storage.DxPath, sourcePath storage.SysPath, force bool, erasureCode erasurecode.ErasureCoder, cipherKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode) (*dxfile.FileSetEntryWithID, error) {
-	return fs.fileSet.NewDxFile(dxPath, sourcePath, force, erasureCode, cipherKey, fileSize, fileMode)
+	dir, err := dxPath.Parent()
+	if err != nil {
+		return nil, err
+	}
+	numFiles, size := uint64(1), fileSize
+	// the file overwritten is replaced by the new file
+	if force && fs.fileSet.Exists(dxPath) {
+		if prev, err := fs.fileSet.Open(dxPath); err == nil {
+			numFiles = 0
+			if prevSize := prev.FileSize(); prevSize < size {
+				size -= prevSize
+			} else {
+				size = 0
+			}
+			prev.Close()
+		}
+	}
+	if err := fs.checkQuota(dir, numFiles, size, nil); err != nil {
+		return nil, err
+	}
+	entry, err := fs.fileSet.NewDxFile(dxPath, sourcePath, force, erasureCode, cipherKey, fileSize, fileMode)
+	if err != nil {
+		return nil, err
+	}
+	// the segments indexed are of the file overwritten, which is retained as a version
+	if numFiles == 0 {
+		fs.contractManager.RemoveFile(dxPath)
+	}
+	return entry, nil
+}
+
+// SubscribeFileHealthEvent subscribes the events of the file health changes
+func (fs *fileSystem) SubscribeFileHealthEvent(ch chan<- FileHealthEvent) event.Subscription {
+	return fs.healthFeed.Subscribe(ch)
 }
 
 // OpenDxFile opens the DxFile specified by the path
@@ -159,12 +227,48 @@ func (fs *fileSystem) OpenDxFile(path storage.DxPath) (*dxfile.FileSetEntryWithI
 
 // Delete delete the dxfile from the file system
 func (fs *fileSystem) DeleteDxFile(dxPath storage.DxPath) error {
-	return fs.fileSet.Delete(dxPath)
+	if err := fs.fileSet.Delete(dxPath); err != nil {
+		return err
+	}
+	fs.contractManager.RemoveFile(dxPath)
+	return nil
 }
 
-// RenameDxFile rename the dxfile from prevPath to newPath
+// RenameDxFile rename the dxfile from prevPath to newPath. The file is not allowed to be moved
+// into the directory if the quota of the directory is exceeded
 func (fs *fileSystem) RenameDxFile(prevPath, newPath storage.DxPath) error {
-	return fs.fileSet.Rename(prevPath, newPath)
+	if err := fs.checkRenameQuota(prevPath, newPath); err != nil {
+		return err
+	}
+	if err := fs.fileSet.Rename(prevPath, newPath); err != nil {
+		return err
+	}
+	fs.contractManager.RenameFile(prevPath, newPath)
+	return nil
+}
+
+// checkRenameQuota checks the quotas of the directories the file is moved into
+func (fs *fileSystem) checkRenameQuota(prevPath, newPath storage.DxPath) error {
+	prevDir, err := prevPath.Parent()
+	if err != nil {
+		return err
+	}
+	newDir, err := newPath.Parent()
+	if err != nil {
+		return err
+	}
+	// the common ancestors already count the file
+	skip := make(map[string]struct{})
+	for _, path := range dxPathAncestors(prevDir) {
+		skip[path.Path] = struct{}{}
+	}
+	file, err := fs.fileSet.Open(prevPath)
+	if err != nil {
+		return err
+	}
+	size := file.FileSize()
+	file.Close()
+	return fs.checkQuota(newDir, 1, size, skip)
 }
 
 // NewDxDir creates a new dxdir specified by path
@@ -545,6 +649,88 @@ func (fs *fileSystem) fileList() ([]storage.FileBriefInfo, error) {
 	return fileList, err
 }
 
+// ReferencedSectors returns the merkle roots of the sectors referenced by all dxfiles and the
+// versions retained, grouped by the hosts storing the sectors. Any error reading the dxfiles is
+// returned, so that the sectors of the dxfile failed to read are never taken as unreferenced
+func (fs *fileSystem) ReferencedSectors() (map[enode.ID]map[common.Hash]struct{}, error) {
+	if err := fs.tm.Add(); err != nil {
+		return nil, err
+	}
+	defer fs.tm.Done()
+
+	referenced := make(map[enode.ID]map[common.Hash]struct{})
+	table := fs.contractManager.HostHealthMap()
+	err := filepath.Walk(string(fs.fileRootDir), func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(path) == storage.DxFileVersionExt {
+			return fs.referenceVersionSectors(referenced, strings.TrimPrefix(path, string(fs.fileRootDir)), table)
+		}
+		if info.IsDir() || filepath.Ext(path) != storage.DxFileExt {
+			return nil
+		}
+		str := strings.TrimSuffix(strings.TrimPrefix(path, string(fs.fileRootDir)), storage.DxFileExt)
+		dxPath, err := storage.NewDxPath(str)
+		if err != nil {
+			return err
+		}
+		file, err := fs.fileSet.Open(dxPath)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return addReferencedSectors(referenced, file.DxFile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return referenced, nil
+}
+
+// referenceVersionSectors adds the sectors of the version at the path relative to the root
+// directory to the referenced sectors. The version expired is pruned instead
+func (fs *fileSystem) referenceVersionSectors(referenced map[enode.ID]map[common.Hash]struct{}, path string, table storage.HostHealthInfoTable) error {
+	dxPath, id, err := dxfile.ParseVersionFilePath(path)
+	if err != nil {
+		return err
+	}
+	expired, err := fs.pruneVersion(dxPath, id, table)
+	if err != nil || expired {
+		return err
+	}
+	version, err := fs.fileSet.OpenVersion(dxPath, id)
+	if err != nil {
+		return err
+	}
+	return addReferencedSectors(referenced, version)
+}
+
+// addReferencedSectors adds the sectors of the dxfile to the referenced sectors
+func addReferencedSectors(referenced map[enode.ID]map[common.Hash]struct{}, df *dxfile.DxFile) error {
+	for segmentIndex := 0; segmentIndex < df.NumSegments(); segmentIndex++ {
+		sectors, err := df.Sectors(segmentIndex)
+		if err != nil {
+			return err
+		}
+		for _, sectorSet := range sectors {
+			for _, sector := range sectorSet {
+				if _, exists := referenced[sector.HostID]; !exists {
+					referenced[sector.HostID] = make(map[common.Hash]struct{})
+				}
+				referenced[sector.HostID][sector.MerkleRoot] = struct{}{}
+			}
+		}
+	}
+	return nil
+}
+
 // fileDetailedInfo returns detailed information for a file specified by the path
 // If the input table is empty, the code the query the contractManager for health info
 func (fs *fileSystem) fileDetailedInfo(path storage.DxPath, table storage.HostHealthInfoTable) (storage.FileInfo, error) {
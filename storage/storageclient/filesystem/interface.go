@@ -10,13 +10,16 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/event"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxdir"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+	"github.com/DxChainNetwork/godx/storage/storageclient/keyring"
 )
 
 // FileSystem is the interface for fileSystem
@@ -35,10 +38,24 @@ type FileSystem interface {
 	RenameDxFile(prevDxPath, curDxPath storage.DxPath) error
 	DeleteDxFile(dxPath storage.DxPath) error
 
+	// DxFile version related methods
+	FileVersions(dxPath storage.DxPath) ([]dxfile.VersionInfo, error)
+	OpenDxFileVersion(dxPath storage.DxPath, id uint64) (*dxfile.DxFile, error)
+	RestoreDxFileVersion(dxPath storage.DxPath, id uint64) error
+
 	// DxDir related methods, including New and open
 	NewDxDir(path storage.DxPath) (*dxdir.DirSetEntryWithID, error)
 	OpenDxDir(path storage.DxPath) (*dxdir.DirSetEntryWithID, error)
 
+	// Directory quota related methods
+	SetQuota(path storage.DxPath, quota DirQuota) error
+	Quotas() map[string]DirQuota
+
+	// Redundancy target related methods
+	SetRedundancy(path storage.DxPath, target RedundancyTarget) error
+	Redundancies() map[string]RedundancyTarget
+	RedundancyTarget(path storage.DxPath) RedundancyTarget
+
 	// Upload/Download logic related functions
 	InitAndUpdateDirMetadata(path storage.DxPath) error
 	SelectDxFileToFix() (*dxfile.FileSetEntryWithID, error)
@@ -46,6 +63,10 @@ type FileSystem interface {
 	OldestLastTimeHealthCheck() (storage.DxPath, time.Time, error)
 	RepairNeededChan() chan struct{}
 	StuckFoundChan() chan struct{}
+	SubscribeFileHealthEvent(ch chan<- FileHealthEvent) event.Subscription
+
+	// Sector garbage collection related functions
+	ReferencedSectors() (map[enode.ID]map[common.Hash]struct{}, error)
 
 	// private function fields used for APIs
 	getLogger() log.Logger
@@ -53,10 +74,13 @@ type FileSystem interface {
 	fileList() ([]storage.FileBriefInfo, error)
 }
 
-// New is the public function used for creating a production fileSystem
-func New(persistDir string, contractor contractManager) FileSystem {
+// New is the public function used for creating a production fileSystem. The cipher keys of the
+// files are sealed by the keyring
+func New(persistDir string, contractor contractManager, kr *keyring.Keyring) FileSystem {
 	d := newStandardDisrupter()
-	return newFileSystem(persistDir, contractor, d)
+	fs := newFileSystem(persistDir, contractor, d)
+	fs.keyring = kr
+	return fs
 }
 
 // contractManager is the contractManager interface used in file system
@@ -66,6 +90,12 @@ type contractManager interface {
 
 	// HostHealthMap returns the full host info table of the contract manager
 	HostHealthMap() (infoTable storage.HostHealthInfoTable)
+
+	// RemoveFile removes the dxfile from the contract file index
+	RemoveFile(dxPath storage.DxPath)
+
+	// RenameFile updates the contract file index after the dxfile is renamed
+	RenameFile(prevPath, newPath storage.DxPath)
 }
 
 // AlwaysSuccessContractManager is the contractManager that always return good condition for all host keys
@@ -88,6 +118,12 @@ func (c *AlwaysSuccessContractManager) HostHealthMap() storage.HostHealthInfoTab
 	return make(storage.HostHealthInfoTable)
 }
 
+// RemoveFile is not used by test case
+func (c *AlwaysSuccessContractManager) RemoveFile(dxPath storage.DxPath) {}
+
+// RenameFile is not used by test case
+func (c *AlwaysSuccessContractManager) RenameFile(prevPath, newPath storage.DxPath) {}
+
 // AlwaysSuccessContractManager is the contractManager that always return wrong condition for all host keys
 type alwaysFailContractManager struct{}
 
@@ -108,6 +144,12 @@ func (c *alwaysFailContractManager) HostHealthMap() storage.HostHealthInfoTable
 	return make(storage.HostHealthInfoTable)
 }
 
+// RemoveFile is not used by test case
+func (c *alwaysFailContractManager) RemoveFile(dxPath storage.DxPath) {}
+
+// RenameFile is not used by test case
+func (c *alwaysFailContractManager) RenameFile(prevPath, newPath storage.DxPath) {}
+
 // randomContractManager is the contractManager that return condition is random possibility
 // rate is the possibility between 0 and 1 for specified conditions
 type randomContractManager struct {
@@ -167,3 +209,9 @@ func (c *randomContractManager) HostHealthMapByID(ids []enode.ID) storage.HostHe
 func (c *randomContractManager) HostHealthMap() storage.HostHealthInfoTable {
 	return make(storage.HostHealthInfoTable)
 }
+
+// RemoveFile is not used in tests thus not implemented
+func (c *randomContractManager) RemoveFile(dxPath storage.DxPath) {}
+
+// RenameFile is not used in tests thus not implemented
+func (c *randomContractManager) RenameFile(prevPath, newPath storage.DxPath) {}
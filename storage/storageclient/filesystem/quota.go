@@ -0,0 +1,144 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// ErrQuotaExceeded is the error that the directory quota is exceeded by the new file
+var ErrQuotaExceeded = errors.New("directory quota exceeded")
+
+var quotaMetadata = common.Metadata{
+	Header:  "DxChain FileSystem Quota",
+	Version: "1.0",
+}
+
+// DirQuota is the quota of a directory, which limits the total size and the number of files
+// in the directory and its subdirectories. Zero value of a field means no limit
+type DirQuota struct {
+	MaxSize  uint64 `json:"maxSize"`
+	MaxFiles uint64 `json:"maxFiles"`
+}
+
+// SetQuota sets the quota of the directory. The zero quota removes the quota of the directory
+func (fs *fileSystem) SetQuota(path storage.DxPath, quota DirQuota) error {
+	fs.quotaLock.Lock()
+	defer fs.quotaLock.Unlock()
+
+	prev, exist := fs.quotas[path.Path]
+	if quota == (DirQuota{}) {
+		delete(fs.quotas, path.Path)
+	} else {
+		fs.quotas[path.Path] = quota
+	}
+	if err := fs.saveQuotas(); err != nil {
+		// revert the quota
+		if exist {
+			fs.quotas[path.Path] = prev
+		} else {
+			delete(fs.quotas, path.Path)
+		}
+		return err
+	}
+	return nil
+}
+
+// Quotas returns the quotas of the directories, keyed by the DxPath of the directory
+func (fs *fileSystem) Quotas() map[string]DirQuota {
+	fs.quotaLock.Lock()
+	defer fs.quotaLock.Unlock()
+
+	quotas := make(map[string]DirQuota, len(fs.quotas))
+	for path, quota := range fs.quotas {
+		quotas[path] = quota
+	}
+	return quotas
+}
+
+// checkQuota checks whether adding files with the total size to the directory dir exceeds the
+// quotas of the directory and its ancestors. The ancestors in skip are not checked, which
+// already count the added files, e.g. the common ancestors of the file renamed.
+// The usage of the directory is taken from the directory metadata
+func (fs *fileSystem) checkQuota(dir storage.DxPath, numFiles, size uint64, skip map[string]struct{}) error {
+	quotas := fs.Quotas()
+	if len(quotas) == 0 {
+		return nil
+	}
+	for _, path := range dxPathAncestors(dir) {
+		quota, exist := quotas[path.Path]
+		if !exist {
+			continue
+		}
+		if _, skipped := skip[path.Path]; skipped {
+			continue
+		}
+		usedFiles, usedSize, err := fs.dirUsage(path)
+		if err != nil {
+			return err
+		}
+		if quota.MaxFiles != 0 && usedFiles+numFiles > quota.MaxFiles {
+			return fmt.Errorf("%v: directory /%v has %d files, max %d files", ErrQuotaExceeded, path.Path, usedFiles, quota.MaxFiles)
+		}
+		if quota.MaxSize != 0 && usedSize+size > quota.MaxSize {
+			return fmt.Errorf("%v: directory /%v has %d bytes, max %d bytes", ErrQuotaExceeded, path.Path, usedSize, quota.MaxSize)
+		}
+	}
+	return nil
+}
+
+// dirUsage returns the number of files and the total size of the directory. The directory not
+// created yet has no usage
+func (fs *fileSystem) dirUsage(path storage.DxPath) (uint64, uint64, error) {
+	if !fs.dirSet.Exists(path) {
+		return 0, 0, nil
+	}
+	entry, err := fs.dirSet.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer entry.Close()
+	md := entry.Metadata()
+	return md.NumFiles, md.TotalSize, nil
+}
+
+// dxPathAncestors returns the DxPath and all of its ancestors up to the root
+func dxPathAncestors(path storage.DxPath) []storage.DxPath {
+	ancestors := []storage.DxPath{path}
+	for !path.IsRoot() {
+		parent, err := path.Parent()
+		if err != nil {
+			break
+		}
+		ancestors = append(ancestors, parent)
+		path = parent
+	}
+	return ancestors
+}
+
+// loadQuotas loads the directory quotas from the persist file. No quota is set if the
+// file does not exist
+func (fs *fileSystem) loadQuotas() error {
+	fs.quotaLock.Lock()
+	defer fs.quotaLock.Unlock()
+
+	fs.quotas = make(map[string]DirQuota)
+	err := common.LoadDxJSON(quotaMetadata, filepath.Join(string(fs.persistDir), quotaFileName), &fs.quotas)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// saveQuotas saves the directory quotas to the persist file. The caller must hold the quotaLock
+func (fs *fileSystem) saveQuotas() error {
+	return common.SaveDxJSON(quotaMetadata, filepath.Join(string(fs.persistDir), quotaFileName), fs.quotas)
+}
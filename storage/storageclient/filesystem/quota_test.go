@@ -0,0 +1,116 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+)
+
+// TestDxPathAncestors test the function dxPathAncestors
+func TestDxPathAncestors(t *testing.T) {
+	tests := []struct {
+		path      string
+		ancestors []string
+	}{
+		{"", []string{""}},
+		{"a", []string{"a", ""}},
+		{"a/b/c", []string{"a/b/c", "a/b", "a", ""}},
+	}
+	for _, test := range tests {
+		path := storage.RootDxPath()
+		if test.path != "" {
+			var err error
+			if path, err = storage.NewDxPath(test.path); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ancestors := dxPathAncestors(path)
+		if len(ancestors) != len(test.ancestors) {
+			t.Fatalf("path %v: expect %d ancestors, got %d", test.path, len(test.ancestors), len(ancestors))
+		}
+		for i, ancestor := range ancestors {
+			if ancestor.Path != test.ancestors[i] {
+				t.Errorf("path %v: ancestor %d expect %v, got %v", test.path, i, test.ancestors[i], ancestor.Path)
+			}
+		}
+	}
+}
+
+// TestFileSystem_Quota test the directory quotas are enforced on creating and renaming files,
+// and are persisted across restarts
+func TestFileSystem_Quota(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", &AlwaysSuccessContractManager{}, newStandardDisrupter())
+	defer fs.Close()
+
+	dirA, _ := storage.NewDxPath("a")
+	if err := fs.SetQuota(dirA, DirQuota{MaxFiles: 1}); err != nil {
+		t.Fatal(err)
+	}
+	newFile := func(path string) error {
+		dxPath, err := storage.NewDxPath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ec, _ := erasurecode.New(erasurecode.ECTypeStandard, 1, 2)
+		ck, _ := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+		entry, err := fs.NewDxFile(dxPath, storage.SysPath(filepath.Join("/", path)), false, ec, ck, 1<<20, 0777)
+		if err != nil {
+			return err
+		}
+		entry.Close()
+		dir, _ := dxPath.Parent()
+		if err = fs.InitAndUpdateDirMetadata(dir); err != nil {
+			t.Fatal(err)
+		}
+		if err = fs.waitForUpdatesComplete(5 * time.Second); err != nil {
+			t.Fatal(err)
+		}
+		return nil
+	}
+	if err := newFile("a/f1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := newFile("a/b/f2"); err == nil {
+		t.Fatal("the file exceeding the quota of the ancestor directory should be rejected")
+	}
+	if err := newFile("c/f2"); err != nil {
+		t.Fatal(err)
+	}
+	prevPath, _ := storage.NewDxPath("c/f2")
+	newPath, _ := storage.NewDxPath("a/f2")
+	if err := fs.RenameDxFile(prevPath, newPath); err == nil {
+		t.Fatal("the file moved into the directory exceeding the quota should be rejected")
+	}
+
+	// the zero quota removes the quota
+	if err := fs.SetQuota(dirA, DirQuota{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fs.Quotas()) != 0 {
+		t.Fatalf("the quota should be removed: %v", fs.Quotas())
+	}
+	if err := fs.RenameDxFile(prevPath, newPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// the quotas are loaded on restart
+	quota := DirQuota{MaxSize: 1 << 30, MaxFiles: 10}
+	if err := fs.SetQuota(dirA, quota); err != nil {
+		t.Fatal(err)
+	}
+	fs.quotas = nil
+	if err := fs.loadQuotas(); err != nil {
+		t.Fatal(err)
+	}
+	if got := fs.Quotas()[dirA.Path]; got != quota {
+		t.Errorf("quota not persisted: expect %+v, got %+v", quota, got)
+	}
+}
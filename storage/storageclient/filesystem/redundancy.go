@@ -0,0 +1,118 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+)
+
+var redundancyMetadata = common.Metadata{
+	Header:  "DxChain FileSystem Redundancy",
+	Version: "1.0",
+}
+
+// RedundancyTarget is the erasure code parameters of the files uploaded to a path, where each
+// segment is encoded into NumSectors sectors and could be recovered from any MinSectors of them.
+// The parameters are saved in the dxfile metadata once the file is created, and the file is
+// repaired up to the NumSectors sectors of the file
+type RedundancyTarget struct {
+	MinSectors uint32 `json:"minSectors"`
+	NumSectors uint32 `json:"numSectors"`
+}
+
+// DefaultRedundancyTarget is the redundancy target of the files with no target set on the file
+// or any of its directories
+var DefaultRedundancyTarget = RedundancyTarget{
+	MinSectors: storage.DefaultMinSectors,
+	NumSectors: storage.DefaultNumSectors,
+}
+
+// ErasureCode returns the standard erasure code of the redundancy target
+func (target RedundancyTarget) ErasureCode() (erasurecode.ErasureCoder, error) {
+	return erasurecode.New(erasurecode.ECTypeStandard, target.MinSectors, target.NumSectors)
+}
+
+// SetRedundancy sets the redundancy target of the file or directory. The target of a directory
+// applies to the files and subdirectories without a target of their own. The zero target removes
+// the target of the path. The files already created keep the erasure code parameters they are
+// created with
+func (fs *fileSystem) SetRedundancy(path storage.DxPath, target RedundancyTarget) error {
+	if target != (RedundancyTarget{}) {
+		if _, err := target.ErasureCode(); err != nil {
+			return err
+		}
+	}
+	fs.redundancyLock.Lock()
+	defer fs.redundancyLock.Unlock()
+
+	prev, exist := fs.redundancies[path.Path]
+	if target == (RedundancyTarget{}) {
+		delete(fs.redundancies, path.Path)
+	} else {
+		fs.redundancies[path.Path] = target
+	}
+	if err := fs.saveRedundancies(); err != nil {
+		// revert the target
+		if exist {
+			fs.redundancies[path.Path] = prev
+		} else {
+			delete(fs.redundancies, path.Path)
+		}
+		return err
+	}
+	return nil
+}
+
+// Redundancies returns the redundancy targets set, keyed by the DxPath of the file or directory
+func (fs *fileSystem) Redundancies() map[string]RedundancyTarget {
+	fs.redundancyLock.Lock()
+	defer fs.redundancyLock.Unlock()
+
+	redundancies := make(map[string]RedundancyTarget, len(fs.redundancies))
+	for path, target := range fs.redundancies {
+		redundancies[path] = target
+	}
+	return redundancies
+}
+
+// RedundancyTarget returns the redundancy target of the file to be created at the path, which
+// is the target of the path itself or of the closest ancestor directory with a target set. The
+// DefaultRedundancyTarget is returned if no target is set
+func (fs *fileSystem) RedundancyTarget(path storage.DxPath) RedundancyTarget {
+	fs.redundancyLock.Lock()
+	defer fs.redundancyLock.Unlock()
+
+	for _, p := range dxPathAncestors(path) {
+		if target, exist := fs.redundancies[p.Path]; exist {
+			return target
+		}
+	}
+	return DefaultRedundancyTarget
+}
+
+// loadRedundancies loads the redundancy targets from the persist file. No target is set if the
+// file does not exist
+func (fs *fileSystem) loadRedundancies() error {
+	fs.redundancyLock.Lock()
+	defer fs.redundancyLock.Unlock()
+
+	fs.redundancies = make(map[string]RedundancyTarget)
+	err := common.LoadDxJSON(redundancyMetadata, filepath.Join(string(fs.persistDir), redundancyFileName), &fs.redundancies)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// saveRedundancies saves the redundancy targets to the persist file. The caller must hold the
+// redundancyLock
+func (fs *fileSystem) saveRedundancies() error {
+	return common.SaveDxJSON(redundancyMetadata, filepath.Join(string(fs.persistDir), redundancyFileName), fs.redundancies)
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestFileSystem_Redundancy test the redundancy target of a file is taken from the file itself or
+// the closest directory, and the targets are persisted across restarts
+func TestFileSystem_Redundancy(t *testing.T) {
+	fs := newEmptyTestFileSystem(t, "", nil, newStandardDisrupter())
+	defer fs.Close()
+
+	dirA, _ := storage.NewDxPath("a")
+	fileA, _ := storage.NewDxPath("a/b/f1")
+	fileB, _ := storage.NewDxPath("c/f2")
+	dirTarget := RedundancyTarget{MinSectors: 2, NumSectors: 6}
+	fileTarget := RedundancyTarget{MinSectors: 4, NumSectors: 12}
+
+	if err := fs.SetRedundancy(dirA, RedundancyTarget{MinSectors: 3, NumSectors: 2}); err == nil {
+		t.Fatal("the invalid redundancy target should be rejected")
+	}
+	if err := fs.SetRedundancy(dirA, dirTarget); err != nil {
+		t.Fatal(err)
+	}
+	if target := fs.RedundancyTarget(fileA); target != dirTarget {
+		t.Errorf("expect the target of the directory %+v, got %+v", dirTarget, target)
+	}
+	if target := fs.RedundancyTarget(fileB); target != DefaultRedundancyTarget {
+		t.Errorf("expect the default target %+v, got %+v", DefaultRedundancyTarget, target)
+	}
+	if err := fs.SetRedundancy(fileA, fileTarget); err != nil {
+		t.Fatal(err)
+	}
+	if target := fs.RedundancyTarget(fileA); target != fileTarget {
+		t.Errorf("expect the target of the file %+v, got %+v", fileTarget, target)
+	}
+
+	// the erasure code of the target
+	ec, err := fileTarget.ErasureCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ec.MinSectors() != fileTarget.MinSectors || ec.NumSectors() != fileTarget.NumSectors {
+		t.Errorf("erasure code %v/%v not expected", ec.MinSectors(), ec.NumSectors())
+	}
+
+	// the zero target removes the target, and the targets are loaded on restart
+	if err := fs.SetRedundancy(fileA, RedundancyTarget{}); err != nil {
+		t.Fatal(err)
+	}
+	fs.redundancies = nil
+	if err := fs.loadRedundancies(); err != nil {
+		t.Fatal(err)
+	}
+	redundancies := fs.Redundancies()
+	if len(redundancies) != 1 || redundancies[dirA.Path] != dirTarget {
+		t.Errorf("redundancy targets not persisted: %+v", redundancies)
+	}
+}
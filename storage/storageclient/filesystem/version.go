@@ -0,0 +1,78 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package filesystem
+
+import (
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// FileVersions returns the versions retained for the file overwritten. The versions of which
+// the contracts storing the sectors have all expired are no longer downloadable, thus removed
+func (fs *fileSystem) FileVersions(dxPath storage.DxPath) ([]dxfile.VersionInfo, error) {
+	if err := fs.tm.Add(); err != nil {
+		return nil, err
+	}
+	defer fs.tm.Done()
+
+	versions, err := fs.fileSet.Versions(dxPath)
+	if err != nil {
+		return nil, err
+	}
+	table := fs.contractManager.HostHealthMap()
+	available := versions[:0]
+	for _, version := range versions {
+		expired, err := fs.pruneVersion(dxPath, version.ID, table)
+		if err != nil {
+			return nil, err
+		}
+		if !expired {
+			available = append(available, version)
+		}
+	}
+	return available, nil
+}
+
+// OpenDxFileVersion opens the version of the file for download
+func (fs *fileSystem) OpenDxFileVersion(dxPath storage.DxPath, id uint64) (*dxfile.DxFile, error) {
+	return fs.fileSet.OpenVersion(dxPath, id)
+}
+
+// RestoreDxFileVersion restores the version of the file as the current file, and the current
+// file is retained as a new version. The version to be restored shall not be expired
+func (fs *fileSystem) RestoreDxFileVersion(dxPath storage.DxPath, id uint64) error {
+	if err := fs.tm.Add(); err != nil {
+		return err
+	}
+	defer fs.tm.Done()
+
+	expired, err := fs.pruneVersion(dxPath, id, fs.contractManager.HostHealthMap())
+	if err != nil {
+		return err
+	}
+	if expired {
+		return dxfile.ErrUnknownVersion
+	}
+	return fs.fileSet.RestoreVersion(dxPath, id)
+}
+
+// pruneVersion deletes the version of the file if none of the hosts storing the sectors of the
+// version is in the table of the active contracts, and returns whether the version is expired
+func (fs *fileSystem) pruneVersion(dxPath storage.DxPath, id uint64, table storage.HostHealthInfoTable) (bool, error) {
+	df, err := fs.fileSet.OpenVersion(dxPath, id)
+	if err != nil {
+		return false, err
+	}
+	for host := range df.SectorHosts() {
+		if _, exists := table[host]; exists {
+			return false, nil
+		}
+	}
+	if err = fs.fileSet.DeleteVersion(dxPath, id); err != nil {
+		return false, err
+	}
+	fs.logger.Info("file version expired", "path", dxPath.Path, "version", id)
+	return true, nil
+}
@@ -6,11 +6,13 @@ package storageclient
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/DxChainNetwork/godx/common/unit"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
 )
 
 // ContractMetaDataAPIDisplay is the data structure used for console
@@ -31,9 +33,16 @@ type ContractMetaDataAPIDisplay struct {
 	GasCost      string
 	ContractFee  string
 
+	RenewStorageCost string
+	ClientFunding    string
+	HostDeposit      string
+
 	UploadAbility string
 	RenewAbility  string
 	Canceled      string
+
+	// MirrorOf is the host of the primary contract mirrored by the contract
+	MirrorOf string `json:",omitempty"`
 }
 
 // formatContractMetaData will format the contract meta data into a format of contract
@@ -52,8 +61,15 @@ func formatContractMetaData(data storage.ContractMetaData) (formatted ContractMe
 	formatted.GasCost = unit.FormatCurrency(data.GasCost)
 	formatted.ContractFee = unit.FormatCurrency(data.ContractFee)
 
+	formatted.RenewStorageCost = unit.FormatCurrency(data.CostBreakdown.RenewStorageCost)
+	formatted.ClientFunding = unit.FormatCurrency(data.CostBreakdown.ClientFunding)
+	formatted.HostDeposit = unit.FormatCurrency(data.CostBreakdown.HostDeposit)
+
 	formatted.UploadAbility, formatted.RenewAbility, formatted.Canceled =
 		formatStatus(data.Status.UploadAbility, data.Status.RenewAbility, data.Status.Canceled)
+	if data.MirrorOf != (enode.ID{}) {
+		formatted.MirrorOf = data.MirrorOf.String()
+	}
 	return
 }
 
@@ -85,6 +101,11 @@ func formatClientSetting(setting storage.ClientSetting) (formatted storage.Clien
 	formatted.EnableIPViolation = formatIPViolation(setting.EnableIPViolation)
 	formatted.MaxUploadSpeed = unit.FormatSpeed(setting.MaxUploadSpeed)
 	formatted.MaxDownloadSpeed = unit.FormatSpeed(setting.MaxDownloadSpeed)
+	formatted.MaxMemory = unit.FormatStorage(setting.MaxMemory, false)
+	formatted.UploadBatchSize = unit.FormatStorage(setting.UploadBatchSize, false)
+	formatted.SegmentCacheSize = unit.FormatStorage(setting.SegmentCacheSize, false)
+	formatted.SegmentCacheDiskSize = unit.FormatStorage(setting.SegmentCacheDiskSize, false)
+	formatted.VerifyUploads = formatVerifyUploads(setting.VerifyUploads)
 	formatted.RentPayment = formatRentPayment(setting.RentPayment)
 	return
 }
@@ -109,6 +130,13 @@ func formatRentPayment(rent storage.RentPayment) (formatted storage.RentPaymentA
 	formatted.ExpectedUpload = unit.FormatStorage(rent.ExpectedUpload, false)
 	formatted.ExpectedDownload = unit.FormatStorage(rent.ExpectedDownload, false)
 	formatted.ExpectedRedundancy = formatRedundancy(rent.ExpectedRedundancy)
+	formatted.MaxHostFundRatio = formatHostFundRatio(rent.MaxHostFundRatio)
+	formatted.PreferRegions = formatRegions(rent.PreferRegions)
+	formatted.RequireRegions = formatRegions(rent.RequireRegions)
+	formatted.EvictionEvalRatio = formatEvictionEvalRatio(rent.EvictionEvalRatio)
+	formatted.EvictionOfflineHours = formatEvictionOfflineHours(rent.EvictionOfflineHours)
+	formatted.MirrorContracts = formatMirrorContracts(rent.MirrorContracts)
+	formatted.HostSelection = formatHostSelection(rent.HostSelection)
 	return
 }
 
@@ -122,3 +150,60 @@ func formatHosts(hosts uint64) (formatted string) {
 func formatRedundancy(redundancy float64) (formatted string) {
 	return fmt.Sprintf("%v Copies", redundancy)
 }
+
+// formatHostFundRatio is used to format the rentPayment.MaxHostFundRatio field for displaying purpose
+func formatHostFundRatio(ratio float64) (formatted string) {
+	if ratio == 0 {
+		return "Unlimited"
+	}
+	return fmt.Sprintf("%v%% of Fund", ratio*100)
+}
+
+// formatRegions is used to format the rentPayment.PreferRegions and RequireRegions fields
+// for displaying purpose
+func formatRegions(regions []string) (formatted string) {
+	if len(regions) == 0 {
+		return "Any"
+	}
+	return strings.Join(regions, ", ")
+}
+
+// formatEvictionEvalRatio is used to format the rentPayment.EvictionEvalRatio field for displaying purpose
+func formatEvictionEvalRatio(ratio float64) (formatted string) {
+	if ratio == 0 {
+		return "Disabled"
+	}
+	return fmt.Sprintf("%v%% of Evaluation Baseline", ratio*100)
+}
+
+// formatEvictionOfflineHours is used to format the rentPayment.EvictionOfflineHours field for displaying purpose
+func formatEvictionOfflineHours(hours uint64) (formatted string) {
+	if hours == 0 {
+		return "Disabled"
+	}
+	return fmt.Sprintf("%v Hours", hours)
+}
+
+// formatMirrorContracts is used to format the rentPayment.MirrorContracts field for displaying purpose
+func formatMirrorContracts(enabled bool) (formatted string) {
+	if enabled {
+		return "Enabled: each contract is mirrored onto a secondary host"
+	}
+	return "Disabled"
+}
+
+// formatVerifyUploads is used to format storage.ClientSetting.VerifyUploads field for displaying purpose
+func formatVerifyUploads(enabled bool) (formatted string) {
+	if enabled {
+		return "Enabled: a random range of each sector uploaded is downloaded and verified"
+	}
+	return "Disabled"
+}
+
+// formatHostSelection is used to format the rentPayment.HostSelection field for displaying purpose
+func formatHostSelection(strategy string) (formatted string) {
+	if strategy == "" {
+		return storagehosttree.DefaultStrategy
+	}
+	return strategy
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+)
+
+var (
+	// errHostBusy is returned when the host is at its concurrency limit
+	errHostBusy = errors.New("the host is at its concurrency limit")
+
+	// errHostLimitStopped is returned when the wait for the host concurrency limit is cancelled
+	errHostLimitStopped = errors.New("stopped waiting for the host concurrency limit")
+)
+
+// hostLimiter limits the simultaneous negotiations and the bytes in flight with each host, so
+// that the work for a slow host waits for the host instead of piling up on it. The limits of
+// the host are refreshed from the limitFunc on each acquisition, thus follow the capacity
+// measured by the host manager
+type hostLimiter struct {
+	limitFunc func(id enode.ID) (negotiations int, inFlight uint64)
+	stopChan  <-chan struct{}
+
+	hosts map[enode.ID]*hostLimit
+	mu    sync.Mutex
+}
+
+// hostLimit is the concurrency limit and the usage of a host
+type hostLimit struct {
+	maxNegotiations int
+	maxInFlight     uint64
+
+	negotiations int
+	inFlight     uint64
+
+	// released is closed and replaced once any negotiation with the host is released
+	released chan struct{}
+}
+
+// newHostLimiter creates the host limiter with the limits of the hosts returned by limitFunc.
+// The waits for the hosts are cancelled once the stopChan is closed
+func newHostLimiter(limitFunc func(id enode.ID) (int, uint64), stopChan <-chan struct{}) *hostLimiter {
+	return &hostLimiter{
+		limitFunc: limitFunc,
+		stopChan:  stopChan,
+		hosts:     make(map[enode.ID]*hostLimit),
+	}
+}
+
+// acquire waits until a negotiation transferring size bytes could be started with the host,
+// and registers it. The wait is cancelled once the cancel channel or the stop channel of the
+// limiter is closed. Each successful acquire shall be followed by a release
+func (hl *hostLimiter) acquire(id enode.ID, size uint64, cancel <-chan struct{}) error {
+	for {
+		ok, released := hl.tryAcquireOrWait(id, size)
+		if ok {
+			return nil
+		}
+		select {
+		case <-released:
+		case <-cancel:
+			return errHostLimitStopped
+		case <-hl.stopChan:
+			return errHostLimitStopped
+		}
+	}
+}
+
+// tryAcquire registers a negotiation transferring size bytes with the host without waiting.
+// errHostBusy is returned if the host is at its limit
+func (hl *hostLimiter) tryAcquire(id enode.ID, size uint64) error {
+	if ok, _ := hl.tryAcquireOrWait(id, size); !ok {
+		return errHostBusy
+	}
+	return nil
+}
+
+// release releases the negotiation transferring size bytes with the host acquired before
+func (hl *hostLimiter) release(id enode.ID, size uint64) {
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+
+	limit, exist := hl.hosts[id]
+	if !exist {
+		return
+	}
+	limit.negotiations--
+	limit.inFlight -= size
+	close(limit.released)
+	limit.released = make(chan struct{})
+
+	// the hosts idle are removed, so that the limits of the hosts no longer used are not kept
+	if limit.negotiations == 0 {
+		delete(hl.hosts, id)
+	}
+}
+
+// tryAcquireOrWait registers the negotiation if the host is within its limits. Otherwise the
+// channel closed on the next release of the host is returned to wait on
+func (hl *hostLimiter) tryAcquireOrWait(id enode.ID, size uint64) (bool, <-chan struct{}) {
+	maxNegotiations, maxInFlight := hl.limitFunc(id)
+
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+
+	limit, exist := hl.hosts[id]
+	if !exist {
+		limit = &hostLimit{released: make(chan struct{})}
+		hl.hosts[id] = limit
+	}
+	limit.maxNegotiations, limit.maxInFlight = maxNegotiations, maxInFlight
+
+	// the negotiation larger than the max bytes in flight is let through once there is
+	// nothing else in flight with the host, otherwise it would never be started
+	if limit.negotiations > 0 && (limit.negotiations >= limit.maxNegotiations || limit.inFlight+size > limit.maxInFlight) {
+		return false, limit.released
+	}
+	limit.negotiations++
+	limit.inFlight += size
+	return true, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+)
+
+// TestHostLimiter test the negotiations exceeding the limits of the host wait for the release,
+// while the other hosts are not affected
+func TestHostLimiter(t *testing.T) {
+	limiter := newHostLimiter(func(id enode.ID) (int, uint64) { return 2, 100 }, make(chan struct{}))
+	host1, host2 := enode.ID{1}, enode.ID{2}
+
+	if err := limiter.tryAcquire(host1, 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := limiter.tryAcquire(host1, 60); err != errHostBusy {
+		t.Fatalf("negotiation exceeding the max bytes in flight: expect %v, got %v", errHostBusy, err)
+	}
+	if err := limiter.tryAcquire(host1, 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := limiter.tryAcquire(host1, 0); err != errHostBusy {
+		t.Fatalf("negotiation exceeding the max negotiations: expect %v, got %v", errHostBusy, err)
+	}
+	if err := limiter.tryAcquire(host2, 100); err != nil {
+		t.Fatalf("negotiation with another host rejected: %v", err)
+	}
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- limiter.acquire(host1, 50, make(chan struct{}))
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("negotiation acquired before the host is released")
+	case <-time.After(50 * time.Millisecond):
+	}
+	limiter.release(host1, 60)
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("negotiation not acquired after the host is released")
+	}
+
+	limiter.release(host1, 40)
+	limiter.release(host1, 50)
+	if _, exist := limiter.hosts[host1]; exist {
+		t.Error("limit of the idle host not removed")
+	}
+}
+
+// TestHostLimiterOversized test the negotiation larger than the max bytes in flight is let
+// through once nothing is in flight with the host
+func TestHostLimiterOversized(t *testing.T) {
+	limiter := newHostLimiter(func(id enode.ID) (int, uint64) { return 4, 100 }, make(chan struct{}))
+	host := enode.ID{1}
+
+	if err := limiter.tryAcquire(host, 200); err != nil {
+		t.Fatalf("oversized negotiation rejected with nothing in flight: %v", err)
+	}
+	if err := limiter.tryAcquire(host, 1); err != errHostBusy {
+		t.Fatalf("expect %v, got %v", errHostBusy, err)
+	}
+}
+
+// TestHostLimiterCancel test the wait for the host is cancelled by the cancel channel and the
+// stop channel of the limiter
+func TestHostLimiterCancel(t *testing.T) {
+	stop := make(chan struct{})
+	limiter := newHostLimiter(func(id enode.ID) (int, uint64) { return 1, 100 }, stop)
+	host := enode.ID{1}
+	if err := limiter.tryAcquire(host, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel := make(chan struct{})
+	close(cancel)
+	if err := limiter.acquire(host, 10, cancel); err != errHostLimitStopped {
+		t.Fatalf("expect %v, got %v", errHostLimitStopped, err)
+	}
+	close(stop)
+	if err := limiter.acquire(host, 10, make(chan struct{})); err != errHostLimitStopped {
+		t.Fatalf("expect %v, got %v", errHostLimitStopped, err)
+	}
+}
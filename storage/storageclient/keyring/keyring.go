@@ -0,0 +1,251 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+// Package keyring encrypts the secrets persisted by the storage client with a key protected
+// by the user passphrase. The data key is generated randomly once the passphrase is set, and
+// persisted encrypted by the key derived from the passphrase with scrypt, so that changing the
+// passphrase does not require the secrets to be encrypted again
+package keyring
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// persistFileName is the file storing the data key encrypted by the passphrase
+	persistFileName = "keyring.json"
+
+	// scrypt parameters of deriving the key from the passphrase, the same as the
+	// light parameters of the account keystore
+	defaultScryptN = 1 << 12
+	defaultScryptP = 6
+	scryptR        = 8
+	scryptKeyLen   = 32
+	saltLen        = 32
+)
+
+var persistMetadata = common.Metadata{
+	Header:  "DxChain StorageClient Keyring",
+	Version: "1.0",
+}
+
+// sealedPrefix is the prefix of the data sealed by the keyring, which distinguishes the sealed
+// data from the plain data persisted before the passphrase is set
+var sealedPrefix = []byte("dxsealed1:")
+
+var (
+	// ErrLocked is returned when the data is to be sealed or opened while the keyring is locked
+	ErrLocked = errors.New("storage client keyring is locked")
+
+	// ErrPassphrase is returned when the passphrase could not decrypt the data key
+	ErrPassphrase = errors.New("could not decrypt the keyring with the passphrase")
+
+	errNoPassphrase = errors.New("storage client passphrase is not set")
+)
+
+type persistence struct {
+	Salt      []byte
+	ScryptN   int
+	ScryptP   int
+	SealedKey []byte
+}
+
+// Keyring holds the data key sealing the secrets persisted by the storage client. The keyring
+// is disabled until the passphrase is set, in which case the data is persisted in plain. A nil
+// keyring is a disabled keyring
+type Keyring struct {
+	persistDir string
+	scryptN    int
+	scryptP    int
+
+	// persist is nil if the passphrase is not set, and key is nil if locked
+	persist *persistence
+	key     crypto.CipherKey
+	lock    sync.RWMutex
+}
+
+// New loads the keyring persisted in the persistDir. The keyring loaded is locked
+func New(persistDir string) (*Keyring, error) {
+	kr := &Keyring{
+		persistDir: persistDir,
+		scryptN:    defaultScryptN,
+		scryptP:    defaultScryptP,
+	}
+	var persist persistence
+	err := common.LoadDxJSON(persistMetadata, kr.persistFilePath(), &persist)
+	if os.IsNotExist(err) {
+		return kr, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	kr.persist = &persist
+	return kr, nil
+}
+
+// IsSealed returns whether the data is sealed by the keyring
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, sealedPrefix)
+}
+
+// Enabled returns whether the passphrase is set, in which case the secrets are sealed
+func (kr *Keyring) Enabled() bool {
+	if kr == nil {
+		return false
+	}
+	kr.lock.RLock()
+	defer kr.lock.RUnlock()
+	return kr.persist != nil
+}
+
+// Locked returns whether the passphrase is set and the keyring is not unlocked yet
+func (kr *Keyring) Locked() bool {
+	if kr == nil {
+		return false
+	}
+	kr.lock.RLock()
+	defer kr.lock.RUnlock()
+	return kr.persist != nil && kr.key == nil
+}
+
+// SetPassphrase sets the passphrase of the keyring, which enables the keyring if the passphrase
+// was not set before. Otherwise, the keyring must be unlockable with the old passphrase. The
+// keyring is unlocked once the passphrase is set
+func (kr *Keyring) SetPassphrase(oldPassphrase, newPassphrase string) error {
+	kr.lock.Lock()
+	defer kr.lock.Unlock()
+
+	var dataKey []byte
+	if kr.persist == nil {
+		key, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+		if err != nil {
+			return err
+		}
+		dataKey = key.Key()
+	} else {
+		key, err := kr.persist.openKey(oldPassphrase)
+		if err != nil {
+			return err
+		}
+		dataKey = key.Key()
+	}
+
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	persist := &persistence{Salt: salt, ScryptN: kr.scryptN, ScryptP: kr.scryptP}
+	passKey, err := persist.passphraseKey(newPassphrase)
+	if err != nil {
+		return err
+	}
+	if persist.SealedKey, err = passKey.Encrypt(dataKey); err != nil {
+		return err
+	}
+	if err := common.SaveDxJSON(persistMetadata, kr.persistFilePath(), persist); err != nil {
+		return err
+	}
+	kr.persist = persist
+	kr.key, err = crypto.NewCipherKey(crypto.GCMCipherCode, dataKey)
+	return err
+}
+
+// Unlock decrypts the data key with the passphrase, after which the data could be sealed and
+// opened until the keyring is locked again
+func (kr *Keyring) Unlock(passphrase string) error {
+	kr.lock.Lock()
+	defer kr.lock.Unlock()
+
+	if kr.persist == nil {
+		return errNoPassphrase
+	}
+	key, err := kr.persist.openKey(passphrase)
+	if err != nil {
+		return err
+	}
+	kr.key = key
+	return nil
+}
+
+// Lock drops the data key from the memory
+func (kr *Keyring) Lock() {
+	kr.lock.Lock()
+	defer kr.lock.Unlock()
+	kr.key = nil
+}
+
+// Seal encrypts the data with the data key. The data is returned as is if the keyring is not
+// enabled, and ErrLocked is returned if the keyring is locked
+func (kr *Keyring) Seal(data []byte) ([]byte, error) {
+	if kr == nil {
+		return data, nil
+	}
+	kr.lock.RLock()
+	defer kr.lock.RUnlock()
+
+	if kr.persist == nil {
+		return data, nil
+	}
+	if kr.key == nil {
+		return nil, ErrLocked
+	}
+	sealed, err := kr.key.Encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+	return append(common.CopyBytes(sealedPrefix), sealed...), nil
+}
+
+// Open decrypts the data sealed by Seal. The data not sealed is returned as is, so that the
+// data persisted before the passphrase is set could still be read
+func (kr *Keyring) Open(data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return data, nil
+	}
+	if kr == nil {
+		return nil, errNoPassphrase
+	}
+	kr.lock.RLock()
+	defer kr.lock.RUnlock()
+
+	if kr.key == nil {
+		return nil, ErrLocked
+	}
+	return kr.key.Decrypt(data[len(sealedPrefix):])
+}
+
+func (kr *Keyring) persistFilePath() string {
+	return filepath.Join(kr.persistDir, persistFileName)
+}
+
+// passphraseKey derives the key from the passphrase
+func (persist *persistence) passphraseKey(passphrase string) (crypto.CipherKey, error) {
+	derived, err := scrypt.Key([]byte(passphrase), persist.Salt, persist.ScryptN, scryptR, persist.ScryptP, scryptKeyLen)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.NewCipherKey(crypto.GCMCipherCode, derived)
+}
+
+// openKey decrypts the data key with the passphrase
+func (persist *persistence) openKey(passphrase string) (crypto.CipherKey, error) {
+	passKey, err := persist.passphraseKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := passKey.Decrypt(persist.SealedKey)
+	if err != nil || len(dataKey) != scryptKeyLen {
+		return nil, ErrPassphrase
+	}
+	return crypto.NewCipherKey(crypto.GCMCipherCode, dataKey)
+}
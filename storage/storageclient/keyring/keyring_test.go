@@ -0,0 +1,95 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package keyring
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// TestKeyring test sealing and opening the data with the keyring through setting, locking,
+// unlocking and changing the passphrase
+func TestKeyring(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := []byte("contract secret")
+
+	// the disabled keyring persists the data in plain
+	kr, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kr.Enabled() || kr.Locked() {
+		t.Fatal("keyring should be disabled before the passphrase is set")
+	}
+	plain, err := kr.Seal(data)
+	if err != nil || !bytes.Equal(plain, data) {
+		t.Fatalf("disabled keyring should not seal the data: %v", err)
+	}
+
+	// the data is sealed once the passphrase is set
+	if err := kr.SetPassphrase("", "pass1"); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := kr.Seal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || bytes.Contains(sealed, data) {
+		t.Fatalf("data not sealed: %x", sealed)
+	}
+	for _, d := range [][]byte{sealed, plain} {
+		opened, err := kr.Open(d)
+		if err != nil || !bytes.Equal(opened, data) {
+			t.Fatalf("failed to open the data: %v", err)
+		}
+	}
+
+	// the keyring reloaded is locked
+	kr, err = New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !kr.Enabled() || !kr.Locked() {
+		t.Fatal("keyring reloaded should be enabled and locked")
+	}
+	if _, err := kr.Open(sealed); err != ErrLocked {
+		t.Fatalf("expect %v, got %v", ErrLocked, err)
+	}
+	if _, err := kr.Seal(data); err != ErrLocked {
+		t.Fatalf("expect %v, got %v", ErrLocked, err)
+	}
+	if err := kr.Unlock("wrong"); err != ErrPassphrase {
+		t.Fatalf("expect %v, got %v", ErrPassphrase, err)
+	}
+	if err := kr.Unlock("pass1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the data sealed is still opened after the passphrase is changed
+	if err := kr.SetPassphrase("wrong", "pass2"); err != ErrPassphrase {
+		t.Fatalf("expect %v, got %v", ErrPassphrase, err)
+	}
+	if err := kr.SetPassphrase("pass1", "pass2"); err != nil {
+		t.Fatal(err)
+	}
+	kr.Lock()
+	if err := kr.Unlock("pass1"); err != ErrPassphrase {
+		t.Fatalf("expect %v, got %v", ErrPassphrase, err)
+	}
+	if err := kr.Unlock("pass2"); err != nil {
+		t.Fatal(err)
+	}
+	opened, err := kr.Open(sealed)
+	if err != nil || !bytes.Equal(opened, data) {
+		t.Fatalf("failed to open the data after the passphrase changed: %v", err)
+	}
+}
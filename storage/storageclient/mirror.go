@@ -0,0 +1,166 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/metrics"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/coinchargemaintenance"
+)
+
+var (
+	mirrorSectorCounter       = metrics.NewRegisteredCounter("storageclient/mirror/sectors", nil)
+	mirrorUploadFailedCounter = metrics.NewRegisteredCounter("storageclient/mirror/failed", nil)
+	mirrorPromotedCounter     = metrics.NewRegisteredCounter("storageclient/mirror/promoted", nil)
+)
+
+// mirrorSectors uploads the sectors uploaded to the primary host onto the host of the mirror
+// contract, and adds them to the files. Mirroring is best effort, the sectors failed to be
+// mirrored are left to the repair
+func (w *worker) mirrorSectors(sectors []batchSector, roots []common.Hash, uploaded []bool) {
+	mirror, exists := w.client.contractManager.MirrorContract(w.contract.EnodeID)
+	if !exists {
+		return
+	}
+	if err := w.client.mirrorUpload(mirror, sectors, roots, uploaded); err != nil {
+		mirrorUploadFailedCounter.Inc(1)
+		w.client.log.Warn("failed to mirror the sectors", "primary", w.contract.EnodeID, "mirror", mirror.EnodeID, "err", err)
+	}
+}
+
+// mirrorUpload uploads the sectors to the host of the mirror contract in batches
+func (client *StorageClient) mirrorUpload(mirror storage.ContractMetaData, sectors []batchSector, roots []common.Hash, uploaded []bool) error {
+	hostInfo, exist := client.storageHostManager.RetrieveHostInfo(mirror.EnodeID)
+	if !exist {
+		return ErrUnableRetrieveHostInfo
+	}
+
+	// the worker of the primary host does not wait for the mirror host at its limit
+	size := client.uploadBatchSize()
+	if err := client.hostLimiter.tryAcquire(mirror.EnodeID, size); err != nil {
+		return err
+	}
+	defer client.hostLimiter.release(mirror.EnodeID, size)
+
+	sp, err := client.SetupConnection(hostInfo.EnodeURL)
+	if err != nil {
+		return err
+	}
+	defer sp.Close()
+	if ok := sp.TryToRenewOrRevise(); !ok {
+		return ErrContractRenewing
+	}
+	defer sp.RevisionOrRenewingDone()
+
+	mirrored := make([]bool, len(sectors))
+	batch := newUploadBatch(client.uploadBatchSize(), func(actions []storage.UploadAction) error {
+		return client.Write(sp, actions, &hostInfo)
+	})
+	for i, sector := range sectors {
+		if !uploaded[i] {
+			continue
+		}
+		i := i
+		if err = batch.Append(sector.uc.physicalSegmentData[sector.sectorIndex], func() { mirrored[i] = true }); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = batch.Flush()
+	}
+
+	// the sectors mirrored before the failure are still added to the files
+	for i, sector := range sectors {
+		if !mirrored[i] {
+			continue
+		}
+		if addErr := sector.uc.fileEntry.AddSector(mirror.EnodeID, roots[i], int(sector.uc.index), int(sector.sectorIndex)); addErr != nil {
+			if err == nil {
+				err = addErr
+			}
+			continue
+		}
+		client.contractManager.AddFileSegment(mirror.ID, sector.uc.fileEntry.DxPath(), sector.uc.index)
+		mirrorSectorCounter.Inc(1)
+	}
+	return err
+}
+
+// mirrorProofLoop periodically checks the storage proofs of the expired contracts which are
+// mirrored. Once the primary host missed the storage proof, the mirror contract is promoted
+// to take over the primary contract
+func (client *StorageClient) mirrorProofLoop() {
+	if err := client.tm.Add(); err != nil {
+		return
+	}
+	defer client.tm.Done()
+
+	// checked is the set of the contracts whose storage proof has been checked
+	checked := make(map[storage.ContractID]struct{})
+
+	ticker := time.NewTicker(MirrorProofCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-client.tm.StopChan():
+			return
+		case <-ticker.C:
+		}
+		client.checkMirroredProofs(checked)
+	}
+}
+
+// checkMirroredProofs checks the storage proofs of the expired contracts whose proof window
+// has ended, and promotes the mirror contract if the storage proof is missed
+func (client *StorageClient) checkMirroredProofs(checked map[storage.ContractID]struct{}) {
+	height := client.ethBackend.GetCurrentBlockHeight()
+	for _, contract := range client.contractManager.RetrieveExpiredContracts() {
+		windowEnd := contract.LatestContractRevision.NewWindowEnd
+		if _, exists := checked[contract.ID]; exists || contract.MirrorOf != (enode.ID{}) || height < windowEnd {
+			continue
+		}
+		checked[contract.ID] = struct{}{}
+		if _, exists := client.contractManager.MirrorContract(contract.EnodeID); !exists {
+			continue
+		}
+		proofed, err := client.proofSubmitted(contract.ID, windowEnd)
+		if err != nil {
+			client.log.Debug("failed to check the storage proof", "contractID", contract.ID, "err", err)
+			continue
+		}
+		if proofed {
+			continue
+		}
+		client.log.Warn("Host missed the storage proof, promoting the mirror", "host", contract.EnodeID, "contractID", contract.ID)
+		if err := client.contractManager.PromoteMirror(contract.EnodeID); err != nil {
+			client.log.Warn("failed to promote the mirror contract", "host", contract.EnodeID, "err", err)
+			continue
+		}
+		mirrorPromotedCounter.Inc(1)
+	}
+}
+
+// proofSubmitted returns whether the storage proof of the contract has been submitted within the
+// proof window. The proof status is cleared by the missed proof maintenance at the end of the
+// window, thus it is read from the state of the block right before the window end
+func (client *StorageClient) proofSubmitted(id storage.ContractID, windowEnd uint64) (bool, error) {
+	block, err := client.ethBackend.GetBlockByNumber(windowEnd - 1)
+	if err != nil {
+		return false, err
+	}
+	if block == nil {
+		return false, fmt.Errorf("block %v not found", windowEnd-1)
+	}
+	state, err := client.ethBackend.GetBlockChain().StateAt(block.Root())
+	if err != nil {
+		return false, err
+	}
+	return coinchargemaintenance.NewStorageContractState(state).Proofed(common.Hash(id), windowEnd), nil
+}
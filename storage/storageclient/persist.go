@@ -20,6 +20,13 @@ var settingsMetadata = common.Metadata{
 type persistence struct {
 	MaxDownloadSpeed int64
 	MaxUploadSpeed   int64
+	MaxMemory        uint64
+	UploadBatchSize  uint64
+
+	SegmentCacheSize     uint64
+	SegmentCacheDiskSize uint64
+
+	VerifyUploads bool
 }
 
 func (client *StorageClient) loadPersist() error {
@@ -47,6 +54,8 @@ func (client *StorageClient) loadSettings() error {
 	if os.IsNotExist(err) {
 		client.persist.MaxDownloadSpeed = DefaultMaxDownloadSpeed
 		client.persist.MaxUploadSpeed = DefaultMaxUploadSpeed
+		client.persist.MaxMemory = DefaultMaxMemory
+		client.persist.UploadBatchSize = DefaultUploadBatchSize
 		err = client.saveSettings()
 		if err != nil {
 			return err
@@ -54,5 +63,17 @@ func (client *StorageClient) loadSettings() error {
 	} else if err != nil {
 		return err
 	}
+	// the settings saved before the max memory is configurable use the default
+	if client.persist.MaxMemory == 0 {
+		client.persist.MaxMemory = DefaultMaxMemory
+	}
+	// the settings saved before the upload batch size is configurable use the default
+	if client.persist.UploadBatchSize == 0 {
+		client.persist.UploadBatchSize = DefaultUploadBatchSize
+	}
+	client.memoryManager.SetMemoryLimit(client.persist.MaxMemory)
+	if err = client.segmentCache.setSize(client.persist.SegmentCacheSize, client.persist.SegmentCacheDiskSize); err != nil {
+		return err
+	}
 	return client.setBandwidthLimits(client.persist.MaxUploadSpeed, client.persist.MaxUploadSpeed)
 }
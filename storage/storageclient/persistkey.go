@@ -0,0 +1,37 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import "errors"
+
+var errEmptyPassphrase = errors.New("the passphrase must not be empty")
+
+// UnlockPersist unlocks the keyring with the passphrase, and loads the storage host information
+// that could not be opened while the keyring was locked
+func (client *StorageClient) UnlockPersist(passphrase string) error {
+	if err := client.keyring.Unlock(passphrase); err != nil {
+		return err
+	}
+	return client.storageHostManager.LoadSealedHosts()
+}
+
+// LockPersist locks the keyring. The cipher keys already loaded are kept by the files opened
+func (client *StorageClient) LockPersist() {
+	client.keyring.Lock()
+}
+
+// SetPersistPassphrase sets the passphrase of the keyring, after which the contract secrets,
+// the storage host information and the cipher keys of the files created are sealed when
+// persisted. The data persisted before the passphrase is set is still readable, and the storage
+// host information is sealed the next time it is saved
+func (client *StorageClient) SetPersistPassphrase(oldPassphrase, newPassphrase string) error {
+	if newPassphrase == "" {
+		return errEmptyPassphrase
+	}
+	if err := client.keyring.SetPassphrase(oldPassphrase, newPassphrase); err != nil {
+		return err
+	}
+	return client.storageHostManager.LoadSealedHosts()
+}
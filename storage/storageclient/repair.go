@@ -91,6 +91,11 @@ func (client *StorageClient) stuckLoop() {
 			return
 		}
 
+		// Wait until the uploads are resumed if paused by the user
+		if !client.blockUntilUploadsResumed() {
+			return
+		}
+
 		// Randomly get directory with stuck files
 		dir, err := client.fileSystem.RandomStuckDirectory()
 		if err != nil && err != filesystem.ErrNoRepairNeeded {
@@ -202,3 +207,27 @@ func (client *StorageClient) healthCheckLoop() {
 		}
 	}
 }
+
+// contractRepairLoop updates the health of the files that lost segments because of
+// contracts expired without being renewed, so that the files are scheduled for repair
+// without waiting for the next health check
+func (client *StorageClient) contractRepairLoop() {
+	err := client.tm.Add()
+	if err != nil {
+		return
+	}
+	defer client.tm.Done()
+
+	for {
+		select {
+		case <-client.tm.StopChan():
+			return
+		case <-client.contractManager.RepairNeededChan():
+		}
+		for _, dxPath := range client.contractManager.FilesToRepair() {
+			if err := client.fileSystem.InitAndUpdateDirMetadata(dxPath); err != nil {
+				client.log.Error("[contract repair loop]update dir meta data failed", "path", dxPath.Path, "error", err)
+			}
+		}
+	}
+}
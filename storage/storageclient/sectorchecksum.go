@@ -0,0 +1,69 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package storageclient
+
+import (
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/metrics"
+	"github.com/cespare/xxhash/v2"
+)
+
+// The sector checksums catch the corruption of the downloaded sectors in the local disk or
+// memory, which happens after the sectors are verified against the merkle proofs provided
+// by the host. The sectors are kept encrypted until the recovery, and checked against the
+// checksums before they are decrypted and erasure decoded. The counters tell the local
+// corruption apart from the bad data of the host
+var (
+	sectorChecksumVerifiedCounter = metrics.NewRegisteredCounter("storageclient/download/checksum/verified", nil)
+	sectorChecksumMismatchCounter = metrics.NewRegisteredCounter("storageclient/download/checksum/mismatch", nil)
+)
+
+// sectorChecksum returns the fast non-cryptographic checksum of the sector data
+func sectorChecksum(data []byte) uint64 {
+	return xxhash.Sum64(data)
+}
+
+// verifySectorChecksum checks the sector data against the checksum computed when the data
+// was received
+func verifySectorChecksum(data []byte, checksum uint64) error {
+	if sum := sectorChecksum(data); sum != checksum {
+		sectorChecksumMismatchCounter.Inc(1)
+		return fmt.Errorf("sector checksum mismatch: expect %x, got %x", checksum, sum)
+	}
+	sectorChecksumVerifiedCounter.Inc(1)
+	return nil
+}
+
+// verifyPhysicalSegmentData checks the encrypted sectors kept for the recovery against the
+// checksums computed when the sectors were received
+func (uds *unfinishedDownloadSegment) verifyPhysicalSegmentData() error {
+	for i, data := range uds.physicalSegmentData {
+		if data == nil {
+			continue
+		}
+		if err := verifySectorChecksum(data, uds.sectorChecksums[i]); err != nil {
+			return fmt.Errorf("sector %v of segment %v corrupted: %v", i, uds.segmentIndex, err)
+		}
+	}
+	return nil
+}
+
+// decryptPhysicalSegmentData decrypts the sectors kept for the recovery in place. It shall
+// be called after the sectors are verified against the checksums
+func (uds *unfinishedDownloadSegment) decryptPhysicalSegmentData() error {
+	key := uds.clientFile.CipherKey()
+	for i, data := range uds.physicalSegmentData {
+		if data == nil {
+			continue
+		}
+		decryptedSector, err := key.DecryptInPlace(data)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt sector %v of segment %v: %v", i, uds.segmentIndex, err)
+		}
+		uds.physicalSegmentData[i] = decryptedSector
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package storageclient
+
+import (
+	"testing"
+)
+
+// TestVerifyPhysicalSegmentData test the corruption of the sector kept for the recovery is detected
+func TestVerifyPhysicalSegmentData(t *testing.T) {
+	uds := &unfinishedDownloadSegment{
+		physicalSegmentData: [][]byte{[]byte("sector 0"), nil, []byte("sector 2")},
+		sectorChecksums:     make([]uint64, 3),
+	}
+	for i, data := range uds.physicalSegmentData {
+		if data != nil {
+			uds.sectorChecksums[i] = sectorChecksum(data)
+		}
+	}
+	if err := uds.verifyPhysicalSegmentData(); err != nil {
+		t.Fatalf("intact sectors reported corrupted: %v", err)
+	}
+
+	// flip a bit of the sector in memory
+	uds.physicalSegmentData[2][0] ^= 1
+	if err := uds.verifyPhysicalSegmentData(); err == nil {
+		t.Fatal("corrupted sector not detected")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// sectorGCLoop periodically collects the sectors stored under the contracts but no longer
+// referenced by any dxfile, which happens after the files are deleted
+func (client *StorageClient) sectorGCLoop() {
+	if err := client.tm.Add(); err != nil {
+		return
+	}
+	defer client.tm.Done()
+
+	ticker := time.NewTicker(SectorGCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-client.tm.StopChan():
+			return
+		case <-ticker.C:
+		}
+		if _, err := client.collectSectorGarbage(); err != nil {
+			client.log.Warn("failed to collect the unreferenced sectors", "err", err)
+		}
+	}
+}
+
+// collectSectorGarbage runs a garbage collection pass, marking the sectors of each active contract
+// which are not referenced by any dxfile in both this pass and the last one. The sectors marked
+// are returned grouped by the contract id. The marked sectors are not paid for by the renewal
+// if none of the sectors of the contract is referenced, and are to be trimmed from the contract
+// once the trim upload action is supported by the storage host
+func (client *StorageClient) collectSectorGarbage() (map[storage.ContractID][]common.Hash, error) {
+	if err := client.tm.Add(); err != nil {
+		return nil, err
+	}
+	defer client.tm.Done()
+
+	client.sectorGCLock.Lock()
+	defer client.sectorGCLock.Unlock()
+
+	// the contract roots are retrieved before the dxfiles are read, so the sectors uploaded
+	// in between are not taken as unreferenced
+	contractSet := client.contractManager.GetStorageContractSet()
+	contractRoots := make(map[storage.ContractID][]common.Hash)
+	contracts := client.contractManager.RetrieveActiveContracts()
+	for _, contract := range contracts {
+		c, exists := contractSet.Acquire(contract.ID)
+		if !exists {
+			continue
+		}
+		roots, err := c.MerkleRoots()
+		if returnErr := contractSet.Return(c); returnErr != nil {
+			client.log.Warn("failed to return the contract", "contractID", contract.ID, "err", returnErr)
+		}
+		if err != nil {
+			return nil, err
+		}
+		contractRoots[contract.ID] = roots
+	}
+
+	referenced, err := client.fileSystem.ReferencedSectors()
+	if err != nil {
+		return nil, err
+	}
+
+	// the sectors no longer referenced could not be deduplicated against
+	if pruned := client.dedupIndex.prune(referenced); pruned > 0 {
+		if err := client.dedupIndex.save(); err != nil {
+			client.log.Warn("failed to save the sector dedup index", "err", err)
+		}
+	}
+
+	marked := make(map[storage.ContractID][]common.Hash)
+	candidates := make(map[storage.ContractID]map[common.Hash]struct{})
+	for _, contract := range contracts {
+		roots, exists := contractRoots[contract.ID]
+		if !exists {
+			continue
+		}
+		marked[contract.ID], candidates[contract.ID] = unreferencedSectors(roots, referenced[contract.EnodeID], client.sectorGCCandidates[contract.ID])
+	}
+	client.sectorGCCandidates = candidates
+	client.contractManager.MarkUnreferencedSectors(marked)
+	return marked, nil
+}
+
+// unreferencedSectors returns the roots not referenced, and found unreferenced in the last pass
+// as well. The roots not referenced in this pass are returned as the candidates of the next pass
+func unreferencedSectors(roots []common.Hash, referenced, prevCandidates map[common.Hash]struct{}) ([]common.Hash, map[common.Hash]struct{}) {
+	var marked []common.Hash
+	candidates := make(map[common.Hash]struct{})
+	for _, root := range roots {
+		if _, exists := referenced[root]; exists {
+			continue
+		}
+		if _, exists := candidates[root]; exists {
+			continue
+		}
+		candidates[root] = struct{}{}
+		if _, exists := prevCandidates[root]; exists {
+			marked = append(marked, root)
+		}
+	}
+	return marked, candidates
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+)
+
+// TestUnreferencedSectors test the sectors are marked only if found unreferenced in two
+// consecutive passes
+func TestUnreferencedSectors(t *testing.T) {
+	r1, r2, r3 := common.HexToHash("0x01"), common.HexToHash("0x02"), common.HexToHash("0x03")
+	roots := []common.Hash{r1, r2, r3, r3}
+
+	// the first pass marks nothing
+	marked, candidates := unreferencedSectors(roots, map[common.Hash]struct{}{r1: {}}, nil)
+	if len(marked) != 0 {
+		t.Fatalf("no sector should be marked in the first pass, got %v", marked)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("expect 2 candidates, got %v", candidates)
+	}
+
+	// r2 is referenced again, for example by the dxfile just uploaded
+	marked, candidates = unreferencedSectors(roots, map[common.Hash]struct{}{r1: {}, r2: {}}, candidates)
+	if len(marked) != 1 || marked[0] != r3 {
+		t.Fatalf("expect %v marked, got %v", r3, marked)
+	}
+
+	// r1 becomes unreferenced after the dxfile is deleted
+	marked, candidates = unreferencedSectors(roots, nil, candidates)
+	if len(marked) != 1 || marked[0] != r3 {
+		t.Fatalf("expect %v marked, got %v", r3, marked)
+	}
+	marked, _ = unreferencedSectors(roots, nil, candidates)
+	if len(marked) != 3 {
+		t.Fatalf("expect all sectors marked, got %v", marked)
+	}
+}
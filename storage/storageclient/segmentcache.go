@@ -0,0 +1,286 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/metrics"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+var (
+	segmentCacheHitCounter     = metrics.NewRegisteredCounter("storageclient/download/segmentcache/hit", nil)
+	segmentCacheDiskHitCounter = metrics.NewRegisteredCounter("storageclient/download/segmentcache/diskhit", nil)
+	segmentCacheMissCounter    = metrics.NewRegisteredCounter("storageclient/download/segmentcache/miss", nil)
+)
+
+type (
+	// segmentCache is the cache of the segments recovered from the downloads, keyed by the
+	// merkle roots of the sectors of the segment, so that the segments streamed repeatedly are
+	// served locally instead of being downloaded and paid for again. The segments are kept in
+	// memory, and written through to the optional disk tier under the persist directory, which
+	// survives the restarts. Both tiers are capped in bytes and evicted in LRU order
+	segmentCache struct {
+		memory *segmentLRU
+		disk   *segmentLRU
+
+		// dir is the directory of the disk tier, and diskLoaded tells whether the segments
+		// persisted in the directory have been loaded into the disk tier
+		dir        string
+		diskLoaded bool
+
+		lock sync.Mutex
+	}
+
+	// segmentLRU is the list of cached segments in LRU order with a capacity in bytes
+	segmentLRU struct {
+		capacity uint64
+		size     uint64
+		ll       *list.List
+		items    map[common.Hash]*list.Element
+	}
+
+	// cachedSegment is the segment in the cache. The data is nil for the segment in the disk tier
+	cachedSegment struct {
+		key  common.Hash
+		size uint64
+		data []byte
+	}
+)
+
+// newSegmentCache creates a segment cache with the disk tier at dir. Both tiers are disabled
+// until the sizes are set
+func newSegmentCache(dir string) *segmentCache {
+	return &segmentCache{
+		memory: newSegmentLRU(0),
+		disk:   newSegmentLRU(0),
+		dir:    dir,
+	}
+}
+
+// newSegmentLRU creates a segmentLRU with the capacity in bytes
+func newSegmentLRU(capacity uint64) *segmentLRU {
+	return &segmentLRU{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[common.Hash]*list.Element),
+	}
+}
+
+// get returns the cached segment and marks it as recently used
+func (l *segmentLRU) get(key common.Hash) (*cachedSegment, bool) {
+	elem, exist := l.items[key]
+	if !exist {
+		return nil, false
+	}
+	l.ll.MoveToFront(elem)
+	return elem.Value.(*cachedSegment), true
+}
+
+// add adds the segment as the most recently used one, and returns the segments evicted. The
+// segment larger than the capacity is evicted right away
+func (l *segmentLRU) add(cs *cachedSegment) (evicted []*cachedSegment) {
+	if cs.size > l.capacity {
+		return []*cachedSegment{cs}
+	}
+	if elem, exist := l.items[cs.key]; exist {
+		l.removeElement(elem)
+	}
+	l.items[cs.key] = l.ll.PushFront(cs)
+	l.size += cs.size
+	return l.trim()
+}
+
+// trim evicts the least recently used segments until the size fits in the capacity
+func (l *segmentLRU) trim() (evicted []*cachedSegment) {
+	for l.size > l.capacity {
+		evicted = append(evicted, l.removeElement(l.ll.Back()))
+	}
+	return evicted
+}
+
+// remove removes the segment from the list. Nil is returned if not cached
+func (l *segmentLRU) remove(key common.Hash) *cachedSegment {
+	elem, exist := l.items[key]
+	if !exist {
+		return nil
+	}
+	return l.removeElement(elem)
+}
+
+// removeElement removes the element from the list
+func (l *segmentLRU) removeElement(elem *list.Element) *cachedSegment {
+	cs := l.ll.Remove(elem).(*cachedSegment)
+	delete(l.items, cs.key)
+	l.size -= cs.size
+	return cs
+}
+
+// segmentCacheKey returns the cache key of the segment, which is the hash of the merkle roots
+// of the sectors of the segment. The segment is not cacheable if any of the sectors is not
+// stored on any host
+func segmentCacheKey(sectors [][]*dxfile.Sector) (common.Hash, bool) {
+	roots := make([]byte, 0, len(sectors)*common.HashLength)
+	for _, sectorSet := range sectors {
+		if len(sectorSet) == 0 {
+			return common.Hash{}, false
+		}
+		roots = append(roots, sectorSet[0].MerkleRoot[:]...)
+	}
+	return crypto.Keccak256Hash(roots), true
+}
+
+// get returns the cached segment data of the size, promoting the segment in the disk tier
+// to memory
+func (sc *segmentCache) get(key common.Hash, size uint64) ([]byte, bool) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	if cs, exist := sc.memory.get(key); exist && cs.size == size {
+		segmentCacheHitCounter.Inc(1)
+		return cs.data, true
+	}
+	if _, exist := sc.disk.get(key); exist {
+		data, err := sc.readDisk(key)
+		if err == nil && uint64(len(data)) == size {
+			segmentCacheDiskHitCounter.Inc(1)
+			// touch the file so that the LRU order is kept across the restarts
+			now := time.Now()
+			os.Chtimes(sc.diskFilePath(key), now, now)
+			sc.memory.add(&cachedSegment{key: key, size: size, data: data})
+			return data, true
+		}
+		sc.disk.remove(key)
+		os.Remove(sc.diskFilePath(key))
+	}
+	segmentCacheMissCounter.Inc(1)
+	return nil, false
+}
+
+// add adds the segment data recovered to the cache. The data must not be modified afterwards
+func (sc *segmentCache) add(key common.Hash, data []byte) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	size := uint64(len(data))
+	sc.memory.add(&cachedSegment{key: key, size: size, data: data})
+	if sc.disk.capacity == 0 {
+		return
+	}
+	if _, exist := sc.disk.get(key); exist {
+		return
+	}
+	if err := sc.writeDisk(key, data); err != nil {
+		return
+	}
+	for _, evicted := range sc.disk.add(&cachedSegment{key: key, size: size}) {
+		os.Remove(sc.diskFilePath(evicted.key))
+	}
+}
+
+// setSize sets the size caps of the memory tier and the disk tier, where zero disables the
+// tier. The segments persisted are loaded the first time the disk tier is enabled, and are
+// removed once the disk tier is disabled
+func (sc *segmentCache) setSize(memorySize, diskSize uint64) error {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	sc.memory.capacity = memorySize
+	sc.memory.trim()
+
+	if diskSize == 0 {
+		sc.clearDisk()
+		return nil
+	}
+	sc.disk.capacity = diskSize
+	if !sc.diskLoaded {
+		if err := sc.loadDisk(); err != nil {
+			return err
+		}
+	}
+	for _, evicted := range sc.disk.trim() {
+		os.Remove(sc.diskFilePath(evicted.key))
+	}
+	return nil
+}
+
+// loadDisk loads the segments persisted in the directory into the disk tier, in the order
+// of the last access time. The caller must hold the lock
+func (sc *segmentCache) loadDisk() error {
+	if err := os.MkdirAll(sc.dir, 0700); err != nil {
+		return fmt.Errorf("cannot create the segment cache directory: %v", err)
+	}
+	infos, err := ioutil.ReadDir(sc.dir)
+	if err != nil {
+		return err
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || filepath.Ext(name) != segmentCacheFileExt || info.Size() < 8 {
+			continue
+		}
+		key := common.HexToHash(strings.TrimSuffix(name, segmentCacheFileExt))
+		for _, evicted := range sc.disk.add(&cachedSegment{key: key, size: uint64(info.Size()) - 8}) {
+			os.Remove(sc.diskFilePath(evicted.key))
+		}
+	}
+	sc.diskLoaded = true
+	return nil
+}
+
+// clearDisk disables the disk tier and removes the segments persisted. The caller must hold
+// the lock
+func (sc *segmentCache) clearDisk() {
+	paths, _ := filepath.Glob(filepath.Join(sc.dir, "*"+segmentCacheFileExt))
+	for _, path := range paths {
+		os.Remove(path)
+	}
+	sc.disk = newSegmentLRU(0)
+	sc.diskLoaded = false
+}
+
+// writeDisk writes the segment data prefixed with the checksum to the disk tier
+func (sc *segmentCache) writeDisk(key common.Hash, data []byte) error {
+	buf := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(buf, sectorChecksum(data))
+	copy(buf[8:], data)
+	return ioutil.WriteFile(sc.diskFilePath(key), buf, 0600)
+}
+
+// readDisk reads the segment data from the disk tier, and verifies it against the checksum
+func (sc *segmentCache) readDisk(key common.Hash) ([]byte, error) {
+	buf, err := ioutil.ReadFile(sc.diskFilePath(key))
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) < 8 {
+		return nil, fmt.Errorf("segment cache file too short: %v bytes", len(buf))
+	}
+	data := buf[8:]
+	if sum := sectorChecksum(data); sum != binary.BigEndian.Uint64(buf) {
+		return nil, fmt.Errorf("segment cache checksum mismatch: expect %x, got %x", binary.BigEndian.Uint64(buf), sum)
+	}
+	return data, nil
+}
+
+// diskFilePath returns the path of the segment file in the disk tier
+func (sc *segmentCache) diskFilePath(key common.Hash) string {
+	return filepath.Join(sc.dir, common.Bytes2Hex(key[:])+segmentCacheFileExt)
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+)
+
+// TestSegmentCacheEviction test the segments are evicted in LRU order once the memory tier
+// exceeds the size cap
+func TestSegmentCacheEviction(t *testing.T) {
+	sc := newSegmentCache("")
+	if err := sc.setSize(30, 0); err != nil {
+		t.Fatal(err)
+	}
+	keys := []common.Hash{{1}, {2}, {3}}
+	for _, key := range keys {
+		sc.add(key, bytes.Repeat(key[:1], 10))
+	}
+	// access the first segment so that the second one is the least recently used
+	if _, cached := sc.get(keys[0], 10); !cached {
+		t.Fatal("segment not cached")
+	}
+	sc.add(common.Hash{4}, make([]byte, 10))
+	if _, cached := sc.get(keys[1], 10); cached {
+		t.Error("least recently used segment not evicted")
+	}
+	if data, cached := sc.get(keys[0], 10); !cached || !bytes.Equal(data, bytes.Repeat([]byte{1}, 10)) {
+		t.Error("recently used segment evicted")
+	}
+	// the segment with unexpected size is not returned
+	if _, cached := sc.get(keys[2], 20); cached {
+		t.Error("segment with unexpected size returned")
+	}
+	// the segment larger than the cap is not cached
+	sc.add(common.Hash{5}, make([]byte, 31))
+	if _, cached := sc.get(common.Hash{5}, 31); cached {
+		t.Error("segment larger than the cap cached")
+	}
+}
+
+// TestSegmentCacheDisk test the segments in the disk tier survive the restarts, and the
+// corrupted segments are dropped
+func TestSegmentCacheDisk(t *testing.T) {
+	dir, err := ioutil.TempDir("", "segmentcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sc := newSegmentCache(dir)
+	if err := sc.setSize(0, 20); err != nil {
+		t.Fatal(err)
+	}
+	sc.add(common.Hash{1}, bytes.Repeat([]byte{1}, 10))
+	sc.add(common.Hash{2}, bytes.Repeat([]byte{2}, 10))
+
+	// restart the cache
+	sc = newSegmentCache(dir)
+	if err := sc.setSize(10, 20); err != nil {
+		t.Fatal(err)
+	}
+	if data, cached := sc.get(common.Hash{1}, 10); !cached || !bytes.Equal(data, bytes.Repeat([]byte{1}, 10)) {
+		t.Fatal("segment not loaded from disk")
+	}
+	if err := ioutil.WriteFile(sc.diskFilePath(common.Hash{2}), make([]byte, 18), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, cached := sc.get(common.Hash{2}, 10); cached {
+		t.Error("corrupted segment returned")
+	}
+	if _, err := os.Stat(sc.diskFilePath(common.Hash{2})); !os.IsNotExist(err) {
+		t.Error("corrupted segment not removed")
+	}
+
+	// disabling the disk tier removes the segments persisted
+	if err := sc.setSize(10, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(sc.diskFilePath(common.Hash{1})); !os.IsNotExist(err) {
+		t.Error("segment not removed with the disk tier disabled")
+	}
+}
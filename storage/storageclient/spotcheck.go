@@ -0,0 +1,137 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/metrics"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
+	"github.com/DxChainNetwork/godx/storage/storageerr"
+)
+
+var (
+	spotCheckPassedCounter = metrics.NewRegisteredCounter("storageclient/spotcheck/passed", nil)
+	spotCheckFailedCounter = metrics.NewRegisteredCounter("storageclient/spotcheck/failed", nil)
+)
+
+// errNoSectorToCheck is returned when the contract has no sector to spot check
+var errNoSectorToCheck = errors.New("no sector stored under the contract")
+
+// spotCheckLoop periodically spot checks the hosts of the active contracts, which downloads
+// a tiny random range of a random sector stored on the host along with the merkle proof. The
+// hosts dropped the data silently are caught long before the storage proof window
+func (client *StorageClient) spotCheckLoop() {
+	if err := client.tm.Add(); err != nil {
+		return
+	}
+	defer client.tm.Done()
+
+	ticker := time.NewTicker(SpotCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-client.tm.StopChan():
+			return
+		case <-ticker.C:
+		}
+		for _, contract := range client.contractManager.RetrieveActiveContracts() {
+			select {
+			case <-client.tm.StopChan():
+				return
+			default:
+			}
+			client.spotCheck(contract)
+		}
+	}
+}
+
+// spotCheck spot checks the host of the contract, and records the result in the interactions
+// of the host. The checks failed for the reasons unrelated to the data stored, e.g. the host
+// is offline or busy, are not recorded
+func (client *StorageClient) spotCheck(contract storage.ContractMetaData) {
+	err := client.spotCheckContract(contract)
+	switch storageerr.CodeOf(err) {
+	case storageerr.CodeInvalidProof, storageerr.CodeNegotiationFailed, storageerr.CodeCommitFailed:
+		spotCheckFailedCounter.Inc(1)
+		client.log.Warn("Host failed the spot check", "host", contract.EnodeID, "contractID", contract.ID, "err", err)
+		client.storageHostManager.IncrementFailedInteractions(contract.EnodeID, storagehostmanager.InteractionSpotCheck)
+	default:
+		if err == nil {
+			spotCheckPassedCounter.Inc(1)
+			client.storageHostManager.IncrementSuccessfulInteractions(contract.EnodeID, storagehostmanager.InteractionSpotCheck)
+		} else if err != errNoSectorToCheck {
+			client.log.Debug("Spot check skipped", "host", contract.EnodeID, "contractID", contract.ID, "err", err)
+		}
+	}
+}
+
+// spotCheckContract downloads a random range of a random sector stored under the contract,
+// which is verified against the merkle root of the sector
+func (client *StorageClient) spotCheckContract(contract storage.ContractMetaData) error {
+	if err := client.tm.Add(); err != nil {
+		return err
+	}
+	defer client.tm.Done()
+
+	roots, err := client.contractRoots(contract.ID)
+	if err != nil {
+		return err
+	}
+	if len(roots) == 0 {
+		return errNoSectorToCheck
+	}
+	hostInfo, exist := client.storageHostManager.RetrieveHostInfo(contract.EnodeID)
+	if !exist {
+		return ErrUnableRetrieveHostInfo
+	}
+
+	// the host busy with the uploads and downloads is checked next time
+	offset, length := spotCheckRange(rand.Uint64(), rand.Uint64())
+	if err := client.hostLimiter.tryAcquire(contract.EnodeID, uint64(length)); err != nil {
+		return err
+	}
+	defer client.hostLimiter.release(contract.EnodeID, uint64(length))
+
+	sp, err := client.SetupConnection(hostInfo.EnodeURL)
+	if err != nil {
+		return err
+	}
+	defer sp.Close()
+	if ok := sp.TryToRenewOrRevise(); !ok {
+		return ErrContractRenewing
+	}
+	defer sp.RevisionOrRenewingDone()
+
+	_, err = client.Download(sp, roots[rand.Intn(len(roots))], offset, length, &hostInfo)
+	return err
+}
+
+// contractRoots returns the merkle roots of the sectors stored under the contract
+func (client *StorageClient) contractRoots(id storage.ContractID) ([]common.Hash, error) {
+	contractSet := client.contractManager.GetStorageContractSet()
+	c, exists := contractSet.Acquire(id)
+	if !exists {
+		return nil, errors.New("contract not found in the contract set")
+	}
+	roots, err := c.MerkleRoots()
+	if returnErr := contractSet.Return(c); returnErr != nil {
+		client.log.Warn("failed to return the contract", "contractID", id, "err", returnErr)
+	}
+	return roots, err
+}
+
+// spotCheckRange maps the random numbers to the range within the sector to spot check, which
+// is aligned to the segments so that the merkle proof of the range could be provided
+func spotCheckRange(offsetRand, lengthRand uint64) (offset, length uint32) {
+	segments := storage.SectorSize / storage.SegmentSize
+	numSegments := 1 + lengthRand%SpotCheckMaxSegments
+	start := offsetRand % (segments - numSegments + 1)
+	return uint32(start * storage.SegmentSize), uint32(numSegments * storage.SegmentSize)
+}
@@ -0,0 +1,38 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"math"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestSpotCheckRange test the spot check range is aligned to the segments, and is within
+// the sector
+func TestSpotCheckRange(t *testing.T) {
+	tests := []struct {
+		offsetRand, lengthRand uint64
+	}{
+		{0, 0},
+		{1, 1},
+		{math.MaxUint64, math.MaxUint64},
+		{storage.SectorSize / storage.SegmentSize, SpotCheckMaxSegments - 1},
+		{12345, 67890},
+	}
+	for _, test := range tests {
+		offset, length := spotCheckRange(test.offsetRand, test.lengthRand)
+		if offset%storage.SegmentSize != 0 || length%storage.SegmentSize != 0 {
+			t.Errorf("range [%v, %v) not aligned to segments", offset, offset+length)
+		}
+		if length == 0 || length > SpotCheckMaxSegments*storage.SegmentSize {
+			t.Errorf("unexpected length %v", length)
+		}
+		if uint64(offset)+uint64(length) > storage.SectorSize {
+			t.Errorf("range [%v, %v) out of the sector", offset, offset+length)
+		}
+	}
+}
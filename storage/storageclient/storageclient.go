@@ -30,9 +30,13 @@ import (
 	"github.com/DxChainNetwork/godx/rlp"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/contractmanager"
+	"github.com/DxChainNetwork/godx/storage/storageclient/contractset"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+	"github.com/DxChainNetwork/godx/storage/storageclient/keyring"
 	"github.com/DxChainNetwork/godx/storage/storageclient/memorymanager"
 	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
+	"github.com/DxChainNetwork/godx/storage/storageerr"
 )
 
 // StorageClient contains fields that are used to perform StorageHost
@@ -46,17 +50,50 @@ type StorageClient struct {
 	storageHostManager *storagehostmanager.StorageHostManager
 	contractManager    *contractmanager.ContractManager
 
+	// keyring seals the contract secrets, storage host information and the file cipher
+	// keys persisted, once the passphrase is set
+	keyring *keyring.Keyring
+
 	// Download management
 	downloadHeapMu sync.Mutex
 	downloadHeap   *downloadSegmentHeap
 	newDownloads   chan struct{}
 
+	// pending downloads indexed by the download id, protected by downloadHeapMu
+	downloads map[string]*download
+
+	// webhooks delivers the storage client events to the user specified urls
+	webhooks *webhookDispatcher
+
+	// dedupIndex is the content addressed index of the sectors uploaded, with which the
+	// sectors already stored are referenced instead of being uploaded again
+	dedupIndex *sectorDedupIndex
+
+	// segmentCache caches the segments downloaded, so that the segments streamed repeatedly
+	// are not downloaded from the hosts again
+	segmentCache *segmentCache
+
+	// sectors found unreferenced in the last garbage collection pass, protected by sectorGCLock
+	sectorGCCandidates map[storage.ContractID]map[common.Hash]struct{}
+	sectorGCLock       sync.Mutex
+
 	// Upload management
 	uploadHeap uploadHeap
 
 	// List of workers that can be used for uploading and/or downloading.
 	workerPool map[storage.ContractID]*worker
 
+	// uploads and repairs paused by the user
+	uploadPause uploadPause
+
+	// hostLimiter limits the simultaneous negotiations and the bytes in flight per host
+	hostLimiter *hostLimiter
+
+	// negotiations tracks the negotiations in progress, which are drained on shutdown
+	// within the drainTimeout before the workers are killed
+	negotiations storage.NegotiationDrain
+	drainTimeout time.Duration
+
 	// Directories and File related
 	persist        persistence
 	persistDir     string
@@ -86,27 +123,39 @@ func New(persistDir string) (*StorageClient, error) {
 		log:            log.New(),
 		newDownloads:   make(chan struct{}, 1),
 		downloadHeap:   new(downloadSegmentHeap),
+		downloads:      make(map[string]*download),
 		uploadHeap: uploadHeap{
 			pendingSegments:     make(map[uploadSegmentID]struct{}),
 			segmentComing:       make(chan struct{}, 1),
 			stuckSegmentSuccess: make(chan storage.DxPath, 1),
 		},
-		workerPool: make(map[storage.ContractID]*worker),
+		workerPool:   make(map[storage.ContractID]*worker),
+		drainTimeout: storage.DefaultDrainTimeout,
 	}
 
 	sc.memoryManager = memorymanager.New(DefaultMaxMemory, sc.tm.StopChan())
+	sc.webhooks = newWebhookDispatcher(persistDir, &sc.tm, sc.log)
+	sc.dedupIndex = newSectorDedupIndex(persistDir)
+	sc.segmentCache = newSegmentCache(filepath.Join(persistDir, SegmentCacheDirectory))
+
+	// load the keyring, which is locked until unlocked with the passphrase
+	if sc.keyring, err = keyring.New(persistDir); err != nil {
+		err = fmt.Errorf("error loading keyring: %s", err.Error())
+		return nil, err
+	}
 
 	// initialize storageHostManager
-	sc.storageHostManager = storagehostmanager.New(sc.persistDir)
+	sc.storageHostManager = storagehostmanager.New(sc.persistDir, sc.keyring)
+	sc.hostLimiter = newHostLimiter(sc.storageHostManager.ConcurrencyLimit, sc.tm.StopChan())
 
 	// initialize storage contract manager
-	if sc.contractManager, err = contractmanager.New(sc.persistDir, sc.storageHostManager); err != nil {
+	if sc.contractManager, err = contractmanager.New(sc.persistDir, sc.storageHostManager, sc.keyring); err != nil {
 		err = fmt.Errorf("error initializing contract manager: %s", err.Error())
 		return nil, err
 	}
 
 	// initialize fileSystem
-	sc.fileSystem = filesystem.New(persistDir, sc.contractManager)
+	sc.fileSystem = filesystem.New(persistDir, sc.contractManager, sc.keyring)
 
 	return sc, nil
 }
@@ -137,6 +186,16 @@ func (client *StorageClient) Start(b storage.EthBackend, apiBackend ethapi.Backe
 		return err
 	}
 
+	// load the webhooks
+	if err := client.webhooks.load(); err != nil {
+		return err
+	}
+
+	// load the sector dedup index
+	if err := client.dedupIndex.load(); err != nil {
+		return err
+	}
+
 	if err = client.fileSystem.Start(); err != nil {
 		return err
 	}
@@ -150,6 +209,11 @@ func (client *StorageClient) Start(b storage.EthBackend, apiBackend ethapi.Backe
 	go client.stuckLoop()
 	go client.uploadOrRepair()
 	go client.healthCheckLoop()
+	go client.contractRepairLoop()
+	go client.webhookLoop()
+	go client.sectorGCLoop()
+	go client.spotCheckLoop()
+	go client.mirrorProofLoop()
 
 	// kill workers on shutdown.
 	client.tm.OnStop(func() error {
@@ -161,13 +225,38 @@ func (client *StorageClient) Start(b storage.EthBackend, apiBackend ethapi.Backe
 		return nil
 	})
 
+	// save the sector dedup index on shutdown
+	client.tm.OnStop(client.dedupIndex.save)
+
 	client.log.Info("Storage Client Started")
 
 	return nil
 }
 
-// Close method will be used to send storage
+// SetDrainTimeout sets the time waited for the negotiations in progress to finish on shutdown
+func (client *StorageClient) SetDrainTimeout(timeout time.Duration) {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	client.drainTimeout = timeout
+}
+
+// DrainNegotiations stops starting new negotiations with the storage hosts, and waits for the
+// negotiations in progress to finish within the drain timeout
+func (client *StorageClient) DrainNegotiations() {
+	client.lock.Lock()
+	timeout := client.drainTimeout
+	client.lock.Unlock()
+
+	if left := client.negotiations.Drain(timeout); left != 0 {
+		client.log.Warn("Storage client negotiations not finished within the drain timeout", "negotiations", left, "timeout", timeout)
+	}
+}
+
+// Close method will be used to send storage. The negotiations in progress are drained before
+// the workers are killed
 func (client *StorageClient) Close() error {
+	client.DrainNegotiations()
+
 	client.log.Info("Closing The Contract Manager")
 	client.contractManager.Stop()
 
@@ -205,6 +294,11 @@ func (client *StorageClient) ContractDetail(contractID storage.ContractID) (deta
 	return client.contractManager.RetrieveActiveContract(contractID)
 }
 
+// ContractTranscript will retrieve the negotiation transcript of the contract
+func (client *StorageClient) ContractTranscript(contractID storage.ContractID) ([]storage.TranscriptEntry, bool, error) {
+	return client.contractManager.GetStorageContractSet().RetrieveTranscript(contractID)
+}
+
 // ActiveContracts will retrieve all active contracts, reformat them, and return them back
 func (client *StorageClient) ActiveContracts() (activeContracts []ActiveContractsAPIDisplay) {
 	allActiveContracts := client.contractManager.RetrieveActiveContracts()
@@ -241,6 +335,15 @@ func (client *StorageClient) SetClientSetting(setting storage.ClientSetting) (er
 			setting.MaxUploadSpeed, setting.MaxDownloadSpeed)
 		return
 	}
+	if setting.MaxMemory == 0 {
+		err = errors.New("max memory cannot be set to 0")
+		return
+	}
+	if setting.UploadBatchSize < storage.SectorSize || setting.UploadBatchSize > MaxUploadBatchSize {
+		err = fmt.Errorf("upload batch size %v must be within the range of [%v, %v]",
+			setting.UploadBatchSize, storage.SectorSize, MaxUploadBatchSize)
+		return
+	}
 
 	// set the rent payment
 	if err = client.contractManager.SetRentPayment(setting.RentPayment, client.storageHostManager); err != nil {
@@ -255,10 +358,24 @@ func (client *StorageClient) SetClientSetting(setting storage.ClientSetting) (er
 	// set the ip violation check
 	client.storageHostManager.SetIPViolationCheck(setting.EnableIPViolation)
 
+	// set the max memory of the upload and download pipelines, the memory requests blocked
+	// are processed if the limit is expanded
+	client.memoryManager.SetMemoryLimit(setting.MaxMemory)
+
+	// resize the segment cache, the segments exceeding the caps are evicted
+	if err = client.segmentCache.setSize(setting.SegmentCacheSize, setting.SegmentCacheDiskSize); err != nil {
+		return
+	}
+
 	// update and save the persist
 	client.lock.Lock()
 	client.persist.MaxDownloadSpeed = setting.MaxDownloadSpeed
 	client.persist.MaxUploadSpeed = setting.MaxUploadSpeed
+	client.persist.MaxMemory = setting.MaxMemory
+	client.persist.UploadBatchSize = setting.UploadBatchSize
+	client.persist.SegmentCacheSize = setting.SegmentCacheSize
+	client.persist.SegmentCacheDiskSize = setting.SegmentCacheDiskSize
+	client.persist.VerifyUploads = setting.VerifyUploads
 	if err = client.saveSettings(); err != nil {
 		err = fmt.Errorf("failed to save the storage client settings: %s", err.Error())
 		client.lock.Unlock()
@@ -280,10 +397,35 @@ func (client *StorageClient) RetrieveClientSetting() (setting storage.ClientSett
 		EnableIPViolation: client.storageHostManager.RetrieveIPViolationCheckSetting(),
 		MaxUploadSpeed:    maxUploadSpeed,
 		MaxDownloadSpeed:  maxDownloadSpeed,
+		MaxMemory:         client.memoryManager.MemoryLimit(),
+		UploadBatchSize:   client.uploadBatchSize(),
+		VerifyUploads:     client.verifyUploads(),
 	}
+	setting.SegmentCacheSize, setting.SegmentCacheDiskSize = client.segmentCacheSize()
 	return
 }
 
+// uploadBatchSize returns the byte budget of the sectors appended in one upload request
+func (client *StorageClient) uploadBatchSize() uint64 {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	return client.persist.UploadBatchSize
+}
+
+// verifyUploads returns whether the sectors uploaded are verified before added to the files
+func (client *StorageClient) verifyUploads() bool {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	return client.persist.VerifyUploads
+}
+
+// segmentCacheSize returns the size caps of the memory and the disk tiers of the segment cache
+func (client *StorageClient) segmentCacheSize() (memorySize, diskSize uint64) {
+	client.lock.Lock()
+	defer client.lock.Unlock()
+	return client.persist.SegmentCacheSize, client.persist.SegmentCacheDiskSize
+}
+
 // setBandwidthLimits specifies the data upload and downloading speed limit
 func (client *StorageClient) setBandwidthLimits(downloadSpeedLimit, uploadSpeedLimit int64) (err error) {
 	// validation
@@ -304,10 +446,17 @@ func (client *StorageClient) setBandwidthLimits(downloadSpeedLimit, uploadSpeedL
 // Append will send the given data to host and return the merkle root of data
 func (client *StorageClient) Append(sp storage.Peer, data []byte, hostInfo *storage.HostInfo) (common.Hash, error) {
 	err := client.Write(sp, []storage.UploadAction{{Type: storage.UploadActionAppend, Data: data}}, hostInfo)
-	return merkle.Sha256MerkleTreeRoot(data), err
+	return merkle.CachedSha256MerkleTreeRoot(data), err
 }
 
 func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadAction, hostInfo *storage.HostInfo) (err error) {
+	// get the host config used in negotiation, its hash is carried in the request so that the
+	// host could reject the negotiation if the config has been changed
+	config, configHash, err := client.storageHostManager.HostConfig(*hostInfo)
+	if err != nil {
+		return err
+	}
+
 	// Retrieve the last contract revision
 	scs := client.contractManager.GetStorageContractSet()
 
@@ -315,20 +464,25 @@ func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadActi
 	contractID := scs.GetContractIDByHostID(hostInfo.EnodeID)
 	contract, exist := scs.Acquire(contractID)
 	if !exist {
-		return fmt.Errorf("contract does not exist: %s", contractID.String())
+		return storageerr.Errorf(storageerr.CodeContractNotFound, "contract does not exist: %s", contractID.String())
 	}
 
 	defer scs.Return(contract)
 
+	// reconcile the revision of the negotiation interrupted before the restart first
+	if err = client.reconcileRevision(sp, contract); err != nil {
+		return err
+	}
+
 	// old contract header and revision
 	contractHeader := contract.Header()
 	contractRevision := contractHeader.LatestContractRevision
 
 	// calculate price per sector
 	blockBytes := storage.SectorSize * uint64(contractRevision.NewWindowEnd-client.ethBackend.GetCurrentBlockHeight())
-	sectorBandwidthPrice := hostInfo.UploadBandwidthPrice.MultUint64(storage.SectorSize)
-	sectorStoragePrice := hostInfo.StoragePrice.MultUint64(blockBytes)
-	sectorDeposit := hostInfo.Deposit.MultUint64(blockBytes)
+	sectorBandwidthPrice := config.UploadBandwidthPrice.MultUint64(storage.SectorSize)
+	sectorStoragePrice := config.StoragePrice.MultUint64(blockBytes)
+	sectorDeposit := config.Deposit.MultUint64(blockBytes)
 
 	// calculate the new Merkle root set and total cost/collateral
 	var bandwidthPrice, storagePrice, deposit common.BigInt
@@ -348,15 +502,15 @@ func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadActi
 
 	// estimate cost of Merkle proof
 	proofSize := storage.HashSize * (128 + len(actions))
-	bandwidthPrice = bandwidthPrice.Add(hostInfo.DownloadBandwidthPrice.MultUint64(uint64(proofSize)))
-	cost := bandwidthPrice.Add(storagePrice).Add(hostInfo.BaseRPCPrice)
+	bandwidthPrice = bandwidthPrice.Add(config.DownloadBandwidthPrice.MultUint64(uint64(proofSize)))
+	cost := bandwidthPrice.Add(storagePrice).Add(config.BaseRPCPrice)
 
 	// check that enough funds are available
 	if contractRevision.NewValidProofOutputs[0].Value.Cmp(cost.BigIntPtr()) < 0 {
-		return errors.New("contract has insufficient funds to support upload")
+		return storageerr.New(storageerr.CodeInsufficientFunds, "contract has insufficient funds to support upload")
 	}
 	if contractRevision.NewMissedProofOutputs[1].Value.Cmp(deposit.BigIntPtr()) < 0 {
-		return errors.New("contract has insufficient collateral to support upload")
+		return storageerr.New(storageerr.CodeInsufficientFunds, "contract has insufficient collateral to support upload")
 	}
 
 	// create the revision; we will update the Merkle root later
@@ -369,6 +523,7 @@ func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadActi
 		StorageContractID: contractRevision.ParentID,
 		Actions:           actions,
 		NewRevisionNumber: rev.NewRevisionNumber,
+		HostConfigHash:    configHash,
 	}
 	req.NewValidProofValues = make([]*big.Int, len(rev.NewValidProofOutputs))
 	for i, o := range rev.NewValidProofOutputs {
@@ -417,12 +572,31 @@ func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadActi
 		return storage.ErrHostBusyHandleReq
 	}
 
+	// the host config used is outdated, refresh it before the next negotiation
+	if msg.Code == storage.HostConfigChangedMsg {
+		client.storageHostManager.InvalidateHostConfig(hostInfo.EnodeID)
+		return storage.ErrHostConfigChanged
+	}
+
+	// the host is running out of the free space, refresh the remaining storage advertised,
+	// and the host's evaluation will not be degraded
+	if msg.Code == storage.HostFullMsg {
+		client.storageHostManager.InvalidateHostConfig(hostInfo.EnodeID)
+		return storage.ErrHostFull
+	}
+
 	if msg.Code == storage.HostNegotiateErrorMsg {
 		hostNegotiateErr = storage.ErrHostNegotiate
 		return hostNegotiateErr
 	}
 
 	if err := msg.Decode(&merkleResp); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		hostNegotiateErr = err
+		return err
+	}
+	if err := merkleResp.Validate(); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
 		hostNegotiateErr = err
 		return err
 	}
@@ -434,7 +608,8 @@ func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadActi
 	leafHashes := merkleResp.OldLeafHashes
 	oldRoot, newRoot := contractRevision.NewFileMerkleRoot, merkleResp.NewMerkleRoot
 
-	if err := merkle.Sha256VerifyDiffProof(proofRanges, numSectors, proofHashes, leafHashes, oldRoot); err != nil {
+	if err := merkle.NewDiffProof(proofRanges, numSectors, proofHashes).VerifyRoots(leafHashes, oldRoot); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorInvalidProof, err)
 		hostNegotiateErr = err
 		return fmt.Errorf("invalid merkle proof for old root, err: %v", err)
 	}
@@ -442,7 +617,8 @@ func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadActi
 	// and then modify the leaves and verify the new Merkle root
 	leafHashes = ModifyLeaves(leafHashes, actions, numSectors)
 	proofRanges = ModifyProofRanges(proofRanges, actions, numSectors)
-	if err := merkle.Sha256VerifyDiffProof(proofRanges, numSectors, proofHashes, leafHashes, newRoot); err != nil {
+	if err := merkle.NewDiffProof(proofRanges, numSectors, proofHashes).VerifyRoots(leafHashes, newRoot); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorInvalidProof, err)
 		hostNegotiateErr = err
 		return fmt.Errorf("invalid merkle proof for new root, err: %v", err)
 	}
@@ -466,6 +642,23 @@ func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadActi
 		return err
 	}
 
+	// record the revision intent before the signed revision is sent to the host. The revision
+	// will be rolled back if the negotiation failed before the host acknowledged the revision
+	txn, err := contract.PrepareRevision(rev)
+	if err != nil {
+		clientNegotiateErr = err
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if errRollback := contract.RollbackRevision(txn); errRollback != nil {
+				client.log.Error("failed to roll back the upload revision", "err", errRollback)
+			}
+		} else if errRelease := contract.ReleaseRevision(txn); errRelease != nil {
+			client.log.Error("failed to release the upload revision intent", "err", errRelease)
+		}
+	}()
+
 	// send client sig to host
 	if err := sp.SendContractUploadClientRevisionSign(clientRevisionSign); err != nil {
 		clientNegotiateErr = err
@@ -506,22 +699,21 @@ func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadActi
 
 	_ = sp.SendClientCommitSuccessMsg()
 
-	// wait for HostAckMsg until timeout
+	// wait for HostAckMsg until timeout. If failed, the revision will be rolled back
 	msg, err = sp.ClientWaitContractResp()
 	if err != nil {
 		log.Error("contract upload failed when wait for host ACK msg", "err", err.Error())
 
-		_ = contract.RollbackUndoMem(contractHeader)
 		err = fmt.Errorf("failed to read host ACK message, error: %s", err.Error())
 		return err
 	}
 
 	switch msg.Code {
 	case storage.HostAckMsg:
+		client.appendTranscript(contract, req, hostRevisionSig, rev.NewRevisionNumber, clientWallet, clientAccount)
 		return
 	default:
 		hostCommitErr = storage.ErrHostCommit
-		_ = contract.RollbackUndoMem(contractHeader)
 
 		_ = sp.SendClientAckMsg()
 		_, _ = sp.ClientWaitContractResp()
@@ -529,30 +721,271 @@ func (client *StorageClient) Write(sp storage.Peer, actions []storage.UploadActi
 	}
 }
 
+// appendTranscript records the negotiation in the transcript of the contract, signed by the
+// client account. The revision has already been committed, so failure is only logged
+func (client *StorageClient) appendTranscript(contract *contractset.Contract, req interface{}, hostSig []byte, revisionNumber uint64, wallet accounts.Wallet, account accounts.Account) {
+	sign := func(hash []byte) ([]byte, error) {
+		return wallet.SignHash(account, hash)
+	}
+	if err := contract.AppendTranscript(req, hostSig, revisionNumber, sign); err != nil {
+		client.log.Warn("failed to record the negotiation transcript", "revision", revisionNumber, "err", err)
+	}
+}
+
+// verifyDownloadData verifies the length of the data responded by the host, and the
+// Merkle proof of each section if requested
+func verifyDownloadData(sections []storage.DownloadRequestSector, merkleProof bool, totalLength uint64, resp storage.DownloadBatchResponse) error {
+	if uint64(len(resp.Data)) != totalLength {
+		return errors.New("host did not send enough sector data")
+	}
+	if !merkleProof {
+		return nil
+	}
+	if len(resp.MerkleProofs) != len(sections) {
+		return storageerr.New(storageerr.CodeInvalidProof, "host did not send the Merkle proof of each section")
+	}
+
+	var dataOffset int
+	for i, sec := range sections {
+		secData := resp.Data[dataOffset : dataOffset+int(sec.Length)]
+		dataOffset += int(sec.Length)
+
+		proofStart := uint64(sec.Offset) / merkle.LeafSize
+		proofEnd := uint64(sec.Offset+sec.Length) / merkle.LeafSize
+		if err := merkle.NewRangeProof(proofStart, proofEnd, resp.MerkleProofs[i]).VerifyData(secData, sec.MerkleRoot); err != nil {
+			return storageerr.New(storageerr.CodeInvalidProof, "host provided incorrect sector data or Merkle proof")
+		}
+	}
+	return nil
+}
+
+// reportInvalidProof reports the misbehavior of the host if the verification failed for the
+// invalid proof provided by the host
+func reportInvalidProof(sp storage.Peer, err error) {
+	if storageerr.Is(err, storageerr.CodeInvalidProof) {
+		sp.ReportMisbehavior(storage.MisbehaviorInvalidProof, err)
+	}
+}
+
+// PublicRead reads the public sectors from the host, writing the requested data to w. No
+// contract with the host is needed, and the data is verified against the Merkle proofs
+func (client *StorageClient) PublicRead(sp storage.Peer, w io.Writer, req storage.PublicReadRequest) error {
+	if len(req.Sections) == 0 {
+		return errors.New("no section requested")
+	}
+	var totalLength uint64
+	for _, sec := range req.Sections {
+		if uint64(sec.Offset)+uint64(sec.Length) > storage.SectorSize {
+			return errors.New("download out boundary of sector")
+		}
+		if req.MerkleProof && (sec.Offset%merkle.LeafSize != 0 || sec.Length%merkle.LeafSize != 0) {
+			return errors.New("offset and length must be multiples of SegmentSize when requesting a Merkle proof")
+		}
+		totalLength += uint64(sec.Length)
+	}
+
+	if err := sp.RequestPublicRead(req); err != nil {
+		return err
+	}
+
+	msg, err := sp.ClientWaitContractResp()
+	if err != nil {
+		return err
+	}
+	switch msg.Code {
+	case storage.HostBusyHandleReqMsg:
+		return storage.ErrHostBusyHandleReq
+	case storage.HostNegotiateErrorMsg:
+		return storage.ErrHostNegotiate
+	case storage.ContractDownloadBatchDataMsg:
+	default:
+		return fmt.Errorf("unexpected public read response message code: %v", msg.Code)
+	}
+
+	var resp storage.DownloadBatchResponse
+	if err := msg.Decode(&resp); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		return err
+	}
+	if err := verifyDownloadData(req.Sections, req.MerkleProof, totalLength, resp); err != nil {
+		reportInvalidProof(sp, err)
+		return err
+	}
+	_, err = w.Write(resp.Data)
+	return err
+}
+
+// PublicReadSector reads the whole public sector with the merkle root from the host
+// specified by the enode url, and verifies the data against the merkle root
+func (client *StorageClient) PublicReadSector(enodeURL string, root common.Hash, w io.Writer) error {
+	sp, err := client.SetupConnection(enodeURL)
+	if err != nil {
+		return err
+	}
+	defer sp.Close()
+
+	req := storage.PublicReadRequest{
+		Sections:    []storage.DownloadRequestSector{{MerkleRoot: root, Offset: 0, Length: uint32(storage.SectorSize)}},
+		MerkleProof: true,
+	}
+	return client.PublicRead(sp, w, req)
+}
+
+// SectorStored asks the host whether the sector with the merkle root is already stored under
+// the contract with the host. The merkle proof responded by the host is verified against the
+// merkle root of the latest contract revision
+func (client *StorageClient) SectorStored(sp storage.Peer, root common.Hash, hostInfo *storage.HostInfo) (bool, error) {
+	scs := client.contractManager.GetStorageContractSet()
+	contractID := scs.GetContractIDByHostID(hostInfo.EnodeID)
+	contract, exist := scs.Acquire(contractID)
+	if !exist {
+		return false, storageerr.Errorf(storageerr.CodeContractNotFound, "contract does not exist: %s", contractID.String())
+	}
+	contractRevision := contract.Header().LatestContractRevision
+	if err := scs.Return(contract); err != nil {
+		return false, err
+	}
+
+	req := storage.SectorCheckRequest{
+		StorageContractID: contractRevision.ParentID,
+		SectorRoot:        root,
+	}
+	if err := sp.RequestSectorCheck(req); err != nil {
+		return false, err
+	}
+
+	msg, err := sp.ClientWaitContractResp()
+	if err != nil {
+		return false, err
+	}
+	switch msg.Code {
+	case storage.HostBusyHandleReqMsg:
+		return false, storage.ErrHostBusyHandleReq
+	case storage.HostNegotiateErrorMsg:
+		return false, storage.ErrHostNegotiate
+	case storage.ContractSectorStoredMsg:
+	default:
+		return false, fmt.Errorf("unexpected sector check response message code: %v", msg.Code)
+	}
+
+	var resp storage.SectorStoredResponse
+	if err := msg.Decode(&resp); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		return false, err
+	}
+	if err := resp.Validate(); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		return false, err
+	}
+	if !resp.Stored {
+		return false, nil
+	}
+	if err := verifySectorStored(root, resp, contractRevision.NewFileSize/storage.SectorSize, contractRevision.NewFileMerkleRoot); err != nil {
+		reportInvalidProof(sp, err)
+		return false, err
+	}
+	return true, nil
+}
+
+// reconcileRevision reconciles the revision intents left by the negotiation with the host
+// interrupted before the restart against the latest revision of the host. If the host does
+// not support the contract revision request, the revision is rolled back, as the host rolls
+// back the revision not acknowledged by the client. The contract must be acquired
+func (client *StorageClient) reconcileRevision(sp storage.Peer, contract *contractset.Contract) error {
+	if !contract.PendingRevision() {
+		return nil
+	}
+
+	req := storage.ContractRevisionRequest{
+		StorageContractID: contract.Header().LatestContractRevision.ParentID,
+	}
+	err := sp.RequestContractRevision(req)
+	if err == storage.ErrContractRevisionUnsupported {
+		return contract.RollbackPendingRevision()
+	}
+	if err != nil {
+		return err
+	}
+
+	msg, err := sp.ClientWaitContractResp()
+	if err != nil {
+		return err
+	}
+	switch msg.Code {
+	case storage.HostBusyHandleReqMsg:
+		return storage.ErrHostBusyHandleReq
+	case storage.HostNegotiateErrorMsg:
+		return storage.ErrHostNegotiate
+	case storage.ContractRevisionMsg:
+	default:
+		return fmt.Errorf("unexpected contract revision response message code: %v", msg.Code)
+	}
+
+	var hostRev types.StorageContractRevision
+	if err := msg.Decode(&hostRev); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		return err
+	}
+	if err := contract.ReconcileRevision(hostRev); err != nil {
+		return storageerr.Wrap(storageerr.CodeInvalidRevision, err)
+	}
+	return nil
+}
+
+// verifySectorStored verifies the merkle proof that the sector root is stored in the contract
+// with numSectors sectors and the merkle root
+func verifySectorStored(root common.Hash, resp storage.SectorStoredResponse, numSectors uint64, merkleRoot common.Hash) error {
+	if resp.Index >= numSectors {
+		return fmt.Errorf("stored sector index %v out of %v sectors", resp.Index, numSectors)
+	}
+	proof := merkle.NewSectorRangeProof(resp.Index, resp.Index+1, resp.MerkleProof)
+	if err := proof.VerifyRoots([]common.Hash{root}, merkleRoot); err != nil {
+		return storageerr.New(storageerr.CodeInvalidProof, "host provided invalid merkle proof of the stored sector")
+	}
+	return nil
+}
+
 // Download calls the Read RPC, writing the requested data to w
 // NOTE: The RPC can be cancelled (with a granularity of one section) via the cancel channel.
-func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.DownloadRequest, cancel <-chan struct{}, hostInfo *storage.HostInfo) (err error) {
+// A single section is requested with the single section download request, which is served by
+// all hosts, while multiple sections are requested with the download batch request
+func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.DownloadBatchRequest, cancel <-chan struct{}, hostInfo *storage.HostInfo) (err error) {
+	// get the host config used in negotiation, its hash is carried in the request so that the
+	// host could reject the negotiation if the config has been changed
+	config, configHash, err := client.storageHostManager.HostConfig(*hostInfo)
+	if err != nil {
+		return err
+	}
+
 	// sanity check the request.
-	sector := req.Sector
-	if uint64(sector.Offset)+uint64(sector.Length) > storage.SectorSize {
-		return errors.New("download out boundary of sector")
+	if len(req.Sections) == 0 {
+		return errors.New("no section requested")
 	}
-	if req.MerkleProof {
-		if sector.Offset%merkle.LeafSize != 0 || sector.Length%merkle.LeafSize != 0 {
-			return errors.New("offset and length must be multiples of SegmentSize when requesting a Merkle proof")
+	var totalLength uint64
+	sectorAccesses := make(map[common.Hash]struct{})
+	for _, sec := range req.Sections {
+		if uint64(sec.Offset)+uint64(sec.Length) > storage.SectorSize {
+			return errors.New("download out boundary of sector")
 		}
+		if req.MerkleProof {
+			if sec.Offset%merkle.LeafSize != 0 || sec.Length%merkle.LeafSize != 0 {
+				return errors.New("offset and length must be multiples of SegmentSize when requesting a Merkle proof")
+			}
+		}
+		totalLength += uint64(sec.Length)
+		sectorAccesses[sec.MerkleRoot] = struct{}{}
+	}
+	if len(req.Sections) > 1 && totalLength > config.MaxDownloadBatchSize {
+		return fmt.Errorf("download batch size %v exceeds the host max download batch size %v", totalLength, config.MaxDownloadBatchSize)
 	}
 
 	// calculate estimated bandwidth
-	var totalLength uint64
-	totalLength += uint64(sector.Length)
-
 	var estProofHashes uint64
 	if req.MerkleProof {
 		// use the worst-case proof size of 2*tree depth,
 		// which occurs when proving across the two leaves in the center of the tree
 		estHashesPerProof := 2 * bits.Len64(storage.SectorSize/storage.SegmentSize)
-		estProofHashes = uint64(estHashesPerProof)
+		estProofHashes = uint64(estHashesPerProof * len(req.Sections))
 	}
 	estBandwidth := totalLength + estProofHashes*uint64(storage.HashSize)
 
@@ -563,21 +996,26 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 	contractID := scs.GetContractIDByHostID(hostInfo.EnodeID)
 	contract, exist := scs.Acquire(contractID)
 	if !exist {
-		return fmt.Errorf("not exist this contract: %s", contractID.String())
+		return storageerr.Errorf(storageerr.CodeContractNotFound, "not exist this contract: %s", contractID.String())
 	}
 	defer scs.Return(contract)
 
+	// reconcile the revision of the negotiation interrupted before the restart first
+	if err = client.reconcileRevision(sp, contract); err != nil {
+		return err
+	}
+
 	// old contract header and revision
 	contractHeader := contract.Header()
 	lastRevision := contractHeader.LatestContractRevision
 
 	// calculate price
-	bandwidthPrice := hostInfo.DownloadBandwidthPrice.MultUint64(estBandwidth)
-	sectorAccessPrice := hostInfo.SectorAccessPrice
+	bandwidthPrice := config.DownloadBandwidthPrice.MultUint64(estBandwidth)
+	sectorAccessPrice := config.SectorAccessPrice.MultUint64(uint64(len(sectorAccesses)))
 
-	price := hostInfo.BaseRPCPrice.Add(bandwidthPrice).Add(sectorAccessPrice)
+	price := config.BaseRPCPrice.Add(bandwidthPrice).Add(sectorAccessPrice)
 	if lastRevision.NewValidProofOutputs[0].Value.Cmp(price.BigIntPtr()) < 0 {
-		return errors.New("client funds not enough to support download")
+		return storageerr.New(storageerr.CodeInsufficientFunds, "client funds not enough to support download")
 	}
 
 	// increase the price fluctuation by 0.2% to mitigate small errors, like different block height
@@ -602,6 +1040,7 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 	req.Signature = clientSig[:]
 	req.StorageContractID = newRevision.ParentID
 	req.NewRevisionNumber = newRevision.NewRevisionNumber
+	req.HostConfigHash = configHash
 
 	req.NewValidProofValues = make([]*big.Int, len(newRevision.NewValidProofOutputs))
 	for i, nvpo := range newRevision.NewValidProofOutputs {
@@ -635,8 +1074,31 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 		}
 	}()
 
-	// send download request
-	err = sp.RequestContractDownload(req)
+	// record the revision intent before the signed revision is sent to the host. The revision
+	// will be rolled back if the negotiation failed before the host acknowledged the revision
+	txn, err := contract.PrepareRevision(newRevision)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if errRollback := contract.RollbackRevision(txn); errRollback != nil {
+				client.log.Error("failed to roll back the download revision", "err", errRollback)
+			}
+		} else if errRelease := contract.ReleaseRevision(txn); errRelease != nil {
+			client.log.Error("failed to release the download revision intent", "err", errRelease)
+		}
+	}()
+
+	// send download request, the request sent is kept for the transcript
+	single := len(req.Sections) == 1
+	var sent interface{} = req
+	if single {
+		sent = req.Single()
+		err = sp.RequestContractDownload(req.Single())
+	} else {
+		err = sp.RequestContractDownloadBatch(req)
+	}
 	if err != nil {
 		return err
 	}
@@ -644,7 +1106,7 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 	// read host data responses
 	var hostSig []byte
 
-	var resp storage.DownloadResponse
+	var resp storage.DownloadBatchResponse
 	msg, err := sp.ClientWaitContractResp()
 	if err != nil {
 		return err
@@ -656,37 +1118,39 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 		return storage.ErrHostBusyHandleReq
 	}
 
+	// the host config used is outdated, refresh it before the next negotiation
+	if msg.Code == storage.HostConfigChangedMsg {
+		client.storageHostManager.InvalidateHostConfig(hostInfo.EnodeID)
+		return storage.ErrHostConfigChanged
+	}
+
 	// if host send some negotiation error, client should handler it
 	if msg.Code == storage.HostNegotiateErrorMsg {
 		hostNegotiateErr = storage.ErrHostNegotiate
 		return hostNegotiateErr
 	}
 
-	err = msg.Decode(&resp)
+	if single {
+		var singleResp storage.DownloadResponse
+		err = msg.Decode(&singleResp)
+		resp = singleResp.Batch()
+	} else {
+		err = msg.Decode(&resp)
+	}
 	if err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
 		hostNegotiateErr = err
 		return err
 	}
 
 	// if host sent data, should validate it
 	if len(resp.Data) > 0 {
-		if len(resp.Data) != int(sector.Length) {
-			err = errors.New("host did not send enough sector data")
+		if err = verifyDownloadData(req.Sections, req.MerkleProof, totalLength, resp); err != nil {
+			reportInvalidProof(sp, err)
 			hostNegotiateErr = err
 			return err
 		}
 
-		if req.MerkleProof {
-			proofStart := int(sector.Offset) / merkle.LeafSize
-			proofEnd := int(sector.Offset+sector.Length) / merkle.LeafSize
-			verified, err := merkle.Sha256VerifyRangeProof(resp.Data, resp.MerkleProof, proofStart, proofEnd, sector.MerkleRoot)
-			if !verified || err != nil {
-				err = errors.New("host provided incorrect sector data or Merkle proof")
-				hostNegotiateErr = err
-				return err
-			}
-		}
-
 		if len(resp.Signature) > 0 {
 			hostSig = resp.Signature
 		} else {
@@ -722,22 +1186,21 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 
 	_ = sp.SendClientCommitSuccessMsg()
 
-	// wait for HostAckMsg until timeout
+	// wait for HostAckMsg until timeout. If failed, the revision will be rolled back
 	msg, err = sp.ClientWaitContractResp()
 	if err != nil {
 		log.Error("contract download failed when wait for host ACK msg", "err", err.Error())
 
-		_ = contract.RollbackUndoMem(contractHeader)
 		err = fmt.Errorf("failed to read host ACK message, error: %s", err.Error())
 		return err
 	}
 
 	switch msg.Code {
 	case storage.HostAckMsg:
+		client.appendTranscript(contract, sent, resp.Signature, newRevision.NewRevisionNumber, wallet, account)
 		return
 	default:
 		hostCommitErr = storage.ErrHostCommit
-		_ = contract.RollbackUndoMem(contractHeader)
 
 		_ = sp.SendClientAckMsg()
 		_, _ = sp.ClientWaitContractResp()
@@ -747,22 +1210,46 @@ func (client *StorageClient) Read(sp storage.Peer, w io.Writer, req storage.Down
 
 // Download requests for a single section and returns the requested data. A Merkle proof is always requested.
 func (client *StorageClient) Download(sp storage.Peer, root common.Hash, offset, length uint32, hostInfo *storage.HostInfo) ([]byte, error) {
+	sections := []storage.DownloadRequestSector{{
+		MerkleRoot: root,
+		Offset:     offset,
+		Length:     length,
+	}}
+	data, err := client.DownloadSections(sp, sections, hostInfo)
+	if err != nil {
+		return nil, err
+	}
+	return data[0], nil
+}
+
+// DownloadSections requests for multiple sections within a single download negotiation, which
+// amortizes the revision signing and round trips. The data of each section is returned in
+// the order of the requested sections. A Merkle proof is always requested.
+func (client *StorageClient) DownloadSections(sp storage.Peer, sections []storage.DownloadRequestSector, hostInfo *storage.HostInfo) ([][]byte, error) {
 	client.lock.Lock()
 	defer client.lock.Unlock()
 
-	req := storage.DownloadRequest{
-		Sector: storage.DownloadRequestSector{
-			MerkleRoot: root,
-			Offset:     offset,
-			Length:     length,
-		},
+	req := storage.DownloadBatchRequest{
+		Sections:    sections,
 		MerkleProof: true,
 	}
 	var buf bytes.Buffer
 	err := client.Read(sp, &buf, req, nil, hostInfo)
 	time.Sleep(1 * time.Second)
+	if err != nil {
+		return nil, err
+	}
 
-	return buf.Bytes(), err
+	// split the received data by sections
+	data := buf.Bytes()
+	sectionData := make([][]byte, len(sections))
+	for i, sec := range sections {
+		if uint32(len(data)) < sec.Length {
+			return nil, errors.New("not enough data received for the requested sections")
+		}
+		sectionData[i], data = data[:sec.Length], data[sec.Length:]
+	}
+	return sectionData, nil
 }
 
 // newDownload creates and initializes a download task based on the provided parameters from outer request
@@ -784,6 +1271,7 @@ func (client *StorageClient) newDownload(params downloadParams) (*download, erro
 
 	// instantiate the download object.
 	d := &download{
+		id:                newDownloadID(),
 		completeChan:      make(chan struct{}),
 		startTime:         time.Now(),
 		destination:       params.destination,
@@ -821,12 +1309,15 @@ func (client *StorageClient) newDownload(params downloadParams) (*download, erro
 
 	// map from the host id to the index of the sector within the segment
 	segmentMaps := make([]map[string]downloadSectorInfo, endSegmentIndex-startSegmentIndex+1)
+	cacheKeys := make([]common.Hash, endSegmentIndex-startSegmentIndex+1)
+	cacheable := make([]bool, endSegmentIndex-startSegmentIndex+1)
 	for segmentIndex := startSegmentIndex; segmentIndex <= endSegmentIndex; segmentIndex++ {
 		segmentMaps[segmentIndex-startSegmentIndex] = make(map[string]downloadSectorInfo)
 		sectors, err := params.file.Sectors(uint64(segmentIndex))
 		if err != nil {
 			return nil, err
 		}
+		cacheKeys[segmentIndex-startSegmentIndex], cacheable[segmentIndex-startSegmentIndex] = segmentCacheKey(sectors)
 		for sectorIndex, sectorSet := range sectors {
 			for _, sector := range sectorSet {
 
@@ -843,6 +1334,9 @@ func (client *StorageClient) newDownload(params downloadParams) (*download, erro
 		}
 	}
 
+	// track the download until it is done, so that it can be cancelled or re-prioritized
+	client.addPendingDownload(d)
+
 	// record where to write every segment
 	writeOffset := int64(0)
 
@@ -865,6 +1359,7 @@ func (client *StorageClient) newDownload(params downloadParams) (*download, erro
 			priority:            params.priority,
 			completedSectors:    make([]bool, params.file.ErasureCode().NumSectors()),
 			physicalSegmentData: make([][]byte, params.file.ErasureCode().NumSectors()),
+			sectorChecksums:     make([]uint64, params.file.ErasureCode().NumSectors()),
 			sectorUsage:         make([]bool, params.file.ErasureCode().NumSectors()),
 			download:            d,
 			clientFile:          params.file,
@@ -890,6 +1385,17 @@ func (client *StorageClient) newDownload(params downloadParams) (*download, erro
 
 		uds.overdrive = uint32(params.overdrive)
 
+		// serve the segment from the segment cache if cached, otherwise cache the segment
+		// once recovered
+		if cacheable[i-startSegmentIndex] {
+			uds.cacheKey, uds.segmentCache = cacheKeys[i-startSegmentIndex], client.segmentCache
+			if data, cached := client.segmentCache.get(uds.cacheKey, uds.segmentSize); cached {
+				// the destination might block the write until the previous segments are written
+				go uds.writeLogicalData(data)
+				continue
+			}
+		}
+
 		// add this segment to the segment heap, and notify the download loop a new task
 		client.addSegmentToDownloadHeap(uds)
 		select {
@@ -906,13 +1412,28 @@ func (client *StorageClient) createDownload(p storage.DownloadParameters) (*down
 	if err != nil {
 		return nil, err
 	}
-	entry, err := client.fileSystem.OpenDxFile(dxPath)
-	if err != nil {
-		return nil, err
-	}
+	// create the snapshot of the file or the version to download
+	var snap *dxfile.Snapshot
+	if p.Version != 0 {
+		version, err := client.fileSystem.OpenDxFileVersion(dxPath, p.Version)
+		if err != nil {
+			return nil, err
+		}
+		if snap, err = version.Snapshot(); err != nil {
+			return nil, fmt.Errorf("cannot create snapshot: %v", err)
+		}
+	} else {
+		entry, err := client.fileSystem.OpenDxFile(dxPath)
+		if err != nil {
+			return nil, err
+		}
+		defer entry.Close()
+		defer entry.SetTimeAccess(time.Now())
 
-	defer entry.Close()
-	defer entry.SetTimeAccess(time.Now())
+		if snap, err = entry.Snapshot(); err != nil {
+			return nil, fmt.Errorf("cannot create snapshot: %v", err)
+		}
+	}
 
 	// validate download parameters.
 	if p.WriteToLocalPath == "" {
@@ -947,10 +1468,6 @@ func (client *StorageClient) createDownload(p storage.DownloadParameters) (*down
 	destinationType = "file"
 
 	// create the download object.
-	snap, err := entry.Snapshot()
-	if err != nil {
-		return nil, fmt.Errorf("cannot create snapshot: %v", err)
-	}
 	d, err := client.newDownload(downloadParams{
 		destination:       dw,
 		destinationType:   destinationType,
@@ -959,7 +1476,7 @@ func (client *StorageClient) createDownload(p storage.DownloadParameters) (*down
 		latencyTarget:     25e3 * time.Millisecond,
 
 		// always download the whole file
-		length:      entry.FileSize(),
+		length:      snap.FileSize(),
 		needsMemory: true,
 
 		// always download from 0
@@ -986,6 +1503,21 @@ func (client *StorageClient) createDownload(p storage.DownloadParameters) (*down
 		return nil
 	})
 
+	// notify the webhooks when the download is done
+	d.onComplete(func(err error) error {
+		data := webhookDownloadData{
+			ID:          d.id,
+			DxPath:      dxPath.Path,
+			Destination: p.WriteToLocalPath,
+			Length:      d.length,
+		}
+		if err != nil {
+			data.Error = err.Error()
+		}
+		client.webhooks.notify(WebhookDownloadComplete, data)
+		return nil
+	})
+
 	return d, nil
 }
 
@@ -1008,10 +1540,11 @@ func (client *StorageClient) DownloadSync(p storage.DownloadParameters) error {
 	fmt.Printf("\n\ndownloading>")
 	go func() {
 		for {
-			time.Sleep(time.Millisecond * 500)
-			fmt.Printf(">")
-			if d.segmentsRemaining == 0 {
-				break
+			select {
+			case <-d.completeChan:
+				return
+			case <-time.After(time.Millisecond * 500):
+				fmt.Printf(">")
 			}
 		}
 	}()
@@ -1116,6 +1649,12 @@ func (client *StorageClient) CheckAndUpdateConnection(peerNode *enode.Node) {
 	client.ethBackend.CheckAndUpdateConnection(peerNode)
 }
 
+// UpdateHostConfig updates the cached config of the storage host with the config update
+// pushed by the host
+func (client *StorageClient) UpdateHostConfig(hostID enode.ID, config storage.HostExtConfig) error {
+	return client.storageHostManager.UpdateHostConfig(hostID, config)
+}
+
 // IsContractSignedWithHost is used to check if the client has signed any contract
 // with the storage host provided by the user
 func (client *StorageClient) IsContractSignedWithHost(hostNode *enode.Node) bool {
@@ -349,6 +349,14 @@ func (b *BackendTest) AccountManager() *accounts.Manager {
 	return &accounts.Manager{}
 }
 
+func (b *BackendTest) RPCGasCap() uint64 {
+	return 0
+}
+
+func (b *BackendTest) RPCEVMTimeout() time.Duration {
+	return 0
+}
+
 func (b *BackendTest) GetCurrentBlockHeight() uint64 {
 	return 0
 }
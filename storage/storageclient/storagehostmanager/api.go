@@ -57,6 +57,15 @@ func (api *PublicStorageHostManagerAPI) StorageHost(id string) storage.HostInfo
 	return info
 }
 
+// HostInfos will return the detailed information with the evaluation detail of a batch of
+// storage hosts in one call. The hosts not found in the storage host pool are skipped
+func (api *PublicStorageHostManagerAPI) HostInfos(ids []enode.ID) ([]StorageHostInfoDetail, error) {
+	if len(ids) > maxHostInfosBatchSize {
+		return nil, fmt.Errorf("the number of hosts requested %v exceeds the limit %v", len(ids), maxHostInfosBatchSize)
+	}
+	return api.shm.StorageHostInfoDetails(ids), nil
+}
+
 // StorageHostRanks will return the storage host rankings based on their evaluations. The
 // higher the evaluation is, the higher order it will be placed
 func (api *PublicStorageHostManagerAPI) StorageHostRanks() (rankings []StorageHostRank) {
@@ -73,6 +82,27 @@ func (api *PublicStorageHostManagerAPI) FilteredHosts() (allFiltered []storage.H
 	return api.shm.filteredTree.All()
 }
 
+// MarketPrice will return the percentile statistics (p25, p50, p75) of the prices among
+// the active storage hosts. The value is cached and refreshed on scans
+func (api *PublicStorageHostManagerAPI) MarketPrice() storage.MarketPriceStats {
+	return api.shm.GetMarketPriceStats()
+}
+
+// StorageHostIPHistory will return the historical IP addresses used by the storage host,
+// from the earliest to the latest
+func (api *PublicStorageHostManagerAPI) StorageHostIPHistory(id enode.ID) ([]storage.HostIPRecord, error) {
+	info, exist := api.shm.storageHostTree.RetrieveHostInfo(id)
+	if !exist {
+		return nil, fmt.Errorf("storage host %v does not exist", id)
+	}
+	return info.IPHistory, nil
+}
+
+// IPChangePenalty will return the ratio of evaluation reduced for each frequent IP change
+func (api *PublicStorageHostManagerAPI) IPChangePenalty() float64 {
+	return api.shm.RetrieveIPChangePenalty()
+}
+
 // PrivateStorageHostManagerAPI defines the object used to call eligible APIs
 // that are used to configure settings
 type PrivateStorageHostManagerAPI struct {
@@ -105,6 +135,18 @@ func (api *PrivateStorageHostManagerAPI) SetFilterMode(fm string, hostInfos []en
 	return
 }
 
+// SetIPChangePenalty will set the ratio of evaluation reduced for each frequent IP change
+// of the storage host. The penalty should be within the range of 0 to 1
+func (api *PrivateStorageHostManagerAPI) SetIPChangePenalty(penalty float64) (resp string, err error) {
+	if err = api.shm.SetIPChangePenalty(penalty); err != nil {
+		err = fmt.Errorf("failed to set the ip change penalty: %s", err.Error())
+		return
+	}
+
+	resp = fmt.Sprintf("the ip change penalty has been successfully set to %v", penalty)
+	return
+}
+
 // PublicHostManagerDebugAPI defines the object used to call eligible APIs
 // that are used to perform testing
 type PublicHostManagerDebugAPI struct {
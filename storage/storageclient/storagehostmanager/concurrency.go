@@ -0,0 +1,41 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"github.com/DxChainNetwork/godx/p2p/enode"
+)
+
+// ConcurrencyLimit returns the max number of simultaneous negotiations and the max bytes in
+// flight with the host, derived from the measured download throughput of the host. The host
+// with more capacity is given more data in flight, so that a slow host does not accumulate
+// the work stuck waiting for it
+func (shm *StorageHostManager) ConcurrencyLimit(id enode.ID) (negotiations int, inFlight uint64) {
+	return calcConcurrencyLimit(shm.DownloadThroughput(id))
+}
+
+// calcConcurrencyLimit returns the concurrency limit of the host with the throughput. The
+// default limit is returned if the throughput is not measured
+func calcConcurrencyLimit(throughput float64) (negotiations int, inFlight uint64) {
+	inFlight = defaultInFlightBytes
+	if throughput > 0 {
+		inFlight = uint64(throughput * concurrencyWindow.Seconds())
+	}
+	if inFlight < minInFlightBytes {
+		inFlight = minInFlightBytes
+	}
+	if inFlight > maxInFlightBytes {
+		inFlight = maxInFlightBytes
+	}
+
+	negotiations = int(inFlight / negotiationBytes)
+	if negotiations < 1 {
+		negotiations = 1
+	}
+	if negotiations > maxNegotiations {
+		negotiations = maxNegotiations
+	}
+	return negotiations, inFlight
+}
@@ -98,6 +98,17 @@ const (
 	maxNumInteractionRecord = 30
 )
 
+// throughput related fields
+const (
+	// throughputSmoothing is the weight of the latest measurement in the exponential moving
+	// average of the download throughput of the host
+	throughputSmoothing = 0.3
+
+	// minThroughputSampleSize is the min size of the download to be measured. The throughput
+	// of the smaller download is dominated by the round trip time, thus not recorded
+	minThroughputSampleSize = 1 << 20
+)
+
 // uptime related fields
 const (
 	// initialAccumulatedUptime is the initial value for hostInfo.AccumulatedUptimeFactor.
@@ -128,6 +139,30 @@ const (
 	uptimeMaxNumScanRecords = 20
 )
 
+// ip change related fields
+const (
+	// defaultIPChangePenalty is the default ratio of the evaluation to be reduced for each
+	// IP change exceeding the ipChangeAllowance within the ipChangeWindow
+	defaultIPChangePenalty = 0.2
+
+	// ipChangeWindow is the time window within which the IP changes of a host are counted
+	ipChangeWindow = 7 * 24 * time.Hour
+
+	// ipChangeAllowance is the number of IP changes allowed within the ipChangeWindow
+	// without any penalty
+	ipChangeAllowance = 1
+
+	// maxNumIPRecords is the maximum number of IP records to be saved in nodeInfo
+	maxNumIPRecords = 20
+)
+
+// maxHostInfosBatchSize is the max number of hosts requested in a single HostInfos call
+const maxHostInfosBatchSize = 1000
+
+// regionNotPreferredFactor is the region score of the host out of the regions preferred
+// by the client
+const regionNotPreferredFactor = 0.5
+
 // host manager remove criteria
 const (
 	// critIntercept is the criteria's intercept with y axis, which is the upRate criteria when
@@ -152,6 +187,13 @@ const (
 	ceilRatio float64 = 0.2
 )
 
+// host config cache related constants
+const (
+	// hostConfigCacheTTL is the time the host config is cached before requested from the
+	// storage host again
+	hostConfigCacheTTL = 10 * time.Minute
+)
+
 var defaultMarketPrice = storage.MarketPrice{
 	ContractPrice: storage.DefaultContractPrice,
 	StoragePrice:  storage.DefaultStoragePrice,
@@ -160,3 +202,25 @@ var defaultMarketPrice = storage.MarketPrice{
 	Deposit:       storage.DefaultDeposit,
 	MaxDeposit:    storage.DefaultMaxDeposit,
 }
+
+// concurrency limit related fields
+const (
+	// concurrencyWindow is the duration of the data in flight to the host at its measured
+	// throughput. The data more than that only waits in the host queue
+	concurrencyWindow = 20 * time.Second
+
+	// minInFlightBytes and maxInFlightBytes bound the max bytes in flight to a host
+	minInFlightBytes = 4 * storage.SectorSize
+	maxInFlightBytes = 64 * storage.SectorSize
+
+	// defaultInFlightBytes is the max bytes in flight to the host whose throughput is not
+	// measured yet
+	defaultInFlightBytes = 8 * storage.SectorSize
+
+	// negotiationBytes is the bytes in flight expected per negotiation with the host, which
+	// derives the max number of simultaneous negotiations from the max bytes in flight
+	negotiationBytes = 4 * storage.SectorSize
+
+	// maxNegotiations is the max number of simultaneous negotiations with a host
+	maxNegotiations = 8
+)
@@ -39,17 +39,20 @@ type (
 		ContractPriceScore    float64 `json:"contract_priceScore"`
 		StorageRemainingScore float64 `json:"storage_remainingScore"`
 		UptimeScore           float64 `json:"uptimeScore"`
+		IPChangeScore         float64 `json:"ipChangeScore"`
+		RegionScore           float64 `json:"regionScore"`
 	}
 
 	// defaultEvaluator is the default host evaluation rules.
 	defaultEvaluator struct {
-		market hostMarket
-		rent   storage.RentPayment
+		market          hostMarket
+		rent            storage.RentPayment
+		ipChangePenalty float64
 	}
 
 	// defaultEvaluationScores contains the default criteria of host evaluation, which contains
-	// six scores: presenceScore, DepositFactor, ContractPriceFactor, StorageRemainingFactor,
-	// InteractionFactor and UptimeFactor.
+	// eight scores: presenceScore, DepositFactor, ContractPriceFactor, StorageRemainingFactor,
+	// InteractionFactor, UptimeFactor, IPChangeFactor and RegionFactor.
 	defaultEvaluationScores struct {
 		presenceScore         float64
 		depositScore          float64
@@ -57,6 +60,8 @@ type (
 		storageRemainingScore float64
 		interactionScore      float64
 		uptimeScore           float64
+		ipChangeScore         float64
+		regionScore           float64
 	}
 )
 
@@ -68,14 +73,15 @@ var (
 )
 
 // newDefaultEvaluator creates a new defaultEvaluator based on give storageHostManager and
-// rentPayment
+// rentPayment. The ip change penalty is read from the storageHostManager
 func newDefaultEvaluator(shm *StorageHostManager, rent storage.RentPayment) *defaultEvaluator {
 	// regulate rent payment
 	regulateRentPayment(&rent)
 
 	return &defaultEvaluator{
-		market: shm,
-		rent:   rent,
+		market:          shm,
+		rent:            rent,
+		ipChangePenalty: shm.ipChangePenalty,
 	}
 }
 
@@ -106,6 +112,8 @@ func (de *defaultEvaluator) EvaluateDetail(info storage.HostInfo) EvaluationDeta
 		ContractPriceScore:    scs.contractPriceScore,
 		StorageRemainingScore: scs.storageRemainingScore,
 		UptimeScore:           scs.uptimeScore,
+		IPChangeScore:         scs.ipChangeScore,
+		RegionScore:           scs.regionScore,
 	}
 }
 
@@ -119,6 +127,8 @@ func (de *defaultEvaluator) calcScores(info storage.HostInfo) *defaultEvaluation
 		storageRemainingScore: storageRemainingScoreCalc(info, r),
 		interactionScore:      interactionScoreCalc(info),
 		uptimeScore:           uptimeScoreCalc(info),
+		ipChangeScore:         ipChangeScoreCalc(info, de.ipChangePenalty),
+		regionScore:           regionScoreCalc(info, r),
 	}
 	return scores
 }
@@ -126,7 +136,8 @@ func (de *defaultEvaluator) calcScores(info storage.HostInfo) *defaultEvaluation
 // calcFinalScore calculate the final store based on the score board
 func (de *defaultEvaluator) calcFinalScore(scores *defaultEvaluationScores) int64 {
 	total := scores.presenceScore * scores.depositScore * scores.contractPriceScore *
-		scores.storageRemainingScore * scores.interactionScore * scores.uptimeScore
+		scores.storageRemainingScore * scores.interactionScore * scores.uptimeScore *
+		scores.ipChangeScore * scores.regionScore
 	total *= scoreDefaultBase
 	if total < minScore {
 		total = minScore
@@ -100,8 +100,8 @@ func TestDefaultEvaluator_calFinalScore(t *testing.T) {
 		scs    defaultEvaluationScores
 		expect int64
 	}{
-		{scs: defaultEvaluationScores{1, 1, 1, 1, 1, 1}, expect: scoreDefaultBase},
-		{scs: defaultEvaluationScores{0, 0, 0, 0, 0, 0}, expect: minScore},
+		{scs: defaultEvaluationScores{1, 1, 1, 1, 1, 1, 1, 1}, expect: scoreDefaultBase},
+		{scs: defaultEvaluationScores{0, 0, 0, 0, 0, 0, 0, 0}, expect: minScore},
 	}
 	for i, test := range tests {
 		de := &defaultEvaluator{}
@@ -0,0 +1,107 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
+)
+
+// hostConfigCache caches the configs retrieved from the storage hosts, so that the config does
+// not need to be requested before every negotiation. The cached config expires after
+// hostConfigCacheTTL, or is invalidated when the host reports the config has been changed
+type hostConfigCache struct {
+	entries map[enode.ID]hostConfigEntry
+	lock    sync.Mutex
+}
+
+// hostConfigEntry is the cached config of a storage host
+type hostConfigEntry struct {
+	config  storage.HostExtConfig
+	hash    common.Hash
+	expires time.Time
+}
+
+// get returns the cached config of the storage host and its hash if not expired
+func (c *hostConfigCache) get(id enode.ID) (storage.HostExtConfig, common.Hash, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, exists := c.entries[id]
+	if !exists {
+		return storage.HostExtConfig{}, common.Hash{}, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, id)
+		return storage.HostExtConfig{}, common.Hash{}, false
+	}
+	return entry.config, entry.hash, true
+}
+
+// set caches the config of the storage host
+func (c *hostConfigCache) set(id enode.ID, config storage.HostExtConfig) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[enode.ID]hostConfigEntry)
+	}
+	c.entries[id] = hostConfigEntry{
+		config:  config,
+		hash:    config.Hash(),
+		expires: time.Now().Add(hostConfigCacheTTL),
+	}
+}
+
+// invalidate removes the cached config of the storage host
+func (c *hostConfigCache) invalidate(id enode.ID) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.entries, id)
+}
+
+// HostConfig returns the config of the storage host and its hash, which is carried in the
+// negotiation requests. The cached config is returned if not expired, otherwise the config
+// is requested from the storage host
+func (shm *StorageHostManager) HostConfig(hi storage.HostInfo) (storage.HostExtConfig, common.Hash, error) {
+	if config, hash, exists := shm.hostConfigs.get(hi.EnodeID); exists {
+		return config, hash, nil
+	}
+
+	config, err := shm.retrieveHostConfig(hi)
+	if err != nil {
+		return storage.HostExtConfig{}, common.Hash{}, err
+	}
+	shm.hostConfigs.set(hi.EnodeID, config)
+	return config, config.Hash(), nil
+}
+
+// InvalidateHostConfig removes the cached config of the storage host, so that the config
+// will be requested from the storage host before the next negotiation
+func (shm *StorageHostManager) InvalidateHostConfig(id enode.ID) {
+	shm.hostConfigs.invalidate(id)
+}
+
+// UpdateHostConfig updates the config of the storage host with the config pushed by the
+// host, so that the negotiations afterwards use the up to date config. The config of the
+// host not known by the storage host manager is ignored
+func (shm *StorageHostManager) UpdateHostConfig(id enode.ID, config storage.HostExtConfig) error {
+	hi, exists := shm.storageHostTree.RetrieveHostInfo(id)
+	if !exists {
+		return storagehosttree.ErrHostNotExists
+	}
+	hi.HostExtConfig = config
+	shm.hostConfigs.set(id, config)
+
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+	return shm.modify(hi)
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestHostConfigCache(t *testing.T) {
+	var cache hostConfigCache
+	id := enode.ID{1}
+	config := storage.HostExtConfig{
+		StoragePrice:     common.NewBigIntUint64(100),
+		RemainingStorage: 1 << 30,
+	}
+
+	if _, _, exists := cache.get(id); exists {
+		t.Fatalf("config should not be cached")
+	}
+	cache.set(id, config)
+	cached, hash, exists := cache.get(id)
+	if !exists {
+		t.Fatalf("config should be cached")
+	}
+	if hash != config.Hash() || cached.StoragePrice.Cmp(config.StoragePrice) != 0 {
+		t.Errorf("cached config not expected. Got %v, Expect %v", cached, config)
+	}
+
+	// the invalidated config should not be returned
+	cache.invalidate(id)
+	if _, _, exists := cache.get(id); exists {
+		t.Errorf("config should be invalidated")
+	}
+
+	// the expired config should not be returned
+	cache.set(id, config)
+	entry := cache.entries[id]
+	entry.expires = time.Now().Add(-time.Second)
+	cache.entries[id] = entry
+	if _, _, exists := cache.get(id); exists {
+		t.Errorf("config should be expired")
+	}
+}
+
+func TestHostConfigHash(t *testing.T) {
+	config := storage.HostExtConfig{
+		StoragePrice:     common.NewBigIntUint64(100),
+		RemainingStorage: 1 << 30,
+	}
+	hash := config.Hash()
+
+	// the remaining storage changes with the data stored, which should not change the hash
+	config.RemainingStorage = 1 << 20
+	if config.Hash() != hash {
+		t.Errorf("hash should not change with the remaining storage")
+	}
+
+	config.StoragePrice = common.NewBigIntUint64(200)
+	if config.Hash() == hash {
+		t.Errorf("hash should change with the storage price")
+	}
+}
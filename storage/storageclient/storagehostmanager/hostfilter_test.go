@@ -12,7 +12,7 @@ import (
 )
 
 func TestStorageHostManager_SetFilterMode(t *testing.T) {
-	shm := New("test")
+	shm := New("test", nil)
 	var allHosts []storage.HostInfo
 
 	for i := 0; i < 10; i++ {
@@ -37,6 +37,16 @@ func (shm *StorageHostManager) GetMarketPrice() storage.MarketPrice {
 	return shm.cachedPrices.getPrices()
 }
 
+// GetMarketPriceStats return the percentile statistics of the active storage host prices.
+// If the initial scan has not finished, the statistics derived from the default market
+// price is returned.
+func (shm *StorageHostManager) GetMarketPriceStats() storage.MarketPriceStats {
+	if !shm.isInitialScanFinished() {
+		return defaultMarketPriceStats()
+	}
+	return shm.cachedPrices.getStats()
+}
+
 // UpdateMarketPriceLoop is a infinite loop to update the market price. The input mutex is locked in
 // the inital status. After the first market price is updated, the lock will be unlocked to allow
 // scan to continue.
@@ -52,8 +62,7 @@ func (shm *StorageHostManager) updateMarketPriceLoop(mutex *sync.Mutex) {
 	// Forever loop to update the prices
 	for {
 		// calculate the prices and update
-		prices := shm.calculateMarketPrice()
-		shm.cachedPrices.updatePrices(prices)
+		shm.refreshMarketPrice()
 		// unlock the mutex for once
 		once.Do(func() { mutex.Unlock() })
 		select {
@@ -66,6 +75,15 @@ func (shm *StorageHostManager) updateMarketPriceLoop(mutex *sync.Mutex) {
 	}
 }
 
+// refreshMarketPrice calculate the market price as well as the market price statistics,
+// and update the values in the cache
+func (shm *StorageHostManager) refreshMarketPrice() {
+	prices := shm.calculateMarketPrice()
+	stats := shm.calculateMarketPriceStats()
+	shm.cachedPrices.updatePrices(prices)
+	shm.cachedPrices.updateStats(stats)
+}
+
 // calculateMarketPrice calculate and return the market price
 func (shm *StorageHostManager) calculateMarketPrice() storage.MarketPrice {
 	// get all host infos
@@ -87,6 +105,47 @@ func (shm *StorageHostManager) calculateMarketPrice() storage.MarketPrice {
 	}
 }
 
+// calculateMarketPriceStats calculate and return the percentile statistics of the prices
+// of the active storage hosts
+func (shm *StorageHostManager) calculateMarketPriceStats() storage.MarketPriceStats {
+	infos := shm.ActiveStorageHosts()
+	// If there is no active hosts, return the statistics of default market price
+	if len(infos) == 0 {
+		return defaultMarketPriceStats()
+	}
+	ptrInfos := hostInfoListToPtrList(infos)
+	return storage.MarketPriceStats{
+		NumHosts:      len(infos),
+		ContractPrice: getPercentilesByField(ptrInfos, fieldContractPrice),
+		StoragePrice:  getPercentilesByField(ptrInfos, fieldStoragePrice),
+		UploadPrice:   getPercentilesByField(ptrInfos, fieldUploadPrice),
+		DownloadPrice: getPercentilesByField(ptrInfos, fieldDownloadPrice),
+		Deposit:       getPercentilesByField(ptrInfos, fieldDeposit),
+	}
+}
+
+// defaultMarketPriceStats return the market price statistics where all percentiles are
+// of the value of default market price
+func defaultMarketPriceStats() storage.MarketPriceStats {
+	return storage.MarketPriceStats{
+		NumHosts:      0,
+		ContractPrice: newFlatPercentiles(defaultMarketPrice.ContractPrice),
+		StoragePrice:  newFlatPercentiles(defaultMarketPrice.StoragePrice),
+		UploadPrice:   newFlatPercentiles(defaultMarketPrice.UploadPrice),
+		DownloadPrice: newFlatPercentiles(defaultMarketPrice.DownloadPrice),
+		Deposit:       newFlatPercentiles(defaultMarketPrice.Deposit),
+	}
+}
+
+// newFlatPercentiles return the PricePercentiles with all percentiles of the same price
+func newFlatPercentiles(price common.BigInt) storage.PricePercentiles {
+	return storage.PricePercentiles{
+		P25: price,
+		P50: price,
+		P75: price,
+	}
+}
+
 // hostInfoListToPtrList change a list of hostInfo to a list of hostInfo pointers
 func hostInfoListToPtrList(infos []storage.HostInfo) []*storage.HostInfo {
 	ptrs := make([]*storage.HostInfo, len(infos))
@@ -104,6 +163,7 @@ func hostInfoListToPtrList(infos []storage.HostInfo) []*storage.HostInfo {
 // and not saved to persistence
 type cachedPrices struct {
 	prices storage.MarketPrice
+	stats  storage.MarketPriceStats
 	lock   sync.RWMutex
 }
 
@@ -123,12 +183,54 @@ func (cp *cachedPrices) getPrices() storage.MarketPrice {
 	return cp.prices
 }
 
+// updateStats update the market price statistics in cachedPrices
+func (cp *cachedPrices) updateStats(stats storage.MarketPriceStats) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	cp.stats = stats
+}
+
+// getStats return the market price statistics stored in cachedPrices
+func (cp *cachedPrices) getStats() storage.MarketPriceStats {
+	cp.lock.RLock()
+	defer cp.lock.RUnlock()
+
+	return cp.stats
+}
+
 // getAveragePriceByField get the average of the field specified by the input field
 func getAveragePriceByField(infos []*storage.HostInfo, field int) common.BigInt {
 	sorter := newInfoPriceSorter(infos, field)
 	return getAverage(sorter)
 }
 
+// getPercentilesByField get the 25th, 50th and 75th percentile of the field specified by the
+// input field. The input infos is assumed to be non-empty.
+func getPercentilesByField(infos []*storage.HostInfo, field int) storage.PricePercentiles {
+	sorter := newInfoPriceSorter(infos, field)
+	sort.Sort(sorter)
+	return storage.PricePercentiles{
+		P25: getPercentile(sorter, 25),
+		P50: getPercentile(sorter, 50),
+		P75: getPercentile(sorter, 75),
+	}
+}
+
+// getPercentile get the price of the percentile from a sorted infoSorter with the nearest
+// rank method. The infoSorter is assumed to be sorted and non-empty.
+func getPercentile(infoSorter *hostInfoPriceSorter, percentile float64) common.BigInt {
+	length := infoSorter.Len()
+	index := int(math.Ceil(percentile/100*float64(length))) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= length {
+		index = length - 1
+	}
+	return infoSorter.getPrice(index)
+}
+
 // hostInfoPriceSorter is the structure for sorting for host infos. The structure implements sort interface
 type hostInfoPriceSorter struct {
 	infos []*storage.HostInfo
@@ -61,6 +61,9 @@ func (t *fakeHostTree) RetrieveHostEval(enodeID enode.ID) (int64, bool) { return
 func (t *fakeHostTree) SelectRandom(needed int, blacklist, addrBlacklist []enode.ID) []storage.HostInfo {
 	return []storage.HostInfo{}
 }
+func (t *fakeHostTree) SelectByStrategy(strategy storagehosttree.Strategy, needed int, blacklist, addrBlacklist []enode.ID) []storage.HostInfo {
+	return []storage.HostInfo{}
+}
 func (t *fakeHostTree) All() []storage.HostInfo { return t.infos }
 
 // newFakeHostTree returns a new fake host tree with the give host infos
@@ -265,6 +268,74 @@ func TestGetAverage(t *testing.T) {
 	}
 }
 
+// TestStorageHostManager_calculateMarketPriceStats test the functionality of
+// StorageHostManager.calculateMarketPriceStats
+func TestStorageHostManager_calculateMarketPriceStats(t *testing.T) {
+	expectedPercentiles := storage.PricePercentiles{
+		P25: common.NewBigInt(1),
+		P50: common.NewBigInt(2),
+		P75: common.NewBigInt(3),
+	}
+	tests := []struct {
+		tree          storagehosttree.StorageHostTree
+		expectedStats storage.MarketPriceStats
+	}{
+		{
+			tree:          newFakeHostTree([]storage.HostInfo{}),
+			expectedStats: defaultMarketPriceStats(),
+		},
+		{
+			tree: newFakeHostTree(makeHostInfos()),
+			expectedStats: storage.MarketPriceStats{
+				NumHosts:      5,
+				ContractPrice: expectedPercentiles,
+				StoragePrice:  expectedPercentiles,
+				UploadPrice:   expectedPercentiles,
+				DownloadPrice: expectedPercentiles,
+				Deposit:       expectedPercentiles,
+			},
+		},
+	}
+	for i, test := range tests {
+		shm := &StorageHostManager{storageHostTree: test.tree}
+		stats := shm.calculateMarketPriceStats()
+		if !reflect.DeepEqual(stats, test.expectedStats) {
+			t.Errorf("Test %d: \n\tGot %+v\n\tExpect %+v", i, stats, test.expectedStats)
+		}
+	}
+}
+
+// TestGetPercentile test the functionality of getPercentile
+func TestGetPercentile(t *testing.T) {
+	tests := []struct {
+		size       int
+		percentile float64
+		expect     int64
+	}{
+		{1, 25, 0},
+		{1, 75, 0},
+		{4, 25, 0},
+		{4, 50, 1},
+		{4, 75, 2},
+		{10, 25, 2},
+		{10, 50, 4},
+		{10, 75, 7},
+		{10, 100, 9},
+	}
+	for i, test := range tests {
+		infos := make([]*storage.HostInfo, test.size)
+		for j := range infos {
+			infos[j] = &storage.HostInfo{
+				HostExtConfig: storage.HostExtConfig{StoragePrice: common.NewBigInt(int64(j))},
+			}
+		}
+		got := getPercentile(newInfoPriceSorter(infos, fieldStoragePrice), test.percentile)
+		if got.Cmp(common.NewBigInt(test.expect)) != 0 {
+			t.Errorf("Test %d: got %v, expect %v", i, got, test.expect)
+		}
+	}
+}
+
 // makeHostInfos return a list of hostInfo. The list have 5 elements and one abnormally high
 // value, one abnormally low value. The expected result of the getAveragePrice is 2.
 // If the value of floorRatio and ceilRatio are to be changed, the values here might need to
@@ -36,6 +36,10 @@ const (
 
 	// InteractionDownload is the interaction code for client's download negotiation
 	InteractionDownload
+
+	// InteractionSpotCheck is the interaction code for client's spot check of the sectors
+	// stored on the host
+	InteractionSpotCheck
 )
 
 var (
@@ -46,6 +50,7 @@ var (
 		InteractionRenewContract:  "renew contract",
 		InteractionUpload:         "upload",
 		InteractionDownload:       "download",
+		InteractionSpotCheck:      "spot check",
 	}
 
 	// interactionNameToTypeDict is the mapping from name string to type
@@ -55,6 +60,7 @@ var (
 		"renew contract":   InteractionRenewContract,
 		"upload":           InteractionUpload,
 		"download":         InteractionDownload,
+		"spot check":       InteractionSpotCheck,
 	}
 
 	// interactonWeight is the mapping from interaction type to weight
@@ -64,6 +70,7 @@ var (
 		InteractionRenewContract:  5,
 		InteractionUpload:         5,
 		InteractionDownload:       10,
+		InteractionSpotCheck:      10,
 	}
 )
 
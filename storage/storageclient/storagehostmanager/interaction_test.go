@@ -23,6 +23,7 @@ func TestInteractionName(t *testing.T) {
 		{InteractionRenewContract, "renew contract"},
 		{InteractionUpload, "upload"},
 		{InteractionDownload, "download"},
+		{InteractionSpotCheck, "spot check"},
 	}
 	for index, test := range tests {
 		name := test.it.String()
@@ -60,6 +61,7 @@ func TestInteractionWeight(t *testing.T) {
 		{InteractionRenewContract, 5},
 		{InteractionUpload, 5},
 		{InteractionDownload, 10},
+		{InteractionSpotCheck, 10},
 	}
 	for _, test := range tests {
 		res := interactionWeight(test.it)
@@ -0,0 +1,86 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// errInvalidIPChangePenalty is the error returned if the ip change penalty is not within [0, 1]
+var errInvalidIPChangePenalty = errors.New("ip change penalty should be within the range of 0 to 1")
+
+// updateIPHistory add the current IP address of the host to the IP history if the
+// IP is changed. If the IP history is larger than maxNumIPRecords, cap the list
+func updateIPHistory(info *storage.HostInfo, now time.Time) {
+	if info.IP == "" {
+		return
+	}
+	if len(info.IPHistory) != 0 && info.IPHistory[len(info.IPHistory)-1].IP == info.IP {
+		return
+	}
+	info.IPHistory = append(info.IPHistory, storage.HostIPRecord{
+		Time: now,
+		IP:   info.IP,
+	})
+	if len(info.IPHistory) > maxNumIPRecords {
+		info.IPHistory = info.IPHistory[len(info.IPHistory)-maxNumIPRecords:]
+	}
+}
+
+// ipChangeCount returns the number of IP changes of the host within the ipChangeWindow.
+// The first IP record of the host is not counted as a change
+func ipChangeCount(info storage.HostInfo, now time.Time) int {
+	var count int
+	for i := 1; i < len(info.IPHistory); i++ {
+		if now.Sub(info.IPHistory[i].Time) < ipChangeWindow {
+			count++
+		}
+	}
+	return count
+}
+
+// ipChangeScoreCalc calculates the score based on the IP changes of the host. Frequent IP
+// changes are a sign of Sybil and abusive hosts. Each change exceeding ipChangeAllowance
+// within the ipChangeWindow reduces the score by the penalty ratio
+func ipChangeScoreCalc(info storage.HostInfo, penalty float64) float64 {
+	excess := ipChangeCount(info, time.Now()) - ipChangeAllowance
+	if excess <= 0 {
+		return 1
+	}
+	return math.Pow(1-penalty, float64(excess))
+}
+
+// SetIPChangePenalty set the penalty ratio applied to the host evaluation for each
+// frequent IP change, and update the evaluation of the hosts
+func (shm *StorageHostManager) SetIPChangePenalty(penalty float64) (err error) {
+	if penalty < 0 || penalty > 1 {
+		return errInvalidIPChangePenalty
+	}
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+
+	shm.ipChangePenalty = penalty
+	// update the host evaluator
+	shm.hostEvaluator = newDefaultEvaluator(shm, shm.rent)
+	if err = shm.evaluateHostTree(shm.storageHostTree); err != nil {
+		return fmt.Errorf("cannot update the host tree: %v", err)
+	}
+	if err = shm.evaluateHostTree(shm.filteredTree); err != nil {
+		return fmt.Errorf("cannot update the filtered host tree: %v", err)
+	}
+	return nil
+}
+
+// RetrieveIPChangePenalty returns the penalty ratio applied for each frequent IP change
+func (shm *StorageHostManager) RetrieveIPChangePenalty() float64 {
+	shm.lock.RLock()
+	defer shm.lock.RUnlock()
+	return shm.ipChangePenalty
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestUpdateIPHistory test the functionality of updateIPHistory
+func TestUpdateIPHistory(t *testing.T) {
+	now := time.Now()
+	info := storage.HostInfo{IP: "104.238.46.146"}
+
+	updateIPHistory(&info, now)
+	updateIPHistory(&info, now)
+	if len(info.IPHistory) != 1 {
+		t.Fatalf("unchanged ip should not be recorded. Got %v records", len(info.IPHistory))
+	}
+
+	info.IP = "104.238.46.147"
+	updateIPHistory(&info, now)
+	if len(info.IPHistory) != 2 || info.IPHistory[1].IP != info.IP {
+		t.Fatalf("changed ip should be recorded. Got %v", info.IPHistory)
+	}
+
+	for i := 0; i != maxNumIPRecords; i++ {
+		info.IP = "10.0.0." + strconv.Itoa(i)
+		updateIPHistory(&info, now)
+	}
+	if len(info.IPHistory) != maxNumIPRecords {
+		t.Errorf("ip history not capped. Got %v, Expect %v", len(info.IPHistory), maxNumIPRecords)
+	}
+	if info.IPHistory[len(info.IPHistory)-1].IP != info.IP {
+		t.Errorf("latest ip not expected. Got %v, Expect %v", info.IPHistory[len(info.IPHistory)-1].IP, info.IP)
+	}
+}
+
+// TestIPChangeScoreCalc test the functionality of ipChangeScoreCalc
+func TestIPChangeScoreCalc(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-2 * ipChangeWindow)
+
+	tests := []struct {
+		recordTimes []time.Time
+		penalty     float64
+		expect      float64
+	}{
+		{nil, 0.5, 1},
+		{[]time.Time{now, now}, 0.5, 1},
+		{[]time.Time{now, now, now}, 0.5, 0.5},
+		{[]time.Time{now, now, now, now}, 0.5, 0.25},
+		{[]time.Time{old, old, old, now}, 0.5, 1},
+		{[]time.Time{now, now, now, now}, 0, 1},
+	}
+	for i, test := range tests {
+		info := storage.HostInfo{}
+		for j, rt := range test.recordTimes {
+			info.IPHistory = append(info.IPHistory, storage.HostIPRecord{
+				Time: rt,
+				IP:   "10.0.0." + strconv.Itoa(j),
+			})
+		}
+		score := ipChangeScoreCalc(info, test.penalty)
+		if score != test.expect {
+			t.Errorf("test %d: score not expected. Got %v, Expect %v", i, score, test.expect)
+		}
+	}
+}
+
+// TestStorageHostManager_SetIPChangePenalty test the validation of SetIPChangePenalty
+func TestStorageHostManager_SetIPChangePenalty(t *testing.T) {
+	shm := New("test", nil)
+	if err := shm.SetIPChangePenalty(1.5); err != errInvalidIPChangePenalty {
+		t.Errorf("expect error %v, got %v", errInvalidIPChangePenalty, err)
+	}
+	if err := shm.SetIPChangePenalty(0.3); err != nil {
+		t.Fatal(err)
+	}
+	if shm.RetrieveIPChangePenalty() != 0.3 {
+		t.Errorf("ip change penalty not expected. Got %v, Expect %v", shm.RetrieveIPChangePenalty(), 0.3)
+	}
+}
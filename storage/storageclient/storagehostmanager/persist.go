@@ -5,6 +5,7 @@
 package storagehostmanager
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/keyring"
 )
 
 // settingsMetadata contains the header and version of the JSON file
@@ -23,18 +25,36 @@ var settingsMetadata = common.Metadata{
 }
 
 // persistence is a data structure defines the what kind of information
-// will be contained in the json file
+// will be contained in the json file. If the keyring is enabled, the storage
+// host information is sealed in SealedHostsInfo instead of StorageHostsInfo
 type persistence struct {
 	StorageHostsInfo []storage.HostInfo
+	SealedHostsInfo  []byte `json:",omitempty"`
 	BlockHeight      uint64
 	IPViolationCheck bool
+	IPChangePenalty  float64
 	FilteredHosts    map[enode.ID]struct{}
 	FilterMode       FilterMode
 }
 
-// saveSettings will save the storage host configurations into the JSON file
+// saveSettings will save the storage host configurations into the JSON file. The settings
+// are not saved until the sealed storage host information is loaded, otherwise the storage
+// hosts persisted would be lost
 func (shm *StorageHostManager) saveSettings() error {
+	if shm.sealedHosts != nil {
+		return nil
+	}
 	persist := shm.persistUpdate()
+	if shm.keyring.Enabled() {
+		data, err := json.Marshal(persist.StorageHostsInfo)
+		if err != nil {
+			return err
+		}
+		if persist.SealedHostsInfo, err = shm.keyring.Seal(data); err != nil {
+			return err
+		}
+		persist.StorageHostsInfo = nil
+	}
 	return common.SaveDxJSON(settingsMetadata, filepath.Join(shm.persistDir, PersistFilename), persist)
 }
 
@@ -45,6 +65,7 @@ func (shm *StorageHostManager) persistUpdate() (persist persistence) {
 		StorageHostsInfo: shm.storageHostTree.All(),
 		BlockHeight:      shm.getBlockHeight(),
 		IPViolationCheck: shm.ipViolationCheck,
+		IPChangePenalty:  shm.ipChangePenalty,
 		FilteredHosts:    shm.filteredHosts,
 		FilterMode:       shm.filterMode,
 	}
@@ -68,7 +89,9 @@ func (shm *StorageHostManager) autoSaveSettings() {
 			shm.lock.Lock()
 			err := shm.saveSettings()
 			shm.lock.Unlock()
-			if err != nil {
+			if err == keyring.ErrLocked {
+				shm.log.Warn("storage host manager settings not saved until the keyring is unlocked")
+			} else if err != nil {
 				shm.log.Error("failed to save storage host manager settings")
 			}
 		}
@@ -85,6 +108,8 @@ func (shm *StorageHostManager) loadSettings() error {
 
 	var persist persistence
 	persist.FilteredHosts = make(map[enode.ID]struct{})
+	// keep the default penalty if the setting is not persisted before
+	persist.IPChangePenalty = shm.ipChangePenalty
 
 	err = common.LoadDxJSON(settingsMetadata, filepath.Join(shm.persistDir, PersistFilename), &persist)
 	if err != nil {
@@ -95,11 +120,66 @@ func (shm *StorageHostManager) loadSettings() error {
 	shm.setBlockHeight(persist.BlockHeight)
 
 	shm.ipViolationCheck = persist.IPViolationCheck
+	shm.ipChangePenalty = persist.IPChangePenalty
 	shm.filteredHosts = persist.FilteredHosts
 	shm.filterMode = persist.FilterMode
+	shm.hostEvaluator = newDefaultEvaluator(shm, shm.rent)
+
+	// the sealed storage host information is loaded once the keyring is unlocked
+	if len(persist.SealedHostsInfo) != 0 {
+		infos, err := shm.openHostsInfo(persist.SealedHostsInfo)
+		if err == keyring.ErrLocked {
+			shm.sealedHosts = persist.SealedHostsInfo
+			shm.log.Warn("storage host information is sealed, loaded once the keyring is unlocked")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		persist.StorageHostsInfo = infos
+	}
+	shm.insertPersistedHosts(persist.StorageHostsInfo)
+	return nil
+}
 
-	// update the storage host tree
-	for _, info := range persist.StorageHostsInfo {
+// LoadSealedHosts loads the sealed storage host information that could not be opened at start
+// since the keyring was locked. It is called once the keyring is unlocked
+func (shm *StorageHostManager) LoadSealedHosts() error {
+	shm.lock.RLock()
+	sealed := shm.sealedHosts
+	shm.lock.RUnlock()
+	if sealed == nil {
+		return nil
+	}
+
+	infos, err := shm.openHostsInfo(sealed)
+	if err != nil {
+		return err
+	}
+	shm.insertPersistedHosts(infos)
+
+	shm.lock.Lock()
+	shm.sealedHosts = nil
+	shm.lock.Unlock()
+	return nil
+}
+
+// openHostsInfo opens the storage host information sealed by the keyring
+func (shm *StorageHostManager) openHostsInfo(sealed []byte) ([]storage.HostInfo, error) {
+	data, err := shm.keyring.Open(sealed)
+	if err != nil {
+		return nil, err
+	}
+	var infos []storage.HostInfo
+	if err := json.Unmarshal(data, &infos); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// insertPersistedHosts inserts the storage host information persisted into the storage host tree
+func (shm *StorageHostManager) insertPersistedHosts(infos []storage.HostInfo) {
+	for _, info := range infos {
 
 		// insert the storage host
 		err := shm.insert(info)
@@ -113,6 +193,4 @@ func (shm *StorageHostManager) loadSettings() error {
 			shm.startScanning(info)
 		}
 	}
-
-	return nil
 }
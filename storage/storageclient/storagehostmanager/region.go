@@ -0,0 +1,23 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// regionScoreCalc calculates the score based on the region tag advertised by the host and
+// the placement preferences of the client. The host out of the required regions scores 0,
+// which gives the host the minimum evaluation. The host out of the preferred regions is
+// evaluated lower by regionNotPreferredFactor
+func regionScoreCalc(info storage.HostInfo, rent storage.RentPayment) float64 {
+	if len(rent.RequireRegions) != 0 && !storage.RegionMatch(rent.RequireRegions, info.Region) {
+		return 0
+	}
+	if len(rent.PreferRegions) != 0 && !storage.RegionMatch(rent.PreferRegions, info.Region) {
+		return regionNotPreferredFactor
+	}
+	return 1
+}
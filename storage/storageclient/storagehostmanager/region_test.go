@@ -0,0 +1,38 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestRegionScoreCalc test the functionality of regionScoreCalc
+func TestRegionScoreCalc(t *testing.T) {
+	tests := []struct {
+		region  string
+		prefer  []string
+		require []string
+		expect  float64
+	}{
+		{"", nil, nil, 1},
+		{"us-east", nil, nil, 1},
+		{"us-east", []string{"us-east"}, nil, 1},
+		{"eu-west", []string{"us-east"}, nil, regionNotPreferredFactor},
+		{"", []string{"us-east"}, nil, regionNotPreferredFactor},
+		{"eu-west", nil, []string{"us-east", "eu-west"}, 1},
+		{"ap-south", nil, []string{"us-east", "eu-west"}, 0},
+		{"", nil, []string{"us-east"}, 0},
+		{"eu-west", []string{"us-east"}, []string{"us-east", "eu-west"}, regionNotPreferredFactor},
+	}
+	for i, test := range tests {
+		info := storage.HostInfo{HostExtConfig: storage.HostExtConfig{Region: test.region}}
+		rent := storage.RentPayment{PreferRegions: test.prefer, RequireRegions: test.require}
+		if score := regionScoreCalc(info, rent); score != test.expect {
+			t.Errorf("test %d: score not expected. Got %v, Expect %v", i, score, test.expect)
+		}
+	}
+}
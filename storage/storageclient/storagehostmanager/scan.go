@@ -214,7 +214,13 @@ func (shm *StorageHostManager) scanExecute(scanWorker <-chan storage.HostInfo) {
 	}
 	shm.lock.Lock()
 	shm.scanningWorkers--
+	finished := shm.scanningWorkers == 0 && len(shm.scanWaitList) == 0
 	shm.lock.Unlock()
+
+	// refresh the cached market price once the host configs are updated by the scan
+	if finished {
+		shm.refreshMarketPrice()
+	}
 }
 
 // scanAndUpdateHostConfig will connect to the host, grabbing the settings,
@@ -241,6 +247,7 @@ func (shm *StorageHostManager) scanAndUpdateHostConfig(hi storage.HostInfo) {
 		shm.log.Warn("failed to get storage host external setting", "hostID", hi.EnodeID, "err", err.Error())
 	} else {
 		hi.HostExtConfig = hostConfig
+		shm.hostConfigs.set(hi.EnodeID, hostConfig)
 	}
 
 	shm.lock.Lock()
@@ -256,9 +263,10 @@ func (shm *StorageHostManager) scanAndUpdateHostConfig(hi storage.HostInfo) {
 }
 
 // retrieveHostSetting will establish connection to the corresponded storage host
-// and get its configurations
+// and get its configurations. The config cached is refreshed with the fields changed
+// only if the storage host still has the config cached
 func (shm *StorageHostManager) retrieveHostConfig(hi storage.HostInfo) (storage.HostExtConfig, error) {
-	var config storage.HostExtConfig
+	config := hi.HostExtConfig
 
 	// send message, and get host setting
 	err := shm.b.GetStorageHostSetting(hi.EnodeID, hi.EnodeURL, &config)
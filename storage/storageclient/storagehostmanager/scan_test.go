@@ -309,6 +309,10 @@ func (st *storageClientBackendTestData) GetPaymentAddress() (common.Address, err
 	return common.Address{}, nil
 }
 
+func (st *storageClientBackendTestData) GetBalance(address common.Address) (common.BigInt, error) {
+	return common.BigInt0, nil
+}
+
 func (st *storageClientBackendTestData) RevisionOrRenewingDone(hostID enode.ID) {}
 
 func (st *storageClientBackendTestData) CheckAndUpdateConnection(peerNode *enode.Node) {}
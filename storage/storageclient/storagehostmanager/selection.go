@@ -0,0 +1,87 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"errors"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
+)
+
+// SelectedHost is the storage host selected by a host selection strategy
+type SelectedHost struct {
+	EnodeID    enode.ID `json:"enodeID"`
+	IP         string   `json:"ip"`
+	Region     string   `json:"region"`
+	Evaluation int64    `json:"evaluation"`
+}
+
+// RetrieveHosts will select storage hosts from the storage host pool with the host selection
+// strategy specified in the rent payment
+//  1. blacklist represents the storage host that are prohibited to be selected
+//  2. addrBlacklist represents for any storage host whose network address is contained
+func (shm *StorageHostManager) RetrieveHosts(num int, blacklist, addrBlacklist []enode.ID) (infos []storage.HostInfo, err error) {
+	shm.lock.RLock()
+	ipCheck := shm.ipViolationCheck
+	strategyName := shm.rent.HostSelection
+	shm.lock.RUnlock()
+
+	// if the initialize scan is not complete
+	if !shm.isInitialScanFinished() {
+		err = errors.New("storage host pool initial scan is not finished")
+		return
+	}
+
+	strategy, err := storagehosttree.StrategyByName(strategyName)
+	if err != nil {
+		return
+	}
+	if !ipCheck {
+		addrBlacklist = nil
+	}
+	infos = shm.filteredTree.SelectByStrategy(strategy, num, blacklist, addrBlacklist)
+	return
+}
+
+// PreviewHostSelection returns the storage hosts that would be selected under each host selection
+// strategy for the rent payment, without forming any contract. The hosts are evaluated with the
+// rent payment given, and the number of hosts selected is the storage hosts of the rent payment
+func (shm *StorageHostManager) PreviewHostSelection(rent storage.RentPayment) map[string][]SelectedHost {
+	if rent.StorageHosts == 0 {
+		rent.StorageHosts = storage.DefaultRentPayment.StorageHosts
+	}
+	shm.lock.RLock()
+	evaluator := newDefaultEvaluator(shm, rent)
+	shm.lock.RUnlock()
+
+	var candidates []storagehosttree.Candidate
+	for _, hi := range shm.filteredTree.All() {
+		if storagehosttree.Eligible(hi) {
+			candidates = append(candidates, storagehosttree.Candidate{HostInfo: hi, Eval: evaluator.Evaluate(hi)})
+		}
+	}
+	evals := make(map[enode.ID]int64)
+	for _, c := range candidates {
+		evals[c.EnodeID] = c.Eval
+	}
+
+	preview := make(map[string][]SelectedHost)
+	for _, name := range storagehosttree.StrategyNames() {
+		strategy, _ := storagehosttree.StrategyByName(name)
+		selected := make([]SelectedHost, 0, rent.StorageHosts)
+		for _, hi := range strategy.Select(candidates, int(rent.StorageHosts), storagehosttree.NewFilter()) {
+			selected = append(selected, SelectedHost{
+				EnodeID:    hi.EnodeID,
+				IP:         hi.IP,
+				Region:     hi.Region,
+				Evaluation: evals[hi.EnodeID],
+			})
+		}
+		preview[name] = selected
+	}
+	return preview
+}
@@ -0,0 +1,248 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
+)
+
+// SimulationArgs is the arguments of the evaluation simulation. The scores of the evaluation
+// factors are raised to the power of the weights, keyed by the factor names returned by
+// EvaluationFactors. The factors not weighted have the weight 1, which is the default
+// evaluation, and the factors weighted 0 are ignored
+type SimulationArgs struct {
+	Weights map[string]float64 `json:"weights"`
+
+	// Cutoff is the time the hosts are selected at. The scans and interactions before the
+	// cutoff are replayed to evaluate the hosts, and the ones after are the outcomes
+	Cutoff time.Time `json:"cutoff"`
+
+	// Hosts is the number of hosts selected, default to the storage hosts of the rent payment
+	Hosts int `json:"hosts"`
+}
+
+// SimulatedHost is the storage host selected in the simulation, along with its scans and
+// interactions after the cutoff
+type SimulatedHost struct {
+	EnodeID    enode.ID `json:"enodeID"`
+	IP         string   `json:"ip"`
+	Evaluation int64    `json:"evaluation"`
+
+	Scans              int `json:"scans"`
+	SuccessfulScans    int `json:"successfulScans"`
+	Interactions       int `json:"interactions"`
+	FailedInteractions int `json:"failedInteractions"`
+}
+
+// SimulationOutcome is the hosts selected under an evaluation weighting and the outcome of
+// the selection. Uptime is the ratio of the successful scans of the selected hosts after the
+// cutoff, which is zero if there is no scan
+type SimulationOutcome struct {
+	Selected           []SimulatedHost `json:"selected"`
+	Uptime             float64         `json:"uptime"`
+	Scans              int             `json:"scans"`
+	Interactions       int             `json:"interactions"`
+	FailedInteractions int             `json:"failedInteractions"`
+}
+
+// SimulationResult is the result of the evaluation simulation. Baseline is the outcome of the
+// default evaluation, and Simulated is the outcome of the weighted evaluation
+type SimulationResult struct {
+	Cutoff     time.Time         `json:"cutoff"`
+	Candidates int               `json:"candidates"`
+	Baseline   SimulationOutcome `json:"baseline"`
+	Simulated  SimulationOutcome `json:"simulated"`
+}
+
+// EvaluationFactors returns the names of the evaluation factors which could be weighted in
+// the evaluation simulation
+func EvaluationFactors() []string {
+	return []string{"presence", "deposit", "interaction", "contractPrice", "storageRemaining", "uptime", "ipChange", "region"}
+}
+
+// SimulateEvaluation replays the scans and interactions of the storage hosts recorded before
+// the cutoff, and selects the hosts with the highest evaluations under both the default and
+// the weighted evaluation. The outcomes are the scans and interactions of the selected hosts
+// after the cutoff, with which the weightings are compared. Only the scans and interactions
+// kept in the host info are replayed, and the host config is the latest one known
+func (shm *StorageHostManager) SimulateEvaluation(args SimulationArgs) (SimulationResult, error) {
+	if err := checkEvaluationWeights(args.Weights); err != nil {
+		return SimulationResult{}, err
+	}
+	if args.Cutoff.IsZero() || !args.Cutoff.Before(time.Now()) {
+		return SimulationResult{}, errors.New("the cutoff of the simulation must be in the past")
+	}
+	if args.Hosts < 0 {
+		return SimulationResult{}, errors.New("the number of hosts selected must not be negative")
+	}
+
+	shm.lock.RLock()
+	rent := shm.rent
+	evaluator := newDefaultEvaluator(shm, rent)
+	shm.lock.RUnlock()
+	if args.Hosts == 0 {
+		args.Hosts = int(rent.StorageHosts)
+	}
+	if args.Hosts == 0 {
+		args.Hosts = int(storage.DefaultRentPayment.StorageHosts)
+	}
+
+	var baseline, simulated []storagehosttree.Candidate
+	outcomes := make(map[enode.ID]SimulatedHost)
+	for _, hi := range shm.filteredTree.All() {
+		historic, active := historicHostInfo(hi, args.Cutoff)
+		if !active {
+			continue
+		}
+		detail := evaluator.EvaluateDetail(historic)
+		baseline = append(baseline, storagehosttree.Candidate{HostInfo: hi, Eval: detail.Evaluation})
+		simulated = append(simulated, storagehosttree.Candidate{HostInfo: hi, Eval: weightedEvaluation(detail, args.Weights)})
+		outcomes[hi.EnodeID] = hostOutcome(hi, args.Cutoff)
+	}
+
+	return SimulationResult{
+		Cutoff:     args.Cutoff,
+		Candidates: len(baseline),
+		Baseline:   simulateSelection(baseline, args.Hosts, outcomes),
+		Simulated:  simulateSelection(simulated, args.Hosts, outcomes),
+	}, nil
+}
+
+// checkEvaluationWeights checks the weights are of the evaluation factors, and are non-negative
+func checkEvaluationWeights(weights map[string]float64) error {
+	factors := make(map[string]struct{})
+	for _, name := range EvaluationFactors() {
+		factors[name] = struct{}{}
+	}
+	for name, weight := range weights {
+		if _, exist := factors[name]; !exist {
+			return fmt.Errorf("unknown evaluation factor %v, available factors: %v", name, EvaluationFactors())
+		}
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("invalid weight %v of the evaluation factor %v", weight, name)
+		}
+	}
+	return nil
+}
+
+// weightedEvaluation calculates the final score of the evaluation detail with the scores
+// raised to the power of the weights
+func weightedEvaluation(detail EvaluationDetail, weights map[string]float64) int64 {
+	scores := map[string]float64{
+		"presence":         detail.PresenceScore,
+		"deposit":          detail.DepositScore,
+		"interaction":      detail.InteractionScore,
+		"contractPrice":    detail.ContractPriceScore,
+		"storageRemaining": detail.StorageRemainingScore,
+		"uptime":           detail.UptimeScore,
+		"ipChange":         detail.IPChangeScore,
+		"region":           detail.RegionScore,
+	}
+	total := float64(scoreDefaultBase)
+	for name, score := range scores {
+		weight, exist := weights[name]
+		if !exist {
+			weight = 1
+		}
+		total *= math.Pow(score, weight)
+	}
+	if total < minScore {
+		total = minScore
+	}
+	return int64(total)
+}
+
+// historicHostInfo returns the host info as it was at the cutoff, with the uptime and the
+// interaction factors calculated from the scans and interactions recorded before the cutoff.
+// The host is regarded as active at the cutoff if the last scan before the cutoff succeeded
+func historicHostInfo(info storage.HostInfo, cutoff time.Time) (storage.HostInfo, bool) {
+	scans, interactions := info.ScanRecords, info.InteractionRecords
+	info.AccumulatedUptime, info.AccumulatedDowntime, info.LastCheckTime, info.ScanRecords = 0, 0, 0, nil
+	info.SuccessfulInteractionFactor, info.FailedInteractionFactor, info.LastInteractionTime, info.InteractionRecords = 0, 0, 0, nil
+
+	var active bool
+	for _, scan := range scans {
+		if !scan.Timestamp.Before(cutoff) {
+			break
+		}
+		now := uint64(scan.Timestamp.Unix())
+		if info.LastCheckTime == 0 {
+			info.AccumulatedUptime, info.AccumulatedDowntime = initialAccumulatedUptime, initialAccumulatedDowntime
+			info.LastCheckTime = now
+		}
+		info = calcUptimeUpdate(info, scan.Success, now)
+		active = scan.Success
+	}
+
+	for _, record := range interactions {
+		if !record.Time.Before(cutoff) {
+			break
+		}
+		now := uint64(record.Time.Unix())
+		if info.LastInteractionTime == 0 {
+			info.SuccessfulInteractionFactor, info.FailedInteractionFactor = initialSuccessfulInteractionFactor, initialFailedInteractionFactor
+			info.LastInteractionTime = now
+		}
+		info = calcInteractionUpdate(info, InteractionNameToType(record.InteractionType), record.Success, now)
+	}
+	return info, active
+}
+
+// hostOutcome returns the scans and interactions of the host recorded after the cutoff
+func hostOutcome(info storage.HostInfo, cutoff time.Time) SimulatedHost {
+	outcome := SimulatedHost{EnodeID: info.EnodeID, IP: info.IP}
+	for _, scan := range info.ScanRecords {
+		if scan.Timestamp.Before(cutoff) {
+			continue
+		}
+		outcome.Scans++
+		if scan.Success {
+			outcome.SuccessfulScans++
+		}
+	}
+	for _, record := range info.InteractionRecords {
+		if record.Time.Before(cutoff) {
+			continue
+		}
+		outcome.Interactions++
+		if !record.Success {
+			outcome.FailedInteractions++
+		}
+	}
+	return outcome
+}
+
+// simulateSelection selects the candidates with the highest evaluations, and sums up the
+// outcomes of the hosts selected
+func simulateSelection(candidates []storagehosttree.Candidate, needed int, outcomes map[enode.ID]SimulatedHost) SimulationOutcome {
+	evals := make(map[enode.ID]int64)
+	for _, c := range candidates {
+		evals[c.EnodeID] = c.Eval
+	}
+	strategy, _ := storagehosttree.StrategyByName(storagehosttree.StrategyTopK)
+
+	result := SimulationOutcome{Selected: make([]SimulatedHost, 0, needed)}
+	var successfulScans int
+	for _, hi := range strategy.Select(candidates, needed, storagehosttree.NewFilter()) {
+		host := outcomes[hi.EnodeID]
+		host.Evaluation = evals[hi.EnodeID]
+		result.Selected = append(result.Selected, host)
+		result.Scans += host.Scans
+		result.Interactions += host.Interactions
+		result.FailedInteractions += host.FailedInteractions
+		successfulScans += host.SuccessfulScans
+	}
+	if result.Scans > 0 {
+		result.Uptime = float64(successfulScans) / float64(result.Scans)
+	}
+	return result
+}
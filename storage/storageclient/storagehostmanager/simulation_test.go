@@ -0,0 +1,141 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestWeightedEvaluation test the evaluation with all factors weighted 1 is the default
+// evaluation, and the factors weighted 0 are ignored
+func TestWeightedEvaluation(t *testing.T) {
+	shm := newHostManagerTestData()
+	detail := shm.hostEvaluator.EvaluateDetail(activeHostInfoGenerator())
+
+	if eval := weightedEvaluation(detail, nil); eval < detail.Evaluation-1 || eval > detail.Evaluation+1 {
+		t.Errorf("expect evaluation %v without weights, got %v", detail.Evaluation, eval)
+	}
+	ignored := make(map[string]float64)
+	for _, name := range EvaluationFactors() {
+		ignored[name] = 0
+	}
+	if eval := weightedEvaluation(detail, ignored); eval != scoreDefaultBase {
+		t.Errorf("expect evaluation %v with all factors ignored, got %v", scoreDefaultBase, eval)
+	}
+	if err := checkEvaluationWeights(map[string]float64{"unknown": 1}); err == nil {
+		t.Error("unknown evaluation factor is weighted")
+	}
+	if err := checkEvaluationWeights(map[string]float64{"uptime": -1}); err == nil {
+		t.Error("negative weight is accepted")
+	}
+}
+
+// TestHistoricHostInfo test the host info at the cutoff is replayed from the scans and
+// interactions before the cutoff
+func TestHistoricHostInfo(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	info := activeHostInfoGenerator()
+	info.ScanRecords = storage.HostPoolScans{
+		{Timestamp: start, Success: true},
+		{Timestamp: start.Add(10 * time.Minute), Success: false},
+		{Timestamp: start.Add(20 * time.Minute), Success: true},
+	}
+	info.InteractionRecords = []storage.HostInteractionRecord{
+		{Time: start, InteractionType: InteractionUpload.String(), Success: false},
+		{Time: start.Add(20 * time.Minute), InteractionType: InteractionUpload.String(), Success: true},
+	}
+
+	historic, active := historicHostInfo(info, start.Add(5*time.Minute))
+	if !active {
+		t.Fatal("host with the last scan before the cutoff succeeded is not active")
+	}
+	if len(historic.ScanRecords) != 1 || len(historic.InteractionRecords) != 1 {
+		t.Fatalf("expect 1 scan and 1 interaction replayed, got %v and %v", len(historic.ScanRecords), len(historic.InteractionRecords))
+	}
+	if historic.FailedInteractionFactor == 0 {
+		t.Error("failed interaction before the cutoff is not replayed")
+	}
+	if _, active = historicHostInfo(info, start.Add(15*time.Minute)); active {
+		t.Error("host with the last scan before the cutoff failed is active")
+	}
+	if _, active = historicHostInfo(info, start); active {
+		t.Error("host without scan before the cutoff is active")
+	}
+
+	outcome := hostOutcome(info, start.Add(5*time.Minute))
+	if outcome.Scans != 2 || outcome.SuccessfulScans != 1 || outcome.Interactions != 1 || outcome.FailedInteractions != 0 {
+		t.Errorf("unexpected outcome after the cutoff: %+v", outcome)
+	}
+}
+
+// TestSimulateEvaluation test the hosts failed the interactions before the cutoff are not
+// selected by the default evaluation, while they are selected once the interaction factor is
+// ignored
+func TestSimulateEvaluation(t *testing.T) {
+	shm := newHostManagerTestData()
+	start := time.Now().Add(-time.Hour)
+	cutoff := start.Add(30 * time.Minute)
+
+	good, failed := activeHostInfoGenerator(), activeHostInfoGenerator()
+	failed.HostExtConfig = good.HostExtConfig
+	failed.FirstSeen = good.FirstSeen
+	good.IP, failed.IP = "104.0.1.1", "104.0.2.1"
+	for _, info := range []*storage.HostInfo{&good, &failed} {
+		info.ScanRecords = storage.HostPoolScans{
+			{Timestamp: start, Success: true},
+			{Timestamp: cutoff.Add(time.Minute), Success: true},
+		}
+	}
+	for i := 0; i < 5; i++ {
+		failed.InteractionRecords = append(failed.InteractionRecords, storage.HostInteractionRecord{
+			Time:            start.Add(time.Duration(i) * time.Minute),
+			InteractionType: InteractionDownload.String(),
+			Success:         false,
+		})
+	}
+	failed.InteractionRecords = append(failed.InteractionRecords, storage.HostInteractionRecord{
+		Time:            cutoff.Add(time.Minute),
+		InteractionType: InteractionDownload.String(),
+		Success:         true,
+	})
+	for _, info := range []storage.HostInfo{good, failed} {
+		if err := shm.insert(info); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := shm.SimulateEvaluation(SimulationArgs{Cutoff: cutoff, Hosts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Candidates != 2 {
+		t.Fatalf("expect 2 candidates, got %v", result.Candidates)
+	}
+	if len(result.Baseline.Selected) != 1 || result.Baseline.Selected[0].EnodeID != good.EnodeID {
+		t.Fatalf("expect host %v selected by the default evaluation, got %+v", good.EnodeID, result.Baseline.Selected)
+	}
+	if result.Baseline.Scans != 1 || result.Baseline.Uptime != 1 {
+		t.Errorf("unexpected baseline outcome: %+v", result.Baseline)
+	}
+
+	// the simulated evaluations of both hosts are the same once the interaction is ignored
+	result, err = shm.SimulateEvaluation(SimulationArgs{Weights: map[string]float64{"interaction": 0}, Cutoff: cutoff, Hosts: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Simulated.Selected) != 2 || result.Simulated.Selected[0].Evaluation != result.Simulated.Selected[1].Evaluation {
+		t.Fatalf("expect both hosts selected with the same evaluation, got %+v", result.Simulated.Selected)
+	}
+	if result.Simulated.Interactions != 1 || result.Simulated.FailedInteractions != 0 {
+		t.Errorf("unexpected simulated outcome: %+v", result.Simulated)
+	}
+
+	if _, err = shm.SimulateEvaluation(SimulationArgs{Cutoff: time.Now().Add(time.Hour)}); err == nil {
+		t.Error("simulation with the cutoff in the future is accepted")
+	}
+}
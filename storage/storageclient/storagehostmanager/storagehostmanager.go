@@ -18,6 +18,7 @@ import (
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/keyring"
 	"github.com/DxChainNetwork/godx/storage/storageclient/storagehosttree"
 )
 
@@ -35,6 +36,9 @@ type StorageHostManager struct {
 	// ip violation check
 	ipViolationCheck bool
 
+	// ipChangePenalty is the ratio of evaluation reduced for each frequent IP change
+	ipChangePenalty float64
+
 	// maintenance related
 	// initialScanFinished is atomic value to denote the status whether the initial scan has been
 	// finished. Initialized to value 0, and changed value to 1 when initial scan is finished.
@@ -47,6 +51,11 @@ type StorageHostManager struct {
 	// persistent directory
 	persistDir string
 
+	// keyring seals the storage host information persisted. sealedHosts is the sealed
+	// storage host information not loaded yet since the keyring is locked
+	keyring     *keyring.Keyring
+	sealedHosts []byte
+
 	// utils
 	log  log.Logger
 	lock sync.RWMutex
@@ -63,17 +72,23 @@ type StorageHostManager struct {
 
 	// host market pricing cache
 	cachedPrices cachedPrices
+
+	// host config cache used in negotiation
+	hostConfigs hostConfigCache
 }
 
-// New will initialize HostPoolManager, making the host pool stay updated
-func New(persistDir string) *StorageHostManager {
+// New will initialize HostPoolManager, making the host pool stay updated. The storage host
+// information persisted is sealed by the keyring
+func New(persistDir string, kr *keyring.Keyring) *StorageHostManager {
 	// initialization
 	shm := &StorageHostManager{
-		persistDir:    persistDir,
-		rent:          storage.DefaultRentPayment,
-		scanLookup:    make(map[enode.ID]struct{}),
-		filterMode:    DisableFilter,
-		filteredHosts: make(map[enode.ID]struct{}),
+		persistDir:      persistDir,
+		keyring:         kr,
+		rent:            storage.DefaultRentPayment,
+		ipChangePenalty: defaultIPChangePenalty,
+		scanLookup:      make(map[enode.ID]struct{}),
+		filterMode:      DisableFilter,
+		filteredHosts:   make(map[enode.ID]struct{}),
 	}
 
 	shm.hostEvaluator = newDefaultEvaluator(shm, shm.rent)
@@ -334,6 +349,25 @@ func (shm *StorageHostManager) StorageHostRanks() (rankings []StorageHostRank) {
 	return
 }
 
+// StorageHostInfoDetails will return the storage host information with the evaluation detail
+// of the hosts requested. The hosts not found in the storage host pool are skipped
+func (shm *StorageHostManager) StorageHostInfoDetails(ids []enode.ID) (details []StorageHostInfoDetail) {
+	shm.lock.RLock()
+	defer shm.lock.RUnlock()
+
+	for _, id := range ids {
+		info, exist := shm.storageHostTree.RetrieveHostInfo(id)
+		if !exist {
+			continue
+		}
+		details = append(details, StorageHostInfoDetail{
+			HostInfo:         info,
+			EvaluationDetail: shm.hostEvaluator.EvaluateDetail(info),
+		})
+	}
+	return
+}
+
 // insert will insert host information into the storageHostTree
 func (shm *StorageHostManager) insert(hi storage.HostInfo) error {
 	// evaluate the host info
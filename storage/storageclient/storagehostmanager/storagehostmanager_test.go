@@ -16,7 +16,7 @@ import (
 	"github.com/Pallinder/go-randomdata"
 )
 
-var shmtest1 = New("test")
+var shmtest1 = New("test", nil)
 var hostInfo = hostInfoGenerator()
 
 func TestStorageHostManager_Insert(t *testing.T) {
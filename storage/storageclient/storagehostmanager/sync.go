@@ -107,6 +107,7 @@ func (shm *StorageHostManager) insertStorageHostInformation(info storage.HostInf
 		// Initiate the uptime and interaction related fields
 		uptimeInitiate(&info)
 		interactionInitiate(&info)
+		updateIPHistory(&info, time.Now())
 
 		if err := shm.insert(info); err != nil {
 			shm.log.Error("unable to insert the storage host information", "err", err.Error())
@@ -132,6 +133,9 @@ func (shm *StorageHostManager) insertStorageHostInformation(info storage.HostInf
 		oldInfo.LastIPNetWorkChange = time.Now()
 	}
 
+	// record the IP address if it is changed
+	updateIPHistory(&oldInfo, time.Now())
+
 	// modify the old storage host information
 	if err := shm.modify(oldInfo); err != nil {
 		shm.log.Error("failed to modify the old storage host information", "err", err.Error())
@@ -0,0 +1,59 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+)
+
+// RecordDownloadThroughput records the throughput measured by downloading size bytes from
+// the host in the elapsed time. The throughput is smoothed with the previous measurements
+func (shm *StorageHostManager) RecordDownloadThroughput(id enode.ID, size uint64, elapsed time.Duration) {
+	if size < minThroughputSampleSize || elapsed <= 0 {
+		return
+	}
+	if err := shm.updateDownloadThroughput(id, float64(size)/elapsed.Seconds()); err != nil {
+		shm.log.Warn("Record download throughput", "err", err)
+	}
+}
+
+// DownloadThroughput returns the measured download throughput of the host in bytes per second.
+// Zero is returned if the throughput of the host is not measured
+func (shm *StorageHostManager) DownloadThroughput(id enode.ID) float64 {
+	info, exist := shm.storageHostTree.RetrieveHostInfo(id)
+	if !exist {
+		return 0
+	}
+	return info.DownloadThroughput
+}
+
+// updateDownloadThroughput updates the download throughput of the host with the measured one
+func (shm *StorageHostManager) updateDownloadThroughput(id enode.ID, measured float64) error {
+	shm.lock.Lock()
+	defer shm.lock.Unlock()
+
+	info, exist := shm.storageHostTree.RetrieveHostInfo(id)
+	if !exist {
+		return fmt.Errorf("failed to retrive host info [%v]", id)
+	}
+	info.DownloadThroughput = calcThroughputUpdate(info.DownloadThroughput, measured)
+	score := shm.hostEvaluator.Evaluate(info)
+	if err := shm.storageHostTree.HostInfoUpdate(info, score); err != nil {
+		return fmt.Errorf("failed to update host info: %v", err)
+	}
+	return nil
+}
+
+// calcThroughputUpdate returns the exponential moving average of the throughput with the
+// measured throughput. The first measurement is taken as it is
+func calcThroughputUpdate(prev, measured float64) float64 {
+	if prev == 0 {
+		return measured
+	}
+	return prev + throughputSmoothing*(measured-prev)
+}
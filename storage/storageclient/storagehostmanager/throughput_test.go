@@ -0,0 +1,49 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehostmanager
+
+import (
+	"math"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestCalcThroughputUpdate(t *testing.T) {
+	tests := []struct {
+		prev     float64
+		measured float64
+		expect   float64
+	}{
+		{0, 100, 100},
+		{100, 100, 100},
+		{100, 200, 130},
+		{200, 100, 170},
+	}
+	for i, test := range tests {
+		if got := calcThroughputUpdate(test.prev, test.measured); math.Abs(got-test.expect) > 1e-9 {
+			t.Errorf("test %d: expect throughput %v, got %v", i, test.expect, got)
+		}
+	}
+}
+
+func TestCalcConcurrencyLimit(t *testing.T) {
+	tests := []struct {
+		throughput   float64
+		negotiations int
+		inFlight     uint64
+	}{
+		{0, 2, defaultInFlightBytes},
+		{1, 1, minInFlightBytes},
+		{float64(storage.SectorSize), 5, 20 * storage.SectorSize},
+		{1 << 40, maxNegotiations, maxInFlightBytes},
+	}
+	for i, test := range tests {
+		negotiations, inFlight := calcConcurrencyLimit(test.throughput)
+		if negotiations != test.negotiations || inFlight != test.inFlight {
+			t.Errorf("test %d: expect limit %v/%v, got %v/%v", i, test.negotiations, test.inFlight, negotiations, inFlight)
+		}
+	}
+}
@@ -23,6 +23,12 @@ type StorageHostRank struct {
 	EnodeID string
 }
 
+// StorageHostInfoDetail is the full storage host information with the evaluation detail
+type StorageHostInfoDetail struct {
+	storage.HostInfo
+	EvaluationDetail EvaluationDetail `json:"evaluationDetail"`
+}
+
 // hostInfoGenerator will randomly generate storage host information
 func hostInfoGenerator() storage.HostInfo {
 	ip := randomdata.IpV4Address()
@@ -6,6 +6,8 @@ package storagehostmanager
 
 import (
 	"testing"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
 )
 
 func TestActiveHostInfoGenerator(t *testing.T) {
@@ -32,3 +34,38 @@ func TestActiveHostInfoGenerator(t *testing.T) {
 		}
 	}
 }
+
+func TestPublicStorageHostManagerAPI_HostInfos(t *testing.T) {
+	shm := newHostManagerTestData()
+	var ids []enode.ID
+	for i := 0; i < 5; i++ {
+		hi := hostInfoGenerator()
+		if err := shm.insert(hi); err != nil {
+			t.Fatalf("error: insert failed: %v", err)
+		}
+		ids = append(ids, hi.EnodeID)
+	}
+	// the unknown host is skipped
+	ids = append(ids, enodeIDGenerator())
+
+	api := NewPublicStorageHostManagerAPI(shm)
+	details, err := api.HostInfos(ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(details) != len(ids)-1 {
+		t.Fatalf("expect %v host infos, got %v", len(ids)-1, len(details))
+	}
+	for i, detail := range details {
+		if detail.EnodeID != ids[i] {
+			t.Errorf("host info %v: expect enode id %v, got %v", i, ids[i], detail.EnodeID)
+		}
+		if expect := shm.hostEvaluator.Evaluate(detail.HostInfo); detail.EvaluationDetail.Evaluation != expect {
+			t.Errorf("host info %v: expect evaluation %v, got %v", i, expect, detail.EvaluationDetail.Evaluation)
+		}
+	}
+
+	if _, err := api.HostInfos(make([]enode.ID, maxHostInfosBatchSize+1)); err == nil {
+		t.Error("expect error on the batch size exceeding the limit")
+	}
+}
@@ -19,4 +19,5 @@ type StorageHostTree interface {
 	RetrieveHostInfo(enodeID enode.ID) (storage.HostInfo, bool)
 	RetrieveHostEval(enodeID enode.ID) (int64, bool)
 	SelectRandom(needed int, blacklist, addrBlacklist []enode.ID) []storage.HostInfo
+	SelectByStrategy(strategy Strategy, needed int, blacklist, addrBlacklist []enode.ID) []storage.HostInfo
 }
@@ -0,0 +1,212 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehosttree
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// Names of the storage host selection strategies
+const (
+	StrategyWeightedRandom = "weighted"
+	StrategyTopK           = "topk"
+	StrategyDiversity      = "diversity"
+)
+
+// DefaultStrategy is the strategy used when the strategy is not specified
+const DefaultStrategy = StrategyWeightedRandom
+
+// Candidate is the storage host eligible for the selection along with its evaluation
+type Candidate struct {
+	storage.HostInfo
+	Eval int64
+}
+
+// Strategy selects the storage hosts from the candidates. The hosts within the ip networks
+// filtered by the filter cannot be selected, and the ip network of the host selected is added
+// to the filter, so that no two hosts within the same ip network are selected
+type Strategy interface {
+	Name() string
+	Select(candidates []Candidate, needed int, filter *Filter) []storage.HostInfo
+}
+
+// strategies is the collection of the storage host selection strategies
+var strategies = map[string]Strategy{
+	StrategyWeightedRandom: weightedRandom{},
+	StrategyTopK:           topK{},
+	StrategyDiversity:      diversityFirst{},
+}
+
+// StrategyByName returns the storage host selection strategy with the name. Empty name
+// means the default strategy
+func StrategyByName(name string) (Strategy, error) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+	strategy, exists := strategies[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown host selection strategy %v, available strategies: %v", name, StrategyNames())
+	}
+	return strategy, nil
+}
+
+// StrategyNames returns the names of all storage host selection strategies in order
+func StrategyNames() []string {
+	return []string{StrategyWeightedRandom, StrategyTopK, StrategyDiversity}
+}
+
+// Eligible tells whether the storage host could be selected, which must accept contracts,
+// be scanned at least once, and the latest scan must be success
+func Eligible(hi storage.HostInfo) bool {
+	return hi.AcceptingContracts && len(hi.ScanRecords) > 0 && hi.ScanRecords[len(hi.ScanRecords)-1].Success
+}
+
+// SelectByStrategy selects the storage hosts from the tree with the strategy. The storage hosts
+// in the blacklist cannot be selected, and the storage hosts within the ip networks of the ones
+// in the addrBlacklist cannot be selected
+func (t *storageHostTree) SelectByStrategy(strategy Strategy, needed int, blacklist, addrBlacklist []enode.ID) []storage.HostInfo {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	filter := NewFilter()
+	for _, enodeID := range addrBlacklist {
+		if node, exists := t.hostPool[enodeID]; exists {
+			filter.Add(node.entry.IP)
+		}
+	}
+	excluded := make(map[enode.ID]struct{})
+	for _, enodeID := range blacklist {
+		excluded[enodeID] = struct{}{}
+	}
+
+	var candidates []Candidate
+	for enodeID, node := range t.hostPool {
+		if _, exists := excluded[enodeID]; exists || !Eligible(node.entry.HostInfo) {
+			continue
+		}
+		candidates = append(candidates, Candidate{HostInfo: node.entry.HostInfo, Eval: node.entry.eval})
+	}
+	return strategy.Select(candidates, needed, filter)
+}
+
+// weightedRandom selects the storage hosts randomly, with the probability in proportion
+// to the evaluation
+type weightedRandom struct{}
+
+// Name returns the name of the strategy
+func (weightedRandom) Name() string { return StrategyWeightedRandom }
+
+// Select selects the storage hosts randomly weighted by the evaluation
+func (weightedRandom) Select(candidates []Candidate, needed int, filter *Filter) (selected []storage.HostInfo) {
+	pool := make([]Candidate, 0, len(candidates))
+	var total int64
+	for _, c := range candidates {
+		if c.Eval > 0 {
+			pool = append(pool, c)
+			total += c.Eval
+		}
+	}
+	for len(pool) > 0 && len(selected) < needed && total > 0 {
+		randEval := rand.Int63n(total)
+		index := 0
+		for ; index < len(pool)-1 && randEval >= pool[index].Eval; index++ {
+			randEval -= pool[index].Eval
+		}
+		c := pool[index]
+		if !filter.Filtered(c.IP) {
+			selected = append(selected, c.HostInfo)
+			filter.Add(c.IP)
+		}
+		total -= c.Eval
+		pool = append(pool[:index], pool[index+1:]...)
+	}
+	return
+}
+
+// topK selects the storage hosts with the highest evaluations deterministically
+type topK struct{}
+
+// Name returns the name of the strategy
+func (topK) Name() string { return StrategyTopK }
+
+// Select selects the storage hosts in the order of the evaluation
+func (topK) Select(candidates []Candidate, needed int, filter *Filter) (selected []storage.HostInfo) {
+	for _, c := range sortCandidates(candidates) {
+		if len(selected) >= needed {
+			break
+		}
+		if filter.Filtered(c.IP) {
+			continue
+		}
+		selected = append(selected, c.HostInfo)
+		filter.Add(c.IP)
+	}
+	return
+}
+
+// diversityFirst selects the storage hosts spread over as many regions as possible. The
+// regions are taken in turns, and the host with the highest evaluation in the region is
+// selected in each turn
+type diversityFirst struct{}
+
+// Name returns the name of the strategy
+func (diversityFirst) Name() string { return StrategyDiversity }
+
+// Select selects the storage hosts region by region in the order of the evaluation
+func (diversityFirst) Select(candidates []Candidate, needed int, filter *Filter) (selected []storage.HostInfo) {
+	// group the candidates by region, the regions are ordered by the best host in the region
+	var regions []string
+	byRegion := make(map[string][]Candidate)
+	for _, c := range sortCandidates(candidates) {
+		if _, exists := byRegion[c.Region]; !exists {
+			regions = append(regions, c.Region)
+		}
+		byRegion[c.Region] = append(byRegion[c.Region], c)
+	}
+
+	for len(selected) < needed {
+		progress := false
+		for _, region := range regions {
+			if len(selected) >= needed {
+				break
+			}
+			for len(byRegion[region]) > 0 {
+				c := byRegion[region][0]
+				byRegion[region] = byRegion[region][1:]
+				if filter.Filtered(c.IP) {
+					continue
+				}
+				selected = append(selected, c.HostInfo)
+				filter.Add(c.IP)
+				progress = true
+				break
+			}
+		}
+		if !progress {
+			break
+		}
+	}
+	return
+}
+
+// sortCandidates returns the candidates sorted by the evaluation from the highest to the lowest.
+// The candidates with the same evaluation are sorted by the enode ID, so that the order is stable
+func sortCandidates(candidates []Candidate) []Candidate {
+	sorted := make([]Candidate, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Eval != sorted[j].Eval {
+			return sorted[i].Eval > sorted[j].Eval
+		}
+		return bytes.Compare(sorted[i].EnodeID[:], sorted[j].EnodeID[:]) < 0
+	})
+	return sorted
+}
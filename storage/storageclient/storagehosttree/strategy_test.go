@@ -0,0 +1,103 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehosttree
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestSelectByStrategy test the hosts selected under each strategy, where the hosts 5 and 6
+// of hostDataSet are within the same ip network
+func TestSelectByStrategy(t *testing.T) {
+	tree, err := newTestStorageHostTree(hostDataSet)
+	if err != nil {
+		t.Fatalf("error new test tree: %v", err)
+	}
+	regions := map[enode.ID]string{
+		enode.ID([32]byte{1}): "eu-west",
+		enode.ID([32]byte{2}): "us-east",
+		enode.ID([32]byte{3}): "us-west",
+		enode.ID([32]byte{4}): "us-east",
+		enode.ID([32]byte{5}): "us-east",
+		enode.ID([32]byte{6}): "us-east",
+	}
+	for id, region := range regions {
+		info, _ := tree.RetrieveHostInfo(id)
+		info.Region = region
+		if err := tree.HostInfoUpdate(info, hostDataSet[id].eval); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		strategy  string
+		needed    int
+		blacklist []enode.ID
+		expect    []byte
+	}{
+		// host 5 shares the ip network with host 6 selected
+		{StrategyTopK, 3, nil, []byte{6, 4, 3}},
+		{StrategyTopK, 3, []enode.ID{{6}}, []byte{5, 4, 3}},
+		{StrategyTopK, 10, nil, []byte{6, 4, 3, 2, 1}},
+		// the best host of each region is taken in turns
+		{StrategyDiversity, 3, nil, []byte{6, 3, 1}},
+		{StrategyDiversity, 5, nil, []byte{6, 3, 1, 4, 2}},
+	}
+	for _, test := range tests {
+		strategy, err := StrategyByName(test.strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		selected := tree.SelectByStrategy(strategy, test.needed, test.blacklist, nil)
+		if len(selected) != len(test.expect) {
+			t.Fatalf("%v: selected %v hosts, expect %v", test.strategy, len(selected), len(test.expect))
+		}
+		for i, info := range selected {
+			if info.EnodeID != enode.ID([32]byte{test.expect[i]}) {
+				t.Errorf("%v: host %v selected at %v, expect %v", test.strategy, info.EnodeID[0], i, test.expect[i])
+			}
+		}
+	}
+
+	// the weighted random selection never selects two hosts within the same ip network
+	strategy, _ := StrategyByName("")
+	for i := 0; i < 100; i++ {
+		selected := tree.SelectByStrategy(strategy, 6, nil, []enode.ID{{1}})
+		if len(selected) != 4 {
+			t.Fatalf("weighted: selected %v hosts, expect 4", len(selected))
+		}
+		for _, info := range selected {
+			if info.EnodeID == enode.ID([32]byte{1}) {
+				t.Fatal("weighted: host within the blacklisted ip network selected")
+			}
+		}
+	}
+
+	// the tree is not changed by the selections
+	if err = treeValidation(tree.root, hostDataSet.totalWeight()); err != nil {
+		t.Errorf("evaluation verification failed: %s", err.Error())
+	}
+	if _, err := StrategyByName("unknown"); err == nil {
+		t.Error("unknown strategy should be rejected")
+	}
+}
+
+// TestEligible test the hosts not accepting contracts or failed the latest scan are not eligible
+func TestEligible(t *testing.T) {
+	info := createHostInfo("99.0.86.9", enode.ID{1}, true)
+	if !Eligible(info) {
+		t.Error("host should be eligible")
+	}
+	if Eligible(createHostInfo("99.0.86.9", enode.ID{1}, false)) {
+		t.Error("host not accepting contracts should not be eligible")
+	}
+	info.ScanRecords = append(info.ScanRecords, storage.HostPoolScan{})
+	if Eligible(info) {
+		t.Error("host failed the latest scan should not be eligible")
+	}
+}
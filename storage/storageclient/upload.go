@@ -11,7 +11,6 @@ import (
 
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/storage"
-	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxdir"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
 )
@@ -41,17 +40,12 @@ func (client *StorageClient) Upload(up storage.FileUploadParams) error {
 		return err
 	}
 
-	// Delete existing file if Override mode
-	//if up.Mode == storage.Override {
-	//	err := client.DeleteFile(up.DxPath)
-	//	if err != nil && err != dxdir.ErrUnknownPath {
-	//		return fmt.Errorf("cannot to delete existing file, error: %v", err)
-	//	}
-	//}
-
-	// Setup ECTypeStandard's ErasureCode with default params
+	// Setup ECTypeStandard's ErasureCode with the redundancy target of the file, which is set on
+	// the file or inherited from the directories
 	if up.ErasureCode == nil {
-		up.ErasureCode, _ = erasurecode.New(erasurecode.ECTypeStandard, storage.DefaultMinSectors, storage.DefaultNumSectors)
+		if up.ErasureCode, err = client.fileSystem.RedundancyTarget(up.DxPath).ErasureCode(); err != nil {
+			return fmt.Errorf("invalid redundancy target: %v", err)
+		}
 	}
 
 	numContracts := uint64(len(client.contractManager.GetStorageContractSet().Contracts()))
@@ -75,13 +69,17 @@ func (client *StorageClient) Upload(up storage.FileUploadParams) error {
 	}
 	//client.log.Error("test error for NewDxDir in upload", "error", err)
 
-	cipherKey, err := crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	// In Override mode the cipher key of the existing file is reused, so that the sectors
+	// unchanged are deduplicated against the ones stored for the version retained
+	cipherKey, err := client.overrideCipherKey(up)
 	if err != nil {
 		return fmt.Errorf("generate cipher key error: %v", err)
 	}
 
-	// Create the DxFile and add to client
-	entry, err := client.fileSystem.NewDxFile(up.DxPath, storage.SysPath(up.Source), false, up.ErasureCode, cipherKey, uint64(sourceInfo.Size()), sourceInfo.Mode())
+	// Create the DxFile and add to client. In Override mode the existing file is overwritten,
+	// and retained as a version until the contracts storing the sectors expire
+	force := up.Mode == storage.Override
+	entry, err := client.fileSystem.NewDxFile(up.DxPath, storage.SysPath(up.Source), force, up.ErasureCode, cipherKey, uint64(sourceInfo.Size()), sourceInfo.Mode())
 
 	if err != nil {
 		return fmt.Errorf("could not create a new dx file, error: %v", err)
@@ -108,3 +106,17 @@ func (client *StorageClient) Upload(up storage.FileUploadParams) error {
 	}
 	return nil
 }
+
+// overrideCipherKey returns the cipher key of the file to be overwritten in Override mode,
+// otherwise a new cipher key is generated
+func (client *StorageClient) overrideCipherKey(up storage.FileUploadParams) (crypto.CipherKey, error) {
+	if up.Mode != storage.Override {
+		return crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	}
+	entry, err := client.fileSystem.OpenDxFile(up.DxPath)
+	if err != nil {
+		return crypto.GenerateCipherKey(crypto.GCMCipherCode)
+	}
+	defer entry.Close()
+	return entry.CipherKey()
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// uploadBatch buffers the sectors appended to a host, and uploads them in the upload requests
+// with multiple append actions. All the sectors in one request are covered by a single contract
+// revision, which saves the revision handshakes of uploading the sectors one by one
+type uploadBatch struct {
+	// budget is the max bytes of the sector data carried in one upload request
+	budget uint64
+
+	// write uploads the actions to the host in one request
+	write func(actions []storage.UploadAction) error
+
+	actions []storage.UploadAction
+	done    []func()
+	size    uint64
+}
+
+// newUploadBatch creates the upload batch with the byte budget of each upload request
+func newUploadBatch(budget uint64, write func(actions []storage.UploadAction) error) *uploadBatch {
+	return &uploadBatch{
+		budget: budget,
+		write:  write,
+	}
+}
+
+// Append buffers the sector data, and calls done once the data is uploaded. The buffered
+// sectors are flushed first if the data does not fit into the current request
+func (b *uploadBatch) Append(data []byte, done func()) error {
+	size := uint64(len(data))
+	if len(b.actions) > 0 && (b.size+size > b.budget || len(b.actions) >= storage.MaxUploadActions) {
+		if err := b.Flush(); err != nil {
+			return err
+		}
+	}
+	b.actions = append(b.actions, storage.UploadAction{Type: storage.UploadActionAppend, Data: data})
+	b.done = append(b.done, done)
+	b.size += size
+	return nil
+}
+
+// Flush uploads the buffered sectors in one request. The buffered sectors are discarded
+// whether the upload succeeded or not, and only the done of the uploaded ones are called
+func (b *uploadBatch) Flush() error {
+	if len(b.actions) == 0 {
+		return nil
+	}
+	actions, done := b.actions, b.done
+	b.actions, b.done, b.size = nil, nil, 0
+
+	if err := b.write(actions); err != nil {
+		return err
+	}
+	for _, fn := range done {
+		fn()
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestUploadBatchFlush test the sectors are flushed once they do not fit into the budget,
+// and the done of the sectors are called once uploaded
+func TestUploadBatchFlush(t *testing.T) {
+	var requests []int
+	batch := newUploadBatch(2*storage.SectorSize, func(actions []storage.UploadAction) error {
+		requests = append(requests, len(actions))
+		return nil
+	})
+
+	var done int
+	for i := 0; i < 5; i++ {
+		if err := batch.Append(make([]byte, storage.SectorSize), func() { done++ }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(requests) != 2 || done != 4 {
+		t.Fatalf("before flush, %v requests sent and %v sectors done, expect 2 and 4", len(requests), done)
+	}
+	if err := batch.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []int{2, 2, 1}
+	if len(requests) != len(expected) || done != 5 {
+		t.Fatalf("after flush, requests %v and %v sectors done, expect %v and 5", requests, done, expected)
+	}
+	for i := range expected {
+		if requests[i] != expected[i] {
+			t.Errorf("request %v has %v actions, expect %v", i, requests[i], expected[i])
+		}
+	}
+}
+
+// TestUploadBatchMaxActions test the request does not exceed the max number of actions
+func TestUploadBatchMaxActions(t *testing.T) {
+	var requests []int
+	batch := newUploadBatch(storage.SectorSize, func(actions []storage.UploadAction) error {
+		requests = append(requests, len(actions))
+		return nil
+	})
+	for i := 0; i < storage.MaxUploadActions+1; i++ {
+		if err := batch.Append([]byte{byte(i)}, func() {}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 || requests[0] != storage.MaxUploadActions || requests[1] != 1 {
+		t.Errorf("unexpected requests %v", requests)
+	}
+}
+
+// TestUploadBatchFailed test the done of the sectors failed to upload are not called, and
+// the failed sectors are discarded
+func TestUploadBatchFailed(t *testing.T) {
+	errWrite := errors.New("write failed")
+	fail := true
+	batch := newUploadBatch(storage.SectorSize, func(actions []storage.UploadAction) error {
+		if fail {
+			return errWrite
+		}
+		return nil
+	})
+
+	var done int
+	if err := batch.Append(make([]byte, storage.SectorSize), func() { done++ }); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Append(make([]byte, storage.SectorSize), func() { done++ }); err != errWrite {
+		t.Fatalf("append with the flush failed, got error %v", err)
+	}
+	fail = false
+	if err := batch.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if done != 0 {
+		t.Errorf("%v sectors done, expect 0", done)
+	}
+}
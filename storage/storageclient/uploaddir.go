@@ -0,0 +1,214 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+var (
+	manifestMetadata = common.Metadata{
+		Header:  "dx directory manifest",
+		Version: "1.0",
+	}
+
+	errNotDirectory  = errors.New("the source path is not a directory")
+	errMemoryStopped = errors.New("unable to request memory, storage client is stopped")
+)
+
+type (
+	// DirManifest records the files uploaded from a local directory, which could be
+	// used to verify the integrity of the files later
+	DirManifest struct {
+		Source    string          `json:"source"`
+		DxPath    string          `json:"dxpath"`
+		Timestamp time.Time       `json:"timestamp"`
+		Files     []ManifestEntry `json:"files"`
+	}
+
+	// ManifestEntry is the record of a single uploaded file in the manifest
+	ManifestEntry struct {
+		Path string      `json:"path"`
+		Size uint64      `json:"size"`
+		Hash common.Hash `json:"hash"`
+	}
+
+	// dirUploadTask is the task of uploading a single file within the directory
+	dirUploadTask struct {
+		relPath string
+		source  string
+		dxPath  storage.DxPath
+		size    uint64
+	}
+)
+
+// UploadDir walks the local directory recursively, creates the corresponding DxDir for each
+// sub directory, and uploads all files concurrently. A manifest containing the relative path
+// and hash of each uploaded file is written to the root of the uploaded DxDir
+func (client *StorageClient) UploadDir(localPath string, dxPath storage.DxPath) (*DirManifest, error) {
+	if err := client.tm.Add(); err != nil {
+		return nil, err
+	}
+	defer client.tm.Done()
+
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat input directory, error: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, errNotDirectory
+	}
+
+	tasks, err := client.prepareDirUpload(localPath, dxPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// upload the files concurrently
+	var (
+		wg       sync.WaitGroup
+		lock     sync.Mutex
+		errs     []error
+		entries  []ManifestEntry
+		taskChan = make(chan dirUploadTask)
+	)
+	for i := 0; i < MaxConcurrentDirUploads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range taskChan {
+				entry, err := client.uploadDirFile(task)
+				lock.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("failed to upload %v: %v", task.relPath, err))
+				} else {
+					entries = append(entries, entry)
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+	for _, task := range tasks {
+		taskChan <- task
+	}
+	close(taskChan)
+	wg.Wait()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	manifest := &DirManifest{
+		Source:    localPath,
+		DxPath:    dxPath.Path,
+		Timestamp: time.Now(),
+		Files:     entries,
+	}
+	manifestPath := filepath.Join(string(dxPath.SysPath(client.fileSystem.RootDir())), storage.DxManifestFileName)
+	if err := common.SaveDxJSON(manifestMetadata, manifestPath, manifest); err != nil {
+		errs = append(errs, fmt.Errorf("failed to save the manifest: %v", err))
+	}
+	return manifest, common.ErrCompose(errs...)
+}
+
+// prepareDirUpload walks through the local directory, creates the DxDir for each directory,
+// and returns the upload tasks of the files within the directory. Empty files are skipped
+func (client *StorageClient) prepareDirUpload(localPath string, dxPath storage.DxPath) ([]dirUploadTask, error) {
+	var tasks []dirUploadTask
+	err := filepath.Walk(localPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(localPath, path)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		curDxPath := dxPath
+		if relPath != "." {
+			if curDxPath, err = dxPath.Join(relPath); err != nil {
+				return err
+			}
+		}
+
+		if info.IsDir() {
+			if curDxPath.IsRoot() {
+				return nil
+			}
+			entry, err := client.fileSystem.NewDxDir(curDxPath)
+			if err == os.ErrExist {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("unable to create dx directory %v, error: %v", curDxPath.Path, err)
+			}
+			return entry.Close()
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if info.Size() == 0 {
+			client.log.Warn("skip uploading empty file", "path", path)
+			return nil
+		}
+		tasks = append(tasks, dirUploadTask{
+			relPath: relPath,
+			source:  path,
+			dxPath:  curDxPath,
+			size:    uint64(info.Size()),
+		})
+		return nil
+	})
+	return tasks, err
+}
+
+// uploadDirFile hash the file and start the upload. The memory used for hashing is
+// requested from the memory manager to limit the total memory used by concurrent uploads
+func (client *StorageClient) uploadDirFile(task dirUploadTask) (ManifestEntry, error) {
+	if !client.memoryManager.Request(DirUploadBufferSize, false) {
+		return ManifestEntry{}, errMemoryStopped
+	}
+	hash, err := hashFile(task.source, make([]byte, DirUploadBufferSize))
+	client.memoryManager.Return(DirUploadBufferSize)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+
+	err = client.Upload(storage.FileUploadParams{
+		Source: task.source,
+		DxPath: task.dxPath,
+		Mode:   storage.Override,
+	})
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	return ManifestEntry{
+		Path: task.relPath,
+		Size: task.size,
+		Hash: hash,
+	}, nil
+}
+
+// hashFile returns the sha256 hash of the file content, using the buffer provided
+func hashFile(path string, buf []byte) (common.Hash, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.CopyBuffer(h, file, buf); err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(h.Sum(nil)), nil
+}
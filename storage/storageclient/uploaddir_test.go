@@ -0,0 +1,48 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestHashFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "uploaddir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the content is larger than the buffer to make sure the file is read in multiple rounds
+	content := make([]byte, 3*DefaultPacketSize+1)
+	for i := range content {
+		content[i] = byte(i)
+	}
+	path := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := hashFile(path, make([]byte, DefaultPacketSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := common.Hash(sha256.Sum256(content)); hash != expect {
+		t.Errorf("file hash not expected. Got %v, Expect %v", hash, expect)
+	}
+}
+
+func TestManifestFileNameReserved(t *testing.T) {
+	if _, err := storage.NewDxPath("dir/" + storage.DxManifestFileName); err == nil {
+		t.Errorf("manifest file name should be reserved")
+	}
+}
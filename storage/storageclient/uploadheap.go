@@ -457,6 +457,11 @@ func (client *StorageClient) uploadOrRepair() {
 			}
 		}
 
+		// Wait until the uploads are resumed if paused by the user
+		if !client.blockUntilUploadsResumed() {
+			return
+		}
+
 		// Pop the next segment and check whether is empty
 		nextSegment := client.uploadHeap.pop()
 		if nextSegment == nil {
@@ -566,6 +571,11 @@ func (client *StorageClient) uploadLoop() {
 			return
 		}
 
+		// Wait until the uploads are resumed if paused by the user
+		if !client.blockUntilUploadsResumed() {
+			return
+		}
+
 		// Check whether a repair is needed of root dir. If the root dir health is more than
 		// RepairHealthThreshold, it is not necessary to upload any sectors
 		rootMetadata, err := client.dirMetadata(storage.RootDxPath())
@@ -0,0 +1,135 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errInvalidPauseDuration is the error returned when uploads are paused for a non-positive duration
+var errInvalidPauseDuration = errors.New("the duration uploads paused for must be positive")
+
+// uploadPause is the state of the uploads and repairs paused by the user. While paused, no segment
+// is popped from the upload heap and the workers take no more sectors from their upload queues,
+// while the sectors already being uploaded are finished
+type uploadPause struct {
+	until  time.Time
+	timer  *time.Timer
+	seq    uint64
+	resume chan struct{}
+	lock   sync.Mutex
+}
+
+// pause pauses the uploads for the duration. Pausing again while paused extends or shortens the
+// pause to the new duration
+func (up *uploadPause) pause(d time.Duration) (time.Time, error) {
+	if d <= 0 {
+		return time.Time{}, errInvalidPauseDuration
+	}
+	up.lock.Lock()
+	defer up.lock.Unlock()
+
+	if up.resume == nil {
+		up.resume = make(chan struct{})
+	}
+	if up.timer != nil {
+		up.timer.Stop()
+	}
+	up.seq++
+	seq := up.seq
+	up.until = time.Now().Add(d)
+	up.timer = time.AfterFunc(d, func() { up.expire(seq) })
+	return up.until, nil
+}
+
+// expire resumes the uploads once the duration of the pause elapsed. The expiry of a pause
+// already replaced or resumed is ignored
+func (up *uploadPause) expire(seq uint64) {
+	up.lock.Lock()
+	current := up.seq == seq && up.resume != nil
+	up.lock.Unlock()
+	if current {
+		up.unpause()
+	}
+}
+
+// unpause resumes the uploads, and wakes up the loops and workers waiting. It returns false if
+// uploads were not paused
+func (up *uploadPause) unpause() bool {
+	up.lock.Lock()
+	defer up.lock.Unlock()
+
+	if up.resume == nil {
+		return false
+	}
+	if up.timer != nil {
+		up.timer.Stop()
+		up.timer = nil
+	}
+	close(up.resume)
+	up.resume = nil
+	up.until = time.Time{}
+	return true
+}
+
+// paused returns whether the uploads are paused, along with the time the uploads are resumed
+func (up *uploadPause) paused() (bool, time.Time) {
+	up.lock.Lock()
+	defer up.lock.Unlock()
+	return up.resume != nil, up.until
+}
+
+// resumed returns the channel closed once the uploads are resumed. A nil channel, which blocks
+// forever, is returned if the uploads are not paused
+func (up *uploadPause) resumed() <-chan struct{} {
+	up.lock.Lock()
+	defer up.lock.Unlock()
+	return up.resume
+}
+
+// PauseUploads pauses the uploads and repairs for the duration, returning the time the uploads
+// are resumed. The sectors being uploaded are finished, while the segments queued are kept until
+// the uploads are resumed
+func (client *StorageClient) PauseUploads(d time.Duration) (time.Time, error) {
+	until, err := client.uploadPause.pause(d)
+	if err != nil {
+		return time.Time{}, err
+	}
+	client.log.Info("Uploads and repairs paused", "until", until)
+	return until, nil
+}
+
+// ResumeUploads resumes the uploads and repairs paused. It returns false if not paused
+func (client *StorageClient) ResumeUploads() bool {
+	if !client.uploadPause.unpause() {
+		return false
+	}
+	client.log.Info("Uploads and repairs resumed")
+	return true
+}
+
+// UploadsPaused returns whether the uploads and repairs are paused, along with the time the
+// uploads are resumed
+func (client *StorageClient) UploadsPaused() (bool, time.Time) {
+	return client.uploadPause.paused()
+}
+
+// blockUntilUploadsResumed blocks until the uploads are not paused. It returns false if the
+// storage client is stopped
+func (client *StorageClient) blockUntilUploadsResumed() bool {
+	for {
+		resume := client.uploadPause.resumed()
+		if resume == nil {
+			return true
+		}
+		select {
+		case <-resume:
+		case <-client.tm.StopChan():
+			return false
+		}
+	}
+}
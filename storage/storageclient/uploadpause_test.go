@@ -0,0 +1,58 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"testing"
+	"time"
+)
+
+// TestUploadPause test the uploads paused are resumed either explicitly or once the duration
+// elapsed, and the waiters are woken up on the resume
+func TestUploadPause(t *testing.T) {
+	var up uploadPause
+	if paused, _ := up.paused(); paused || up.resumed() != nil {
+		t.Fatal("uploads paused initially")
+	}
+	if _, err := up.pause(0); err != errInvalidPauseDuration {
+		t.Fatalf("expect error %v, got %v", errInvalidPauseDuration, err)
+	}
+
+	// resumed explicitly
+	if _, err := up.pause(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	resume := up.resumed()
+	if paused, until := up.paused(); !paused || time.Until(until) <= 0 {
+		t.Fatalf("expect uploads paused, got %v until %v", paused, until)
+	}
+	if !up.unpause() {
+		t.Fatal("failed to resume the uploads")
+	}
+	select {
+	case <-resume:
+	default:
+		t.Fatal("waiters not woken up on the resume")
+	}
+	if up.unpause() {
+		t.Fatal("uploads resumed twice")
+	}
+
+	// pausing again shortens the pause, and the uploads are resumed once the duration elapsed
+	if _, err := up.pause(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := up.pause(10 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-up.resumed():
+	case <-time.After(time.Second):
+		t.Fatal("uploads not resumed after the duration elapsed")
+	}
+	if paused, _ := up.paused(); paused {
+		t.Fatal("uploads still paused after the duration elapsed")
+	}
+}
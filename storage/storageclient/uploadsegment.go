@@ -12,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
 )
@@ -57,6 +58,10 @@ type unfinishedUploadSegment struct {
 	logicalSegmentData  [][]byte
 	physicalSegmentData [][]byte
 
+	// dedupKeys are the keys of the sectors in the sector dedup index, with which the sectors
+	// are indexed once uploaded
+	dedupKeys []common.Hash
+
 	mu                  sync.Mutex
 	sectorSlotsStatus   []bool              // 'true' in that index if a sector is either uploaded, or a worker is attempting to upload that sector
 	sectorsCompletedNum int                 // number of sectors that have been successful completely uploaded
@@ -244,8 +249,8 @@ func (client *StorageClient) retrieveDataAndDispatchSegment(segment *unfinishedU
 	segment.physicalSegmentData, err = ec.Encode(segmentBytes)
 	if err != nil {
 		segment.workersRemain = 0
-		client.memoryManager.Return(sectorCompletedMemory)
-		segment.memoryReleased += sectorCompletedMemory
+		client.memoryManager.Return(erasureCodingMemory + sectorCompletedMemory)
+		segment.memoryReleased += erasureCodingMemory + sectorCompletedMemory
 		for i := 0; i < len(segment.physicalSegmentData); i++ {
 			segment.physicalSegmentData[i] = nil
 		}
@@ -266,6 +271,10 @@ func (client *StorageClient) retrieveDataAndDispatchSegment(segment *unfinishedU
 	if err != nil {
 		return
 	}
+	// Reference the sectors already stored under the contracts instead of uploading them again
+	if deduped := client.dedupSegmentSectors(segment, key); deduped > 0 {
+		sectorCompletedMemory += uint64(deduped) * storage.SectorSize
+	}
 	// Loop through the sectorSlots and encrypt any that are needed
 	// If the sector has been used, set physicalSegmentData nil and gc routine will collect this memory
 	for i := 0; i < len(segment.sectorSlotsStatus); i++ {
@@ -0,0 +1,63 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"math/rand"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/metrics"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
+	"github.com/DxChainNetwork/godx/storage/storageerr"
+)
+
+var (
+	uploadVerifyPassedCounter = metrics.NewRegisteredCounter("storageclient/uploadverify/passed", nil)
+	uploadVerifyFailedCounter = metrics.NewRegisteredCounter("storageclient/uploadverify/failed", nil)
+)
+
+// verifyUploadedSectors downloads a random range of each sector uploaded to the host in the
+// upload batch along with the merkle proof, within a single download negotiation. The host
+// acknowledging the upload but discarding the data is caught before the sectors are added to
+// the files. The failure of the verification is recorded in the interactions of the host the
+// same way as the spot check
+func (w *worker) verifyUploadedSectors(sp storage.Peer, hostInfo *storage.HostInfo, roots []common.Hash, uploaded []bool) error {
+	sections := uploadVerifySections(roots, uploaded, rand.Uint64)
+	if len(sections) == 0 {
+		return nil
+	}
+	_, err := w.client.DownloadSections(sp, sections, hostInfo)
+	switch storageerr.CodeOf(err) {
+	case storageerr.CodeInvalidProof, storageerr.CodeNegotiationFailed, storageerr.CodeCommitFailed:
+		uploadVerifyFailedCounter.Inc(1)
+		w.client.log.Warn("Host failed the upload verification", "host", w.contract.EnodeID, "contractID", w.contract.ID, "err", err)
+		w.client.storageHostManager.IncrementFailedInteractions(w.contract.EnodeID, storagehostmanager.InteractionSpotCheck)
+	default:
+		if err == nil {
+			uploadVerifyPassedCounter.Inc(1)
+			w.client.storageHostManager.IncrementSuccessfulInteractions(w.contract.EnodeID, storagehostmanager.InteractionSpotCheck)
+		}
+	}
+	return err
+}
+
+// uploadVerifySections returns the sections downloaded to verify the sectors uploaded, which
+// is a random range of each sector aligned to the segments
+func uploadVerifySections(roots []common.Hash, uploaded []bool, random func() uint64) []storage.DownloadRequestSector {
+	var sections []storage.DownloadRequestSector
+	for i, root := range roots {
+		if !uploaded[i] {
+			continue
+		}
+		offset, length := spotCheckRange(random(), random())
+		sections = append(sections, storage.DownloadRequestSector{
+			MerkleRoot: root,
+			Offset:     offset,
+			Length:     length,
+		})
+	}
+	return sections
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestUploadVerifySections test a segment aligned range within the sector is verified for
+// each sector uploaded, and the sectors not uploaded are skipped
+func TestUploadVerifySections(t *testing.T) {
+	roots := []common.Hash{{1}, {2}, {3}}
+	uploaded := []bool{true, false, true}
+	var n uint64
+	random := func() uint64 {
+		n += 12345
+		return n
+	}
+
+	sections := uploadVerifySections(roots, uploaded, random)
+	if len(sections) != 2 {
+		t.Fatalf("expect 2 sections, got %v", len(sections))
+	}
+	for i, expect := range []common.Hash{roots[0], roots[2]} {
+		sec := sections[i]
+		if sec.MerkleRoot != expect {
+			t.Errorf("section %v: expect root %v, got %v", i, expect, sec.MerkleRoot)
+		}
+		if sec.Offset%storage.SegmentSize != 0 || sec.Length%storage.SegmentSize != 0 || sec.Length == 0 {
+			t.Errorf("section %v: range [%v, %v) not aligned to segments", i, sec.Offset, sec.Offset+sec.Length)
+		}
+		if uint64(sec.Offset)+uint64(sec.Length) > storage.SectorSize {
+			t.Errorf("section %v: range [%v, %v) out of the sector", i, sec.Offset, sec.Offset+sec.Length)
+		}
+	}
+
+	if sections := uploadVerifySections(roots, make([]bool, len(roots)), random); len(sections) != 0 {
+		t.Errorf("expect no section without the sectors uploaded, got %v", len(sections))
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"math/big"
 	"sort"
+	"sync"
 	"time"
 
 	"io/ioutil"
@@ -24,6 +25,7 @@ import (
 	"github.com/DxChainNetwork/godx/storage"
 	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem"
 	"github.com/DxChainNetwork/godx/storage/storageclient/storagehostmanager"
+	"github.com/DxChainNetwork/godx/storage/storageerr"
 )
 
 // ActiveContractsAPI is used to re-format the contract information that is going to
@@ -62,6 +64,15 @@ func (client *StorageClient) GetTxByBlockHash(blockHash common.Hash) (types.Tran
 	return block.Transactions(), nil
 }
 
+// GetBalance returns the balance of the address at the current block
+func (client *StorageClient) GetBalance(address common.Address) (common.BigInt, error) {
+	stateDB, err := client.ethBackend.GetBlockChain().State()
+	if err != nil {
+		return common.BigInt0, err
+	}
+	return common.PtrBigInt(stateDB.GetBalance(address)), nil
+}
+
 // GetStorageHostSetting will be used to get the storage host's external setting based on the
 // peerID provided
 func (client *StorageClient) GetStorageHostSetting(hostEnodeID enode.ID, hostEnodeURL string, config *storage.HostExtConfig) error {
@@ -149,7 +160,29 @@ func (client *StorageClient) DirList(dxPath storage.DxPath) ([]storage.Directory
 
 // SetupConnection will establish the secure P2P connection with the node provided
 func (client *StorageClient) SetupConnection(enodeURL string) (storage.Peer, error) {
-	return client.ethBackend.SetupConnection(enodeURL)
+	if err := client.negotiations.Begin(); err != nil {
+		return nil, err
+	}
+	sp, err := client.ethBackend.SetupConnection(enodeURL)
+	if err != nil {
+		client.negotiations.End()
+		return nil, storageerr.Wrap(storageerr.CodeHostOffline, err)
+	}
+	return &negotiationSession{Peer: sp, end: client.negotiations.End}, nil
+}
+
+// negotiationSession is the session set up for a negotiation, which finishes tracking the
+// negotiation once closed
+type negotiationSession struct {
+	storage.Peer
+	end  func()
+	once sync.Once
+}
+
+// Close closes the session and finishes tracking the negotiation
+func (s *negotiationSession) Close() {
+	s.Peer.Close()
+	s.once.Do(s.end)
 }
 
 // AccountManager will be used to acquire the account manager object which will be
@@ -250,7 +283,7 @@ func ModifyLeaves(leafHashes []common.Hash, actions []storage.UploadAction, numS
 	for _, action := range actions {
 		switch action.Type {
 		case storage.UploadActionAppend:
-			leafHashes = append(leafHashes, merkle.Sha256MerkleTreeRoot(action.Data))
+			leafHashes = append(leafHashes, merkle.CachedSha256MerkleTreeRoot(action.Data))
 		}
 	}
 	return leafHashes
@@ -0,0 +1,409 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/threadmanager"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/storage/storageclient/contractmanager"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem/dxfile"
+)
+
+// The types of the events sent to the webhooks
+const (
+	WebhookUploadComplete   = "upload.complete"
+	WebhookDownloadComplete = "download.complete"
+	WebhookContractRenewed  = "contract.renewed"
+	WebhookFileHealthLow    = "file.health.low"
+)
+
+// The headers of the webhook request
+const (
+	webhookEventHeader     = "X-DxChain-Event"
+	webhookSignatureHeader = "X-DxChain-Signature"
+)
+
+var webhookEventTypes = []string{WebhookUploadComplete, WebhookDownloadComplete, WebhookContractRenewed, WebhookFileHealthLow}
+
+var webhookMetadata = common.Metadata{
+	Header:  "storage client webhooks",
+	Version: PersistStorageClientVersion,
+}
+
+var (
+	// errWebhookNotFound is the error that the webhook with the id does not exist
+	errWebhookNotFound = errors.New("webhook not found")
+
+	// errInvalidWebhookURL is the error that the url of the webhook is not a http or https url
+	errInvalidWebhookURL = errors.New("webhook url must be an absolute http or https url")
+)
+
+type (
+	// Webhook is the url the storage client POSTs the events to. The request body is signed
+	// with HMAC-SHA256 using the secret, and the hex encoded signature is carried in the
+	// X-DxChain-Signature header
+	Webhook struct {
+		ID     string   `json:"id"`
+		URL    string   `json:"url"`
+		Secret string   `json:"secret,omitempty"`
+		Events []string `json:"events"`
+
+		// HealthThreshold is the health below which the file.health.low event is sent
+		HealthThreshold uint32 `json:"healthThreshold"`
+	}
+
+	// WebhookEvent is the request body POSTed to the webhook
+	WebhookEvent struct {
+		ID   string      `json:"id"`
+		Type string      `json:"type"`
+		Time time.Time   `json:"time"`
+		Data interface{} `json:"data"`
+	}
+
+	// webhookFileData is the data of the upload.complete event
+	webhookFileData struct {
+		DxPath string `json:"dxpath"`
+	}
+
+	// webhookDownloadData is the data of the download.complete event
+	webhookDownloadData struct {
+		ID          string `json:"id"`
+		DxPath      string `json:"dxpath"`
+		Destination string `json:"destination"`
+		Length      uint64 `json:"length"`
+		Error       string `json:"error,omitempty"`
+	}
+
+	// webhookContractData is the data of the contract.renewed event
+	webhookContractData struct {
+		OldContractID string `json:"oldContractID"`
+		NewContractID string `json:"newContractID"`
+		HostID        string `json:"hostID"`
+	}
+
+	// webhookHealthData is the data of the file.health.low event
+	webhookHealthData struct {
+		DxPath     string `json:"dxpath"`
+		PrevHealth uint32 `json:"prevHealth"`
+		Health     uint32 `json:"health"`
+		Threshold  uint32 `json:"threshold"`
+	}
+
+	// webhookDispatcher keeps the webhooks and delivers the events to them, retrying the failed
+	// deliveries with the interval doubled each time
+	webhookDispatcher struct {
+		hooks      map[string]Webhook
+		persistDir string
+
+		httpClient    *http.Client
+		maxAttempts   int
+		retryInterval time.Duration
+
+		tm   *threadmanager.ThreadManager
+		log  log.Logger
+		lock sync.RWMutex
+	}
+)
+
+// newWebhookDispatcher creates the webhook dispatcher persisting the webhooks in persistDir
+func newWebhookDispatcher(persistDir string, tm *threadmanager.ThreadManager, logger log.Logger) *webhookDispatcher {
+	return &webhookDispatcher{
+		hooks:         make(map[string]Webhook),
+		persistDir:    persistDir,
+		httpClient:    &http.Client{Timeout: WebhookTimeout},
+		maxAttempts:   WebhookMaxAttempts,
+		retryInterval: WebhookRetryInterval,
+		tm:            tm,
+		log:           logger,
+	}
+}
+
+// add validates and adds the webhook, and returns the id of the webhook. The webhook without
+// events subscribes all events, and the webhook without health threshold uses the
+// RepairHealthThreshold
+func (wd *webhookDispatcher) add(hook Webhook) (string, error) {
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errInvalidWebhookURL
+	}
+	for _, e := range hook.Events {
+		if !isWebhookEventType(e) {
+			return "", fmt.Errorf("unknown webhook event %v, must be one of %v", e, webhookEventTypes)
+		}
+	}
+	if len(hook.Events) == 0 {
+		hook.Events = append([]string(nil), webhookEventTypes...)
+	}
+	if hook.HealthThreshold == 0 {
+		hook.HealthThreshold = dxfile.RepairHealthThreshold
+	}
+	hook.ID = randomWebhookID()
+
+	wd.lock.Lock()
+	defer wd.lock.Unlock()
+	wd.hooks[hook.ID] = hook
+	if err := wd.save(); err != nil {
+		delete(wd.hooks, hook.ID)
+		return "", err
+	}
+	return hook.ID, nil
+}
+
+// remove removes the webhook with the id
+func (wd *webhookDispatcher) remove(id string) error {
+	wd.lock.Lock()
+	defer wd.lock.Unlock()
+
+	hook, exist := wd.hooks[id]
+	if !exist {
+		return errWebhookNotFound
+	}
+	delete(wd.hooks, id)
+	if err := wd.save(); err != nil {
+		wd.hooks[id] = hook
+		return err
+	}
+	return nil
+}
+
+// webhooks returns the webhooks sorted by the id, with the secrets hidden
+func (wd *webhookDispatcher) webhooks() []Webhook {
+	wd.lock.RLock()
+	defer wd.lock.RUnlock()
+
+	hooks := make([]Webhook, 0, len(wd.hooks))
+	for _, hook := range wd.hooks {
+		hook.Secret = ""
+		hooks = append(hooks, hook)
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].ID < hooks[j].ID
+	})
+	return hooks
+}
+
+// subscribed returns whether any webhook subscribes the event type
+func (wd *webhookDispatcher) subscribed(eventType string) bool {
+	wd.lock.RLock()
+	defer wd.lock.RUnlock()
+
+	for _, hook := range wd.hooks {
+		if hook.subscribes(eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// notify sends the event to all webhooks subscribing the event type
+func (wd *webhookDispatcher) notify(eventType string, data interface{}) {
+	wd.lock.RLock()
+	defer wd.lock.RUnlock()
+
+	for _, hook := range wd.hooks {
+		if hook.subscribes(eventType) {
+			go wd.deliver(hook, newWebhookEvent(eventType, data))
+		}
+	}
+}
+
+// notifyFileHealth sends the file.health.low event to the webhooks whose health threshold is
+// crossed by the health change of the file
+func (wd *webhookDispatcher) notifyFileHealth(e filesystem.FileHealthEvent) {
+	wd.lock.RLock()
+	defer wd.lock.RUnlock()
+
+	for _, hook := range wd.hooks {
+		if !hook.subscribes(WebhookFileHealthLow) {
+			continue
+		}
+		if e.Health >= hook.HealthThreshold || e.PrevHealth < hook.HealthThreshold {
+			continue
+		}
+		go wd.deliver(hook, newWebhookEvent(WebhookFileHealthLow, webhookHealthData{
+			DxPath:     e.DxPath.Path,
+			PrevHealth: e.PrevHealth,
+			Health:     e.Health,
+			Threshold:  hook.HealthThreshold,
+		}))
+	}
+}
+
+// deliver POSTs the event to the webhook until succeeded, maxAttempts reached, or the
+// storage client stopped
+func (wd *webhookDispatcher) deliver(hook Webhook, event WebhookEvent) {
+	if err := wd.tm.Add(); err != nil {
+		return
+	}
+	defer wd.tm.Done()
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		wd.log.Error("failed to encode the webhook event", "type", event.Type, "err", err)
+		return
+	}
+	interval := wd.retryInterval
+	for attempt := 1; ; attempt++ {
+		err = wd.post(hook, event.Type, body)
+		if err == nil {
+			return
+		}
+		if attempt >= wd.maxAttempts {
+			wd.log.Warn("failed to deliver the webhook event", "url", hook.URL, "type", event.Type, "attempts", attempt, "err", err)
+			return
+		}
+		select {
+		case <-time.After(interval):
+		case <-wd.tm.StopChan():
+			return
+		}
+		interval *= 2
+	}
+}
+
+// post POSTs the signed body to the webhook. The response with status other than 2xx is
+// regarded as failed
+func (wd *webhookDispatcher) post(hook Webhook, eventType string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, eventType)
+	if hook.Secret != "" {
+		req.Header.Set(webhookSignatureHeader, webhookSignature(hook.Secret, body))
+	}
+	resp, err := wd.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %v", resp.Status)
+	}
+	return nil
+}
+
+// load loads the webhooks from the persist file. No webhook is added if the file does not exist
+func (wd *webhookDispatcher) load() error {
+	wd.lock.Lock()
+	defer wd.lock.Unlock()
+
+	var hooks []Webhook
+	err := common.LoadDxJSON(webhookMetadata, filepath.Join(wd.persistDir, WebhookFilename), &hooks)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		wd.hooks[hook.ID] = hook
+	}
+	return nil
+}
+
+// save saves the webhooks to the persist file. The caller must hold the lock
+func (wd *webhookDispatcher) save() error {
+	hooks := make([]Webhook, 0, len(wd.hooks))
+	for _, hook := range wd.hooks {
+		hooks = append(hooks, hook)
+	}
+	return common.SaveDxJSON(webhookMetadata, filepath.Join(wd.persistDir, WebhookFilename), hooks)
+}
+
+// subscribes returns whether the webhook subscribes the event type
+func (hook Webhook) subscribes(eventType string) bool {
+	for _, e := range hook.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// newWebhookEvent creates the webhook event with a random id
+func newWebhookEvent(eventType string, data interface{}) WebhookEvent {
+	return WebhookEvent{
+		ID:   randomWebhookID(),
+		Type: eventType,
+		Time: time.Now(),
+		Data: data,
+	}
+}
+
+// webhookSignature returns the hex encoded HMAC-SHA256 of the body with the secret
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// isWebhookEventType returns whether the event type is supported
+func isWebhookEventType(eventType string) bool {
+	for _, e := range webhookEventTypes {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// randomWebhookID creates a random id for the webhook and the webhook event
+func randomWebhookID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// webhookLoop sends the contract renew events and file health events to the webhooks
+func (client *StorageClient) webhookLoop() {
+	if err := client.tm.Add(); err != nil {
+		return
+	}
+	defer client.tm.Done()
+
+	renewCh := make(chan contractmanager.ContractRenewEvent, 16)
+	renewSub := client.contractManager.SubscribeContractRenewEvent(renewCh)
+	defer renewSub.Unsubscribe()
+
+	healthCh := make(chan filesystem.FileHealthEvent, 64)
+	healthSub := client.fileSystem.SubscribeFileHealthEvent(healthCh)
+	defer healthSub.Unsubscribe()
+
+	for {
+		select {
+		case e := <-renewCh:
+			client.webhooks.notify(WebhookContractRenewed, webhookContractData{
+				OldContractID: e.OldContractID.String(),
+				NewContractID: e.NewContractID.String(),
+				HostID:        e.HostID.String(),
+			})
+		case e := <-healthCh:
+			client.webhooks.notifyFileHealth(e)
+		case <-client.tm.StopChan():
+			return
+		}
+	}
+}
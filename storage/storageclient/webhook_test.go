@@ -0,0 +1,177 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common/threadmanager"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageclient/filesystem"
+)
+
+// newTestWebhookDispatcher creates a webhook dispatcher persisting in a temp directory
+func newTestWebhookDispatcher(t *testing.T) (*webhookDispatcher, *threadmanager.ThreadManager) {
+	dir := filepath.Join(os.TempDir(), "storageclient", t.Name())
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	tm := &threadmanager.ThreadManager{}
+	wd := newWebhookDispatcher(dir, tm, log.New())
+	wd.retryInterval = 10 * time.Millisecond
+	return wd, tm
+}
+
+// TestWebhookDispatcher_Deliver test the webhook event is signed, and retried until the
+// webhook responded with success
+func TestWebhookDispatcher_Deliver(t *testing.T) {
+	wd, tm := newTestWebhookDispatcher(t)
+	defer tm.Stop()
+
+	secret := "secret"
+	received := make(chan WebhookEvent, 1)
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if got := r.Header.Get(webhookSignatureHeader); got != webhookSignature(secret, body) {
+			t.Errorf("invalid signature %v", got)
+		}
+		if got := r.Header.Get(webhookEventHeader); got != WebhookUploadComplete {
+			t.Errorf("invalid event header %v", got)
+		}
+		var event WebhookEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Error(err)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	if _, err := wd.add(Webhook{URL: server.URL, Secret: secret, Events: []string{WebhookUploadComplete}}); err != nil {
+		t.Fatal(err)
+	}
+	if wd.subscribed(WebhookDownloadComplete) {
+		t.Fatal("the download complete event should not be subscribed")
+	}
+	wd.notify(WebhookDownloadComplete, webhookFileData{DxPath: "file"})
+	wd.notify(WebhookUploadComplete, webhookFileData{DxPath: "file"})
+
+	select {
+	case event := <-received:
+		if event.Type != WebhookUploadComplete {
+			t.Errorf("expect event %v, got %v", WebhookUploadComplete, event.Type)
+		}
+		if data, ok := event.Data.(map[string]interface{}); !ok || data["dxpath"] != "file" {
+			t.Errorf("unexpected event data %v", event.Data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the webhook event is not delivered")
+	}
+	if attempts != 3 {
+		t.Errorf("expect 3 attempts, got %d", attempts)
+	}
+}
+
+// TestWebhookDispatcher_FileHealth test the file.health.low event is only sent when the health
+// of the file drops across the threshold of the webhook
+func TestWebhookDispatcher_FileHealth(t *testing.T) {
+	wd, tm := newTestWebhookDispatcher(t)
+	defer tm.Stop()
+
+	received := make(chan webhookHealthData, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event struct {
+			Data webhookHealthData `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Error(err)
+		}
+		received <- event.Data
+	}))
+	defer server.Close()
+
+	if _, err := wd.add(Webhook{URL: server.URL, HealthThreshold: 150}); err != nil {
+		t.Fatal(err)
+	}
+	path, _ := storage.NewDxPath("file")
+	wd.notifyFileHealth(filesystem.FileHealthEvent{DxPath: path, PrevHealth: 200, Health: 160})
+	wd.notifyFileHealth(filesystem.FileHealthEvent{DxPath: path, PrevHealth: 140, Health: 120})
+	wd.notifyFileHealth(filesystem.FileHealthEvent{DxPath: path, PrevHealth: 160, Health: 140})
+
+	select {
+	case data := <-received:
+		if data.DxPath != "file" || data.PrevHealth != 160 || data.Health != 140 || data.Threshold != 150 {
+			t.Errorf("unexpected event data %+v", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the webhook event is not delivered")
+	}
+	select {
+	case data := <-received:
+		t.Errorf("unexpected event %+v", data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestWebhookDispatcher_Persist test adding, removing and loading the webhooks
+func TestWebhookDispatcher_Persist(t *testing.T) {
+	wd, tm := newTestWebhookDispatcher(t)
+	defer tm.Stop()
+
+	if _, err := wd.add(Webhook{URL: "ftp://example.com"}); err != errInvalidWebhookURL {
+		t.Fatalf("expect error %v, got %v", errInvalidWebhookURL, err)
+	}
+	if _, err := wd.add(Webhook{URL: "http://example.com", Events: []string{"unknown"}}); err == nil {
+		t.Fatal("the unknown event should be rejected")
+	}
+	id1, err := wd.add(Webhook{URL: "http://example.com/1", Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id2, err := wd.add(Webhook{URL: "http://example.com/2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = wd.remove(id2); err != nil {
+		t.Fatal(err)
+	}
+	if err = wd.remove(id2); err != errWebhookNotFound {
+		t.Fatalf("expect error %v, got %v", errWebhookNotFound, err)
+	}
+
+	loaded := newWebhookDispatcher(wd.persistDir, tm, log.New())
+	if err = loaded.load(); err != nil {
+		t.Fatal(err)
+	}
+	hooks := loaded.webhooks()
+	if len(hooks) != 1 || hooks[0].ID != id1 || hooks[0].URL != "http://example.com/1" {
+		t.Fatalf("unexpected webhooks loaded: %+v", hooks)
+	}
+	if hooks[0].Secret != "" {
+		t.Error("the secret should be hidden")
+	}
+	if len(hooks[0].Events) != len(webhookEventTypes) {
+		t.Errorf("the webhook without events should subscribe all events: %v", hooks[0].Events)
+	}
+	if loaded.hooks[id1].Secret != "secret" {
+		t.Error("the secret should be persisted")
+	}
+}
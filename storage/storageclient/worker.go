@@ -12,12 +12,13 @@ import (
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
+	"github.com/DxChainNetwork/godx/storage/storageerr"
 )
 
 var (
 	// ErrNoContractsWithHost will be used when the client has no contract with host
 	// the worker will be terminated
-	ErrNoContractsWithHost = errors.New("no contract with host which is need to terminate")
+	ErrNoContractsWithHost = storageerr.New(storageerr.CodeContractNotFound, "no contract with host which is need to terminate")
 
 	// ErrUnableRetrieveHostInfo is used when host information cannot be retrieved
 	// worker will be terminated
@@ -42,6 +43,11 @@ type worker struct {
 	// the time that last failure
 	ownedDownloadRecentFailure time.Time
 
+	// How many negotiations with the host failed in a row, and the time of the last failure.
+	// The worker takes no task until the cooldown passed and the host is probed again
+	hostConsecutiveFailures int
+	hostRecentFailure       time.Time
+
 	// Notifications of new download work. Takes priority over uploads.
 	downloadChan chan struct{}
 
@@ -59,6 +65,9 @@ type worker struct {
 	uploadRecentFailure       time.Time     // How recent was the last failure?
 	uploadTerminated          bool          // Have we stopped uploading?
 
+	// the host failed to respond the sector check, the sectors are uploaded without checking
+	sectorCheckDisabled bool
+
 	// Worker will shut down if a signal is sent down this channel.
 	killChan chan struct{}
 	mu       sync.Mutex
@@ -118,26 +127,38 @@ func (w *worker) workLoop() {
 	defer w.killDownloading()
 
 	for {
+		// wait for the cooldown and probe the host before taking any task, so that the
+		// host which failed the negotiations in a row is not hammered by the tasks
+		if w.onHostCooldown() {
+			if !w.waitHostCooldown() {
+				return
+			}
+			if err := w.probeHost(); err == ErrNoContractsWithHost || err == ErrUnableRetrieveHostInfo {
+				break
+			}
+			continue
+		}
+
 		downloadSegment := w.nextDownloadSegment()
 		if downloadSegment != nil {
 			err := w.download(downloadSegment)
-			if err == ErrNoContractsWithHost || err == ErrUnableRetrieveHostInfo {
+			if err == ErrNoContractsWithHost || err == ErrUnableRetrieveHostInfo || err == storage.ErrNegotiationDraining {
 				break
 			}
 
 			if err == ErrContractRenewing {
 				<-time.After(50 * time.Millisecond)
 			}
-			if err != nil {
-				return
-			}
+
+			// a failed download does not terminate the worker. The sectors of the worker
+			// have been handed over to the other workers, and the worker is on cooldown
 			continue
 		}
 
 		segment, sectorIndex := w.nextUploadSegment()
 		if segment != nil {
 			err := w.upload(segment, sectorIndex)
-			if err == ErrNoContractsWithHost || err == ErrUnableRetrieveHostInfo {
+			if err == ErrNoContractsWithHost || err == ErrUnableRetrieveHostInfo || err == storage.ErrNegotiationDraining {
 				break
 			}
 
@@ -145,9 +166,8 @@ func (w *worker) workLoop() {
 			if err == ErrContractRenewing {
 				<-time.After(50 * time.Millisecond)
 			}
-			if err != nil {
-				return
-			}
+
+			// a failed upload does not terminate the worker, the worker is on cooldown instead
 			continue
 		}
 
@@ -157,6 +177,8 @@ func (w *worker) workLoop() {
 			continue
 		case <-w.uploadChan:
 			continue
+		case <-w.client.uploadPause.resumed():
+			continue
 		case <-w.killChan:
 			return
 		case <-w.client.tm.StopChan():
@@ -228,56 +250,109 @@ func (w *worker) checkConnection() (storage.Peer, *storage.HostInfo, error) {
 
 	// set up the connection
 	sp, err := w.client.SetupConnection(hostInfo.EnodeURL)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// start contract revision, if failed, meaning the
 	// renewing is started
 	if ok := sp.TryToRenewOrRevise(); !ok {
-		return nil, nil, errors.New("the contract is currently renewing or revising")
+		sp.Close()
+		return nil, nil, ErrContractRenewing
 	}
 
-	return sp, hostInfo, err
+	return sp, hostInfo, nil
 }
 
-// Actually perform a download task
+// Actually perform a download task. The queued segments that also need sectors from the host
+// are coalesced into the same download request to amortize the revision signing and round trips.
 func (w *worker) download(uds *unfinishedDownloadSegment) error {
-	sp, hostInfo, err := w.checkConnection()
-	defer sp.RevisionOrRenewingDone()
+	// wait for the host to be within its concurrency limit before connecting to it
+	size := MaxDownloadBatchSectors * storage.SectorSize
+	if err := w.client.hostLimiter.acquire(w.hostID, size, w.killChan); err != nil {
+		uds.removeWorker()
+		return err
+	}
+	defer w.client.hostLimiter.release(w.hostID, size)
 
+	sp, hostInfo, err := w.checkConnection()
 	if err != nil {
 		w.client.log.Error("failed to check the connection", "err", err)
+		w.hostFailed(err)
+		// the worker is not able to work on the segment, remove it from the segment
+		// so that the segment will not wait for the worker forever
+		uds.removeWorker()
 		return err
 	}
+	defer sp.Close()
+	defer sp.RevisionOrRenewingDone()
 
 	// check the uds whether can be the worker performed
 	uds = w.processDownloadSegment(uds)
 	if uds == nil {
-		return err
+		return nil
 	}
+	batch := append([]*unfinishedDownloadSegment{uds}, w.nextDownloadBatch(hostInfo)...)
 
 	// whether download success or fail, we should remove the worker at last
-	defer uds.removeWorker()
+	for _, s := range batch {
+		defer s.removeWorker()
+	}
 
 	// for not supporting partial encoding, we need to download the whole sector every time.
-	fetchOffset, fetchLength := 0, storage.SectorSize
-	root := uds.segmentMap[w.hostID.String()].root
+	sections := make([]storage.DownloadRequestSector, len(batch))
+	for i, s := range batch {
+		sections[i] = storage.DownloadRequestSector{
+			MerkleRoot: s.segmentMap[w.hostID.String()].root,
+			Offset:     0,
+			Length:     uint32(storage.SectorSize),
+		}
+	}
 
 	// call rpc request the data from host, if get error, unregister the worker.
-	sectorData, err := w.client.Download(sp, root, uint32(fetchOffset), uint32(fetchLength), hostInfo)
+	start := time.Now()
+	sectorsData, err := w.client.DownloadSections(sp, sections, hostInfo)
 	if err != nil {
 		w.client.log.Error("worker failed to download sector", "error", err)
-		uds.unregisterWorker(w)
+		w.downloadFailed(batch)
+		w.hostFailed(err)
 		return err
 	}
+	w.downloadSucceeded()
+	w.hostSucceeded()
+	w.client.storageHostManager.RecordDownloadThroughput(w.hostID, uint64(len(sections))*storage.SectorSize, time.Since(start))
 
-	// decrypt the sector
-	key := uds.clientFile.CipherKey()
-	decryptedSector, err := key.DecryptInPlace(sectorData)
-	if err != nil {
-		w.client.log.Error("worker failed to decrypt sector", "error", err)
-		uds.unregisterWorker(w)
-		return err
+	for i, s := range batch {
+		w.completeDownloadSector(s, sectorsData[i])
+	}
+	return nil
+}
+
+// nextDownloadBatch pulls the queued segments which can be downloaded along with the segment
+// in progress. The number of sectors in a batch is limited by the host max download batch size
+func (w *worker) nextDownloadBatch(hostInfo *storage.HostInfo) []*unfinishedDownloadSegment {
+	maxSectors := hostInfo.MaxDownloadBatchSize / storage.SectorSize
+	if maxSectors > MaxDownloadBatchSectors {
+		maxSectors = MaxDownloadBatchSectors
 	}
 
+	var batch []*unfinishedDownloadSegment
+	for uint64(len(batch)+1) < maxSectors {
+		uds := w.nextDownloadSegment()
+		if uds == nil {
+			break
+		}
+		if uds = w.processDownloadSegment(uds); uds != nil {
+			batch = append(batch, uds)
+		}
+	}
+	return batch
+}
+
+// completeDownloadSector marks the sector downloaded for the segment as completed. The sector
+// verified by the host proofs is kept encrypted along with its checksum, and is decrypted at
+// the recovery once it is checked against the checksum
+func (w *worker) completeDownloadSector(uds *unfinishedDownloadSegment, sectorData []byte) {
 	// mark the sector as completed
 	sectorIndex := uds.segmentMap[w.hostID.String()].index
 	uds.mu.Lock()
@@ -285,9 +360,10 @@ func (w *worker) download(uds *unfinishedDownloadSegment) error {
 	uds.sectorsRegistered--
 
 	// if the num of sectorsCompleted has not reached the required min sector num,
-	// go on keeping the decrypted sector.
+	// go on keeping the encrypted sector.
 	if uds.sectorsCompleted <= uds.erasureCode.MinSectors() {
-		uds.physicalSegmentData[sectorIndex] = decryptedSector
+		uds.physicalSegmentData[sectorIndex] = sectorData
+		uds.sectorChecksums[sectorIndex] = sectorChecksum(sectorData)
 		w.client.log.Debug("received a sector,but not enough to recover", "sectors_completed", uds.sectorsCompleted)
 	}
 
@@ -298,8 +374,28 @@ func (w *worker) download(uds *unfinishedDownloadSegment) error {
 	}
 
 	uds.mu.Unlock()
+}
 
-	return nil
+// downloadFailed is called when the host returned bad data or timed out. The worker is put on
+// cooldown, and the sectors registered by the worker are released. Since the worker is removed
+// from the segments afterwards, the standby workers holding the redundant sectors will be queued
+// to download in place of the worker.
+func (w *worker) downloadFailed(segments []*unfinishedDownloadSegment) {
+	w.mu.Lock()
+	w.ownedDownloadConsecutiveFailures++
+	w.ownedDownloadRecentFailure = time.Now()
+	w.mu.Unlock()
+
+	for _, uds := range segments {
+		uds.unregisterWorker(w)
+	}
+}
+
+// downloadSucceeded reset the consecutive download failures of the worker
+func (w *worker) downloadSucceeded() {
+	w.mu.Lock()
+	w.ownedDownloadConsecutiveFailures = 0
+	w.mu.Unlock()
 }
 
 // Check the given download segment whether there is work to do, and update its info
@@ -326,7 +422,7 @@ func (w *worker) processDownloadSegment(uds *unfinishedDownloadSegment) *unfinis
 	sectorsInProgress := uds.sectorsRegistered + uds.sectorsCompleted
 	desiredSectorsInProgress := uds.erasureCode.MinSectors() + uds.overdrive
 	workersDesired := sectorsInProgress < desiredSectorsInProgress && !sectorTaken
-	if workersDesired {
+	if workersDesired && !uds.deferToFasterHosts(w.hostID.String(), desiredSectorsInProgress-sectorsInProgress) {
 		uds.sectorsRegistered++
 		uds.sectorUsage[sectorData.index] = true
 		return uds
@@ -339,6 +435,12 @@ func (w *worker) processDownloadSegment(uds *unfinishedDownloadSegment) *unfinis
 
 // Return true if the worker is on cooldown for download failure.
 func (w *worker) onDownloadCooldown() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if time.Now().Before(w.hostCooldownUntil()) {
+		return true
+	}
 	requiredCooldown := DownloadFailureCooldown
 	for i := 0; i < w.ownedDownloadConsecutiveFailures && i < MaxConsecutivePenalty; i++ {
 		requiredCooldown *= 2
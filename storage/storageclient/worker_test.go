@@ -0,0 +1,183 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file
+
+package storageclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
+)
+
+func TestWorker_downloadFailed(t *testing.T) {
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failedWorker := &worker{hostID: enode.ID{1}, downloadChan: make(chan struct{}, 1)}
+	standbyWorker := &worker{hostID: enode.ID{2}, downloadChan: make(chan struct{}, 1)}
+
+	// the failed worker is downloading the sector 0, and the standby worker holds the sector 1
+	uds := &unfinishedDownloadSegment{
+		erasureCode: ec,
+		segmentMap: map[string]downloadSectorInfo{
+			failedWorker.hostID.String():  {index: 0},
+			standbyWorker.hostID.String(): {index: 1},
+		},
+		completedSectors:  make([]bool, 2),
+		sectorUsage:       []bool{true, false},
+		sectorsRegistered: 1,
+		workersRemaining:  2,
+		workersStandby:    []*worker{standbyWorker},
+		download:          &download{},
+	}
+
+	failedWorker.downloadFailed([]*unfinishedDownloadSegment{uds})
+	uds.removeWorker()
+
+	if !failedWorker.onDownloadCooldown() {
+		t.Errorf("failed worker should be on download cooldown")
+	}
+	if uds.sectorUsage[0] || uds.sectorsRegistered != 0 {
+		t.Errorf("sector of the failed worker should be released")
+	}
+	if len(standbyWorker.downloadSegments) != 1 || standbyWorker.downloadSegments[0] != uds {
+		t.Fatalf("segment should be rescheduled to the standby worker")
+	}
+
+	// the standby worker should take the redundant sector
+	if standbyWorker.processDownloadSegment(uds) != uds {
+		t.Fatalf("standby worker should download the segment")
+	}
+	if !uds.sectorUsage[1] || uds.sectorsRegistered != 1 {
+		t.Errorf("sector of the standby worker should be registered")
+	}
+
+	failedWorker.downloadSucceeded()
+	if failedWorker.ownedDownloadConsecutiveFailures != 0 {
+		t.Errorf("consecutive failures should be reset after a successful download")
+	}
+}
+
+func TestWorker_hostCooldown(t *testing.T) {
+	w := &worker{hostID: enode.ID{1}}
+	if w.onHostCooldown() {
+		t.Fatalf("worker without failure should not be on cooldown")
+	}
+
+	// the cooldown doubles for each consecutive failure, up to MaxConsecutivePenalty times
+	now := time.Now()
+	w.hostRecentFailure = now
+	tests := []struct {
+		failures int
+		cooldown time.Duration
+	}{
+		{1, HostFailureCooldown},
+		{2, 2 * HostFailureCooldown},
+		{4, 8 * HostFailureCooldown},
+		{MaxConsecutivePenalty + 5, HostFailureCooldown << uint(MaxConsecutivePenalty-1)},
+	}
+	for _, test := range tests {
+		w.hostConsecutiveFailures = test.failures
+		if got := w.hostCooldownUntil().Sub(now); got != test.cooldown {
+			t.Errorf("cooldown of %d failures not expected. Got %v, Expect %v", test.failures, got, test.cooldown)
+		}
+	}
+	if !w.onHostCooldown() || !w.onDownloadCooldown() || !w.onUploadCoolDown() {
+		t.Errorf("worker should be on cooldown after the negotiation failures")
+	}
+
+	// the worker resumes after the cooldown passed and the host is probed successfully
+	w.hostRecentFailure = now.Add(-time.Hour * 24)
+	if w.onHostCooldown() {
+		t.Errorf("worker should not be on cooldown after the cooldown passed")
+	}
+	w.hostSucceeded()
+	if w.hostConsecutiveFailures != 0 || !w.hostCooldownUntil().IsZero() {
+		t.Errorf("consecutive failures should be reset after the host succeeded")
+	}
+}
+
+func TestWorker_releaseQueuedTasks(t *testing.T) {
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cooldownWorker := &worker{hostID: enode.ID{1}, downloadChan: make(chan struct{}, 1)}
+	standbyWorker := &worker{hostID: enode.ID{2}, downloadChan: make(chan struct{}, 1)}
+	uds := &unfinishedDownloadSegment{
+		erasureCode: ec,
+		segmentMap: map[string]downloadSectorInfo{
+			cooldownWorker.hostID.String(): {index: 0},
+			standbyWorker.hostID.String():  {index: 1},
+		},
+		completedSectors: make([]bool, 2),
+		sectorUsage:      make([]bool, 2),
+		workersRemaining: 2,
+		workersStandby:   []*worker{standbyWorker},
+		download:         &download{},
+	}
+	cooldownWorker.queueDownloadSegment(uds)
+	cooldownWorker.releaseQueuedTasks()
+
+	if len(cooldownWorker.downloadSegments) != 0 {
+		t.Errorf("queued download segments should be released")
+	}
+	if uds.workersRemaining != 1 {
+		t.Errorf("worker should be removed from the released segment. Got %v workers remaining", uds.workersRemaining)
+	}
+	if len(standbyWorker.downloadSegments) != 1 || standbyWorker.downloadSegments[0] != uds {
+		t.Errorf("released segment should be handed over to the standby worker")
+	}
+}
+
+func TestWorker_deferToFasterHosts(t *testing.T) {
+	ec, err := erasurecode.New(erasurecode.ECTypeStandard, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slowWorker := &worker{hostID: enode.ID{1}, downloadChan: make(chan struct{}, 1)}
+	fastWorker := &worker{hostID: enode.ID{2}, downloadChan: make(chan struct{}, 1)}
+	uds := &unfinishedDownloadSegment{
+		erasureCode: ec,
+		segmentMap: map[string]downloadSectorInfo{
+			slowWorker.hostID.String(): {index: 0},
+			fastWorker.hostID.String(): {index: 1},
+		},
+		hostThroughput: map[string]float64{
+			slowWorker.hostID.String(): 1 << 20,
+			fastWorker.hostID.String(): 10 << 20,
+		},
+		completedSectors: make([]bool, 2),
+		sectorUsage:      make([]bool, 2),
+		workersRemaining: 2,
+		download:         &download{},
+	}
+
+	// the slow worker defers the sector to the fast worker
+	if slowWorker.processDownloadSegment(uds) != nil {
+		t.Fatal("slow worker should defer to the fast worker")
+	}
+	if len(uds.workersStandby) != 1 || uds.workersStandby[0] != slowWorker {
+		t.Fatal("slow worker should be put on standby")
+	}
+	if fastWorker.processDownloadSegment(uds) != uds {
+		t.Fatal("fast worker should download the segment")
+	}
+
+	// the slow worker takes the sector once the fast worker failed
+	fastWorker.downloadFailed([]*unfinishedDownloadSegment{uds})
+	uds.removeWorker()
+	if len(slowWorker.downloadSegments) != 1 || slowWorker.downloadSegments[0] != uds {
+		t.Fatal("segment should be rescheduled to the slow worker")
+	}
+	if slowWorker.processDownloadSegment(uds) != uds {
+		t.Fatal("slow worker should not defer twice")
+	}
+}
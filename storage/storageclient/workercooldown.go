@@ -0,0 +1,164 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageclient
+
+import (
+	"sort"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// WorkerInfo is the information of a worker, including the cooldown state of the worker
+type WorkerInfo struct {
+	ContractID          string    `json:"contractID"`
+	HostID              string    `json:"hostID"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	RecentFailure       time.Time `json:"recentFailure"`
+	OnCooldown          bool      `json:"onCooldown"`
+	CooldownUntil       time.Time `json:"cooldownUntil"`
+	PendingDownloads    int       `json:"pendingDownloads"`
+	PendingUploads      int       `json:"pendingUploads"`
+}
+
+// hostFailed records a failed negotiation with the host, which doubles the cooldown of the
+// worker. The failures not caused by the host, or caused by the client being offline, are
+// not recorded
+func (w *worker) hostFailed(err error) {
+	if err == ErrContractRenewing || err == ErrNoContractsWithHost || err == ErrUnableRetrieveHostInfo || err == storage.ErrNegotiationDraining {
+		return
+	}
+	if !w.client.Online() {
+		return
+	}
+	w.mu.Lock()
+	w.hostConsecutiveFailures++
+	w.hostRecentFailure = time.Now()
+	w.mu.Unlock()
+}
+
+// hostSucceeded resets the consecutive negotiation failures of the worker
+func (w *worker) hostSucceeded() {
+	w.mu.Lock()
+	w.hostConsecutiveFailures = 0
+	w.mu.Unlock()
+}
+
+// hostCooldownUntil returns the time until which the worker is on cooldown for the consecutive
+// negotiation failures. The cooldown doubles for each failure, up to MaxConsecutivePenalty times.
+// The caller must hold the worker lock
+func (w *worker) hostCooldownUntil() time.Time {
+	if w.hostConsecutiveFailures == 0 {
+		return time.Time{}
+	}
+	requiredCooldown := HostFailureCooldown
+	for i := 1; i < w.hostConsecutiveFailures && i < MaxConsecutivePenalty; i++ {
+		requiredCooldown *= 2
+	}
+	return w.hostRecentFailure.Add(requiredCooldown)
+}
+
+// onHostCooldown returns true if the worker is on cooldown for the consecutive negotiation failures
+func (w *worker) onHostCooldown() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return time.Now().Before(w.hostCooldownUntil())
+}
+
+// waitHostCooldown blocks until the cooldown of the worker passed. The tasks queued to the worker
+// are released while waiting, so that they are handed over to the other workers instead of waiting
+// for the worker on cooldown. False is returned if the worker is killed while waiting
+func (w *worker) waitHostCooldown() bool {
+	for {
+		w.releaseQueuedTasks()
+
+		w.mu.Lock()
+		remaining := time.Until(w.hostCooldownUntil())
+		w.mu.Unlock()
+		if remaining <= 0 {
+			return true
+		}
+
+		select {
+		case <-time.After(remaining):
+		case <-w.downloadChan:
+		case <-w.uploadChan:
+		case <-w.killChan:
+			return false
+		case <-w.client.tm.StopChan():
+			return false
+		}
+	}
+}
+
+// releaseQueuedTasks removes the worker from all of the download and upload segments queued
+func (w *worker) releaseQueuedTasks() {
+	w.downloadMu.Lock()
+	segments := w.downloadSegments
+	w.downloadSegments = nil
+	w.downloadMu.Unlock()
+
+	for _, uds := range segments {
+		uds.removeWorker()
+	}
+	w.dropUploadSegments()
+}
+
+// probeHost probes the host after the cooldown by setting up the connection to the host. The
+// worker resumes taking the tasks if the probe succeeded, otherwise the cooldown is doubled
+func (w *worker) probeHost() error {
+	sp, _, err := w.checkConnection()
+	if err != nil {
+		w.client.log.Warn("worker failed to probe the host", "hostID", w.hostID.String(), "err", err)
+		w.hostFailed(err)
+		return err
+	}
+	sp.RevisionOrRenewingDone()
+	sp.Close()
+
+	w.hostSucceeded()
+	w.client.log.Info("worker resumed after probing the host", "hostID", w.hostID.String())
+	return nil
+}
+
+// info returns the information of the worker
+func (w *worker) info() WorkerInfo {
+	w.downloadMu.Lock()
+	pendingDownloads := len(w.downloadSegments)
+	w.downloadMu.Unlock()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	cooldownUntil := w.hostCooldownUntil()
+	return WorkerInfo{
+		ContractID:          w.contract.ID.String(),
+		HostID:              w.hostID.String(),
+		ConsecutiveFailures: w.hostConsecutiveFailures,
+		RecentFailure:       w.hostRecentFailure,
+		OnCooldown:          time.Now().Before(cooldownUntil),
+		CooldownUntil:       cooldownUntil,
+		PendingDownloads:    pendingDownloads,
+		PendingUploads:      len(w.pendingSegments),
+	}
+}
+
+// Workers returns the information of the workers in the worker pool, sorted by the host id
+func (client *StorageClient) Workers() []WorkerInfo {
+	client.lock.Lock()
+	workers := make([]*worker, 0, len(client.workerPool))
+	for _, w := range client.workerPool {
+		workers = append(workers, w)
+	}
+	client.lock.Unlock()
+
+	infos := make([]WorkerInfo, 0, len(workers))
+	for _, w := range workers {
+		infos = append(infos, w.info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].HostID < infos[j].HostID
+	})
+	return infos
+}
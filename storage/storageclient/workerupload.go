@@ -7,6 +7,9 @@ package storageclient
 import (
 	"time"
 
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/p2p/enode"
 	"github.com/DxChainNetwork/godx/storage"
 )
 
@@ -47,7 +50,12 @@ func (w *worker) killUploading() {
 }
 
 // nextUploadSegment pull the next segment task from the worker's upload task list
+// No segment is returned while the uploads are paused, so the sectors queued wait for the resume
 func (w *worker) nextUploadSegment() (nextSegment *unfinishedUploadSegment, sectorIndex uint64) {
+	if paused, _ := w.client.UploadsPaused(); paused {
+		return nil, 0
+	}
+
 	// Loop through the unprocessed segments and find some work to do
 	for {
 		// Pull a segment off of the unprocessed segments stack
@@ -81,7 +89,7 @@ func (w *worker) isReady(uc *unfinishedUploadSegment) bool {
 		uploadAbility = true
 	}
 	if meta, ok := w.client.contractManager.RetrieveActiveContract(w.contract.ID); ok {
-		uploadAbility = meta.Status.UploadAbility
+		uploadAbility = meta.Status.UploadAbility && meta.MirrorOf == (enode.ID{})
 	}
 
 	onCoolDown := w.onUploadCoolDown()
@@ -104,34 +112,136 @@ func (w *worker) signalUploadChan(uc *unfinishedUploadSegment) {
 	}
 }
 
-// upload will perform some upload work
+// batchSector is a sector of the upload segment, which is uploaded in the upload batch
+type batchSector struct {
+	uc          *unfinishedUploadSegment
+	sectorIndex uint64
+}
+
+// upload will perform some upload work. The following sectors assigned to the worker are
+// uploaded along with the sector in the same upload requests, up to the upload batch size
 func (w *worker) upload(uc *unfinishedUploadSegment, sectorIndex uint64) error {
-	sp, hostInfo, err := w.checkConnection()
-	defer sp.RevisionOrRenewingDone()
+	// wait for the host to be within its concurrency limit before connecting to it
+	budget := w.client.uploadBatchSize()
+	if err := w.client.hostLimiter.acquire(w.hostID, budget, w.killChan); err != nil {
+		w.uploadFailed(uc, sectorIndex)
+		return err
+	}
+	defer w.client.hostLimiter.release(w.hostID, budget)
 
+	sp, hostInfo, err := w.checkConnection()
 	if err != nil {
 		w.client.log.Error("failed to check the connection", "err", err)
+		w.hostFailed(err)
 		w.uploadFailed(uc, sectorIndex)
 		return err
 	}
+	defer sp.Close()
+	defer sp.RevisionOrRenewingDone()
+
+	sectors := w.batchSectors(uc, sectorIndex, budget)
+	batch := newUploadBatch(budget, func(actions []storage.UploadAction) error {
+		return w.client.Write(sp, actions, hostInfo)
+	})
+
+	// upload sectors to host, unless the host has already stored the sector under the contract
+	roots := make([]common.Hash, len(sectors))
+	uploaded := make([]bool, len(sectors))
+	for i, sector := range sectors {
+		i := i
+		data := sector.uc.physicalSegmentData[sector.sectorIndex]
+		roots[i] = merkle.CachedSha256MerkleTreeRoot(data)
+		if w.sectorStored(hostInfo, roots[i]) {
+			uploaded[i] = true
+			continue
+		}
+		if err = batch.Append(data, func() { uploaded[i] = true }); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = batch.Flush()
+	}
+
+	// the sectors uploaded, including the ones the host claimed already stored, are not
+	// added to the files until the host proves storing them, and are uploaded again by
+	// the other workers if the verification failed
+	if err == nil && w.client.verifyUploads() {
+		if err = w.verifyUploadedSectors(sp, hostInfo, roots, uploaded); err != nil {
+			for i := range uploaded {
+				uploaded[i] = false
+			}
+		}
+	}
 
-	// upload segment to host
-	root, err := w.client.Append(sp, uc.physicalSegmentData[sectorIndex], hostInfo)
 	if err != nil {
 		w.client.log.Error("Worker failed to upload", "err", err)
-		w.uploadFailed(uc, sectorIndex)
-		return err
+		w.hostFailed(err)
+		var failed []batchSector
+		for i, sector := range sectors {
+			if !uploaded[i] {
+				failed = append(failed, sector)
+			}
+		}
+		w.uploadBatchFailed(failed)
+	} else {
+		w.mu.Lock()
+		w.uploadConsecutiveFailures = 0
+		w.mu.Unlock()
+		w.hostSucceeded()
 	}
-	w.mu.Lock()
-	w.uploadConsecutiveFailures = 0
-	w.mu.Unlock()
+
+	// the sectors uploaded are mirrored before the memory of the sectors is released
+	w.mirrorSectors(sectors, roots, uploaded)
+
+	// the sectors uploaded before the failure are still added to the files
+	for i, sector := range sectors {
+		if !uploaded[i] {
+			continue
+		}
+		if addErr := w.sectorUploaded(sector.uc, sector.sectorIndex, roots[i]); addErr != nil && err == nil {
+			err = addErr
+		}
+	}
+	return err
+}
+
+// batchSectors takes the following sectors assigned to the worker, as long as the sectors
+// fit into the upload batch
+func (w *worker) batchSectors(uc *unfinishedUploadSegment, sectorIndex uint64, budget uint64) []batchSector {
+	sectors := []batchSector{{uc: uc, sectorIndex: sectorIndex}}
+	for uint64(len(sectors)+1)*storage.SectorSize <= budget && len(sectors) < storage.MaxUploadActions {
+		next, index := w.nextUploadSegment()
+		if next == nil {
+			break
+		}
+		sectors = append(sectors, batchSector{uc: next, sectorIndex: index})
+	}
+	return sectors
+}
+
+// sectorUploaded adds the sector uploaded to the file, and releases the memory of the sector
+func (w *worker) sectorUploaded(uc *unfinishedUploadSegment, sectorIndex uint64, root common.Hash) error {
 	// Add sector to storage clientFile
-	err = uc.fileEntry.AddSector(w.contract.EnodeID, root, int(uc.index), int(sectorIndex))
+	notifyUpload := w.client.webhooks.subscribed(WebhookUploadComplete)
+	var prevProgress float64
+	if notifyUpload {
+		prevProgress = uc.fileEntry.UploadProgress()
+	}
+	err := uc.fileEntry.AddSector(w.contract.EnodeID, root, int(uc.index), int(sectorIndex))
 	if err != nil {
 		w.client.log.Error("Worker failed to add new sector in dxfile", "err", err)
 		w.uploadFailed(uc, sectorIndex)
 		return err
 	}
+	// the file is fully uploaded by the sector
+	if notifyUpload && prevProgress < 100 && uc.fileEntry.UploadProgress() >= 100 {
+		w.client.webhooks.notify(WebhookUploadComplete, webhookFileData{DxPath: uc.fileEntry.DxPath().Path})
+	}
+	w.client.contractManager.AddFileSegment(w.contract.ID, uc.fileEntry.DxPath(), uc.index)
+	if int(sectorIndex) < len(uc.dedupKeys) && uc.dedupKeys[sectorIndex] != (common.Hash{}) {
+		w.client.dedupIndex.add(uc.dedupKeys[sectorIndex], w.contract.EnodeID, root)
+	}
 	// Upload is complete. Update the state of the Segment and the storage client's memory
 	// available to reflect the completed upload.
 	uc.mu.Lock()
@@ -147,8 +257,45 @@ func (w *worker) upload(uc *unfinishedUploadSegment, sectorIndex uint64) error {
 	return nil
 }
 
-// onUploadCoolDown returns true if the worker is on coolDown from failed uploads
+// sectorStored checks whether the host has already stored the sector under the contract in a
+// separate session. If the host failed to respond, e.g. the host does not support the sector
+// check, the following sectors are uploaded without checking
+func (w *worker) sectorStored(hostInfo *storage.HostInfo, root common.Hash) bool {
+	w.mu.Lock()
+	disabled := w.sectorCheckDisabled
+	w.mu.Unlock()
+	if disabled {
+		return false
+	}
+
+	sp, err := w.client.SetupConnection(hostInfo.EnodeURL)
+	if err != nil {
+		return false
+	}
+	defer sp.Close()
+
+	stored, err := w.client.SectorStored(sp, root, hostInfo)
+	if err == storage.ErrHostBusyHandleReq {
+		return false
+	}
+	if err != nil {
+		w.client.log.Debug("sector check failed, upload the sectors without checking", "hostID", w.hostID.String(), "err", err)
+		w.mu.Lock()
+		w.sectorCheckDisabled = true
+		w.mu.Unlock()
+		return false
+	}
+	if stored {
+		w.client.log.Debug("sector is already stored by the host", "hostID", w.hostID.String(), "root", root)
+	}
+	return stored
+}
+
+// onUploadCoolDown returns true if the worker is on coolDown from failed uploads or failed negotiations
 func (w *worker) onUploadCoolDown() bool {
+	if time.Now().Before(w.hostCooldownUntil()) {
+		return true
+	}
 	requiredCoolDown := UploadFailureCoolDown
 	for i := 0; i < w.uploadConsecutiveFailures && i < MaxConsecutivePenalty; i++ {
 		requiredCoolDown *= 2
@@ -161,7 +308,7 @@ func (w *worker) preProcessUploadSegment(uc *unfinishedUploadSegment) (*unfinish
 	// Determine the usability value of this worker
 	uploadAbility := false
 	if meta, ok := w.client.contractManager.RetrieveActiveContract(w.contract.ID); ok {
-		uploadAbility = meta.Status.UploadAbility
+		uploadAbility = meta.Status.UploadAbility && meta.MirrorOf == (enode.ID{})
 	}
 
 	w.mu.Lock()
@@ -218,6 +365,12 @@ func (w *worker) preProcessUploadSegment(uc *unfinishedUploadSegment) (*unfinish
 
 // uploadFailed is called if a worker failed to upload part of an unfinished segment
 func (w *worker) uploadFailed(uc *unfinishedUploadSegment, sectorIndex uint64) {
+	w.uploadBatchFailed([]batchSector{{uc: uc, sectorIndex: sectorIndex}})
+}
+
+// uploadBatchFailed is called if a worker failed to upload the sectors of an upload batch.
+// The failure is counted once for the whole batch
+func (w *worker) uploadBatchFailed(sectors []batchSector) {
 	// Mark the failure in the worker if the gateway says we are online. It's
 	// not the worker's fault if we are offline
 	if w.client.Online() {
@@ -227,15 +380,17 @@ func (w *worker) uploadFailed(uc *unfinishedUploadSegment, sectorIndex uint64) {
 		w.mu.Unlock()
 	}
 
-	// Unregister the sector from the segment and hunt for a replacement
-	uc.mu.Lock()
-	uc.workersRemain--
-	uc.sectorsUploadingNum--
-	uc.sectorSlotsStatus[sectorIndex] = false
-	uc.mu.Unlock()
+	for _, sector := range sectors {
+		// Unregister the sector from the segment and hunt for a replacement
+		sector.uc.mu.Lock()
+		sector.uc.workersRemain--
+		sector.uc.sectorsUploadingNum--
+		sector.uc.sectorSlotsStatus[sector.sectorIndex] = false
+		sector.uc.mu.Unlock()
 
-	// Clean up this segment, we may notify backup workers of segment to help upload
-	w.client.cleanupUploadSegment(uc)
+		// Clean up this segment, we may notify backup workers of segment to help upload
+		w.client.cleanupUploadSegment(sector.uc)
+	}
 
 	// Because the worker is now on cool down, drop all other remaining segments
 	w.dropUploadSegments()
@@ -0,0 +1,141 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+// Package storageerr defines the typed errors of the storage domain. Each error carries
+// a Code, which is kept as the error code when the error is returned through the RPC,
+// so that callers can programmatically distinguish the failure reasons, for example
+// insufficient funds from host offline from invalid proof
+package storageerr
+
+import "fmt"
+
+// Code is the code of the storage error. The codes are in the range reserved for
+// the storage domain in the RPC error codes
+type Code int
+
+// Storage error codes
+const (
+	CodeUnknown Code = -33000 - iota
+	CodeInsufficientFunds
+	CodeHostOffline
+	CodeInvalidProof
+	CodeContractNotFound
+	CodeHostBusy
+	CodeNegotiationFailed
+	CodeCommitFailed
+	CodeHostConfigChanged
+	CodeLimitExceeded
+	CodeInvalidContract
+	CodeInvalidRevision
+	CodeHostFull
+)
+
+var codeNames = map[Code]string{
+	CodeUnknown:           "unknown",
+	CodeInsufficientFunds: "insufficient funds",
+	CodeHostOffline:       "host offline",
+	CodeInvalidProof:      "invalid proof",
+	CodeContractNotFound:  "contract not found",
+	CodeHostBusy:          "host busy",
+	CodeNegotiationFailed: "negotiation failed",
+	CodeCommitFailed:      "commit failed",
+	CodeHostConfigChanged: "host config changed",
+	CodeLimitExceeded:     "limit exceeded",
+	CodeInvalidContract:   "invalid contract",
+	CodeInvalidRevision:   "invalid revision",
+	CodeHostFull:          "host full",
+}
+
+// String returns the name of the code
+func (c Code) String() string {
+	if name, exist := codeNames[c]; exist {
+		return name
+	}
+	return codeNames[CodeUnknown]
+}
+
+// valid returns whether the code is a defined storage error code
+func (c Code) valid() bool {
+	_, exist := codeNames[c]
+	return exist
+}
+
+// Error is the storage error with the code. The pointer to Error is used as the error,
+// so that the error defined as package level variable could be compared directly
+type Error struct {
+	code  Code
+	msg   string
+	cause error
+}
+
+// New creates a storage error with the code and the message
+func New(code Code, msg string) *Error {
+	return &Error{
+		code: code,
+		msg:  msg,
+	}
+}
+
+// Wrap wraps the cause error into the storage error with the code. If the cause is
+// nil, nil is returned
+func Wrap(code Code, cause error) error {
+	if cause == nil {
+		return nil
+	}
+	return &Error{
+		code:  code,
+		msg:   cause.Error(),
+		cause: cause,
+	}
+}
+
+// Errorf creates a storage error with the code and the formatted message
+func Errorf(code Code, format string, args ...interface{}) error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Error returns the message of the error
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// ErrorCode returns the code of the error as int, which implements the rpc.Error
+// interface so that the code is returned to the RPC caller
+func (e *Error) ErrorCode() int {
+	return int(e.code)
+}
+
+// Code returns the code of the error
+func (e *Error) Code() Code {
+	return e.code
+}
+
+// Cause returns the error wrapped by the storage error, nil if not wrapped
+func (e *Error) Cause() error {
+	return e.cause
+}
+
+// CodeOf returns the code of the error. Besides the storage error, any error having
+// the ErrorCode method returning a storage error code is recognized. Otherwise the
+// wrapped errors are looked up through the Cause method
+func CodeOf(err error) Code {
+	for err != nil {
+		if coder, ok := err.(interface{ ErrorCode() int }); ok {
+			if code := Code(coder.ErrorCode()); code.valid() {
+				return code
+			}
+		}
+		causer, ok := err.(interface{ Cause() error })
+		if !ok {
+			break
+		}
+		err = causer.Cause()
+	}
+	return CodeUnknown
+}
+
+// Is returns whether the error is a storage error with the code
+func Is(err error, code Code) bool {
+	return err != nil && CodeOf(err) == code
+}
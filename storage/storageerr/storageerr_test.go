@@ -0,0 +1,70 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storageerr
+
+import (
+	"errors"
+	"testing"
+)
+
+// codedError is the error carrying the error code other than the storage error
+type codedError struct {
+	code int
+}
+
+func (e codedError) Error() string  { return "coded error" }
+func (e codedError) ErrorCode() int { return e.code }
+
+// TestCodeOf test the code is returned for the storage error, the wrapped storage error,
+// and the errors having the ErrorCode method
+func TestCodeOf(t *testing.T) {
+	errFunds := New(CodeInsufficientFunds, "insufficient funds")
+	tests := []struct {
+		err  error
+		code Code
+	}{
+		{nil, CodeUnknown},
+		{errors.New("plain error"), CodeUnknown},
+		{errFunds, CodeInsufficientFunds},
+		{Errorf(CodeContractNotFound, "contract %v not found", 1), CodeContractNotFound},
+		{Wrap(CodeHostOffline, errors.New("dial failed")), CodeHostOffline},
+		{Wrap(CodeHostOffline, errFunds), CodeHostOffline},
+		{codedError{int(CodeInvalidProof)}, CodeInvalidProof},
+		{codedError{-32000}, CodeUnknown},
+	}
+	for i, test := range tests {
+		if code := CodeOf(test.err); code != test.code {
+			t.Errorf("test %d: expect code %v, got %v", i, test.code, code)
+		}
+	}
+	if !Is(errFunds, CodeInsufficientFunds) || Is(errFunds, CodeHostOffline) {
+		t.Error("the error code is not matched correctly")
+	}
+	if Is(nil, CodeUnknown) {
+		t.Error("nil error should not match any code")
+	}
+}
+
+// TestWrap test the wrapped error keeps the message and the cause
+func TestWrap(t *testing.T) {
+	if Wrap(CodeHostOffline, nil) != nil {
+		t.Fatal("wrapping nil error should return nil")
+	}
+	cause := errors.New("dial failed")
+	err := Wrap(CodeHostOffline, cause)
+	if err.Error() != cause.Error() {
+		t.Errorf("expect message %v, got %v", cause.Error(), err.Error())
+	}
+	e, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("expect storage error, got %T", err)
+	}
+	if e.Cause() != cause {
+		t.Errorf("expect cause %v, got %v", cause, e.Cause())
+	}
+	if e.ErrorCode() != int(CodeHostOffline) {
+		t.Errorf("expect error code %v, got %v", int(CodeHostOffline), e.ErrorCode())
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/event"
 	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rpc"
 )
 
@@ -21,6 +22,7 @@ type HostBackend interface {
 	GetBlockByHash(blockHash common.Hash) (*types.Block, error)
 	GetBlockByNumber(number uint64) (*types.Block, error)
 	GetBlockChain() *core.BlockChain
+	ChainConfig() *params.ChainConfig
 	AccountManager() *accounts.Manager
 	SetStatic(node *enode.Node)
 	CheckAndUpdateConnection(peerNode *enode.Node)
@@ -0,0 +1,264 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/unit"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// The types of the alerts sent to the host operator
+const (
+	AlertProofFailed     = "proof.failed"
+	AlertProofAtRisk     = "proof.risk"
+	AlertDepositAtRisk   = "deposit.risk"
+	AlertLowFreeSpace    = "space.low"
+	AlertLowProofBalance = "balance.low"
+	AlertLowFeeReserve   = "reserve.low"
+	AlertHostFull        = "space.full"
+)
+
+type (
+	// HostAlert is the alert passed to the alert command and POSTed to the alert url
+	HostAlert struct {
+		Type        string    `json:"type"`
+		Time        time.Time `json:"time"`
+		BlockHeight uint64    `json:"blockHeight"`
+		Message     string    `json:"message"`
+	}
+
+	// hostAlerts records the alerts of the risky conditions currently raised, so that the
+	// alert is sent once when the condition arises, instead of on every block
+	hostAlerts struct {
+		raised map[string]bool
+		lock   sync.Mutex
+	}
+
+	// alertStatus is the status of the host checked for the alerts
+	alertStatus struct {
+		riskedDeposit common.BigInt
+		freeSpace     uint64
+		lowSpace      storage.HostLowSpaceConfig
+
+		// balances and proofFees are the balance of each address sending the storage proofs,
+		// and the fee to send the upcoming storage proofs from the address
+		balances  map[common.Address]common.BigInt
+		proofFees map[common.Address]common.BigInt
+
+		// reserve is the fee reserve config, and reserveBalance is the balance of the reserve
+		reserve        storage.HostFeeReserveConfig
+		reserveBalance common.BigInt
+	}
+)
+
+// update raises or clears the alert of the condition, and returns whether the alert is
+// newly raised
+func (ha *hostAlerts) update(alertType string, raised bool) bool {
+	ha.lock.Lock()
+	defer ha.lock.Unlock()
+
+	if ha.raised == nil {
+		ha.raised = make(map[string]bool)
+	}
+	prev := ha.raised[alertType]
+	ha.raised[alertType] = raised
+	return raised && !prev
+}
+
+// checkAlerts checks the risky conditions of the host, and sends the alerts of the conditions
+// newly arisen. It is called after the block height change is handled
+func (h *StorageHost) checkAlerts() {
+	h.lock.RLock()
+	config := h.config.Alert
+	height := h.blockHeight
+	h.lock.RUnlock()
+	if config.Command == "" && config.URL == "" {
+		return
+	}
+
+	status, err := h.alertStatus(height)
+	if err != nil {
+		h.log.Warn("failed to check the host alerts", "err", err)
+		return
+	}
+	for _, alert := range riskyConditions(config, status) {
+		if h.alerts.update(alert.Type, alert.Message != "") {
+			h.dispatchAlert(config, height, alert.Type, alert.Message)
+		}
+	}
+}
+
+// alertStatus collects the status of the host checked for the alerts
+func (h *StorageHost) alertStatus(height uint64) (alertStatus, error) {
+	stateDB, err := h.ethBackend.GetBlockChain().State()
+	if err != nil {
+		return alertStatus{}, fmt.Errorf("failed to get the state db: %v", err)
+	}
+
+	h.lock.RLock()
+	status := alertStatus{
+		riskedDeposit: h.financialMetrics.RiskedStorageDeposit,
+		balances:      make(map[common.Address]common.BigInt),
+		proofFees:     make(map[common.Address]common.BigInt),
+		reserve:       h.config.FeeReserve,
+		lowSpace:      h.config.LowSpace,
+	}
+	sos := h.storageResponsibilities()
+	h.lock.RUnlock()
+	status.freeSpace = h.freeSpace()
+	if status.reserve.Address != (common.Address{}) {
+		status.reserveBalance = common.PtrBigInt(stateDB.GetBalance(status.reserve.Address))
+	}
+
+	// the storage proofs of the unresolved storage responsibilities expiring within the
+	// alertProofHorizon are to be sent from the fee reserve if configured, otherwise from the
+	// host address of the contract
+	for _, so := range sos {
+		if so.ResponsibilityStatus != responsibilityUnresolved || so.StorageProofConfirmed || len(so.SectorRoots) == 0 {
+			continue
+		}
+		if so.expiration() > height+alertProofHorizon {
+			continue
+		}
+		from := so.OriginStorageContract.ValidProofOutputs[1].Address
+		if status.reserve.Address != (common.Address{}) {
+			from = status.reserve.Address
+		}
+		status.proofFees[from] = status.proofFees[from].Add(alertProofTxFee)
+		if _, exists := status.balances[from]; !exists {
+			status.balances[from] = common.PtrBigInt(stateDB.GetBalance(from))
+		}
+	}
+	return status, nil
+}
+
+// riskyConditions returns an alert for each risky condition checked. The message of the alert
+// is empty if the condition does not arise
+func riskyConditions(config storage.HostAlertConfig, status alertStatus) []HostAlert {
+	var depositMsg, spaceMsg, balanceMsg, reserveMsg, fullMsg string
+	if config.DepositThreshold.Sign() > 0 && status.riskedDeposit.Cmp(config.DepositThreshold) > 0 {
+		depositMsg = fmt.Sprintf("risked storage deposit %v exceeds the threshold %v",
+			unit.FormatCurrency(status.riskedDeposit), unit.FormatCurrency(config.DepositThreshold))
+	}
+	if config.FreeSpaceThreshold != 0 && status.freeSpace < config.FreeSpaceThreshold {
+		spaceMsg = fmt.Sprintf("free space %v is below the threshold %v",
+			unit.FormatStorage(status.freeSpace, false), unit.FormatStorage(config.FreeSpaceThreshold, false))
+	}
+	if lowSpaceForContracts(status.lowSpace, status.freeSpace) {
+		fullMsg = fmt.Sprintf("free space %v is below the threshold %v, new contracts are rejected",
+			unit.FormatStorage(status.freeSpace, false), unit.FormatStorage(status.lowSpace.ContractThreshold, false))
+	}
+	var short []string
+	for addr, fee := range status.proofFees {
+		if balance := status.balances[addr]; balance.Cmp(fee) < 0 {
+			short = append(short, fmt.Sprintf("%v has %v for fee %v", addr.String(), unit.FormatCurrency(balance), unit.FormatCurrency(fee)))
+		}
+	}
+	if len(short) != 0 {
+		balanceMsg = "balance could not cover the fee of the upcoming storage proofs: " + strings.Join(short, ", ")
+	}
+	reserve := status.reserve
+	if reserve.Address != (common.Address{}) && reserve.LowWatermark.Sign() > 0 && status.reserveBalance.Cmp(reserve.LowWatermark) < 0 {
+		reserveMsg = fmt.Sprintf("fee reserve %v has %v below the low watermark %v", reserve.Address.String(),
+			unit.FormatCurrency(status.reserveBalance), unit.FormatCurrency(reserve.LowWatermark))
+	}
+	return []HostAlert{
+		{Type: AlertDepositAtRisk, Message: depositMsg},
+		{Type: AlertLowFreeSpace, Message: spaceMsg},
+		{Type: AlertLowProofBalance, Message: balanceMsg},
+		{Type: AlertLowFeeReserve, Message: reserveMsg},
+		{Type: AlertHostFull, Message: fullMsg},
+	}
+}
+
+// sendAlert executes the alert command and POSTs the alert url in background
+func (h *StorageHost) sendAlert(alertType string, message string) {
+	h.lock.RLock()
+	config, height := h.config.Alert, h.blockHeight
+	h.lock.RUnlock()
+	h.dispatchAlert(config, height, alertType, message)
+}
+
+// dispatchAlert sends the alert with the alert config. It is used in place of sendAlert when
+// the lock of the storage host is held by the caller
+func (h *StorageHost) dispatchAlert(config storage.HostAlertConfig, height uint64, alertType string, message string) {
+	alert := HostAlert{
+		Type:        alertType,
+		Time:        time.Now(),
+		BlockHeight: height,
+		Message:     message,
+	}
+	if config.Command == "" && config.URL == "" {
+		return
+	}
+	h.log.Warn("Host alert", "type", alertType, "message", message)
+
+	body, err := json.Marshal(alert)
+	if err != nil {
+		h.log.Error("failed to encode the host alert", "err", err)
+		return
+	}
+	go func() {
+		if err := h.tm.Add(); err != nil {
+			return
+		}
+		defer h.tm.Done()
+
+		if config.Command != "" {
+			if err := runAlertCommand(config.Command, body); err != nil {
+				h.log.Warn("failed to execute the alert command", "command", config.Command, "err", err)
+			}
+		}
+		if config.URL != "" {
+			if err := postAlert(config.URL, body); err != nil {
+				h.log.Warn("failed to post the alert", "url", config.URL, "err", err)
+			}
+		}
+	}()
+}
+
+// runAlertCommand executes the command with the alert passed through the standard input. The
+// command is split by the white spaces into the program and the arguments
+func runAlertCommand(command string, body []byte) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), alertTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// postAlert POSTs the alert to the url. The response with status other than 2xx is regarded
+// as failed
+func postAlert(url string, body []byte) error {
+	client := &http.Client{Timeout: alertTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alert url responded with status %v", resp.Status)
+	}
+	return nil
+}
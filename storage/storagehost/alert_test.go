@@ -0,0 +1,117 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestRiskyConditions test the risky conditions are checked against the thresholds
+func TestRiskyConditions(t *testing.T) {
+	addr := common.HexToAddress("0x01")
+	reserve := storage.HostFeeReserveConfig{
+		Address:      common.HexToAddress("0x02"),
+		LowWatermark: common.NewBigInt(500),
+	}
+	lowSpace := storage.HostLowSpaceConfig{ContractThreshold: 1 << 30}
+	config := storage.HostAlertConfig{
+		DepositThreshold:   common.NewBigInt(1000),
+		FreeSpaceThreshold: 1 << 30,
+	}
+	tests := []struct {
+		status alertStatus
+		raised map[string]bool
+	}{
+		{
+			status: alertStatus{
+				riskedDeposit:  common.NewBigInt(1000),
+				freeSpace:      1 << 30,
+				balances:       map[common.Address]common.BigInt{addr: common.NewBigInt(100)},
+				proofFees:      map[common.Address]common.BigInt{addr: common.NewBigInt(100)},
+				reserve:        reserve,
+				reserveBalance: common.NewBigInt(500),
+				lowSpace:       lowSpace,
+			},
+			raised: map[string]bool{},
+		},
+		{
+			status: alertStatus{
+				riskedDeposit:  common.NewBigInt(1001),
+				freeSpace:      1<<30 - 1,
+				balances:       map[common.Address]common.BigInt{addr: common.NewBigInt(99)},
+				proofFees:      map[common.Address]common.BigInt{addr: common.NewBigInt(100)},
+				reserve:        reserve,
+				reserveBalance: common.NewBigInt(499),
+				lowSpace:       lowSpace,
+			},
+			raised: map[string]bool{AlertDepositAtRisk: true, AlertLowFreeSpace: true, AlertLowProofBalance: true, AlertLowFeeReserve: true, AlertHostFull: true},
+		},
+	}
+	for i, test := range tests {
+		for _, alert := range riskyConditions(config, test.status) {
+			if raised := alert.Message != ""; raised != test.raised[alert.Type] {
+				t.Errorf("test %d: alert %v raised %v, expect %v", i, alert.Type, raised, test.raised[alert.Type])
+			}
+		}
+	}
+
+	// zero thresholds disable the alerts
+	for _, alert := range riskyConditions(storage.HostAlertConfig{}, tests[1].status) {
+		if alert.Message != "" && alert.Type != AlertLowProofBalance && alert.Type != AlertLowFeeReserve && alert.Type != AlertHostFull {
+			t.Errorf("alert %v should be disabled", alert.Type)
+		}
+	}
+}
+
+// TestHostAlertsUpdate test the alert is sent only when the condition newly arises
+func TestHostAlertsUpdate(t *testing.T) {
+	var ha hostAlerts
+	steps := []struct {
+		raised bool
+		send   bool
+	}{
+		{false, false},
+		{true, true},
+		{true, false},
+		{false, false},
+		{true, true},
+	}
+	for i, step := range steps {
+		if send := ha.update(AlertLowFreeSpace, step.raised); send != step.send {
+			t.Errorf("step %d: send %v, expect %v", i, send, step.send)
+		}
+	}
+}
+
+// TestPostAlert test the alert is POSTed to the url in json
+func TestPostAlert(t *testing.T) {
+	received := make(chan HostAlert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert HostAlert
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&alert) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- alert
+	}))
+	defer server.Close()
+
+	body, _ := json.Marshal(HostAlert{Type: AlertProofFailed, Message: "test"})
+	if err := postAlert(server.URL, body); err != nil {
+		t.Fatal(err)
+	}
+	if alert := <-received; alert.Type != AlertProofFailed || alert.Message != "test" {
+		t.Errorf("unexpected alert received: %+v", alert)
+	}
+	if err := postAlert(server.URL, []byte("invalid")); err == nil {
+		t.Error("expect error on the bad request status")
+	}
+}
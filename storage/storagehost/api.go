@@ -5,8 +5,12 @@
 package storagehost
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
@@ -43,10 +47,12 @@ func (h *HostPrivateAPI) PersistDir() string {
 
 // Announce set accepting contracts to true, and then send the announcement
 // transaction
-func (h *HostPrivateAPI) Announce() string {
+func (h *HostPrivateAPI) Announce(ctx context.Context) string {
+	prevConfig := h.storageHost.getInternalConfig()
 	if err := h.storageHost.setAcceptContracts(true); err != nil {
 		return fmt.Sprintf("cannot set AcceptingContracts: %v", err)
 	}
+	h.storageHost.auditConfig(ctx, "shost_announce", prevConfig, h.storageHost.getInternalConfig())
 	address, err := h.storageHost.getPaymentAddress()
 	if err != nil {
 		return fmt.Sprintf("cannot get the payment address: %v", err)
@@ -68,6 +74,11 @@ func (h *HostPrivateAPI) AvailableSpace() storage.HostSpace {
 	return h.storageHost.StorageManager.AvailableSpace()
 }
 
+// ReadCacheStats return the statistics of the sector read cache of the host
+func (h *HostPrivateAPI) ReadCacheStats() storage.HostReadCacheStats {
+	return h.storageHost.StorageManager.ReadCacheStats()
+}
+
 // GetHostConfig return the internal settings of the storage host
 func (h *HostPrivateAPI) GetHostConfig() storage.HostIntConfigForDisplay {
 	// Get the internal setting
@@ -89,6 +100,42 @@ func (h *HostPrivateAPI) GetHostConfig() storage.HostIntConfigForDisplay {
 		SectorAccessPrice:      unit.FormatCurrency(config.SectorAccessPrice, "/sector"),
 		StoragePrice:           unit.FormatCurrency(config.StoragePrice, "/byte/block"),
 		UploadBandwidthPrice:   unit.FormatCurrency(config.UploadBandwidthPrice, "/byte"),
+		MinContractDuration:    unit.FormatTime(config.ContractPolicy.MinDuration),
+		MaxContractSize:        unit.FormatStorage(config.ContractPolicy.MaxContractSize, false),
+		MinPriceMargin:         strconv.FormatFloat(config.ContractPolicy.MinPriceMargin, 'f', -1, 64),
+		MaxClientContracts:     strconv.FormatUint(config.ContractPolicy.MaxClientContracts, 10),
+		MaxDownloadSections:    strconv.FormatUint(config.DownloadLimits.MaxSections, 10),
+		MaxDownloadSize:        unit.FormatStorage(config.DownloadLimits.MaxTotalSize, false),
+		MaxDownloadProofSize:   unit.FormatStorage(config.DownloadLimits.MaxProofSize, false),
+		PublicRead:             unit.FormatBool(config.PublicRead),
+		ReadCacheSize:          unit.FormatStorage(config.ReadCacheSize, false),
+		ReadCacheDiskPath:      config.ReadCacheDiskPath,
+		ReadCacheDiskSize:      unit.FormatStorage(config.ReadCacheDiskSize, false),
+		ReadWorkers:            strconv.FormatUint(config.ReadIO.Workers, 10),
+		Readahead:              strconv.FormatUint(config.ReadIO.Readahead, 10),
+		DiskReadConcurrency:    strconv.FormatUint(config.ReadIO.DiskConcurrency, 10),
+		FolderReadConcurrency:  formatFolderReadConcurrency(config.ReadIO.FolderConcurrency),
+
+		AlertCommand:          config.Alert.Command,
+		AlertURL:              config.Alert.URL,
+		AlertDepositThreshold: unit.FormatCurrency(config.Alert.DepositThreshold),
+		AlertFreeSpace:        unit.FormatStorage(config.Alert.FreeSpaceThreshold, false),
+
+		FeeReserveLowWatermark: unit.FormatCurrency(config.FeeReserve.LowWatermark),
+		FeeReserveTarget:       unit.FormatCurrency(config.FeeReserve.Target),
+
+		ProofGasPriceCeiling: unit.FormatCurrency(config.ProofGasPriceCeiling),
+
+		LowSpaceContractThreshold: unit.FormatStorage(config.LowSpace.ContractThreshold, false),
+		LowSpaceAppendThreshold:   unit.FormatStorage(config.LowSpace.AppendThreshold, false),
+
+		Region: config.Region,
+	}
+	for _, addr := range config.ContractPolicy.ClientAllowlist {
+		display.ClientAllowlist = append(display.ClientAllowlist, addr.String())
+	}
+	if config.FeeReserve.Address != (common.Address{}) {
+		display.FeeReserveAddress = config.FeeReserve.Address.String()
 	}
 
 	return display
@@ -112,10 +159,62 @@ func (h *HostPrivateAPI) GetFinancialMetrics() HostFinancialMetricsForDisplay {
 		PotentialDownloadBandwidthRevenue: unit.FormatCurrency(fm.PotentialDownloadBandwidthRevenue),
 		PotentialUploadBandwidthRevenue:   unit.FormatCurrency(fm.PotentialUploadBandwidthRevenue),
 		UploadBandwidthRevenue:            unit.FormatCurrency(fm.UploadBandwidthRevenue),
+		UploadBandwidthBytes:              unit.FormatStorage(fm.UploadBandwidthBytes, true),
+		DownloadBandwidthBytes:            unit.FormatStorage(fm.DownloadBandwidthBytes, true),
 	}
 	return display
 }
 
+// Responsibilities returns the storage responsibilities filtered by the status, which could be
+// unresolved, rejected, succeeded or failed. Empty status returns all storage responsibilities.
+// The result is sorted by the negotiation height, and paginated by offset and limit, where zero
+// limit means no limit
+func (h *HostPrivateAPI) Responsibilities(status string, offset, limit uint64) ([]StorageResponsibilityForDisplay, error) {
+	sos, err := filterStorageResponsibilities(h.storageHost.storageResponsibilities(), status, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	displays := make([]StorageResponsibilityForDisplay, 0, len(sos))
+	for _, so := range sos {
+		displays = append(displays, StorageResponsibilityForDisplay{
+			ContractID:           so.id(),
+			Status:               responsibilityStatusName(so.ResponsibilityStatus),
+			FileSize:             unit.FormatStorage(so.fileSize(), false),
+			NegotiationHeight:    so.NegotiationBlockNumber,
+			ExpirationHeight:     so.expiration(),
+			ProofDeadline:        so.proofDeadline(),
+			ContractCost:         unit.FormatCurrency(so.ContractCost),
+			LockedStorageDeposit: unit.FormatCurrency(so.LockedStorageDeposit),
+			RiskedStorageDeposit: unit.FormatCurrency(so.RiskedStorageDeposit),
+		})
+	}
+	return displays, nil
+}
+
+// ConfigHistory returns the host config changes made through the api, paginated by offset
+// and limit. Zero limit returns all the changes from the offset
+func (h *HostPrivateAPI) ConfigHistory(offset, limit uint64) ([]ConfigAuditEntry, error) {
+	return h.storageHost.configHistory(offset, limit)
+}
+
+// BandwidthByContract returns the bandwidth served for each storage contract, sorted by the
+// total bytes served. Zero limit returns all the contracts
+func (h *HostPrivateAPI) BandwidthByContract(limit uint64) []BandwidthUsage {
+	return limitBandwidthUsages(h.storageHost.bandwidth.byContract(), limit)
+}
+
+// BandwidthByPeer returns the bandwidth served for each client peer, sorted by the total bytes
+// served, so that the heavy clients come first. Zero limit returns all the peers
+func (h *HostPrivateAPI) BandwidthByPeer(limit uint64) []BandwidthUsage {
+	return limitBandwidthUsages(h.storageHost.bandwidth.byPeer(), limit)
+}
+
+// Transcript returns the negotiation transcript of the storage responsibility recorded and
+// signed by the storage host
+func (h *HostPrivateAPI) Transcript(contractID common.Hash) ([]storage.TranscriptEntry, error) {
+	return h.storageHost.transcript(contractID)
+}
+
 //GetPaymentAddress get the account address used to sign the storage contract. If not configured, the first address in the local wallet will be used as the paymentAddress by default.
 func (h *HostPrivateAPI) GetPaymentAddress() string {
 	addr, err := h.storageHost.getPaymentAddress()
@@ -165,39 +264,104 @@ func (h *HostPrivateAPI) DeleteFolder(folderPath string) (string, error) {
 	return "successfully delete the storage folder", nil
 }
 
+// MigrateFolder moves the sectors of the folder to the target path, which could be a local
+// directory or an object store path in the form of s3://endpoint/bucket/prefix
+func (h *HostPrivateAPI) MigrateFolder(folderPath string, targetPath string) (string, error) {
+	err := h.storageHost.StorageManager.MigrateFolder(folderPath, targetPath)
+	if err != nil {
+		return "", err
+	}
+	return "successfully migrate the storage folder", nil
+}
+
+// Export packages the storage responsibilities, the sector data references, the config and the
+// host key to the file for migrating the host to new hardware. The host does not accept new
+// contracts, uploads or downloads during the export. The host key is encrypted with the
+// passphrase of the payment address
+func (h *HostPrivateAPI) Export(path string, passphrase string) (string, error) {
+	if err := h.storageHost.export(path, passphrase); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("successfully export the host to %v", path), nil
+}
+
+// Import imports the host exported by Export. The storage folders and the storage manager
+// shall be moved to the host before the import, so that the sectors referenced are available
+func (h *HostPrivateAPI) Import(ctx context.Context, path string, passphrase string) (string, error) {
+	prevConfig := h.storageHost.getInternalConfig()
+	if err := h.storageHost.importHost(path, passphrase); err != nil {
+		return "", err
+	}
+	h.storageHost.auditConfig(ctx, "shost_import", prevConfig, h.storageHost.getInternalConfig())
+	return "successfully import the host", nil
+}
+
 // hostSetterCallbacks is the mapping from the field name to the setter function
 var hostSetterCallbacks = map[string]func(*HostPrivateAPI, string) error{
-	"acceptingContracts":     (*HostPrivateAPI).setAcceptingContracts,
-	"maxDownloadBatchSize":   (*HostPrivateAPI).setMaxDownloadBatchSize,
-	"maxDuration":            (*HostPrivateAPI).setMaxDuration,
-	"maxReviseBatchSize":     (*HostPrivateAPI).setMaxReviseBatchSize,
-	"paymentAddress":         (*HostPrivateAPI).setPaymentAddress,
-	"deposit":                (*HostPrivateAPI).setDeposit,
-	"depositBudget":          (*HostPrivateAPI).setDepositBudget,
-	"maxDeposit":             (*HostPrivateAPI).setMaxDeposit,
-	"baseRPCPrice":           (*HostPrivateAPI).setBaseRPCPrice,
-	"contractPrice":          (*HostPrivateAPI).setContractPrice,
-	"downloadBandwidthPrice": (*HostPrivateAPI).setDownloadBandwidthPrice,
-	"sectorAccessPrice":      (*HostPrivateAPI).setSectorAccessPrice,
-	"storagePrice":           (*HostPrivateAPI).setStoragePrice,
-	"uploadBandwidthPrice":   (*HostPrivateAPI).setUploadBandwidthPrice,
+	"acceptingContracts":        (*HostPrivateAPI).setAcceptingContracts,
+	"maxDownloadBatchSize":      (*HostPrivateAPI).setMaxDownloadBatchSize,
+	"maxDuration":               (*HostPrivateAPI).setMaxDuration,
+	"maxReviseBatchSize":        (*HostPrivateAPI).setMaxReviseBatchSize,
+	"paymentAddress":            (*HostPrivateAPI).setPaymentAddress,
+	"deposit":                   (*HostPrivateAPI).setDeposit,
+	"depositBudget":             (*HostPrivateAPI).setDepositBudget,
+	"maxDeposit":                (*HostPrivateAPI).setMaxDeposit,
+	"baseRPCPrice":              (*HostPrivateAPI).setBaseRPCPrice,
+	"contractPrice":             (*HostPrivateAPI).setContractPrice,
+	"downloadBandwidthPrice":    (*HostPrivateAPI).setDownloadBandwidthPrice,
+	"sectorAccessPrice":         (*HostPrivateAPI).setSectorAccessPrice,
+	"storagePrice":              (*HostPrivateAPI).setStoragePrice,
+	"uploadBandwidthPrice":      (*HostPrivateAPI).setUploadBandwidthPrice,
+	"minContractDuration":       (*HostPrivateAPI).setMinContractDuration,
+	"maxContractSize":           (*HostPrivateAPI).setMaxContractSize,
+	"minPriceMargin":            (*HostPrivateAPI).setMinPriceMargin,
+	"maxClientContracts":        (*HostPrivateAPI).setMaxClientContracts,
+	"clientAllowlist":           (*HostPrivateAPI).setClientAllowlist,
+	"maxDownloadSections":       (*HostPrivateAPI).setMaxDownloadSections,
+	"maxDownloadSize":           (*HostPrivateAPI).setMaxDownloadSize,
+	"maxDownloadProofSize":      (*HostPrivateAPI).setMaxDownloadProofSize,
+	"publicRead":                (*HostPrivateAPI).setPublicRead,
+	"readCacheSize":             (*HostPrivateAPI).setReadCacheSize,
+	"readCacheDiskPath":         (*HostPrivateAPI).setReadCacheDiskPath,
+	"readCacheDiskSize":         (*HostPrivateAPI).setReadCacheDiskSize,
+	"readWorkers":               (*HostPrivateAPI).setReadWorkers,
+	"readahead":                 (*HostPrivateAPI).setReadahead,
+	"diskReadConcurrency":       (*HostPrivateAPI).setDiskReadConcurrency,
+	"folderReadConcurrency":     (*HostPrivateAPI).setFolderReadConcurrency,
+	"alertCommand":              (*HostPrivateAPI).setAlertCommand,
+	"alertURL":                  (*HostPrivateAPI).setAlertURL,
+	"alertDepositThreshold":     (*HostPrivateAPI).setAlertDepositThreshold,
+	"alertFreeSpace":            (*HostPrivateAPI).setAlertFreeSpace,
+	"feeReserveAddress":         (*HostPrivateAPI).setFeeReserveAddress,
+	"feeReserveLowWatermark":    (*HostPrivateAPI).setFeeReserveLowWatermark,
+	"feeReserveTarget":          (*HostPrivateAPI).setFeeReserveTarget,
+	"proofGasPriceCeiling":      (*HostPrivateAPI).setProofGasPriceCeiling,
+	"lowSpaceContractThreshold": (*HostPrivateAPI).setLowSpaceContractThreshold,
+	"lowSpaceAppendThreshold":   (*HostPrivateAPI).setLowSpaceAppendThreshold,
+	"region":                    (*HostPrivateAPI).setRegion,
 }
 
 // SetConfig set the config specified by a mapping of key value pair
-func (h *HostPrivateAPI) SetConfig(config map[string]string) (string, error) {
+func (h *HostPrivateAPI) SetConfig(ctx context.Context, config map[string]string) (string, error) {
+	// notify the connected clients of the changes once the lock is released
+	defer h.storageHost.notifyConfigUpdate()
+
 	h.storageHost.lock.Lock()
 	// record the previous config and register the defer function
 	var err error
 	prevConfig := h.storageHost.config
 	defer func() {
-		// If error happened, revert to the previous config
+		// If error happened, revert to the previous config, otherwise record the changes
 		if err != nil {
 			h.storageHost.config = prevConfig
+		} else {
+			h.storageHost.auditConfig(ctx, "shost_setConfig", prevConfig, h.storageHost.config)
 		}
 		h.storageHost.lock.Unlock()
 	}()
 
 	// Loops over the user set config and change the host settings
+	var readCacheChanged bool
 	for key, value := range config {
 		callback, exist := hostSetterCallbacks[key]
 		if !exist {
@@ -207,6 +371,15 @@ func (h *HostPrivateAPI) SetConfig(config map[string]string) (string, error) {
 		if err = callback(h, value); err != nil {
 			return "", err
 		}
+		if _, exist = readCacheConfigKeys[key]; exist {
+			readCacheChanged = true
+		}
+	}
+	// apply the read cache config only if it is changed
+	if readCacheChanged {
+		if err = h.storageHost.applyReadCacheConfig(); err != nil {
+			return "", err
+		}
 	}
 	// sync the config
 	if err = h.storageHost.syncConfig(); err != nil {
@@ -220,6 +393,16 @@ to broadcast the config changes.
 `, nil
 }
 
+// readCacheConfigKeys are the config keys applied to the storage manager by
+// applyReadCacheConfig once set
+var readCacheConfigKeys = map[string]struct{}{
+	"readCacheSize":         {},
+	"readCacheDiskPath":     {},
+	"readCacheDiskSize":     {},
+	"diskReadConcurrency":   {},
+	"folderReadConcurrency": {},
+}
+
 // setAcceptingContracts set host AcceptingContracts to val specified by valStr
 func (h *HostPrivateAPI) setAcceptingContracts(valStr string) error {
 	val, err := unit.ParseBool(valStr)
@@ -365,3 +548,301 @@ func (h *HostPrivateAPI) setUploadBandwidthPrice(str string) error {
 	h.storageHost.config.UploadBandwidthPrice = wei
 	return nil
 }
+
+// setMinContractDuration set the min contract duration in the host contract policy
+func (h *HostPrivateAPI) setMinContractDuration(str string) error {
+	val, err := unit.ParseTime(str)
+	if err != nil {
+		return fmt.Errorf("invalid time string: %v", err)
+	}
+	h.storageHost.config.ContractPolicy.MinDuration = val
+	return nil
+}
+
+// setMaxContractSize set the max contract size in the host contract policy
+func (h *HostPrivateAPI) setMaxContractSize(str string) error {
+	val, err := unit.ParseStorage(str)
+	if err != nil {
+		return fmt.Errorf("invalid storage string: %v", err)
+	}
+	h.storageHost.config.ContractPolicy.MaxContractSize = val
+	return nil
+}
+
+// setMinPriceMargin set the min price margin in the host contract policy
+func (h *HostPrivateAPI) setMinPriceMargin(str string) error {
+	val, err := strconv.ParseFloat(str, 64)
+	if err != nil || val < 0 {
+		return fmt.Errorf("invalid price margin: %v", str)
+	}
+	h.storageHost.config.ContractPolicy.MinPriceMargin = val
+	return nil
+}
+
+// setMaxClientContracts set the max concurrent contracts per client in the host contract policy
+func (h *HostPrivateAPI) setMaxClientContracts(str string) error {
+	val, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number: %v", err)
+	}
+	h.storageHost.config.ContractPolicy.MaxClientContracts = val
+	return nil
+}
+
+// setClientAllowlist set the client allowlist in the host contract policy. The addresses
+// are separated by comma, and empty string clears the allowlist
+func (h *HostPrivateAPI) setClientAllowlist(str string) error {
+	var allowlist []common.Address
+	for _, addrStr := range strings.Split(str, ",") {
+		addrStr = strings.TrimSpace(addrStr)
+		if addrStr == "" {
+			continue
+		}
+		if !common.IsHexAddress(addrStr) {
+			return fmt.Errorf("invalid address: %v", addrStr)
+		}
+		allowlist = append(allowlist, common.HexToAddress(addrStr))
+	}
+	h.storageHost.config.ContractPolicy.ClientAllowlist = allowlist
+	return nil
+}
+
+// setMaxDownloadSections set the max number of sections in a download request
+func (h *HostPrivateAPI) setMaxDownloadSections(str string) error {
+	val, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number: %v", err)
+	}
+	h.storageHost.config.DownloadLimits.MaxSections = val
+	return nil
+}
+
+// setMaxDownloadSize set the max total length of the sections in a download request
+func (h *HostPrivateAPI) setMaxDownloadSize(str string) error {
+	val, err := unit.ParseStorage(str)
+	if err != nil {
+		return fmt.Errorf("invalid storage string: %v", err)
+	}
+	h.storageHost.config.DownloadLimits.MaxTotalSize = val
+	return nil
+}
+
+// setMaxDownloadProofSize set the max size of the merkle proofs of a download request
+func (h *HostPrivateAPI) setMaxDownloadProofSize(str string) error {
+	val, err := unit.ParseStorage(str)
+	if err != nil {
+		return fmt.Errorf("invalid storage string: %v", err)
+	}
+	h.storageHost.config.DownloadLimits.MaxProofSize = val
+	return nil
+}
+
+// setPublicRead set host PublicRead to val specified by valStr
+func (h *HostPrivateAPI) setPublicRead(valStr string) error {
+	val, err := unit.ParseBool(valStr)
+	if err != nil {
+		return fmt.Errorf("invalid bool string: %v", err)
+	}
+	h.storageHost.config.PublicRead = val
+	return nil
+}
+
+// setReadCacheSize set the memory size of the sector read cache
+func (h *HostPrivateAPI) setReadCacheSize(str string) error {
+	val, err := unit.ParseStorage(str)
+	if err != nil {
+		return fmt.Errorf("invalid storage string: %v", err)
+	}
+	h.storageHost.config.ReadCacheSize = val
+	return nil
+}
+
+// setReadCacheDiskPath set the directory of the disk tier of the sector read cache. Empty
+// string disables the disk tier
+func (h *HostPrivateAPI) setReadCacheDiskPath(str string) error {
+	h.storageHost.config.ReadCacheDiskPath = strings.TrimSpace(str)
+	return nil
+}
+
+// setReadCacheDiskSize set the size of the disk tier of the sector read cache
+func (h *HostPrivateAPI) setReadCacheDiskSize(str string) error {
+	val, err := unit.ParseStorage(str)
+	if err != nil {
+		return fmt.Errorf("invalid storage string: %v", err)
+	}
+	h.storageHost.config.ReadCacheDiskSize = val
+	return nil
+}
+
+// setReadWorkers set the number of sectors read concurrently for a download request
+func (h *HostPrivateAPI) setReadWorkers(str string) error {
+	val, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number: %v", err)
+	}
+	h.storageHost.config.ReadIO.Workers = val
+	return nil
+}
+
+// setReadahead set the number of sections read in advance beyond the read workers
+func (h *HostPrivateAPI) setReadahead(str string) error {
+	val, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number: %v", err)
+	}
+	h.storageHost.config.ReadIO.Readahead = val
+	return nil
+}
+
+// setDiskReadConcurrency set the max number of concurrent reads from a storage folder.
+// Zero means no limit
+func (h *HostPrivateAPI) setDiskReadConcurrency(str string) error {
+	val, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number: %v", err)
+	}
+	h.storageHost.config.ReadIO.DiskConcurrency = val
+	return nil
+}
+
+// setFolderReadConcurrency set the max number of concurrent reads of the storage folders,
+// in the format of "path:limit,path:limit". Empty string clears the limits of the folders
+func (h *HostPrivateAPI) setFolderReadConcurrency(str string) error {
+	limits, err := parseFolderReadConcurrency(str)
+	if err != nil {
+		return err
+	}
+	h.storageHost.config.ReadIO.FolderConcurrency = limits
+	return nil
+}
+
+// setAlertCommand set the command executed on the host alert. Empty string disables the command
+func (h *HostPrivateAPI) setAlertCommand(str string) error {
+	h.storageHost.config.Alert.Command = strings.TrimSpace(str)
+	return nil
+}
+
+// setAlertURL set the url POSTed on the host alert. Empty string disables the url
+func (h *HostPrivateAPI) setAlertURL(str string) error {
+	str = strings.TrimSpace(str)
+	if str != "" {
+		u, err := url.Parse(str)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid alert url: %v", str)
+		}
+	}
+	h.storageHost.config.Alert.URL = str
+	return nil
+}
+
+// setAlertDepositThreshold set the risked storage deposit above which the host is alerted.
+// Zero disables the alert
+func (h *HostPrivateAPI) setAlertDepositThreshold(str string) error {
+	wei, err := unit.ParseCurrency(str)
+	if err != nil {
+		return fmt.Errorf("invalid currency expression: %v", err)
+	}
+	h.storageHost.config.Alert.DepositThreshold = wei
+	return nil
+}
+
+// setAlertFreeSpace set the free space below which the host is alerted. Zero
+// disables the alert
+func (h *HostPrivateAPI) setAlertFreeSpace(str string) error {
+	val, err := unit.ParseStorage(str)
+	if err != nil {
+		return fmt.Errorf("invalid storage string: %v", err)
+	}
+	h.storageHost.config.Alert.FreeSpaceThreshold = val
+	return nil
+}
+
+// setFeeReserveAddress set the account paying the gas of the storage proof and revision txs,
+// which must be an address of the local wallet other than the payment address. Empty string
+// disables the fee reserve
+func (h *HostPrivateAPI) setFeeReserveAddress(addrStr string) error {
+	addrStr = strings.TrimSpace(addrStr)
+	if addrStr == "" {
+		h.storageHost.config.FeeReserve.Address = common.Address{}
+		return nil
+	}
+	if !common.IsHexAddress(addrStr) {
+		return fmt.Errorf("invalid address: %v", addrStr)
+	}
+	addr := common.HexToAddress(addrStr)
+	if addr == h.storageHost.config.PaymentAddress {
+		return errors.New("fee reserve shall not be the payment address")
+	}
+	if h.storageHost.am == nil {
+		return errors.New("storage host has no account manager")
+	}
+	if _, err := h.storageHost.am.Find(accounts.Account{Address: addr}); err != nil {
+		return errors.New("unknown account")
+	}
+	h.storageHost.config.FeeReserve.Address = addr
+	return nil
+}
+
+// setFeeReserveLowWatermark set the balance below which the fee reserve is topped up and the
+// host is alerted. Zero disables the top up and the alert
+func (h *HostPrivateAPI) setFeeReserveLowWatermark(str string) error {
+	wei, err := unit.ParseCurrency(str)
+	if err != nil {
+		return fmt.Errorf("invalid currency expression: %v", err)
+	}
+	h.storageHost.config.FeeReserve.LowWatermark = wei
+	return nil
+}
+
+// setFeeReserveTarget set the balance the fee reserve is topped up to
+func (h *HostPrivateAPI) setFeeReserveTarget(str string) error {
+	wei, err := unit.ParseCurrency(str)
+	if err != nil {
+		return fmt.Errorf("invalid currency expression: %v", err)
+	}
+	h.storageHost.config.FeeReserve.Target = wei
+	return nil
+}
+
+// setProofGasPriceCeiling set the gas price the storage proof tx at risk of missing the proof
+// window is bumped up to. Zero disables the gas price bumping
+func (h *HostPrivateAPI) setProofGasPriceCeiling(str string) error {
+	wei, err := unit.ParseCurrency(str)
+	if err != nil {
+		return fmt.Errorf("invalid currency expression: %v", err)
+	}
+	h.storageHost.config.ProofGasPriceCeiling = wei
+	return nil
+}
+
+// setLowSpaceContractThreshold set the free space below which the host stops accepting new
+// contracts. Zero disables the threshold
+func (h *HostPrivateAPI) setLowSpaceContractThreshold(str string) error {
+	val, err := unit.ParseStorage(str)
+	if err != nil {
+		return fmt.Errorf("invalid storage string: %v", err)
+	}
+	h.storageHost.config.LowSpace.ContractThreshold = val
+	return nil
+}
+
+// setLowSpaceAppendThreshold set the free space kept by the host, which is not advertised
+// and rejects the data appended. Zero disables the threshold
+func (h *HostPrivateAPI) setLowSpaceAppendThreshold(str string) error {
+	val, err := unit.ParseStorage(str)
+	if err != nil {
+		return fmt.Errorf("invalid storage string: %v", err)
+	}
+	h.storageHost.config.LowSpace.AppendThreshold = val
+	return nil
+}
+
+// setRegion set the region tag advertised to the clients. Empty string clears the region
+func (h *HostPrivateAPI) setRegion(str string) error {
+	region, err := storage.ParseRegion(str)
+	if err != nil {
+		return fmt.Errorf("invalid region: %v", err)
+	}
+	h.storageHost.config.Region = region
+	return nil
+}
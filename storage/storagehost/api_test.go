@@ -5,6 +5,7 @@
 package storagehost
 
 import (
+	"context"
 	"errors"
 	"os"
 	"path/filepath"
@@ -92,6 +93,43 @@ func TestHostPrivateAPI_SetConfig(t *testing.T) {
 			storage.HostIntConfig{UploadBandwidthPrice: mustParseCurrency("1camel")},
 			nil,
 		},
+		"minContractDuration": {
+			map[string]string{"minContractDuration": "1w"},
+			storage.HostIntConfig{ContractPolicy: storage.HostContractPolicy{MinDuration: mustParseTime("1w")}},
+			nil,
+		},
+		"maxContractSize": {
+			map[string]string{"maxContractSize": "1gb"},
+			storage.HostIntConfig{ContractPolicy: storage.HostContractPolicy{MaxContractSize: mustParseStorage("1gb")}},
+			nil,
+		},
+		"minPriceMargin": {
+			map[string]string{"minPriceMargin": "1.5"},
+			storage.HostIntConfig{ContractPolicy: storage.HostContractPolicy{MinPriceMargin: 1.5}},
+			nil,
+		},
+		"maxClientContracts": {
+			map[string]string{"maxClientContracts": "3"},
+			storage.HostIntConfig{ContractPolicy: storage.HostContractPolicy{MaxClientContracts: 3}},
+			nil,
+		},
+		"clientAllowlist": {
+			map[string]string{"clientAllowlist": "0x0000000000000000000000000000000000000001, 0x0000000000000000000000000000000000000002"},
+			storage.HostIntConfig{ContractPolicy: storage.HostContractPolicy{ClientAllowlist: []common.Address{
+				common.HexToAddress("0x01"), common.HexToAddress("0x02"),
+			}}},
+			nil,
+		},
+		"publicRead": {
+			map[string]string{"publicRead": "true"},
+			storage.HostIntConfig{PublicRead: true},
+			nil,
+		},
+		"price margin parse error": {
+			map[string]string{"minPriceMargin": "-1", "acceptingContracts": "true"},
+			storage.HostIntConfig{},
+			errors.New("price margin error"),
+		},
 		"currency parse error": {
 			map[string]string{"baseRPCPrice": "1234", "acceptingContracts": "true"},
 			storage.HostIntConfig{},
@@ -150,7 +188,7 @@ func TestHostPrivateAPI_SetConfig(t *testing.T) {
 	for key, test := range tests {
 		// Create a new storage host api and apply the test config
 		h := NewHostPrivateAPI(&StorageHost{persistDir: dir})
-		_, err := h.SetConfig(test.config)
+		_, err := h.SetConfig(context.Background(), test.config)
 		// errors should be as expected
 		if (err == nil) != (test.err == nil) {
 			t.Fatalf("Test %v not expected error. Expect %v, Got %v", key, test.err, err)
@@ -0,0 +1,204 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// BandwidthUsage is the bandwidth served by the host for a storage contract or a client peer,
+// along with the bandwidth revenue paid for the bytes served
+type BandwidthUsage struct {
+	// ID is the storage contract id, or the enode id of the client peer
+	ID string `json:"id"`
+
+	UploadBytes     uint64        `json:"uploadbytes"`
+	DownloadBytes   uint64        `json:"downloadbytes"`
+	UploadRevenue   common.BigInt `json:"uploadrevenue"`
+	DownloadRevenue common.BigInt `json:"downloadrevenue"`
+}
+
+// totalBytes returns the total bytes uploaded and downloaded
+func (u BandwidthUsage) totalBytes() uint64 {
+	return u.UploadBytes + u.DownloadBytes
+}
+
+// bandwidthPersistence is the bandwidth usage saved in the bandwidth file
+type bandwidthPersistence struct {
+	Contracts []BandwidthUsage `json:"contracts"`
+	Peers     []BandwidthUsage `json:"peers"`
+}
+
+// bandwidthAccounting records the bandwidth usage per storage contract and per client peer
+type bandwidthAccounting struct {
+	contracts map[string]*BandwidthUsage
+	peers     map[string]*BandwidthUsage
+
+	// dirty tells whether the usage has changed since the last save
+	dirty bool
+	lock  sync.Mutex
+}
+
+// record adds the bytes and revenue to the usage of the contract and the peer. Empty peer
+// means the client peer is unknown, and only the usage of the contract is recorded
+func (ba *bandwidthAccounting) record(contractID common.Hash, peer string, uploadBytes, downloadBytes uint64, revenue common.BigInt) {
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+
+	if ba.contracts == nil {
+		ba.contracts = make(map[string]*BandwidthUsage)
+		ba.peers = make(map[string]*BandwidthUsage)
+	}
+	ba.dirty = true
+	addBandwidthUsage(ba.contracts, contractID.String(), uploadBytes, downloadBytes, revenue)
+	if peer != "" {
+		addBandwidthUsage(ba.peers, peer, uploadBytes, downloadBytes, revenue)
+	}
+}
+
+// addBandwidthUsage adds the bytes and revenue to the usage with the id in the usages. The
+// revenue is accounted as upload revenue if there are bytes uploaded, else download revenue
+func addBandwidthUsage(usages map[string]*BandwidthUsage, id string, uploadBytes, downloadBytes uint64, revenue common.BigInt) {
+	usage, exists := usages[id]
+	if !exists {
+		usage = &BandwidthUsage{ID: id}
+		usages[id] = usage
+	}
+	usage.UploadBytes += uploadBytes
+	usage.DownloadBytes += downloadBytes
+	if uploadBytes != 0 {
+		usage.UploadRevenue = usage.UploadRevenue.Add(revenue)
+	} else {
+		usage.DownloadRevenue = usage.DownloadRevenue.Add(revenue)
+	}
+}
+
+// byContract returns the bandwidth usage of the contracts sorted by the total bytes
+func (ba *bandwidthAccounting) byContract() []BandwidthUsage {
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+	return sortedBandwidthUsages(ba.contracts)
+}
+
+// byPeer returns the bandwidth usage of the client peers sorted by the total bytes
+func (ba *bandwidthAccounting) byPeer() []BandwidthUsage {
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+	return sortedBandwidthUsages(ba.peers)
+}
+
+// sortedBandwidthUsages returns the usages sorted by the total bytes from the highest to the
+// lowest. The usages with the same total bytes are sorted by the id
+func sortedBandwidthUsages(usages map[string]*BandwidthUsage) []BandwidthUsage {
+	sorted := make([]BandwidthUsage, 0, len(usages))
+	for _, usage := range usages {
+		sorted = append(sorted, *usage)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].totalBytes() != sorted[j].totalBytes() {
+			return sorted[i].totalBytes() > sorted[j].totalBytes()
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+// save saves the bandwidth usage to the file if it has changed since the last save
+func (ba *bandwidthAccounting) save(path string) error {
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+
+	if !ba.dirty {
+		return nil
+	}
+	persist := bandwidthPersistence{
+		Contracts: sortedBandwidthUsages(ba.contracts),
+		Peers:     sortedBandwidthUsages(ba.peers),
+	}
+	if err := common.SaveDxJSON(bandwidthMeta, path, persist); err != nil {
+		return err
+	}
+	ba.dirty = false
+	return nil
+}
+
+// load loads the bandwidth usage from the file. Nothing is loaded if the file does not exist
+func (ba *bandwidthAccounting) load(path string) error {
+	ba.lock.Lock()
+	defer ba.lock.Unlock()
+
+	ba.contracts = make(map[string]*BandwidthUsage)
+	ba.peers = make(map[string]*BandwidthUsage)
+	var persist bandwidthPersistence
+	if err := common.LoadDxJSON(bandwidthMeta, path, &persist); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for i := range persist.Contracts {
+		ba.contracts[persist.Contracts[i].ID] = &persist.Contracts[i]
+	}
+	for i := range persist.Peers {
+		ba.peers[persist.Peers[i].ID] = &persist.Peers[i]
+	}
+	return nil
+}
+
+// recordBandwidth records the bytes served for the storage contract and the client peer, and
+// adds the bytes to the financial metrics. It is called after the revision is acknowledged
+func (h *StorageHost) recordBandwidth(storageContractID common.Hash, sp storage.Peer, uploadBytes, downloadBytes uint64, revenue common.BigInt) {
+	var peer string
+	if node := sp.PeerNode(); node != nil {
+		peer = node.ID().String()
+	}
+	h.bandwidth.record(storageContractID, peer, uploadBytes, downloadBytes, revenue)
+
+	h.lock.Lock()
+	h.financialMetrics.UploadBandwidthBytes += uploadBytes
+	h.financialMetrics.DownloadBandwidthBytes += downloadBytes
+	h.lock.Unlock()
+}
+
+// bandwidthPath returns the path of the bandwidth file
+func (h *StorageHost) bandwidthPath() string {
+	return filepath.Join(h.getPersistDir(), BandwidthFile)
+}
+
+// bandwidthPersistLoop periodically saves the bandwidth usage to the bandwidth file
+func (h *StorageHost) bandwidthPersistLoop() {
+	if err := h.tm.Add(); err != nil {
+		return
+	}
+	defer h.tm.Done()
+
+	ticker := time.NewTicker(bandwidthPersistInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.tm.StopChan():
+			return
+		case <-ticker.C:
+		}
+		if err := h.bandwidth.save(h.bandwidthPath()); err != nil {
+			h.log.Warn("failed to save the bandwidth usage", "err", err)
+		}
+	}
+}
+
+// limitBandwidthUsages returns the first limit usages, where zero limit means no limit
+func limitBandwidthUsages(usages []BandwidthUsage, limit uint64) []BandwidthUsage {
+	if limit != 0 && uint64(len(usages)) > limit {
+		return usages[:limit]
+	}
+	return usages
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+)
+
+// TestBandwidthAccounting test the bandwidth served is accounted per contract and per peer,
+// sorted by the total bytes, and kept across the save and load
+func TestBandwidthAccounting(t *testing.T) {
+	dir := tempDir(t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, BandwidthFile)
+
+	var ba bandwidthAccounting
+	ba.record(common.Hash{1}, "peer1", 100, 0, common.NewBigInt(10))
+	ba.record(common.Hash{1}, "peer1", 0, 50, common.NewBigInt(5))
+	ba.record(common.Hash{2}, "peer2", 0, 300, common.NewBigInt(30))
+	ba.record(common.Hash{3}, "", 20, 0, common.NewBigInt(2))
+
+	contracts := ba.byContract()
+	if len(contracts) != 3 {
+		t.Fatalf("expect 3 contracts, got %v", len(contracts))
+	}
+	expect := BandwidthUsage{
+		ID:              common.Hash{1}.String(),
+		UploadBytes:     100,
+		DownloadBytes:   50,
+		UploadRevenue:   common.NewBigInt(10),
+		DownloadRevenue: common.NewBigInt(5),
+	}
+	if !bandwidthUsagesEqual(contracts[1:2], []BandwidthUsage{expect}) {
+		t.Errorf("contract usage not expected: \n\tgot %+v\n\texpect %+v", contracts[1], expect)
+	}
+	if contracts[0].ID != (common.Hash{2}).String() || contracts[2].ID != (common.Hash{3}).String() {
+		t.Errorf("contracts not sorted by the total bytes: %+v", contracts)
+	}
+
+	// the usage of the unknown peer is not recorded
+	peers := ba.byPeer()
+	if len(peers) != 2 || peers[0].ID != "peer2" || peers[1].ID != "peer1" {
+		t.Fatalf("peers not expected: %+v", peers)
+	}
+	if peers[1].UploadBytes != 100 || peers[1].DownloadBytes != 50 {
+		t.Errorf("peer usage not expected: %+v", peers[1])
+	}
+	if limited := limitBandwidthUsages(peers, 1); len(limited) != 1 || limited[0].ID != "peer2" {
+		t.Errorf("limited peers not expected: %+v", limited)
+	}
+
+	if err := ba.save(path); err != nil {
+		t.Fatal(err)
+	}
+	var loaded bandwidthAccounting
+	if err := loaded.load(path); err != nil {
+		t.Fatal(err)
+	}
+	if !bandwidthUsagesEqual(loaded.byContract(), contracts) {
+		t.Errorf("loaded contracts not expected: \n\tgot %+v\n\texpect %+v", loaded.byContract(), contracts)
+	}
+	if !bandwidthUsagesEqual(loaded.byPeer(), peers) {
+		t.Errorf("loaded peers not expected: \n\tgot %+v\n\texpect %+v", loaded.byPeer(), peers)
+	}
+
+	// loading from the file not exist is not an error
+	if err := loaded.load(filepath.Join(dir, "notexist")); err != nil {
+		t.Errorf("failed to load from the file not exist: %v", err)
+	}
+	if len(loaded.byContract()) != 0 {
+		t.Errorf("usage loaded from the file not exist")
+	}
+}
+
+// bandwidthUsagesEqual tells whether the two lists of bandwidth usages are the same
+func bandwidthUsagesEqual(got, expect []BandwidthUsage) bool {
+	if len(got) != len(expect) {
+		return false
+	}
+	for i := range got {
+		if got[i].ID != expect[i].ID || got[i].UploadBytes != expect[i].UploadBytes || got[i].DownloadBytes != expect[i].DownloadBytes ||
+			got[i].UploadRevenue.Cmp(expect[i].UploadRevenue) != 0 || got[i].DownloadRevenue.Cmp(expect[i].DownloadRevenue) != 0 {
+			return false
+		}
+	}
+	return true
+}
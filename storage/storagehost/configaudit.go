@@ -0,0 +1,175 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// ConfigAuditEntry is a host config mutation recorded in the config audit log
+type ConfigAuditEntry struct {
+	Time time.Time `json:"time"`
+
+	// Source is the api method mutated the config
+	Source string `json:"source"`
+
+	// Caller is the remote address of the rpc caller, or local for the ipc and console calls
+	Caller string `json:"caller"`
+
+	Changes []ConfigChange `json:"changes"`
+}
+
+// ConfigChange is the change of a config field, with the json encoded old and new values
+type ConfigChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// configAuditLog is the append-only log of the host config mutations under the persist dir,
+// in which each line is a json encoded ConfigAuditEntry
+type configAuditLog struct {
+	lock sync.Mutex
+}
+
+// append appends the entry to the log file
+func (l *configAuditLog) append(path string, entry ConfigAuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// read reads the entries from the log file, paginated by offset and limit, where zero limit
+// means no limit. Empty log is returned if the log file does not exist
+func (l *configAuditLog) read(path string, offset, limit uint64) ([]ConfigAuditEntry, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	entries := make([]ConfigAuditEntry, 0)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for index := uint64(0); scanner.Scan(); index++ {
+		if index < offset {
+			continue
+		}
+		if limit != 0 && uint64(len(entries)) >= limit {
+			break
+		}
+		var entry ConfigAuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("corrupted config audit entry %d: %v", index, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// auditConfig records the changes from the previous config to the current config in the config
+// audit log. The config has been changed when the audit is recorded, so failure is only logged.
+// The persist dir is fixed once the host is created, thus the host lock is not needed
+func (h *StorageHost) auditConfig(ctx context.Context, source string, prev, cur storage.HostIntConfig) {
+	changes, err := diffConfig(prev, cur)
+	if err != nil {
+		h.log.Warn("failed to compare the host config changes", "source", source, "err", err)
+		return
+	}
+	if len(changes) == 0 {
+		return
+	}
+	entry := ConfigAuditEntry{
+		Time:    time.Now(),
+		Source:  source,
+		Caller:  rpcCaller(ctx),
+		Changes: changes,
+	}
+	if err := h.configAudit.append(filepath.Join(h.persistDir, ConfigAuditFile), entry); err != nil {
+		h.log.Warn("failed to record the host config changes", "source", source, "err", err)
+	}
+}
+
+// configHistory returns the host config mutations recorded in the config audit log
+func (h *StorageHost) configHistory(offset, limit uint64) ([]ConfigAuditEntry, error) {
+	return h.configAudit.read(filepath.Join(h.getPersistDir(), ConfigAuditFile), offset, limit)
+}
+
+// diffConfig returns the changes of the json fields between the configs, sorted by the field
+func diffConfig(prev, cur storage.HostIntConfig) ([]ConfigChange, error) {
+	prevFields, err := configFields(prev)
+	if err != nil {
+		return nil, err
+	}
+	curFields, err := configFields(cur)
+	if err != nil {
+		return nil, err
+	}
+	var changes []ConfigChange
+	for field, value := range curFields {
+		if old := prevFields[field]; !bytes.Equal(old, value) {
+			changes = append(changes, ConfigChange{Field: field, Old: old, New: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes, nil
+}
+
+// configFields returns the json encoded fields of the config
+func configFields(config storage.HostIntConfig) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// rpcCaller returns the remote address of the http rpc caller. The calls through ipc or the
+// console carry no remote address, and are considered local
+func rpcCaller(ctx context.Context) string {
+	if ctx != nil {
+		if remote, ok := ctx.Value("remote").(string); ok && remote != "" {
+			return remote
+		}
+	}
+	return "local"
+}
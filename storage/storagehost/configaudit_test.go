@@ -0,0 +1,69 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/log"
+)
+
+// TestConfigAudit test the config changes made through the api are recorded in the config
+// audit log, and could be queried with pagination
+func TestConfigAudit(t *testing.T) {
+	dir := tempDir(t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	h := NewHostPrivateAPI(&StorageHost{persistDir: dir, log: log.New()})
+	ctx := context.WithValue(context.Background(), "remote", "10.0.0.1:5000")
+	if _, err := h.SetConfig(ctx, map[string]string{"maxDuration": "100b", "acceptingContracts": "true"}); err != nil {
+		t.Fatal(err)
+	}
+	// config unchanged should not be recorded
+	if _, err := h.SetConfig(context.Background(), map[string]string{"maxDuration": "100b"}); err != nil {
+		t.Fatal(err)
+	}
+	// config reverted on error should not be recorded
+	if _, err := h.SetConfig(context.Background(), map[string]string{"maxDuration": "invalid"}); err == nil {
+		t.Fatal("invalid config should be rejected")
+	}
+	if _, err := h.SetConfig(context.Background(), map[string]string{"maxDuration": "200b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := h.ConfigHistory(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("%v entries recorded, expect 2", len(entries))
+	}
+	first := entries[0]
+	if first.Source != "shost_setConfig" || first.Caller != "10.0.0.1:5000" {
+		t.Errorf("unexpected source %v and caller %v", first.Source, first.Caller)
+	}
+	if len(first.Changes) != 2 || first.Changes[0].Field != "acceptingContracts" || first.Changes[1].Field != "maxDuration" {
+		t.Fatalf("unexpected changes %+v", first.Changes)
+	}
+	if string(first.Changes[1].Old) != "0" || string(first.Changes[1].New) != "100" {
+		t.Errorf("max duration changed from %s to %s, expect 0 to 100", first.Changes[1].Old, first.Changes[1].New)
+	}
+	if entries[1].Caller != "local" {
+		t.Errorf("caller %v, expect local", entries[1].Caller)
+	}
+
+	entries, err = h.ConfigHistory(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || string(entries[0].Changes[0].New) != "200" {
+		t.Errorf("unexpected paginated entries %+v", entries)
+	}
+}
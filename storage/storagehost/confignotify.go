@@ -0,0 +1,51 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"sync"
+
+	"github.com/DxChainNetwork/godx/event"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// configNotifier notifies the subscribers of the changes of the host config terms, which
+// are pushed to the connected storage clients
+type configNotifier struct {
+	feed event.Feed
+
+	lock     sync.Mutex
+	notified storage.HostExtConfig
+}
+
+// update records the config, and returns whether the terms changed from the config
+// notified previously
+func (n *configNotifier) update(config storage.HostExtConfig) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	changed := config.TermsChanged(n.notified)
+	n.notified = config
+	return changed
+}
+
+// SubscribeConfigUpdate subscribes the updates of the host config, which are sent once the
+// host changes the prices or stops or restarts accepting contracts
+func (h *StorageHost) SubscribeConfigUpdate(ch chan<- storage.HostExtConfig) event.Subscription {
+	return h.configNotifier.feed.Subscribe(ch)
+}
+
+// notifyConfigUpdate sends the host config to the subscribers if the terms changed since
+// the last notification. The host not started has no clients to notify
+func (h *StorageHost) notifyConfigUpdate() {
+	if h.ethBackend == nil {
+		return
+	}
+	config := h.externalConfig()
+	if h.configNotifier.update(config) {
+		h.recentConfigs.add(config)
+		h.configNotifier.feed.Send(config)
+	}
+}
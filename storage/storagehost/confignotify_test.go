@@ -0,0 +1,40 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestConfigNotifierUpdate test only the changes of the prices and the acceptance of the
+// contracts are notified
+func TestConfigNotifierUpdate(t *testing.T) {
+	var n configNotifier
+	config := storage.HostExtConfig{
+		AcceptingContracts: true,
+		StoragePrice:       common.NewBigIntUint64(10),
+		RemainingStorage:   1 << 30,
+	}
+	n.update(config)
+
+	tests := []struct {
+		modify  func(*storage.HostExtConfig)
+		changed bool
+	}{
+		{func(c *storage.HostExtConfig) { c.RemainingStorage = 1 << 20 }, false},
+		{func(c *storage.HostExtConfig) { c.StoragePrice = common.NewBigIntUint64(20) }, true},
+		{func(c *storage.HostExtConfig) { c.AcceptingContracts = false }, true},
+		{func(c *storage.HostExtConfig) {}, false},
+	}
+	for i, test := range tests {
+		test.modify(&config)
+		if changed := n.update(config); changed != test.changed {
+			t.Errorf("test %v: changed %v, expect %v", i, changed, test.changed)
+		}
+	}
+}
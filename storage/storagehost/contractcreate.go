@@ -6,7 +6,6 @@ package storagehost
 
 import (
 	"crypto/ecdsa"
-	"errors"
 	"fmt"
 
 	"github.com/DxChainNetwork/godx/accounts"
@@ -27,22 +26,35 @@ func ContractCreateHandler(h *StorageHost, sp storage.Peer, contractCreateReqMsg
 		if clientNegotiateErr != nil || clientCommitErr != nil {
 			_ = sp.SendHostAckMsg()
 			h.ethBackend.CheckAndUpdateConnection(sp.PeerNode())
+		} else if hostNegotiateErr == storage.ErrHostConfigChanged {
+			_ = sp.SendHostConfigChangedMsg()
 		} else if hostNegotiateErr != nil {
 			_ = sp.SendHostNegotiateErrorMsg()
 		}
 	}()
 
-	if !h.externalConfig().AcceptingContracts {
-		hostNegotiateErr = errors.New("host is not accepting new contracts")
-		return
-	}
-
 	// 1. Read ContractCreateRequest msg
 	var req storage.ContractCreateRequest
 	if err := contractCreateReqMsg.Decode(&req); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
 		clientNegotiateErr = fmt.Errorf("failed to decode the contract create request message: %s", err.Error())
 		return
 	}
+	if err := req.Validate(); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		clientNegotiateErr = fmt.Errorf("invalid contract create request: %s", err.Error())
+		return
+	}
+
+	// the client negotiates with the outdated host config
+	if err := h.checkHostConfigHash(req.HostConfigHash); err != nil {
+		hostNegotiateErr = err
+		return
+	}
+	if h.isReadOnly() {
+		hostNegotiateErr = errHostReadOnly
+		return
+	}
 
 	sc := req.StorageContract
 	clientPK, err := crypto.SigToPub(sc.RLPHash().Bytes(), req.Sign)
@@ -51,6 +63,13 @@ func ContractCreateHandler(h *StorageHost, sp storage.Peer, contractCreateReqMsg
 		return
 	}
 
+	// evaluate the contract against the contract policy of the host
+	if err := h.evaluateContractPolicy(sc, crypto.PubkeyToAddress(*clientPK), req.Renew, req.OldContractID); err != nil {
+		h.log.Debug("storage host rejected the contract", "reason", err)
+		hostNegotiateErr = err
+		return
+	}
+
 	// Check host balance >= storage contract cost
 	hostAddress := sc.ValidProofOutputs[1].Address
 	stateDB, err := h.ethBackend.GetBlockChain().State()
@@ -105,8 +124,12 @@ func ContractCreateHandler(h *StorageHost, sp storage.Peer, contractCreateReqMsg
 		}
 	}
 
-	// 2. After check, send host contract sign to client
-	if err := sp.SendContractCreationHostSign(hostContractSign); err != nil {
+	// 2. After check, send host contract sign to client along with the cost breakdown
+	resp := storage.ContractCreateResponse{
+		Sign: hostContractSign,
+		Cost: h.contractCostBreakdown(sc, req.Renew, req.OldContractID),
+	}
+	if err := sp.SendContractCreationHostSign(resp); err != nil {
 		log.Error("storage host failed to send contract creation host sign", "err", err)
 		return
 	}
@@ -360,6 +383,22 @@ func rollbackStorageResponsibility(h *StorageHost, so StorageResponsibility) err
 	return nil
 }
 
+// contractCostBreakdown itemizes the cost of the contract to be sent to the storage client. For
+// the renewed contract, the renew storage cost is the base price of the data in the old contract
+func (h *StorageHost) contractCostBreakdown(sc types.StorageContract, renew bool, oldContractID common.Hash) storage.ContractCostBreakdown {
+	config := h.externalConfig()
+	renewStorageCost := common.BigInt0
+	if renew {
+		h.lock.RLock()
+		so, err := getStorageResponsibility(h.db, oldContractID)
+		h.lock.RUnlock()
+		if err == nil {
+			renewStorageCost = renewBasePrice(so, config, sc)
+		}
+	}
+	return storage.NewContractCostBreakdown(sc, config.ContractPrice, renewStorageCost)
+}
+
 // renewBasePrice returns the base cost of the storage in the  contract,
 // using the host external settings and the starting file contract.
 func renewBasePrice(so StorageResponsibility, settings storage.HostExtConfig, fc types.StorageContract) common.BigInt {
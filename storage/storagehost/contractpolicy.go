@@ -0,0 +1,108 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+)
+
+var (
+	// errNotAcceptingContracts is returned if the host is not accepting new contracts
+	errNotAcceptingContracts = ErrorCreateContract("host is not accepting new contracts")
+
+	// errClientNotAllowed is returned if the client is not in the allowlist of the host
+	errClientNotAllowed = ErrorCreateContract("client is not in the allowlist of the host")
+
+	// errShortDuration is returned if the proof window of the contract starts
+	// earlier than the min contract duration of the host
+	errShortDuration = ErrorCreateContract("contract duration is shorter than the min contract duration")
+
+	// errLargeContract is returned if the file size of the contract exceeds
+	// the max contract size of the host
+	errLargeContract = ErrorCreateContract("contract size exceeds the max contract size")
+
+	// errLowPriceMargin is returned if the client funds is too low compared with
+	// the deposit the host need to lock for the contract
+	errLowPriceMargin = ErrorCreateContract("contract price margin is lower than the min price margin")
+
+	// errTooManyClientContracts is returned if the client has reached the max
+	// concurrent contracts with the host
+	errTooManyClientContracts = ErrorCreateContract("client has reached the max concurrent contracts")
+)
+
+// evaluateContractPolicy evaluate the contract create request against the contract policy of
+// the host. If the contract is rejected, the typed rejection reason is returned
+func (h *StorageHost) evaluateContractPolicy(sc types.StorageContract, clientAddr common.Address, renew bool, oldContractID common.Hash) error {
+	h.lock.RLock()
+	config := h.config
+	blockHeight := h.blockHeight
+	h.lock.RUnlock()
+	policy := config.ContractPolicy
+
+	if !config.AcceptingContracts {
+		return errNotAcceptingContracts
+	}
+
+	// the renewed contract carries over the data stored, which takes up no more free space
+	if !renew && config.LowSpace.ContractThreshold != 0 && lowSpaceForContracts(config.LowSpace, h.freeSpace()) {
+		return errHostLowSpace
+	}
+
+	// the client must be in the allowlist if the allowlist is configured
+	if len(policy.ClientAllowlist) != 0 && !addressInList(clientAddr, policy.ClientAllowlist) {
+		return errClientNotAllowed
+	}
+
+	// the proof window must not start earlier than the min duration
+	if policy.MinDuration != 0 && sc.WindowStart < blockHeight+policy.MinDuration {
+		return errShortDuration
+	}
+
+	// the file size of the contract, which is carried over on renew, must not exceed the max size
+	if policy.MaxContractSize != 0 && sc.FileSize > policy.MaxContractSize {
+		return errLargeContract
+	}
+
+	// the client funds must cover the deposit locked by the host by the min price margin
+	if policy.MinPriceMargin != 0 && len(sc.ValidProofOutputs) == 2 {
+		clientFunds := common.PtrBigInt(sc.ValidProofOutputs[0].Value)
+		hostDeposit := common.PtrBigInt(sc.ValidProofOutputs[1].Value).Sub(config.ContractPrice)
+		if hostDeposit.Sign() > 0 && clientFunds.Cmp(hostDeposit.MultFloat64(policy.MinPriceMargin)) < 0 {
+			return errLowPriceMargin
+		}
+	}
+
+	// the number of active contracts with the client must not exceed the limit. The renewed
+	// contract is not counted since it will be replaced by the new contract
+	if policy.MaxClientContracts != 0 {
+		var count uint64
+		h.lock.RLock()
+		sos := h.storageResponsibilities()
+		h.lock.RUnlock()
+		for _, so := range sos {
+			if renew && so.id() == oldContractID {
+				continue
+			}
+			if so.OriginStorageContract.ClientCollateral.Address == clientAddr {
+				count++
+			}
+		}
+		if count >= policy.MaxClientContracts {
+			return errTooManyClientContracts
+		}
+	}
+	return nil
+}
+
+// addressInList check whether the address is in the address list
+func addressInList(addr common.Address, list []common.Address) bool {
+	for _, a := range list {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestStorageHost_evaluateContractPolicy(t *testing.T) {
+	clientAddr := common.HexToAddress("0x01")
+	sc := types.StorageContract{
+		WindowStart: 1000,
+		ValidProofOutputs: []types.DxcoinCharge{
+			{Address: clientAddr, Value: big.NewInt(100)},
+			{Value: big.NewInt(110)},
+		},
+	}
+
+	tests := []struct {
+		accepting bool
+		policy    storage.HostContractPolicy
+		sc        types.StorageContract
+		expectErr error
+	}{
+		{false, storage.HostContractPolicy{}, sc, errNotAcceptingContracts},
+		{true, storage.HostContractPolicy{}, sc, nil},
+		{true, storage.HostContractPolicy{ClientAllowlist: []common.Address{clientAddr}}, sc, nil},
+		{true, storage.HostContractPolicy{ClientAllowlist: []common.Address{common.HexToAddress("0x02")}}, sc, errClientNotAllowed},
+		{true, storage.HostContractPolicy{MinDuration: 900}, sc, nil},
+		{true, storage.HostContractPolicy{MinDuration: 1100}, sc, errShortDuration},
+		{true, storage.HostContractPolicy{MaxContractSize: 1 << 22}, types.StorageContract{FileSize: 1 << 23, ValidProofOutputs: sc.ValidProofOutputs}, errLargeContract},
+		{true, storage.HostContractPolicy{MinPriceMargin: 1}, sc, nil},
+		{true, storage.HostContractPolicy{MinPriceMargin: 20}, sc, errLowPriceMargin},
+		{true, storage.HostContractPolicy{MaxClientContracts: 1}, sc, nil},
+	}
+
+	for i, test := range tests {
+		h := &StorageHost{
+			blockHeight: 50,
+			config: storage.HostIntConfig{
+				AcceptingContracts: test.accepting,
+				ContractPrice:      common.NewBigInt(10),
+				ContractPolicy:     test.policy,
+			},
+		}
+		err := h.evaluateContractPolicy(test.sc, clientAddr, false, common.Hash{})
+		if err != test.expectErr {
+			t.Errorf("test %d: error not expected. Got %v, Expect %v", i, err, test.expectErr)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// ContractRevisionHandler handles the contract revision request sent by the storage client
+// which restarted in the middle of a negotiation. The host responds with its latest revision
+// of the contract, with which the client reconciles the revision of the interrupted
+// negotiation. No revision is involved in the negotiation
+func ContractRevisionHandler(h *StorageHost, sp storage.Peer, contractRevisionReqMsg p2p.Msg) {
+	var hostNegotiateErr error
+
+	defer func() {
+		if hostNegotiateErr != nil {
+			log.Debug("contract revision negotiation failed", "err", hostNegotiateErr)
+			_ = sp.SendHostNegotiateErrorMsg()
+		}
+	}()
+
+	var req storage.ContractRevisionRequest
+	if err := contractRevisionReqMsg.Decode(&req); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		hostNegotiateErr = fmt.Errorf("error decoding the contract revision request message: %s", err.Error())
+		return
+	}
+
+	h.lock.RLock()
+	so, err := getStorageResponsibility(h.db, req.StorageContractID)
+	h.lock.RUnlock()
+	if err != nil {
+		hostNegotiateErr = fmt.Errorf("failed to get storage responsibility: %s", err.Error())
+		return
+	}
+	if len(so.StorageContractRevisions) == 0 {
+		hostNegotiateErr = errors.New("no contract revision in the storage responsibility")
+		return
+	}
+
+	if err := sp.SendContractRevision(so.StorageContractRevisions[len(so.StorageContractRevisions)-1]); err != nil {
+		log.Error("failed to send the contract revision message", "err", err)
+	}
+}
@@ -42,14 +42,18 @@ func (h *StorageHost) deleteStorageResponsibilities(soids []common.Hash) error {
 // GetStorageResponsibility will be used to get the storage responsibility information
 // based on the storage contractID provided
 func (h *StorageHost) GetStorageResponsibility(storageContractID common.Hash) (StorageResponsibility, error) {
-	h.lock.RLock()
-	defer h.lock.RUnlock()
+	if h.checkAndRLockStorageResponsibility(storageContractID) {
+		defer h.checkAndRUnlockStorageResponsibility(storageContractID)
+	}
 	return getStorageResponsibility(h.db, storageContractID)
 }
 
 //deleteStorageResponsibility delete storageResponsibility from DB
 func deleteStorageResponsibility(db ethdb.Database, storageContractID common.Hash) error {
 	scdb := ethdb.StorageContractDB{db}
+	if err := scdb.DeleteWithPrefix(storageContractID, prefixTranscript); err != nil {
+		return err
+	}
 	return scdb.DeleteWithPrefix(storageContractID, prefixStorageResponsibility)
 }
 
@@ -98,3 +102,32 @@ func getHeight(db ethdb.Database, height uint64) ([]byte, error) {
 
 	return valueBytes, nil
 }
+
+//putPublicSectors marks the sectors as public, which could be read without a contract
+func putPublicSectors(db ethdb.Database, roots []common.Hash) error {
+	scdb := ethdb.StorageContractDB{db}
+	for _, root := range roots {
+		if err := scdb.StoreWithPrefix(root, []byte{1}, prefixPublicSector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//deletePublicSectors removes the public marks of the sectors
+func deletePublicSectors(db ethdb.Database, roots []common.Hash) error {
+	scdb := ethdb.StorageContractDB{db}
+	for _, root := range roots {
+		if err := scdb.DeleteWithPrefix(root, prefixPublicSector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//isPublicSector checks whether the sector is marked as public
+func isPublicSector(db ethdb.Database, root common.Hash) bool {
+	scdb := ethdb.StorageContractDB{db}
+	_, err := scdb.GetWithPrefix(root, prefixPublicSector)
+	return err == nil
+}
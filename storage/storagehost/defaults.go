@@ -6,11 +6,13 @@ package storagehost
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/unit"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/storage"
 )
 
@@ -21,6 +23,10 @@ const (
 	Version = "1.0"
 	// HostSettingFile is the file name for saving the setting of host
 	HostSettingFile = "host.json"
+	// ConfigAuditFile is the file name of the append-only log of the host config changes
+	ConfigAuditFile = "configaudit.log"
+	// BandwidthFile is the file name for saving the bandwidth served per contract and per client
+	BandwidthFile = "bandwidth.json"
 	// HostDB is the database dir for storing host obligation
 	databaseFile = "hostdb"
 	// StorageManager is a dir for storagemanager related topic
@@ -38,8 +44,53 @@ const (
 	//prefixHeight db prefix for task
 	prefixHeight = "height-"
 
+	//prefixRevisionJournal db prefix for revision journal
+	prefixRevisionJournal = "RevisionJournal-"
+
+	//prefixPublicSector db prefix for the sectors could be read publicly
+	prefixPublicSector = "PublicSector-"
+
+	//prefixTranscript db prefix for the negotiation transcript of the storage responsibility
+	prefixTranscript = "Transcript-"
+
 	//Total time to sign the contract
 	postponedExecutionBuffer = 12 * unit.BlocksPerHour
+
+	//responsibilityLockShards is the number of shards of the storage responsibility locks
+	responsibilityLockShards = 32
+
+	//responsibilityDeadlockTimeout is the time waiting for the storage responsibility lock
+	//before a possible deadlock is reported
+	responsibilityDeadlockTimeout = 5 * time.Minute
+
+	//maxStorageProofBatchSize is the max number of storage proofs sent in a single transaction
+	maxStorageProofBatchSize = 64
+
+	//maxStorageProofBatchPayload is the max size of the payload of the storage proof batch
+	//transaction, which is kept below the size limit of the storage transaction payload
+	maxStorageProofBatchPayload = 30 * 1024
+
+	//alertTimeout is the timeout of executing the alert command or posting the alert url
+	alertTimeout = 10 * time.Second
+
+	//alertProofHorizon is the number of blocks ahead the upcoming storage proofs are checked
+	//against the balance of the host
+	alertProofHorizon = unit.BlocksPerDay
+
+	//feeReserveTopUpInterval is the number of blocks waited for the top up transaction to be
+	//included before the fee reserve is topped up again
+	feeReserveTopUpInterval = 20
+
+	//bandwidthPersistInterval is the interval the bandwidth usage is saved to the bandwidth file
+	bandwidthPersistInterval = 10 * time.Minute
+
+	//proofRiskBlocks is the number of blocks before the proof deadline, within which the storage
+	//proof still not included is at risk of missing the proof window
+	proofRiskBlocks = unit.BlocksPerHour
+
+	//recentConfigsSize is the number of the latest host configs kept, against which the
+	//changes of the host config are sent to the clients
+	recentConfigsSize = 256
 )
 
 var (
@@ -51,8 +102,17 @@ var (
 		Version: "V1.0",
 	}
 
+	bandwidthMeta = common.Metadata{
+		Header:  "DxChain StorageHost Bandwidth JSON",
+		Version: "V1.0",
+	}
+
 	//Storage contract should not be empty
 	emptyStorageContract = types.StorageContract{}
+
+	//alertProofTxFee is the estimated fee of a storage proof transaction, checked against
+	//the balance of the host for the alert
+	alertProofTxFee = common.NewBigInt(90000 * params.GWei)
 )
 
 // init set the initial value for sector height
@@ -88,6 +148,20 @@ func defaultConfig() storage.HostIntConfig {
 		SectorAccessPrice:      storage.DefaultSectorAccessPrice,
 		StoragePrice:           storage.DefaultStoragePrice,
 		UploadBandwidthPrice:   storage.DefaultUploadBandwidthPrice,
+
+		DownloadLimits: storage.HostDownloadLimits{
+			MaxSections:  storage.DefaultMaxDownloadSections,
+			MaxTotalSize: storage.DefaultMaxDownloadSize,
+			MaxProofSize: storage.DefaultMaxDownloadProofSize,
+		},
+
+		ReadCacheSize: storage.DefaultReadCacheSize,
+
+		ReadIO: storage.HostReadIOConfig{
+			Workers:         storage.DefaultReadWorkers,
+			Readahead:       storage.DefaultReadahead,
+			DiskConcurrency: storage.DefaultDiskReadConcurrency,
+		},
 	}
 }
 
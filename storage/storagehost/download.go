@@ -8,36 +8,57 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
-	"math/bits"
 	"reflect"
 
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/core/types"
-	"github.com/DxChainNetwork/godx/crypto/merkle"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p"
 	"github.com/DxChainNetwork/godx/storage"
 )
 
-// DownloadHandler handles the download negotiation
+// DownloadHandler handles the download negotiation of a single section
 func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
+	handleDownload(h, sp, downloadReqMsg, true)
+}
+
+// DownloadBatchHandler handles the download negotiation of multiple sections
+func DownloadBatchHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
+	handleDownload(h, sp, downloadReqMsg, false)
+}
+
+// handleDownload handles the download negotiation. The single section request is handled as
+// the batch of one section, and responded in the form of the request
+func handleDownload(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg, single bool) {
 	var hostNegotiateErr, clientNegotiateErr, clientCommitErr error
 
 	defer func() {
 		if clientNegotiateErr != nil || clientCommitErr != nil {
 			_ = sp.SendHostAckMsg()
 			h.ethBackend.CheckAndUpdateConnection(sp.PeerNode())
+		} else if hostNegotiateErr == storage.ErrHostConfigChanged {
+			_ = sp.SendHostConfigChangedMsg()
 		} else if hostNegotiateErr != nil {
 			_ = sp.SendHostNegotiateErrorMsg()
 		}
 	}()
 
-	// read the download request.
-	var req storage.DownloadRequest
-	err := downloadReqMsg.Decode(&req)
+	// read the download request. The request received is kept for the transcript
+	req, received, err := decodeDownloadRequest(downloadReqMsg, single)
 	if err != nil {
-		clientNegotiateErr = fmt.Errorf("error decoding the download request message: %s", err.Error())
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		clientNegotiateErr = err
+		return
+	}
+	if err := validateDownloadLimits(req.Sections, req.MerkleProof, h.getInternalConfig().DownloadLimits); err != nil {
+		hostNegotiateErr = err
+		return
+	}
+
+	// the client negotiates with the outdated host config
+	if err := h.checkHostConfigHash(req.HostConfigHash); err != nil {
+		hostNegotiateErr = err
 		return
 	}
 
@@ -52,6 +73,10 @@ func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
 		hostNegotiateErr = err
 		return
 	}
+	if h.isReadOnly() {
+		hostNegotiateErr = errHostReadOnly
+		return
+	}
 
 	// check whether the contract is empty
 	if reflect.DeepEqual(so.OriginStorageContract, types.StorageContract{}) {
@@ -63,18 +88,32 @@ func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
 	currentRevision := so.StorageContractRevisions[len(so.StorageContractRevisions)-1]
 
 	// Validate the request.
-	sec := req.Sector
-	switch {
-	case uint64(sec.Offset)+uint64(sec.Length) > storage.SectorSize:
-		err = errors.New("download out boundary of sector")
-	case sec.Length == 0:
-		err = errors.New("length cannot be 0")
-	case req.MerkleProof && (sec.Offset%storage.SegmentSize != 0 || sec.Length%storage.SegmentSize != 0):
-		err = errors.New("offset and length must be multiples of SegmentSize when requesting a Merkle proof")
-	case len(req.NewValidProofValues) != len(currentRevision.NewValidProofOutputs):
-		err = errors.New("the number of valid proof values not match the old")
-	case len(req.NewMissedProofValues) != len(currentRevision.NewMissedProofOutputs):
-		err = errors.New("the number of missed proof values not match the old")
+	var totalLength uint64
+	for _, sec := range req.Sections {
+		switch {
+		case uint64(sec.Offset)+uint64(sec.Length) > storage.SectorSize:
+			err = errors.New("download out boundary of sector")
+		case sec.Length == 0:
+			err = errors.New("length cannot be 0")
+		case req.MerkleProof && (sec.Offset%storage.SegmentSize != 0 || sec.Length%storage.SegmentSize != 0):
+			err = errors.New("offset and length must be multiples of SegmentSize when requesting a Merkle proof")
+		}
+		if err != nil {
+			break
+		}
+		totalLength += uint64(sec.Length)
+	}
+	if err == nil {
+		switch {
+		case len(req.Sections) == 0:
+			err = errors.New("no section requested")
+		case len(req.Sections) > 1 && totalLength > settings.MaxDownloadBatchSize:
+			err = fmt.Errorf("download batch size %v exceeds the max download batch size %v", totalLength, settings.MaxDownloadBatchSize)
+		case len(req.NewValidProofValues) != len(currentRevision.NewValidProofOutputs):
+			err = errors.New("the number of valid proof values not match the old")
+		case len(req.NewMissedProofValues) != len(currentRevision.NewMissedProofOutputs):
+			err = errors.New("the number of missed proof values not match the old")
+		}
 	}
 	if err != nil {
 		hostNegotiateErr = fmt.Errorf("download request validation failed: %s", err.Error())
@@ -104,9 +143,10 @@ func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
 	sectorAccesses := make(map[common.Hash]struct{})
 	// use the worst-case proof size of 2*tree depth (this occurs when
 	// proving across the two leaves in the center of the tree)
-	estHashesPerProof := 2 * bits.Len64(storage.SectorSize/merkle.LeafSize)
-	estBandwidth += uint64(sec.Length) + uint64(estHashesPerProof*storage.HashSize)
-	sectorAccesses[sec.MerkleRoot] = struct{}{}
+	for _, sec := range req.Sections {
+		estBandwidth += uint64(sec.Length) + estProofSizePerSection
+		sectorAccesses[sec.MerkleRoot] = struct{}{}
+	}
 
 	// calculate total cost
 	bandwidthCost := settings.DownloadBandwidthPrice.MultUint64(estBandwidth)
@@ -139,35 +179,36 @@ func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
 	so.PotentialDownloadRevenue = so.PotentialDownloadRevenue.Add(paymentTransfer)
 	so.StorageContractRevisions = append(so.StorageContractRevisions, newRevision)
 
-	// fetch the requested data from host local storage
-	sectorData, err := h.ReadSector(sec.MerkleRoot)
+	// fetch the requested data of each section from host local storage, and
+	// construct the Merkle proofs, if requested. The sections are read concurrently
+	data, proofs, err := readSections(req.Sections, req.MerkleProof, h.getInternalConfig().ReadIO, h.ReadSector)
 	if err != nil {
-		hostNegotiateErr = fmt.Errorf("host failed read sector: %s", err.Error())
+		hostNegotiateErr = err
 		return
 	}
-	data := sectorData[sec.Offset : sec.Offset+sec.Length]
 
-	// construct the Merkle proof, if requested.
-	var proof []common.Hash
-	if req.MerkleProof {
-		proofStart := int(sec.Offset) / merkle.LeafSize
-		proofEnd := int(sec.Offset+sec.Length) / merkle.LeafSize
-		proof, err = merkle.Sha256RangeProof(sectorData, proofStart, proofEnd)
-		if err != nil {
-			hostNegotiateErr = fmt.Errorf("host failed to generate the merkle proof: %s", err.Error())
-			return
-		}
+	// send the response
+	resp := storage.DownloadBatchResponse{
+		Signature:    nil,
+		Data:         data,
+		MerkleProofs: proofs,
 	}
 
-	// send the response
-	resp := storage.DownloadResponse{
-		Signature:   nil,
-		Data:        data,
-		MerkleProof: proof,
+	// journal the negotiation before the host signature is sent out. The journal is deleted
+	// when the negotiation finished, and used to recover the negotiation if the host crashed
+	if err := h.journalRevision(snapshotSo, newRevision, nil); err != nil {
+		hostNegotiateErr = fmt.Errorf("failed to journal the revision: %s", err.Error())
+		return
 	}
+	defer h.finishRevisionJournal(snapshotSo.id())
 
 	resp.Signature = hostSig
-	if err := sp.SendContractDownloadData(resp); err != nil {
+	if single {
+		err = sp.SendContractDownloadData(resp.Single())
+	} else {
+		err = sp.SendContractDownloadBatchData(resp)
+	}
+	if err != nil {
 		log.Error("failed to send the contract download data message", "err", err)
 		return
 	}
@@ -213,12 +254,47 @@ func DownloadHandler(h *StorageHost, sp storage.Peer, downloadReqMsg p2p.Msg) {
 		h.ethBackend.SetStatic(node)
 	}
 
+	// the client keeps the new revision once the host ack is received, thus the revision
+	// shall not be rolled back on recovery afterwards
+	if err := h.acknowledgeRevisionJournal(snapshotSo.id()); err != nil {
+		log.Error("storage host failed to acknowledge the revision journal", "err", err)
+		_ = h.rollbackStorageResponsibility(snapshotSo, nil, nil, nil)
+		return
+	}
+
 	// send host 'ACK' msg to client
 	if err := sp.SendHostAckMsg(); err != nil {
 		log.Error("storage host failed to send host ack msg", "err", err)
 		_ = h.rollbackStorageResponsibility(snapshotSo, nil, nil, nil)
 		h.ethBackend.CheckAndUpdateConnection(sp.PeerNode())
+		return
+	}
+	h.appendTranscript(so.id(), received, hostSig, newRevision.NewRevisionNumber, wallet, account)
+	h.recordBandwidth(so.id(), sp, 0, uint64(len(data)), paymentTransfer)
+}
+
+// decodeDownloadRequest decodes the download request message, and validates the sizes of its
+// fields. The single section request is converted to the batch request, and the request
+// decoded is returned as well
+func decodeDownloadRequest(msg p2p.Msg, single bool) (storage.DownloadBatchRequest, interface{}, error) {
+	var req storage.DownloadBatchRequest
+	var received interface{}
+	var err error
+	if single {
+		var singleReq storage.DownloadRequest
+		err = msg.Decode(&singleReq)
+		req, received = singleReq.Batch(), singleReq
+	} else {
+		err = msg.Decode(&req)
+		received = req
+	}
+	if err != nil {
+		return storage.DownloadBatchRequest{}, nil, fmt.Errorf("error decoding the download request message: %s", err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return storage.DownloadBatchRequest{}, nil, fmt.Errorf("invalid download request: %s", err.Error())
 	}
+	return req, received, nil
 }
 
 // verifyPaymentRevision verifies that the revision being provided to pay for
@@ -0,0 +1,55 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"math/bits"
+
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+var (
+	// errTooManySections is returned if the download request has more sections than the
+	// max sections of the host
+	errTooManySections = ErrorDownloadLimit("number of sections exceeds the max download sections")
+
+	// errDownloadTooLarge is returned if the total length of the sections exceeds the
+	// max download size of the host
+	errDownloadTooLarge = ErrorDownloadLimit("total length of sections exceeds the max download size")
+
+	// errProofTooLarge is returned if the merkle proofs requested exceeds the max proof
+	// size of the host
+	errProofTooLarge = ErrorDownloadLimit("merkle proof size exceeds the max download proof size")
+)
+
+// estProofSizePerSection is the worst-case size of the merkle proof of a section, which
+// is 2*tree depth hashes when proving across the two leaves in the center of the tree
+var estProofSizePerSection = uint64(2*bits.Len64(storage.SectorSize/merkle.LeafSize)) * storage.HashSize
+
+// validateDownloadLimits checks the sections requested against the download limits of the
+// host. It only looks at the request itself, so that the pathological request is rejected
+// before any sector is read
+func validateDownloadLimits(sections []storage.DownloadRequestSector, merkleProof bool, limits storage.HostDownloadLimits) error {
+	numSections := uint64(len(sections))
+	if limits.MaxSections != 0 && numSections > limits.MaxSections {
+		return errTooManySections
+	}
+
+	if limits.MaxTotalSize != 0 {
+		var totalLength uint64
+		for _, sec := range sections {
+			totalLength += uint64(sec.Length)
+		}
+		if totalLength > limits.MaxTotalSize {
+			return errDownloadTooLarge
+		}
+	}
+
+	if merkleProof && limits.MaxProofSize != 0 && numSections*estProofSizePerSection > limits.MaxProofSize {
+		return errProofTooLarge
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestValidateDownloadLimits(t *testing.T) {
+	sections := make([]storage.DownloadRequestSector, 4)
+	for i := range sections {
+		sections[i] = storage.DownloadRequestSector{Length: 1 << 20}
+	}
+
+	tests := []struct {
+		merkleProof bool
+		limits      storage.HostDownloadLimits
+		expectErr   error
+	}{
+		{true, storage.HostDownloadLimits{}, nil},
+		{true, storage.HostDownloadLimits{MaxSections: 4}, nil},
+		{true, storage.HostDownloadLimits{MaxSections: 3}, errTooManySections},
+		{true, storage.HostDownloadLimits{MaxTotalSize: 4 << 20}, nil},
+		{true, storage.HostDownloadLimits{MaxTotalSize: 4<<20 - 1}, errDownloadTooLarge},
+		{true, storage.HostDownloadLimits{MaxProofSize: 4 * estProofSizePerSection}, nil},
+		{true, storage.HostDownloadLimits{MaxProofSize: 4*estProofSizePerSection - 1}, errProofTooLarge},
+		{false, storage.HostDownloadLimits{MaxProofSize: 1}, nil},
+	}
+
+	for i, test := range tests {
+		err := validateDownloadLimits(sections, test.merkleProof, test.limits)
+		if err != test.expectErr {
+			t.Errorf("test %d: expect error %v, got %v", i, test.expectErr, err)
+		}
+		if err != nil {
+			if _, ok := err.(ErrorDownloadLimit); !ok {
+				t.Errorf("test %d: expect the typed download limit error, got %T", i, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// sendFeeReservedTx sends the storage proof or revision transaction with the send function from
+// the fee reserve, so that the gas is paid by the reserve instead of the host address. The
+// storage transactions are authorized by the signatures in the payload, thus could be sent from
+// any account. If the fee reserve is not configured or failed to send the transaction, the
+// transaction is sent from the host address
+func (h *StorageHost) sendFeeReservedTx(reserve, from common.Address, input []byte, send func(common.Address, []byte) (common.Hash, error)) (common.Hash, error) {
+	if reserve != (common.Address{}) {
+		hash, err := send(reserve, input)
+		if err == nil {
+			return hash, nil
+		}
+		h.log.Warn("Failed to send the transaction from the fee reserve", "reserve", reserve, "err", err)
+	}
+	return send(from, input)
+}
+
+// topUpFeeReserve transfers from the payment address to the fee reserve when the balance of the
+// reserve drops below the low watermark, so that the reserve is kept funded by the revenue of
+// the host. A top up is sent at most once per feeReserveTopUpInterval blocks, waiting for the
+// previous one to be included
+func (h *StorageHost) topUpFeeReserve() {
+	h.lock.RLock()
+	reserve := h.config.FeeReserve
+	height, lastTopUp := h.blockHeight, h.feeReserveTopUpHeight
+	h.lock.RUnlock()
+	if reserve.Address == (common.Address{}) || reserve.LowWatermark.Sign() <= 0 {
+		return
+	}
+	if lastTopUp != 0 && height < lastTopUp+feeReserveTopUpInterval {
+		return
+	}
+
+	stateDB, err := h.ethBackend.GetBlockChain().State()
+	if err != nil {
+		h.log.Warn("failed to get the state db for the fee reserve", "err", err)
+		return
+	}
+	amount := feeReserveTopUpAmount(reserve, common.PtrBigInt(stateDB.GetBalance(reserve.Address)))
+	if amount.Sign() <= 0 {
+		return
+	}
+	paymentAddress, err := h.getPaymentAddress()
+	if err != nil {
+		h.log.Warn("failed to get the payment address for the fee reserve", "err", err)
+		return
+	}
+	// transfer what the payment address could afford, leaving the fee of the transfer
+	if available := common.PtrBigInt(stateDB.GetBalance(paymentAddress)).Sub(alertProofTxFee); available.Cmp(amount) < 0 {
+		amount = available
+	}
+	if amount.Sign() <= 0 {
+		h.log.Warn("payment address could not afford to top up the fee reserve", "paymentAddress", paymentAddress)
+		return
+	}
+	if _, err = h.parseAPI.StorageTx.SendFeeReserveTopUpTX(paymentAddress, reserve.Address, amount.BigIntPtr()); err != nil {
+		h.log.Warn("failed to top up the fee reserve", "reserve", reserve.Address, "err", err)
+		return
+	}
+	h.log.Info("Fee reserve topped up", "reserve", reserve.Address, "amount", amount)
+
+	h.lock.Lock()
+	h.feeReserveTopUpHeight = height
+	h.lock.Unlock()
+}
+
+// feeReserveTopUpAmount returns the amount to top up the fee reserve with the balance. The
+// reserve is topped up to the target once the balance drops below the low watermark
+func feeReserveTopUpAmount(reserve storage.HostFeeReserveConfig, balance common.BigInt) common.BigInt {
+	if reserve.LowWatermark.Sign() <= 0 || balance.Cmp(reserve.LowWatermark) >= 0 {
+		return common.BigInt0
+	}
+	target := reserve.Target
+	if target.Cmp(reserve.LowWatermark) < 0 {
+		target = reserve.LowWatermark
+	}
+	return target.Sub(balance)
+}
@@ -0,0 +1,73 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestFeeReserveTopUpAmount test the fee reserve is topped up to the target below the low watermark
+func TestFeeReserveTopUpAmount(t *testing.T) {
+	tests := []struct {
+		reserve storage.HostFeeReserveConfig
+		balance int64
+		amount  int64
+	}{
+		{storage.HostFeeReserveConfig{LowWatermark: common.NewBigInt(100), Target: common.NewBigInt(300)}, 100, 0},
+		{storage.HostFeeReserveConfig{LowWatermark: common.NewBigInt(100), Target: common.NewBigInt(300)}, 99, 201},
+		{storage.HostFeeReserveConfig{LowWatermark: common.NewBigInt(100)}, 40, 60},
+		{storage.HostFeeReserveConfig{Target: common.NewBigInt(300)}, 0, 0},
+	}
+	for i, test := range tests {
+		amount := feeReserveTopUpAmount(test.reserve, common.NewBigInt(test.balance))
+		if amount.Cmp(common.NewBigInt(test.amount)) != 0 {
+			t.Errorf("test %d: top up amount %v, expect %v", i, amount, test.amount)
+		}
+	}
+}
+
+// TestSendFeeReservedTx test the transaction is sent from the fee reserve, and falls back to the
+// host address if the reserve is not configured or failed
+func TestSendFeeReservedTx(t *testing.T) {
+	h := &StorageHost{log: log.New()}
+	reserve, host := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+
+	var senders []common.Address
+	send := func(from common.Address, input []byte) (common.Hash, error) {
+		senders = append(senders, from)
+		if from == reserve && len(input) == 0 {
+			return common.Hash{}, errors.New("insufficient funds")
+		}
+		return common.Hash{}, nil
+	}
+	tests := []struct {
+		reserve common.Address
+		input   []byte
+		senders []common.Address
+	}{
+		{reserve, []byte{1}, []common.Address{reserve}},
+		{reserve, nil, []common.Address{reserve, host}},
+		{common.Address{}, []byte{1}, []common.Address{host}},
+	}
+	for i, test := range tests {
+		senders = nil
+		if _, err := h.sendFeeReservedTx(test.reserve, host, test.input, send); err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if len(senders) != len(test.senders) {
+			t.Fatalf("test %d: sent from %v, expect %v", i, senders, test.senders)
+		}
+		for j := range senders {
+			if senders[j] != test.senders[j] {
+				t.Errorf("test %d: sent from %v, expect %v", i, senders, test.senders)
+			}
+		}
+	}
+}
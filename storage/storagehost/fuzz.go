@@ -0,0 +1,46 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+// +build gofuzz
+
+package storagehost
+
+import (
+	"bytes"
+
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// FuzzUploadRequest is the entry point for the go-fuzz tool, decoding the upload request
+// with the streaming decoder used by the upload handler.
+//
+// This returns 1 if the request is decoded, 0 otherwise. It panics if the request decoded
+// by the streaming decoder is different from the one decoded at once, or the merkle roots
+// calculated while streaming do not match the sector data
+func FuzzUploadRequest(input []byte) int {
+	msg := p2p.Msg{Size: uint32(len(input)), Payload: bytes.NewReader(input)}
+	req, roots, err := decodeUploadRequest(msg)
+	if err != nil {
+		return 0
+	}
+
+	var expected storage.UploadRequest
+	if err := rlp.NewStream(bytes.NewReader(input), uint64(len(input))).Decode(&expected); err != nil {
+		panic("streaming decoder accepted the invalid upload request: " + err.Error())
+	}
+	got, _ := rlp.EncodeToBytes(req)
+	want, _ := rlp.EncodeToBytes(expected)
+	if !bytes.Equal(got, want) {
+		panic("streaming decoder decoded a different upload request")
+	}
+	for i, action := range req.Actions {
+		if action.Type == storage.UploadActionAppend && roots[i] != merkle.Sha256MerkleTreeRoot(action.Data) {
+			panic("streaming merkle root mismatch")
+		}
+	}
+	return 1
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/DxChainNetwork/godx/core"
 	"github.com/DxChainNetwork/godx/core/types"
 	"github.com/DxChainNetwork/godx/core/vm"
-	"github.com/DxChainNetwork/godx/rlp"
 )
 
 // subscribeChainChangeEvent will receive changes on the block chain (blocks added / reverted)
@@ -41,9 +40,17 @@ func (h *StorageHost) hostBlockHeightChange(cce core.ChainChangeEvent) {
 
 	//Block executing the main chain
 	taskItems := h.applyBlockHashesStorageResponsibility(cce.AppliedBlockHashes)
+	proofs := make(storageProofBatches)
 	for i := range taskItems {
-		h.handleTaskItem(taskItems[i])
+		h.handleTaskItem(taskItems[i], proofs)
 	}
+	h.sendStorageProofs(proofs)
+
+	// prioritize the storage proofs still not included when the proof window is closing
+	h.prioritizeProofsAtRisk()
+
+	// top up the fee reserve from the payment address
+	h.topUpFeeReserve()
 
 	// update the contractToClientID
 	h.UpdateContractToClientNodeMappingAndConnection()
@@ -53,6 +60,12 @@ func (h *StorageHost) hostBlockHeightChange(cce core.ChainChangeEvent) {
 	if err != nil {
 		h.log.Error("could not save during ProcessConsensusChange", "err", err)
 	}
+
+	// alert the host operator of the risky conditions
+	h.checkAlerts()
+
+	// notify the clients if the host stopped accepting contracts for the low space
+	h.notifyConfigUpdate()
 }
 
 //applyBlockHashesStorageResponsibility block executing the main chain
@@ -114,6 +127,7 @@ func (h *StorageHost) applyBlockHashesStorageResponsibility(blocks []common.Hash
 				continue
 			}
 			so.StorageProofConfirmed = true
+			h.proofs.confirmed(id)
 			errPut := putStorageResponsibility(h.db, so.id(), so)
 			if errPut != nil {
 				h.log.Error("Failed to put storage responsibility", "err", errPut)
@@ -215,26 +229,26 @@ func (h *StorageHost) revertedBlockHashesStorageResponsibility(blocks []common.H
 //getAllStorageContractIDsWithBlockHash analyze the block structure and get three kinds of transaction collections: contractCreate, revision, and proof、block height.
 func (h *StorageHost) getAllStorageContractIDsWithBlockHash(blockHash common.Hash) (ContractCreateIDs []common.Hash, revisionIDs map[common.Hash]uint64, storageProofIDs []common.Hash, number uint64, errGet error) {
 	revisionIDs = make(map[common.Hash]uint64)
-	precompiled := vm.PrecompiledStorageContracts
 	block, err := h.ethBackend.GetBlockByHash(blockHash)
 	if err != nil {
 		errGet = err
 		return
 	}
 	number = block.NumberU64()
+	config := h.ethBackend.ChainConfig()
 	txs := block.Transactions()
 	for _, tx := range txs {
 		if tx.To() == nil {
 			continue
 		}
-		p, ok := precompiled[*tx.To()]
+		p, ok := vm.ActiveStorageContractTxType(config, block.Number(), *tx.To())
 		if !ok {
 			continue
 		}
 		switch p {
 		case vm.ContractCreateTransaction:
 			var sc types.StorageContract
-			err := rlp.DecodeBytes(tx.Data(), &sc)
+			err := vm.DecodeStoragePayloadAt(config, block.Number(), tx.Data(), &sc)
 			if err != nil {
 				h.log.Error("Error when serializing storage contract:", "err", err)
 				continue
@@ -242,7 +256,7 @@ func (h *StorageHost) getAllStorageContractIDsWithBlockHash(blockHash common.Has
 			ContractCreateIDs = append(ContractCreateIDs, sc.RLPHash())
 		case vm.CommitRevisionTransaction:
 			var scr types.StorageContractRevision
-			err := rlp.DecodeBytes(tx.Data(), &scr)
+			err := vm.DecodeStoragePayloadAt(config, block.Number(), tx.Data(), &scr)
 			if err != nil {
 				h.log.Error("Error when serializing revision:", "err", err)
 				continue
@@ -250,12 +264,32 @@ func (h *StorageHost) getAllStorageContractIDsWithBlockHash(blockHash common.Has
 			revisionIDs[scr.ParentID] = scr.NewRevisionNumber
 		case vm.StorageProofTransaction:
 			var sp types.StorageProof
-			err := rlp.DecodeBytes(tx.Data(), &sp)
+			err := vm.DecodeStoragePayloadAt(config, block.Number(), tx.Data(), &sp)
 			if err != nil {
 				h.log.Error("Error when serializing proof:", "err", err)
 				continue
 			}
 			storageProofIDs = append(storageProofIDs, sp.ParentID)
+		case vm.StorageProofBatchTransaction:
+			var batch types.StorageProofBatch
+			err := vm.DecodeStoragePayloadAt(config, block.Number(), tx.Data(), &batch)
+			if err != nil {
+				h.log.Error("Error when serializing proof batch:", "err", err)
+				continue
+			}
+			for _, sp := range batch.Proofs {
+				storageProofIDs = append(storageProofIDs, sp.ParentID)
+			}
+		case vm.RenewContractTransaction:
+			var renewal types.StorageContractRenewal
+			err := vm.DecodeStoragePayloadAt(config, block.Number(), tx.Data(), &renewal)
+			if err != nil {
+				h.log.Error("Error when serializing renewal:", "err", err)
+				continue
+			}
+			// the renewal creates the new contract and settles the old one as if proofed
+			ContractCreateIDs = append(ContractCreateIDs, renewal.NewContract.RLPHash())
+			storageProofIDs = append(storageProofIDs, renewal.OldContractID)
 		default:
 			continue
 		}
@@ -11,6 +11,7 @@ import (
 	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/event"
 	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/params"
 	"github.com/DxChainNetwork/godx/rlp"
 	"github.com/DxChainNetwork/godx/rpc"
 )
@@ -179,6 +180,7 @@ func (m *mockHostBackend) SubscribeChainChangeEvent(ch chan<- core.ChainChangeEv
 	return nil
 }
 func (m *mockHostBackend) GetBlockChain() *core.BlockChain               { return nil }
+func (m *mockHostBackend) ChainConfig() *params.ChainConfig              { return params.TestChainConfig }
 func (m *mockHostBackend) AccountManager() *accounts.Manager             { return nil }
 func (m *mockHostBackend) SetStatic(node *enode.Node)                    {}
 func (m *mockHostBackend) CheckAndUpdateConnection(peerNode *enode.Node) {}
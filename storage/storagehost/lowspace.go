@@ -0,0 +1,45 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// errHostLowSpace is returned if the host rejects the new contracts for the low free space
+var errHostLowSpace = ErrorCreateContract("host is low on free space")
+
+// freeSpace returns the free space of the storage manager in bytes
+func (h *StorageHost) freeSpace() uint64 {
+	return h.StorageManager.AvailableSpace().FreeSectors * storage.SectorSize
+}
+
+// lowSpaceForContracts returns whether the free space is below the threshold for accepting
+// new contracts
+func lowSpaceForContracts(config storage.HostLowSpaceConfig, free uint64) bool {
+	return config.ContractThreshold != 0 && free < config.ContractThreshold
+}
+
+// advertisedRemainingStorage returns the remaining storage advertised to the clients, which
+// excludes the free space kept by the append threshold
+func advertisedRemainingStorage(config storage.HostLowSpaceConfig, free uint64) uint64 {
+	if free <= config.AppendThreshold {
+		return 0
+	}
+	return free - config.AppendThreshold
+}
+
+// checkAppendSpace returns ErrHostFull if the sectors appended would take up the free space
+// kept by the append threshold
+func (h *StorageHost) checkAppendSpace(sectors int) error {
+	config := h.getInternalConfig().LowSpace
+	if config.AppendThreshold == 0 {
+		return nil
+	}
+	if advertisedRemainingStorage(config, h.freeSpace()) < uint64(sectors)*storage.SectorSize {
+		return storage.ErrHostFull
+	}
+	return nil
+}
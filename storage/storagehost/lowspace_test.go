@@ -0,0 +1,37 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestLowSpaceDegradation test the new contracts are rejected below the contract threshold, and
+// the free space kept by the append threshold is not advertised
+func TestLowSpaceDegradation(t *testing.T) {
+	tests := []struct {
+		config     storage.HostLowSpaceConfig
+		free       uint64
+		lowSpace   bool
+		advertised uint64
+	}{
+		{storage.HostLowSpaceConfig{}, 0, false, 0},
+		{storage.HostLowSpaceConfig{}, 1 << 30, false, 1 << 30},
+		{storage.HostLowSpaceConfig{ContractThreshold: 1 << 30}, 1 << 30, false, 1 << 30},
+		{storage.HostLowSpaceConfig{ContractThreshold: 1 << 30}, 1<<30 - 1, true, 1<<30 - 1},
+		{storage.HostLowSpaceConfig{AppendThreshold: 1 << 20}, 1 << 30, false, 1<<30 - 1<<20},
+		{storage.HostLowSpaceConfig{AppendThreshold: 1 << 20}, 1 << 19, false, 0},
+	}
+	for i, test := range tests {
+		if lowSpace := lowSpaceForContracts(test.config, test.free); lowSpace != test.lowSpace {
+			t.Errorf("test %d: low space %v, expect %v", i, lowSpace, test.lowSpace)
+		}
+		if advertised := advertisedRemainingStorage(test.config, test.free); advertised != test.advertised {
+			t.Errorf("test %d: advertised %v, expect %v", i, advertised, test.advertised)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/accounts"
+	"github.com/DxChainNetwork/godx/accounts/keystore"
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/common/hexutil"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// hostExportMeta is the metadata of the host export file
+var hostExportMeta = common.Metadata{
+	Header:  "DxChain StorageHost Export",
+	Version: "V1.0",
+}
+
+type (
+	// hostExport is the package of the storage host for migrating the host to another
+	// machine. The sector data is not included in the package, but referenced by the
+	// sector roots of the storage responsibilities and the storage folders, which shall
+	// be moved to the target machine with the storage manager
+	hostExport struct {
+		BlockHeight      uint64                 `json:"blockHeight"`
+		Config           storage.HostIntConfig  `json:"config"`
+		FinancialMetrics HostFinancialMetrics   `json:"financialMetrics"`
+		Contracts        map[string]common.Hash `json:"contracts"`
+		Responsibilities []hexutil.Bytes        `json:"responsibilities"`
+		Tasks            []exportedTask         `json:"tasks"`
+		Folders          []storage.HostFolder   `json:"folders"`
+		HostKey          json.RawMessage        `json:"hostKey,omitempty"`
+		Checksum         common.Hash            `json:"checksum"`
+	}
+
+	// exportedTask is the storage responsibilities scheduled to be checked at the height
+	exportedTask struct {
+		Height uint64        `json:"height"`
+		IDs    []common.Hash `json:"ids"`
+	}
+)
+
+// checksum returns the hash of the package with the checksum field cleared
+func (he hostExport) checksum() (common.Hash, error) {
+	he.Checksum = common.Hash{}
+	b, err := json.Marshal(he)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(b), nil
+}
+
+// export packages the host to the file at path. The host is read only during the export,
+// so that the storage responsibilities are not changed after packaged. The host key of the
+// payment address is exported encrypted with the passphrase of the account
+func (h *StorageHost) export(path string, passphrase string) error {
+	h.setReadOnly(true)
+	defer h.setReadOnly(false)
+
+	he, err := h.exportHost()
+	if err != nil {
+		return err
+	}
+	if he.HostKey, err = h.exportHostKey(he.Config.PaymentAddress, passphrase); err != nil {
+		return fmt.Errorf("cannot export the host key: %v", err)
+	}
+	if he.Checksum, err = he.checksum(); err != nil {
+		return err
+	}
+	return common.SaveDxJSON(hostExportMeta, path, he)
+}
+
+// exportHost packages the storage responsibilities, the scheduled tasks, the config and the
+// storage folders of the host. All sectors of the storage responsibilities are checked to be
+// stored in the storage manager
+func (h *StorageHost) exportHost() (*hostExport, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	he := &hostExport{
+		BlockHeight:      h.blockHeight,
+		Config:           h.config,
+		FinancialMetrics: h.financialMetrics,
+		Contracts:        make(map[string]common.Hash),
+		Folders:          h.StorageManager.Folders(),
+	}
+	if he.Config.PaymentAddress == (common.Address{}) {
+		return nil, errors.New("payment address not set")
+	}
+
+	// the storage responsibilities indexed by the lock manager and the client contracts
+	var maxHeight uint64
+	ids := h.lockedStorageResponsibility.ids()
+	for client, id := range h.clientToContract {
+		he.Contracts[client] = id
+		ids = append(ids, id)
+	}
+	exported := make(map[common.Hash]struct{})
+	for _, id := range ids {
+		if _, exist := exported[id]; exist {
+			continue
+		}
+		so, err := getStorageResponsibility(h.db, id)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get storage responsibility %v: %v", id.Hex(), err)
+		}
+		if err = h.checkSectorsStored(so); err != nil {
+			return nil, err
+		}
+		b, err := rlp.EncodeToBytes(so)
+		if err != nil {
+			return nil, err
+		}
+		he.Responsibilities = append(he.Responsibilities, b)
+		exported[id] = struct{}{}
+		if deadline := so.proofDeadline() + postponedExecution*2; deadline > maxHeight {
+			maxHeight = deadline
+		}
+	}
+
+	// the tasks of the exported storage responsibilities not yet executed
+	for height := h.blockHeight; height <= maxHeight; height++ {
+		b, err := getHeight(h.db, height)
+		if err != nil {
+			continue
+		}
+		task := exportedTask{Height: height}
+		for i := 0; i+common.HashLength <= len(b); i += common.HashLength {
+			id := common.BytesToHash(b[i : i+common.HashLength])
+			if _, exist := exported[id]; exist {
+				task.IDs = append(task.IDs, id)
+			}
+		}
+		if len(task.IDs) != 0 {
+			he.Tasks = append(he.Tasks, task)
+		}
+	}
+	return he, nil
+}
+
+// importHost imports the package at path to the host. The package is checked for consistency
+// before any change is made to the host: the checksum, the storage responsibilities not already
+// in the host, the sectors stored in the storage manager, and the host key matching the payment
+// address. The host key is imported with the passphrase the key is exported with
+func (h *StorageHost) importHost(path string, passphrase string) error {
+	he := new(hostExport)
+	if err := common.LoadDxJSON(hostExportMeta, path, he); err != nil {
+		return err
+	}
+	sos, err := h.checkHostExport(he)
+	if err != nil {
+		return err
+	}
+	if err = h.importHostKey(he.HostKey, he.Config.PaymentAddress, passphrase); err != nil {
+		return fmt.Errorf("cannot import the host key: %v", err)
+	}
+	return h.applyHostExport(he, sos)
+}
+
+// checkHostExport checks the consistency of the package against itself and the host, and
+// returns the storage responsibilities decoded
+func (h *StorageHost) checkHostExport(he *hostExport) ([]StorageResponsibility, error) {
+	checksum, err := he.checksum()
+	if err != nil {
+		return nil, err
+	}
+	if checksum != he.Checksum {
+		return nil, errors.New("checksum mismatch, the export file might be corrupted")
+	}
+
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	sos := make([]StorageResponsibility, 0, len(he.Responsibilities))
+	ids := make(map[common.Hash]struct{})
+	for _, b := range he.Responsibilities {
+		var so StorageResponsibility
+		if err := rlp.DecodeBytes(b, &so); err != nil {
+			return nil, fmt.Errorf("cannot decode storage responsibility: %v", err)
+		}
+		id := so.id()
+		if _, exist := ids[id]; exist {
+			return nil, fmt.Errorf("duplicate storage responsibility %v", id.Hex())
+		}
+		if _, err := getStorageResponsibility(h.db, id); err == nil {
+			return nil, fmt.Errorf("storage responsibility %v already exists", id.Hex())
+		}
+		if err := h.checkSectorsStored(so); err != nil {
+			return nil, err
+		}
+		ids[id] = struct{}{}
+		sos = append(sos, so)
+	}
+	for client, id := range he.Contracts {
+		if _, exist := ids[id]; !exist {
+			return nil, fmt.Errorf("contract %v of client %v not exported", id.Hex(), client)
+		}
+	}
+	for _, task := range he.Tasks {
+		for _, id := range task.IDs {
+			if _, exist := ids[id]; !exist {
+				return nil, fmt.Errorf("task at height %v for unknown storage responsibility %v", task.Height, id.Hex())
+			}
+		}
+	}
+	return sos, nil
+}
+
+// applyHostExport writes the package to the host. The read cache settings are specific to the
+// machine, thus not imported
+func (h *StorageHost) applyHostExport(he *hostExport, sos []StorageResponsibility) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for _, so := range sos {
+		if err := putStorageResponsibility(h.db, so.id(), so); err != nil {
+			return err
+		}
+		h.lockedStorageResponsibility.get(so.id(), true)
+	}
+	for _, task := range he.Tasks {
+		for _, id := range task.IDs {
+			if err := storeHeight(h.db, id, task.Height); err != nil {
+				return err
+			}
+		}
+	}
+
+	config := he.Config
+	config.ReadCacheSize = h.config.ReadCacheSize
+	config.ReadCacheDiskPath = h.config.ReadCacheDiskPath
+	config.ReadCacheDiskSize = h.config.ReadCacheDiskSize
+	config.ReadIO = h.config.ReadIO
+	h.config = config
+	h.financialMetrics = he.FinancialMetrics
+	for client, id := range he.Contracts {
+		h.clientToContract[client] = id
+	}
+	if he.BlockHeight > h.blockHeight {
+		h.blockHeight = he.BlockHeight
+	}
+	return h.syncConfig()
+}
+
+// checkSectorsStored checks whether all sectors of the storage responsibility are stored in the
+// storage manager
+func (h *StorageHost) checkSectorsStored(so StorageResponsibility) error {
+	for _, root := range so.SectorRoots {
+		stored, err := h.StorageManager.HasSector(root)
+		if err != nil {
+			return err
+		}
+		if !stored {
+			return fmt.Errorf("sector %v of storage responsibility %v not stored", root.Hex(), so.id().Hex())
+		}
+	}
+	return nil
+}
+
+// exportHostKey exports the key of the payment address, encrypted with the passphrase
+func (h *StorageHost) exportHostKey(address common.Address, passphrase string) ([]byte, error) {
+	ks, err := h.hostKeyStore()
+	if err != nil {
+		return nil, err
+	}
+	return ks.Export(accounts.Account{Address: address}, passphrase, passphrase)
+}
+
+// importHostKey imports the key of the payment address to the key store, if not already exist
+func (h *StorageHost) importHostKey(keyJSON []byte, address common.Address, passphrase string) error {
+	ks, err := h.hostKeyStore()
+	if err != nil {
+		return err
+	}
+	if ks.HasAddress(address) {
+		return nil
+	}
+	account, err := ks.Import(keyJSON, passphrase, passphrase)
+	if err != nil {
+		return err
+	}
+	if account.Address != address {
+		return fmt.Errorf("host key %v does not match the payment address %v", account.Address.Hex(), address.Hex())
+	}
+	return nil
+}
+
+// hostKeyStore returns the key store of the account manager
+func (h *StorageHost) hostKeyStore() (*keystore.KeyStore, error) {
+	backends := h.ethBackend.AccountManager().Backends(keystore.KeyStoreType)
+	if len(backends) == 0 {
+		return nil, errors.New("key store not available")
+	}
+	return backends[0].(*keystore.KeyStore), nil
+}
+
+// setReadOnly sets whether the host is read only
+func (h *StorageHost) setReadOnly(val bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.readOnly = val
+}
+
+// isReadOnly returns whether the host is read only
+func (h *StorageHost) isReadOnly() bool {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return h.readOnly
+}
@@ -0,0 +1,147 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+)
+
+// TestHostExportImport test the host exported is imported to another host with the storage
+// responsibilities, the tasks and the config, and the consistency checks of the import
+func TestHostExportImport(t *testing.T) {
+	src := newMigrationTestHost(t, "source")
+	defer closeMigrationTestHost(src)
+	dst := newMigrationTestHost(t, "target")
+	defer closeMigrationTestHost(dst)
+
+	// payment address shall be set for the export
+	if _, err := src.exportHost(); err == nil {
+		t.Fatalf("export shall fail without the payment address")
+	}
+	src.config.PaymentAddress = common.HexToAddress("0x1")
+	so := StorageResponsibility{
+		OriginStorageContract: types.StorageContract{
+			WindowStart:    100,
+			RevisionNumber: 1,
+			WindowEnd:      200,
+		},
+	}
+	if err := putStorageResponsibility(src.db, so.id(), so); err != nil {
+		t.Fatal(err)
+	}
+	src.lockedStorageResponsibility.get(so.id(), true)
+	src.clientToContract["enode://client"] = so.id()
+	if err := storeHeight(src.db, so.id(), 150); err != nil {
+		t.Fatal(err)
+	}
+
+	he, err := src.exportHost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if he.Checksum, err = he.checksum(); err != nil {
+		t.Fatal(err)
+	}
+	sos, err := dst.checkHostExport(he)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = dst.applyHostExport(he, sos); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := getStorageResponsibility(dst.db, so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.id() != so.id() {
+		t.Fatalf("storage responsibility not imported")
+	}
+	if !dst.lockedStorageResponsibility.has(so.id()) {
+		t.Fatalf("storage responsibility not indexed")
+	}
+	task, err := getHeight(dst.db, 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(task, so.id().Bytes()) {
+		t.Fatalf("task not imported: %x", task)
+	}
+	if dst.config.PaymentAddress != src.config.PaymentAddress {
+		t.Fatalf("config not imported")
+	}
+	if !reflect.DeepEqual(dst.clientToContract, src.clientToContract) {
+		t.Fatalf("contracts not imported: %v", dst.clientToContract)
+	}
+
+	// the storage responsibility already exists in the host
+	if _, err = dst.checkHostExport(he); err == nil {
+		t.Fatalf("importing the existing storage responsibility shall fail")
+	}
+	// corrupted package
+	fresh := newMigrationTestHost(t, "fresh")
+	defer closeMigrationTestHost(fresh)
+	he.BlockHeight++
+	if _, err = fresh.checkHostExport(he); err == nil {
+		t.Fatalf("importing the corrupted package shall fail")
+	}
+	// the sectors referenced are not stored
+	so.SectorRoots = []common.Hash{common.HexToHash("0x2")}
+	if err = putStorageResponsibility(src.db, so.id(), so); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = src.exportHost(); err == nil {
+		t.Fatalf("exporting the storage responsibility with missing sectors shall fail")
+	}
+}
+
+// TestHostReadOnly test the storage responsibilities are not changed when the host is read only
+func TestHostReadOnly(t *testing.T) {
+	h := newMigrationTestHost(t, "")
+	defer closeMigrationTestHost(h)
+
+	h.setReadOnly(true)
+	so := StorageResponsibility{
+		OriginStorageContract: types.StorageContract{
+			WindowStart:    1000000,
+			RevisionNumber: 1,
+			WindowEnd:      1440000,
+		},
+	}
+	if err := h.insertStorageResponsibility(so); err != errHostReadOnly {
+		t.Fatalf("expect error %v, got %v", errHostReadOnly, err)
+	}
+	if err := h.modifyStorageResponsibility(so, nil, nil, nil); err != errHostReadOnly {
+		t.Fatalf("expect error %v, got %v", errHostReadOnly, err)
+	}
+	h.setReadOnly(false)
+	if h.isReadOnly() {
+		t.Fatalf("host shall not be read only")
+	}
+}
+
+func newMigrationTestHost(t *testing.T, name string) *StorageHost {
+	h, err := New(tempDir(t.Name(), name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = h.load(); err != nil {
+		t.Fatal(err)
+	}
+	if err = h.StorageManager.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func closeMigrationTestHost(h *StorageHost) {
+	h.StorageManager.Close()
+	h.db.Close()
+}
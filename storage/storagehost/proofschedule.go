@@ -0,0 +1,134 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/DxChainNetwork/godx/common"
+)
+
+// pendingProof is the storage proof created but not yet included in the chain
+type pendingProof struct {
+	// txHash is the hash of the transaction carrying the proof, empty if failed to send
+	txHash   common.Hash
+	deadline uint64
+
+	// atRisk is set once the proof is found at risk of missing the proof window, so that the
+	// transaction is prioritized and the alert is sent once
+	atRisk bool
+}
+
+// proofsAtRisk is the storage proofs sent in the same transaction which are at risk of missing
+// the proof window
+type proofsAtRisk struct {
+	txHash    common.Hash
+	deadline  uint64
+	contracts []common.Hash
+}
+
+// proofSchedule tracks the storage proofs created until they are included in the chain, so that
+// the proofs still not included when the proof window is closing are prioritized
+type proofSchedule struct {
+	pending map[common.Hash]*pendingProof
+	lock    sync.Mutex
+}
+
+// created starts tracking the storage proof of the contract with the proof deadline
+func (ps *proofSchedule) created(contractID common.Hash, deadline uint64) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if ps.pending == nil {
+		ps.pending = make(map[common.Hash]*pendingProof)
+	}
+	ps.pending[contractID] = &pendingProof{deadline: deadline}
+}
+
+// sent records the transaction carrying the storage proofs of the contracts
+func (ps *proofSchedule) sent(contractIDs []common.Hash, txHash common.Hash) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	for _, id := range contractIDs {
+		if p, exists := ps.pending[id]; exists {
+			p.txHash = txHash
+		}
+	}
+}
+
+// confirmed stops tracking the storage proof of the contract included in the chain
+func (ps *proofSchedule) confirmed(contractID common.Hash) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+	delete(ps.pending, contractID)
+}
+
+// atRisk returns the storage proofs newly found at risk of missing the proof window at the
+// block height, grouped by the transaction. The proofs past the deadline are no longer tracked
+func (ps *proofSchedule) atRisk(height uint64) []proofsAtRisk {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	txs := make(map[common.Hash]*proofsAtRisk)
+	for id, p := range ps.pending {
+		if p.deadline < height {
+			delete(ps.pending, id)
+			continue
+		}
+		if p.atRisk || height+proofRiskBlocks < p.deadline {
+			continue
+		}
+		p.atRisk = true
+		risk, exists := txs[p.txHash]
+		if !exists {
+			risk = &proofsAtRisk{txHash: p.txHash, deadline: p.deadline}
+			txs[p.txHash] = risk
+		}
+		if p.deadline < risk.deadline {
+			risk.deadline = p.deadline
+		}
+		risk.contracts = append(risk.contracts, id)
+	}
+
+	risks := make([]proofsAtRisk, 0, len(txs))
+	for _, risk := range txs {
+		sort.Slice(risk.contracts, func(i, j int) bool {
+			return risk.contracts[i].Big().Cmp(risk.contracts[j].Big()) < 0
+		})
+		risks = append(risks, *risk)
+	}
+	sort.Slice(risks, func(i, j int) bool {
+		return risks[i].deadline < risks[j].deadline
+	})
+	return risks
+}
+
+// prioritizeProofsAtRisk bumps the gas price of the storage proof transactions still not included
+// when the proof window is closing, up to the proof gas price ceiling, and alerts the host operator
+// of the proofs at risk
+func (h *StorageHost) prioritizeProofsAtRisk() {
+	h.lock.RLock()
+	height, ceiling := h.blockHeight, h.config.ProofGasPriceCeiling
+	h.lock.RUnlock()
+
+	for _, risk := range h.proofs.atRisk(height) {
+		msg := fmt.Sprintf("storage proofs of %v contracts not included %v blocks before the deadline %v", len(risk.contracts), risk.deadline-height, risk.deadline)
+		h.log.Warn("Storage proofs at risk of missing the proof window", "tx", risk.txHash, "contracts", len(risk.contracts), "deadline", risk.deadline)
+
+		if risk.txHash != (common.Hash{}) && ceiling.Sign() > 0 {
+			hash, err := h.parseAPI.StorageTx.PrioritizeStorageTX(risk.txHash, ceiling.BigIntPtr())
+			if err != nil {
+				h.log.Warn("Failed to prioritize the storage proof transaction", "tx", risk.txHash, "err", err)
+			} else {
+				h.log.Info("Prioritized the storage proof transaction", "tx", risk.txHash, "replacement", hash, "gasPriceCeiling", ceiling)
+				msg += fmt.Sprintf(", gas price bumped up to %v", ceiling)
+			}
+		}
+		h.sendAlert(AlertProofAtRisk, msg)
+	}
+}
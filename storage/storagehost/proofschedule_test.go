@@ -0,0 +1,61 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+)
+
+// TestProofScheduleAtRisk test the storage proofs not included within proofRiskBlocks before
+// the deadline are reported at risk once, grouped by the transaction carrying them
+func TestProofScheduleAtRisk(t *testing.T) {
+	var ps proofSchedule
+	deadline := uint64(1000)
+	ps.created(common.Hash{1}, deadline)
+	ps.created(common.Hash{2}, deadline+10)
+	ps.created(common.Hash{3}, deadline)
+	ps.created(common.Hash{4}, deadline)
+	ps.sent([]common.Hash{{1}, {2}}, common.Hash{0xa})
+	ps.sent([]common.Hash{{3}}, common.Hash{0xb})
+	ps.confirmed(common.Hash{3})
+
+	if risks := ps.atRisk(deadline - proofRiskBlocks - 1); len(risks) != 0 {
+		t.Fatalf("no proof should be at risk, got %+v", risks)
+	}
+
+	// proof 4 failed to send is reported with the empty tx hash
+	risks := ps.atRisk(deadline - proofRiskBlocks)
+	if len(risks) != 2 {
+		t.Fatalf("expect proofs at risk of 2 txs, got %+v", risks)
+	}
+	for _, risk := range risks {
+		switch risk.txHash {
+		case common.Hash{0xa}:
+			if len(risk.contracts) != 1 || risk.contracts[0] != (common.Hash{1}) || risk.deadline != deadline {
+				t.Errorf("proofs at risk not expected: %+v", risk)
+			}
+		case common.Hash{}:
+			if len(risk.contracts) != 1 || risk.contracts[0] != (common.Hash{4}) {
+				t.Errorf("proofs at risk not expected: %+v", risk)
+			}
+		default:
+			t.Errorf("unexpected tx at risk: %+v", risk)
+		}
+	}
+
+	// the proofs at risk are reported once, and proof 2 turns at risk later
+	risks = ps.atRisk(deadline + 10 - proofRiskBlocks)
+	if len(risks) != 1 || len(risks[0].contracts) != 1 || risks[0].contracts[0] != (common.Hash{2}) {
+		t.Fatalf("expect proof 2 at risk, got %+v", risks)
+	}
+
+	// the proofs past the deadline are no longer tracked
+	ps.atRisk(deadline + 11)
+	if len(ps.pending) != 0 {
+		t.Errorf("expect no proof tracked, got %v", len(ps.pending))
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+var (
+	// errPublicReadDisabled is returned if the host does not serve the public read
+	errPublicReadDisabled = errors.New("host does not allow public read")
+
+	// errSectorNotPublic is returned if the requested sector is not marked as public
+	errSectorNotPublic = errors.New("requested sector is not public")
+)
+
+// PublicReadHandler handles the public read request. The sectors marked as public are
+// served without a contract, thus no revision is signed and no payment is made
+func PublicReadHandler(h *StorageHost, sp storage.Peer, publicReadReqMsg p2p.Msg) {
+	var hostNegotiateErr error
+
+	defer func() {
+		if hostNegotiateErr != nil {
+			log.Debug("public read negotiation failed", "err", hostNegotiateErr)
+			_ = sp.SendHostNegotiateErrorMsg()
+		}
+	}()
+
+	// read the public read request
+	var req storage.PublicReadRequest
+	if err := publicReadReqMsg.Decode(&req); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		hostNegotiateErr = fmt.Errorf("error decoding the public read request message: %s", err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		hostNegotiateErr = fmt.Errorf("invalid public read request: %s", err.Error())
+		return
+	}
+
+	config := h.getInternalConfig()
+	if !config.PublicRead {
+		hostNegotiateErr = errPublicReadDisabled
+		return
+	}
+	if err := validateDownloadLimits(req.Sections, req.MerkleProof, config.DownloadLimits); err != nil {
+		hostNegotiateErr = err
+		return
+	}
+
+	isPublic := func(root common.Hash) bool {
+		h.lock.RLock()
+		defer h.lock.RUnlock()
+		return isPublicSector(h.db, root)
+	}
+	if err := validatePublicReadRequest(req, config.MaxDownloadBatchSize, isPublic); err != nil {
+		hostNegotiateErr = fmt.Errorf("public read request validation failed: %s", err.Error())
+		return
+	}
+
+	// fetch the requested data of each section from host local storage, and
+	// construct the Merkle proofs, if requested. The sections are read concurrently
+	data, proofs, err := readSections(req.Sections, req.MerkleProof, config.ReadIO, h.ReadSector)
+	if err != nil {
+		hostNegotiateErr = err
+		return
+	}
+
+	// the response is not signed since there is no revision involved
+	resp := storage.DownloadBatchResponse{
+		Data:         data,
+		MerkleProofs: proofs,
+	}
+	if err := sp.SendContractDownloadBatchData(resp); err != nil {
+		log.Error("failed to send the public read data message", "err", err)
+	}
+}
+
+// validatePublicReadRequest validates the sections requested in the public read request.
+// All requested sectors must be marked as public
+func validatePublicReadRequest(req storage.PublicReadRequest, maxBatchSize uint64, isPublic func(root common.Hash) bool) error {
+	if len(req.Sections) == 0 {
+		return errors.New("no section requested")
+	}
+
+	var totalLength uint64
+	for _, sec := range req.Sections {
+		switch {
+		case uint64(sec.Offset)+uint64(sec.Length) > storage.SectorSize:
+			return errors.New("download out boundary of sector")
+		case sec.Length == 0:
+			return errors.New("length cannot be 0")
+		case req.MerkleProof && (sec.Offset%storage.SegmentSize != 0 || sec.Length%storage.SegmentSize != 0):
+			return errors.New("offset and length must be multiples of SegmentSize when requesting a Merkle proof")
+		case !isPublic(sec.MerkleRoot):
+			return errSectorNotPublic
+		}
+		totalLength += uint64(sec.Length)
+	}
+	if len(req.Sections) > 1 && totalLength > maxBatchSize {
+		return fmt.Errorf("download batch size %v exceeds the max download batch size %v", totalLength, maxBatchSize)
+	}
+	return nil
+}
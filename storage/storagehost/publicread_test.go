@@ -0,0 +1,63 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestPublicSectors(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	roots := []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")}
+	if err := putPublicSectors(db, roots); err != nil {
+		t.Fatal(err)
+	}
+	for _, root := range roots {
+		if !isPublicSector(db, root) {
+			t.Errorf("sector %v should be public", root.String())
+		}
+	}
+	if isPublicSector(db, common.HexToHash("0x03")) {
+		t.Errorf("sector not marked should not be public")
+	}
+
+	if err := deletePublicSectors(db, roots[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if isPublicSector(db, roots[0]) || !isPublicSector(db, roots[1]) {
+		t.Errorf("only the deleted sector should not be public")
+	}
+}
+
+func TestValidatePublicReadRequest(t *testing.T) {
+	public := common.HexToHash("0x01")
+	isPublic := func(root common.Hash) bool { return root == public }
+
+	tests := []struct {
+		sections    []storage.DownloadRequestSector
+		merkleProof bool
+		valid       bool
+	}{
+		{nil, false, false},
+		{[]storage.DownloadRequestSector{{MerkleRoot: public, Length: uint32(storage.SectorSize)}}, true, true},
+		{[]storage.DownloadRequestSector{{MerkleRoot: common.HexToHash("0x02"), Length: 64}}, true, false},
+		{[]storage.DownloadRequestSector{{MerkleRoot: public, Offset: 1, Length: 64}}, true, false},
+		{[]storage.DownloadRequestSector{{MerkleRoot: public, Offset: 1, Length: 64}}, false, true},
+		{[]storage.DownloadRequestSector{{MerkleRoot: public, Length: 0}}, false, false},
+		{[]storage.DownloadRequestSector{{MerkleRoot: public, Offset: uint32(storage.SectorSize), Length: 1}}, false, false},
+		{[]storage.DownloadRequestSector{{MerkleRoot: public, Length: 64}, {MerkleRoot: public, Length: 128}}, false, false},
+	}
+	for i, test := range tests {
+		req := storage.PublicReadRequest{Sections: test.sections, MerkleProof: test.merkleProof}
+		err := validatePublicReadRequest(req, 128, isPublic)
+		if (err == nil) != test.valid {
+			t.Errorf("test %d: validation result not expected. Got err %v, Expect valid %v", i, err, test.valid)
+		}
+	}
+}
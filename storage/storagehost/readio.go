@@ -0,0 +1,140 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// sectionResult is the data and the merkle proof of a section read by the read workers
+type sectionResult struct {
+	data  []byte
+	proof []common.Hash
+	err   error
+}
+
+// readSections reads the data of the sections, and constructs the merkle proofs if requested.
+// The sections are read concurrently by config.Workers workers, and at most
+// config.Workers + config.Readahead sections are read ahead of the section being assembled,
+// so that the memory held by a request is bounded. The data and proofs are returned in the
+// order of the sections
+func readSections(sections []storage.DownloadRequestSector, merkleProof bool, config storage.HostReadIOConfig, readSector func(common.Hash) ([]byte, error)) ([]byte, [][]common.Hash, error) {
+	workers := config.Workers
+	if workers == 0 {
+		workers = 1
+	}
+	window := workers + config.Readahead
+
+	// the result of each section is sent to the channel of the section, and the sections
+	// are dispatched to the workers in order
+	results := make([]chan sectionResult, len(sections))
+	for i := range results {
+		results[i] = make(chan sectionResult, 1)
+	}
+	jobs := make(chan int)
+	quit := make(chan struct{})
+	defer close(quit)
+	for i := uint64(0); i < workers && i < uint64(len(sections)); i++ {
+		go func() {
+			for index := range jobs {
+				results[index] <- readSection(sections[index], merkleProof, readSector)
+			}
+		}()
+	}
+
+	// the dispatcher keeps at most window sections in flight. A slot is released each time
+	// a section is assembled
+	slots := make(chan struct{}, window)
+	go func() {
+		defer close(jobs)
+		for i := range sections {
+			select {
+			case slots <- struct{}{}:
+			case <-quit:
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	var data []byte
+	var proofs [][]common.Hash
+	for i := range sections {
+		res := <-results[i]
+		<-slots
+		if res.err != nil {
+			return nil, nil, res.err
+		}
+		data = append(data, res.data...)
+		if merkleProof {
+			proofs = append(proofs, res.proof)
+		}
+	}
+	return data, proofs, nil
+}
+
+// readSection reads the sector of the section, and returns the data within the section and
+// the merkle proof of the data if requested
+func readSection(sec storage.DownloadRequestSector, merkleProof bool, readSector func(common.Hash) ([]byte, error)) sectionResult {
+	sectorData, err := readSector(sec.MerkleRoot)
+	if err != nil {
+		return sectionResult{err: fmt.Errorf("host failed read sector: %s", err.Error())}
+	}
+	res := sectionResult{data: sectorData[sec.Offset : sec.Offset+sec.Length]}
+	if merkleProof {
+		proofStart := int(sec.Offset) / merkle.LeafSize
+		proofEnd := int(sec.Offset+sec.Length) / merkle.LeafSize
+		if res.proof, err = merkle.Sha256RangeProof(sectorData, proofStart, proofEnd); err != nil {
+			return sectionResult{err: fmt.Errorf("host failed to generate the merkle proof: %s", err.Error())}
+		}
+	}
+	return res
+}
+
+// parseFolderReadConcurrency parses the read concurrency of the storage folders in the
+// format of "path:limit,path:limit". Empty string returns no limit
+func parseFolderReadConcurrency(str string) (map[string]uint64, error) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return nil, nil
+	}
+	limits := make(map[string]uint64)
+	for _, item := range strings.Split(str, ",") {
+		item = strings.TrimSpace(item)
+		// the path might contain colon, e.g. the windows path, thus split at the last colon
+		sep := strings.LastIndex(item, ":")
+		if sep <= 0 {
+			return nil, fmt.Errorf("invalid folder read concurrency %q, expect path:limit", item)
+		}
+		limit, err := strconv.ParseUint(strings.TrimSpace(item[sep+1:]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid folder read concurrency %q: %v", item, err)
+		}
+		limits[strings.TrimSpace(item[:sep])] = limit
+	}
+	return limits, nil
+}
+
+// formatFolderReadConcurrency formats the read concurrency of the storage folders in the
+// format parsed by parseFolderReadConcurrency, sorted by the path
+func formatFolderReadConcurrency(limits map[string]uint64) string {
+	items := make([]string, 0, len(limits))
+	for path, limit := range limits {
+		items = append(items, path+":"+strconv.FormatUint(limit, 10))
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
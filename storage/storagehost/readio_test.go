@@ -0,0 +1,136 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestReadSections test the sections read concurrently are assembled in order, and the number
+// of concurrent reads is bounded by the workers
+func TestReadSections(t *testing.T) {
+	sectors := make(map[common.Hash][]byte)
+	var sections []storage.DownloadRequestSector
+	for i := 0; i < 10; i++ {
+		data := bytes.Repeat([]byte{byte(i)}, int(storage.SectorSize))
+		data[i*merkle.LeafSize] = 0xff
+		root := merkle.Sha256MerkleTreeRoot(data)
+		sectors[root] = data
+		sections = append(sections, storage.DownloadRequestSector{
+			MerkleRoot: root,
+			Offset:     uint32(i * merkle.LeafSize),
+			Length:     uint32(2 * merkle.LeafSize),
+		})
+	}
+
+	var lock sync.Mutex
+	var reading, maxReading int
+	readSector := func(root common.Hash) ([]byte, error) {
+		lock.Lock()
+		reading++
+		if reading > maxReading {
+			maxReading = reading
+		}
+		lock.Unlock()
+		defer func() {
+			lock.Lock()
+			reading--
+			lock.Unlock()
+		}()
+		return sectors[root], nil
+	}
+
+	var expectData []byte
+	var expectProofs [][]common.Hash
+	for _, sec := range sections {
+		data, proofs, err := readSections([]storage.DownloadRequestSector{sec}, true, storage.HostReadIOConfig{}, readSector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectData = append(expectData, data...)
+		expectProofs = append(expectProofs, proofs...)
+	}
+
+	config := storage.HostReadIOConfig{Workers: 3, Readahead: 2}
+	data, proofs, err := readSections(sections, true, config, readSector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, expectData) {
+		t.Error("data of the sections read concurrently not expected")
+	}
+	if !reflect.DeepEqual(proofs, expectProofs) {
+		t.Error("proofs of the sections read concurrently not expected")
+	}
+	if maxReading > int(config.Workers) {
+		t.Errorf("concurrent reads %v exceeds the workers %v", maxReading, config.Workers)
+	}
+
+	data, proofs, err = readSections(sections, false, config, readSector)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, expectData) || proofs != nil {
+		t.Error("data without proofs not expected")
+	}
+}
+
+// TestReadSectionsError test the error of reading a sector is returned
+func TestReadSectionsError(t *testing.T) {
+	sections := make([]storage.DownloadRequestSector, 8)
+	for i := range sections {
+		sections[i] = storage.DownloadRequestSector{MerkleRoot: common.Hash{byte(i)}, Length: 64}
+	}
+	errRead := errors.New("read failed")
+	readSector := func(root common.Hash) ([]byte, error) {
+		if root == (common.Hash{3}) {
+			return nil, errRead
+		}
+		return make([]byte, storage.SectorSize), nil
+	}
+	_, _, err := readSections(sections, false, storage.HostReadIOConfig{Workers: 2, Readahead: 1}, readSector)
+	if err == nil {
+		t.Fatal("error of reading the sector not returned")
+	}
+}
+
+func TestParseFolderReadConcurrency(t *testing.T) {
+	tests := []struct {
+		str    string
+		limits map[string]uint64
+		valid  bool
+	}{
+		{"", nil, true},
+		{"/mnt/hdd:1", map[string]uint64{"/mnt/hdd": 1}, true},
+		{"/mnt/hdd:1, /mnt/ssd : 8", map[string]uint64{"/mnt/hdd": 1, "/mnt/ssd": 8}, true},
+		{`C:\data:2`, map[string]uint64{`C:\data`: 2}, true},
+		{"/mnt/hdd", nil, false},
+		{":1", nil, false},
+		{"/mnt/hdd:x", nil, false},
+	}
+	for i, test := range tests {
+		limits, err := parseFolderReadConcurrency(test.str)
+		if (err == nil) != test.valid {
+			t.Errorf("test %d: parse result not expected. Got err %v, Expect valid %v", i, err, test.valid)
+			continue
+		}
+		if test.valid && !reflect.DeepEqual(limits, test.limits) {
+			t.Errorf("test %d: expect %v, got %v", i, test.limits, limits)
+		}
+		if test.valid {
+			if parsed, _ := parseFolderReadConcurrency(formatFolderReadConcurrency(limits)); !reflect.DeepEqual(parsed, limits) {
+				t.Errorf("test %d: formatted limits not parsed back", i)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"sync"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// recentConfigs keeps the latest host configs by the stamp, up to recentConfigsSize configs
+type recentConfigs struct {
+	stamps  []common.Hash
+	configs map[common.Hash]storage.HostExtConfig
+	lock    sync.Mutex
+}
+
+// add adds the config to the history, where the oldest config is evicted if the history is full
+func (rc *recentConfigs) add(config storage.HostExtConfig) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	if rc.configs == nil {
+		rc.configs = make(map[common.Hash]storage.HostExtConfig)
+	}
+	stamp := config.Stamp()
+	if _, exists := rc.configs[stamp]; exists {
+		return
+	}
+	if len(rc.stamps) >= recentConfigsSize {
+		delete(rc.configs, rc.stamps[0])
+		rc.stamps = rc.stamps[1:]
+	}
+	rc.stamps = append(rc.stamps, stamp)
+	rc.configs[stamp] = config
+}
+
+// get returns the config with the stamp in the history
+func (rc *recentConfigs) get(stamp common.Hash) (storage.HostExtConfig, bool) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	config, exists := rc.configs[stamp]
+	return config, exists
+}
+
+// ExternalConfigDelta returns the fields of the external config retrieved changed since the
+// config with the stamp. False is returned if the config with the stamp is not known, in
+// which case the whole config should be sent
+func (h *StorageHost) ExternalConfigDelta(stamp common.Hash, config storage.HostExtConfig) (storage.HostConfigDelta, bool) {
+	if stamp == (common.Hash{}) {
+		return storage.HostConfigDelta{}, false
+	}
+	base, exists := h.recentConfigs.get(stamp)
+	if !exists {
+		return storage.HostConfigDelta{}, false
+	}
+	delta, err := storage.NewHostConfigDelta(base, config)
+	if err != nil {
+		h.log.Warn("failed to get the host config delta", "err", err)
+		return storage.HostConfigDelta{}, false
+	}
+	return delta, true
+}
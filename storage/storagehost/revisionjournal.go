@@ -0,0 +1,154 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// revisionJournal is the intermediate state of a revision negotiation. It is persisted
+// before the host signature is sent to the storage client, and deleted once the negotiation
+// is finished. A journal left in the database means the host crashed in the middle of
+// the negotiation
+type revisionJournal struct {
+	// OldResponsibility is the storage responsibility before the negotiation
+	OldResponsibility StorageResponsibility
+
+	// NewRevision is the revision signed by both the client and the host
+	NewRevision types.StorageContractRevision
+
+	// SectorsGained is the sectors to be added in the negotiation
+	SectorsGained []common.Hash
+
+	// Acknowledged is set right before the host acknowledges the commit of the storage
+	// client, after which the client keeps the new revision
+	Acknowledged bool
+}
+
+// putRevisionJournal store the revision journal in the db
+func putRevisionJournal(db ethdb.Database, storageContractID common.Hash, rj revisionJournal) error {
+	scdb := ethdb.StorageContractDB{db}
+	data, err := rlp.EncodeToBytes(rj)
+	if err != nil {
+		return err
+	}
+	return scdb.StoreWithPrefix(storageContractID, data, prefixRevisionJournal)
+}
+
+// getRevisionJournal get the revision journal from the db
+func getRevisionJournal(db ethdb.Database, storageContractID common.Hash) (revisionJournal, error) {
+	scdb := ethdb.StorageContractDB{db}
+	valueBytes, err := scdb.GetWithPrefix(storageContractID, prefixRevisionJournal)
+	if err != nil {
+		return revisionJournal{}, err
+	}
+	var rj revisionJournal
+	if err = rlp.DecodeBytes(valueBytes, &rj); err != nil {
+		return revisionJournal{}, err
+	}
+	return rj, nil
+}
+
+// deleteRevisionJournal delete the revision journal from the db
+func deleteRevisionJournal(db ethdb.Database, storageContractID common.Hash) error {
+	scdb := ethdb.StorageContractDB{db}
+	return scdb.DeleteWithPrefix(storageContractID, prefixRevisionJournal)
+}
+
+// journalRevision persist the intermediate state of the negotiation. It must be called before
+// the host signature of the new revision is sent to the storage client
+func (h *StorageHost) journalRevision(oldSo StorageResponsibility, newRev types.StorageContractRevision, sectorsGained []common.Hash) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return putRevisionJournal(h.db, oldSo.id(), revisionJournal{
+		OldResponsibility: oldSo,
+		NewRevision:       newRev,
+		SectorsGained:     sectorsGained,
+	})
+}
+
+// acknowledgeRevisionJournal marks the revision journal as acknowledged. It must be called after
+// the new revision is committed by the host, and before the host ack is sent to the client
+func (h *StorageHost) acknowledgeRevisionJournal(storageContractID common.Hash) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	rj, err := getRevisionJournal(h.db, storageContractID)
+	if err != nil {
+		return err
+	}
+	rj.Acknowledged = true
+	return putRevisionJournal(h.db, storageContractID, rj)
+}
+
+// finishRevisionJournal delete the revision journal after the negotiation is finished, whether
+// the revision is committed or rolled back
+func (h *StorageHost) finishRevisionJournal(storageContractID common.Hash) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if err := deleteRevisionJournal(h.db, storageContractID); err != nil {
+		h.log.Warn("failed to delete the revision journal", "id", storageContractID, "err", err)
+	}
+}
+
+// recoverRevisionJournals recover the negotiations interrupted by a crash. For each of the
+// revision journal left in the database:
+//  1. If the storage responsibility has been modified with the new revision, and the journal
+//     is not acknowledged, the host did not get the chance to acknowledge the revision. Since
+//     the storage client rolls back the revision without the host ack, the storage
+//     responsibility is rolled back as well.
+//  2. If the journal is acknowledged, the host ack has been sent to the client, which keeps
+//     the new revision. The storage responsibility is kept, and the journal is discarded.
+//  3. Otherwise the new revision has not been applied, and the journal is simply discarded.
+//     The client does not commit a revision without the host signature and commit success.
+func (h *StorageHost) recoverRevisionJournals() error {
+	h.lock.RLock()
+	journals, err := h.revisionJournals()
+	h.lock.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	for id, rj := range journals {
+		h.lock.RLock()
+		so, err := getStorageResponsibility(h.db, id)
+		h.lock.RUnlock()
+
+		if err == nil && len(so.StorageContractRevisions) != 0 {
+			latestRev := so.StorageContractRevisions[len(so.StorageContractRevisions)-1]
+			if latestRev.NewRevisionNumber == rj.NewRevision.NewRevisionNumber && !rj.Acknowledged {
+				h.log.Info("Rolling back the interrupted revision negotiation", "id", id, "revision", latestRev.NewRevisionNumber)
+				if err = h.rollbackStorageResponsibility(rj.OldResponsibility, rj.SectorsGained, nil, nil); err != nil {
+					return fmt.Errorf("failed to roll back the storage responsibility %v: %v", id, err)
+				}
+			}
+		}
+		h.finishRevisionJournal(id)
+	}
+	return nil
+}
+
+// revisionJournals return all revision journals stored in the database
+func (h *StorageHost) revisionJournals() (map[common.Hash]revisionJournal, error) {
+	journals := make(map[common.Hash]revisionJournal)
+
+	iter := h.db.NewIteratorWithPrefix([]byte(prefixRevisionJournal))
+	defer iter.Release()
+	for iter.Next() {
+		var rj revisionJournal
+		if err := rlp.DecodeBytes(iter.Value(), &rj); err != nil {
+			return nil, fmt.Errorf("failed to decode the revision journal: %v", err)
+		}
+		journals[rj.OldResponsibility.id()] = rj
+	}
+	return journals, iter.Error()
+}
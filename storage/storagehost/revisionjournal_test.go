@@ -0,0 +1,104 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/core/types"
+)
+
+func TestRevisionJournal(t *testing.T) {
+	h := newTestStorageHost(t)
+	defer h.db.Close()
+
+	so := StorageResponsibility{
+		OriginStorageContract: types.StorageContract{
+			WindowStart:    1000000,
+			RevisionNumber: 1,
+			WindowEnd:      1440000,
+		},
+		StorageContractRevisions: []types.StorageContractRevision{{NewRevisionNumber: 1}},
+	}
+	newRev := types.StorageContractRevision{NewRevisionNumber: 2}
+
+	if err := h.journalRevision(so, newRev, nil); err != nil {
+		t.Fatal(err)
+	}
+	rj, err := getRevisionJournal(h.db, so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rj.NewRevision.NewRevisionNumber != newRev.NewRevisionNumber {
+		t.Fatalf("revision number not expected. Got %v, Expect %v", rj.NewRevision.NewRevisionNumber, newRev.NewRevisionNumber)
+	}
+
+	journals, err := h.revisionJournals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exist := journals[so.id()]; !exist || len(journals) != 1 {
+		t.Fatalf("unexpected revision journals: %v", journals)
+	}
+
+	// The storage responsibility does not exist, the journal shall be discarded
+	if err = h.recoverRevisionJournals(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = getRevisionJournal(h.db, so.id()); err == nil {
+		t.Fatal("revision journal not deleted after recovery")
+	}
+}
+
+// TestRevisionJournalAcknowledged test the revision acknowledged before the crash is kept on
+// recovery, since the storage client keeps the revision once the host ack is received
+func TestRevisionJournalAcknowledged(t *testing.T) {
+	h := newTestStorageHost(t)
+	defer h.db.Close()
+
+	oldSo := StorageResponsibility{
+		OriginStorageContract: types.StorageContract{
+			WindowStart:    1000000,
+			RevisionNumber: 1,
+			WindowEnd:      1440000,
+		},
+		StorageContractRevisions: []types.StorageContractRevision{{NewRevisionNumber: 1}},
+	}
+	newRev := types.StorageContractRevision{NewRevisionNumber: 2}
+	newSo := oldSo
+	newSo.StorageContractRevisions = append([]types.StorageContractRevision{}, oldSo.StorageContractRevisions...)
+	newSo.StorageContractRevisions = append(newSo.StorageContractRevisions, newRev)
+	if err := putStorageResponsibility(h.db, newSo.id(), newSo); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.journalRevision(oldSo, newRev, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.acknowledgeRevisionJournal(oldSo.id()); err != nil {
+		t.Fatal(err)
+	}
+	rj, err := getRevisionJournal(h.db, oldSo.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rj.Acknowledged {
+		t.Fatal("revision journal not acknowledged")
+	}
+
+	if err = h.recoverRevisionJournals(); err != nil {
+		t.Fatal(err)
+	}
+	so, err := getStorageResponsibility(h.db, oldSo.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest := so.StorageContractRevisions[len(so.StorageContractRevisions)-1]; latest.NewRevisionNumber != newRev.NewRevisionNumber {
+		t.Fatalf("acknowledged revision rolled back. Got %v, Expect %v", latest.NewRevisionNumber, newRev.NewRevisionNumber)
+	}
+	if _, err = getRevisionJournal(h.db, oldSo.id()); err == nil {
+		t.Fatal("revision journal not deleted after recovery")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// SectorCheckHandler handles the sector check request sent by the storage client before
+// uploading a sector. If the sector is already stored under the contract, the host responds
+// with the merkle proof of the sector root instead of receiving the sector data again. No
+// revision is involved in the negotiation
+func SectorCheckHandler(h *StorageHost, sp storage.Peer, sectorCheckReqMsg p2p.Msg) {
+	var hostNegotiateErr error
+
+	defer func() {
+		if hostNegotiateErr != nil {
+			log.Debug("sector check negotiation failed", "err", hostNegotiateErr)
+			_ = sp.SendHostNegotiateErrorMsg()
+		}
+	}()
+
+	var req storage.SectorCheckRequest
+	if err := sectorCheckReqMsg.Decode(&req); err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
+		hostNegotiateErr = fmt.Errorf("error decoding the sector check request message: %s", err.Error())
+		return
+	}
+
+	h.lock.RLock()
+	so, err := getStorageResponsibility(h.db, req.StorageContractID)
+	h.lock.RUnlock()
+	if err != nil {
+		hostNegotiateErr = fmt.Errorf("failed to get storage responsibility: %s", err.Error())
+		return
+	}
+
+	resp, err := sectorStoredResponse(so.SectorRoots, req.SectorRoot)
+	if err != nil {
+		hostNegotiateErr = err
+		return
+	}
+	if err := sp.SendSectorStored(resp); err != nil {
+		log.Error("failed to send the sector stored message", "err", err)
+	}
+}
+
+// sectorStoredResponse creates the response of whether the root is in the sector roots of
+// the contract, with the merkle proof of the root if found
+func sectorStoredResponse(roots []common.Hash, root common.Hash) (storage.SectorStoredResponse, error) {
+	for i, r := range roots {
+		if r != root {
+			continue
+		}
+		proof, err := merkle.Sha256SectorRangeProof(roots, i, i+1)
+		if err != nil {
+			return storage.SectorStoredResponse{}, fmt.Errorf("host failed to generate the merkle proof: %s", err.Error())
+		}
+		return storage.SectorStoredResponse{
+			Stored:      true,
+			Index:       uint64(i),
+			MerkleProof: proof,
+		}, nil
+	}
+	return storage.SectorStoredResponse{}, nil
+}
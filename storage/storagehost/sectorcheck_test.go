@@ -0,0 +1,39 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+)
+
+func TestSectorStoredResponse(t *testing.T) {
+	roots := []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02"), common.HexToHash("0x03"), common.HexToHash("0x04"), common.HexToHash("0x05")}
+	merkleRoot := merkle.Sha256CachedTreeRoot2(roots)
+
+	for i, root := range roots {
+		resp, err := sectorStoredResponse(roots, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Stored || resp.Index != uint64(i) {
+			t.Fatalf("sector %d: expect stored at index %d, got %+v", i, i, resp)
+		}
+		verified, err := merkle.Sha256VerifySectorRangeProof([]common.Hash{root}, resp.MerkleProof, i, i+1, merkleRoot)
+		if err != nil || !verified {
+			t.Errorf("sector %d: invalid merkle proof: %v", i, err)
+		}
+	}
+
+	resp, err := sectorStoredResponse(roots, common.HexToHash("0x06"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Stored {
+		t.Error("the sector not in the contract should not be stored")
+	}
+}
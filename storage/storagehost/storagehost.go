@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
@@ -38,9 +39,41 @@ type StorageHost struct {
 	// storage host manager for manipulating the file storage system
 	sm.StorageManager
 
-	lockedStorageResponsibility map[common.Hash]*TryMutex
+	lockedStorageResponsibility responsibilityLockManager
 	clientToContract            map[string]common.Hash
 
+	// readOnly is set while the host is being exported, during which the host does
+	// not accept new contracts or modifications of the storage responsibilities
+	readOnly bool
+
+	// alerts of the risky conditions sent to the host operator
+	alerts hostAlerts
+
+	// feeReserveTopUpHeight is the block height the latest fee reserve top up is sent at
+	feeReserveTopUpHeight uint64
+
+	// proofs tracks the storage proofs sent until included, so that the proofs at risk of
+	// missing the proof window are prioritized
+	proofs proofSchedule
+
+	// configNotifier notifies the connected clients of the host config changes
+	configNotifier configNotifier
+
+	// configAudit records the host config changes made through the api
+	configAudit configAuditLog
+
+	// recentConfigs keeps the latest host configs sent to the clients, so that the clients
+	// refreshing the config could be sent the fields changed only
+	recentConfigs recentConfigs
+
+	// bandwidth records the bandwidth served per storage contract and per client peer
+	bandwidth bandwidthAccounting
+
+	// negotiations tracks the negotiations in progress, which are drained on shutdown
+	// within the drainTimeout
+	negotiations storage.NegotiationDrain
+	drainTimeout time.Duration
+
 	// things for log and persistence
 	db         *ethdb.LDBDatabase
 	persistDir string
@@ -74,7 +107,7 @@ func (h *StorageHost) UpdateContractToClientNodeMappingAndConnection() {
 	// are not included in the storage responsibility, and delete
 	// them from the clientToContract mapping
 	for clientURL, contractID := range h.clientToContract {
-		if _, exists := h.lockedStorageResponsibility[contractID]; !exists {
+		if !h.lockedStorageResponsibility.has(contractID) {
 			delete(h.clientToContract, clientURL)
 			// parse the client URL to node
 			clientNode, err := enode.ParseV4(clientURL)
@@ -87,9 +120,12 @@ func (h *StorageHost) UpdateContractToClientNodeMappingAndConnection() {
 }
 
 // RetrieveExternalConfig is used to get the storage host's external
-// configuration
+// configuration. The config is kept in the recent configs, against which
+// the changes are sent to the clients refreshing the config
 func (h *StorageHost) RetrieveExternalConfig() storage.HostExtConfig {
-	return h.externalConfig()
+	config := h.externalConfig()
+	h.recentConfigs.add(config)
+	return config
 }
 
 // GetCurrentBlockHeight is used to retrieve the current
@@ -105,10 +141,10 @@ func (h *StorageHost) GetCurrentBlockHeight() uint64 {
 func New(persistDir string) (*StorageHost, error) {
 	// do a host creation, but incomplete config
 	h := StorageHost{
-		log:                         log.New(),
-		persistDir:                  persistDir,
-		lockedStorageResponsibility: make(map[common.Hash]*TryMutex),
-		clientToContract:            make(map[string]common.Hash),
+		log:              log.New(),
+		persistDir:       persistDir,
+		clientToContract: make(map[string]common.Hash),
+		drainTimeout:     storage.DefaultDrainTimeout,
 	}
 
 	var err error
@@ -143,23 +179,75 @@ func (h *StorageHost) Start(eth storage.HostBackend) (err error) {
 	if err = h.StorageManager.Start(); err != nil {
 		return err
 	}
+	// apply the read cache config to the storage manager
+	h.lock.RLock()
+	err = h.applyReadCacheConfig()
+	h.lock.RUnlock()
+	if err != nil {
+		return err
+	}
 	// parse storage contract tx API
 	err = storage.FilterAPIs(h.ethBackend.APIs(), &h.parseAPI)
 	if err != nil {
 		h.log.Error("responsibilityFailed to parse storage contract tx API for host", "error", err)
 		return
 	}
+	// recover the revision negotiations interrupted by the last shutdown
+	if err = h.recoverRevisionJournals(); err != nil {
+		return err
+	}
 	//Delete residual storage responsibility
 	if err = h.pruneStaleStorageResponsibilities(); err != nil {
 		return err
 	}
+	// load the bandwidth usage recorded before the last shutdown
+	if err = h.bandwidth.load(h.bandwidthPath()); err != nil {
+		return err
+	}
+	// record the config the clients start with, only the changes afterwards are notified
+	h.configNotifier.update(h.externalConfig())
+
 	// subscribe block chain change event
 	go h.subscribeChainChangEvent()
+	go h.bandwidthPersistLoop()
 	return nil
 }
 
-// Close the storage host and persist the data
+// SetDrainTimeout sets the time waited for the negotiations in progress to finish on shutdown
+func (h *StorageHost) SetDrainTimeout(timeout time.Duration) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.drainTimeout = timeout
+}
+
+// BeginNegotiation starts tracking a negotiation requested by the client. The negotiation is
+// rejected with storage.ErrNegotiationDraining once the host starts shutting down
+func (h *StorageHost) BeginNegotiation() error {
+	return h.negotiations.Begin()
+}
+
+// EndNegotiation finishes tracking a negotiation started by BeginNegotiation
+func (h *StorageHost) EndNegotiation() {
+	h.negotiations.End()
+}
+
+// DrainNegotiations stops accepting new negotiations, and waits for the negotiations in
+// progress to finish within the drain timeout
+func (h *StorageHost) DrainNegotiations() {
+	h.lock.RLock()
+	timeout := h.drainTimeout
+	h.lock.RUnlock()
+
+	if left := h.negotiations.Drain(timeout); left != 0 {
+		h.log.Warn("Storage host negotiations not finished within the drain timeout", "negotiations", left, "timeout", timeout)
+	}
+}
+
+// Close the storage host and persist the data. The negotiations in progress are drained
+// before the host is stopped
 func (h *StorageHost) Close() error {
+	h.DrainNegotiations()
+
 	err := h.tm.Stop()
 
 	newErr := h.StorageManager.Close()
@@ -167,6 +255,9 @@ func (h *StorageHost) Close() error {
 
 	h.db.Close()
 
+	newErr = h.bandwidth.save(h.bandwidthPath())
+	err = common.ErrCompose(err, newErr)
+
 	newErr = h.syncConfig()
 	err = common.ErrCompose(err, newErr)
 	return err
@@ -215,6 +306,17 @@ func (h *StorageHost) load() error {
 	return nil
 }
 
+// applyReadCacheConfig applies the read cache config and the read concurrency of the storage
+// folders to the storage manager. The caller must hold the host lock. Nothing is applied if
+// the storage manager is not set up
+func (h *StorageHost) applyReadCacheConfig() error {
+	if h.StorageManager == nil {
+		return nil
+	}
+	h.StorageManager.SetReadConcurrency(h.config.ReadIO.DiskConcurrency, h.config.ReadIO.FolderConcurrency)
+	return h.StorageManager.SetReadCache(h.config.ReadCacheSize, h.config.ReadCacheDiskPath, h.config.ReadCacheDiskSize)
+}
+
 // getPaymentAddress get the current payment address. If no address is set, assign the first
 // account address as the payment address
 func (h *StorageHost) getPaymentAddress() (common.Address, error) {
@@ -403,6 +505,15 @@ func (h *StorageHost) setUploadBandwidthPrice(val common.BigInt) error {
 	return h.syncConfig()
 }
 
+// checkHostConfigHash checks whether the host config hash carried in the negotiation request
+// matches the current host config. The empty hash is not checked
+func (h *StorageHost) checkHostConfigHash(hash common.Hash) error {
+	if hash == (common.Hash{}) || hash == h.externalConfig().Hash() {
+		return nil
+	}
+	return storage.ErrHostConfigChanged
+}
+
 //return the externalConfig for host
 func (h *StorageHost) externalConfig() storage.HostExtConfig {
 	h.lock.Lock()
@@ -415,7 +526,9 @@ func (h *StorageHost) externalConfig() storage.HostExtConfig {
 	totalStorageSpace = storage.SectorSize * hs.TotalSectors
 	remainingStorageSpace = storage.SectorSize * hs.FreeSectors
 
-	acceptingContracts := h.config.AcceptingContracts
+	// degrade gracefully when running out of the free space
+	acceptingContracts := h.config.AcceptingContracts && !lowSpaceForContracts(h.config.LowSpace, remainingStorageSpace)
+	remainingStorageSpace = advertisedRemainingStorage(h.config.LowSpace, remainingStorageSpace)
 	MaxDeposit := h.config.MaxDeposit
 	paymentAddress := h.config.PaymentAddress
 
@@ -448,13 +561,24 @@ func (h *StorageHost) externalConfig() storage.HostExtConfig {
 		}
 	}
 
+	// the storage protocol parameters active at the current block height, the host stops
+	// accepting contracts if the version is not supported
+	windowSize := h.config.WindowSize
+	pp, err := storage.ActiveProtocolParams(h.ethBackend.ChainConfig(), h.blockHeight)
+	if err != nil {
+		h.log.Warn("Failed to get the storage protocol parameters", "err", err)
+		acceptingContracts = false
+	} else if windowSize < pp.ProofWindowSize {
+		windowSize = pp.ProofWindowSize
+	}
+
 	return storage.HostExtConfig{
 		AcceptingContracts:     acceptingContracts,
 		MaxDownloadBatchSize:   h.config.MaxDownloadBatchSize,
 		MaxDuration:            h.config.MaxDuration,
 		MaxReviseBatchSize:     h.config.MaxReviseBatchSize,
 		SectorSize:             storage.SectorSize,
-		WindowSize:             h.config.WindowSize,
+		WindowSize:             windowSize,
 		PaymentAddress:         paymentAddress,
 		TotalStorage:           totalStorageSpace,
 		RemainingStorage:       remainingStorageSpace,
@@ -467,5 +591,8 @@ func (h *StorageHost) externalConfig() storage.HostExtConfig {
 		StoragePrice:           h.config.StoragePrice,
 		UploadBandwidthPrice:   h.config.UploadBandwidthPrice,
 		Version:                storage.ConfigVersion,
+		PublicRead:             h.config.PublicRead,
+		Region:                 h.config.Region,
+		ProtocolVersion:        pp.Version,
 	}
 }
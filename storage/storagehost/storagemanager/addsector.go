@@ -379,7 +379,7 @@ func (update *addSectorUpdate) processNormal(manager *storageManager) (err error
 		return
 	}
 	if update.physical {
-		_, err = update.folder.dataFile.WriteAt(update.data, int64(update.sector.index*storage.SectorSize))
+		_, err = update.folder.store.WriteAt(update.data, int64(update.sector.index*storage.SectorSize))
 		if err != nil {
 			return
 		}
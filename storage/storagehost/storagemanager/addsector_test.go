@@ -413,7 +413,7 @@ func checkSectorExist(root common.Hash, sm *storageManager, data []byte, count u
 	}
 	// check whether the sector data is saved correctly
 	b := make([]byte, storage.SectorSize)
-	n, err := mmFolder.dataFile.ReadAt(b, int64(sector.index*storage.SectorSize))
+	n, err := mmFolder.store.ReadAt(b, int64(sector.index*storage.SectorSize))
 	if err != nil {
 		return err
 	}
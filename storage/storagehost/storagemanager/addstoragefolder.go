@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/unit"
@@ -85,8 +84,11 @@ func (sm *storageManager) validateAddStorageFolder(path string, size uint64) (er
 		return
 	}
 	// check whether the folder path already exists
-	_, err = os.Stat(path)
-	if !os.IsNotExist(err) {
+	exist, err := sectorStoreExists(path)
+	if err != nil {
+		return
+	}
+	if exist {
 		err = fmt.Errorf("folder already exists: %v", path)
 		return
 	}
@@ -96,7 +98,7 @@ func (sm *storageManager) validateAddStorageFolder(path string, size uint64) (er
 		return
 	}
 	// Check the existence of the folder in database
-	exist, err := sm.db.hasStorageFolder(path)
+	exist, err = sm.db.hasStorageFolder(path)
 	if err != nil {
 		err = fmt.Errorf("check existence error: %v", err)
 		return
@@ -232,10 +234,10 @@ func (update *addStorageFolderUpdate) release(manager *storageManager, upErr *up
 		err = common.ErrCompose(err, newErr)
 		return
 	}
-	// Close the folder datafile
+	// Close the folder sector store
 	if update.folder != nil {
-		if newErr := update.folder.dataFile.Close(); newErr != nil {
-			err = common.ErrCompose(err, newErr)
+		if update.folder.store != nil {
+			err = common.ErrCompose(err, update.folder.store.Close())
 		}
 		// Delete the entry in database
 		if newErr := manager.db.deleteStorageFolder(update.folder); newErr != nil {
@@ -247,7 +249,7 @@ func (update *addStorageFolderUpdate) release(manager *storageManager, upErr *up
 	// file, which might be useful to other programs. So delete the file only if the processErr
 	// is not os.ErrExist
 	if upErr.processErr != os.ErrExist {
-		if newErr := os.Remove(filepath.Join(update.path, dataFileName)); newErr != nil {
+		if newErr := removeSectorStore(update.path); newErr != nil {
 			err = common.ErrCompose(err, newErr)
 		}
 	}
@@ -303,20 +305,11 @@ func (update *addStorageFolderUpdate) processNormal(manager *storageManager) (er
 		return fmt.Errorf("cannot commit the transaction: %v", err)
 	}
 	// check again whether the folder exists
-	if _, err := os.Stat(filepath.Join(update.path)); !os.IsNotExist(err) {
+	if exist, err := sectorStoreExists(update.path); err != nil || exist {
 		return os.ErrExist
 	}
-	// create the directory
-	if err = os.MkdirAll(update.path, 0700); err != nil {
-		return err
-	}
-	// create the data file
-	update.folder.dataFile, err = os.Create(filepath.Join(update.path, dataFileName))
-	if err != nil {
-		return
-	}
-	// truncate the data file
-	if err = update.folder.dataFile.Truncate(int64(update.size)); err != nil {
+	// create the sector store with the size
+	if update.folder.store, err = createSectorStore(update.path, update.size); err != nil {
 		return err
 	}
 	// write the batch to database
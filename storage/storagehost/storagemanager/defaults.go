@@ -36,6 +36,9 @@ const (
 	databaseFileName = "storagemanager.db"
 	walFileName      = "storagemanager.wal"
 	dataFileName     = "dxstorage.dat"
+
+	// readCacheFileExt is the extension of the sector files in the disk tier of the read cache
+	readCacheFileExt = ".sector"
 )
 
 const (
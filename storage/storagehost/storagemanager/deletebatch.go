@@ -69,6 +69,10 @@ func (sm *storageManager) DeleteSectorBatch(roots []common.Hash) (err error) {
 	// Lock the storage manager
 	sm.lock.Lock()
 	defer sm.lock.Unlock()
+	// remove the sectors from the read cache
+	for _, root := range roots {
+		sm.cache.remove(root)
+	}
 	// create the update and record the intent
 	update := sm.createDeleteSectorBatchUpdate(roots)
 	if err = update.recordIntent(sm); err != nil {
@@ -170,7 +170,7 @@ func (update *expandFolderUpdate) processNormal(manager *storageManager) (err er
 		return err
 	}
 	// truncate the related file
-	if err = update.folder.dataFile.Truncate(int64(numSectorsToSize(update.targetNumSectors))); err != nil {
+	if err = update.folder.store.Truncate(int64(numSectorsToSize(update.targetNumSectors))); err != nil {
 		return err
 	}
 	// apply the batch
@@ -224,7 +224,7 @@ func (update *expandFolderUpdate) release(manager *storageManager, upErr *update
 	newErr = manager.db.writeBatch(batch)
 	err = common.ErrCompose(err, newErr)
 	// revert the file data
-	newErr = update.folder.dataFile.Truncate(int64(numSectorsToSize(update.prevNumSectors)))
+	newErr = update.folder.store.Truncate(int64(numSectorsToSize(update.prevNumSectors)))
 	err = common.ErrCompose(err, newErr)
 	// release the transaction
 	newErr = update.txn.Release()
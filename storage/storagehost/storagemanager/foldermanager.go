@@ -37,10 +37,12 @@ func loadFolderManager(db *database) (fm *folderManager, err error) {
 	return
 }
 
-// close close all files in the storage folders
+// close close all sector stores in the storage folders
 func (fm *folderManager) close() (err error) {
 	for _, sf := range fm.sfs {
-		err = common.ErrCompose(err, sf.dataFile.Close())
+		if sf.store != nil {
+			err = common.ErrCompose(err, sf.store.Close())
+		}
 	}
 	return
 }
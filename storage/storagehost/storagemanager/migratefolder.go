@@ -0,0 +1,117 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagemanager
+
+import (
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// MigrateFolder moves the sectors of the storage folder to a new sector store at the target
+// path, which could be of another backend, e.g. from the local directory to the object store.
+// The folder keeps the id, so the sector locations in database are not changed. The folder
+// is switched to the target only after all sectors are copied, and the previous store is
+// removed afterwards
+func (sm *storageManager) MigrateFolder(folderPath string, targetPath string) (err error) {
+	// Change the paths to absolute path
+	if folderPath, err = absolutePath(folderPath); err != nil {
+		return
+	}
+	if targetPath, err = absolutePath(targetPath); err != nil {
+		return
+	}
+	if err = sm.tm.Add(); err != nil {
+		return errStopped
+	}
+	defer sm.tm.Done()
+
+	sm.lock.Lock()
+	defer sm.lock.Unlock()
+
+	sf, err := sm.folders.get(folderPath)
+	if err != nil {
+		return err
+	}
+	if sf.status == folderUnavailable {
+		return fmt.Errorf("folder status unavailable")
+	}
+	if err = sm.validateMigrateTarget(targetPath); err != nil {
+		return err
+	}
+	// copy the sectors to the target store
+	target, err := createSectorStore(targetPath, numSectorsToSize(sf.numSectors))
+	if err != nil {
+		return fmt.Errorf("cannot create the target sector store: %v", err)
+	}
+	if err = sm.copySectors(sf, target); err != nil {
+		err = common.ErrCompose(err, target.Close(), removeSectorStore(targetPath))
+		return fmt.Errorf("cannot copy the sectors: %v", err)
+	}
+	// switch the folder to the target path in database and memory
+	prevPath, prevStore := sf.path, sf.store
+	sf.path, sf.store = targetPath, target
+	batch := sm.db.newBatch()
+	batch.Delete(makeFolderKey(prevPath))
+	if batch, err = sm.db.saveStorageFolderToBatch(batch, sf); err == nil {
+		err = sm.db.writeBatch(batch)
+	}
+	if err != nil {
+		sf.path, sf.store = prevPath, prevStore
+		return common.ErrCompose(err, target.Close(), removeSectorStore(targetPath))
+	}
+	sm.folders.delete(prevPath)
+	if err = sm.folders.addFolder(sf); err != nil {
+		return err
+	}
+	// all sectors are safe in the target store. Remove the previous store
+	if err = common.ErrCompose(prevStore.Close(), removeSectorStore(prevPath)); err != nil {
+		sm.log.Warn("Cannot remove the migrated sector store", "path", prevPath, "err", err)
+	}
+	return nil
+}
+
+// validateMigrateTarget validates the target path of the folder migration
+func (sm *storageManager) validateMigrateTarget(targetPath string) (err error) {
+	if sm.folders.exist(targetPath) {
+		return fmt.Errorf("folder already exist in memory")
+	}
+	exist, err := sm.db.hasStorageFolder(targetPath)
+	if err != nil {
+		return fmt.Errorf("check existence error: %v", err)
+	}
+	if exist {
+		return fmt.Errorf("folder already exist in database")
+	}
+	if exist, err = sectorStoreExists(targetPath); err != nil {
+		return err
+	}
+	if exist {
+		return fmt.Errorf("folder already exists: %v", targetPath)
+	}
+	return nil
+}
+
+// copySectors copies the used sector slots of the folder to the same slots in the target
+func (sm *storageManager) copySectors(sf *storageFolder, target SectorStore) error {
+	b := make([]byte, storage.SectorSize)
+	for index := uint64(0); index < sf.numSectors; index++ {
+		if sf.usage[index/bitVectorGranularity].isFree(index % bitVectorGranularity) {
+			continue
+		}
+		if sm.stopped() {
+			return errStopped
+		}
+		off := int64(index * storage.SectorSize)
+		if n, err := sf.store.ReadAt(b, off); err != nil || uint64(n) != storage.SectorSize {
+			return fmt.Errorf("cannot read sector %v: %v", index, err)
+		}
+		if n, err := target.WriteAt(b, off); err != nil || uint64(n) != storage.SectorSize {
+			return fmt.Errorf("cannot write sector %v: %v", index, err)
+		}
+	}
+	return nil
+}
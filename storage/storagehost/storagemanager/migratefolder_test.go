@@ -0,0 +1,69 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagemanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestMigrateFolder test the sectors are moved to the target path, and the storage manager
+// serves the sectors from the target after restart
+func TestMigrateFolder(t *testing.T) {
+	sm := newTestStorageManager(t, "", newDisruptor())
+	path := randomFolderPath(t, "")
+	size := uint64(1 << 25)
+	if err := sm.AddStorageFolder(path, size); err != nil {
+		t.Fatal(err)
+	}
+	datas := make([][]byte, 0, 3)
+	for i := 0; i != 3; i++ {
+		data := randomBytes(storage.SectorSize)
+		if err := sm.AddSector(merkle.Sha256MerkleTreeRoot(data), data); err != nil {
+			t.Fatal(err)
+		}
+		datas = append(datas, data)
+	}
+	target := randomFolderPath(t, "")
+	if err := sm.MigrateFolder(path, target); err != nil {
+		t.Fatal(err)
+	}
+	// the previous data file shall be removed, and the folder is moved to the target
+	if _, err := os.Stat(filepath.Join(path, dataFileName)); !os.IsNotExist(err) {
+		t.Fatalf("data file not removed after migration: %v", err)
+	}
+	if sm.folders.exist(path) {
+		t.Fatalf("previous path still in folder manager")
+	}
+	if err := checkFolderSize(sm, target, size); err != nil {
+		t.Fatal(err)
+	}
+	// migrating to an existing folder shall fail
+	if err := sm.MigrateFolder(target, target); err == nil {
+		t.Fatalf("migrating to an existing folder shall fail")
+	}
+
+	// restart the storage manager and check the sectors
+	if err := sm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	newSM, err := New(sm.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = newSM.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer newSM.Close()
+	for _, data := range datas {
+		if err := checkSectorExist(merkle.Sha256MerkleTreeRoot(data), newSM.(*storageManager), data, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
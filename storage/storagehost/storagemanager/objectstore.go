@@ -0,0 +1,367 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagemanager
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// The storage folder could be backed by the S3 compatible object store, e.g. AWS S3 or minio,
+// where the folder path is in the form of s3://endpoint/bucket/prefix. Use s3+http:// for the
+// endpoint without TLS. The credentials are read from the environment variables
+const (
+	objectStoreScheme     = "s3://"
+	objectStoreHTTPScheme = "s3+http://"
+
+	envObjectStoreAccessKey = "AWS_ACCESS_KEY_ID"
+	envObjectStoreSecretKey = "AWS_SECRET_ACCESS_KEY"
+	envObjectStoreRegion    = "AWS_REGION"
+
+	defaultObjectStoreRegion = "us-east-1"
+
+	// objectStoreTimeout is the timeout of a single request to the object store
+	objectStoreTimeout = 2 * time.Minute
+
+	// the object names of the store size and the sectors under the folder prefix
+	objectNameSize    = "size"
+	objectNameSectors = "sectors"
+)
+
+var (
+	// errBlobNotFound is the error that the object is not found in the object store
+	errBlobNotFound = errors.New("object not found")
+
+	// errSectorStoreNotExist is the error that the sector store of the folder not exist
+	errSectorStoreNotExist = errors.New("sector store not exist")
+
+	// errUnalignedAccess is the error that the object store is not accessed by full sectors
+	errUnalignedAccess = errors.New("object store shall be accessed by full sectors")
+)
+
+// blobClient is the client of the object store
+type blobClient interface {
+	get(key string) ([]byte, error)
+	put(key string, data []byte) error
+	delete(key string) error
+}
+
+// objectSectorStore is the sector store backed by the object store, where each sector is
+// stored as an object. The sector never written is read as zeros, as a sparse data file
+type objectSectorStore struct {
+	client blobClient
+	prefix string
+
+	size int64
+	lock sync.RWMutex
+}
+
+// newObjectSectorStore creates a new empty sector store under the prefix
+func newObjectSectorStore(client blobClient, prefix string) (*objectSectorStore, error) {
+	store := &objectSectorStore{
+		client: client,
+		prefix: prefix,
+	}
+	if err := store.putSize(0); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// loadObjectSectorStore loads the existing sector store under the prefix
+func loadObjectSectorStore(client blobClient, prefix string) (*objectSectorStore, error) {
+	store := &objectSectorStore{
+		client: client,
+		prefix: prefix,
+	}
+	b, err := client.get(store.sizeKey())
+	if err == errBlobNotFound {
+		return nil, errSectorStoreNotExist
+	} else if err != nil {
+		return nil, err
+	}
+	if store.size, err = strconv.ParseInt(string(b), 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid sector store size: %v", err)
+	}
+	return store, nil
+}
+
+// ReadAt reads the sector at the offset. The sector never written is read as zeros
+func (store *objectSectorStore) ReadAt(b []byte, off int64) (int, error) {
+	if !sectorAligned(b, off) {
+		return 0, errUnalignedAccess
+	}
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	if off+int64(len(b)) > store.size {
+		return 0, io.EOF
+	}
+	data, err := store.client.get(store.sectorKey(off))
+	if err == errBlobNotFound {
+		for i := range b {
+			b[i] = 0
+		}
+		return len(b), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(data) != len(b) {
+		return 0, fmt.Errorf("sector object size %v not expected", len(data))
+	}
+	return copy(b, data), nil
+}
+
+// WriteAt writes the sector at the offset, which shall be within the size of the store
+func (store *objectSectorStore) WriteAt(b []byte, off int64) (int, error) {
+	if !sectorAligned(b, off) {
+		return 0, errUnalignedAccess
+	}
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	if off+int64(len(b)) > store.size {
+		return 0, fmt.Errorf("write beyond the sector store size %v", store.size)
+	}
+	if err := store.client.put(store.sectorKey(off), b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Truncate changes the size of the store. The sectors beyond the size are deleted
+func (store *objectSectorStore) Truncate(size int64) error {
+	if size < 0 || size%int64(storage.SectorSize) != 0 {
+		return errUnalignedAccess
+	}
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	// Update the size before the sectors are deleted, so that the sectors left over by
+	// the interruption are never read
+	prevSize := store.size
+	if err := store.putSize(size); err != nil {
+		return err
+	}
+	return store.deleteSectors(size, prevSize)
+}
+
+// Size returns the size of the store
+func (store *objectSectorStore) Size() (int64, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	return store.size, nil
+}
+
+// Close closes the store. Nothing is to be released for the object store
+func (store *objectSectorStore) Close() error {
+	return nil
+}
+
+// remove deletes all the objects of the store
+func (store *objectSectorStore) remove() error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	if err := store.deleteSectors(0, store.size); err != nil {
+		return err
+	}
+	store.size = 0
+	return store.client.delete(store.sizeKey())
+}
+
+// deleteSectors deletes the sector objects within the offset range [from, to)
+func (store *objectSectorStore) deleteSectors(from, to int64) error {
+	for off := from; off < to; off += int64(storage.SectorSize) {
+		if err := store.client.delete(store.sectorKey(off)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putSize writes the size of the store
+func (store *objectSectorStore) putSize(size int64) error {
+	if err := store.client.put(store.sizeKey(), []byte(strconv.FormatInt(size, 10))); err != nil {
+		return err
+	}
+	store.size = size
+	return nil
+}
+
+// sizeKey returns the object key of the store size
+func (store *objectSectorStore) sizeKey() string {
+	return store.prefix + "/" + objectNameSize
+}
+
+// sectorKey returns the object key of the sector at the offset
+func (store *objectSectorStore) sectorKey(off int64) string {
+	index := uint64(off) / storage.SectorSize
+	return store.prefix + "/" + objectNameSectors + "/" + strconv.FormatUint(index, 10)
+}
+
+// sectorAligned checks whether the data and offset are of a single full sector
+func sectorAligned(b []byte, off int64) bool {
+	return uint64(len(b)) == storage.SectorSize && off >= 0 && uint64(off)%storage.SectorSize == 0
+}
+
+// s3Client is the client of the S3 compatible object store, which signs the requests with
+// the AWS signature version 4 and accesses the bucket in the path style
+type s3Client struct {
+	scheme    string
+	host      string
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+
+	client *http.Client
+}
+
+// parseObjectStorePath parses the folder path of the object store to the client and the
+// prefix of the objects of the folder
+func parseObjectStorePath(path string) (blobClient, string, error) {
+	scheme, rest := "https", strings.TrimPrefix(path, objectStoreScheme)
+	if strings.HasPrefix(path, objectStoreHTTPScheme) {
+		scheme, rest = "http", strings.TrimPrefix(path, objectStoreHTTPScheme)
+	}
+	parts := strings.SplitN(strings.Trim(rest, "/"), "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, "", fmt.Errorf("invalid object store path %v: expect %vendpoint/bucket/prefix", path, objectStoreScheme)
+	}
+	bucket, prefix := parts[1], strings.TrimRight(parts[2], "/")
+	for _, c := range bucket + prefix {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && !strings.ContainsRune("-_./", c) {
+			return nil, "", fmt.Errorf("invalid object store path %v: invalid character %q", path, c)
+		}
+	}
+	accessKey, secretKey := os.Getenv(envObjectStoreAccessKey), os.Getenv(envObjectStoreSecretKey)
+	if accessKey == "" || secretKey == "" {
+		return nil, "", fmt.Errorf("object store credentials not set in %v and %v", envObjectStoreAccessKey, envObjectStoreSecretKey)
+	}
+	region := os.Getenv(envObjectStoreRegion)
+	if region == "" {
+		region = defaultObjectStoreRegion
+	}
+	return &s3Client{
+		scheme:    scheme,
+		host:      parts[0],
+		bucket:    bucket,
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: objectStoreTimeout},
+	}, prefix, nil
+}
+
+// get downloads the object. errBlobNotFound is returned if the object not exist
+func (c *s3Client) get(key string) ([]byte, error) {
+	resp, err := c.do(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errBlobNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, s3ResponseError(resp)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// put uploads the object
+func (c *s3Client) put(key string, data []byte) error {
+	resp, err := c.do(http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3ResponseError(resp)
+	}
+	return nil
+}
+
+// delete deletes the object. Deleting the object not exist is not an error
+func (c *s3Client) delete(key string) error {
+	resp, err := c.do(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3ResponseError(resp)
+	}
+	return nil
+}
+
+// do sends the signed request of the object
+func (c *s3Client) do(method string, key string, body []byte) (*http.Response, error) {
+	uri := "/" + c.bucket + "/" + key
+	req, err := http.NewRequest(method, c.scheme+"://"+c.host+uri, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	c.sign(req, uri, body, time.Now().UTC())
+	return c.client.Do(req)
+}
+
+// sign signs the request with the AWS signature version 4
+func (c *s3Client) sign(req *http.Request, uri string, body []byte, now time.Time) {
+	amzDate, date := now.Format("20060102T150405Z"), now.Format("20060102")
+	payloadHash := sha256Hex(body)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + c.host + "\n" + "x-amz-content-sha256:" + payloadHash + "\n" + "x-amz-date:" + amzDate + "\n"
+	canonicalRequest := strings.Join([]string{req.Method, uri, "", canonicalHeaders, signedHeaders, payloadHash}, "\n")
+
+	scope := date + "/" + c.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	for _, s := range []string{c.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", c.accessKey, scope, signedHeaders, signature))
+}
+
+// s3ResponseError returns the error of the unexpected response of the object store
+func s3ResponseError(resp *http.Response) error {
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("object store responded %v: %s", resp.Status, msg)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
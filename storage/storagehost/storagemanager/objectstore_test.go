@@ -0,0 +1,155 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagemanager
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// memBlobClient is the in-memory blob client used for testing
+type memBlobClient struct {
+	objects map[string][]byte
+	lock    sync.Mutex
+}
+
+func newMemBlobClient() *memBlobClient {
+	return &memBlobClient{objects: make(map[string][]byte)}
+}
+
+func (c *memBlobClient) get(key string) ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	data, exist := c.objects[key]
+	if !exist {
+		return nil, errBlobNotFound
+	}
+	return append([]byte{}, data...), nil
+}
+
+func (c *memBlobClient) put(key string, data []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.objects[key] = append([]byte{}, data...)
+	return nil
+}
+
+func (c *memBlobClient) delete(key string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	delete(c.objects, key)
+	return nil
+}
+
+// TestObjectSectorStore test the read, write and truncate of the object sector store
+func TestObjectSectorStore(t *testing.T) {
+	client := newMemBlobClient()
+	if _, err := loadObjectSectorStore(client, "bucket/folder"); err != errSectorStoreNotExist {
+		t.Fatalf("expect error %v, got %v", errSectorStoreNotExist, err)
+	}
+	store, err := newObjectSectorStore(client, "bucket/folder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Truncate(int64(4 * storage.SectorSize)); err != nil {
+		t.Fatal(err)
+	}
+	data := randomBytes(storage.SectorSize)
+	if _, err = store.WriteAt(data, int64(2*storage.SectorSize)); err != nil {
+		t.Fatal(err)
+	}
+	// the access beyond the size or not by full sectors shall fail
+	if _, err = store.WriteAt(data, int64(4*storage.SectorSize)); err == nil {
+		t.Fatal("write beyond the size shall fail")
+	}
+	if _, err = store.WriteAt(data[1:], int64(2*storage.SectorSize)); err != errUnalignedAccess {
+		t.Fatalf("expect error %v, got %v", errUnalignedAccess, err)
+	}
+
+	// the store shall be reloaded with the size and data
+	loaded, err := loadObjectSectorStore(client, "bucket/folder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size, _ := loaded.Size(); size != int64(4*storage.SectorSize) {
+		t.Fatalf("expect size %v, got %v", 4*storage.SectorSize, size)
+	}
+	b := make([]byte, storage.SectorSize)
+	if _, err = loaded.ReadAt(b, int64(2*storage.SectorSize)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, data) {
+		t.Fatal("sector data not expected")
+	}
+	// the sector never written is read as zeros
+	if _, err = loaded.ReadAt(b, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, make([]byte, storage.SectorSize)) {
+		t.Fatal("sector never written shall be zeros")
+	}
+
+	// truncate deletes the sectors beyond the size
+	if err = loaded.Truncate(int64(2 * storage.SectorSize)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = loaded.ReadAt(b, int64(2*storage.SectorSize)); err != io.EOF {
+		t.Fatalf("expect error %v, got %v", io.EOF, err)
+	}
+	if _, err = client.get(loaded.sectorKey(int64(2 * storage.SectorSize))); err != errBlobNotFound {
+		t.Fatalf("sector beyond the size not deleted")
+	}
+	if err = loaded.remove(); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.objects) != 0 {
+		t.Fatalf("%v objects left after the store is removed", len(client.objects))
+	}
+}
+
+func TestParseObjectStorePath(t *testing.T) {
+	os.Setenv(envObjectStoreAccessKey, "access")
+	os.Setenv(envObjectStoreSecretKey, "secret")
+	defer func() {
+		os.Unsetenv(envObjectStoreAccessKey)
+		os.Unsetenv(envObjectStoreSecretKey)
+	}()
+
+	tests := []struct {
+		path   string
+		scheme string
+		host   string
+		bucket string
+		prefix string
+		valid  bool
+	}{
+		{"s3://s3.amazonaws.com/bucket/host/folder1", "https", "s3.amazonaws.com", "bucket", "host/folder1", true},
+		{"s3+http://localhost:9000/bucket/folder1/", "http", "localhost:9000", "bucket", "folder1", true},
+		{"s3://localhost:9000/bucket", "", "", "", "", false},
+		{"s3://localhost:9000/bucket/folder 1", "", "", "", "", false},
+	}
+	for _, test := range tests {
+		client, prefix, err := parseObjectStorePath(test.path)
+		if (err == nil) != test.valid {
+			t.Errorf("%v: expect valid %v, got error %v", test.path, test.valid, err)
+			continue
+		}
+		if !test.valid {
+			continue
+		}
+		c := client.(*s3Client)
+		if c.scheme != test.scheme || c.host != test.host || c.bucket != test.bucket || prefix != test.prefix {
+			t.Errorf("%v: got %v %v %v %v", test.path, c.scheme, c.host, c.bucket, prefix)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagemanager
+
+import (
+	"container/list"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/metrics"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+var (
+	readCacheHitCounter     = metrics.NewRegisteredCounter("storagehost/storagemanager/readcache/hit", nil)
+	readCacheDiskHitCounter = metrics.NewRegisteredCounter("storagehost/storagemanager/readcache/diskhit", nil)
+	readCacheMissCounter    = metrics.NewRegisteredCounter("storagehost/storagemanager/readcache/miss", nil)
+)
+
+type (
+	// readCache is the cache of the sectors read from the storage folders, keyed by the
+	// merkle root of the sector. The hot sectors are kept in memory, and the sectors evicted
+	// from memory are spilled to the optional disk tier, which is expected to be a faster
+	// disk, e.g. SSD, than the storage folders. Both tiers are evicted in LRU order
+	readCache struct {
+		memory *sectorLRU
+		disk   *sectorLRU
+
+		// diskPath is the directory of the disk tier. Empty if the disk tier is disabled
+		diskPath string
+
+		hits, diskHits, misses uint64
+
+		lock sync.Mutex
+	}
+
+	// sectorLRU is the list of cached sectors in LRU order with a capacity in number of sectors
+	sectorLRU struct {
+		capacity uint64
+		ll       *list.List
+		items    map[common.Hash]*list.Element
+	}
+
+	// cachedSector is the sector in the cache. The data is nil for the sector in the disk tier
+	cachedSector struct {
+		root common.Hash
+		data []byte
+	}
+)
+
+// newReadCache creates a read cache with the memory size and without the disk tier
+func newReadCache(memorySize uint64) *readCache {
+	return &readCache{
+		memory: newSectorLRU(memorySize / storage.SectorSize),
+		disk:   newSectorLRU(0),
+	}
+}
+
+// newSectorLRU creates a sectorLRU with the capacity
+func newSectorLRU(capacity uint64) *sectorLRU {
+	return &sectorLRU{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[common.Hash]*list.Element),
+	}
+}
+
+// get returns the cached sector and marks it as recently used
+func (l *sectorLRU) get(root common.Hash) (*cachedSector, bool) {
+	elem, exist := l.items[root]
+	if !exist {
+		return nil, false
+	}
+	l.ll.MoveToFront(elem)
+	return elem.Value.(*cachedSector), true
+}
+
+// add adds the sector as the most recently used one, and returns the sectors evicted
+func (l *sectorLRU) add(cs *cachedSector) (evicted []*cachedSector) {
+	if l.capacity == 0 {
+		return []*cachedSector{cs}
+	}
+	if elem, exist := l.items[cs.root]; exist {
+		elem.Value = cs
+		l.ll.MoveToFront(elem)
+		return nil
+	}
+	l.items[cs.root] = l.ll.PushFront(cs)
+	for uint64(l.ll.Len()) > l.capacity {
+		evicted = append(evicted, l.removeElement(l.ll.Back()))
+	}
+	return evicted
+}
+
+// remove removes the sector from the list. Nil is returned if not cached
+func (l *sectorLRU) remove(root common.Hash) *cachedSector {
+	elem, exist := l.items[root]
+	if !exist {
+		return nil
+	}
+	return l.removeElement(elem)
+}
+
+// removeElement removes the element from the list
+func (l *sectorLRU) removeElement(elem *list.Element) *cachedSector {
+	cs := l.ll.Remove(elem).(*cachedSector)
+	delete(l.items, cs.root)
+	return cs
+}
+
+// get returns the copy of the cached sector data, promoting the sector in the disk tier
+// to memory
+func (rc *readCache) get(root common.Hash) ([]byte, bool) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	if cs, exist := rc.memory.get(root); exist {
+		rc.hits++
+		readCacheHitCounter.Inc(1)
+		return common.CopyBytes(cs.data), true
+	}
+	if rc.disk.remove(root) != nil {
+		data, err := ioutil.ReadFile(rc.diskFilePath(root))
+		os.Remove(rc.diskFilePath(root))
+		if err == nil && uint64(len(data)) == storage.SectorSize {
+			rc.diskHits++
+			readCacheDiskHitCounter.Inc(1)
+			rc.addToMemory(&cachedSector{root: root, data: data})
+			return common.CopyBytes(data), true
+		}
+	}
+	rc.misses++
+	readCacheMissCounter.Inc(1)
+	return nil, false
+}
+
+// add adds the copy of the sector data read from the storage folder to the cache
+func (rc *readCache) add(root common.Hash, data []byte) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	rc.addToMemory(&cachedSector{root: root, data: common.CopyBytes(data)})
+}
+
+// addToMemory adds the sector to memory, and spills the sectors evicted from memory to
+// the disk tier. The caller must hold the lock
+func (rc *readCache) addToMemory(cs *cachedSector) {
+	for _, evicted := range rc.memory.add(cs) {
+		rc.addToDisk(evicted)
+	}
+}
+
+// addToDisk writes the sector to the disk tier, and removes the sectors evicted from the
+// disk tier. The caller must hold the lock
+func (rc *readCache) addToDisk(cs *cachedSector) {
+	if rc.diskPath == "" || rc.disk.capacity == 0 {
+		return
+	}
+	if err := ioutil.WriteFile(rc.diskFilePath(cs.root), cs.data, 0600); err != nil {
+		return
+	}
+	for _, evicted := range rc.disk.add(&cachedSector{root: cs.root}) {
+		os.Remove(rc.diskFilePath(evicted.root))
+	}
+}
+
+// remove removes the sector from both tiers of the cache
+func (rc *readCache) remove(root common.Hash) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	rc.memory.remove(root)
+	if rc.disk.remove(root) != nil {
+		os.Remove(rc.diskFilePath(root))
+	}
+}
+
+// setSize resizes the memory tier, and replaces the disk tier with the one at diskPath with
+// diskSize. The sectors in the previous disk tier are removed
+func (rc *readCache) setSize(memorySize uint64, diskPath string, diskSize uint64) error {
+	if diskPath != "" {
+		var err error
+		if diskPath, err = absolutePath(diskPath); err != nil {
+			return err
+		}
+		if err = os.MkdirAll(diskPath, 0700); err != nil {
+			return fmt.Errorf("cannot create the read cache directory: %v", err)
+		}
+	}
+
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	rc.clearDisk()
+	rc.diskPath = diskPath
+	rc.disk = newSectorLRU(0)
+	if diskPath != "" {
+		rc.disk.capacity = diskSize / storage.SectorSize
+		// remove the stale sectors left by the last run
+		stale, _ := filepath.Glob(filepath.Join(diskPath, "*"+readCacheFileExt))
+		for _, path := range stale {
+			os.Remove(path)
+		}
+	}
+
+	rc.memory.capacity = memorySize / storage.SectorSize
+	for uint64(rc.memory.ll.Len()) > rc.memory.capacity {
+		rc.memory.removeElement(rc.memory.ll.Back())
+	}
+	return nil
+}
+
+// clear removes all sectors in the cache
+func (rc *readCache) clear() {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	rc.memory = newSectorLRU(rc.memory.capacity)
+	rc.clearDisk()
+}
+
+// clearDisk removes all sectors in the disk tier. The caller must hold the lock
+func (rc *readCache) clearDisk() {
+	for root := range rc.disk.items {
+		os.Remove(rc.diskFilePath(root))
+	}
+	rc.disk = newSectorLRU(rc.disk.capacity)
+}
+
+// diskFilePath returns the path of the sector file in the disk tier
+func (rc *readCache) diskFilePath(root common.Hash) string {
+	return filepath.Join(rc.diskPath, common.Bytes2Hex(root[:])+readCacheFileExt)
+}
+
+// stats returns the statistics of the read cache
+func (rc *readCache) stats() storage.HostReadCacheStats {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	return storage.HostReadCacheStats{
+		MemorySectors:  uint64(rc.memory.ll.Len()),
+		MemoryCapacity: rc.memory.capacity,
+		DiskPath:       rc.diskPath,
+		DiskSectors:    uint64(rc.disk.ll.Len()),
+		DiskCapacity:   rc.disk.capacity,
+		Hits:           rc.hits,
+		DiskHits:       rc.diskHits,
+		Misses:         rc.misses,
+	}
+}
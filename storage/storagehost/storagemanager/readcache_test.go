@@ -0,0 +1,105 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagemanager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// TestReadCache_Memory test the LRU eviction of the memory tier of the read cache
+func TestReadCache_Memory(t *testing.T) {
+	rc := newReadCache(2 * storage.SectorSize)
+	roots := []common.Hash{{1}, {2}, {3}}
+	data := make([][]byte, len(roots))
+	for i := range roots {
+		data[i] = randomBytes(storage.SectorSize)
+	}
+	rc.add(roots[0], data[0])
+	rc.add(roots[1], data[1])
+	// access the first sector so that the second sector is the least recently used one
+	if got, cached := rc.get(roots[0]); !cached || !bytes.Equal(got, data[0]) {
+		t.Fatal("the first sector should be cached")
+	}
+	rc.add(roots[2], data[2])
+	if _, cached := rc.get(roots[1]); cached {
+		t.Fatal("the least recently used sector should be evicted")
+	}
+	for _, i := range []int{0, 2} {
+		if got, cached := rc.get(roots[i]); !cached || !bytes.Equal(got, data[i]) {
+			t.Fatalf("sector %d should be cached", i)
+		}
+	}
+	// the data returned should not be modified by the caller
+	got, _ := rc.get(roots[0])
+	got[0]++
+	if got, _ = rc.get(roots[0]); !bytes.Equal(got, data[0]) {
+		t.Fatal("the cached data is modified by the caller")
+	}
+	rc.remove(roots[0])
+	if _, cached := rc.get(roots[0]); cached {
+		t.Fatal("the removed sector should not be cached")
+	}
+
+	stats := rc.stats()
+	if stats.MemorySectors != 1 || stats.MemoryCapacity != 2 || stats.Hits != 5 || stats.Misses != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+// TestReadCache_Disk test the sectors evicted from memory are spilled to the disk tier
+func TestReadCache_Disk(t *testing.T) {
+	diskPath := tempDir(t.Name())
+	rc := newReadCache(storage.SectorSize)
+	if err := rc.setSize(storage.SectorSize, diskPath, storage.SectorSize); err != nil {
+		t.Fatal(err)
+	}
+	roots := []common.Hash{{1}, {2}, {3}}
+	data := make([][]byte, len(roots))
+	for i := range roots {
+		data[i] = randomBytes(storage.SectorSize)
+	}
+	rc.add(roots[0], data[0])
+	rc.add(roots[1], data[1])
+	if _, err := os.Stat(rc.diskFilePath(roots[0])); err != nil {
+		t.Fatalf("the sector evicted from memory should be spilled to disk: %v", err)
+	}
+	// the third sector evicts the second sector to disk, which evicts the first sector from disk
+	rc.add(roots[2], data[2])
+	if _, cached := rc.get(roots[0]); cached {
+		t.Fatal("the sector evicted from disk should not be cached")
+	}
+	if _, err := os.Stat(rc.diskFilePath(roots[0])); !os.IsNotExist(err) {
+		t.Fatal("the sector file evicted from disk should be removed")
+	}
+	// the sector in the disk tier is promoted to memory
+	if got, cached := rc.get(roots[1]); !cached || !bytes.Equal(got, data[1]) {
+		t.Fatal("the second sector should be cached on disk")
+	}
+	if got, cached := rc.get(roots[2]); !cached || !bytes.Equal(got, data[2]) {
+		t.Fatal("the third sector should be spilled to disk")
+	}
+	stats := rc.stats()
+	if stats.DiskHits != 2 || stats.Misses != 1 || stats.MemorySectors != 1 || stats.DiskSectors != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// disabling the disk tier removes the sector files
+	if err := rc.setSize(storage.SectorSize, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(diskPath, "*"+readCacheFileExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("the sector files should be removed: %v", files)
+	}
+}
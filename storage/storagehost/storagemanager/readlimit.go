@@ -0,0 +1,74 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagemanager
+
+import "sync"
+
+// readLimiter limits the number of concurrent sector reads from each storage folder, so that
+// the parallel reads of a download request do not thrash a spinning disk. Each folder is
+// limited by its own limit if configured, otherwise by the default limit. Zero limit means
+// no limit
+type readLimiter struct {
+	defaultLimit uint64
+	limits       map[string]uint64
+
+	// sems is the semaphore of each folder path, created on first use
+	sems map[string]chan struct{}
+
+	lock sync.Mutex
+}
+
+// newReadLimiter creates a read limiter without any limit
+func newReadLimiter() *readLimiter {
+	return &readLimiter{
+		limits: make(map[string]uint64),
+		sems:   make(map[string]chan struct{}),
+	}
+}
+
+// setLimits sets the default limit and the limits of the folders. The reads already holding
+// the old semaphores are not affected, and the new limits apply to the reads afterwards
+func (rl *readLimiter) setLimits(defaultLimit uint64, limits map[string]uint64) {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	rl.defaultLimit = defaultLimit
+	rl.limits = make(map[string]uint64, len(limits))
+	for path, limit := range limits {
+		rl.limits[path] = limit
+	}
+	rl.sems = make(map[string]chan struct{})
+}
+
+// acquire blocks until the read from the folder is allowed, and returns the function
+// releasing the read
+func (rl *readLimiter) acquire(folderPath string) (release func()) {
+	sem := rl.semaphore(folderPath)
+	if sem == nil {
+		return func() {}
+	}
+	sem <- struct{}{}
+	return func() { <-sem }
+}
+
+// semaphore returns the semaphore of the folder. Nil is returned if the folder is not limited
+func (rl *readLimiter) semaphore(folderPath string) chan struct{} {
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	if sem, exist := rl.sems[folderPath]; exist {
+		return sem
+	}
+	limit, exist := rl.limits[folderPath]
+	if !exist {
+		limit = rl.defaultLimit
+	}
+	var sem chan struct{}
+	if limit != 0 {
+		sem = make(chan struct{}, limit)
+	}
+	rl.sems[folderPath] = sem
+	return sem
+}
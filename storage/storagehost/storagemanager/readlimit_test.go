@@ -0,0 +1,49 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagemanager
+
+import (
+	"testing"
+	"time"
+)
+
+// TestReadLimiter test the concurrent reads of each folder are limited by the folder limit
+// or the default limit
+func TestReadLimiter(t *testing.T) {
+	rl := newReadLimiter()
+	rl.setLimits(2, map[string]uint64{"/hdd": 1, "/ssd": 0})
+
+	tests := []struct {
+		path     string
+		acquired int
+	}{
+		{"/other", 2},
+		{"/hdd", 1},
+		{"/ssd", 8},
+	}
+	for _, test := range tests {
+		var releases []func()
+		for i := 0; i < 8; i++ {
+			done := make(chan func())
+			go func() { done <- rl.acquire(test.path) }()
+			select {
+			case release := <-done:
+				releases = append(releases, release)
+				continue
+			case <-time.After(50 * time.Millisecond):
+			}
+			// the acquire blocked, release one of the reads to unblock it
+			releases[0]()
+			releases = append(releases[1:], <-done)
+			break
+		}
+		if len(releases) != test.acquired {
+			t.Errorf("%v: expect %v concurrent reads, got %v", test.path, test.acquired, len(releases))
+		}
+		for _, release := range releases {
+			release()
+		}
+	}
+}
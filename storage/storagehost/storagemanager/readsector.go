@@ -12,11 +12,16 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 )
 
-//ReadSector read the sector data
+//ReadSector read the sector data. The sector data is read from the read cache if cached,
+//otherwise it is read from the storage folder and added to the read cache
 func (sm *storageManager) ReadSector(root common.Hash) (data []byte, err error) {
 	sm.lock.RLock()
 	defer sm.lock.RUnlock()
 
+	if data, cached := sm.cache.get(root); cached {
+		return data, nil
+	}
+
 	// calculate the sector id
 	id := sm.calculateSectorID(root)
 	// get the sector from database
@@ -43,14 +48,43 @@ func (sm *storageManager) ReadSector(root common.Hash) (data []byte, err error)
 		return nil, fmt.Errorf("folder status unavailable")
 	}
 
-	// Read the data from folder
+	// Read the data from folder, limited by the read concurrency of the folder
 	data = make([]byte, storage.SectorSize)
-	n, err := folder.dataFile.ReadAt(data, int64(index*storage.SectorSize))
+	release := sm.readLimiter.acquire(folderPath)
+	n, err := folder.store.ReadAt(data, int64(index*storage.SectorSize))
+	release()
 	if uint64(n) != storage.SectorSize {
 		return nil, fmt.Errorf("cannot read the sector: read %v bytes, expect %v bytes", n, storage.SectorSize)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("cannot read the sector: %v", err)
 	}
+	sm.cache.add(root, data)
 	return
 }
+
+// HasSector checks whether the sector with the merkle root is stored in the storage manager,
+// without reading the sector data
+func (sm *storageManager) HasSector(root common.Hash) (bool, error) {
+	sm.lock.RLock()
+	defer sm.lock.RUnlock()
+
+	return sm.db.hasSector(sm.calculateSectorID(root))
+}
+
+// SetReadCache sets the memory size of the read cache, and the path and size of the disk tier
+// of the read cache. Empty diskPath disables the disk tier
+func (sm *storageManager) SetReadCache(memorySize uint64, diskPath string, diskSize uint64) error {
+	return sm.cache.setSize(memorySize, diskPath, diskSize)
+}
+
+// SetReadConcurrency sets the max number of concurrent sector reads from each storage folder.
+// The folders not in limits are limited by defaultLimit. Zero limit means no limit
+func (sm *storageManager) SetReadConcurrency(defaultLimit uint64, limits map[string]uint64) {
+	sm.readLimiter.setLimits(defaultLimit, limits)
+}
+
+// ReadCacheStats returns the statistics of the read cache
+func (sm *storageManager) ReadCacheStats() storage.HostReadCacheStats {
+	return sm.cache.stats()
+}
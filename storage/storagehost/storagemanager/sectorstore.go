@@ -0,0 +1,139 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagemanager
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SectorStore is the backend where the sector data of a storage folder is persisted.
+// The sectors are addressed by the offset in the store, which is the sector index in
+// the folder times the sector size, and the store is sized with Truncate as a data file
+type SectorStore interface {
+	io.ReaderAt
+	io.WriterAt
+
+	// Truncate changes the size of the store
+	Truncate(size int64) error
+
+	// Size returns the size of the store
+	Size() (int64, error)
+
+	// Close closes the store
+	Close() error
+}
+
+// fileSectorStore is the sector store backed by the data file in the folder directory
+type fileSectorStore struct {
+	*os.File
+}
+
+// Size returns the size of the data file
+func (fs *fileSectorStore) Size() (int64, error) {
+	info, err := fs.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// isObjectStorePath checks whether the folder path refers to the object store
+func isObjectStorePath(path string) bool {
+	return strings.HasPrefix(path, objectStoreScheme) || strings.HasPrefix(path, objectStoreHTTPScheme)
+}
+
+// sectorStoreExists checks whether the sector store of the folder path already exists. For
+// the folder backed by the data file, the folder directory shall not exist
+func sectorStoreExists(path string) (bool, error) {
+	if isObjectStorePath(path) {
+		client, prefix, err := parseObjectStorePath(path)
+		if err != nil {
+			return false, err
+		}
+		_, err = loadObjectSectorStore(client, prefix)
+		if err == errSectorStoreNotExist {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	_, err := os.Stat(path)
+	return !os.IsNotExist(err), nil
+}
+
+// openSectorStore opens the existing sector store of the folder path
+func openSectorStore(path string) (SectorStore, error) {
+	if isObjectStorePath(path) {
+		client, prefix, err := parseObjectStorePath(path)
+		if err != nil {
+			return nil, err
+		}
+		store, err := loadObjectSectorStore(client, prefix)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
+	datafilePath := filepath.Join(path, dataFileName)
+	if _, err := os.Stat(datafilePath); os.IsNotExist(err) {
+		return nil, errors.New("data file not exist")
+	}
+	file, err := os.OpenFile(datafilePath, os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSectorStore{file}, nil
+}
+
+// createSectorStore creates the sector store of the folder path with the size
+func createSectorStore(path string, size uint64) (store SectorStore, err error) {
+	if isObjectStorePath(path) {
+		client, prefix, parseErr := parseObjectStorePath(path)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		store, err = newObjectSectorStore(client, prefix)
+	} else {
+		// create the directory and the data file
+		if err = os.MkdirAll(path, 0700); err != nil {
+			return nil, err
+		}
+		var file *os.File
+		if file, err = os.Create(filepath.Join(path, dataFileName)); err != nil {
+			return nil, err
+		}
+		store = &fileSectorStore{file}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = store.Truncate(int64(size)); err != nil {
+		store.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// removeSectorStore removes the data in the sector store of the folder path. The store
+// shall be closed before removed
+func removeSectorStore(path string) error {
+	if isObjectStorePath(path) {
+		client, prefix, err := parseObjectStorePath(path)
+		if err != nil {
+			return err
+		}
+		store, err := loadObjectSectorStore(client, prefix)
+		if err == errSectorStoreNotExist {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return store.remove()
+	}
+	return os.Remove(filepath.Join(path, dataFileName))
+}
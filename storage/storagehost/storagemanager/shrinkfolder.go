@@ -310,7 +310,7 @@ func (update *shrinkFolderUpdate) processNormal(manager *storageManager) (err er
 	for _, relocate := range update.relocates {
 		// read data
 		prevIndex := relocate.PrevLocation.Index
-		n, err := update.targetFolder.dataFile.ReadAt(b, int64(prevIndex*storage.SectorSize))
+		n, err := update.targetFolder.store.ReadAt(b, int64(prevIndex*storage.SectorSize))
 		if err != nil || uint64(n) != storage.SectorSize {
 			return fmt.Errorf("not read full sector")
 		}
@@ -320,7 +320,7 @@ func (update *shrinkFolderUpdate) processNormal(manager *storageManager) (err er
 			return fmt.Errorf("folder not in folders")
 		}
 		newIndex := relocate.NewLocation.Index
-		n, err = targetFolder.dataFile.WriteAt(b, int64(newIndex*storage.SectorSize))
+		n, err = targetFolder.store.WriteAt(b, int64(newIndex*storage.SectorSize))
 		if err != nil || n != int(storage.SectorSize) {
 			return fmt.Errorf("not full write")
 		}
@@ -335,7 +335,7 @@ func (update *shrinkFolderUpdate) processNormal(manager *storageManager) (err er
 	if manager.disruptor.disrupt("shrink folder process normal stop") {
 		return errStopped
 	}
-	if err = update.targetFolder.dataFile.Truncate(int64(numSectorsToSize(update.targetNumSectors))); err != nil {
+	if err = update.targetFolder.store.Truncate(int64(numSectorsToSize(update.targetNumSectors))); err != nil {
 		return err
 	}
 	return
@@ -378,14 +378,14 @@ func (update *shrinkFolderUpdate) release(manager *storageManager, upErr *update
 		return
 	}
 	// Check whether the file has been truncated
-	info, newErr := update.targetFolder.dataFile.Stat()
+	size, newErr := update.targetFolder.store.Size()
 	err = common.ErrCompose(err, newErr)
-	if newErr == nil && info.Size() != int64(numSectorsToSize(update.prevNumSectors)) {
+	if newErr == nil && size != int64(numSectorsToSize(update.prevNumSectors)) {
 		// the folder has been truncated. Only truncate the file to previous size, and
 		// revert the folder db info. The sectors can reside in new locations
 		update.targetFolder.numSectors = update.prevNumSectors
 		update.targetFolder.usage = expandUsage(update.targetFolder.usage, update.targetNumSectors)
-		newErr = update.targetFolder.dataFile.Truncate(int64(numSectorsToSize(update.targetNumSectors)))
+		newErr = update.targetFolder.store.Truncate(int64(numSectorsToSize(update.targetNumSectors)))
 		err = common.ErrCompose(err, newErr)
 		newErr = manager.db.saveStorageFolder(update.targetFolder)
 		err = common.ErrCompose(err, newErr)
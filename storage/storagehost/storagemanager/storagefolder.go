@@ -10,8 +10,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
 
 	"github.com/DxChainNetwork/godx/common/math"
 	"github.com/DxChainNetwork/godx/rlp"
@@ -41,8 +39,9 @@ type (
 		// StoredSectors is the number of sectors stored in the folder
 		storedSectors uint64
 
-		// dataFile is the file where all the data sectors locates
-		dataFile *os.File
+		// store is the sector store where all the data sectors locates, which is
+		// the data file in the folder directory or the object store
+		store SectorStore
 	}
 
 	// storageFolderPersist defines the persist data to be stored in database
@@ -82,24 +81,23 @@ func (sf *storageFolder) DecodeRLP(st *rlp.Stream) (err error) {
 	return
 }
 
-// load load the storage folder data file.
+// load load the storage folder sector store.
 func (sf *storageFolder) load() (err error) {
-	datafilePath := filepath.Join(sf.path, dataFileName)
-	fileInfo, err := os.Stat(datafilePath)
-	if os.IsNotExist(err) {
+	store, err := openSectorStore(sf.path)
+	if err != nil {
 		sf.status = folderUnavailable
-		err = errors.New("data file not exist")
 		return
 	}
-	if fileInfo.Size() < int64(sf.numSectors)*int64(storage.SectorSize) {
-		sf.status = folderUnavailable
+	size, err := store.Size()
+	if err == nil && size < int64(sf.numSectors)*int64(storage.SectorSize) {
 		err = errors.New("file size too small")
-		return
 	}
-	if sf.dataFile, err = os.OpenFile(datafilePath, os.O_RDWR, 0600); err != nil {
+	if err != nil {
 		sf.status = folderUnavailable
+		store.Close()
 		return
 	}
+	sf.store = store
 	return
 }
 
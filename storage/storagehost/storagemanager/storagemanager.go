@@ -6,7 +6,6 @@ package storagemanager
 
 import (
 	"fmt"
-	"os"
 	"os/user"
 	"path/filepath"
 	"strings"
@@ -31,10 +30,16 @@ type (
 		DeleteSector(sectorRoot common.Hash) error
 		DeleteSectorBatch(sectorRoots []common.Hash) error
 		ReadSector(sectorRoot common.Hash) ([]byte, error)
+		HasSector(sectorRoot common.Hash) (bool, error)
+		// Functions for the read cache
+		SetReadCache(memorySize uint64, diskPath string, diskSize uint64) error
+		ReadCacheStats() storage.HostReadCacheStats
+		SetReadConcurrency(defaultLimit uint64, limits map[string]uint64)
 		// Functions from user calls
 		AddStorageFolder(path string, size uint64) error
 		DeleteFolder(folderPath string) error
 		ResizeFolder(folderPath string, size uint64) error
+		MigrateFolder(folderPath string, targetPath string) error
 		// Status check
 		Folders() []storage.HostFolder
 		AvailableSpace() storage.HostSpace
@@ -51,6 +56,12 @@ type (
 		// folders is a in-memory map of the folder
 		folders *folderManager
 
+		// cache is the read cache of the hot sectors
+		cache *readCache
+
+		// readLimiter limits the concurrent sector reads from each folder
+		readLimiter *readLimiter
+
 		// utility field
 		log        log.Logger
 		persistDir string
@@ -84,6 +95,8 @@ func newStorageManager(persistDir string, d *disruptor) (sm *storageManager, err
 	// Only initialize the WAL in start
 	sm.tm = &threadmanager.ThreadManager{}
 	sm.disruptor = d
+	sm.cache = newReadCache(storage.DefaultReadCacheSize)
+	sm.readLimiter = newReadLimiter()
 	return
 }
 
@@ -155,6 +168,9 @@ func (sm *storageManager) Close() (fullErr error) {
 	_, err = sm.wal.CloseIncomplete()
 	fullErr = common.ErrCompose(fullErr, err)
 
+	// Remove the sectors in the disk tier of the read cache
+	sm.cache.clear()
+
 	return
 }
 
@@ -226,10 +242,10 @@ func (sm *storageManager) DeleteFolder(folderPath string) (err error) {
 		return err
 	}
 	sm.folders.delete(folderPath)
-	if err = sf.dataFile.Close(); err != nil {
+	if err = sf.store.Close(); err != nil {
 		return err
 	}
-	if err = os.Remove(filepath.Join(sf.path, dataFileName)); err != nil {
+	if err = removeSectorStore(sf.path); err != nil {
 		return err
 	}
 	return nil
@@ -281,6 +297,10 @@ func (sm *storageManager) stopped() bool {
 
 // absolutePath convert the path to abs path
 func absolutePath(path string) (absPath string, err error) {
+	// The path of the object store is not a local path
+	if isObjectStorePath(path) {
+		return path, nil
+	}
 	usr, _ := user.Current()
 	dir := usr.HomeDir
 
@@ -0,0 +1,111 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// storageProofBatches collects the storage proofs created while handling the task items,
+// grouped by the address sending the storage proof transaction
+type storageProofBatches map[common.Address][]types.StorageProof
+
+// add adds the storage proof to be sent from the address
+func (b storageProofBatches) add(from common.Address, sp types.StorageProof) {
+	b[from] = append(b[from], sp)
+}
+
+// sendStorageProofs sends the storage proofs collected. The storage proofs sent from the same
+// address are packed into as few transactions as possible, so that the host with many storage
+// contracts expiring at the same height does not pay the overhead of a transaction per contract.
+// Before the storage proof batch fork, each storage proof is sent in its own transaction. The
+// transactions are sent from the fee reserve if configured
+func (h *StorageHost) sendStorageProofs(batches storageProofBatches) {
+	h.lock.RLock()
+	reserve := h.config.FeeReserve.Address
+	height := h.blockHeight
+	h.lock.RUnlock()
+
+	maxCount := storageProofBatchSize(h.ethBackend.ChainConfig(), height)
+	for from, proofs := range batches {
+		for _, batch := range splitStorageProofs(proofs, maxCount, maxStorageProofBatchPayload) {
+			hash, err := h.sendStorageProofBatch(reserve, from, batch)
+			if err != nil {
+				h.log.Warn("Error sending a storage proof transaction", "proofs", len(batch), "err", err)
+				h.sendAlert(AlertProofFailed, fmt.Sprintf("failed to send %v storage proofs from %v: %v", len(batch), from.String(), err))
+				continue
+			}
+			ids := make([]common.Hash, 0, len(batch))
+			for _, sp := range batch {
+				ids = append(ids, sp.ParentID)
+			}
+			h.proofs.sent(ids, hash)
+		}
+	}
+}
+
+// sendStorageProofBatch sends the storage proofs in a single transaction. The single storage
+// proof is sent with the storage proof transaction. The hash of the transaction is returned
+func (h *StorageHost) sendStorageProofBatch(reserve, from common.Address, proofs []types.StorageProof) (common.Hash, error) {
+	if len(proofs) == 1 {
+		spBytes, err := rlp.EncodeToBytes(proofs[0])
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return h.sendFeeReservedTx(reserve, from, spBytes, h.sendStorageProofTx)
+	}
+	batchBytes, err := rlp.EncodeToBytes(types.StorageProofBatch{Proofs: proofs})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return h.sendFeeReservedTx(reserve, from, batchBytes, h.sendStorageProofBatchTx)
+}
+
+// storageProofBatchSize returns the max number of storage proofs sent in a transaction at the
+// block height. The transactions are included in the next block at the earliest, so the
+// storage proofs are batched only if the storage proof batch fork is active at the next block
+func storageProofBatchSize(config *params.ChainConfig, height uint64) int {
+	if config.IsStorageProofBatch(new(big.Int).SetUint64(height + 1)) {
+		return maxStorageProofBatchSize
+	}
+	return 1
+}
+
+// splitStorageProofs splits the storage proofs into batches, each of which has at most maxCount
+// storage proofs and the encoded storage proofs of at most maxSize bytes. The storage proof
+// exceeding maxSize itself is put in a batch alone
+func splitStorageProofs(proofs []types.StorageProof, maxCount, maxSize int) [][]types.StorageProof {
+	var batches [][]types.StorageProof
+	var batch []types.StorageProof
+	var batchSize int
+	for _, sp := range proofs {
+		size := rlpSize(sp)
+		if len(batch) != 0 && (len(batch) >= maxCount || batchSize+size > maxSize) {
+			batches = append(batches, batch)
+			batch, batchSize = nil, 0
+		}
+		batch = append(batch, sp)
+		batchSize += size
+	}
+	if len(batch) != 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// rlpSize returns the size of the rlp encoded value, 0 if the value could not be encoded
+func rlpSize(val interface{}) int {
+	data, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/params"
+)
+
+// TestSplitStorageProofs test the storage proofs are split into batches limited by both
+// the number of the storage proofs and the encoded size
+func TestSplitStorageProofs(t *testing.T) {
+	proofs := make([]types.StorageProof, 5)
+	for i := range proofs {
+		proofs[i] = types.StorageProof{
+			ParentID: common.BigToHash(common.Big1),
+			HashSet:  make([]common.Hash, 4),
+		}
+	}
+	size := rlpSize(proofs[0])
+
+	tests := []struct {
+		maxCount int
+		maxSize  int
+		lengths  []int
+	}{
+		{10, 10 * size, []int{5}},
+		{2, 10 * size, []int{2, 2, 1}},
+		{10, 3 * size, []int{3, 2}},
+		{10, size - 1, []int{1, 1, 1, 1, 1}},
+	}
+	for i, test := range tests {
+		batches := splitStorageProofs(proofs, test.maxCount, test.maxSize)
+		if len(batches) != len(test.lengths) {
+			t.Fatalf("test %d: expect %d batches, got %d", i, len(test.lengths), len(batches))
+		}
+		for j, batch := range batches {
+			if len(batch) != test.lengths[j] {
+				t.Errorf("test %d: batch %d expect %d proofs, got %d", i, j, test.lengths[j], len(batch))
+			}
+		}
+	}
+	if batches := splitStorageProofs(nil, 10, size); len(batches) != 0 {
+		t.Errorf("expect no batch, got %d", len(batches))
+	}
+}
+
+// TestStorageProofBatchSize test the storage proofs are sent one per transaction until the
+// storage proof batch fork is active at the next block
+func TestStorageProofBatchSize(t *testing.T) {
+	config := &params.ChainConfig{StorageProofBatchBlock: big.NewInt(100)}
+	if size := storageProofBatchSize(config, 98); size != 1 {
+		t.Errorf("expect 1 storage proof per transaction before the fork, got %v", size)
+	}
+	if size := storageProofBatchSize(config, 99); size != maxStorageProofBatchSize {
+		t.Errorf("expect %v storage proofs per transaction at the fork, got %v", maxStorageProofBatchSize, size)
+	}
+	if size := storageProofBatchSize(&params.ChainConfig{}, 1000); size != 1 {
+		t.Errorf("expect 1 storage proof per transaction without the fork, got %v", size)
+	}
+}
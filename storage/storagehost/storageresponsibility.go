@@ -5,9 +5,11 @@
 package storagehost
 
 import (
-	"bytes"
+	"fmt"
 	"math/big"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/DxChainNetwork/godx/accounts"
 	"github.com/DxChainNetwork/godx/common"
@@ -130,10 +132,7 @@ func (so StorageResponsibility) value() common.BigInt {
 // storageResponsibilities fetches the set of storage Responsibility in the host and
 // returns metadata on them.
 func (h *StorageHost) storageResponsibilities() (sos []StorageResponsibility) {
-	if len(h.lockedStorageResponsibility) < 1 {
-		return nil
-	}
-	for i := range h.lockedStorageResponsibility {
+	for _, i := range h.lockedStorageResponsibility.ids() {
 		so, err := getStorageResponsibility(h.db, i)
 		if err != nil {
 			h.log.Warn("Failed to get storage responsibility", "err", err)
@@ -159,6 +158,9 @@ func (h *StorageHost) insertStorageResponsibility(so StorageResponsibility) erro
 	h.lock.Lock()
 	defer h.lock.Unlock()
 	err := func() error {
+		if h.readOnly {
+			return errHostReadOnly
+		}
 		// Submit revision time exceeds storage responsibility expiration time
 		if h.blockHeight+postponedExecutionBuffer >= so.expiration() {
 			h.log.Warn("responsibilityFailed to submit revision in storage responsibility due date")
@@ -236,6 +238,10 @@ func (h *StorageHost) modifyStorageResponsibility(so StorageResponsibility, sect
 	h.lock.Lock()
 	defer h.lock.Unlock()
 
+	if h.readOnly {
+		return errHostReadOnly
+	}
+
 	//Need enough time to submit revision
 	if so.expiration()-postponedExecutionBuffer <= h.blockHeight {
 		return errNotAllowed
@@ -430,6 +436,9 @@ func (h *StorageHost) removeStorageResponsibility(so StorageResponsibility, sos
 	if err := h.DeleteSectorBatch(so.SectorRoots); err != nil {
 		h.log.Error("delete sector batch", "err", err)
 	}
+	if err := deletePublicSectors(h.db, so.SectorRoots); err != nil {
+		h.log.Error("delete public sectors", "err", err)
+	}
 
 	switch sos {
 	case responsibilityUnresolved:
@@ -529,8 +538,9 @@ func (h *StorageHost) resetFinancialMetrics() error {
 	return nil
 }
 
-//Handling storage responsibilities in the task queue
-func (h *StorageHost) handleTaskItem(soid common.Hash) {
+//Handling storage responsibilities in the task queue. The storage proof created is added to
+//proofs, which are sent in batch after all task items of the block are handled
+func (h *StorageHost) handleTaskItem(soid common.Hash, proofs storageProofBatches) {
 	// Lock the storage responsibility
 	h.checkAndLockStorageResponsibility(soid)
 	defer h.checkAndUnlockStorageResponsibility(soid)
@@ -593,7 +603,7 @@ func (h *StorageHost) handleTaskItem(soid common.Hash) {
 		}
 
 		//The host sends a revision transaction to the transaction pool.
-		if _, err := h.sendStorageContractRevisionTx(scrv.NewValidProofOutputs[1].Address, scBytes); err != nil {
+		if _, err := h.sendFeeReservedTx(h.config.FeeReserve.Address, scrv.NewValidProofOutputs[1].Address, scBytes, h.sendStorageContractRevisionTx); err != nil {
 			h.log.Warn("Error sending a revision transaction", "err", err)
 			return
 		}
@@ -612,6 +622,7 @@ func (h *StorageHost) handleTaskItem(soid common.Hash) {
 
 		if so.proofDeadline() < h.blockHeight {
 			h.log.Info("If the storage contract has expired and the proof transaction has not been confirmed, delete the storage responsibility", "id", so.id().String())
+			h.dispatchAlert(h.config.Alert, h.blockHeight, AlertProofFailed, fmt.Sprintf("storage proof of contract %v not confirmed before the deadline %v", so.id().String(), so.proofDeadline()))
 			err := h.removeStorageResponsibility(so, responsibilityFailed)
 			if err != nil {
 				h.log.Warn("Error removing storage Responsibility", "err", err)
@@ -674,17 +685,9 @@ func (h *StorageHost) handleTaskItem(soid common.Hash) {
 		}
 		sp.Signature = spSign
 
-		spBytes, err := rlp.EncodeToBytes(sp)
-		if err != nil {
-			h.log.Warn("Error when serializing proof", "err", err)
-			return
-		}
-
-		//The host sends a storage proof transaction to the transaction pool.
-		if _, err := h.sendStorageProofTx(fromAddress, spBytes); err != nil {
-			h.log.Warn("Error sending a storage proof transaction", "err", err)
-			return
-		}
+		//The storage proof is sent together with the other storage proofs of the block
+		proofs.add(fromAddress, sp)
+		h.proofs.created(so.id(), so.proofDeadline())
 
 		//Insert the check proof task in the task queue.
 		err = h.queueTaskItem(so.proofDeadline(), so.id())
@@ -711,28 +714,11 @@ func (h *StorageHost) handleTaskItem(soid common.Hash) {
 
 //merkleProof get the storage proof
 func merkleProof(b []byte, proofIndex uint64) (base []byte, hashSet []common.Hash) {
-	t := merkle.NewSha256MerkleTree()
-	//This error doesn't mean anything to us.
-	t.SetStorageProofIndex(proofIndex)
-
-	buf := bytes.NewBuffer(b)
-	for buf.Len() > 0 {
-		t.PushLeaf(buf.Next(merkle.LeafSize))
-	}
-
-	// Get the storage proof
-	_, proof, _, _ := t.ProofList()
-	if len(proof) == 0 {
+	base, hashSet, _, err := merkle.Sha256MerkleTreeProof(b, proofIndex)
+	if err != nil {
 		//If there is no data, it will return a blank value
 		return nil, nil
 	}
-
-	base = proof[0]
-	hashSet = make([]common.Hash, len(proof)-1)
-	for i, p := range proof[1:] {
-		copy(hashSet[i][:], p)
-	}
-
 	return base, hashSet
 }
 
@@ -772,3 +758,62 @@ func (h *StorageHost) sendStorageContractRevisionTx(from common.Address, input [
 func (h *StorageHost) sendStorageProofTx(from common.Address, input []byte) (common.Hash, error) {
 	return h.parseAPI.StorageTx.SendStorageProofTX(from, input)
 }
+
+// sendStorageProofBatchTx send the tx carrying the storage proofs of multiple storage contracts
+func (h *StorageHost) sendStorageProofBatchTx(from common.Address, input []byte) (common.Hash, error) {
+	return h.parseAPI.StorageTx.SendStorageProofBatchTX(from, input)
+}
+
+// responsibilityStatusNames is the mapping from the status name used in the api to the status
+var responsibilityStatusNames = map[string]storageResponsibilityStatus{
+	"unresolved": responsibilityUnresolved,
+	"rejected":   responsibilityRejected,
+	"succeeded":  responsibilitySucceeded,
+	"failed":     responsibilityFailed,
+}
+
+// responsibilityStatusName returns the status name used in the api
+func responsibilityStatusName(status storageResponsibilityStatus) string {
+	for name, s := range responsibilityStatusNames {
+		if s == status {
+			return name
+		}
+	}
+	return status.String()
+}
+
+// filterStorageResponsibilities filters the storage responsibilities by the status name, sorts them
+// by the negotiation height, and returns the page specified by offset and limit. Empty status
+// name matches all storage responsibilities, and zero limit means no limit
+func filterStorageResponsibilities(sos []StorageResponsibility, statusName string, offset, limit uint64) ([]StorageResponsibility, error) {
+	var filtered []StorageResponsibility
+	if statusName == "" {
+		filtered = append(filtered, sos...)
+	} else {
+		status, exists := responsibilityStatusNames[strings.ToLower(statusName)]
+		if !exists {
+			return nil, fmt.Errorf("unknown storage responsibility status: %v", statusName)
+		}
+		for _, so := range sos {
+			if so.ResponsibilityStatus == status {
+				filtered = append(filtered, so)
+			}
+		}
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if filtered[i].NegotiationBlockNumber != filtered[j].NegotiationBlockNumber {
+			return filtered[i].NegotiationBlockNumber < filtered[j].NegotiationBlockNumber
+		}
+		return filtered[i].id().Big().Cmp(filtered[j].id().Big()) < 0
+	})
+
+	if offset >= uint64(len(filtered)) {
+		return nil, nil
+	}
+	filtered = filtered[offset:]
+	if limit != 0 && limit < uint64(len(filtered)) {
+		filtered = filtered[:limit]
+	}
+	return filtered, nil
+}
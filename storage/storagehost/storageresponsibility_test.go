@@ -159,3 +159,43 @@ func TestStoreHeight(t *testing.T) {
 		}
 	}
 }
+
+func TestFilterStorageResponsibilities(t *testing.T) {
+	var sos []StorageResponsibility
+	statuses := []storageResponsibilityStatus{responsibilityUnresolved, responsibilitySucceeded, responsibilityUnresolved, responsibilityFailed, responsibilityUnresolved}
+	for i, status := range statuses {
+		// the responsibilities are added in the reverse order of the negotiation height
+		sos = append(sos, StorageResponsibility{
+			NegotiationBlockNumber: uint64(len(statuses) - i),
+			ResponsibilityStatus:   status,
+		})
+	}
+
+	tests := []struct {
+		status        string
+		offset, limit uint64
+		expectHeights []uint64
+		expectErr     bool
+	}{
+		{"", 0, 0, []uint64{1, 2, 3, 4, 5}, false},
+		{"unresolved", 0, 0, []uint64{1, 3, 5}, false},
+		{"Unresolved", 1, 1, []uint64{3}, false},
+		{"succeeded", 0, 10, []uint64{4}, false},
+		{"rejected", 0, 0, nil, false},
+		{"", 5, 0, nil, false},
+		{"unknown", 0, 0, nil, true},
+	}
+	for i, test := range tests {
+		filtered, err := filterStorageResponsibilities(sos, test.status, test.offset, test.limit)
+		if (err != nil) != test.expectErr {
+			t.Fatalf("test %d: error not expected: %v", i, err)
+		}
+		var heights []uint64
+		for _, so := range filtered {
+			heights = append(heights, so.NegotiationBlockNumber)
+		}
+		if !reflect.DeepEqual(heights, test.expectHeights) {
+			t.Errorf("test %d: heights not expected. Got %v, Expect %v", i, heights, test.expectHeights)
+		}
+	}
+}
@@ -6,60 +6,152 @@ package storagehost
 
 import (
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/log"
+	"github.com/DxChainNetwork/godx/metrics"
 )
 
 var (
 	errObligationLocked = errors.New("storage responsibility has been locked")
 )
 
-//If not locked, create a new one
-func (h *StorageHost) checkAndLockStorageResponsibility(soid common.Hash) {
-	h.lock.Lock()
-	tl, exists := h.lockedStorageResponsibility[soid]
-	if !exists {
-		tl = new(TryMutex)
-		h.lockedStorageResponsibility[soid] = tl
-	}
-	h.lock.Unlock()
+var (
+	responsibilityLockWaitTimer      = metrics.NewRegisteredTimer("storagehost/responsibility/lock/wait", nil)
+	responsibilityLockTimeoutCounter = metrics.NewRegisteredCounter("storagehost/responsibility/lock/timeout", nil)
+	responsibilityDeadlockCounter    = metrics.NewRegisteredCounter("storagehost/responsibility/lock/deadlock", nil)
+)
 
-	tl.Lock()
+// responsibilityLockShard holds the locks of the storage responsibilities that fall into the shard
+type responsibilityLockShard struct {
+	lock  sync.Mutex
+	locks map[common.Hash]*TryRWMutex
 }
 
-//Try to lock this storage obligation
-func (h *StorageHost) checkAndTryLockStorageResponsibility(soid common.Hash, timeout time.Duration) error {
-	h.lock.Lock()
-	defer h.lock.Unlock()
-	tl, exists := h.lockedStorageResponsibility[soid]
-	if !exists {
-		tl = new(TryMutex)
-		h.lockedStorageResponsibility[soid] = tl
+// responsibilityLockManager manages the per storage responsibility locks. The locks are sharded
+// by the storage responsibility id, so that locking a storage responsibility does not contend
+// with the others. The ids of the locks also serve as the index of the storage responsibilities.
+// The zero value is ready to use
+type responsibilityLockManager struct {
+	shards [responsibilityLockShards]responsibilityLockShard
+}
+
+// shard returns the shard the storage responsibility belongs to
+func (lm *responsibilityLockManager) shard(soid common.Hash) *responsibilityLockShard {
+	return &lm.shards[int(soid[0])%responsibilityLockShards]
+}
+
+// get returns the lock of the storage responsibility. If not exist and create is true,
+// a new lock is created
+func (lm *responsibilityLockManager) get(soid common.Hash, create bool) *TryRWMutex {
+	shard := lm.shard(soid)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	tl, exists := shard.locks[soid]
+	if !exists && create {
+		if shard.locks == nil {
+			shard.locks = make(map[common.Hash]*TryRWMutex)
+		}
+		tl = new(TryRWMutex)
+		shard.locks[soid] = tl
+	}
+	return tl
+}
+
+// lock grabs the lock of the storage responsibility, blocking until the lock is obtained. If
+// the lock is not obtained after responsibilityDeadlockTimeout, a possible deadlock is reported
+func (lm *responsibilityLockManager) lock(soid common.Hash, write bool) {
+	tl := lm.get(soid, true)
+	start := time.Now()
+	defer responsibilityLockWaitTimer.UpdateSince(start)
+
+	for !tl.acquireTimed(write, responsibilityDeadlockTimeout) {
+		responsibilityDeadlockCounter.Inc(1)
+		log.Warn("Storage responsibility lock is held too long, possible deadlock", "id", soid, "waited", common.PrettyDuration(time.Since(start)))
 	}
+}
+
+// tryLock grabs the lock of the storage responsibility, returning errObligationLocked if the
+// lock is not obtained after the provided duration
+func (lm *responsibilityLockManager) tryLock(soid common.Hash, write bool, timeout time.Duration) error {
+	tl := lm.get(soid, true)
+	start := time.Now()
+	defer responsibilityLockWaitTimer.UpdateSince(start)
 
-	if tl.TryLockTimed(timeout) {
+	if tl.acquireTimed(write, timeout) {
 		return nil
 	}
+	responsibilityLockTimeoutCounter.Inc(1)
 	return errObligationLocked
 }
 
+// unlock releases the lock of the storage responsibility if exists
+func (lm *responsibilityLockManager) unlock(soid common.Hash, write bool) {
+	if tl := lm.get(soid, false); tl != nil {
+		tl.release(write)
+	}
+}
+
+// has checks whether the lock of the storage responsibility exists
+func (lm *responsibilityLockManager) has(soid common.Hash) bool {
+	return lm.get(soid, false) != nil
+}
+
+// remove removes the lock of the storage responsibility
+func (lm *responsibilityLockManager) remove(soid common.Hash) {
+	shard := lm.shard(soid)
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	delete(shard.locks, soid)
+}
+
+// ids returns the ids of all storage responsibilities with the lock
+func (lm *responsibilityLockManager) ids() (soids []common.Hash) {
+	for i := range lm.shards {
+		shard := &lm.shards[i]
+		shard.lock.Lock()
+		for soid := range shard.locks {
+			soids = append(soids, soid)
+		}
+		shard.lock.Unlock()
+	}
+	return
+}
+
+//If not locked, create a new one
+func (h *StorageHost) checkAndLockStorageResponsibility(soid common.Hash) {
+	h.lockedStorageResponsibility.lock(soid, true)
+}
+
+//Try to lock this storage obligation
+func (h *StorageHost) checkAndTryLockStorageResponsibility(soid common.Hash, timeout time.Duration) error {
+	return h.lockedStorageResponsibility.tryLock(soid, true, timeout)
+}
+
 //If it exists, unlock it
 func (h *StorageHost) checkAndUnlockStorageResponsibility(soid common.Hash) {
-	h.lock.Lock()
-	defer h.lock.Unlock()
+	h.lockedStorageResponsibility.unlock(soid, true)
+}
 
-	tl, exists := h.lockedStorageResponsibility[soid]
-	if !exists {
-		return
+//Read lock the storage responsibility for queries, the queries could be done concurrently.
+//Returns false if the storage responsibility does not exist, and there is nothing to unlock
+func (h *StorageHost) checkAndRLockStorageResponsibility(soid common.Hash) bool {
+	if !h.lockedStorageResponsibility.has(soid) {
+		return false
 	}
-	tl.Unlock()
+	h.lockedStorageResponsibility.lock(soid, false)
+	return true
+}
 
+//Release the read lock
+func (h *StorageHost) checkAndRUnlockStorageResponsibility(soid common.Hash) {
+	h.lockedStorageResponsibility.unlock(soid, false)
 }
 
 func (h *StorageHost) deleteLockedStorageResponsibility(soID common.Hash) {
-	h.lock.Lock()
-	defer h.lock.Unlock()
-
-	delete(h.lockedStorageResponsibility, soID)
+	h.lockedStorageResponsibility.remove(soID)
 }
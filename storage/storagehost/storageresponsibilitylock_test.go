@@ -0,0 +1,75 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/DxChainNetwork/godx/common"
+)
+
+func TestTryRWMutex(t *testing.T) {
+	var tm TryRWMutex
+
+	// multiple readers could hold the lock at the same time
+	tm.RLock()
+	if !tm.TryRLockTimed(0) {
+		t.Fatalf("read lock should be shared")
+	}
+	if tm.TryLockTimed(10 * time.Millisecond) {
+		t.Fatalf("write lock should not be grabbed while read locked")
+	}
+
+	// the writer waiting should be woken up once all readers released
+	locked := make(chan struct{})
+	go func() {
+		tm.Lock()
+		close(locked)
+	}()
+	tm.RUnlock()
+	tm.RUnlock()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatalf("write lock should be grabbed after the read locks released")
+	}
+	if tm.TryRLockTimed(10 * time.Millisecond) {
+		t.Fatalf("read lock should not be grabbed while write locked")
+	}
+	tm.Unlock()
+	if !tm.TryLockTimed(0) {
+		t.Fatalf("write lock should be grabbed after unlock")
+	}
+}
+
+func TestResponsibilityLockManager(t *testing.T) {
+	var lm responsibilityLockManager
+	id1, id2 := common.HexToHash("0x01"), common.HexToHash("0x02")
+
+	lm.lock(id1, true)
+	if err := lm.tryLock(id1, true, 10*time.Millisecond); err != errObligationLocked {
+		t.Errorf("error not expected. Got %v, Expect %v", err, errObligationLocked)
+	}
+	// the other storage responsibility should not be affected
+	if err := lm.tryLock(id2, false, 0); err != nil {
+		t.Errorf("failed to lock the other storage responsibility: %v", err)
+	}
+	lm.unlock(id1, true)
+	lm.unlock(id2, false)
+
+	ids := lm.ids()
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Big().Cmp(ids[j].Big()) < 0 })
+	if !reflect.DeepEqual(ids, []common.Hash{id1, id2}) {
+		t.Errorf("ids not expected. Got %v, Expect %v", ids, []common.Hash{id1, id2})
+	}
+
+	lm.remove(id1)
+	if lm.has(id1) || !lm.has(id2) {
+		t.Errorf("only the removed lock should not exist")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"github.com/DxChainNetwork/godx/accounts"
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/ethdb"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+// putTranscript store the negotiation transcript of the storage responsibility in the db
+func putTranscript(db ethdb.Database, storageContractID common.Hash, transcript []storage.TranscriptEntry) error {
+	scdb := ethdb.StorageContractDB{db}
+	data, err := rlp.EncodeToBytes(transcript)
+	if err != nil {
+		return err
+	}
+	return scdb.StoreWithPrefix(storageContractID, data, prefixTranscript)
+}
+
+// getTranscript get the negotiation transcript of the storage responsibility from the db. If
+// no negotiation has been recorded, empty transcript is returned
+func getTranscript(db ethdb.Database, storageContractID common.Hash) ([]storage.TranscriptEntry, error) {
+	key, err := ethdb.MakeKey(prefixTranscript, storageContractID)
+	if err != nil {
+		return nil, err
+	}
+	if exist, err := db.Has(key); err != nil || !exist {
+		return nil, err
+	}
+	valueBytes, err := db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var transcript []storage.TranscriptEntry
+	if err = rlp.DecodeBytes(valueBytes, &transcript); err != nil {
+		return nil, err
+	}
+	return transcript, nil
+}
+
+// appendTranscript appends the negotiation signed by the host account to the transcript of
+// the storage responsibility. It is called after the revision is acknowledged, so failure
+// is only logged
+func (h *StorageHost) appendTranscript(storageContractID common.Hash, req interface{}, hostSig []byte, revisionNumber uint64, wallet accounts.Wallet, account accounts.Account) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	transcript, err := getTranscript(h.db, storageContractID)
+	if err != nil {
+		h.log.Warn("failed to get the negotiation transcript", "id", storageContractID, "err", err)
+		return
+	}
+	entry := storage.NewTranscriptEntry(transcript, req, hostSig, revisionNumber)
+	if entry.Signature, err = wallet.SignHash(account, entry.Hash().Bytes()); err != nil {
+		h.log.Warn("failed to sign the negotiation transcript", "id", storageContractID, "err", err)
+		return
+	}
+	if err = putTranscript(h.db, storageContractID, append(transcript, entry)); err != nil {
+		h.log.Warn("failed to record the negotiation transcript", "id", storageContractID, "err", err)
+	}
+}
+
+// transcript returns the negotiation transcript of the storage responsibility
+func (h *StorageHost) transcript(storageContractID common.Hash) ([]storage.TranscriptEntry, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return getTranscript(h.db, storageContractID)
+}
@@ -78,3 +78,108 @@ func (tm *TryMutex) Unlock() {
 		panic("unlock called when TryMutex is not locked")
 	}
 }
+
+// TryRWMutex provides a reader/writer mutex that allows you to attempt to grab
+// the lock, and then fail if the lock is not grabbed by the specified duration.
+type TryRWMutex struct {
+	mu      sync.Mutex
+	readers int
+	writer  bool
+
+	// changed is closed and replaced whenever the lock is released, which wakes
+	// up the goroutines waiting for the lock
+	changed chan struct{}
+}
+
+// tryAcquire tries to grab the lock without blocking. If failed, the channel
+// closed on the next release is returned
+func (tm *TryRWMutex) tryAcquire(write bool) (bool, chan struct{}) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	switch {
+	case write && !tm.writer && tm.readers == 0:
+		tm.writer = true
+		return true, nil
+	case !write && !tm.writer:
+		tm.readers++
+		return true, nil
+	}
+	if tm.changed == nil {
+		tm.changed = make(chan struct{})
+	}
+	return false, tm.changed
+}
+
+// acquireTimed grabs the lock, returning false if the lock is not grabbed after
+// the provided duration. Negative duration means no timeout
+func (tm *TryRWMutex) acquireTimed(write bool, t time.Duration) bool {
+	var timeout <-chan time.Time
+	if t >= 0 {
+		timer := time.NewTimer(t)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for {
+		acquired, changed := tm.tryAcquire(write)
+		if acquired {
+			return true
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+// release releases the lock and wakes up the waiting goroutines
+func (tm *TryRWMutex) release(write bool) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	switch {
+	case write && tm.writer:
+		tm.writer = false
+	case !write && tm.readers > 0:
+		tm.readers--
+	default:
+		panic("unlock called when TryRWMutex is not locked")
+	}
+	if tm.changed != nil {
+		close(tm.changed)
+		tm.changed = nil
+	}
+}
+
+// Lock grabs the write lock, blocking until the lock is obtained.
+func (tm *TryRWMutex) Lock() {
+	tm.acquireTimed(true, -1)
+}
+
+// TryLockTimed grabs the write lock, returning false if the lock is not grabbed
+// after the provided duration.
+func (tm *TryRWMutex) TryLockTimed(t time.Duration) bool {
+	return tm.acquireTimed(true, t)
+}
+
+// Unlock releases the write lock.
+func (tm *TryRWMutex) Unlock() {
+	tm.release(true)
+}
+
+// RLock grabs a read lock, blocking until the lock is obtained.
+func (tm *TryRWMutex) RLock() {
+	tm.acquireTimed(false, -1)
+}
+
+// TryRLockTimed grabs a read lock, returning false if the lock is not grabbed
+// after the provided duration.
+func (tm *TryRWMutex) TryRLockTimed(t time.Duration) bool {
+	return tm.acquireTimed(false, t)
+}
+
+// RUnlock releases a read lock.
+func (tm *TryRWMutex) RUnlock() {
+	tm.release(false)
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 
 	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/storage/storageerr"
 )
 
 var (
@@ -117,6 +118,7 @@ var (
 	errInsaneRevision             = errors.New("revision is not necessary")
 	errNotAllowed                 = errors.New("time is not allowed")
 	errTransactionNotConfirmed    = errors.New("transaction not confirmed")
+	errHostReadOnly               = errors.New("host is read only during the export")
 )
 
 // ExtendErr wraps a error with a string
@@ -150,6 +152,8 @@ type (
 		PotentialDownloadBandwidthRevenue common.BigInt `json:"potentialdownloadbandwidthrevenue"`
 		PotentialUploadBandwidthRevenue   common.BigInt `json:"potentialuploadbandwidthrevenue"`
 		UploadBandwidthRevenue            common.BigInt `json:"uploadbandwidthrevenue"`
+		UploadBandwidthBytes              uint64        `json:"uploadbandwidthbytes"`
+		DownloadBandwidthBytes            uint64        `json:"downloadbandwidthbytes"`
 	}
 
 	// ErrorRevision is some error that occurs in revision
@@ -158,6 +162,9 @@ type (
 	// ErrorCreateContract is some error that occurs in contract creation
 	ErrorCreateContract string
 
+	// ErrorDownloadLimit is the error that the download request exceeds the download limits
+	ErrorDownloadLimit string
+
 	// HostFinancialMetricsForDisplay is the financial metric for display
 	HostFinancialMetricsForDisplay struct {
 		ContractCount                     uint64 `json:"contractcount"`
@@ -174,6 +181,21 @@ type (
 		PotentialDownloadBandwidthRevenue string `json:"potentialdownloadbandwidthrevenue"`
 		PotentialUploadBandwidthRevenue   string `json:"potentialuploadbandwidthrevenue"`
 		UploadBandwidthRevenue            string `json:"uploadbandwidthrevenue"`
+		UploadBandwidthBytes              string `json:"uploadbandwidthbytes"`
+		DownloadBandwidthBytes            string `json:"downloadbandwidthbytes"`
+	}
+
+	// StorageResponsibilityForDisplay is the storage responsibility for display
+	StorageResponsibilityForDisplay struct {
+		ContractID           common.Hash `json:"contractid"`
+		Status               string      `json:"status"`
+		FileSize             string      `json:"filesize"`
+		NegotiationHeight    uint64      `json:"negotiationheight"`
+		ExpirationHeight     uint64      `json:"expirationheight"`
+		ProofDeadline        uint64      `json:"proofdeadline"`
+		ContractCost         string      `json:"contractcost"`
+		LockedStorageDeposit string      `json:"lockedstoragedeposit"`
+		RiskedStorageDeposit string      `json:"riskedstoragedeposit"`
 	}
 )
 
@@ -184,3 +206,22 @@ func (e ErrorRevision) Error() string {
 func (e ErrorCreateContract) Error() string {
 	return "create contract error:" + string(e)
 }
+
+func (e ErrorDownloadLimit) Error() string {
+	return "download limit error: " + string(e)
+}
+
+// ErrorCode returns the storage error code of the revision error
+func (e ErrorRevision) ErrorCode() int {
+	return int(storageerr.CodeInvalidRevision)
+}
+
+// ErrorCode returns the storage error code of the create contract error
+func (e ErrorCreateContract) ErrorCode() int {
+	return int(storageerr.CodeInvalidContract)
+}
+
+// ErrorCode returns the storage error code of the download limit error
+func (e ErrorDownloadLimit) ErrorCode() int {
+	return int(storageerr.CodeLimitExceeded)
+}
@@ -7,6 +7,7 @@ package storagehost
 import (
 	"errors"
 	"fmt"
+	"io"
 	"sort"
 
 	"github.com/DxChainNetwork/godx/accounts"
@@ -15,9 +16,17 @@ import (
 	"github.com/DxChainNetwork/godx/crypto/merkle"
 	"github.com/DxChainNetwork/godx/log"
 	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/rlp"
 	"github.com/DxChainNetwork/godx/storage"
 )
 
+// uploadReadChunkSize is the size of data read from the stream each time while
+// calculating the merkle root of the appended sector
+const uploadReadChunkSize = 64 * merkle.LeafSize
+
+// errOversizedSector is returned if the data of the upload action exceeds the sector size
+var errOversizedSector = errors.New("upload data exceeds the sector size")
+
 // UploadHandler handles the upload negotiation
 func UploadHandler(h *StorageHost, sp storage.Peer, uploadReqMsg p2p.Msg) {
 	var hostNegotiateErr, clientNegotiateErr, clientCommitErr error
@@ -26,17 +35,37 @@ func UploadHandler(h *StorageHost, sp storage.Peer, uploadReqMsg p2p.Msg) {
 		if clientNegotiateErr != nil || clientCommitErr != nil {
 			_ = sp.SendHostAckMsg()
 			h.ethBackend.CheckAndUpdateConnection(sp.PeerNode())
+		} else if hostNegotiateErr == storage.ErrHostConfigChanged {
+			_ = sp.SendHostConfigChangedMsg()
+		} else if hostNegotiateErr == storage.ErrHostFull {
+			_ = sp.SendHostFullMsg()
 		} else if hostNegotiateErr != nil {
 			_ = sp.SendHostNegotiateErrorMsg()
 		}
 	}()
 
-	// Read upload request
-	var uploadRequest storage.UploadRequest
-	if err := uploadReqMsg.Decode(&uploadRequest); err != nil {
+	// Read upload request, the merkle roots of the appended sectors are calculated
+	// while the sector data is being read
+	uploadRequest, actionRoots, err := decodeUploadRequest(uploadReqMsg)
+	if err != nil {
+		sp.ReportMisbehavior(storage.MisbehaviorMalformedMsg, err)
 		clientNegotiateErr = fmt.Errorf("failed to decode the upload request message: %s", err.Error())
 		return
 	}
+	if err := uploadRequest.Validate(); err != nil {
+		clientNegotiateErr = fmt.Errorf("invalid upload request: %s", err.Error())
+		return
+	}
+
+	// the client negotiates with the outdated host config
+	if err := h.checkHostConfigHash(uploadRequest.HostConfigHash); err != nil {
+		hostNegotiateErr = err
+		return
+	}
+	if h.isReadOnly() {
+		hostNegotiateErr = errHostReadOnly
+		return
+	}
 
 	// Get revision from storage responsibility
 	h.lock.RLock()
@@ -61,11 +90,11 @@ func UploadHandler(h *StorageHost, sp storage.Peer, uploadReqMsg p2p.Msg) {
 	var bandwidthRevenue common.BigInt
 	var sectorsGained []common.Hash
 	var gainedSectorData [][]byte
-	for _, action := range uploadRequest.Actions {
+	for i, action := range uploadRequest.Actions {
 		switch action.Type {
 		case storage.UploadActionAppend:
 			// Update sector roots.
-			newRoot := merkle.Sha256MerkleTreeRoot(action.Data)
+			newRoot := actionRoots[i]
 			newRoots = append(newRoots, newRoot)
 			sectorsGained = append(sectorsGained, newRoot)
 			gainedSectorData = append(gainedSectorData, action.Data)
@@ -79,6 +108,12 @@ func UploadHandler(h *StorageHost, sp storage.Peer, uploadReqMsg p2p.Msg) {
 		}
 	}
 
+	// reject the sectors appended before running out of the free space
+	if err := h.checkAppendSpace(len(sectorsGained)); err != nil {
+		hostNegotiateErr = err
+		return
+	}
+
 	//var storageRevenue, newDeposit *big.Int
 	var storageRevenue, newDeposit common.BigInt
 
@@ -101,6 +136,10 @@ func UploadHandler(h *StorageHost, sp storage.Peer, uploadReqMsg p2p.Msg) {
 			newRevision.NewFileSize += storage.SectorSize
 		}
 	}
+	if maxSize := h.getInternalConfig().ContractPolicy.MaxContractSize; maxSize != 0 && newRevision.NewFileSize > maxSize {
+		hostNegotiateErr = errLargeContract
+		return
+	}
 	newRevision.NewFileMerkleRoot = newMerkleRoot
 	newRevision.NewValidProofOutputs = make([]types.DxcoinCharge, len(currentRevision.NewValidProofOutputs))
 	for i := range newRevision.NewValidProofOutputs {
@@ -210,6 +249,14 @@ func UploadHandler(h *StorageHost, sp storage.Peer, uploadReqMsg p2p.Msg) {
 	so.PotentialUploadRevenue = so.PotentialUploadRevenue.Add(bandwidthRevenue)
 	so.StorageContractRevisions = append(so.StorageContractRevisions, newRevision)
 
+	// journal the negotiation before the host signature is sent out. The journal is deleted
+	// when the negotiation finished, and used to recover the negotiation if the host crashed
+	if err := h.journalRevision(snapshotSo, newRevision, sectorsGained); err != nil {
+		hostNegotiateErr = fmt.Errorf("failed to journal the revision: %s", err.Error())
+		return
+	}
+	defer h.finishRevisionJournal(snapshotSo.id())
+
 	// send the host revision sign
 	if err := sp.SendUploadHostRevisionSign(hostSig); err != nil {
 		log.Error("failed to send the upload host revision sign", "err", err)
@@ -239,6 +286,16 @@ func UploadHandler(h *StorageHost, sp storage.Peer, uploadReqMsg p2p.Msg) {
 			_ = sp.SendHostAckMsg()
 			return
 		}
+
+		// the sectors uploaded as public could be read by anyone without a contract
+		if uploadRequest.Public && len(sectorsGained) != 0 {
+			h.lock.Lock()
+			err = putPublicSectors(h.db, sectorsGained)
+			h.lock.Unlock()
+			if err != nil {
+				log.Error("failed to mark the sectors as public", "err", err)
+			}
+		}
 	} else if msg.Code == storage.ClientCommitFailedMsg {
 		clientCommitErr = storage.ErrClientCommit
 		return
@@ -257,12 +314,28 @@ func UploadHandler(h *StorageHost, sp storage.Peer, uploadReqMsg p2p.Msg) {
 		h.ethBackend.SetStatic(node)
 	}
 
+	// the client keeps the new revision once the host ack is received, thus the revision
+	// shall not be rolled back on recovery afterwards
+	if err := h.acknowledgeRevisionJournal(snapshotSo.id()); err != nil {
+		log.Error("storage host failed to acknowledge the revision journal", "err", err)
+		_ = h.rollbackStorageResponsibility(snapshotSo, sectorsGained, nil, nil)
+		return
+	}
+
 	// send host 'ACK' msg to client
 	if err := sp.SendHostAckMsg(); err != nil {
 		log.Error("storage host failed to send host ack msg", "err", err)
 		_ = h.rollbackStorageResponsibility(snapshotSo, sectorsGained, nil, nil)
 		h.ethBackend.CheckAndUpdateConnection(sp.PeerNode())
+		return
+	}
+	h.appendTranscript(so.id(), uploadRequest, hostSig, newRevision.NewRevisionNumber, wallet, account)
+
+	var uploadBytes uint64
+	for _, data := range gainedSectorData {
+		uploadBytes += uint64(len(data))
 	}
+	h.recordBandwidth(so.id(), sp, uploadBytes, 0, bandwidthRevenue)
 }
 
 // VerifyRevision checks that the revision pays the host correctly, and that
@@ -365,3 +438,114 @@ func VerifyRevision(so *StorageResponsibility, revision *types.StorageContractRe
 
 	return nil
 }
+
+// decodeUploadRequest decodes the upload request from the message. Instead of decoding the
+// whole message at once, the fields are read from the stream in order, so that the merkle
+// root of each appended sector is calculated while the bytes arrive. The returned roots are
+// indexed by the actions, and only filled for the append actions
+func decodeUploadRequest(msg p2p.Msg) (req storage.UploadRequest, roots []common.Hash, err error) {
+	s := rlp.NewStream(msg.Payload, uint64(msg.Size))
+	if _, err = s.List(); err != nil {
+		return
+	}
+	if err = s.Decode(&req.StorageContractID); err != nil {
+		return
+	}
+
+	// decode the actions
+	if _, err = s.List(); err != nil {
+		return
+	}
+	for {
+		var action storage.UploadAction
+		var root common.Hash
+		if _, err = s.List(); err == rlp.EOL {
+			break
+		} else if err != nil {
+			return
+		}
+		// reject the request with too many actions before reading the data of the actions
+		if len(req.Actions) >= storage.MaxUploadActions {
+			err = storage.ErrTooManyUploadActions
+			return
+		}
+		if err = s.Decode(&action.Type); err != nil {
+			return
+		}
+		if err = s.Decode(&action.A); err != nil {
+			return
+		}
+		if err = s.Decode(&action.B); err != nil {
+			return
+		}
+		if action.Type == storage.UploadActionAppend {
+			action.Data, root, err = handleUploadAppendType(s)
+		} else {
+			action.Data, err = s.Bytes()
+		}
+		if err != nil {
+			return
+		}
+		if err = s.ListEnd(); err != nil {
+			return
+		}
+		req.Actions = append(req.Actions, action)
+		roots = append(roots, root)
+	}
+	if err = s.ListEnd(); err != nil {
+		return
+	}
+
+	// decode the revision related fields
+	if err = s.Decode(&req.NewRevisionNumber); err != nil {
+		return
+	}
+	if err = s.Decode(&req.NewValidProofValues); err != nil {
+		return
+	}
+	if err = s.Decode(&req.NewMissedProofValues); err != nil {
+		return
+	}
+	if err = s.Decode(&req.HostConfigHash); err != nil {
+		return
+	}
+	if err = s.Decode(&req.Public); err != nil {
+		return
+	}
+	err = s.ListEnd()
+	return
+}
+
+// handleUploadAppendType reads the sector data of the append action from the stream, and
+// calculates the merkle root incrementally while reading. The data larger than the sector
+// size is rejected before being read
+func handleUploadAppendType(s *rlp.Stream) (data []byte, root common.Hash, err error) {
+	r, size, err := s.BytesReader()
+	if err != nil {
+		return
+	}
+	if size > storage.SectorSize {
+		err = errOversizedSector
+		return
+	}
+
+	mt := merkle.NewSha256MerkleTree()
+	data = make([]byte, size)
+	for offset := uint64(0); offset < size; offset += uploadReadChunkSize {
+		end := offset + uploadReadChunkSize
+		if end > size {
+			end = size
+		}
+		if _, err = io.ReadFull(r, data[offset:end]); err != nil {
+			return
+		}
+		for leaf := offset; leaf < end; leaf += merkle.LeafSize {
+			leafEnd := leaf + merkle.LeafSize
+			if leafEnd > end {
+				leafEnd = end
+			}
+			mt.PushLeaf(data[leaf:leafEnd])
+		}
+	}
+	return data, mt.Root(), nil
+}
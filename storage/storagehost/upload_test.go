@@ -0,0 +1,63 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storagehost
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto/merkle"
+	"github.com/DxChainNetwork/godx/p2p"
+	"github.com/DxChainNetwork/godx/rlp"
+	"github.com/DxChainNetwork/godx/storage"
+)
+
+func TestDecodeUploadRequest(t *testing.T) {
+	data := make([]byte, 3*uploadReadChunkSize+10)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	req := storage.UploadRequest{
+		StorageContractID: common.HexToHash("0x01"),
+		Actions: []storage.UploadAction{
+			{Type: storage.UploadActionAppend, Data: data},
+			{Type: "Unknown", A: 1, B: 2, Data: []byte{1, 2, 3}},
+		},
+		NewRevisionNumber:    10,
+		NewValidProofValues:  []*big.Int{big.NewInt(1), big.NewInt(2)},
+		NewMissedProofValues: []*big.Int{big.NewInt(3), big.NewInt(4)},
+		HostConfigHash:       common.HexToHash("0x02"),
+	}
+
+	decoded, roots, err := decodeUploadRequest(newTestMsg(t, req))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, req) {
+		t.Errorf("decoded request not expected. Got %+v, Expect %+v", decoded, req)
+	}
+	expectRoots := []common.Hash{merkle.Sha256MerkleTreeRoot(data), {}}
+	if !reflect.DeepEqual(roots, expectRoots) {
+		t.Errorf("roots not expected. Got %v, Expect %v", roots, expectRoots)
+	}
+
+	// the oversized sector should be rejected
+	req.Actions = []storage.UploadAction{{Type: storage.UploadActionAppend, Data: make([]byte, storage.SectorSize+1)}}
+	if _, _, err := decodeUploadRequest(newTestMsg(t, req)); err != errOversizedSector {
+		t.Errorf("error not expected. Got %v, Expect %v", err, errOversizedSector)
+	}
+}
+
+// newTestMsg creates the p2p message with the value encoded as payload
+func newTestMsg(t *testing.T, val interface{}) p2p.Msg {
+	enc, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p2p.Msg{Size: uint32(len(enc)), Payload: bytes.NewReader(enc)}
+}
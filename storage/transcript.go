@@ -0,0 +1,80 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+// TranscriptEntry is the record of a negotiation that revised the storage contract. Both the
+// storage client and the storage host append an entry to their own transcript of the contract
+// after each negotiation. The entries are chained by hash and signed by the party recording
+// the transcript, so that either side could prove what was agreed when the revision numbers
+// later disagree. The signature is not included in the hash chain, so that the transcripts
+// recorded by both sides are expected to be identical except for the signatures
+type TranscriptEntry struct {
+	PrevHash       common.Hash `json:"prevHash"`
+	RequestHash    common.Hash `json:"requestHash"`
+	ResponseHash   common.Hash `json:"responseHash"`
+	RevisionNumber uint64      `json:"revisionNumber"`
+	Signature      []byte      `json:"signature"`
+}
+
+// Hash returns the hash of the transcript entry without the signature, which is the
+// hash to be signed and to be linked by the next entry
+func (e TranscriptEntry) Hash() common.Hash {
+	return TranscriptHash([]interface{}{e.PrevHash, e.RequestHash, e.ResponseHash, e.RevisionNumber})
+}
+
+// TranscriptHash returns the hash of the rlp encoded negotiation message
+func TranscriptHash(msg interface{}) common.Hash {
+	data, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return common.Hash{}
+	}
+	return crypto.Keccak256Hash(data)
+}
+
+// NewTranscriptEntry creates the unsigned transcript entry following the transcript, with the
+// hash of the negotiation request and response, and the revision number agreed
+func NewTranscriptEntry(transcript []TranscriptEntry, request, response interface{}, revisionNumber uint64) TranscriptEntry {
+	var prevHash common.Hash
+	if len(transcript) != 0 {
+		prevHash = transcript[len(transcript)-1].Hash()
+	}
+	return TranscriptEntry{
+		PrevHash:       prevHash,
+		RequestHash:    TranscriptHash(request),
+		ResponseHash:   TranscriptHash(response),
+		RevisionNumber: revisionNumber,
+	}
+}
+
+// VerifyTranscript verifies that the transcript entries are chained by hash with increasing
+// revision numbers, and that all entries are signed by the signer
+func VerifyTranscript(transcript []TranscriptEntry, signer common.Address) error {
+	var prev *TranscriptEntry
+	for i, entry := range transcript {
+		if prev != nil && (entry.PrevHash != prev.Hash() || entry.RevisionNumber <= prev.RevisionNumber) {
+			return fmt.Errorf("transcript entry %d is not chained to the previous entry", i)
+		}
+		if prev == nil && entry.PrevHash != (common.Hash{}) {
+			return fmt.Errorf("the first transcript entry must not have the previous hash")
+		}
+		pubKey, err := crypto.SigToPub(entry.Hash().Bytes(), entry.Signature)
+		if err != nil {
+			return fmt.Errorf("failed to recover the signer of transcript entry %d: %s", i, err.Error())
+		}
+		if crypto.PubkeyToAddress(*pubKey) != signer {
+			return fmt.Errorf("transcript entry %d is not signed by %s", i, signer.String())
+		}
+		prev = &transcript[i]
+	}
+	return nil
+}
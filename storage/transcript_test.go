@@ -0,0 +1,51 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/DxChainNetwork/godx/crypto"
+)
+
+func TestVerifyTranscript(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	var transcript []TranscriptEntry
+	for i := uint64(1); i <= 3; i++ {
+		entry := NewTranscriptEntry(transcript, UploadRequest{NewRevisionNumber: i}, []byte{byte(i)}, i)
+		sig, err := crypto.Sign(entry.Hash().Bytes(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry.Signature = sig
+		transcript = append(transcript, entry)
+	}
+	if err := VerifyTranscript(transcript, signer); err != nil {
+		t.Fatalf("failed to verify the transcript: %v", err)
+	}
+
+	// the transcript recorded by the other side is chained the same way
+	if NewTranscriptEntry(transcript[:1], UploadRequest{NewRevisionNumber: 2}, []byte{2}, 2).Hash() != transcript[1].Hash() {
+		t.Error("transcript entry hash should not depend on the signature")
+	}
+
+	// the transcript signed by other key should fail
+	otherKey, _ := crypto.GenerateKey()
+	if err := VerifyTranscript(transcript, crypto.PubkeyToAddress(otherKey.PublicKey)); err == nil {
+		t.Error("transcript signed by other key should not be verified")
+	}
+
+	// the tampered transcript should fail
+	tampered := append([]TranscriptEntry{}, transcript...)
+	tampered[1].RevisionNumber = 10
+	if err := VerifyTranscript(tampered, signer); err == nil {
+		t.Error("tampered transcript should not be verified")
+	}
+	if err := VerifyTranscript([]TranscriptEntry{transcript[0], transcript[2]}, signer); err == nil {
+		t.Error("transcript with missing entry should not be verified")
+	}
+}
@@ -13,8 +13,11 @@ import (
 	"github.com/DxChainNetwork/godx/common"
 	"github.com/DxChainNetwork/godx/common/hexutil"
 	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/crypto"
 	"github.com/DxChainNetwork/godx/internal/ethapi"
 	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/params"
+	"github.com/DxChainNetwork/godx/rlp"
 	"github.com/DxChainNetwork/godx/rpc"
 	"github.com/DxChainNetwork/godx/storage/storageclient/erasurecode"
 )
@@ -47,6 +50,12 @@ const (
 	// DxFileExt is the extension of DxFile
 	DxFileExt = ".dxfile"
 
+	// DxFileVersionExt is the extension of the retained versions of DxFile
+	DxFileVersionExt = ".dxversion"
+
+	// DxManifestFileName is the name of the manifest file written for an uploaded directory
+	DxManifestFileName = ".dxmanifest"
+
 	// ConfigVersion is the version of host config
 	ConfigVersion = "1.0.1"
 )
@@ -71,6 +80,137 @@ type (
 		SectorAccessPrice      common.BigInt `json:"sectorAccessPrice"`
 		StoragePrice           common.BigInt `json:"storagePrice"`
 		UploadBandwidthPrice   common.BigInt `json:"uploadBandwidthPrice"`
+
+		ContractPolicy HostContractPolicy `json:"contractPolicy"`
+		DownloadLimits HostDownloadLimits `json:"downloadLimits"`
+
+		// PublicRead allows anyone to read the public sectors without a contract
+		PublicRead bool `json:"publicRead"`
+
+		// ReadCacheSize is the memory size of the sector read cache
+		ReadCacheSize uint64 `json:"readCacheSize"`
+
+		// ReadCacheDiskPath is the directory on the fast disk, e.g. SSD, as the second tier
+		// of the sector read cache. Empty path disables the disk tier
+		ReadCacheDiskPath string `json:"readCacheDiskPath"`
+		ReadCacheDiskSize uint64 `json:"readCacheDiskSize"`
+
+		// ReadIO is the scheduling of the sector reads serving the download requests
+		ReadIO HostReadIOConfig `json:"readIO"`
+
+		// Alert is the hooks notifying the host operator of the risky events
+		Alert HostAlertConfig `json:"alert"`
+
+		// FeeReserve is the account paying the gas of the storage proof and revision txs
+		FeeReserve HostFeeReserveConfig `json:"feeReserve"`
+
+		// ProofGasPriceCeiling is the gas price the storage proof tx at risk of missing the
+		// proof window is bumped up to. Zero disables the gas price bumping of the proofs at risk
+		ProofGasPriceCeiling common.BigInt `json:"proofGasPriceCeiling"`
+
+		// LowSpace is the thresholds of the free space the host degrades gracefully below
+		LowSpace HostLowSpaceConfig `json:"lowSpace"`
+
+		// Region is the region tag advertised to the clients for the data placement
+		Region string `json:"region"`
+	}
+
+	// HostAlertConfig is the hooks triggered when the host runs into the risky events. Empty
+	// Command and URL disable the alerts, and zero value of a threshold disables the alert
+	HostAlertConfig struct {
+		// Command is executed with the alert passed in json through the standard input
+		Command string `json:"command"`
+
+		// URL is POSTed the alert in json
+		URL string `json:"url"`
+
+		// DepositThreshold is the risked storage deposit above which the alert is triggered
+		DepositThreshold common.BigInt `json:"depositThreshold"`
+
+		// FreeSpaceThreshold is the free space below which the alert is triggered
+		FreeSpaceThreshold uint64 `json:"freeSpaceThreshold"`
+	}
+
+	// HostFeeReserveConfig is the account managed by the host to pre-fund the gas of the storage
+	// proof and revision txs, so that the proofs are not missed when the payment address is emptied
+	// by the operator. The reserve is topped up from the payment address, where the revenue of the
+	// host goes. Empty Address disables the fee reserve
+	HostFeeReserveConfig struct {
+		// Address is the account in the local wallet sending the storage proof and revision txs
+		Address common.Address `json:"address"`
+
+		// LowWatermark is the balance below which the reserve is topped up and the alert is
+		// triggered. Zero disables the top up and the alert
+		LowWatermark common.BigInt `json:"lowWatermark"`
+
+		// Target is the balance the reserve is topped up to
+		Target common.BigInt `json:"target"`
+	}
+
+	// HostLowSpaceConfig is the thresholds of the free space below which the host degrades
+	// gracefully, instead of failing the uploads in the middle of the negotiation. Zero value
+	// of a threshold disables the degradation
+	HostLowSpaceConfig struct {
+		// ContractThreshold is the free space below which the host stops accepting new contracts
+		ContractThreshold uint64 `json:"contractThreshold"`
+
+		// AppendThreshold is the free space kept by the host, which is not advertised to the
+		// clients, and the append actions taking up the space are rejected with ErrHostFull
+		AppendThreshold uint64 `json:"appendThreshold"`
+	}
+
+	// HostContractPolicy is the policy evaluated by the host in contract create negotiation
+	// to decide whether to accept the contract. Zero value of a field means no limit
+	HostContractPolicy struct {
+		// MinDuration is the min number of blocks before the proof window of the contract starts
+		MinDuration uint64 `json:"minDuration"`
+
+		// MaxContractSize is the max file size of the contract
+		MaxContractSize uint64 `json:"maxContractSize"`
+
+		// MinPriceMargin is the min ratio of the client funds to the deposit locked by the host
+		MinPriceMargin float64 `json:"minPriceMargin"`
+
+		// MaxClientContracts is the max number of concurrent contracts with the same client
+		MaxClientContracts uint64 `json:"maxClientContracts"`
+
+		// ClientAllowlist is the clients allowed to create contracts. Empty list allows all clients
+		ClientAllowlist []common.Address `json:"clientAllowlist"`
+	}
+
+	// HostDownloadLimits is the limits of a single download request enforced by the host
+	// before reading any sector. Zero value of a field means no limit
+	HostDownloadLimits struct {
+		// MaxSections is the max number of sections in a download request
+		MaxSections uint64 `json:"maxSections"`
+
+		// MaxTotalSize is the max total length of the sections in a download request
+		MaxTotalSize uint64 `json:"maxTotalSize"`
+
+		// MaxProofSize is the max size of the merkle proofs of a download request, estimated
+		// with the worst-case proof size of each section
+		MaxProofSize uint64 `json:"maxProofSize"`
+	}
+
+	// HostReadIOConfig is the scheduling of the sector reads serving a download request. The
+	// sections of a request are read concurrently by a bounded pool of workers, and the reads
+	// from each storage folder are limited, so that a spinning disk is not thrashed
+	HostReadIOConfig struct {
+		// Workers is the number of sectors read concurrently for a download request. Zero
+		// or one reads the sections one by one
+		Workers uint64 `json:"workers"`
+
+		// Readahead is the number of sections read in advance beyond the workers, while the
+		// sections before are still being processed
+		Readahead uint64 `json:"readahead"`
+
+		// DiskConcurrency is the max number of concurrent reads from a storage folder not in
+		// FolderConcurrency. Zero means no limit
+		DiskConcurrency uint64 `json:"diskConcurrency"`
+
+		// FolderConcurrency is the max number of concurrent reads from the storage folder,
+		// keyed by the folder path. Zero means no limit
+		FolderConcurrency map[string]uint64 `json:"folderConcurrency"`
 	}
 
 	// HostIntConfigForDisplay is the host internal config for displayed
@@ -92,6 +232,43 @@ type (
 		SectorAccessPrice      string `json:"sectorAccessPrice"`
 		StoragePrice           string `json:"storagePrice"`
 		UploadBandwidthPrice   string `json:"uploadBandwidthPrice"`
+
+		MinContractDuration string   `json:"minContractDuration"`
+		MaxContractSize     string   `json:"maxContractSize"`
+		MinPriceMargin      string   `json:"minPriceMargin"`
+		MaxClientContracts  string   `json:"maxClientContracts"`
+		ClientAllowlist     []string `json:"clientAllowlist"`
+
+		MaxDownloadSections  string `json:"maxDownloadSections"`
+		MaxDownloadSize      string `json:"maxDownloadSize"`
+		MaxDownloadProofSize string `json:"maxDownloadProofSize"`
+
+		PublicRead string `json:"publicRead"`
+
+		ReadCacheSize     string `json:"readCacheSize"`
+		ReadCacheDiskPath string `json:"readCacheDiskPath"`
+		ReadCacheDiskSize string `json:"readCacheDiskSize"`
+
+		ReadWorkers           string `json:"readWorkers"`
+		Readahead             string `json:"readahead"`
+		DiskReadConcurrency   string `json:"diskReadConcurrency"`
+		FolderReadConcurrency string `json:"folderReadConcurrency"`
+
+		AlertCommand          string `json:"alertCommand"`
+		AlertURL              string `json:"alertURL"`
+		AlertDepositThreshold string `json:"alertDepositThreshold"`
+		AlertFreeSpace        string `json:"alertFreeSpace"`
+
+		FeeReserveAddress      string `json:"feeReserveAddress"`
+		FeeReserveLowWatermark string `json:"feeReserveLowWatermark"`
+		FeeReserveTarget       string `json:"feeReserveTarget"`
+
+		ProofGasPriceCeiling string `json:"proofGasPriceCeiling"`
+
+		LowSpaceContractThreshold string `json:"lowSpaceContractThreshold"`
+		LowSpaceAppendThreshold   string `json:"lowSpaceAppendThreshold"`
+
+		Region string `json:"region"`
 	}
 
 	// HostExtConfig make group of host setting to broadcast as object
@@ -117,7 +294,13 @@ type (
 		StoragePrice           common.BigInt `json:"storagePrice"`
 		UploadBandwidthPrice   common.BigInt `json:"uploadBandwidthPrice"`
 
-		Version string `json:"version"`
+		Version    string `json:"version"`
+		PublicRead bool   `json:"publicRead"`
+		Region     string `json:"region"`
+
+		// ProtocolVersion is the storage protocol version active on the host, zero for the
+		// hosts not reporting the version, which are regarded as version 1
+		ProtocolVersion uint64 `json:"protocolVersion,omitempty"`
 	}
 
 	// HostInfo storage storage host information
@@ -132,6 +315,10 @@ type (
 		LastInteractionTime         uint64                  `json:"lastInteractionTime"`
 		InteractionRecords          []HostInteractionRecord `json:"interactionRecords"`
 
+		// DownloadThroughput is the measured download throughput from the host in bytes per
+		// second, smoothed by the exponential moving average. Zero if never measured
+		DownloadThroughput float64 `json:"downloadThroughput"`
+
 		// TODO: refactor this into an interface: host scans
 		AccumulatedUptime   float64       `json:"accumulated_uptime"`
 		AccumulatedDowntime float64       `json:"accumulated_downtime"`
@@ -144,6 +331,9 @@ type (
 		IPNetwork           string    `json:"ip_network"`
 		LastIPNetWorkChange time.Time `json:"last_ipnetwork_change"`
 
+		// IPHistory is the historical IP addresses of the host, from the earliest to the latest
+		IPHistory []HostIPRecord `json:"ip_history"`
+
 		EnodeID    enode.ID `json:"enodeid"`
 		EnodeURL   string   `json:"enodeurl"`
 		NodePubKey []byte   `json:"nodepubkey"`
@@ -161,6 +351,12 @@ type (
 		Success   bool      `json:"success"`
 	}
 
+	// HostIPRecord records an IP address used by the storage host and the time it is first seen
+	HostIPRecord struct {
+		Time time.Time `json:"time"`
+		IP   string    `json:"ip"`
+	}
+
 	// HostInteractionRecord is the interaction record for client-host interactions
 	// which is used in hostManager
 	HostInteractionRecord struct {
@@ -178,6 +374,25 @@ type (
 		Deposit       common.BigInt
 		MaxDeposit    common.BigInt
 	}
+
+	// PricePercentiles is the percentile statistics of a single price field among
+	// the active storage hosts
+	PricePercentiles struct {
+		P25 common.BigInt `json:"p25"`
+		P50 common.BigInt `json:"p50"`
+		P75 common.BigInt `json:"p75"`
+	}
+
+	// MarketPriceStats is the aggregated percentile statistics of the active storage
+	// host configs, which is used for auto pricing, cost estimation and displaying
+	MarketPriceStats struct {
+		NumHosts      int              `json:"numHosts"`
+		ContractPrice PricePercentiles `json:"contractPrice"`
+		StoragePrice  PricePercentiles `json:"storagePrice"`
+		UploadPrice   PricePercentiles `json:"uploadPrice"`
+		DownloadPrice PricePercentiles `json:"downloadPrice"`
+		Deposit       PricePercentiles `json:"deposit"`
+	}
 )
 
 // ContractParams is the drafted contract sent by the storage client.
@@ -205,6 +420,32 @@ type RentPayment struct {
 	ExpectedDownload uint64 `json:"expectedDownload"`
 	// ExpectedRedundancy is the average redundancy of files uploaded
 	ExpectedRedundancy float64 `json:"expectedRedundancy"`
+
+	// MaxHostFundRatio is the maximum fraction of the fund that could be committed to the
+	// contracts with a single host operator. Zero means no limit
+	MaxHostFundRatio float64 `json:"maxHostFundRatio"`
+
+	// PreferRegions is the host regions preferred for the data placement, the hosts out of
+	// the regions are evaluated lower. RequireRegions is the only host regions allowed.
+	// Empty list means any region
+	PreferRegions  []string `json:"preferRegions"`
+	RequireRegions []string `json:"requireRegions"`
+
+	// EvictionEvalRatio and EvictionOfflineHours define the eviction policy of the hosts. A host
+	// is evicted once its evaluation drops below the ratio of the evaluation baseline, or it has
+	// failed the scans for the hours. The data stored on the evicted host is repaired onto other
+	// hosts proactively. Zero disables the policy
+	EvictionEvalRatio    float64 `json:"evictionEvalRatio"`
+	EvictionOfflineHours uint64  `json:"evictionOfflineHours"`
+
+	// MirrorContracts enables the insurance mode, in which each contract is paired with a
+	// mirror contract formed with a secondary host storing the same sectors. The mirror is
+	// promoted to the primary once the primary host misses the storage proof
+	MirrorContracts bool `json:"mirrorContracts"`
+
+	// HostSelection is the strategy selecting the hosts to form contracts with, which is one
+	// of weighted, topk and diversity. Empty means the weighted random selection
+	HostSelection string `json:"hostSelection"`
 }
 
 // ClientSetting defines the settings that client used to create contract with other peers,
@@ -215,6 +456,22 @@ type ClientSetting struct {
 	EnableIPViolation bool        `json:"enableIPViolation"`
 	MaxUploadSpeed    int64       `json:"maxUploadSpeed"`
 	MaxDownloadSpeed  int64       `json:"maxDownloadSpeed"`
+
+	// MaxMemory is the max memory used by the upload and download pipelines
+	MaxMemory uint64 `json:"maxMemory"`
+
+	// UploadBatchSize is the byte budget of the sectors appended to a host in one upload
+	// request, which share one revision handshake
+	UploadBatchSize uint64 `json:"uploadBatchSize"`
+
+	// SegmentCacheSize and SegmentCacheDiskSize are the size caps of the memory and the disk
+	// tiers of the cache of the segments downloaded, where zero disables the tier
+	SegmentCacheSize     uint64 `json:"segmentCacheSize"`
+	SegmentCacheDiskSize uint64 `json:"segmentCacheDiskSize"`
+
+	// VerifyUploads downloads a random range of each sector uploaded along with the merkle
+	// proof before the sector is added to the file, at the cost of the download bandwidth
+	VerifyUploads bool `json:"verifyUploads"`
 }
 
 type (
@@ -232,14 +489,31 @@ type (
 		ExpectedDownload string `json:"Expected Download"`
 		// ExpectedRedundancy is the average redundancy of files uploaded
 		ExpectedRedundancy string `json:"Expected Redundancy"`
+		// MaxHostFundRatio is the maximum fraction of the fund committed to a single host operator
+		MaxHostFundRatio string `json:"Max Fund Per Host"`
+		// PreferRegions and RequireRegions are the host regions of the data placement
+		PreferRegions  string `json:"Preferred Regions"`
+		RequireRegions string `json:"Required Regions"`
+		// EvictionEvalRatio and EvictionOfflineHours are the eviction policy of the hosts
+		EvictionEvalRatio    string `json:"Eviction Evaluation Ratio"`
+		EvictionOfflineHours string `json:"Eviction Offline Hours"`
+		// MirrorContracts is the status of the mirror contracts insurance mode
+		MirrorContracts string `json:"Mirror Contracts"`
+		// HostSelection is the strategy selecting the hosts
+		HostSelection string `json:"Host Selection"`
 	}
 
 	// ClientSettingAPIDisplay is used for API Configurations Display
 	ClientSettingAPIDisplay struct {
-		RentPayment       RentPaymentAPIDisplay `json:"RentPayment Setting"`
-		EnableIPViolation string                `json:"IP Violation Check Status"`
-		MaxUploadSpeed    string                `json:"Max Upload Speed"`
-		MaxDownloadSpeed  string                `json:"Max Download Speed"`
+		RentPayment          RentPaymentAPIDisplay `json:"RentPayment Setting"`
+		EnableIPViolation    string                `json:"IP Violation Check Status"`
+		MaxUploadSpeed       string                `json:"Max Upload Speed"`
+		MaxDownloadSpeed     string                `json:"Max Download Speed"`
+		MaxMemory            string                `json:"Max Memory"`
+		UploadBatchSize      string                `json:"Upload Batch Size"`
+		SegmentCacheSize     string                `json:"Segment Cache Size"`
+		SegmentCacheDiskSize string                `json:"Segment Cache Disk Size"`
+		VerifyUploads        string                `json:"Upload Verification"`
 	}
 )
 
@@ -276,7 +550,39 @@ type (
 		GasCost     common.BigInt
 		ContractFee common.BigInt
 
+		// CostBreakdown is the itemized cost when the contract is created
+		CostBreakdown ContractCostBreakdown
+
 		Status ContractStatus
+
+		// MirrorOf is the host of the primary contract mirrored by the contract, which is
+		// empty for the primary contracts
+		MirrorOf enode.ID
+	}
+
+	// ContractCostBreakdown itemizes where the funding of the storage contract goes when the
+	// contract is created or renewed. The funding is split into the contract fee, the renew
+	// storage cost and the client funding, and the storage host locks the host deposit in the
+	// contract. The transaction fee is paid by the client on top of the funding. No tax is
+	// charged on the storage contract
+	ContractCostBreakdown struct {
+		// ContractFee is the fee paid to the storage host for forming the contract
+		ContractFee common.BigInt `json:"contractFee"`
+
+		// RenewStorageCost is paid to the storage host for storing the data of the old
+		// contract over the renewed period. Zero for the newly created contract
+		RenewStorageCost common.BigInt `json:"renewStorageCost"`
+
+		// ClientFunding is the initial client collateral, which is available for upload,
+		// download and storage
+		ClientFunding common.BigInt `json:"clientFunding"`
+
+		// HostDeposit is the initial collateral locked by the storage host
+		HostDeposit common.BigInt `json:"hostDeposit"`
+
+		// TxFeeEstimate is the estimated fee of the contract create transaction, which is
+		// filled by the storage client
+		TxFeeEstimate common.BigInt `json:"txFeeEstimate"`
 	}
 
 	// PeriodCost specifies cost storage client needs to pay within one
@@ -296,11 +602,85 @@ type (
 	}
 )
 
+// NewContractCostBreakdown itemizes the cost of the storage contract with the contract fee
+// and the renew storage cost charged by the storage host. The transaction fee estimate is
+// left as zero
+func NewContractCostBreakdown(sc types.StorageContract, contractFee common.BigInt, renewStorageCost common.BigInt) ContractCostBreakdown {
+	return ContractCostBreakdown{
+		ContractFee:      contractFee,
+		RenewStorageCost: renewStorageCost,
+		ClientFunding:    common.PtrBigInt(sc.ClientCollateral.Value),
+		HostDeposit:      common.PtrBigInt(sc.HostCollateral.Value).Sub(contractFee).Sub(renewStorageCost),
+		TxFeeEstimate:    common.BigInt0,
+	}
+}
+
+// Total returns the total cost of the storage contract to the storage client
+func (cost ContractCostBreakdown) Total() common.BigInt {
+	return cost.ContractFee.Add(cost.RenewStorageCost).Add(cost.ClientFunding).Add(cost.TxFeeEstimate)
+}
+
 // String method is used to convert the contractID into string format
 func (ci ContractID) String() string {
 	return hexutil.Encode(ci[:])
 }
 
+// Hash returns the hash of the host config terms used in negotiation. The fields derived
+// from the runtime status of the host, such as the remaining storage and the max deposit
+// capped by the balance, are excluded, so that the hash only changes when the storage
+// host changes its settings. The storage protocol version is hashed since version 2, so
+// that the hash of the version 1 hosts is not changed
+func (config HostExtConfig) Hash() common.Hash {
+	fields := []interface{}{
+		config.MaxDownloadBatchSize,
+		config.MaxDuration,
+		config.MaxReviseBatchSize,
+		config.PaymentAddress,
+		config.SectorSize,
+		config.WindowSize,
+		config.Deposit,
+		config.BaseRPCPrice,
+		config.ContractPrice,
+		config.DownloadBandwidthPrice,
+		config.SectorAccessPrice,
+		config.StoragePrice,
+		config.UploadBandwidthPrice,
+		config.Version,
+	}
+	if config.ProtocolVersion > params.StorageProtocolV1 {
+		fields = append(fields, config.ProtocolVersion)
+	}
+	enc, _ := rlp.EncodeToBytes(fields)
+	return crypto.Keccak256Hash(enc)
+}
+
+// TermsChanged returns whether the config terms used in negotiation or the acceptance of the
+// contracts differ from the previous config, of which the clients should be notified
+func (config HostExtConfig) TermsChanged(prev HostExtConfig) bool {
+	return config.Hash() != prev.Hash() || config.AcceptingContracts != prev.AcceptingContracts
+}
+
+// SigHash returns the hash of the config update signed by the storage host
+func (update HostConfigUpdate) SigHash() common.Hash {
+	enc, _ := rlp.EncodeToBytes(update.Config)
+	return crypto.Keccak256Hash(enc)
+}
+
+// Verify checks the config update is signed by the storage host with the node id
+func (update HostConfigUpdate) Verify(hostID enode.ID) error {
+	if len(update.Signature) != maxSignatureLength {
+		return errInvalidSignatureLength
+	}
+	pubkey, err := crypto.SigToPub(update.SigHash().Bytes(), update.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to recover the signer of the host config update: %s", err.Error())
+	}
+	if enode.PubkeyToIDV4(pubkey) != hostID {
+		return errors.New("host config update is not signed by the storage host")
+	}
+	return nil
+}
+
 // StringToContractID convert string to ContractID
 func StringToContractID(s string) (id ContractID, err error) {
 	// decode the string to byte slice
@@ -416,6 +796,18 @@ type (
 		UsedSectors  uint64 `json:"usedSectors"`
 		FreeSectors  uint64 `json:"freeSectors"`
 	}
+
+	// HostReadCacheStats is the statistics of the host sector read cache
+	HostReadCacheStats struct {
+		MemorySectors  uint64 `json:"memorySectors"`
+		MemoryCapacity uint64 `json:"memoryCapacity"`
+		DiskPath       string `json:"diskPath"`
+		DiskSectors    uint64 `json:"diskSectors"`
+		DiskCapacity   uint64 `json:"diskCapacity"`
+		Hits           uint64 `json:"hits"`
+		DiskHits       uint64 `json:"diskHits"`
+		Misses         uint64 `json:"misses"`
+	}
 )
 
 const (
@@ -0,0 +1,73 @@
+// Copyright 2019 DxChain, All rights reserved.
+// Use of this source code is governed by an Apache
+// License 2.0 that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/DxChainNetwork/godx/common"
+	"github.com/DxChainNetwork/godx/core/types"
+	"github.com/DxChainNetwork/godx/crypto"
+	"github.com/DxChainNetwork/godx/p2p/enode"
+	"github.com/DxChainNetwork/godx/rlp"
+)
+
+func TestNewContractCostBreakdown(t *testing.T) {
+	sc := types.StorageContract{
+		ClientCollateral: types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{Value: big.NewInt(800)}},
+		HostCollateral:   types.DxcoinCollateral{DxcoinCharge: types.DxcoinCharge{Value: big.NewInt(1500)}},
+	}
+	cost := NewContractCostBreakdown(sc, common.NewBigInt(100), common.NewBigInt(300))
+	if cost.ClientFunding.Cmp(common.NewBigInt(800)) != 0 {
+		t.Errorf("expect client funding 800, got %v", cost.ClientFunding)
+	}
+	if cost.HostDeposit.Cmp(common.NewBigInt(1100)) != 0 {
+		t.Errorf("expect host deposit 1100, got %v", cost.HostDeposit)
+	}
+	cost.TxFeeEstimate = common.NewBigInt(10)
+	if total := cost.Total(); total.Cmp(common.NewBigInt(1210)) != 0 {
+		t.Errorf("expect total cost 1210, got %v", total)
+	}
+
+	// the breakdown is carried in the contract create response
+	b, err := rlp.EncodeToBytes(ContractCreateResponse{Sign: []byte{1, 2, 3}, Cost: cost})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp ContractCreateResponse
+	if err = rlp.DecodeBytes(b, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Cost.Total().Cmp(cost.Total()) != 0 || resp.Cost.HostDeposit.Cmp(cost.HostDeposit) != 0 {
+		t.Errorf("cost breakdown not expected after decode: %+v", resp.Cost)
+	}
+}
+
+func TestHostConfigUpdateVerify(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostID := enode.PubkeyToIDV4(&key.PublicKey)
+	update := HostConfigUpdate{
+		Config: HostExtConfig{AcceptingContracts: true, StoragePrice: common.NewBigIntUint64(10)},
+	}
+	if update.Signature, err = crypto.Sign(update.SigHash().Bytes(), key); err != nil {
+		t.Fatal(err)
+	}
+	if err := update.Verify(hostID); err != nil {
+		t.Errorf("config update signed by the host failed verification: %v", err)
+	}
+	if err := update.Verify(enode.ID{1}); err == nil {
+		t.Errorf("config update not signed by the host passed verification")
+	}
+
+	// the config modified after signed should fail the verification
+	update.Config.StoragePrice = common.NewBigIntUint64(20)
+	if err := update.Verify(hostID); err == nil {
+		t.Errorf("config update modified passed verification")
+	}
+}
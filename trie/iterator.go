@@ -74,6 +74,16 @@ type (
 var (
 	// errIteratorEnd is stored in nodeIterator.err when iteration is done.
 	errIteratorEnd = errors.New("end of iteration")
+
+	// errInvalidCursor is returned if the cursor to resume the iteration is malformed
+	errInvalidCursor = errors.New("invalid trie iterator cursor")
+)
+
+// The first byte of the cursor tells whether the iteration is finished. Otherwise, the
+// cursor is followed by the hex-encoded path to the node where the iterator is positioned
+const (
+	cursorPosition byte = 0x00
+	cursorEnd      byte = 0xff
 )
 
 // NodeIterator is an iterator to traverse the trie pre-order
@@ -116,6 +126,18 @@ type NodeIterator interface {
 	LeafProof() [][]byte
 }
 
+// ResumableNodeIterator is a NodeIterator whose position could be serialized as a cursor,
+// so that long-running jobs could checkpoint the progress and resume the iteration later,
+// e.g. after the restart
+type ResumableNodeIterator interface {
+	NodeIterator
+
+	// Cursor returns the serialized position of the iterator. The iteration resumed with
+	// the cursor continues after the current node, as if Next(true) is called. Nil is
+	// returned if the iteration has not started
+	Cursor() []byte
+}
+
 // NewIterator creates a new key-value iterator from a node iterator
 func NewIterator(it NodeIterator) *Iterator {
 	return &Iterator{
@@ -162,6 +184,64 @@ func newNodeIterator(trie *Trie, start []byte) NodeIterator {
 	return it
 }
 
+// newResumableNodeIterator creates a node iterator resuming the iteration from the cursor.
+// Nil cursor starts the iteration from the beginning
+func newResumableNodeIterator(trie *Trie, cursor []byte) (ResumableNodeIterator, error) {
+	if len(cursor) != 0 && cursor[0] != cursorPosition && (cursor[0] != cursorEnd || len(cursor) != 1) {
+		return nil, errInvalidCursor
+	}
+	if len(cursor) > 1 {
+		for _, nibble := range cursor[1:] {
+			if nibble > 16 {
+				return nil, errInvalidCursor
+			}
+		}
+	}
+	if trie.Hash() == emptyState {
+		return new(nodeIterator), nil
+	}
+	it := &nodeIterator{trie: trie}
+	switch {
+	case len(cursor) == 0:
+	case cursor[0] == cursorEnd:
+		it.err = errIteratorEnd
+	default:
+		if err := it.resume(cursor[1:]); err == errIteratorEnd {
+			it.err = errIteratorEnd
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return it, nil
+}
+
+// Cursor returns the serialized position of the iterator, which is the hex-encoded path
+// to the current node, or the end mark if the iteration is finished
+func (it *nodeIterator) Cursor() []byte {
+	if it.err == errIteratorEnd {
+		return []byte{cursorEnd}
+	}
+	if len(it.stack) == 0 {
+		return nil
+	}
+	return append([]byte{cursorPosition}, it.path...)
+}
+
+// resume moves the iterator to the node of the path, or the last node before the path if
+// the node is not in the trie. Since the nodes are iterated in the order of the paths, the
+// following nodes are those after the path
+func (it *nodeIterator) resume(path []byte) error {
+	for {
+		state, parentIndex, next, err := it.peek(bytes.HasPrefix(path, it.path))
+		if err != nil {
+			return err
+		} else if bytes.Compare(next, path) > 0 {
+			return nil
+		}
+		it.push(state, parentIndex, next)
+	}
+}
+
 // Hash returns the hash of the current node
 func (it *nodeIterator) Hash() common.Hash {
 	if len(it.stack) == 0 {
@@ -529,3 +529,53 @@ func TestPrefixIterator(t *testing.T) {
 		}
 	}
 }
+
+func TestResumableNodeIterator(t *testing.T) {
+	diskdb := ethdb.NewMemDatabase()
+	triedb := NewDatabase(diskdb)
+	tr, _ := New(common.Hash{}, triedb)
+	for i := 0; i < 100; i++ {
+		tr.Update(common.LeftPadBytes([]byte{byte(i), byte(i * 7)}, 32), []byte{byte(i)})
+	}
+	root, _ := tr.Commit(nil)
+	triedb.Commit(root, true)
+
+	var all []string
+	for it := tr.NodeIterator(nil); it.Next(true); {
+		all = append(all, fmt.Sprintf("%x", it.Path()))
+	}
+
+	for checkpoint := 0; checkpoint <= len(all); checkpoint++ {
+		it, err := tr.ResumableNodeIterator(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for i := 0; i < checkpoint && it.Next(true); i++ {
+			paths = append(paths, fmt.Sprintf("%x", it.Path()))
+		}
+		cursor := it.Cursor()
+
+		// resume the iteration on the trie loaded from the database
+		resumed, _ := New(root, triedb)
+		rit, err := resumed.ResumableNodeIterator(cursor)
+		if err != nil {
+			t.Fatalf("checkpoint %d: failed to resume: %v", checkpoint, err)
+		}
+		for rit.Next(true) {
+			paths = append(paths, fmt.Sprintf("%x", rit.Path()))
+		}
+		if fmt.Sprint(paths) != fmt.Sprint(all) {
+			t.Fatalf("checkpoint %d: resumed iteration mismatch", checkpoint)
+		}
+		if end := rit.Cursor(); !bytes.Equal(end, []byte{cursorEnd}) {
+			t.Fatalf("checkpoint %d: expect the end cursor, got %x", checkpoint, end)
+		}
+	}
+
+	for _, cursor := range [][]byte{{0x01}, {cursorEnd, 0x01}, {cursorPosition, 0x11}} {
+		if _, err := tr.ResumableNodeIterator(cursor); err != errInvalidCursor {
+			t.Errorf("cursor %x: expect error %v, got %v", cursor, errInvalidCursor, err)
+		}
+	}
+}
@@ -157,6 +157,12 @@ func (t *SecureTrie) NodeIterator(start []byte) NodeIterator {
 	return t.trie.NodeIterator(start)
 }
 
+// ResumableNodeIterator returns an iterator that returns nodes of the underlying trie,
+// resuming the iteration after the position of the cursor.
+func (t *SecureTrie) ResumableNodeIterator(cursor []byte) (ResumableNodeIterator, error) {
+	return t.trie.ResumableNodeIterator(cursor)
+}
+
 // hashKey returns the hash of key as an ephemeral buffer.
 // The caller must not hold onto the return value because it will become
 // invalid on the next call to hashKey or secKey
@@ -104,6 +104,13 @@ func (t *Trie) NodeIterator(start []byte) NodeIterator {
 	return newNodeIterator(t, start)
 }
 
+// ResumableNodeIterator returns an iterator that returns nodes of the trie, resuming the
+// iteration after the position of the cursor returned by ResumableNodeIterator.Cursor.
+// Nil cursor starts the iteration from the beginning
+func (t *Trie) ResumableNodeIterator(cursor []byte) (ResumableNodeIterator, error) {
+	return newResumableNodeIterator(t, cursor)
+}
+
 // PrefixIterator returns an iterator that returns nodes of the trie with the specified prefix path,
 // it will start iteration at the key after the given prefix path.
 func (t *Trie) PrefixIterator(prefix []byte) NodeIterator {